@@ -85,6 +85,7 @@ repos="github.com/golang/glog \
        github.com/golang/lint/golint \
        github.com/golang/protobuf/proto \
        github.com/golang/protobuf/protoc-gen-go \
+       github.com/golang/snappy \
        github.com/tools/godep \
        golang.org/x/net/context \
        golang.org/x/oauth2/google \
@@ -92,6 +93,8 @@ repos="github.com/golang/glog \
        google.golang.org/grpc \
        google.golang.org/cloud \
        google.golang.org/cloud/storage \
+       github.com/aws/aws-sdk-go/aws \
+       github.com/aws/aws-sdk-go/service/s3 \
 "
 
 # Packages for uploading code coverage to coveralls.io (used by Travis CI).
@@ -124,6 +127,12 @@ case "$MYSQL_FLAVOR" in
     echo "Found MySQL 5.6 installation in $VT_MYSQL_ROOT."
     ;;
 
+  "MySQL57")
+    myversion=`$VT_MYSQL_ROOT/bin/mysql --version | grep 'Distrib 5\.7'`
+    [ "$myversion" != "" ] || fail "Couldn't find MySQL 5.7 in $VT_MYSQL_ROOT. Set VT_MYSQL_ROOT to override search location."
+    echo "Found MySQL 5.7 installation in $VT_MYSQL_ROOT."
+    ;;
+
   "MariaDB")
     myversion=`$VT_MYSQL_ROOT/bin/mysql --version | grep MariaDB`
     [ "$myversion" != "" ] || fail "Couldn't find MariaDB in $VT_MYSQL_ROOT. Set VT_MYSQL_ROOT to override search location."
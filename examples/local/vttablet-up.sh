@@ -34,6 +34,10 @@ case "$MYSQL_FLAVOR" in
     bootstrap_archive=mysql-db-dir_5.6.24.tbz
     export EXTRA_MY_CNF=$VTROOT/config/mycnf/master_mysql56.cnf
     ;;
+  "MySQL57")
+    bootstrap_archive=mysql-db-dir_5.6.24.tbz
+    export EXTRA_MY_CNF=$VTROOT/config/mycnf/master_mysql57.cnf
+    ;;
   "MariaDB")
     bootstrap_archive=mysql-db-dir_10.0.13-MariaDB.tbz
     export EXTRA_MY_CNF=$VTROOT/config/mycnf/master_mariadb.cnf
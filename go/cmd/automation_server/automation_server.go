@@ -15,6 +15,12 @@ import (
 	"github.com/youtube/vitess/go/vt/automation"
 	pbs "github.com/youtube/vitess/go/vt/proto/automationservice"
 	"github.com/youtube/vitess/go/vt/servenv"
+	"github.com/youtube/vitess/go/vt/topo"
+)
+
+var (
+	checkpointStore = flag.String("checkpoint_store", "", "where to persist the state of cluster operations: 'topo', 'file' or empty to not persist it")
+	checkpointDir   = flag.String("checkpoint_dir", "", "directory for the checkpoints if -checkpoint_store=file")
 )
 
 func init() {
@@ -35,8 +41,32 @@ func main() {
 		os.Exit(2)
 	}
 
+	var store automation.CheckpointStore
+	switch *checkpointStore {
+	case "":
+	case "topo":
+		store, err = automation.NewTopoCheckpointStore(topo.GetServer())
+		if err != nil {
+			fmt.Printf("Failed to create checkpoint store: %v", err)
+			os.Exit(3)
+		}
+	case "file":
+		if *checkpointDir == "" {
+			fmt.Println("-checkpoint_dir is required for -checkpoint_store=file.")
+			os.Exit(1)
+		}
+		store, err = automation.NewFileCheckpointStore(*checkpointDir)
+		if err != nil {
+			fmt.Printf("Failed to create checkpoint store: %v", err)
+			os.Exit(3)
+		}
+	default:
+		fmt.Printf("Unknown -checkpoint_store: %v", *checkpointStore)
+		os.Exit(1)
+	}
+
 	grpcServer := grpc.NewServer()
-	scheduler, err := automation.NewScheduler()
+	scheduler, err := automation.NewScheduler(store)
 	if err != nil {
 		fmt.Printf("Failed to create scheduler: %v", err)
 		os.Exit(3)
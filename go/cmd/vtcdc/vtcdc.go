@@ -0,0 +1,110 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// vtcdc publishes the update stream of shards to an external sink,
+// for change data capture. The position reached in each shard is
+// checkpointed, so a restarted vtcdc resumes where it stopped.
+package main
+
+import (
+	"flag"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/exit"
+	"github.com/youtube/vitess/go/flagutil"
+	"github.com/youtube/vitess/go/vt/cdc"
+	"github.com/youtube/vitess/go/vt/servenv"
+	"github.com/youtube/vitess/go/vt/tabletmanager/tmclient"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
+)
+
+var (
+	cell           = flag.String("cell", "", "cell of the tablets to stream from")
+	tabletType     = flag.String("tablet_type", "replica", "type of the tablets to stream from")
+	format         = flag.String("format", "json", "format of the published events: json, avro or proto")
+	sinkName       = flag.String("sink", "stdout", "where to publish the events: stdout, file or kafka")
+	sinkTarget     = flag.String("sink_target", "", "target of the sink: the file name for file, the topic for kafka")
+	checkpointFile = flag.String("checkpoint_file", "", "file storing the position reached in each shard")
+	flushInterval  = flag.Duration("flush_interval", time.Second, "how often to flush the sink and checkpoint the positions")
+	retryDelay     = flag.Duration("retry_delay", 5*time.Second, "how long to wait before restarting a failed stream")
+	shards         flagutil.StringListValue
+)
+
+func init() {
+	servenv.RegisterDefaultFlags()
+	flag.Var(&shards, "shards", "comma separated list of keyspace/shard to stream")
+}
+
+func main() {
+	defer exit.Recover()
+
+	flag.Parse()
+	servenv.Init()
+
+	if len(shards) == 0 {
+		log.Error("no shards to stream specified, use -shards")
+		exit.Return(1)
+	}
+	if *cell == "" {
+		log.Error("-cell is required")
+		exit.Return(1)
+	}
+	if *checkpointFile == "" {
+		log.Error("-checkpoint_file is required")
+		exit.Return(1)
+	}
+	tt, err := topoproto.ParseTabletType(*tabletType)
+	if err != nil {
+		log.Errorf("invalid -tablet_type: %v", err)
+		exit.Return(1)
+	}
+	encoder, err := cdc.GetEncoder(*format)
+	if err != nil {
+		log.Errorf("invalid -format: %v", err)
+		exit.Return(1)
+	}
+	checkpointer, err := cdc.NewFileCheckpointer(*checkpointFile)
+	if err != nil {
+		log.Errorf("cannot open checkpoint file: %v", err)
+		exit.Return(1)
+	}
+	sink, err := cdc.NewSink(*sinkName, *sinkTarget, encoder)
+	if err != nil {
+		log.Errorf("cannot create sink: %v", err)
+		exit.Return(1)
+	}
+
+	ts := topo.GetServer()
+	tmc := tmclient.NewTabletManagerClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	for _, keyspaceShard := range shards {
+		keyspace, shard, err := topoproto.ParseKeyspaceShard(keyspaceShard)
+		if err != nil {
+			log.Errorf("invalid shard in -shards: %v", err)
+			exit.Return(1)
+		}
+		ss := cdc.NewShardStreamer(ts, tmc, *cell, keyspace, shard, tt, encoder, sink, checkpointer, *flushInterval)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ss.Run(ctx, *retryDelay)
+		}()
+	}
+
+	// on shutdown, the streamers flush and checkpoint what they sent
+	servenv.OnTermSync(func() {
+		cancel()
+		wg.Wait()
+		if err := sink.Close(); err != nil {
+			log.Errorf("cannot close sink: %v", err)
+		}
+	})
+	servenv.RunDefault()
+}
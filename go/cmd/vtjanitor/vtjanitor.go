@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 
@@ -62,8 +63,15 @@ func main() {
 		exit.Return(1)
 	}
 
-	scheduler.Enable(activeModules)
-	scheduler.EnableDryRun(dryRunModules)
+	if err := scheduler.Enable(activeModules); err != nil {
+		log.Errorf("cannot enable active modules: %v", err)
+		exit.Return(1)
+	}
+	if err := scheduler.EnableDryRun(dryRunModules); err != nil {
+		log.Errorf("cannot enable dry run modules: %v", err)
+		exit.Return(1)
+	}
+	http.Handle("/janitorz/", scheduler)
 	go scheduler.Run()
 	servenv.RunDefault()
 }
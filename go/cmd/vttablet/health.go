@@ -9,10 +9,14 @@ import (
 
 var (
 	enableReplicationLagCheck = flag.Bool("enable_replication_lag_check", false, "will register the mysql health check module that directly calls mysql")
+	enableHeartbeatLagCheck   = flag.Bool("enable_heartbeat_lag_check", false, "will register the health check module that computes the replication lag from the heartbeat written by the master (needs -enable_heartbeat on the master)")
 )
 
 func registerHealthReporter(mysqld *mysqlctl.Mysqld) {
 	if *enableReplicationLagCheck {
 		health.DefaultAggregator.Register("replication_reporter", mysqlctl.MySQLReplicationLag(mysqld))
 	}
+	if *enableHeartbeatLagCheck {
+		health.DefaultAggregator.Register("heartbeat_reporter", mysqlctl.HeartbeatReplicationLag(mysqld))
+	}
 }
@@ -127,6 +127,9 @@ Binlog player state: {{.State}}</br>
           {{else}}
             (picking source tablet)
           {{end}}
+          {{if .ThrottledReason}}
+            <br>throttled: {{.ThrottledReason}}
+          {{end}}
         {{end}}</td>
       <td>{{if .StopPosition}}{{.StopPosition}}{{end}}</td>
       <td>{{.LastPosition}}</td>
@@ -0,0 +1,125 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/youtube/vitess/go/vt/topo"
+	"golang.org/x/net/context"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+)
+
+// CheckpointStore persists the state of cluster operations.
+// The Scheduler saves a checkpoint every time the state of an operation
+// changes and reloads all operations when it is created.
+type CheckpointStore interface {
+	// Save stores the latest state of "clusterOp".
+	// A previous checkpoint of the same operation is overwritten.
+	Save(clusterOp *pb.ClusterOperation) error
+
+	// LoadAll returns the last saved state of all cluster operations.
+	LoadAll() ([]*pb.ClusterOperation, error)
+}
+
+// TopoCheckpointStore stores the checkpoints in the global topology.
+type TopoCheckpointStore struct {
+	cos topo.ClusterOperationStore
+}
+
+// NewTopoCheckpointStore returns a CheckpointStore which uses "ts".
+// It fails if the topology implementation cannot store cluster
+// operations.
+func NewTopoCheckpointStore(ts topo.Server) (*TopoCheckpointStore, error) {
+	cos, ok := ts.Impl.(topo.ClusterOperationStore)
+	if !ok {
+		return nil, fmt.Errorf("topology implementation %T cannot store cluster operations", ts.Impl)
+	}
+	return &TopoCheckpointStore{cos: cos}, nil
+}
+
+// Save is part of the CheckpointStore interface.
+func (s *TopoCheckpointStore) Save(clusterOp *pb.ClusterOperation) error {
+	data, err := json.MarshalIndent(clusterOp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.cos.SaveClusterOperation(context.TODO(), clusterOp.Id, string(data))
+}
+
+// LoadAll is part of the CheckpointStore interface.
+func (s *TopoCheckpointStore) LoadAll() ([]*pb.ClusterOperation, error) {
+	contents, err := s.cos.GetClusterOperations(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	var result []*pb.ClusterOperation
+	for id, content := range contents {
+		clusterOp := &pb.ClusterOperation{}
+		if err := json.Unmarshal([]byte(content), clusterOp); err != nil {
+			return nil, fmt.Errorf("cannot parse checkpoint of ClusterOperation %v: %v", id, err)
+		}
+		result = append(result, clusterOp)
+	}
+	return result, nil
+}
+
+// FileCheckpointStore stores each checkpoint as a file in a local directory.
+type FileCheckpointStore struct {
+	dir string
+}
+
+const checkpointFileSuffix = ".checkpoint"
+
+// NewFileCheckpointStore returns a CheckpointStore which uses "dir".
+// The directory is created if it does not exist yet.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileCheckpointStore{dir: dir}, nil
+}
+
+// Save is part of the CheckpointStore interface.
+// The file is replaced atomically, so a crash never leaves a partial checkpoint.
+func (s *FileCheckpointStore) Save(clusterOp *pb.ClusterOperation) error {
+	data, err := json.MarshalIndent(clusterOp, "", "  ")
+	if err != nil {
+		return err
+	}
+	filename := path.Join(s.dir, clusterOp.Id+checkpointFileSuffix)
+	tmpFilename := filename + ".tmp"
+	if err := ioutil.WriteFile(tmpFilename, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFilename, filename)
+}
+
+// LoadAll is part of the CheckpointStore interface.
+func (s *FileCheckpointStore) LoadAll() ([]*pb.ClusterOperation, error) {
+	filenames, err := filepath.Glob(path.Join(s.dir, "*"+checkpointFileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	var result []*pb.ClusterOperation
+	for _, filename := range filenames {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		clusterOp := &pb.ClusterOperation{}
+		if err := json.Unmarshal(content, clusterOp); err != nil {
+			return nil, fmt.Errorf("cannot parse checkpoint file %v: %v", filename, err)
+		}
+		result = append(result, clusterOp)
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/topo/test/faketopo"
+	"github.com/youtube/vitess/go/vt/zktopo"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+)
+
+func TestFileCheckpointStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint_store")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileCheckpointStore(path.Join(dir, "checkpoints"))
+	if err != nil {
+		t.Fatalf("NewFileCheckpointStore failed: %v", err)
+	}
+	clusterOps, err := store.LoadAll()
+	if err != nil || len(clusterOps) != 0 {
+		t.Fatalf("LoadAll() on an empty store = %v, %v", clusterOps, err)
+	}
+
+	clusterOp := &pb.ClusterOperation{
+		Id:          "1",
+		SerialTasks: []*pb.TaskContainer{NewTaskContainerWithSingleTask("TestingEchoTask", map[string]string{"echo_text": "hello"})},
+		State:       pb.ClusterOperationState_CLUSTER_OPERATION_RUNNING,
+	}
+	if err := store.Save(clusterOp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	// A second save overwrites the first checkpoint.
+	clusterOp.State = pb.ClusterOperationState_CLUSTER_OPERATION_DONE
+	if err := store.Save(clusterOp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	clusterOps, err = store.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	if len(clusterOps) != 1 || !reflect.DeepEqual(clusterOps[0], clusterOp) {
+		t.Errorf("LoadAll() = %v, want [%v]", clusterOps, clusterOp)
+	}
+}
+
+func TestTopoCheckpointStore(t *testing.T) {
+	store, err := NewTopoCheckpointStore(zktopo.NewTestServer(t, []string{"cell1"}))
+	if err != nil {
+		t.Fatalf("NewTopoCheckpointStore failed: %v", err)
+	}
+	clusterOp := &pb.ClusterOperation{
+		Id:    "1",
+		State: pb.ClusterOperationState_CLUSTER_OPERATION_RUNNING,
+	}
+	if err := store.Save(clusterOp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	clusterOps, err := store.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	if len(clusterOps) != 1 || !reflect.DeepEqual(clusterOps[0], clusterOp) {
+		t.Errorf("LoadAll() = %v, want [%v]", clusterOps, clusterOp)
+	}
+
+	// A topology implementation without cluster operation support is refused.
+	if _, err := NewTopoCheckpointStore(topo.Server{Impl: faketopo.FakeTopo{}}); err == nil {
+		t.Errorf("NewTopoCheckpointStore with a topology that cannot store cluster operations: got no error")
+	}
+}
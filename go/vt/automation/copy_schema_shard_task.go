@@ -12,20 +12,28 @@ import (
 )
 
 // CopySchemaShardTask runs vtctl CopySchemaShard to copy the schema from one shard to another.
+// If "dest_keyspace" is set, the destination shard is in another keyspace e.g. for a vertical split.
 type CopySchemaShardTask struct {
 }
 
 // Run is part of the Task interface.
 func (t *CopySchemaShardTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
 	keyspaceAndSourceShard := fmt.Sprintf("%v/%v", parameters["keyspace"], parameters["source_shard"])
-	keyspaceAndDestShard := fmt.Sprintf("%v/%v", parameters["keyspace"], parameters["dest_shard"])
+	destKeyspace := parameters["keyspace"]
+	if k := parameters["dest_keyspace"]; k != "" {
+		destKeyspace = k
+	}
+	keyspaceAndDestShard := fmt.Sprintf("%v/%v", destKeyspace, parameters["dest_shard"])
 
 	args := []string{"CopySchemaShard"}
+	if tables := parameters["tables"]; tables != "" {
+		args = append(args, "--tables="+tables)
+	}
 	if excludeTables := parameters["exclude_tables"]; excludeTables != "" {
 		args = append(args, "--exclude_tables="+excludeTables)
 	}
 	args = append(args, keyspaceAndSourceShard, keyspaceAndDestShard)
-	output, err := ExecuteVtctl(context.TODO(), parameters["vtctld_endpoint"], args)
+	output, err := executeVtctlOrDryRun(context.TODO(), parameters, args)
 	return nil, output, err
 }
 
@@ -36,5 +44,5 @@ func (t *CopySchemaShardTask) RequiredParameters() []string {
 
 // OptionalParameters is part of the Task interface.
 func (t *CopySchemaShardTask) OptionalParameters() []string {
-	return []string{"exclude_tables"}
+	return []string{"dest_keyspace", "tables", "exclude_tables", "dry_run"}
 }
@@ -0,0 +1,63 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
+	"golang.org/x/net/context"
+)
+
+// servedFromTypes are the tablet types which a keyspace can be served from.
+var servedFromTypes = []pbt.TabletType{pbt.TabletType_MASTER, pbt.TabletType_REPLICA, pbt.TabletType_RDONLY}
+
+// CreateServedFromKeyspaceTask runs vtctl CreateKeyspace to create a keyspace
+// whose tablet types are all served from the source keyspace.
+// If the keyspace already exists and is served from the source keyspace
+// (e.g. because the cluster operation is resumed), the task does nothing.
+type CreateServedFromKeyspaceTask struct {
+}
+
+// Run is part of the Task interface.
+func (t *CreateServedFromKeyspaceTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
+	keyspace := parameters["keyspace"]
+	sourceKeyspace := parameters["source_keyspace"]
+
+	dryRun, err := isDryRun(parameters)
+	if err != nil {
+		return nil, "", err
+	}
+	if !dryRun {
+		if ki, err := getKeyspace(context.TODO(), parameters["vtctld_endpoint"], keyspace); err == nil {
+			for _, tabletType := range servedFromTypes {
+				if sf := servedFrom(ki, tabletType, ""); sf != sourceKeyspace {
+					return nil, "", fmt.Errorf("keyspace %v already exists, but its type %v is not served from %v", keyspace, strings.ToLower(tabletType.String()), sourceKeyspace)
+				}
+			}
+			return nil, fmt.Sprintf("Skipped CreateKeyspace because keyspace %v already exists and is served from %v.", keyspace, sourceKeyspace), nil
+		}
+	}
+
+	var sf []string
+	for _, tabletType := range servedFromTypes {
+		sf = append(sf, strings.ToLower(tabletType.String())+":"+sourceKeyspace)
+	}
+	args := []string{"CreateKeyspace", "--served_from=" + strings.Join(sf, ","), keyspace}
+	output, err := executeVtctlOrDryRun(context.TODO(), parameters, args)
+	return nil, output, err
+}
+
+// RequiredParameters is part of the Task interface.
+func (t *CreateServedFromKeyspaceTask) RequiredParameters() []string {
+	return []string{"keyspace", "source_keyspace", "vtctld_endpoint"}
+}
+
+// OptionalParameters is part of the Task interface.
+func (t *CreateServedFromKeyspaceTask) OptionalParameters() []string {
+	return []string{"dry_run"}
+}
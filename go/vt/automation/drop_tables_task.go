@@ -0,0 +1,47 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+	"golang.org/x/net/context"
+)
+
+// tableNameRegexp matches plain table names. Table lists of other tasks may
+// contain regular expressions, but we only drop tables which are named explicitly.
+var tableNameRegexp = regexp.MustCompile(`^\w+$`)
+
+// DropTablesTask runs vtctl ApplySchema to drop tables from a keyspace.
+// Tables which do not exist are ignored, so the task can be run again.
+type DropTablesTask struct {
+}
+
+// Run is part of the Task interface.
+func (t *DropTablesTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
+	var sqls []string
+	for _, table := range strings.Split(parameters["tables"], ",") {
+		if !tableNameRegexp.MatchString(table) {
+			return nil, "", fmt.Errorf("invalid table name: %q, only plain table names can be dropped", table)
+		}
+		sqls = append(sqls, "DROP TABLE IF EXISTS "+table)
+	}
+	args := []string{"ApplySchema", "-sql=" + strings.Join(sqls, ";"), parameters["keyspace"]}
+	output, err := executeVtctlOrDryRun(context.TODO(), parameters, args)
+	return nil, output, err
+}
+
+// RequiredParameters is part of the Task interface.
+func (t *DropTablesTask) RequiredParameters() []string {
+	return []string{"keyspace", "tables", "vtctld_endpoint"}
+}
+
+// OptionalParameters is part of the Task interface.
+func (t *DropTablesTask) OptionalParameters() []string {
+	return []string{"dry_run"}
+}
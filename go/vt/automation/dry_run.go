@@ -0,0 +1,61 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// isDryRun returns true if the optional task parameter "dry_run" is set.
+// In a dry run, tasks do not change anything and only output the commands they would run.
+func isDryRun(parameters map[string]string) (bool, error) {
+	v := parameters["dry_run"]
+	if v == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for parameter dry_run: %v", v)
+	}
+	return dryRun, nil
+}
+
+// executeVtctlOrDryRun runs vtctl like ExecuteVtctl.
+// In a dry run, it only returns the command which would be run.
+func executeVtctlOrDryRun(ctx context.Context, parameters map[string]string, args []string) (string, error) {
+	dryRun, err := isDryRun(parameters)
+	if err != nil {
+		return "", err
+	}
+	if dryRun {
+		return fmt.Sprintf("Dry run: would execute vtctl command: %v server: %v", strings.Join(args, " "), parameters["vtctld_endpoint"]), nil
+	}
+	return ExecuteVtctl(ctx, parameters["vtctld_endpoint"], args)
+}
+
+// executeVtworkerOrDryRun runs vtworker like ExecuteVtworker and resets the
+// vtworker afterwards, if the command succeeded.
+// In a dry run, it only returns the command which would be run.
+func executeVtworkerOrDryRun(ctx context.Context, parameters map[string]string, args []string) (string, error) {
+	dryRun, err := isDryRun(parameters)
+	if err != nil {
+		return "", err
+	}
+	if dryRun {
+		return fmt.Sprintf("Dry run: would execute vtworker command: %v server: %v", strings.Join(args, " "), parameters["vtworker_endpoint"]), nil
+	}
+	output, err := ExecuteVtworker(ctx, parameters["vtworker_endpoint"], args)
+
+	// TODO(mberlin): Remove explicit reset when vtworker supports it implicility.
+	if err == nil {
+		// Ignore output and error of the Reset.
+		ExecuteVtworker(ctx, parameters["vtworker_endpoint"], []string{"Reset"})
+	}
+	return output, err
+}
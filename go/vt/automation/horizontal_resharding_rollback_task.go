@@ -0,0 +1,70 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"strings"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+)
+
+// HorizontalReshardingRollbackTask is a cluster operation which reverts a HorizontalReshardingTask.
+// It migrates the replica and rdonly served types back to the source shards and stops the
+// filtered replication to the destination shards. Served types which were not migrated yet are
+// skipped.
+// Note that a master cannot be migrated back (MigrateServedTypes refuses it): Once the master was
+// migrated, the source shards are dead and the resharding cannot be rolled back anymore.
+type HorizontalReshardingRollbackTask struct {
+}
+
+// Run is part of the Task interface.
+func (t *HorizontalReshardingRollbackTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
+	// Example: test_keyspace
+	keyspace := parameters["keyspace"]
+	// Example: 10-20
+	sourceShards := strings.Split(parameters["source_shard_list"], ",")
+	// Example: 10-18,18-20
+	destShards := strings.Split(parameters["dest_shard_list"], ",")
+	// Example: localhost:15000
+	vtctldEndpoint := parameters["vtctld_endpoint"]
+
+	var newTasks []*pb.TaskContainer
+	// The reverse order of HorizontalReshardingTask, without the master.
+	for _, servedType := range []string{"replica", "rdonly"} {
+		migrateServedTypesTasks := NewTaskContainer()
+		for _, sourceShard := range sourceShards {
+			AddTask(migrateServedTypesTasks, "MigrateServedTypesTask", map[string]string{
+				"keyspace":        keyspace,
+				"source_shard":    sourceShard,
+				"type":            servedType,
+				"reverse":         "true",
+				"vtctld_endpoint": vtctldEndpoint,
+			})
+		}
+		newTasks = append(newTasks, migrateServedTypesTasks)
+	}
+
+	stopTasks := NewTaskContainer()
+	for _, destShard := range destShards {
+		AddTask(stopTasks, "StopFilteredReplicationTask", map[string]string{
+			"keyspace":        keyspace,
+			"shard":           destShard,
+			"vtctld_endpoint": vtctldEndpoint,
+		})
+	}
+	newTasks = append(newTasks, stopTasks)
+
+	return newTasks, "", nil
+}
+
+// RequiredParameters is part of the Task interface.
+func (t *HorizontalReshardingRollbackTask) RequiredParameters() []string {
+	return []string{"keyspace", "source_shard_list", "dest_shard_list", "vtctld_endpoint"}
+}
+
+// OptionalParameters is part of the Task interface.
+func (t *HorizontalReshardingRollbackTask) OptionalParameters() []string {
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"testing"
+)
+
+func TestHorizontalReshardingRollbackTaskEmittedTasks(t *testing.T) {
+	rollbackTask := &HorizontalReshardingRollbackTask{}
+
+	parameters := map[string]string{
+		"keyspace":          "test_keyspace",
+		"source_shard_list": "10-20",
+		"dest_shard_list":   "10-18,18-20",
+		"vtctld_endpoint":   "localhost:15000",
+	}
+
+	err := validateParameters(rollbackTask, parameters)
+	if err != nil {
+		t.Fatalf("Not all required parameters were specified: %v", err)
+	}
+
+	newTaskContainers, _, err := rollbackTask.Run(parameters)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(newTaskContainers) != 3 {
+		t.Fatalf("wrong number of emitted task containers: got: %v want: 3", len(newTaskContainers))
+	}
+	// The served types are migrated back in the reverse order. The master
+	// cannot be migrated back.
+	for i, servedType := range []string{"replica", "rdonly"} {
+		task := newTaskContainers[i].ParallelTasks[0]
+		if task.Name != "MigrateServedTypesTask" || task.Parameters["type"] != servedType || task.Parameters["reverse"] != "true" {
+			t.Errorf("task container %v: got: %v want: reverse migration of %v", i, task, servedType)
+		}
+	}
+	stopTasks := newTaskContainers[2].ParallelTasks
+	if len(stopTasks) != 2 || stopTasks[0].Name != "StopFilteredReplicationTask" || stopTasks[1].Parameters["shard"] != "18-20" {
+		t.Errorf("wrong StopFilteredReplicationTasks: %v", stopTasks)
+	}
+}
@@ -16,3 +16,19 @@ type IDGenerator struct {
 func (ig *IDGenerator) GetNextID() string {
 	return strconv.FormatInt(atomic.AddInt64(&ig.counter, 1), 10)
 }
+
+// ObserveID makes sure that "id" will never be returned by GetNextID.
+// It is used for IDs which were generated before e.g. by a previous process.
+// IDs which are not numbers are ignored.
+func (ig *IDGenerator) ObserveID(id string) {
+	v, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return
+	}
+	for {
+		current := atomic.LoadInt64(&ig.counter)
+		if v <= current || atomic.CompareAndSwapInt64(&ig.counter, current, v) {
+			return
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"fmt"
+	"strconv"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
+	"golang.org/x/net/context"
+)
+
+// MigrateServedFromTask runs vtctl MigrateServedFrom to make the destination
+// keyspace of a vertical split serve a tablet type itself.
+// If "reverse" is set, the type is served from the source keyspace again.
+// The task checks the keyspace first and does nothing if the type is already
+// migrated. Therefore, it can be run again e.g. when its cluster operation is resumed.
+type MigrateServedFromTask struct {
+}
+
+// Run is part of the Task interface.
+func (t *MigrateServedFromTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
+	keyspaceAndShard := fmt.Sprintf("%v/%v", parameters["keyspace"], parameters["shard"])
+
+	servedType, err := topoproto.ParseTabletType(parameters["type"])
+	if err != nil {
+		return nil, "", err
+	}
+	reverse := false
+	if r := parameters["reverse"]; r != "" {
+		if reverse, err = strconv.ParseBool(r); err != nil {
+			return nil, "", fmt.Errorf("invalid value for parameter reverse: %v", r)
+		}
+	}
+	dryRun, err := isDryRun(parameters)
+	if err != nil {
+		return nil, "", err
+	}
+	if !dryRun {
+		ki, err := getKeyspace(context.TODO(), parameters["vtctld_endpoint"], parameters["keyspace"])
+		if err != nil {
+			return nil, "", err
+		}
+		if migrated := servedFrom(ki, servedType, "") == ""; migrated != reverse {
+			return nil, fmt.Sprintf("Skipped MigrateServedFrom because the served type %v of keyspace %v is already migrated (reverse: %v).", parameters["type"], parameters["keyspace"], reverse), nil
+		}
+	}
+
+	args := []string{"MigrateServedFrom"}
+	if reverse {
+		args = append(args, "--reverse=true")
+	}
+	args = append(args, keyspaceAndShard, parameters["type"])
+	output, err := executeVtctlOrDryRun(context.TODO(), parameters, args)
+	return nil, output, err
+}
+
+// RequiredParameters is part of the Task interface.
+func (t *MigrateServedFromTask) RequiredParameters() []string {
+	return []string{"keyspace", "shard", "type", "vtctld_endpoint"}
+}
+
+// OptionalParameters is part of the Task interface.
+func (t *MigrateServedFromTask) OptionalParameters() []string {
+	return []string{"reverse", "dry_run"}
+}
@@ -0,0 +1,143 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/vtctl/fakevtctlclient"
+	"github.com/youtube/vitess/go/vt/vtctl/vtctlclient"
+
+	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+// registerGetKeyspaceResult registers "keyspace" as result of vtctl GetKeyspace with the fake.
+func registerGetKeyspaceResult(t *testing.T, fake *fakevtctlclient.FakeVtctlClient, name string, keyspace *pbt.Keyspace) {
+	data, err := json.MarshalIndent(keyspace, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal keyspace: %v", err)
+	}
+	fake.RegisterResult([]string{"GetKeyspace", name},
+		string(data),
+		nil) // No error.
+}
+
+func TestMigrateServedFromTask(t *testing.T) {
+	fake := fakevtctlclient.NewFakeVtctlClient()
+	vtctlclient.RegisterFactory("fake", fake.FakeVtctlClientFactory)
+	defer vtctlclient.UnregisterFactoryForTest("fake")
+	flag.Set("vtctl_client_protocol", "fake")
+	task := &MigrateServedFromTask{}
+
+	// rdonly and master are still served from the source keyspace.
+	registerGetKeyspaceResult(t, fake, "moved_keyspace", &pbt.Keyspace{
+		ServedFroms: []*pbt.Keyspace_ServedFrom{
+			{TabletType: pbt.TabletType_MASTER, Keyspace: "test_keyspace"},
+			{TabletType: pbt.TabletType_RDONLY, Keyspace: "test_keyspace"},
+		},
+	})
+	fake.RegisterResult([]string{"MigrateServedFrom", "moved_keyspace/0", "rdonly"},
+		"",  // No output.
+		nil) // No error.
+	parameters := map[string]string{
+		"keyspace":        "moved_keyspace",
+		"shard":           "0",
+		"type":            "rdonly",
+		"vtctld_endpoint": "localhost:15000",
+	}
+	testTask(t, "MigrateServedFrom", task, parameters)
+
+	// No result is registered for the following calls of MigrateServedFrom.
+	// The task would fail if it ran it.
+	// replica was already migrated.
+	parameters["type"] = "replica"
+	testTask(t, "MigrateServedFrom", task, parameters)
+	// master was never migrated, so there is nothing to roll back.
+	parameters["type"] = "master"
+	parameters["reverse"] = "true"
+	testTask(t, "MigrateServedFrom", task, parameters)
+}
+
+func TestCreateServedFromKeyspaceTask(t *testing.T) {
+	fake := fakevtctlclient.NewFakeVtctlClient()
+	vtctlclient.RegisterFactory("fake", fake.FakeVtctlClientFactory)
+	defer vtctlclient.UnregisterFactoryForTest("fake")
+	flag.Set("vtctl_client_protocol", "fake")
+	task := &CreateServedFromKeyspaceTask{}
+
+	// GetKeyspace fails for moved_keyspace because no result is registered.
+	fake.RegisterResult([]string{"CreateKeyspace", "--served_from=master:test_keyspace,replica:test_keyspace,rdonly:test_keyspace", "moved_keyspace"},
+		"",  // No output.
+		nil) // No error.
+	parameters := map[string]string{
+		"keyspace":        "moved_keyspace",
+		"source_keyspace": "test_keyspace",
+		"vtctld_endpoint": "localhost:15000",
+	}
+	testTask(t, "CreateServedFromKeyspace", task, parameters)
+
+	// The keyspace exists already.
+	registerGetKeyspaceResult(t, fake, "existing_keyspace", &pbt.Keyspace{
+		ServedFroms: []*pbt.Keyspace_ServedFrom{
+			{TabletType: pbt.TabletType_MASTER, Keyspace: "test_keyspace"},
+			{TabletType: pbt.TabletType_REPLICA, Keyspace: "test_keyspace"},
+			{TabletType: pbt.TabletType_RDONLY, Keyspace: "test_keyspace"},
+		},
+	})
+	parameters["keyspace"] = "existing_keyspace"
+	testTask(t, "CreateServedFromKeyspace", task, parameters)
+
+	// The keyspace exists, but is served from another keyspace.
+	parameters["source_keyspace"] = "other_keyspace"
+	if _, _, err := task.Run(parameters); err == nil {
+		t.Errorf("CreateServedFromKeyspaceTask should fail if the keyspace is served from another keyspace")
+	}
+}
+
+func TestDropTablesTask(t *testing.T) {
+	fake := fakevtctlclient.NewFakeVtctlClient()
+	vtctlclient.RegisterFactory("fake", fake.FakeVtctlClientFactory)
+	defer vtctlclient.UnregisterFactoryForTest("fake")
+	flag.Set("vtctl_client_protocol", "fake")
+	task := &DropTablesTask{}
+
+	fake.RegisterResult([]string{"ApplySchema", "-sql=DROP TABLE IF EXISTS table1;DROP TABLE IF EXISTS table2", "test_keyspace"},
+		"",  // No output.
+		nil) // No error.
+	parameters := map[string]string{
+		"keyspace":        "test_keyspace",
+		"tables":          "table1,table2",
+		"vtctld_endpoint": "localhost:15000",
+	}
+	testTask(t, "DropTables", task, parameters)
+
+	parameters["tables"] = "/table.*/"
+	if _, _, err := task.Run(parameters); err == nil {
+		t.Errorf("DropTablesTask should not drop tables which are matched by a regular expression")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	// No fake is registered. The tasks would fail if they ran any command.
+	parameters := map[string]string{
+		"keyspace":          "moved_keyspace",
+		"shard":             "0",
+		"tables":            "table1",
+		"vtworker_endpoint": "localhost:15001",
+		"dry_run":           "true",
+	}
+	testTask(t, "VerticalSplitClone", &VerticalSplitCloneTask{}, parameters)
+
+	parameters = map[string]string{
+		"keyspace":        "moved_keyspace",
+		"shard":           "0",
+		"type":            "master",
+		"vtctld_endpoint": "localhost:15000",
+		"dry_run":         "true",
+	}
+	testTask(t, "MigrateServedFrom", &MigrateServedFromTask{}, parameters)
+}
@@ -6,13 +6,19 @@ package automation
 
 import (
 	"fmt"
+	"strconv"
 
 	pb "github.com/youtube/vitess/go/vt/proto/automation"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
 	"golang.org/x/net/context"
 )
 
 // MigrateServedTypesTask runs vtctl MigrateServedTypes to migrate a serving
 // type from the source shard to the shards that it replicates to.
+// If "reverse" is set, the type is migrated back to the source shard.
+// The task checks the serving state of the source shard first and does nothing
+// if the type is already migrated. Therefore, it can be run again e.g. when
+// its cluster operation is resumed.
 type MigrateServedTypesTask struct {
 }
 
@@ -20,12 +26,30 @@ type MigrateServedTypesTask struct {
 func (t *MigrateServedTypesTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
 	keyspaceAndShard := fmt.Sprintf("%v/%v", parameters["keyspace"], parameters["source_shard"])
 
+	servedType, err := topoproto.ParseTabletType(parameters["type"])
+	if err != nil {
+		return nil, "", err
+	}
+	reverse := false
+	if r := parameters["reverse"]; r != "" {
+		if reverse, err = strconv.ParseBool(r); err != nil {
+			return nil, "", fmt.Errorf("invalid value for parameter reverse: %v", r)
+		}
+	}
+	shard, err := getShard(context.TODO(), parameters["vtctld_endpoint"], parameters["keyspace"], parameters["source_shard"])
+	if err != nil {
+		return nil, "", err
+	}
+	if servesType(shard, servedType, parameters["cell"]) == reverse {
+		return nil, fmt.Sprintf("Skipped MigrateServedTypes because the served type %v of source shard %v is already migrated (reverse: %v).", parameters["type"], keyspaceAndShard, reverse), nil
+	}
+
 	args := []string{"MigrateServedTypes"}
 	if cell := parameters["cell"]; cell != "" {
 		args = append(args, "--cell="+cell)
 	}
-	if reverse := parameters["reverse"]; reverse != "" {
-		args = append(args, "--reverse="+reverse)
+	if r := parameters["reverse"]; r != "" {
+		args = append(args, "--reverse="+r)
 	}
 	args = append(args, keyspaceAndShard, parameters["type"])
 	output, err := ExecuteVtctl(context.TODO(), parameters["vtctld_endpoint"], args)
@@ -10,6 +10,8 @@ import (
 
 	"github.com/youtube/vitess/go/vt/vtctl/fakevtctlclient"
 	"github.com/youtube/vitess/go/vt/vtctl/vtctlclient"
+
+	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
 )
 
 func TestMigrateServedTypesTask(t *testing.T) {
@@ -19,6 +21,13 @@ func TestMigrateServedTypesTask(t *testing.T) {
 	flag.Set("vtctl_client_protocol", "fake")
 	task := &MigrateServedTypesTask{}
 
+	// Shard 0 still serves rdonly in all cells.
+	registerGetShardResult(t, fake, "test_keyspace/0", &pbt.Shard{
+		ServedTypes: []*pbt.Shard_ServedType{
+			{TabletType: pbt.TabletType_MASTER},
+			{TabletType: pbt.TabletType_RDONLY},
+		},
+	})
 	fake.RegisterResult([]string{"MigrateServedTypes", "test_keyspace/0", "rdonly"},
 		"",  // No output.
 		nil) // No error.
@@ -30,10 +39,46 @@ func TestMigrateServedTypesTask(t *testing.T) {
 	}
 	testTask(t, "MigrateServedTypes", task, parameters)
 
-	fake.RegisterResult([]string{"MigrateServedTypes", "--cell=cell1", "--reverse=true", "test_keyspace/0", "rdonly"},
+	// Shard 1 does not serve rdonly in cell1 anymore.
+	registerGetShardResult(t, fake, "test_keyspace/1", &pbt.Shard{
+		ServedTypes: []*pbt.Shard_ServedType{
+			{TabletType: pbt.TabletType_MASTER},
+			{TabletType: pbt.TabletType_RDONLY, Cells: []string{"cell2"}},
+		},
+	})
+	fake.RegisterResult([]string{"MigrateServedTypes", "--cell=cell1", "--reverse=true", "test_keyspace/1", "rdonly"},
 		"",  // No output.
 		nil) // No error.
+	parameters["source_shard"] = "1"
 	parameters["cell"] = "cell1"
 	parameters["reverse"] = "true"
 	testTask(t, "MigrateServedTypes", task, parameters)
 }
+
+func TestMigrateServedTypesTaskSkipsMigratedType(t *testing.T) {
+	fake := fakevtctlclient.NewFakeVtctlClient()
+	vtctlclient.RegisterFactory("fake", fake.FakeVtctlClientFactory)
+	defer vtctlclient.UnregisterFactoryForTest("fake")
+	flag.Set("vtctl_client_protocol", "fake")
+	task := &MigrateServedTypesTask{}
+
+	// No result is registered for MigrateServedTypes. The task would fail if it ran it.
+	registerGetShardResult(t, fake, "test_keyspace/0", &pbt.Shard{
+		ServedTypes: []*pbt.Shard_ServedType{
+			{TabletType: pbt.TabletType_MASTER},
+		},
+	})
+	parameters := map[string]string{
+		"keyspace":        "test_keyspace",
+		"source_shard":    "0",
+		"type":            "replica",
+		"vtctld_endpoint": "localhost:15000",
+	}
+	// replica was already migrated.
+	testTask(t, "MigrateServedTypes", task, parameters)
+
+	// master was never migrated, so there is nothing to roll back.
+	parameters["type"] = "master"
+	parameters["reverse"] = "true"
+	testTask(t, "MigrateServedTypes", task, parameters)
+}
@@ -0,0 +1,128 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"fmt"
+	"strconv"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+)
+
+// MoveTablesTask is a cluster operation which moves tables from a keyspace
+// into a new keyspace (vertical split) with a live cutover:
+// - create the destination keyspace, served from the source keyspace
+// - copy the schema of the tables
+// - copy the data and start filtered replication
+// - wait for filtered replication to catch up and diff the tables
+// - migrate rdonly, replica and master to the destination keyspace
+// - drop the tables in the source keyspace
+// The destination tablets must be started after the keyspace was created
+// and before the schema is copied. If they are not up yet, the
+// CopySchemaShardTask fails and the operation can be resumed later.
+// With "dry_run", no step changes anything. With "pause_between_steps",
+// the operation pauses before each step and must be resumed.
+// The operation can be aborted with CancelClusterOperation.
+type MoveTablesTask struct {
+}
+
+// Run is part of the Task interface.
+func (t *MoveTablesTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
+	// Example: test_keyspace
+	sourceKeyspace := parameters["source_keyspace"]
+	// Example: moved_keyspace
+	destKeyspace := parameters["dest_keyspace"]
+	// Example: table1,table2
+	tables := parameters["tables"]
+	// Example: localhost:15000
+	vtctldEndpoint := parameters["vtctld_endpoint"]
+	// Example: localhost:15001
+	vtworkerEndpoint := parameters["vtworker_endpoint"]
+	// Vertical splits are done between unsharded keyspaces.
+	shard := "0"
+	if s := parameters["shard"]; s != "" {
+		shard = s
+	}
+	pause := false
+	if p := parameters["pause_between_steps"]; p != "" {
+		var err error
+		if pause, err = strconv.ParseBool(p); err != nil {
+			return nil, "", fmt.Errorf("invalid value for parameter pause_between_steps: %v", p)
+		}
+	}
+	if _, err := isDryRun(parameters); err != nil {
+		return nil, "", err
+	}
+
+	var newTasks []*pb.TaskContainer
+	addStep := func(taskName string, stepParameters map[string]string) {
+		if pause && len(newTasks) > 0 {
+			newTasks = append(newTasks, NewTaskContainerWithSingleTask("PauseTask", map[string]string{
+				"next_step": taskName,
+			}))
+		}
+		if dryRun := parameters["dry_run"]; dryRun != "" {
+			stepParameters["dry_run"] = dryRun
+		}
+		newTasks = append(newTasks, NewTaskContainerWithSingleTask(taskName, stepParameters))
+	}
+
+	addStep("CreateServedFromKeyspaceTask", map[string]string{
+		"keyspace":        destKeyspace,
+		"source_keyspace": sourceKeyspace,
+		"vtctld_endpoint": vtctldEndpoint,
+	})
+	addStep("CopySchemaShardTask", map[string]string{
+		"keyspace":        sourceKeyspace,
+		"source_shard":    shard,
+		"dest_keyspace":   destKeyspace,
+		"dest_shard":      shard,
+		"tables":          tables,
+		"vtctld_endpoint": vtctldEndpoint,
+	})
+	addStep("VerticalSplitCloneTask", map[string]string{
+		"keyspace":          destKeyspace,
+		"shard":             shard,
+		"tables":            tables,
+		"vtworker_endpoint": vtworkerEndpoint,
+	})
+	addStep("WaitForFilteredReplicationTask", map[string]string{
+		"keyspace":        destKeyspace,
+		"shard":           shard,
+		"max_delay":       "30s",
+		"vtctld_endpoint": vtctldEndpoint,
+	})
+	addStep("VerticalSplitDiffTask", map[string]string{
+		"keyspace":          destKeyspace,
+		"shard":             shard,
+		"vtworker_endpoint": vtworkerEndpoint,
+	})
+	for _, servedType := range []string{"rdonly", "replica", "master"} {
+		addStep("MigrateServedFromTask", map[string]string{
+			"keyspace":        destKeyspace,
+			"shard":           shard,
+			"type":            servedType,
+			"vtctld_endpoint": vtctldEndpoint,
+		})
+	}
+	addStep("DropTablesTask", map[string]string{
+		"keyspace":        sourceKeyspace,
+		"tables":          tables,
+		"vtctld_endpoint": vtctldEndpoint,
+	})
+
+	return newTasks, "", nil
+}
+
+// RequiredParameters is part of the Task interface.
+func (t *MoveTablesTask) RequiredParameters() []string {
+	return []string{"source_keyspace", "dest_keyspace", "tables",
+		"vtctld_endpoint", "vtworker_endpoint"}
+}
+
+// OptionalParameters is part of the Task interface.
+func (t *MoveTablesTask) OptionalParameters() []string {
+	return []string{"shard", "dry_run", "pause_between_steps"}
+}
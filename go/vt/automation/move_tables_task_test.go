@@ -0,0 +1,78 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"testing"
+)
+
+func TestMoveTablesTaskEmittedTasks(t *testing.T) {
+	moveTablesTask := &MoveTablesTask{}
+
+	parameters := map[string]string{
+		"source_keyspace":   "test_keyspace",
+		"dest_keyspace":     "moved_keyspace",
+		"tables":            "table1,table2",
+		"vtctld_endpoint":   "localhost:15000",
+		"vtworker_endpoint": "localhost:15001",
+		"dry_run":           "true",
+	}
+	err := validateParameters(moveTablesTask, parameters)
+	if err != nil {
+		t.Fatalf("Not all required parameters were specified: %v", err)
+	}
+
+	newTaskContainers, _, err := moveTablesTask.Run(parameters)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := []string{
+		"CreateServedFromKeyspaceTask",
+		"CopySchemaShardTask",
+		"VerticalSplitCloneTask",
+		"WaitForFilteredReplicationTask",
+		"VerticalSplitDiffTask",
+		"MigrateServedFromTask",
+		"MigrateServedFromTask",
+		"MigrateServedFromTask",
+		"DropTablesTask",
+	}
+	if len(newTaskContainers) != len(want) {
+		t.Fatalf("wrong number of emitted task containers: got: %v want: %v", len(newTaskContainers), len(want))
+	}
+	for i, name := range want {
+		task := newTaskContainers[i].ParallelTasks[0]
+		if task.Name != name || task.Parameters["dry_run"] != "true" {
+			t.Errorf("task container %v: got: %v want: %v in a dry run", i, task, name)
+		}
+	}
+	if got := newTaskContainers[5].ParallelTasks[0].Parameters["type"]; got != "rdonly" {
+		t.Errorf("rdonly must be migrated first, got: %v", got)
+	}
+	if got := newTaskContainers[8].ParallelTasks[0].Parameters["keyspace"]; got != "test_keyspace" {
+		t.Errorf("tables must be dropped in the source keyspace, got: %v", got)
+	}
+
+	// With pause_between_steps, there is a PauseTask before every step but the first.
+	parameters["pause_between_steps"] = "true"
+	newTaskContainers, _, err = moveTablesTask.Run(parameters)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(newTaskContainers) != 2*len(want)-1 {
+		t.Fatalf("wrong number of emitted task containers: got: %v want: %v", len(newTaskContainers), 2*len(want)-1)
+	}
+	for i, name := range want {
+		if task := newTaskContainers[2*i].ParallelTasks[0]; task.Name != name {
+			t.Errorf("task container %v: got: %v want: %v", 2*i, task, name)
+		}
+		if i == 0 {
+			continue
+		}
+		if task := newTaskContainers[2*i-1].ParallelTasks[0]; task.Name != "PauseTask" || task.Parameters["next_step"] != name {
+			t.Errorf("task container %v: got: %v want: PauseTask before %v", 2*i-1, task, name)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"errors"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+)
+
+// errPauseClusterOperation is returned by a task to pause its cluster operation.
+// The scheduler marks the task as succeeded and stops the operation.
+// It can be continued with ResumeClusterOperation.
+var errPauseClusterOperation = errors.New("pause requested")
+
+// PauseTask pauses its cluster operation. It allows to check the cluster
+// before the next step of a multi-step operation is run.
+type PauseTask struct {
+}
+
+// Run is part of the Task interface.
+func (t *PauseTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
+	output := "Paused. Resume the ClusterOperation to continue."
+	if step := parameters["next_step"]; step != "" {
+		output = "Paused before: " + step + ". Resume the ClusterOperation to continue."
+	}
+	return nil, output, errPauseClusterOperation
+}
+
+// RequiredParameters is part of the Task interface.
+func (t *PauseTask) RequiredParameters() []string {
+	return nil
+}
+
+// OptionalParameters is part of the Task interface.
+func (t *PauseTask) OptionalParameters() []string {
+	return []string{"next_step"}
+}
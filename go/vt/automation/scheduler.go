@@ -38,9 +38,19 @@ type Scheduler struct {
 	// Guarded by "mu".
 	registeredClusterOperations map[string]bool
 	// Guarded by "mu".
-	toBeScheduledClusterOperations chan ClusterOperationInstance
+	// Operations waiting to be processed, in order. The queue is not
+	// bounded, so adding an operation never blocks while holding "mu".
+	toBeScheduledClusterOperations []ClusterOperationInstance
+	// Signaled when an operation is queued, or the state changes.
+	toBeScheduledCond *sync.Cond
 	// Guarded by "mu".
 	state schedulerState
+	// Guarded by "mu".
+	// Unfinished operations loaded from the checkpoint store. They are scheduled by Run().
+	toBeResumedClusterOperations []ClusterOperationInstance
+
+	// May be nil. In that case, the state of cluster operations is not persisted.
+	checkpointStore CheckpointStore
 
 	// Guarded by "taskCreatorMu". May be overriden by testing code.
 	taskCreator   taskCreator
@@ -57,28 +67,73 @@ type Scheduler struct {
 	// Guarded by "muOpList".
 	// The key of the map is ClusterOperationInstance.ID.
 	finishedClusterOperations map[string]ClusterOperationInstance
+	// Guarded by "muOpList".
+	// The key of the map is ClusterOperationInstance.ID.
+	// Active operations in this map won't start any new task.
+	canceledClusterOperations map[string]bool
 }
 
 // NewScheduler creates a new instance.
-func NewScheduler() (*Scheduler, error) {
+// If "checkpointStore" is not nil, all operations are reloaded from it and
+// unfinished operations will be resumed once Run() is called.
+func NewScheduler(checkpointStore CheckpointStore) (*Scheduler, error) {
 	defaultClusterOperations := map[string]bool{
-		"HorizontalReshardingTask": true,
+		"HorizontalReshardingTask":         true,
+		"HorizontalReshardingRollbackTask": true,
+		"MoveTablesTask":                   true,
 	}
 
 	s := &Scheduler{
-		registeredClusterOperations:    defaultClusterOperations,
-		idGenerator:                    IDGenerator{},
-		toBeScheduledClusterOperations: make(chan ClusterOperationInstance, 10),
-		state:                     stateNotRunning,
-		taskCreator:               defaultTaskCreator,
-		pendingOpsWg:              &sync.WaitGroup{},
-		activeClusterOperations:   make(map[string]ClusterOperationInstance),
-		finishedClusterOperations: make(map[string]ClusterOperationInstance),
+		registeredClusterOperations: defaultClusterOperations,
+		idGenerator:                 IDGenerator{},
+		state:                       stateNotRunning,
+		taskCreator:                 defaultTaskCreator,
+		pendingOpsWg:                &sync.WaitGroup{},
+		activeClusterOperations:     make(map[string]ClusterOperationInstance),
+		finishedClusterOperations:   make(map[string]ClusterOperationInstance),
+		canceledClusterOperations:   make(map[string]bool),
+		checkpointStore:             checkpointStore,
+	}
+	s.toBeScheduledCond = sync.NewCond(&s.mu)
+
+	if checkpointStore != nil {
+		if err := s.loadCheckpoints(); err != nil {
+			return nil, err
+		}
 	}
 
 	return s, nil
 }
 
+// loadCheckpoints restores all cluster operations from the checkpoint store.
+func (s *Scheduler) loadCheckpoints() error {
+	clusterOps, err := s.checkpointStore.LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoints: %v", err)
+	}
+
+	for _, clusterOpProto := range clusterOps {
+		// New IDs must not collide with the ones of the loaded operations.
+		s.idGenerator.ObserveID(clusterOpProto.Id)
+		taskIDGenerator := &IDGenerator{}
+		for _, taskContainer := range clusterOpProto.SerialTasks {
+			for _, task := range taskContainer.ParallelTasks {
+				taskIDGenerator.ObserveID(task.Id)
+			}
+		}
+		clusterOp := ClusterOperationInstance{*clusterOpProto, taskIDGenerator}
+
+		if clusterOp.State == pb.ClusterOperationState_CLUSTER_OPERATION_DONE {
+			s.finishedClusterOperations[clusterOp.Id] = clusterOp
+			continue
+		}
+		log.Infof("ClusterOperation: %v will be resumed from its checkpoint. Details: %v", clusterOp.Id, clusterOp)
+		s.activeClusterOperations[clusterOp.Id] = clusterOp.Clone()
+		s.toBeResumedClusterOperations = append(s.toBeResumedClusterOperations, clusterOp)
+	}
+	return nil
+}
+
 func (s *Scheduler) registerClusterOperation(clusterOperationName string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -89,10 +144,39 @@ func (s *Scheduler) registerClusterOperation(clusterOperationName string) {
 // Run processes queued cluster operations.
 func (s *Scheduler) Run() {
 	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.state = stateRunning
-	s.mu.Unlock()
 
 	s.startProcessRequestsLoop()
+
+	// Resume the operations which were not finished by the previous process.
+	for _, clusterOp := range s.toBeResumedClusterOperations {
+		s.scheduleClusterOperation(clusterOp)
+	}
+	s.toBeResumedClusterOperations = nil
+}
+
+// scheduleClusterOperation queues an operation for the processing loop.
+// Must be called with "mu" held.
+func (s *Scheduler) scheduleClusterOperation(clusterOp ClusterOperationInstance) {
+	s.toBeScheduledClusterOperations = append(s.toBeScheduledClusterOperations, clusterOp)
+	s.toBeScheduledCond.Signal()
+}
+
+// nextClusterOperation waits for the next queued operation. It returns
+// false once the scheduler is shutting down and the queue is empty.
+func (s *Scheduler) nextClusterOperation() (ClusterOperationInstance, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.toBeScheduledClusterOperations) == 0 {
+		if s.state != stateRunning {
+			return ClusterOperationInstance{}, false
+		}
+		s.toBeScheduledCond.Wait()
+	}
+	clusterOp := s.toBeScheduledClusterOperations[0]
+	s.toBeScheduledClusterOperations = s.toBeScheduledClusterOperations[1:]
+	return clusterOp, true
 }
 
 func (s *Scheduler) startProcessRequestsLoop() {
@@ -105,7 +189,11 @@ func (s *Scheduler) startProcessRequestsLoop() {
 func (s *Scheduler) processRequestsLoop() {
 	defer s.pendingOpsWg.Done()
 
-	for op := range s.toBeScheduledClusterOperations {
+	for {
+		op, ok := s.nextClusterOperation()
+		if !ok {
+			break
+		}
 		s.processClusterOperation(op)
 	}
 	log.Infof("Stopped processing loop for ClusterOperations.")
@@ -118,12 +206,25 @@ func (s *Scheduler) processClusterOperation(clusterOp ClusterOperationInstance)
 	}
 
 	log.Infof("ClusterOperation: %v running. Details: %v", clusterOp.Id, clusterOp)
+	clusterOp.State = pb.ClusterOperationState_CLUSTER_OPERATION_RUNNING
+	s.Checkpoint(clusterOp)
 
 clusterOpLoop:
 	for i := 0; i < len(clusterOp.SerialTasks); i++ {
 		taskContainer := clusterOp.SerialTasks[i]
 		for _, taskProto := range taskContainer.ParallelTasks {
+			if s.isCanceled(clusterOp.Id) {
+				log.Infof("ClusterOperation: %v canceled. Not starting task: %v (%v/%v)", clusterOp.Id, taskProto.Name, clusterOp.Id, taskProto.Id)
+				clusterOp.Error = errClusterOperationCanceled
+				break clusterOpLoop
+			}
 			newTaskContainers, output, err := s.runTask(taskProto, clusterOp.Id)
+			if err == errPauseClusterOperation {
+				log.Infof("ClusterOperation: %v paused by task: %v (%v/%v)", clusterOp.Id, taskProto.Name, clusterOp.Id, taskProto.Id)
+				MarkTaskSucceeded(taskProto, output)
+				clusterOp.Error = errClusterOperationPaused
+				break clusterOpLoop
+			}
 			if err != nil {
 				MarkTaskFailed(taskProto, output, err)
 				clusterOp.Error = err.Error()
@@ -152,18 +253,32 @@ clusterOpLoop:
 
 	clusterOp.State = pb.ClusterOperationState_CLUSTER_OPERATION_DONE
 	log.Infof("ClusterOperation: %v finished. Details: %v", clusterOp.Id, clusterOp)
-	s.Checkpoint(clusterOp)
+	s.saveCheckpoint(clusterOp)
 
 	// Move operation from active to finished.
+	// The active copy is not updated before, otherwise it could be seen as done while it's still active.
 	s.muOpList.Lock()
 	defer s.muOpList.Unlock()
 	if _, ok := s.activeClusterOperations[clusterOp.Id]; !ok {
 		panic("Pending ClusterOperation was not recorded as active, but should have.")
 	}
 	delete(s.activeClusterOperations, clusterOp.Id)
+	delete(s.canceledClusterOperations, clusterOp.Id)
 	s.finishedClusterOperations[clusterOp.Id] = clusterOp
 }
 
+// errClusterOperationCanceled is set as error of a ClusterOperation which was canceled.
+const errClusterOperationCanceled = "ClusterOperation was canceled"
+
+// errClusterOperationPaused is set as error of a ClusterOperation which was paused by a task.
+const errClusterOperationPaused = "ClusterOperation was paused"
+
+func (s *Scheduler) isCanceled(clusterOpID string) bool {
+	s.muOpList.Lock()
+	defer s.muOpList.Unlock()
+	return s.canceledClusterOperations[clusterOpID]
+}
+
 func (s *Scheduler) runTask(taskProto *pb.Task, clusterOpID string) ([]*pb.TaskContainer, string, error) {
 	if taskProto.State == pb.TaskState_DONE {
 		// Task is already done (e.g. because we resume from a checkpoint).
@@ -205,16 +320,34 @@ func defaultTaskCreator(taskName string) Task {
 	switch taskName {
 	case "HorizontalReshardingTask":
 		return &HorizontalReshardingTask{}
+	case "HorizontalReshardingRollbackTask":
+		return &HorizontalReshardingRollbackTask{}
 	case "CopySchemaShardTask":
 		return &CopySchemaShardTask{}
 	case "MigrateServedTypesTask":
 		return &MigrateServedTypesTask{}
+	case "StopFilteredReplicationTask":
+		return &StopFilteredReplicationTask{}
 	case "WaitForFilteredReplicationTask":
 		return &WaitForFilteredReplicationTask{}
 	case "SplitCloneTask":
 		return &SplitCloneTask{}
 	case "SplitDiffTask":
 		return &SplitDiffTask{}
+	case "MoveTablesTask":
+		return &MoveTablesTask{}
+	case "CreateServedFromKeyspaceTask":
+		return &CreateServedFromKeyspaceTask{}
+	case "VerticalSplitCloneTask":
+		return &VerticalSplitCloneTask{}
+	case "VerticalSplitDiffTask":
+		return &VerticalSplitDiffTask{}
+	case "MigrateServedFromTask":
+		return &MigrateServedFromTask{}
+	case "DropTablesTask":
+		return &DropTablesTask{}
+	case "PauseTask":
+		return &PauseTask{}
 	default:
 		return nil
 	}
@@ -304,7 +437,7 @@ func (s *Scheduler) EnqueueClusterOperation(ctx context.Context, req *pb.Enqueue
 	s.muOpList.Lock()
 	s.activeClusterOperations[clusterOpID] = clusterOp.Clone()
 	s.muOpList.Unlock()
-	s.toBeScheduledClusterOperations <- clusterOp
+	s.scheduleClusterOperation(clusterOp)
 
 	return &pb.EnqueueClusterOperationResponse{
 		Id: clusterOp.Id,
@@ -329,12 +462,25 @@ func (s *Scheduler) findClusterOp(id string) (ClusterOperationInstance, error) {
 }
 
 // Checkpoint should be called every time the state of the cluster op changes.
-// It is used to update the copy of the state in activeClusterOperations.
+// It is used to update the copy of the state in activeClusterOperations
+// and to persist the state in the checkpoint store, if there is one.
 func (s *Scheduler) Checkpoint(clusterOp ClusterOperationInstance) {
-	// TODO(mberlin): Add here support for persistent checkpoints.
 	s.muOpList.Lock()
-	defer s.muOpList.Unlock()
 	s.activeClusterOperations[clusterOp.Id] = clusterOp.Clone()
+	s.muOpList.Unlock()
+
+	s.saveCheckpoint(clusterOp)
+}
+
+// saveCheckpoint persists the state of the cluster op.
+// Failures are only logged because the operation can continue without it.
+func (s *Scheduler) saveCheckpoint(clusterOp ClusterOperationInstance) {
+	if s.checkpointStore == nil {
+		return
+	}
+	if err := s.checkpointStore.Save(&clusterOp.ClusterOperation); err != nil {
+		log.Errorf("ClusterOperation: %v failed to save checkpoint. Error: %v", clusterOp.Id, err)
+	}
 }
 
 // GetClusterOperationDetails can be used to query the full details of active or finished operations.
@@ -348,12 +494,77 @@ func (s *Scheduler) GetClusterOperationDetails(ctx context.Context, req *pb.GetC
 	}, nil
 }
 
+// ResumeClusterOperation restarts a cluster operation which failed, was canceled or was paused.
+// Failed tasks are reset and the operation continues with the first task which did not succeed.
+func (s *Scheduler) ResumeClusterOperation(ctx context.Context, req *pb.ResumeClusterOperationRequest) (*pb.ResumeClusterOperationResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state != stateRunning {
+		return nil, fmt.Errorf("scheduler is not running. State: %v", s.state)
+	}
+
+	s.muOpList.Lock()
+	if _, ok := s.activeClusterOperations[req.Id]; ok {
+		s.muOpList.Unlock()
+		return nil, fmt.Errorf("ClusterOperation with id: %v is still running", req.Id)
+	}
+	clusterOp, ok := s.finishedClusterOperations[req.Id]
+	if !ok {
+		s.muOpList.Unlock()
+		return nil, fmt.Errorf("ClusterOperation with id: %v not found", req.Id)
+	}
+	if clusterOp.Error == "" {
+		s.muOpList.Unlock()
+		return nil, fmt.Errorf("ClusterOperation with id: %v finished successfully and cannot be resumed", req.Id)
+	}
+
+	clusterOp = clusterOp.Clone()
+	for _, taskContainer := range clusterOp.SerialTasks {
+		for _, task := range taskContainer.ParallelTasks {
+			if task.State == pb.TaskState_DONE && task.Error != "" {
+				task.State = pb.TaskState_NOT_STARTED
+				task.Output = ""
+				task.Error = ""
+			}
+		}
+	}
+	clusterOp.Error = ""
+	clusterOp.State = pb.ClusterOperationState_CLUSTER_OPERATION_NOT_STARTED
+	delete(s.finishedClusterOperations, req.Id)
+	s.activeClusterOperations[req.Id] = clusterOp.Clone()
+	s.muOpList.Unlock()
+
+	log.Infof("ClusterOperation: %v will be resumed. Details: %v", clusterOp.Id, clusterOp)
+	s.saveCheckpoint(clusterOp)
+	s.scheduleClusterOperation(clusterOp)
+
+	return &pb.ResumeClusterOperationResponse{}, nil
+}
+
+// CancelClusterOperation stops a cluster operation after its currently running task.
+// The operation finishes with an error and can be resumed with ResumeClusterOperation.
+func (s *Scheduler) CancelClusterOperation(ctx context.Context, req *pb.CancelClusterOperationRequest) (*pb.CancelClusterOperationResponse, error) {
+	s.muOpList.Lock()
+	defer s.muOpList.Unlock()
+
+	if _, ok := s.activeClusterOperations[req.Id]; !ok {
+		if _, ok := s.finishedClusterOperations[req.Id]; ok {
+			return nil, fmt.Errorf("ClusterOperation with id: %v has already finished", req.Id)
+		}
+		return nil, fmt.Errorf("ClusterOperation with id: %v not found", req.Id)
+	}
+	log.Infof("ClusterOperation: %v will be canceled.", req.Id)
+	s.canceledClusterOperations[req.Id] = true
+	return &pb.CancelClusterOperationResponse{}, nil
+}
+
 // ShutdownAndWait shuts down the scheduler and waits infinitely until all pending cluster operations have finished.
 func (s *Scheduler) ShutdownAndWait() {
 	s.mu.Lock()
 	if s.state != stateShuttingDown {
 		s.state = stateShuttingDown
-		close(s.toBeScheduledClusterOperations)
+		s.toBeScheduledCond.Broadcast()
 	}
 	s.mu.Unlock()
 
@@ -5,6 +5,9 @@
 package automation
 
 import (
+	"io/ioutil"
+	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -18,7 +21,7 @@ import (
 // newTestScheduler constructs a scheduler with test tasks.
 // If tasks should be available as cluster operation, they still have to be registered manually with scheduler.registerClusterOperation.
 func newTestScheduler(t *testing.T) *Scheduler {
-	scheduler, err := NewScheduler()
+	scheduler, err := NewScheduler(nil)
 	if err != nil {
 		t.Fatalf("Failed to create scheduler: %v", err)
 	}
@@ -95,7 +98,7 @@ func waitForClusterOperation(t *testing.T, scheduler *Scheduler, id string, expe
 
 func TestSchedulerImmediateShutdown(t *testing.T) {
 	// Make sure that the scheduler shuts down cleanly when it was instantiated, but not started with Run().
-	scheduler, err := NewScheduler()
+	scheduler, err := NewScheduler(nil)
 	if err != nil {
 		t.Fatalf("Failed to create scheduler: %v", err)
 	}
@@ -252,3 +255,228 @@ func TestTaskEmitsTaskWhichCannotBeInstantiated(t *testing.T) {
 		t.Errorf("A task has been emitted, but it shouldn't. Details:\n%v", proto.MarshalTextString(details))
 	}
 }
+
+// newTestCheckpointStore returns a FileCheckpointStore which contains an
+// unfinished ClusterOperation with the id "5". Its first task is done and its
+// second task has not started yet.
+// The first task is a TestingFailTask: if it ran again, the operation would fail.
+func newTestCheckpointStore(t *testing.T) (*FileCheckpointStore, string) {
+	dir, err := ioutil.TempDir("", "scheduler_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	store, err := NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileCheckpointStore failed: %v", err)
+	}
+
+	firstTask := NewTaskContainerWithSingleTask("TestingFailTask", map[string]string{"echo_text": "first"})
+	firstTask.ParallelTasks[0].Id = "1"
+	MarkTaskSucceeded(firstTask.ParallelTasks[0], "first task output")
+	secondTask := NewTaskContainerWithSingleTask("TestingEchoTask", map[string]string{"echo_text": "resumed"})
+	secondTask.ParallelTasks[0].Id = "2"
+	clusterOp := &pb.ClusterOperation{
+		Id:          "5",
+		SerialTasks: []*pb.TaskContainer{firstTask, secondTask},
+		State:       pb.ClusterOperationState_CLUSTER_OPERATION_RUNNING,
+	}
+	if err := store.Save(clusterOp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	return store, dir
+}
+
+func TestSchedulerResumesFromCheckpoint(t *testing.T) {
+	store, dir := newTestCheckpointStore(t)
+	defer os.RemoveAll(dir)
+
+	scheduler, err := NewScheduler(store)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	defer scheduler.ShutdownAndWait()
+	scheduler.setTaskCreator(testingTaskCreator)
+	scheduler.registerClusterOperation("TestingEchoTask")
+	scheduler.Run()
+
+	waitForClusterOperation(t, scheduler, "5", "resumed", "")
+
+	// The final state was persisted.
+	clusterOps, err := store.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	if len(clusterOps) != 1 || clusterOps[0].State != pb.ClusterOperationState_CLUSTER_OPERATION_DONE {
+		t.Errorf("checkpoint was not updated: %v", clusterOps)
+	}
+
+	// IDs of new operations do not collide with the loaded ones.
+	enqueueResponse, err := scheduler.EnqueueClusterOperation(context.Background(), &pb.EnqueueClusterOperationRequest{
+		Name:       "TestingEchoTask",
+		Parameters: map[string]string{"echo_text": "new"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start cluster operation: %v", err)
+	}
+	if enqueueResponse.Id != "6" {
+		t.Errorf("new ClusterOperation got id: %v want: 6", enqueueResponse.Id)
+	}
+	waitForClusterOperation(t, scheduler, enqueueResponse.Id, "new", "")
+}
+
+func TestSchedulerResumesManyOperations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduler_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileCheckpointStore failed: %v", err)
+	}
+	// More operations than the scheduler used to be able to queue.
+	const count = 25
+	for i := 1; i <= count; i++ {
+		task := NewTaskContainerWithSingleTask("TestingEchoTask", map[string]string{"echo_text": "resumed"})
+		task.ParallelTasks[0].Id = "1"
+		if err := store.Save(&pb.ClusterOperation{
+			Id:          strconv.Itoa(i),
+			SerialTasks: []*pb.TaskContainer{task},
+			State:       pb.ClusterOperationState_CLUSTER_OPERATION_RUNNING,
+		}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	scheduler, err := NewScheduler(store)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	defer scheduler.ShutdownAndWait()
+	scheduler.setTaskCreator(testingTaskCreator)
+	scheduler.registerClusterOperation("TestingEchoTask")
+	scheduler.Run()
+
+	// The scheduler still accepts requests while the operations are resumed.
+	enqueueResponse, err := scheduler.EnqueueClusterOperation(context.Background(), &pb.EnqueueClusterOperationRequest{
+		Name:       "TestingEchoTask",
+		Parameters: map[string]string{"echo_text": "new"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start cluster operation: %v", err)
+	}
+	for i := 1; i <= count; i++ {
+		waitForClusterOperation(t, scheduler, strconv.Itoa(i), "resumed", "")
+	}
+	waitForClusterOperation(t, scheduler, enqueueResponse.Id, "new", "")
+}
+
+func TestCancelAndResumeClusterOperation(t *testing.T) {
+	store, dir := newTestCheckpointStore(t)
+	defer os.RemoveAll(dir)
+
+	scheduler, err := NewScheduler(store)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	defer scheduler.ShutdownAndWait()
+	scheduler.setTaskCreator(testingTaskCreator)
+
+	if _, err := scheduler.CancelClusterOperation(context.Background(), &pb.CancelClusterOperationRequest{Id: "-1"}); err == nil {
+		t.Errorf("CancelClusterOperation should fail for an unknown id")
+	}
+	// Cancel before the scheduler runs: no task of the operation must be started.
+	if _, err := scheduler.CancelClusterOperation(context.Background(), &pb.CancelClusterOperationRequest{Id: "5"}); err != nil {
+		t.Fatalf("CancelClusterOperation failed: %v", err)
+	}
+	scheduler.Run()
+
+	details := waitForClusterOperation(t, scheduler, "5", "first task output", "")
+	if details.Error != errClusterOperationCanceled {
+		t.Errorf("canceled ClusterOperation has wrong error. got: '%v' want: '%v'", details.Error, errClusterOperationCanceled)
+	}
+	if details.SerialTasks[1].ParallelTasks[0].State != pb.TaskState_NOT_STARTED {
+		t.Errorf("Task of a canceled ClusterOperation must not have been started.")
+	}
+	if _, err := scheduler.CancelClusterOperation(context.Background(), &pb.CancelClusterOperationRequest{Id: "5"}); err == nil {
+		t.Errorf("CancelClusterOperation should fail for a finished operation")
+	}
+
+	if _, err := scheduler.ResumeClusterOperation(context.Background(), &pb.ResumeClusterOperationRequest{Id: "5"}); err != nil {
+		t.Fatalf("ResumeClusterOperation failed: %v", err)
+	}
+	details = waitForClusterOperation(t, scheduler, "5", "resumed", "")
+	if details.Error != "" {
+		t.Errorf("resumed ClusterOperation failed: %v", details.Error)
+	}
+}
+
+func TestResumeFailedClusterOperation(t *testing.T) {
+	scheduler := newTestScheduler(t)
+	defer scheduler.ShutdownAndWait()
+	// Let the task fail the first time.
+	scheduler.setTaskCreator(func(taskName string) Task {
+		if taskName == "TestingEchoTask" {
+			return &TestingFailTask{}
+		}
+		return nil
+	})
+	scheduler.registerClusterOperation("TestingEchoTask")
+	scheduler.Run()
+
+	enqueueResponse, err := scheduler.EnqueueClusterOperation(context.Background(), &pb.EnqueueClusterOperationRequest{
+		Name:       "TestingEchoTask",
+		Parameters: map[string]string{"echo_text": "works now"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start cluster operation: %v", err)
+	}
+	id := enqueueResponse.Id
+	waitForClusterOperation(t, scheduler, id, "", "full error message")
+
+	scheduler.setTaskCreator(testingTaskCreator)
+	if _, err := scheduler.ResumeClusterOperation(context.Background(), &pb.ResumeClusterOperationRequest{Id: id}); err != nil {
+		t.Fatalf("ResumeClusterOperation failed: %v", err)
+	}
+	details := waitForClusterOperation(t, scheduler, id, "works now", "")
+	if details.Error != "" || details.SerialTasks[0].ParallelTasks[0].Error != "" {
+		t.Errorf("resumed ClusterOperation failed: %v", details)
+	}
+
+	want := "ClusterOperation with id: " + id + " finished successfully and cannot be resumed"
+	if _, err := scheduler.ResumeClusterOperation(context.Background(), &pb.ResumeClusterOperationRequest{Id: id}); err == nil || err.Error() != want {
+		t.Errorf("ResumeClusterOperation of a successful operation: got error: '%v' want: '%v'", err, want)
+	}
+}
+
+func TestPauseAndResumeClusterOperation(t *testing.T) {
+	scheduler := newTestScheduler(t)
+	defer scheduler.ShutdownAndWait()
+	scheduler.registerClusterOperation("TestingEmitPauseEchoTask")
+	scheduler.Run()
+
+	enqueueResponse, err := scheduler.EnqueueClusterOperation(context.Background(), &pb.EnqueueClusterOperationRequest{
+		Name:       "TestingEmitPauseEchoTask",
+		Parameters: map[string]string{"echo_text": "after pause"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start cluster operation: %v", err)
+	}
+	id := enqueueResponse.Id
+
+	details := waitForClusterOperation(t, scheduler, id, "Paused before: TestingEchoTask. Resume the ClusterOperation to continue.", "")
+	if details.Error != errClusterOperationPaused {
+		t.Errorf("paused ClusterOperation has wrong error. got: '%v' want: '%v'", details.Error, errClusterOperationPaused)
+	}
+	if details.SerialTasks[2].ParallelTasks[0].State != pb.TaskState_NOT_STARTED {
+		t.Errorf("Task after the pause must not have been started.")
+	}
+
+	if _, err := scheduler.ResumeClusterOperation(context.Background(), &pb.ResumeClusterOperationRequest{Id: id}); err != nil {
+		t.Fatalf("ResumeClusterOperation failed: %v", err)
+	}
+	details = waitForClusterOperation(t, scheduler, id, "after pause", "")
+	if details.Error != "" {
+		t.Errorf("resumed ClusterOperation failed: %v", details.Error)
+	}
+}
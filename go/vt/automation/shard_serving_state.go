@@ -0,0 +1,70 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/youtube/vitess/go/vt/topo"
+	"golang.org/x/net/context"
+
+	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+// getShard runs vtctl GetShard and returns the shard record from the topology.
+// Tasks use it to check the serving state of a shard before they change it.
+func getShard(ctx context.Context, vtctldEndpoint, keyspace, shard string) (*pbt.Shard, error) {
+	keyspaceAndShard := fmt.Sprintf("%v/%v", keyspace, shard)
+	output, err := ExecuteVtctl(ctx, vtctldEndpoint, []string{"GetShard", keyspaceAndShard})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shard %v: %v", keyspaceAndShard, err)
+	}
+	result := &pbt.Shard{}
+	if err := json.Unmarshal([]byte(output), result); err != nil {
+		return nil, fmt.Errorf("failed to parse shard %v: %v output: %v", keyspaceAndShard, err, output)
+	}
+	return result, nil
+}
+
+// getKeyspace runs vtctl GetKeyspace and returns the keyspace record from the topology.
+func getKeyspace(ctx context.Context, vtctldEndpoint, keyspace string) (*pbt.Keyspace, error) {
+	output, err := ExecuteVtctl(ctx, vtctldEndpoint, []string{"GetKeyspace", keyspace})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keyspace %v: %v", keyspace, err)
+	}
+	result := &pbt.Keyspace{}
+	if err := json.Unmarshal([]byte(output), result); err != nil {
+		return nil, fmt.Errorf("failed to parse keyspace %v: %v output: %v", keyspace, err, output)
+	}
+	return result, nil
+}
+
+// servedFrom returns the keyspace which serves "tabletType" in "cell" for
+// "keyspace", or "" if "keyspace" serves it itself.
+// An empty "cell" means any cell.
+func servedFrom(keyspace *pbt.Keyspace, tabletType pbt.TabletType, cell string) string {
+	for _, sf := range keyspace.ServedFroms {
+		if sf.TabletType != tabletType {
+			continue
+		}
+		if cell == "" || topo.InCellList(cell, sf.Cells) {
+			return sf.Keyspace
+		}
+	}
+	return ""
+}
+
+// servesType returns true if "shard" serves "tabletType" in "cell".
+// An empty "cell" means any cell.
+func servesType(shard *pbt.Shard, tabletType pbt.TabletType, cell string) bool {
+	for _, st := range shard.ServedTypes {
+		if st.TabletType != tabletType {
+			continue
+		}
+		return cell == "" || topo.InCellList(cell, st.Cells)
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
+	"golang.org/x/net/context"
+)
+
+// StopFilteredReplicationTask removes all SourceShards of a destination shard
+// and runs vtctl RefreshState on its master. This stops the filtered replication
+// from the source shards. The shard must not serve any type anymore
+// i.e. all served types must have been migrated back to the source shards first.
+type StopFilteredReplicationTask struct {
+}
+
+// Run is part of the Task interface.
+func (t *StopFilteredReplicationTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
+	keyspaceAndShard := fmt.Sprintf("%v/%v", parameters["keyspace"], parameters["shard"])
+	vtctldEndpoint := parameters["vtctld_endpoint"]
+
+	shard, err := getShard(context.TODO(), vtctldEndpoint, parameters["keyspace"], parameters["shard"])
+	if err != nil {
+		return nil, "", err
+	}
+	if len(shard.ServedTypes) > 0 {
+		return nil, "", fmt.Errorf("shard %v still serves %v. Migrate all served types back to the source shards first", keyspaceAndShard, shard.ServedTypes)
+	}
+
+	var output bytes.Buffer
+	for _, sourceShard := range shard.SourceShards {
+		out, err := ExecuteVtctl(context.TODO(), vtctldEndpoint,
+			[]string{"SourceShardDelete", keyspaceAndShard, strconv.FormatUint(uint64(sourceShard.Uid), 10)})
+		output.WriteString(out)
+		if err != nil {
+			return nil, output.String(), err
+		}
+	}
+	// The master stops its binlog players once it sees that there are no SourceShards anymore.
+	// We refresh it even if the SourceShards were already gone, because a previous run may have
+	// failed before it got here.
+	if shard.MasterAlias != nil {
+		out, err := ExecuteVtctl(context.TODO(), vtctldEndpoint,
+			[]string{"RefreshState", topoproto.TabletAliasString(shard.MasterAlias)})
+		output.WriteString(out)
+		if err != nil {
+			return nil, output.String(), err
+		}
+	}
+	return nil, output.String(), nil
+}
+
+// RequiredParameters is part of the Task interface.
+func (t *StopFilteredReplicationTask) RequiredParameters() []string {
+	return []string{"keyspace", "shard", "vtctld_endpoint"}
+}
+
+// OptionalParameters is part of the Task interface.
+func (t *StopFilteredReplicationTask) OptionalParameters() []string {
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/vtctl/fakevtctlclient"
+	"github.com/youtube/vitess/go/vt/vtctl/vtctlclient"
+
+	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+func TestStopFilteredReplicationTask(t *testing.T) {
+	fake := fakevtctlclient.NewFakeVtctlClient()
+	vtctlclient.RegisterFactory("fake", fake.FakeVtctlClientFactory)
+	defer vtctlclient.UnregisterFactoryForTest("fake")
+	flag.Set("vtctl_client_protocol", "fake")
+	task := &StopFilteredReplicationTask{}
+
+	registerGetShardResult(t, fake, "test_keyspace/10-18", &pbt.Shard{
+		MasterAlias: &pbt.TabletAlias{Cell: "cell1", Uid: 100},
+		SourceShards: []*pbt.Shard_SourceShard{
+			{Uid: 0, Keyspace: "test_keyspace", Shard: "10-20"},
+		},
+	})
+	fake.RegisterResult([]string{"SourceShardDelete", "test_keyspace/10-18", "0"},
+		"",  // No output.
+		nil) // No error.
+	fake.RegisterResult([]string{"RefreshState", "cell1-0000000100"},
+		"",  // No output.
+		nil) // No error.
+	parameters := map[string]string{
+		"keyspace":        "test_keyspace",
+		"shard":           "10-18",
+		"vtctld_endpoint": "localhost:15000",
+	}
+	testTask(t, "StopFilteredReplication", task, parameters)
+
+	// A shard which still serves a type must not lose its SourceShards.
+	registerGetShardResult(t, fake, "test_keyspace/18-20", &pbt.Shard{
+		ServedTypes: []*pbt.Shard_ServedType{
+			{TabletType: pbt.TabletType_RDONLY},
+		},
+		SourceShards: []*pbt.Shard_SourceShard{
+			{Uid: 0, Keyspace: "test_keyspace", Shard: "10-20"},
+		},
+	})
+	parameters["shard"] = "18-20"
+	if _, _, err := task.Run(parameters); err == nil {
+		t.Errorf("StopFilteredReplication should fail for a shard which still serves rdonly")
+	}
+}
@@ -5,10 +5,14 @@
 package automation
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 
+	"github.com/youtube/vitess/go/vt/vtctl/fakevtctlclient"
+
 	pb "github.com/youtube/vitess/go/vt/proto/automation"
+	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
 )
 
 func testingTaskCreator(taskName string) Task {
@@ -22,6 +26,10 @@ func testingTaskCreator(taskName string) Task {
 		return &TestingEmitEchoTask{}
 	case "TestingEmitEchoFailEchoTask":
 		return &TestingEmitEchoFailEchoTask{}
+	case "TestingEmitPauseEchoTask":
+		return &TestingEmitPauseEchoTask{}
+	case "PauseTask":
+		return &PauseTask{}
 	default:
 		return nil
 	}
@@ -102,6 +110,27 @@ func (t *TestingEmitEchoFailEchoTask) OptionalParameters() []string {
 	return nil
 }
 
+// TestingEmitPauseEchoTask is used only for testing.
+// It emits two sequential tasks: Pause, Echo.
+type TestingEmitPauseEchoTask struct {
+}
+
+func (t *TestingEmitPauseEchoTask) Run(parameters map[string]string) (newTasks []*pb.TaskContainer, output string, err error) {
+	newTasks = []*pb.TaskContainer{
+		NewTaskContainerWithSingleTask("PauseTask", map[string]string{"next_step": "TestingEchoTask"}),
+		NewTaskContainerWithSingleTask("TestingEchoTask", parameters),
+	}
+	return newTasks, "emitted tasks: Pause, Echo", nil
+}
+
+func (t *TestingEmitPauseEchoTask) RequiredParameters() []string {
+	return []string{"echo_text"}
+}
+
+func (t *TestingEmitPauseEchoTask) OptionalParameters() []string {
+	return nil
+}
+
 // testTask runs the given tasks and checks if it succeeds.
 // To make the task succeed you have to register the result with a fake first
 // e.g. see migrate_served_types_task_test.go for an example.
@@ -119,3 +148,15 @@ func testTask(t *testing.T, test string, task Task, parameters map[string]string
 		t.Errorf("%s: Task should not fail: %v", err, test)
 	}
 }
+
+// registerGetShardResult registers "shard" as result of vtctl GetShard with the fake.
+// Tasks which check the serving state of a shard call GetShard first.
+func registerGetShardResult(t *testing.T, fake *fakevtctlclient.FakeVtctlClient, keyspaceAndShard string, shard *pbt.Shard) {
+	data, err := json.MarshalIndent(shard, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal shard: %v", err)
+	}
+	fake.RegisterResult([]string{"GetShard", keyspaceAndShard},
+		string(data),
+		nil) // No error.
+}
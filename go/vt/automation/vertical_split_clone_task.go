@@ -0,0 +1,36 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"fmt"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+	"golang.org/x/net/context"
+)
+
+// VerticalSplitCloneTask runs VerticalSplitClone on a remote vtworker to copy
+// tables from the source keyspace into the destination keyspace.
+// It populates the blp_checkpoint table, i.e. filtered replication starts afterwards.
+type VerticalSplitCloneTask struct {
+}
+
+// Run is part of the Task interface.
+func (t *VerticalSplitCloneTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
+	keyspaceAndShard := fmt.Sprintf("%v/%v", parameters["keyspace"], parameters["shard"])
+	args := []string{"VerticalSplitClone", "--tables=" + parameters["tables"], "--strategy=-populate_blp_checkpoint", keyspaceAndShard}
+	output, err := executeVtworkerOrDryRun(context.TODO(), parameters, args)
+	return nil, output, err
+}
+
+// RequiredParameters is part of the Task interface.
+func (t *VerticalSplitCloneTask) RequiredParameters() []string {
+	return []string{"keyspace", "shard", "tables", "vtworker_endpoint"}
+}
+
+// OptionalParameters is part of the Task interface.
+func (t *VerticalSplitCloneTask) OptionalParameters() []string {
+	return []string{"dry_run"}
+}
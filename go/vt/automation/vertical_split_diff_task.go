@@ -0,0 +1,34 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package automation
+
+import (
+	"fmt"
+
+	pb "github.com/youtube/vitess/go/vt/proto/automation"
+	"golang.org/x/net/context"
+)
+
+// VerticalSplitDiffTask runs VerticalSplitDiff on a remote vtworker to compare
+// the moved tables in the destination keyspace against the source keyspace.
+type VerticalSplitDiffTask struct {
+}
+
+// Run is part of the Task interface.
+func (t *VerticalSplitDiffTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
+	keyspaceAndShard := fmt.Sprintf("%v/%v", parameters["keyspace"], parameters["shard"])
+	output, err := executeVtworkerOrDryRun(context.TODO(), parameters, []string{"VerticalSplitDiff", keyspaceAndShard})
+	return nil, output, err
+}
+
+// RequiredParameters is part of the Task interface.
+func (t *VerticalSplitDiffTask) RequiredParameters() []string {
+	return []string{"keyspace", "shard", "vtworker_endpoint"}
+}
+
+// OptionalParameters is part of the Task interface.
+func (t *VerticalSplitDiffTask) OptionalParameters() []string {
+	return []string{"dry_run"}
+}
@@ -19,7 +19,7 @@ type WaitForFilteredReplicationTask struct {
 // Run is part of the Task interface.
 func (t *WaitForFilteredReplicationTask) Run(parameters map[string]string) ([]*pb.TaskContainer, string, error) {
 	keyspaceAndShard := fmt.Sprintf("%v/%v", parameters["keyspace"], parameters["shard"])
-	output, err := ExecuteVtctl(context.TODO(), parameters["vtctld_endpoint"],
+	output, err := executeVtctlOrDryRun(context.TODO(), parameters,
 		[]string{"WaitForFilteredReplication", "-max_delay", parameters["max_delay"], keyspaceAndShard})
 	return nil, output, err
 }
@@ -31,5 +31,5 @@ func (t *WaitForFilteredReplicationTask) RequiredParameters() []string {
 
 // OptionalParameters is part of the Task interface.
 func (t *WaitForFilteredReplicationTask) OptionalParameters() []string {
-	return nil
+	return []string{"dry_run"}
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/youtube/vitess/go/stats"
 	"github.com/youtube/vitess/go/sync2"
 	"github.com/youtube/vitess/go/vt/binlog/proto"
+	"github.com/youtube/vitess/go/vt/keyresolver"
 	"github.com/youtube/vitess/go/vt/mysqlctl"
 	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 
@@ -71,6 +72,15 @@ type BinlogStreamer struct {
 	sendTransaction sendTransactionFunc
 
 	conn *mysqlctl.SlaveConnection
+
+	// tableSchemas caches the table definitions used to convert the
+	// row based replication events. It is cleared on DDLs.
+	tableSchemas map[string]*tableSchema
+
+	// keyResolverFactory is set for the key range streams, to
+	// annotate the statements of the row based replication events
+	// with the keyspace id of their row.
+	keyResolverFactory keyresolver.Factory
 }
 
 // NewBinlogStreamer creates a BinlogStreamer.
@@ -148,6 +158,10 @@ func (bls *BinlogStreamer) parseEvents(ctx *sync2.ServiceContext, events <-chan
 	var autocommit = true
 	var err error
 
+	// tableMaps are the TABLE_MAP_EVENTs that describe the tables
+	// the following row events apply to, by table ID.
+	tableMaps := make(map[uint64]*proto.TableMap)
+
 	// A begin can be triggered either by a BEGIN query, or by a GTID_EVENT.
 	begin := func() {
 		if statements != nil {
@@ -266,6 +280,32 @@ func (bls *BinlogStreamer) parseEvents(ctx *sync2.ServiceContext, events <-chan
 				Category: pb.BinlogTransaction_Statement_BL_SET,
 				Sql:      fmt.Sprintf("SET @@RAND_SEED1=%d, @@RAND_SEED2=%d", seed1, seed2),
 			})
+		case ev.IsTableMap(): // TABLE_MAP_EVENT
+			tm, err := ev.TableMap(format)
+			if err != nil {
+				return pos, fmt.Errorf("can't parse TABLE_MAP_EVENT: %v, event data: %#v", err, ev)
+			}
+			tableMaps[ev.TableID(format)] = tm
+		case ev.IsWriteRows() || ev.IsUpdateRows() || ev.IsDeleteRows(): // *_ROWS_EVENT
+			tableID := ev.TableID(format)
+			tm, ok := tableMaps[tableID]
+			if !ok {
+				return pos, fmt.Errorf("got a rows event for unknown table id %v, event data: %#v", tableID, ev)
+			}
+			if tm.Database != "" && tm.Database != bls.dbname {
+				// Skip cross-db statements.
+				continue
+			}
+			rowStatements, err := bls.rowsStatements(ev, format, tm)
+			if err != nil {
+				return pos, fmt.Errorf("can't parse rows event: %v, event data: %#v", err, ev)
+			}
+			setTimestamp := &pb.BinlogTransaction_Statement{
+				Category: pb.BinlogTransaction_Statement_BL_SET,
+				Sql:      fmt.Sprintf("SET TIMESTAMP=%d", ev.Timestamp()),
+			}
+			statements = append(statements, setTimestamp)
+			statements = append(statements, rowStatements...)
 		case ev.IsQuery(): // QUERY_EVENT
 			// Extract the query string and group into transactions.
 			q, err := ev.Query(format)
@@ -291,6 +331,11 @@ func (bls *BinlogStreamer) parseEvents(ctx *sync2.ServiceContext, events <-chan
 					// Skip cross-db statements.
 					continue
 				}
+				if cat == pb.BinlogTransaction_Statement_BL_DDL {
+					// The schema used to convert row events may
+					// have changed.
+					bls.tableSchemas = nil
+				}
 				setTimestamp := &pb.BinlogTransaction_Statement{
 					Category: pb.BinlogTransaction_Statement_BL_SET,
 					Sql:      fmt.Sprintf("SET TIMESTAMP=%d", ev.Timestamp()),
@@ -0,0 +1,318 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package binlog
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/binlog/proto"
+	"github.com/youtube/vitess/go/vt/keyresolver"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/sqlannotation"
+
+	pb "github.com/youtube/vitess/go/vt/proto/binlogdata"
+)
+
+// This file converts the row based replication events into DML
+// statements, so the rest of the stream (filters, players) doesn't
+// need to know which binlog_format the master uses. For key range
+// streams, the statements are annotated with the keyspace id computed
+// from the typed row images, as the statements of the applications.
+
+// tableSchema is what we know of a table of our database, to convert
+// its row based replication events.
+type tableSchema struct {
+	td *myproto.TableDefinition
+
+	// types and unsigned describe each column, from the CREATE
+	// TABLE of td.
+	types    []string
+	unsigned []bool
+
+	// keyResolver computes the keyspace id of the rows, if the
+	// stream needs it. It is created at the first row, and
+	// keyResolverErr is set if it cannot be.
+	keyResolver    keyresolver.Resolver
+	keyResolverErr error
+}
+
+// tableSchema returns the definition of a table of our database. The
+// row events only have column types, so we get the column names, the
+// primary key and the signedness of the integers from the schema. It
+// is cached until the next DDL.
+func (bls *BinlogStreamer) tableSchema(name string) (*tableSchema, error) {
+	if ts, ok := bls.tableSchemas[name]; ok {
+		return ts, nil
+	}
+	if bls.mysqld == nil {
+		return nil, fmt.Errorf("no mysqld to get the schema of table %v", name)
+	}
+	sd, err := bls.mysqld.GetSchema(bls.dbname, []string{"^" + regexp.QuoteMeta(name) + "$"}, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("can't get schema of table %v: %v", name, err)
+	}
+	if len(sd.TableDefinitions) != 1 {
+		return nil, fmt.Errorf("can't find table %v in database %v", name, bls.dbname)
+	}
+	if bls.tableSchemas == nil {
+		bls.tableSchemas = make(map[string]*tableSchema)
+	}
+	td := sd.TableDefinitions[0]
+	types, unsigned := columnTypes(td)
+	ts := &tableSchema{
+		td:       td,
+		types:    types,
+		unsigned: unsigned,
+	}
+	bls.tableSchemas[name] = ts
+	return ts, nil
+}
+
+// columnTypes returns the type of each column of the table, like
+// "bigint", and whether it is unsigned, from the column definitions
+// of its CREATE TABLE.
+func columnTypes(td *myproto.TableDefinition) ([]string, []bool) {
+	lineTypes := make(map[string]string)
+	lineUnsigned := make(map[string]bool)
+	for _, line := range strings.Split(td.Schema, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "`") {
+			continue
+		}
+		i := strings.Index(line[1:], "`")
+		if i < 0 {
+			continue
+		}
+		name, definition := line[1:i+1], strings.TrimSpace(line[i+2:])
+		if j := strings.IndexAny(definition, "( ,"); j >= 0 {
+			lineTypes[name] = strings.ToLower(definition[:j])
+		} else {
+			lineTypes[name] = strings.ToLower(definition)
+		}
+		lineUnsigned[name] = strings.Contains(definition, " unsigned")
+	}
+	types := make([]string, len(td.Columns))
+	unsigned := make([]bool, len(td.Columns))
+	for i, col := range td.Columns {
+		types[i] = lineTypes[col]
+		unsigned[i] = lineUnsigned[col]
+	}
+	return types, unsigned
+}
+
+// binlogTypes are the types the TABLE_MAP_EVENT can use for the
+// column types of a CREATE TABLE. The types we don't know are not
+// checked.
+var binlogTypes = map[string][]byte{
+	"tinyint":    {1},
+	"smallint":   {2},
+	"mediumint":  {9},
+	"int":        {3},
+	"integer":    {3},
+	"bigint":     {8},
+	"float":      {4},
+	"double":     {5},
+	"real":       {5},
+	"decimal":    {246},
+	"numeric":    {246},
+	"date":       {10, 14},
+	"time":       {11, 19},
+	"datetime":   {12, 18},
+	"timestamp":  {7, 17},
+	"year":       {13},
+	"char":       {254},
+	"binary":     {254},
+	"enum":       {254, 247},
+	"set":        {254, 248},
+	"varchar":    {15, 253},
+	"varbinary":  {15, 253},
+	"bit":        {16},
+	"tinyblob":   {252},
+	"blob":       {252},
+	"mediumblob": {252},
+	"longblob":   {252},
+	"tinytext":   {252},
+	"text":       {252},
+	"mediumtext": {252},
+	"longtext":   {252},
+}
+
+// checkTableMap returns an error if the columns of the table map
+// don't match the schema. The schema is the current one, while the
+// table map describes the table when the event was logged: if they
+// differ, we can't know which column is which.
+func (ts *tableSchema) checkTableMap(tm *proto.TableMap) error {
+	if len(ts.td.Columns) != len(tm.Types) {
+		return fmt.Errorf("table %v has %v columns, but the binlog has %v: the schema changed since the event was logged", tm.Name, len(ts.td.Columns), len(tm.Types))
+	}
+	for c, typ := range ts.types {
+		codes, ok := binlogTypes[typ]
+		if !ok || bytes.IndexByte(codes, tm.Types[c]) >= 0 {
+			continue
+		}
+		return fmt.Errorf("column %v of table %v is a %v, but the binlog has type %v: the schema changed since the event was logged", ts.td.Columns[c], tm.Name, typ, tm.Types[c])
+	}
+	return nil
+}
+
+// keyspaceID returns the keyspace id of a row image.
+func (ts *tableSchema) keyspaceID(factory keyresolver.Factory, row []sqltypes.Value) ([]byte, error) {
+	if ts.keyResolver == nil && ts.keyResolverErr == nil {
+		ts.keyResolver, ts.keyResolverErr = factory(ts.td.Name, ts.td.Columns)
+	}
+	if ts.keyResolverErr != nil {
+		return nil, ts.keyResolverErr
+	}
+	return ts.keyResolver.KeyspaceID(row)
+}
+
+// annotate adds the keyspace id of the row to a statement of a row
+// based replication event, for the key range filter. The keyspace id
+// is computed from the image of the row before the change if it has
+// one, and after it otherwise. An error stops the stream: the filter
+// would drop a statement without a keyspace id.
+func (bls *BinlogStreamer) annotate(ts *tableSchema, sql string, row proto.Row) (string, error) {
+	var keyspaceID []byte
+	err := fmt.Errorf("no row image")
+	if row.Identify != nil {
+		keyspaceID, err = ts.keyspaceID(bls.keyResolverFactory, row.Identify)
+	}
+	if err != nil && row.Data != nil {
+		keyspaceID, err = ts.keyspaceID(bls.keyResolverFactory, row.Data)
+	}
+	if err != nil {
+		updateStreamErrors.Add("KeyspaceIdFromRow", 1)
+		return "", fmt.Errorf("cannot compute the keyspace id of a row of table %v: %v", ts.td.Name, err)
+	}
+	return sqlannotation.AddKeyspaceID(sql, keyspaceID), nil
+}
+
+// rowsStatements decodes a {WRITE,UPDATE,DELETE}_ROWS_EVENT, and
+// returns one DML statement per row.
+func (bls *BinlogStreamer) rowsStatements(ev proto.BinlogEvent, format proto.BinlogFormat, tm *proto.TableMap) ([]*pb.BinlogTransaction_Statement, error) {
+	ts, err := bls.tableSchema(tm.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.checkTableMap(tm); err != nil {
+		return nil, err
+	}
+	if tm.Unsigned == nil {
+		tm.Unsigned = ts.unsigned
+	}
+	td := ts.td
+
+	rows, err := ev.Rows(format, tm)
+	if err != nil {
+		return nil, err
+	}
+	statements := make([]*pb.BinlogTransaction_Statement, 0, len(rows.Rows))
+	for _, row := range rows.Rows {
+		buf := &bytes.Buffer{}
+		switch {
+		case ev.IsWriteRows():
+			fmt.Fprintf(buf, "INSERT INTO `%v` (", tm.Name)
+			writeColumnNames(buf, td.Columns, rows.DataColumns)
+			buf.WriteString(") VALUES (")
+			writeValues(buf, row.Data, rows.DataColumns)
+			buf.WriteString(")")
+		case ev.IsUpdateRows():
+			fmt.Fprintf(buf, "UPDATE `%v` SET ", tm.Name)
+			writeAssignments(buf, td.Columns, row.Data, rows.DataColumns)
+			buf.WriteString(" WHERE ")
+			writeWhere(buf, td, row.Identify, rows.IdentifyColumns)
+		default:
+			fmt.Fprintf(buf, "DELETE FROM `%v` WHERE ", tm.Name)
+			writeWhere(buf, td, row.Identify, rows.IdentifyColumns)
+		}
+		sql := buf.String()
+		if bls.keyResolverFactory != nil {
+			if sql, err = bls.annotate(ts, sql, row); err != nil {
+				return nil, err
+			}
+		}
+		statements = append(statements, &pb.BinlogTransaction_Statement{
+			Category: pb.BinlogTransaction_Statement_BL_DML,
+			Sql:      sql,
+		})
+	}
+	return statements, nil
+}
+
+func writeColumnNames(buf *bytes.Buffer, columns []string, present proto.Bitmap) {
+	sep := ""
+	for c, name := range columns {
+		if !present.Bit(c) {
+			continue
+		}
+		fmt.Fprintf(buf, "%v`%v`", sep, name)
+		sep = ", "
+	}
+}
+
+func writeValues(buf *bytes.Buffer, values []sqltypes.Value, present proto.Bitmap) {
+	sep := ""
+	for c, value := range values {
+		if !present.Bit(c) {
+			continue
+		}
+		buf.WriteString(sep)
+		value.EncodeSQL(buf)
+		sep = ", "
+	}
+}
+
+func writeAssignments(buf *bytes.Buffer, columns []string, values []sqltypes.Value, present proto.Bitmap) {
+	sep := ""
+	for c, name := range columns {
+		if !present.Bit(c) {
+			continue
+		}
+		fmt.Fprintf(buf, "%v`%v`=", sep, name)
+		values[c].EncodeSQL(buf)
+		sep = ", "
+	}
+}
+
+// writeWhere identifies the row by its primary key if the image has
+// it, or by all the columns of the image otherwise.
+func writeWhere(buf *bytes.Buffer, td *myproto.TableDefinition, values []sqltypes.Value, present proto.Bitmap) {
+	where := make([]bool, len(td.Columns))
+	hasPK := len(td.PrimaryKeyColumns) > 0
+	for _, pk := range td.PrimaryKeyColumns {
+		found := false
+		for c, name := range td.Columns {
+			if name == pk && present.Bit(c) {
+				where[c] = true
+				found = true
+			}
+		}
+		hasPK = hasPK && found
+	}
+	if !hasPK {
+		for c := range td.Columns {
+			where[c] = present.Bit(c)
+		}
+	}
+
+	sep := ""
+	for c, name := range td.Columns {
+		if !where[c] {
+			continue
+		}
+		fmt.Fprintf(buf, "%v`%v`", sep, name)
+		if values[c].IsNull() {
+			buf.WriteString(" IS NULL")
+		} else {
+			buf.WriteString("=")
+			values[c].EncodeSQL(buf)
+		}
+		sep = " AND "
+	}
+}
@@ -13,12 +13,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/youtube/vitess/go/sqltypes"
 	"github.com/youtube/vitess/go/sync2"
 	"github.com/youtube/vitess/go/vt/binlog/proto"
+	"github.com/youtube/vitess/go/vt/keyresolver"
 	"github.com/youtube/vitess/go/vt/mysqlctl"
 	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/vtgate/planbuilder"
 
 	pb "github.com/youtube/vitess/go/vt/proto/binlogdata"
+	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
 )
 
 // fakeEvent implements proto.BinlogEvent.
@@ -32,6 +36,10 @@ func (fakeEvent) IsGTID() bool                    { return false }
 func (fakeEvent) IsRotate() bool                  { return false }
 func (fakeEvent) IsIntVar() bool                  { return false }
 func (fakeEvent) IsRand() bool                    { return false }
+func (fakeEvent) IsTableMap() bool                { return false }
+func (fakeEvent) IsWriteRows() bool               { return false }
+func (fakeEvent) IsUpdateRows() bool              { return false }
+func (fakeEvent) IsDeleteRows() bool              { return false }
 func (fakeEvent) HasGTID(proto.BinlogFormat) bool { return true }
 func (fakeEvent) Timestamp() uint32               { return 1407805592 }
 func (fakeEvent) Format() (proto.BinlogFormat, error) {
@@ -50,6 +58,13 @@ func (fakeEvent) IntVar(proto.BinlogFormat) (string, uint64, error) {
 func (fakeEvent) Rand(proto.BinlogFormat) (uint64, uint64, error) {
 	return 0, 0, errors.New("not a rand")
 }
+func (fakeEvent) TableID(proto.BinlogFormat) uint64 { return 0 }
+func (fakeEvent) TableMap(proto.BinlogFormat) (*proto.TableMap, error) {
+	return nil, errors.New("not a table map")
+}
+func (fakeEvent) Rows(proto.BinlogFormat, *proto.TableMap) (proto.Rows, error) {
+	return proto.Rows{}, errors.New("not a rows event")
+}
 func (ev fakeEvent) StripChecksum(proto.BinlogFormat) (proto.BinlogEvent, []byte, error) {
 	return ev, nil, nil
 }
@@ -139,6 +154,38 @@ func (ev invalidIntVarEvent) StripChecksum(proto.BinlogFormat) (proto.BinlogEven
 	return ev, nil, nil
 }
 
+type tableMapEvent struct {
+	fakeEvent
+	tableMap proto.TableMap
+}
+
+func (tableMapEvent) IsTableMap() bool                  { return true }
+func (tableMapEvent) TableID(proto.BinlogFormat) uint64 { return 12 }
+func (ev tableMapEvent) TableMap(proto.BinlogFormat) (*proto.TableMap, error) {
+	tm := ev.tableMap
+	return &tm, nil
+}
+func (ev tableMapEvent) StripChecksum(proto.BinlogFormat) (proto.BinlogEvent, []byte, error) {
+	return ev, nil, nil
+}
+
+type rowsEvent struct {
+	fakeEvent
+	write, update bool
+	rows          proto.Rows
+}
+
+func (ev rowsEvent) IsWriteRows() bool              { return ev.write }
+func (ev rowsEvent) IsUpdateRows() bool             { return ev.update }
+func (ev rowsEvent) IsDeleteRows() bool             { return !ev.write && !ev.update }
+func (rowsEvent) TableID(proto.BinlogFormat) uint64 { return 12 }
+func (ev rowsEvent) Rows(proto.BinlogFormat, *proto.TableMap) (proto.Rows, error) {
+	return ev.rows, nil
+}
+func (ev rowsEvent) StripChecksum(proto.BinlogFormat) (proto.BinlogEvent, []byte, error) {
+	return ev, nil, nil
+}
+
 // sample MariaDB event data
 var (
 	mariadbRotateEvent         = mysqlctl.NewMariadbBinlogEvent([]byte{0x0, 0x0, 0x0, 0x0, 0x4, 0x88, 0xf3, 0x0, 0x0, 0x33, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x20, 0x0, 0x4, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x76, 0x74, 0x2d, 0x30, 0x30, 0x30, 0x30, 0x30, 0x36, 0x32, 0x33, 0x34, 0x34, 0x2d, 0x62, 0x69, 0x6e, 0x2e, 0x30, 0x30, 0x30, 0x30, 0x30, 0x31})
@@ -1041,3 +1088,306 @@ func TestGetStatementCategory(t *testing.T) {
 		}
 	}
 }
+
+func TestBinlogStreamerParseEventsRows(t *testing.T) {
+	bothColumns := proto.NewBitmap([]byte{0x3}, 2)
+	idColumn := proto.NewBitmap([]byte{0x1}, 2)
+	id := sqltypes.MakeNumeric([]byte("1"))
+	input := []proto.BinlogEvent{
+		rotateEvent{},
+		formatEvent{},
+		queryEvent{query: proto.Query{Database: "vt_test_keyspace", Sql: "BEGIN"}},
+		tableMapEvent{tableMap: proto.TableMap{Database: "vt_test_keyspace", Name: "vt_a", Types: []byte{8, 15}}},
+		rowsEvent{write: true, rows: proto.Rows{
+			DataColumns: bothColumns,
+			Rows: []proto.Row{
+				{Data: []sqltypes.Value{id, sqltypes.MakeString([]byte("a'b"))}},
+			},
+		}},
+		rowsEvent{update: true, rows: proto.Rows{
+			IdentifyColumns: bothColumns,
+			DataColumns:     bothColumns,
+			Rows: []proto.Row{
+				{
+					Identify: []sqltypes.Value{id, sqltypes.MakeString([]byte("a'b"))},
+					Data:     []sqltypes.Value{id, {}},
+				},
+			},
+		}},
+		rowsEvent{rows: proto.Rows{
+			IdentifyColumns: idColumn,
+			Rows: []proto.Row{
+				{Identify: []sqltypes.Value{id, {}}},
+			},
+		}},
+		// Row events of other databases are skipped.
+		tableMapEvent{tableMap: proto.TableMap{Database: "other", Name: "vt_b", Types: []byte{8}}},
+		rowsEvent{write: true, rows: proto.Rows{}},
+		xidEvent{},
+	}
+
+	events := make(chan proto.BinlogEvent)
+
+	want := []pb.BinlogTransaction{
+		pb.BinlogTransaction{
+			Statements: []*pb.BinlogTransaction_Statement{
+				{Category: pb.BinlogTransaction_Statement_BL_SET, Sql: "SET TIMESTAMP=1407805592"},
+				{Category: pb.BinlogTransaction_Statement_BL_DML, Sql: "INSERT INTO `vt_a` (`id`, `msg`) VALUES (1, 'a\\'b')"},
+				{Category: pb.BinlogTransaction_Statement_BL_SET, Sql: "SET TIMESTAMP=1407805592"},
+				{Category: pb.BinlogTransaction_Statement_BL_DML, Sql: "UPDATE `vt_a` SET `id`=1, `msg`=null WHERE `id`=1"},
+				{Category: pb.BinlogTransaction_Statement_BL_SET, Sql: "SET TIMESTAMP=1407805592"},
+				{Category: pb.BinlogTransaction_Statement_BL_DML, Sql: "DELETE FROM `vt_a` WHERE `id`=1"},
+			},
+			Timestamp: 1407805592,
+			TransactionId: myproto.EncodeGTID(myproto.MariadbGTID{
+				Domain:   0,
+				Server:   62344,
+				Sequence: 0x0d,
+			}),
+		},
+	}
+	var got []pb.BinlogTransaction
+	sendTransaction := func(trans *pb.BinlogTransaction) error {
+		got = append(got, *trans)
+		return nil
+	}
+	mysqld := mysqlctl.NewFakeMysqlDaemon(nil)
+	mysqld.Schema = &myproto.SchemaDefinition{
+		TableDefinitions: []*myproto.TableDefinition{
+			{
+				Name:              "vt_a",
+				Schema:            "CREATE TABLE `vt_a` (\n  `id` bigint(20) unsigned NOT NULL,\n  `msg` varchar(64) DEFAULT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB",
+				Columns:           []string{"id", "msg"},
+				PrimaryKeyColumns: []string{"id"},
+				Type:              myproto.TableBaseTable,
+			},
+		},
+	}
+	bls := NewBinlogStreamer("vt_test_keyspace", mysqld, nil, myproto.ReplicationPosition{}, sendTransaction)
+
+	go sendTestEvents(events, input)
+	svm := &sync2.ServiceManager{}
+	svm.Go(func(ctx *sync2.ServiceContext) error {
+		_, err := bls.parseEvents(ctx, events)
+		return err
+	})
+	if err := svm.Join(); err != ErrServerEOF {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("binlogConnStreamer.parseEvents(): got %v, want %v", got, want)
+	}
+}
+
+func TestColumnTypes(t *testing.T) {
+	td := &myproto.TableDefinition{
+		Schema:  "CREATE TABLE `t` (\n  `a` int(10) unsigned NOT NULL,\n  `b` int(11) DEFAULT NULL,\n  `c` bigint(20) unsigned DEFAULT NULL,\n  `d` TEXT,\n  PRIMARY KEY (`a`)\n) ENGINE=InnoDB",
+		Columns: []string{"a", "b", "c", "d"},
+	}
+	types, unsigned := columnTypes(td)
+	if want := []string{"int", "int", "bigint", "text"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("columnTypes() types = %v, want %v", types, want)
+	}
+	if want := []bool{true, false, true, false}; !reflect.DeepEqual(unsigned, want) {
+		t.Errorf("columnTypes() unsigned = %v, want %v", unsigned, want)
+	}
+}
+
+func TestCheckTableMap(t *testing.T) {
+	td := &myproto.TableDefinition{
+		Name:    "t",
+		Schema:  "CREATE TABLE `t` (\n  `id` bigint(20) NOT NULL,\n  `msg` varchar(64) DEFAULT NULL,\n  `g` geometry DEFAULT NULL\n) ENGINE=InnoDB",
+		Columns: []string{"id", "msg", "g"},
+	}
+	types, unsigned := columnTypes(td)
+	ts := &tableSchema{td: td, types: types, unsigned: unsigned}
+	testcases := []struct {
+		types []byte
+		err   string
+	}{{
+		types: []byte{8, 15, 255},
+	}, {
+		// a column was added since the event was logged
+		types: []byte{8, 15},
+		err:   "table t has 3 columns, but the binlog has 2",
+	}, {
+		// the columns were swapped since the event was logged
+		types: []byte{15, 8, 255},
+		err:   "column id of table t is a bigint, but the binlog has type 15",
+	}}
+	for _, tcase := range testcases {
+		err := ts.checkTableMap(&proto.TableMap{Name: "t", Types: tcase.types})
+		if tcase.err == "" {
+			if err != nil {
+				t.Errorf("checkTableMap(%v) failed: %v", tcase.types, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tcase.err) {
+			t.Errorf("checkTableMap(%v) returned %v, want error containing %v", tcase.types, err, tcase.err)
+		}
+	}
+}
+
+func TestBinlogStreamerParseEventsRowsKeyspaceID(t *testing.T) {
+	schema, err := planbuilder.NewSchema([]byte(`{
+  "Keyspaces": {
+    "ks": {
+      "Sharded": true,
+      "Vindexes": {"user_index": {"Type": "numeric"}},
+      "Classes": {"user": {"ColVindexes": [{"Col": "user_id", "Name": "user_index"}]}},
+      "Tables": {"vt_a": "user"}
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatalf("NewSchema failed: %v", err)
+	}
+	testcases := []struct {
+		name    string
+		factory keyresolver.Factory
+	}{{
+		name: "sharding column",
+		factory: func(table string, columns []string) (keyresolver.Resolver, error) {
+			return keyresolver.NewV2Resolver("user_id", pbt.KeyspaceIdType_UINT64, table, columns)
+		},
+	}, {
+		name: "vindex",
+		factory: func(table string, columns []string) (keyresolver.Resolver, error) {
+			return keyresolver.NewV3Resolver(schema, "ks", table, columns)
+		},
+	}}
+
+	allColumns := proto.NewBitmap([]byte{0x7}, 3)
+	row := func(id, userID, msg string) []sqltypes.Value {
+		return []sqltypes.Value{
+			sqltypes.MakeNumeric([]byte(id)),
+			sqltypes.MakeNumeric([]byte(userID)),
+			sqltypes.MakeString([]byte(msg)),
+		}
+	}
+	for _, tcase := range testcases {
+		input := []proto.BinlogEvent{
+			rotateEvent{},
+			formatEvent{},
+			queryEvent{query: proto.Query{Database: "vt_test_keyspace", Sql: "BEGIN"}},
+			tableMapEvent{tableMap: proto.TableMap{Database: "vt_test_keyspace", Name: "vt_a", Types: []byte{8, 8, 15}}},
+			rowsEvent{write: true, rows: proto.Rows{
+				DataColumns: allColumns,
+				Rows: []proto.Row{
+					{Data: row("1", "72057594037927936", "a")},
+					// a negative value is the two's complement
+					// of the keyspace id, in a signed column
+					{Data: row("2", "-1", "b")},
+				},
+			}},
+			rowsEvent{update: true, rows: proto.Rows{
+				IdentifyColumns: allColumns,
+				DataColumns:     allColumns,
+				Rows: []proto.Row{
+					{
+						Identify: row("1", "72057594037927936", "a"),
+						Data:     row("1", "72057594037927936", "c"),
+					},
+				},
+			}},
+			xidEvent{},
+		}
+		events := make(chan proto.BinlogEvent)
+		var got []string
+		sendTransaction := func(trans *pb.BinlogTransaction) error {
+			for _, statement := range trans.Statements {
+				if statement.Category == pb.BinlogTransaction_Statement_BL_DML {
+					got = append(got, statement.Sql)
+				}
+			}
+			return nil
+		}
+		mysqld := mysqlctl.NewFakeMysqlDaemon(nil)
+		mysqld.Schema = &myproto.SchemaDefinition{
+			TableDefinitions: []*myproto.TableDefinition{
+				{
+					Name:              "vt_a",
+					Schema:            "CREATE TABLE `vt_a` (\n  `id` bigint(20) NOT NULL,\n  `user_id` bigint(20) NOT NULL,\n  `msg` varchar(64) DEFAULT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB",
+					Columns:           []string{"id", "user_id", "msg"},
+					PrimaryKeyColumns: []string{"id"},
+					Type:              myproto.TableBaseTable,
+				},
+			},
+		}
+		bls := NewBinlogStreamer("vt_test_keyspace", mysqld, nil, myproto.ReplicationPosition{}, sendTransaction)
+		bls.keyResolverFactory = tcase.factory
+
+		go sendTestEvents(events, input)
+		svm := &sync2.ServiceManager{}
+		svm.Go(func(ctx *sync2.ServiceContext) error {
+			_, err := bls.parseEvents(ctx, events)
+			return err
+		})
+		if err := svm.Join(); err != ErrServerEOF {
+			t.Errorf("%v: unexpected error: %v", tcase.name, err)
+		}
+
+		want := []string{
+			"INSERT INTO `vt_a` (`id`, `user_id`, `msg`) VALUES (1, 72057594037927936, 'a') /* vtgate:: keyspace_id:0100000000000000 */",
+			"INSERT INTO `vt_a` (`id`, `user_id`, `msg`) VALUES (2, -1, 'b') /* vtgate:: keyspace_id:ffffffffffffffff */",
+			"UPDATE `vt_a` SET `id`=1, `user_id`=72057594037927936, `msg`='c' WHERE `id`=1 /* vtgate:: keyspace_id:0100000000000000 */",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: got %v, want %v", tcase.name, got, want)
+		}
+	}
+}
+
+func TestBinlogStreamerParseEventsRowsKeyspaceIDError(t *testing.T) {
+	input := []proto.BinlogEvent{
+		rotateEvent{},
+		formatEvent{},
+		queryEvent{query: proto.Query{Database: "vt_test_keyspace", Sql: "BEGIN"}},
+		tableMapEvent{tableMap: proto.TableMap{Database: "vt_test_keyspace", Name: "vt_a", Types: []byte{8, 8, 15}}},
+		rowsEvent{write: true, rows: proto.Rows{
+			DataColumns: proto.NewBitmap([]byte{0x7}, 3),
+			Rows: []proto.Row{
+				{Data: []sqltypes.Value{
+					sqltypes.MakeNumeric([]byte("1")),
+					sqltypes.MakeNumeric([]byte("1")),
+					sqltypes.MakeString([]byte("a")),
+				}},
+			},
+		}},
+		xidEvent{},
+	}
+	events := make(chan proto.BinlogEvent)
+	sendTransaction := func(trans *pb.BinlogTransaction) error {
+		t.Errorf("unexpected transaction: %v", trans)
+		return nil
+	}
+	mysqld := mysqlctl.NewFakeMysqlDaemon(nil)
+	mysqld.Schema = &myproto.SchemaDefinition{
+		TableDefinitions: []*myproto.TableDefinition{
+			{
+				Name:              "vt_a",
+				Schema:            "CREATE TABLE `vt_a` (\n  `id` bigint(20) NOT NULL,\n  `user_id` bigint(20) NOT NULL,\n  `msg` varchar(64) DEFAULT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB",
+				Columns:           []string{"id", "user_id", "msg"},
+				PrimaryKeyColumns: []string{"id"},
+				Type:              myproto.TableBaseTable,
+			},
+		},
+	}
+	bls := NewBinlogStreamer("vt_test_keyspace", mysqld, nil, myproto.ReplicationPosition{}, sendTransaction)
+	bls.keyResolverFactory = func(table string, columns []string) (keyresolver.Resolver, error) {
+		return nil, fmt.Errorf("no sharding column in table %v", table)
+	}
+
+	go sendTestEvents(events, input)
+	svm := &sync2.ServiceManager{}
+	svm.Go(func(ctx *sync2.ServiceContext) error {
+		_, err := bls.parseEvents(ctx, events)
+		return err
+	})
+	want := "cannot compute the keyspace id of a row of table vt_a: no sharding column in table vt_a"
+	if err := svm.Join(); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("wrong error, got %v, want something containing %v", err, want)
+	}
+}
@@ -43,6 +43,13 @@ var (
 	BlplQuery = "Query"
 	// BlplTransaction is the key for the stats map.
 	BlplTransaction = "Transaction"
+	// BlplThrottled is the key for the stats map. Its count is the
+	// number of times the player waited because it was throttled.
+	BlplThrottled = "Throttled"
+
+	// throttleCheckInterval is how long a throttled player waits
+	// before checking again.
+	throttleCheckInterval = time.Second
 
 	// flags for the blp_checkpoint table. The database entry is just
 	// a join(",") of these flags.
@@ -63,6 +70,9 @@ type BinlogPlayerStats struct {
 	lastPosition        myproto.ReplicationPosition
 	lastPositionMutex   sync.RWMutex
 	SecondsBehindMaster sync2.AtomicInt64
+
+	// throttledReason is why the player is throttled, or empty
+	throttledReason sync2.AtomicString
 }
 
 // SetLastPosition sets the last replication position.
@@ -79,6 +89,24 @@ func (bps *BinlogPlayerStats) GetLastPosition() myproto.ReplicationPosition {
 	return bps.lastPosition
 }
 
+// SetThrottledReason sets why the player is throttled, "" if it isn't.
+func (bps *BinlogPlayerStats) SetThrottledReason(reason string) {
+	bps.throttledReason.Set(reason)
+}
+
+// GetThrottledReason returns why the player is throttled, "" if it isn't.
+func (bps *BinlogPlayerStats) GetThrottledReason() string {
+	return bps.throttledReason.Get()
+}
+
+// Throttler decides when a player has to pause applying transactions,
+// for instance to let the replicas of the destination catch up.
+type Throttler interface {
+	// Throttled returns why the player must not apply the next
+	// transaction yet, or "" if it can.
+	Throttled() string
+}
+
 // NewBinlogPlayerStats creates a new BinlogPlayerStats structure
 func NewBinlogPlayerStats() *BinlogPlayerStats {
 	bps := &BinlogPlayerStats{}
@@ -99,6 +127,13 @@ type BinlogPlayer struct {
 	// for table base requests
 	tables []string
 
+	// transformer rewrites the statements before they are applied,
+	// if set
+	transformer *Transformer
+
+	// throttler is checked before each transaction, if set
+	throttler Throttler
+
 	// common to all
 	blpPos         proto.BlpPosition
 	stopPosition   myproto.ReplicationPosition
@@ -138,6 +173,41 @@ func NewBinlogPlayerTables(dbClient VtClient, endPoint *pbt.EndPoint, tables []s
 	}
 }
 
+// SetTransformer makes the player rewrite the DML statements with the
+// provided Transformer before applying them.
+func (blp *BinlogPlayer) SetTransformer(transformer *Transformer) {
+	blp.transformer = transformer
+}
+
+// SetThrottler makes the player wait before each transaction, as long
+// as the provided Throttler says so.
+func (blp *BinlogPlayer) SetThrottler(throttler Throttler) {
+	blp.throttler = throttler
+}
+
+// throttle waits until the throttler lets the player apply the next
+// transaction. It returns false if the context was done first.
+func (blp *BinlogPlayer) throttle(ctx context.Context) bool {
+	if blp.throttler == nil {
+		return true
+	}
+	defer blp.blplStats.SetThrottledReason("")
+	for {
+		reason := blp.throttler.Throttled()
+		if reason == "" {
+			return true
+		}
+		blp.blplStats.SetThrottledReason(reason)
+		throttleStartTime := time.Now()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(throttleCheckInterval):
+		}
+		blp.blplStats.Timings.Record(BlplThrottled, throttleStartTime)
+	}
+}
+
 // writeRecoveryPosition will write the current GTID as the recovery position
 // for the next transaction.
 // We will also try to get the timestamp for the transaction. Two cases:
@@ -202,6 +272,17 @@ func (blp *BinlogPlayer) processTransaction(tx *pb.BinlogTransaction) (ok bool,
 		return false, err
 	}
 	for i, stmt := range tx.Statements {
+		sql := string(stmt.Sql)
+		if blp.transformer != nil && stmt.Category == pb.BinlogTransaction_Statement_BL_DML {
+			if sql, err = blp.transformer.TransformStatement(sql); err != nil {
+				return false, err
+			}
+			if sql == "" {
+				// no row of the statement is replicated
+				continue
+			}
+		}
+
 		// Make sure the statement is replayed in the proper charset.
 		if dbClient, ok := blp.dbClient.(*DBClient); ok {
 			var stmtCharset *pb.Charset
@@ -225,7 +306,7 @@ func (blp *BinlogPlayer) processTransaction(tx *pb.BinlogTransaction) (ok bool,
 				blp.currentCharset = stmtCharset
 			}
 		}
-		if _, err = blp.exec(string(stmt.Sql)); err == nil {
+		if _, err = blp.exec(sql); err == nil {
 			continue
 		}
 		if sqlErr, ok := err.(*sqldb.SQLError); ok && sqlErr.Number() == 1213 {
@@ -334,6 +415,9 @@ func (blp *BinlogPlayer) ApplyBinlogEvents(ctx context.Context) error {
 	}
 
 	for response := range responseChan {
+		if !blp.throttle(ctx) {
+			return nil
+		}
 		for {
 			ok, err = blp.processTransaction(response)
 			if err != nil {
@@ -387,12 +471,17 @@ func CreateBlpCheckpoint() []string {
 }
 
 // PopulateBlpCheckpoint returns a statement to populate the first value into
-// the _vt.blp_checkpoint table.
-func PopulateBlpCheckpoint(index uint32, pos myproto.ReplicationPosition, timeUpdated int64, flags string) string {
-	return fmt.Sprintf("INSERT INTO _vt.blp_checkpoint "+
+// the _vt.blp_checkpoint table. If ignoreExisting is set, the statement
+// leaves an existing value alone instead of failing.
+func PopulateBlpCheckpoint(index uint32, pos myproto.ReplicationPosition, timeUpdated int64, flags string, ignoreExisting bool) string {
+	insert := "INSERT"
+	if ignoreExisting {
+		insert = "INSERT IGNORE"
+	}
+	return fmt.Sprintf("%v INTO _vt.blp_checkpoint "+
 		"(source_shard_uid, pos, time_updated, transaction_timestamp, flags) "+
 		"VALUES (%v, '%v', %v, 0, '%v')",
-		index, myproto.EncodeReplicationPosition(pos), timeUpdated, flags)
+		insert, index, myproto.EncodeReplicationPosition(pos), timeUpdated, flags)
 }
 
 // UpdateBlpCheckpoint returns a statement to update a value in the
@@ -5,9 +5,12 @@
 package binlogplayer
 
 import (
+	"sync"
 	"testing"
+	"time"
 
 	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"golang.org/x/net/context"
 )
 
 func TestPopulateBlpCheckpoint(t *testing.T) {
@@ -16,10 +19,18 @@ func TestPopulateBlpCheckpoint(t *testing.T) {
 		"(source_shard_uid, pos, time_updated, transaction_timestamp, flags) " +
 		"VALUES (18372, 'MariaDB/0-1-1083', 481823, 0, 'myflags')"
 
-	got := PopulateBlpCheckpoint(18372, myproto.ReplicationPosition{GTIDSet: gtid.GTIDSet()}, 481823, "myflags")
+	got := PopulateBlpCheckpoint(18372, myproto.ReplicationPosition{GTIDSet: gtid.GTIDSet()}, 481823, "myflags", false)
 	if got != want {
 		t.Errorf("PopulateBlpCheckpoint() = %#v, want %#v", got, want)
 	}
+
+	want = "INSERT IGNORE INTO _vt.blp_checkpoint " +
+		"(source_shard_uid, pos, time_updated, transaction_timestamp, flags) " +
+		"VALUES (18372, 'MariaDB/0-1-1083', 481823, 0, 'myflags')"
+	got = PopulateBlpCheckpoint(18372, myproto.ReplicationPosition{GTIDSet: gtid.GTIDSet()}, 481823, "myflags", true)
+	if got != want {
+		t.Errorf("PopulateBlpCheckpoint(ignoreExisting) = %#v, want %#v", got, want)
+	}
 }
 
 func TestUpdateBlpCheckpoint(t *testing.T) {
@@ -53,3 +64,50 @@ func TestQueryBlpCheckpoint(t *testing.T) {
 		t.Errorf("QueryBlpCheckpoint(482821) = %#v, want %#v", got, want)
 	}
 }
+
+// fakeThrottler throttles the player a given number of times.
+type fakeThrottler struct {
+	mu        sync.Mutex
+	remaining int
+}
+
+func (ft *fakeThrottler) Throttled() string {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	if ft.remaining == 0 {
+		return ""
+	}
+	ft.remaining--
+	return "replica is lagging"
+}
+
+func TestThrottle(t *testing.T) {
+	saved := throttleCheckInterval
+	throttleCheckInterval = time.Millisecond
+	defer func() { throttleCheckInterval = saved }()
+
+	blp := &BinlogPlayer{
+		blplStats: NewBinlogPlayerStats(),
+	}
+	if !blp.throttle(context.Background()) {
+		t.Errorf("throttle() without throttler should return true")
+	}
+
+	blp.SetThrottler(&fakeThrottler{remaining: 3})
+	if !blp.throttle(context.Background()) {
+		t.Errorf("throttle() should return true once the throttler lets go")
+	}
+	if got := blp.blplStats.Timings.Counts()[BlplThrottled]; got != 3 {
+		t.Errorf("got %v throttled waits, want 3", got)
+	}
+	if got := blp.blplStats.GetThrottledReason(); got != "" {
+		t.Errorf("throttled reason should be cleared, got %v", got)
+	}
+
+	blp.SetThrottler(&fakeThrottler{remaining: -1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if blp.throttle(ctx) {
+		t.Errorf("throttle() should return false when the context is done")
+	}
+}
@@ -6,6 +6,7 @@ package binlogplayer
 
 import (
 	"flag"
+	"fmt"
 	"time"
 
 	"golang.org/x/net/context"
@@ -59,3 +60,13 @@ func RegisterClientFactory(name string, factory ClientFactory) {
 	}
 	clientFactories[name] = factory
 }
+
+// NewClient returns a new Client for the protocol set by
+// -binlog_player_protocol.
+func NewClient() (Client, error) {
+	clientFactory, ok := clientFactories[*binlogPlayerProtocol]
+	if !ok {
+		return nil, fmt.Errorf("no binlog player client factory named %v", *binlogPlayerProtocol)
+	}
+	return clientFactory(), nil
+}
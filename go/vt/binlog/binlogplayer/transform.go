@@ -0,0 +1,401 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package binlogplayer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/youtube/vitess/go/vt/sqlparser"
+
+	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+// Transformer rewrites the DML statements of a filtered replication
+// stream, following the TableTransforms of a SourceShard: it renames
+// tables and columns, drops columns, and only keeps the rows matching
+// a filter. Statements on other tables are not changed.
+//
+// The row filter is applied to the values of INSERT statements. UPDATE
+// and DELETE statements only affect the destination rows matching the
+// filter, and cannot change the filter column, as a row moving in or
+// out of the filtered set cannot be replicated with statements.
+type Transformer struct {
+	// tables is indexed by source table name
+	tables map[string]*tableTransformer
+}
+
+// tableTransformer applies the TableTransform of one table.
+type tableTransformer struct {
+	table          string
+	targetTable    string
+	droppedColumns map[string]bool
+	renamedColumns map[string]string
+	filterColumn   string
+	filterValues   map[string]bool
+	// sortedFilterValues is used to generate the WHERE clauses
+	sortedFilterValues []string
+}
+
+// NewTransformer checks the transforms and returns a Transformer for them.
+func NewTransformer(transforms []*pbt.TableTransform) (*Transformer, error) {
+	t := &Transformer{
+		tables: make(map[string]*tableTransformer),
+	}
+	for _, transform := range transforms {
+		if transform.Table == "" {
+			return nil, fmt.Errorf("transform has no table: %v", transform)
+		}
+		if _, ok := t.tables[transform.Table]; ok {
+			return nil, fmt.Errorf("table %v has more than one transform", transform.Table)
+		}
+		tt := &tableTransformer{
+			table:          transform.Table,
+			targetTable:    transform.TargetTable,
+			droppedColumns: make(map[string]bool),
+			renamedColumns: transform.RenamedColumns,
+			filterColumn:   transform.FilterColumn,
+			filterValues:   make(map[string]bool),
+		}
+		if tt.targetTable == "" {
+			tt.targetTable = tt.table
+		}
+		for _, column := range transform.DroppedColumns {
+			if _, ok := tt.renamedColumns[column]; ok {
+				return nil, fmt.Errorf("column %v of table %v is both dropped and renamed", column, tt.table)
+			}
+			tt.droppedColumns[column] = true
+		}
+		if tt.filterColumn != "" {
+			if tt.droppedColumns[tt.filterColumn] {
+				return nil, fmt.Errorf("filter column %v of table %v cannot be dropped", tt.filterColumn, tt.table)
+			}
+			if len(transform.FilterValues) == 0 {
+				return nil, fmt.Errorf("filter column %v of table %v has no filter values", tt.filterColumn, tt.table)
+			}
+		}
+		for _, value := range transform.FilterValues {
+			tt.filterValues[value] = true
+			tt.sortedFilterValues = append(tt.sortedFilterValues, value)
+		}
+		sort.Strings(tt.sortedFilterValues)
+		t.tables[tt.table] = tt
+	}
+	return t, nil
+}
+
+// TransformStatement returns the statement to apply on the destination
+// for a DML statement of the source. It returns "" if no row of the
+// statement is replicated.
+func (t *Transformer) TransformStatement(sql string) (string, error) {
+	statement, err := sqlparser.Parse(sql)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse statement to transform it: %v: %v", sql, err)
+	}
+	var table *sqlparser.TableName
+	switch stmt := statement.(type) {
+	case *sqlparser.Insert:
+		table = stmt.Table
+	case *sqlparser.Update:
+		table = stmt.Table
+	case *sqlparser.Delete:
+		table = stmt.Table
+	default:
+		return sql, nil
+	}
+	tt, ok := t.tables[string(table.Name)]
+	if !ok {
+		return sql, nil
+	}
+
+	switch stmt := statement.(type) {
+	case *sqlparser.Insert:
+		ok, err = tt.transformInsert(stmt)
+	case *sqlparser.Update:
+		ok, err = tt.transformUpdate(stmt)
+	case *sqlparser.Delete:
+		ok, err = tt.transformDelete(stmt)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot transform statement: %v: %v", sql, err)
+	}
+	if !ok {
+		return "", nil
+	}
+	return sqlparser.String(statement), nil
+}
+
+// transformInsert rewrites an INSERT statement in place. It returns
+// false if no row is left.
+func (tt *tableTransformer) transformInsert(ins *sqlparser.Insert) (bool, error) {
+	if ins.Columns == nil {
+		return false, fmt.Errorf("INSERT without column list")
+	}
+	rows, ok := ins.Rows.(sqlparser.Values)
+	if !ok {
+		return false, fmt.Errorf("INSERT ... SELECT is not supported")
+	}
+
+	// find which columns we keep, and the filter column
+	filterIndex := -1
+	var keptIndexes []int
+	var columns sqlparser.Columns
+	for i, column := range ins.Columns {
+		nse, ok := column.(*sqlparser.NonStarExpr)
+		if !ok {
+			return false, fmt.Errorf("unexpected INSERT column %v", sqlparser.String(column))
+		}
+		colName, ok := nse.Expr.(*sqlparser.ColName)
+		if !ok {
+			return false, fmt.Errorf("unexpected INSERT column %v", sqlparser.String(column))
+		}
+		name := string(colName.Name)
+		if name == tt.filterColumn {
+			filterIndex = i
+		}
+		if tt.droppedColumns[name] {
+			continue
+		}
+		tt.renameColumn(colName)
+		keptIndexes = append(keptIndexes, i)
+		columns = append(columns, nse)
+	}
+	if tt.filterColumn != "" && filterIndex == -1 {
+		return false, fmt.Errorf("INSERT doesn't set filter column %v", tt.filterColumn)
+	}
+
+	// filter the rows, and drop their columns
+	var values sqlparser.Values
+	for _, row := range rows {
+		tuple, ok := row.(sqlparser.ValTuple)
+		if !ok || len(tuple) != len(ins.Columns) {
+			return false, fmt.Errorf("unexpected INSERT row %v", sqlparser.String(row))
+		}
+		if filterIndex != -1 {
+			matches, err := tt.matchesFilter(tuple[filterIndex])
+			if err != nil {
+				return false, err
+			}
+			if !matches {
+				continue
+			}
+		}
+		newTuple := make(sqlparser.ValTuple, 0, len(keptIndexes))
+		for _, i := range keptIndexes {
+			if err := tt.renameExpr(tuple[i]); err != nil {
+				return false, err
+			}
+			newTuple = append(newTuple, tuple[i])
+		}
+		values = append(values, newTuple)
+	}
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	if ins.OnDup != nil {
+		exprs, err := tt.transformUpdateExprs(sqlparser.UpdateExprs(ins.OnDup))
+		if err != nil {
+			return false, err
+		}
+		if len(exprs) == 0 {
+			// on a duplicate key, the source only updated
+			// dropped columns, so there is nothing to do
+			ins.OnDup = nil
+			ins.Ignore = "ignore "
+		} else {
+			ins.OnDup = sqlparser.OnDup(exprs)
+		}
+	}
+
+	ins.Table.Name = sqlparser.SQLName(tt.targetTable)
+	ins.Columns = columns
+	ins.Rows = values
+	return true, nil
+}
+
+// transformUpdate rewrites an UPDATE statement in place. It returns
+// false if it only updates dropped columns.
+func (tt *tableTransformer) transformUpdate(upd *sqlparser.Update) (bool, error) {
+	exprs, err := tt.transformUpdateExprs(upd.Exprs)
+	if err != nil {
+		return false, err
+	}
+	if len(exprs) == 0 {
+		return false, nil
+	}
+	if err := tt.transformWhere(&upd.Where, upd.OrderBy); err != nil {
+		return false, err
+	}
+	upd.Table.Name = sqlparser.SQLName(tt.targetTable)
+	upd.Exprs = exprs
+	return true, nil
+}
+
+// transformDelete rewrites a DELETE statement in place.
+func (tt *tableTransformer) transformDelete(del *sqlparser.Delete) (bool, error) {
+	if err := tt.transformWhere(&del.Where, del.OrderBy); err != nil {
+		return false, err
+	}
+	del.Table.Name = sqlparser.SQLName(tt.targetTable)
+	return true, nil
+}
+
+// transformUpdateExprs returns the update expressions without the
+// dropped columns, and renames the others.
+func (tt *tableTransformer) transformUpdateExprs(exprs sqlparser.UpdateExprs) (sqlparser.UpdateExprs, error) {
+	var result sqlparser.UpdateExprs
+	for _, expr := range exprs {
+		name := string(expr.Name.Name)
+		if name == tt.filterColumn {
+			return nil, fmt.Errorf("cannot change filter column %v", tt.filterColumn)
+		}
+		if tt.droppedColumns[name] {
+			continue
+		}
+		tt.renameColumn(expr.Name)
+		if err := tt.renameExpr(expr.Expr); err != nil {
+			return nil, err
+		}
+		result = append(result, expr)
+	}
+	return result, nil
+}
+
+// transformWhere renames the columns of a WHERE clause and of an ORDER
+// BY clause, and restricts the WHERE clause to the filtered rows.
+func (tt *tableTransformer) transformWhere(where **sqlparser.Where, orderBy sqlparser.OrderBy) error {
+	if *where != nil {
+		if err := tt.renameExpr((*where).Expr); err != nil {
+			return err
+		}
+	}
+	for _, order := range orderBy {
+		if err := tt.renameExpr(order.Expr); err != nil {
+			return err
+		}
+	}
+	if tt.filterColumn == "" {
+		return nil
+	}
+
+	filter := &sqlparser.ComparisonExpr{
+		Operator: sqlparser.InStr,
+		Left:     &sqlparser.ColName{Name: sqlparser.SQLName(tt.targetColumn(tt.filterColumn))},
+	}
+	var values sqlparser.ValTuple
+	for _, value := range tt.sortedFilterValues {
+		values = append(values, sqlparser.StrVal(value))
+	}
+	filter.Right = values
+	if *where == nil {
+		*where = &sqlparser.Where{Type: sqlparser.WhereStr, Expr: filter}
+		return nil
+	}
+	(*where).Expr = &sqlparser.AndExpr{
+		Left:  &sqlparser.ParenBoolExpr{Expr: (*where).Expr},
+		Right: filter,
+	}
+	return nil
+}
+
+// matchesFilter returns true if a value of the filter column is one of
+// the filter values.
+func (tt *tableTransformer) matchesFilter(expr sqlparser.ValExpr) (bool, error) {
+	switch val := expr.(type) {
+	case sqlparser.StrVal:
+		return tt.filterValues[string(val)], nil
+	case sqlparser.NumVal:
+		return tt.filterValues[string(val)], nil
+	case *sqlparser.NullVal:
+		return false, nil
+	}
+	return false, fmt.Errorf("cannot evaluate filter column %v value %v", tt.filterColumn, sqlparser.String(expr))
+}
+
+// targetColumn returns the destination name of a source column.
+func (tt *tableTransformer) targetColumn(name string) string {
+	if newName, ok := tt.renamedColumns[name]; ok {
+		return newName
+	}
+	return name
+}
+
+// renameColumn renames a column in place.
+func (tt *tableTransformer) renameColumn(colName *sqlparser.ColName) {
+	colName.Name = sqlparser.SQLName(tt.targetColumn(string(colName.Name)))
+	if string(colName.Qualifier) == tt.table {
+		colName.Qualifier = sqlparser.SQLName(tt.targetTable)
+	}
+}
+
+// renameExpr renames in place the columns used in an expression. It
+// fails if the expression uses a dropped column, or a subquery.
+func (tt *tableTransformer) renameExpr(expr sqlparser.Expr) error {
+	switch expr := expr.(type) {
+	case nil, sqlparser.StrVal, sqlparser.NumVal, sqlparser.ValArg, *sqlparser.NullVal, sqlparser.BoolVal, sqlparser.ListArg:
+		return nil
+	case *sqlparser.ColName:
+		if tt.droppedColumns[string(expr.Name)] {
+			return fmt.Errorf("statement uses dropped column %v", string(expr.Name))
+		}
+		tt.renameColumn(expr)
+		return nil
+	case *sqlparser.AndExpr:
+		return tt.renameExprs(expr.Left, expr.Right)
+	case *sqlparser.OrExpr:
+		return tt.renameExprs(expr.Left, expr.Right)
+	case *sqlparser.NotExpr:
+		return tt.renameExpr(expr.Expr)
+	case *sqlparser.ParenBoolExpr:
+		return tt.renameExpr(expr.Expr)
+	case *sqlparser.ComparisonExpr:
+		return tt.renameExprs(expr.Left, expr.Right)
+	case *sqlparser.RangeCond:
+		return tt.renameExprs(expr.Left, expr.From, expr.To)
+	case *sqlparser.IsExpr:
+		return tt.renameExpr(expr.Expr)
+	case *sqlparser.BinaryExpr:
+		return tt.renameExprs(expr.Left, expr.Right)
+	case *sqlparser.UnaryExpr:
+		return tt.renameExpr(expr.Expr)
+	case sqlparser.ValTuple:
+		for _, e := range expr {
+			if err := tt.renameExpr(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *sqlparser.FuncExpr:
+		for _, e := range expr.Exprs {
+			if nse, ok := e.(*sqlparser.NonStarExpr); ok {
+				if err := tt.renameExpr(nse.Expr); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case *sqlparser.CaseExpr:
+		if err := tt.renameExprs(expr.Expr, expr.Else); err != nil {
+			return err
+		}
+		for _, when := range expr.Whens {
+			if err := tt.renameExprs(when.Cond, when.Val); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported expression %v", sqlparser.String(expr))
+}
+
+func (tt *tableTransformer) renameExprs(exprs ...sqlparser.Expr) error {
+	for _, expr := range exprs {
+		if err := tt.renameExpr(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
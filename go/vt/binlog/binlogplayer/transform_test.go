@@ -0,0 +1,142 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package binlogplayer
+
+import (
+	"strings"
+	"testing"
+
+	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+func TestTransformStatement(t *testing.T) {
+	transformer, err := NewTransformer([]*pbt.TableTransform{
+		{
+			Table:          "user",
+			TargetTable:    "tenant_user",
+			DroppedColumns: []string{"password"},
+			RenamedColumns: map[string]string{"name": "user_name"},
+			FilterColumn:   "tenant_id",
+			FilterValues:   []string{"2", "1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewTransformer failed: %v", err)
+	}
+
+	testcases := []struct {
+		in, out string
+	}{{
+		// other tables are not changed
+		in:  "insert into other(id, password) values (1, 'x') /* _stream other (id ) (1 ); */",
+		out: "insert into other(id, password) values (1, 'x') /* _stream other (id ) (1 ); */",
+	}, {
+		in:  "insert into user(id, tenant_id, name, password) values (1, 1, 'a', 'x'), (2, 3, 'b', 'y'), (3, 2, 'c', 'z') /* _stream user (id ) (1 ) (2 ) (3 ); */",
+		out: "insert into tenant_user(id, tenant_id, user_name) values (1, 1, 'a'), (3, 2, 'c')",
+	}, {
+		// no row matches the filter
+		in:  "insert into user(id, tenant_id, name) values (2, 3, 'b')",
+		out: "",
+	}, {
+		in:  "insert into user(id, tenant_id, name, password) values (1, 1, 'a', 'x') on duplicate key update name = concat(name, 'a'), password = 'x'",
+		out: "insert into tenant_user(id, tenant_id, user_name) values (1, 1, 'a') on duplicate key update user_name = concat(user_name, 'a')",
+	}, {
+		in:  "insert into user(id, tenant_id, password) values (1, 1, 'x') on duplicate key update password = 'x'",
+		out: "insert ignore into tenant_user(id, tenant_id) values (1, 1)",
+	}, {
+		in:  "update user set name = 'b', password = 'y' where id = 1 /* _stream user (id ) (1 ); */",
+		out: "update tenant_user set user_name = 'b' where (id = 1) and tenant_id in ('1', '2')",
+	}, {
+		// only dropped columns are updated
+		in:  "update user set password = 'y' where id = 1",
+		out: "",
+	}, {
+		in:  "delete from user where user.name = 'a'",
+		out: "delete from tenant_user where (tenant_user.user_name = 'a') and tenant_id in ('1', '2')",
+	}, {
+		in:  "delete from user",
+		out: "delete from tenant_user where tenant_id in ('1', '2')",
+	}}
+	for _, tcase := range testcases {
+		got, err := transformer.TransformStatement(tcase.in)
+		if err != nil {
+			t.Errorf("TransformStatement(%v) failed: %v", tcase.in, err)
+			continue
+		}
+		if got != tcase.out {
+			t.Errorf("TransformStatement(%v) = %v, want %v", tcase.in, got, tcase.out)
+		}
+	}
+}
+
+func TestTransformStatementErrors(t *testing.T) {
+	transformer, err := NewTransformer([]*pbt.TableTransform{
+		{
+			Table:          "user",
+			DroppedColumns: []string{"password"},
+			FilterColumn:   "tenant_id",
+			FilterValues:   []string{"1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewTransformer failed: %v", err)
+	}
+
+	testcases := []struct {
+		in, err string
+	}{{
+		in:  "insert into user values (1, 1)",
+		err: "INSERT without column list",
+	}, {
+		in:  "insert into user(id, name) values (1, 'a')",
+		err: "INSERT doesn't set filter column tenant_id",
+	}, {
+		in:  "insert into user(id, tenant_id) values (1, 1 + 1)",
+		err: "cannot evaluate filter column tenant_id value 1 + 1",
+	}, {
+		in:  "update user set tenant_id = 2 where id = 1",
+		err: "cannot change filter column tenant_id",
+	}, {
+		in:  "delete from user where password = 'x'",
+		err: "statement uses dropped column password",
+	}, {
+		in:  "delete from user where id in (select id from other)",
+		err: "unsupported expression",
+	}}
+	for _, tcase := range testcases {
+		_, err := transformer.TransformStatement(tcase.in)
+		if err == nil || !strings.Contains(err.Error(), tcase.err) {
+			t.Errorf("TransformStatement(%v) returned %v, want error containing %v", tcase.in, err, tcase.err)
+		}
+	}
+}
+
+func TestNewTransformerErrors(t *testing.T) {
+	testcases := []struct {
+		transforms []*pbt.TableTransform
+		err        string
+	}{{
+		transforms: []*pbt.TableTransform{{}},
+		err:        "transform has no table",
+	}, {
+		transforms: []*pbt.TableTransform{{Table: "t"}, {Table: "t"}},
+		err:        "table t has more than one transform",
+	}, {
+		transforms: []*pbt.TableTransform{{Table: "t", DroppedColumns: []string{"c"}, RenamedColumns: map[string]string{"c": "d"}}},
+		err:        "column c of table t is both dropped and renamed",
+	}, {
+		transforms: []*pbt.TableTransform{{Table: "t", DroppedColumns: []string{"c"}, FilterColumn: "c", FilterValues: []string{"1"}}},
+		err:        "filter column c of table t cannot be dropped",
+	}, {
+		transforms: []*pbt.TableTransform{{Table: "t", FilterColumn: "c"}},
+		err:        "filter column c of table t has no filter values",
+	}}
+	for _, tcase := range testcases {
+		_, err := NewTransformer(tcase.transforms)
+		if err == nil || !strings.Contains(err.Error(), tcase.err) {
+			t.Errorf("NewTransformer(%v) returned %v, want error containing %v", tcase.transforms, err, tcase.err)
+		}
+	}
+}
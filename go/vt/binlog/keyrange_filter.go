@@ -17,6 +17,11 @@ import (
 // in the transaction match the specified keyrange. The resulting function can be
 // passed into the BinlogStreamer: bls.Stream(file, pos, sendTransaction) ->
 // bls.Stream(file, pos, KeyRangeFilterFunc(keyrange, sendTransaction))
+// DML statements without a keyspace id annotation are skipped. The
+// statements the BinlogStreamer builds from row based replication
+// events are only annotated if its keyResolverFactory is set, as
+// UpdateStream.StreamKeyRange does: the stream then fails on a row
+// whose keyspace id cannot be computed.
 // TODO(erez): Remove 'KeyspaceIdType' from here: it's no longer used.
 func KeyRangeFilterFunc(unused pbt.KeyspaceIdType, keyrange *pbt.KeyRange, sendReply sendTransactionFunc) sendTransactionFunc {
 	return func(reply *pb.BinlogTransaction) error {
@@ -7,6 +7,7 @@ package proto
 import (
 	"fmt"
 
+	"github.com/youtube/vitess/go/sqltypes"
 	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 
 	pb "github.com/youtube/vitess/go/vt/proto/binlogdata"
@@ -47,6 +48,14 @@ type BinlogEvent interface {
 	IsIntVar() bool
 	// IsRand returns true if this is a RAND_EVENT.
 	IsRand() bool
+	// IsTableMap returns true if this is a TABLE_MAP_EVENT.
+	IsTableMap() bool
+	// IsWriteRows returns true if this is a WRITE_ROWS_EVENT (v1 or v2).
+	IsWriteRows() bool
+	// IsUpdateRows returns true if this is an UPDATE_ROWS_EVENT (v1 or v2).
+	IsUpdateRows() bool
+	// IsDeleteRows returns true if this is a DELETE_ROWS_EVENT (v1 or v2).
+	IsDeleteRows() bool
 	// HasGTID returns true if this event contains a GTID. That could either be
 	// because it's a GTID_EVENT (MariaDB, MySQL 5.6), or because it is some
 	// arbitrary event type that has a GTID in the header (Google MySQL).
@@ -75,6 +84,19 @@ type BinlogEvent interface {
 	// Rand returns the two seed values for a RAND_EVENT.
 	// This is only valid if IsRand() returns true.
 	Rand(BinlogFormat) (uint64, uint64, error)
+	// TableID returns the table ID for a TABLE_MAP_EVENT or a
+	// {WRITE,UPDATE,DELETE}_ROWS_EVENT.
+	// This is only valid if IsTableMap() or Is*Rows() returns true.
+	TableID(BinlogFormat) uint64
+	// TableMap returns a TableMap struct representing data from a
+	// TABLE_MAP_EVENT.
+	// This is only valid if IsTableMap() returns true.
+	TableMap(BinlogFormat) (*TableMap, error)
+	// Rows returns a Rows struct representing data from a
+	// {WRITE,UPDATE,DELETE}_ROWS_EVENT, decoded with the TableMap of
+	// the same table ID.
+	// This is only valid if Is*Rows() returns true.
+	Rows(BinlogFormat, *TableMap) (Rows, error)
 
 	// StripChecksum returns the checksum and a modified event with the checksum
 	// stripped off, if any. If there is no checksum, it returns the same event
@@ -113,3 +135,86 @@ func (q Query) String() string {
 	return fmt.Sprintf("{Database: %q, Charset: %v, Sql: %q}",
 		q.Database, q.Charset, q.Sql)
 }
+
+// TableMap contains data from a TABLE_MAP_EVENT. It describes the
+// table the following row events of the same table ID apply to.
+type TableMap struct {
+	Flags    uint16
+	Database string
+	Name     string
+
+	// Types is the MySQL type of each column.
+	Types []byte
+
+	// CanBeNull has a bit set for each column that can be NULL.
+	CanBeNull Bitmap
+
+	// Metadata is the type-specific metadata of each column, like
+	// the maximum length of a VARCHAR.
+	Metadata []uint16
+
+	// Unsigned is not part of the event: the binlogs don't say if
+	// an integer column is unsigned. Callers that know the schema
+	// can set it before decoding rows, otherwise all the integers
+	// are decoded as signed.
+	Unsigned []bool
+}
+
+// Rows contains data from a {WRITE,UPDATE,DELETE}_ROWS_EVENT.
+type Rows struct {
+	Flags uint16
+
+	// IdentifyColumns has a bit set for each column present in
+	// the Identify images. It is only used by UPDATE and DELETE.
+	IdentifyColumns Bitmap
+
+	// DataColumns has a bit set for each column present in the
+	// Data images. It is only used by WRITE and UPDATE.
+	DataColumns Bitmap
+
+	Rows []Row
+}
+
+// Row contains the images of a single row. Both slices are indexed by
+// column number, and only the columns present in the image have a
+// value: a nil Value is either a NULL or a column that's not there.
+type Row struct {
+	// Identify is the image of the row before the change.
+	Identify []sqltypes.Value
+
+	// Data is the image of the row after the change.
+	Data []sqltypes.Value
+}
+
+// Bitmap is the bit set the binlogs use to describe columns.
+type Bitmap struct {
+	data  []byte
+	count int
+}
+
+// NewBitmap returns a Bitmap of count bits, backed by data, which
+// needs to have at least (count+7)/8 bytes.
+func NewBitmap(data []byte, count int) Bitmap {
+	return Bitmap{data: data, count: count}
+}
+
+// Count returns the number of bits in the Bitmap.
+func (b Bitmap) Count() int {
+	return b.count
+}
+
+// Bit returns true if the bit at index is set.
+func (b Bitmap) Bit(index int) bool {
+	return b.data[index/8]&(1<<uint(index%8)) != 0
+}
+
+// BitCount returns the number of bits that are set.
+func (b Bitmap) BitCount() int {
+	result := 0
+	for i := 0; i < b.count; i++ {
+		if b.Bit(i) {
+			result++
+		}
+	}
+	return result
+}
@@ -8,13 +8,17 @@ import (
 	"fmt"
 	"sync"
 
+	"golang.org/x/net/context"
+
 	log "github.com/golang/glog"
 	"github.com/youtube/vitess/go/stats"
 	"github.com/youtube/vitess/go/sync2"
 	"github.com/youtube/vitess/go/tb"
 	"github.com/youtube/vitess/go/vt/binlog/proto"
+	"github.com/youtube/vitess/go/vt/keyresolver"
 	"github.com/youtube/vitess/go/vt/mysqlctl"
 	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/topo"
 
 	pb "github.com/youtube/vitess/go/vt/proto/binlogdata"
 	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
@@ -93,8 +97,10 @@ func (m *UpdateStreamControlMock) IsEnabled() bool {
 type UpdateStream struct {
 	// the following variables are set at construction time
 
-	mysqld mysqlctl.MysqlDaemon
-	dbname string
+	mysqld   mysqlctl.MysqlDaemon
+	dbname   string
+	ts       topo.Server
+	keyspace string
 
 	// actionLock protects the following variables
 	actionLock     sync.Mutex
@@ -142,11 +148,15 @@ type RegisterUpdateStreamServiceFunc func(proto.UpdateStream)
 // callbacks to invoke
 var RegisterUpdateStreamServices []RegisterUpdateStreamServiceFunc
 
-// NewUpdateStream returns a new UpdateStream object
-func NewUpdateStream(mysqld mysqlctl.MysqlDaemon, dbname string) *UpdateStream {
+// NewUpdateStream returns a new UpdateStream object.
+// ts and keyspace are used to find the sharding column of the keyspace,
+// for the statements that have no keyspace id annotation.
+func NewUpdateStream(mysqld mysqlctl.MysqlDaemon, dbname string, ts topo.Server, keyspace string) *UpdateStream {
 	return &UpdateStream{
-		mysqld: mysqld,
-		dbname: dbname,
+		mysqld:   mysqld,
+		dbname:   dbname,
+		ts:       ts,
+		keyspace: keyspace,
 	}
 }
 
@@ -268,7 +278,14 @@ func (updateStream *UpdateStream) StreamKeyRange(position string, keyspaceIDType
 		keyrangeTransactions.Add(1)
 		return sendReply(reply)
 	})
+	// Without a way to compute the keyspace ids, the statements of
+	// the row based replication events would all be filtered out.
+	factory, err := updateStream.keyspaceIDResolverFactory(keyspaceIDType)
+	if err != nil {
+		return err
+	}
 	bls := NewBinlogStreamer(updateStream.dbname, updateStream.mysqld, charset, pos, f)
+	bls.keyResolverFactory = factory
 
 	svm := &sync2.ServiceManager{}
 	svm.Go(bls.Stream)
@@ -277,6 +294,23 @@ func (updateStream *UpdateStream) StreamKeyRange(position string, keyspaceIDType
 	return svm.Join()
 }
 
+// keyspaceIDResolverFactory returns how to compute the keyspace id of
+// the rows of our keyspace.
+func (updateStream *UpdateStream) keyspaceIDResolverFactory(keyspaceIDType pbt.KeyspaceIdType) (keyresolver.Factory, error) {
+	if updateStream.ts.Impl == nil {
+		return nil, fmt.Errorf("no topology server to read keyspace %v from", updateStream.keyspace)
+	}
+	ki, err := updateStream.ts.GetKeyspace(context.TODO(), updateStream.keyspace)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read keyspace %v to compute its keyspace ids: %v", updateStream.keyspace, err)
+	}
+	factory, err := keyresolver.NewFactory(context.TODO(), updateStream.ts, ki, keyspaceIDType)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find how to compute the keyspace ids of keyspace %v: %v", updateStream.keyspace, err)
+	}
+	return factory, nil
+}
+
 // StreamTables is part of the proto.UpdateStream interface
 func (updateStream *UpdateStream) StreamTables(position string, tables []string, charset *pb.Charset, sendReply func(reply *pb.BinlogTransaction) error) (err error) {
 	pos, err := myproto.DecodeReplicationPosition(position)
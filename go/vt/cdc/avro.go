@@ -0,0 +1,105 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cdc
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// AvroSchema is the Avro schema of the events produced by the avro
+// encoder. Consumers need it to decode the events, as it is not
+// included in each of them.
+const AvroSchema = `{
+  "type": "record",
+  "name": "ChangeEvent",
+  "namespace": "vitess.cdc",
+  "fields": [
+    {"name": "keyspace", "type": "string"},
+    {"name": "shard", "type": "string"},
+    {"name": "position", "type": "string"},
+    {"name": "category", "type": "string"},
+    {"name": "table", "type": "string"},
+    {"name": "primary_key_fields", "type": {"type": "array", "items": "string"}},
+    {"name": "primary_key_values", "type": {"type": "array", "items": {"type": "array", "items": ["null", "bytes"]}}},
+    {"name": "sql", "type": "string"},
+    {"name": "timestamp", "type": "long"}
+  ]
+}`
+
+// avroEncoder encodes events in the Avro binary encoding, following
+// AvroSchema.
+type avroEncoder struct{}
+
+// Encode is part of the Encoder interface.
+func (avroEncoder) Encode(event *Event) ([]byte, error) {
+	se := event.StreamEvent
+	buf := &bytes.Buffer{}
+	avroWriteString(buf, event.Keyspace)
+	avroWriteString(buf, event.Shard)
+	avroWriteString(buf, event.Position)
+	avroWriteString(buf, se.Category)
+	avroWriteString(buf, se.TableName)
+
+	if len(se.PrimaryKeyFields) > 0 {
+		avroWriteLong(buf, int64(len(se.PrimaryKeyFields)))
+		for _, field := range se.PrimaryKeyFields {
+			avroWriteString(buf, field.Name)
+		}
+	}
+	avroWriteLong(buf, 0)
+
+	if len(se.PrimaryKeyValues) > 0 {
+		avroWriteLong(buf, int64(len(se.PrimaryKeyValues)))
+		for _, row := range se.PrimaryKeyValues {
+			if len(row) > 0 {
+				avroWriteLong(buf, int64(len(row)))
+				for _, value := range row {
+					// index of the branch in the ["null", "bytes"] union
+					if value.IsNull() {
+						avroWriteLong(buf, 0)
+						continue
+					}
+					avroWriteLong(buf, 1)
+					avroWriteBytes(buf, value.Raw())
+				}
+			}
+			avroWriteLong(buf, 0)
+		}
+	}
+	avroWriteLong(buf, 0)
+
+	avroWriteString(buf, se.Sql)
+	avroWriteLong(buf, se.Timestamp)
+	return buf.Bytes(), nil
+}
+
+// LineDelimited is part of the Encoder interface.
+func (avroEncoder) LineDelimited() bool {
+	return false
+}
+
+// avroWriteLong writes an int or long: a zig-zag encoded varint.
+func avroWriteLong(buf *bytes.Buffer, v int64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], v)
+	buf.Write(b[:n])
+}
+
+// avroWriteBytes writes bytes: their length as a long, then the data.
+func avroWriteBytes(buf *bytes.Buffer, b []byte) {
+	avroWriteLong(buf, int64(len(b)))
+	buf.Write(b)
+}
+
+// avroWriteString writes a string, encoded like bytes.
+func avroWriteString(buf *bytes.Buffer, s string) {
+	avroWriteLong(buf, int64(len(s)))
+	buf.WriteString(s)
+}
+
+func init() {
+	RegisterEncoder("avro", avroEncoder{})
+}
@@ -0,0 +1,96 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cdc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+)
+
+// Checkpointer durably stores the position reached in each shard.
+// Implementations must be safe for concurrent use.
+type Checkpointer interface {
+	// Position returns the stored position of a shard, or "" if
+	// there is none.
+	Position(keyspace, shard string) (string, error)
+
+	// SetPosition stores the position of a shard. It must be durable
+	// when it returns.
+	SetPosition(keyspace, shard, position string) error
+}
+
+// fileCheckpointer stores the positions of all shards in a JSON file.
+type fileCheckpointer struct {
+	path string
+
+	// mu protects positions and the file
+	mu sync.Mutex
+	// positions is indexed by keyspace/shard
+	positions map[string]string
+}
+
+// NewFileCheckpointer returns a Checkpointer using the file at path.
+// The file is created on the first SetPosition if it doesn't exist.
+func NewFileCheckpointer(path string) (Checkpointer, error) {
+	fc := &fileCheckpointer{
+		path:      path,
+		positions: make(map[string]string),
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fc, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fc.positions); err != nil {
+		return nil, fmt.Errorf("cannot decode checkpoint file %v: %v", path, err)
+	}
+	return fc, nil
+}
+
+// Position is part of the Checkpointer interface.
+func (fc *fileCheckpointer) Position(keyspace, shard string) (string, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.positions[keyspace+"/"+shard], nil
+}
+
+// SetPosition is part of the Checkpointer interface. It rewrites the
+// whole file, through a temporary file so a crash never leaves it
+// half written.
+func (fc *fileCheckpointer) SetPosition(keyspace, shard, position string) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.positions[keyspace+"/"+shard] = position
+	data, err := json.MarshalIndent(fc.positions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(path.Dir(fc.path), path.Base(fc.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), fc.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("cannot write checkpoint file %v: %v", fc.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cdc
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestFileCheckpointer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "positions.json")
+
+	fc, err := NewFileCheckpointer(file)
+	if err != nil {
+		t.Fatalf("NewFileCheckpointer failed: %v", err)
+	}
+	if pos, err := fc.Position("ks", "-80"); err != nil || pos != "" {
+		t.Errorf("Position on empty file = (%v, %v), want no position", pos, err)
+	}
+	if err := fc.SetPosition("ks", "-80", "MariaDB/0-1-5"); err != nil {
+		t.Fatalf("SetPosition failed: %v", err)
+	}
+	if err := fc.SetPosition("ks", "80-", "MariaDB/0-1-7"); err != nil {
+		t.Fatalf("SetPosition failed: %v", err)
+	}
+
+	// a new checkpointer reads the positions back
+	fc, err = NewFileCheckpointer(file)
+	if err != nil {
+		t.Fatalf("NewFileCheckpointer failed: %v", err)
+	}
+	for shard, want := range map[string]string{"-80": "MariaDB/0-1-5", "80-": "MariaDB/0-1-7"} {
+		if got, err := fc.Position("ks", shard); err != nil || got != want {
+			t.Errorf("Position(ks, %v) = (%v, %v), want %v", shard, got, err, want)
+		}
+	}
+
+	// no temporary file is left behind
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("got %v files in the directory, want 1", len(files))
+	}
+}
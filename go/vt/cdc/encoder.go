@@ -0,0 +1,124 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cdc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	log "github.com/golang/glog"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/binlog/proto"
+
+	pb "github.com/youtube/vitess/go/vt/proto/binlogdata"
+)
+
+// Encoder converts events to the format published to the sinks.
+type Encoder interface {
+	// Encode returns the encoded event.
+	Encode(event *Event) ([]byte, error)
+
+	// LineDelimited is true if the encoded events are text without
+	// newlines. Otherwise sinks writing to a stream have to prefix
+	// them with their length.
+	LineDelimited() bool
+}
+
+var encoders = make(map[string]Encoder)
+
+// RegisterEncoder adds a new encoder. Call during init().
+func RegisterEncoder(name string, encoder Encoder) {
+	if _, ok := encoders[name]; ok {
+		log.Fatalf("Encoder %s already exists", name)
+	}
+	encoders[name] = encoder
+}
+
+// GetEncoder returns the encoder registered under the provided name.
+func GetEncoder(name string) (Encoder, error) {
+	encoder, ok := encoders[name]
+	if !ok {
+		return nil, fmt.Errorf("no encoder named %v", name)
+	}
+	return encoder, nil
+}
+
+// jsonEvent is the JSON representation of an Event.
+type jsonEvent struct {
+	Keyspace string `json:"keyspace"`
+	Shard    string `json:"shard"`
+	Position string `json:"position"`
+	Category string `json:"category"`
+	Table    string `json:"table,omitempty"`
+	// PrimaryKeys has the primary key of each changed row.
+	// A NULL value is a column set by auto-increment we couldn't resolve.
+	PrimaryKeys []map[string]*string `json:"primary_keys,omitempty"`
+	Sql         string               `json:"sql,omitempty"`
+	Timestamp   int64                `json:"timestamp"`
+}
+
+// jsonEncoder encodes events as JSON objects, one per line.
+type jsonEncoder struct{}
+
+// Encode is part of the Encoder interface.
+func (jsonEncoder) Encode(event *Event) ([]byte, error) {
+	se := event.StreamEvent
+	je := &jsonEvent{
+		Keyspace:  event.Keyspace,
+		Shard:     event.Shard,
+		Position:  event.Position,
+		Category:  se.Category,
+		Table:     se.TableName,
+		Sql:       se.Sql,
+		Timestamp: se.Timestamp,
+	}
+	for _, row := range se.PrimaryKeyValues {
+		pk := make(map[string]*string, len(row))
+		for i, value := range row {
+			if i < len(se.PrimaryKeyFields) {
+				pk[se.PrimaryKeyFields[i].Name] = stringOrNil(value)
+			}
+		}
+		je.PrimaryKeys = append(je.PrimaryKeys, pk)
+	}
+	return json.Marshal(je)
+}
+
+// LineDelimited is part of the Encoder interface.
+func (jsonEncoder) LineDelimited() bool {
+	return true
+}
+
+// protoEncoder encodes events as binlogdata.ChangeEvent protobufs.
+type protoEncoder struct{}
+
+// Encode is part of the Encoder interface.
+func (protoEncoder) Encode(event *Event) ([]byte, error) {
+	return protobuf.Marshal(&pb.ChangeEvent{
+		Keyspace:    event.Keyspace,
+		Shard:       event.Shard,
+		Position:    event.Position,
+		StreamEvent: proto.StreamEventToProto(event.StreamEvent),
+	})
+}
+
+// LineDelimited is part of the Encoder interface.
+func (protoEncoder) LineDelimited() bool {
+	return false
+}
+
+func stringOrNil(v sqltypes.Value) *string {
+	if v.IsNull() {
+		return nil
+	}
+	s := v.String()
+	return &s
+}
+
+func init() {
+	RegisterEncoder("json", jsonEncoder{})
+	RegisterEncoder("proto", protoEncoder{})
+}
@@ -0,0 +1,114 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cdc
+
+import (
+	"bytes"
+	"testing"
+
+	protobuf "github.com/golang/protobuf/proto"
+	mproto "github.com/youtube/vitess/go/mysql/proto"
+	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/binlog/proto"
+
+	pb "github.com/youtube/vitess/go/vt/proto/binlogdata"
+)
+
+func testEvent() *Event {
+	return &Event{
+		Keyspace: "ks",
+		Shard:    "-80",
+		Position: "MariaDB/0-1-5",
+		StreamEvent: &proto.StreamEvent{
+			Category:  "DML",
+			TableName: "t1",
+			PrimaryKeyFields: []mproto.Field{
+				{Name: "id", Type: mproto.VT_LONGLONG},
+			},
+			PrimaryKeyValues: [][]sqltypes.Value{
+				{sqltypes.MakeString([]byte("1"))},
+				{sqltypes.Value{}},
+			},
+			Sql:       "update t1 set msg='a' where id in (1, 2)",
+			Timestamp: 1400000000,
+		},
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	encoder, err := GetEncoder("json")
+	if err != nil {
+		t.Fatalf("GetEncoder failed: %v", err)
+	}
+	got, err := encoder.Encode(testEvent())
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	want := `{"keyspace":"ks","shard":"-80","position":"MariaDB/0-1-5","category":"DML","table":"t1","primary_keys":[{"id":"1"},{"id":null}],"sql":"update t1 set msg='a' where id in (1, 2)","timestamp":1400000000}`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestProtoEncoder(t *testing.T) {
+	encoder, err := GetEncoder("proto")
+	if err != nil {
+		t.Fatalf("GetEncoder failed: %v", err)
+	}
+	data, err := encoder.Encode(testEvent())
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	got := &pb.ChangeEvent{}
+	if err := protobuf.Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got.Keyspace != "ks" || got.Shard != "-80" || got.Position != "MariaDB/0-1-5" {
+		t.Errorf("wrong shard or position: %v", got)
+	}
+	if got.StreamEvent.TableName != "t1" || len(got.StreamEvent.PrimaryKeyValues) != 2 {
+		t.Errorf("wrong stream event: %v", got.StreamEvent)
+	}
+}
+
+func TestAvroEncoder(t *testing.T) {
+	encoder, err := GetEncoder("avro")
+	if err != nil {
+		t.Fatalf("GetEncoder failed: %v", err)
+	}
+	got, err := encoder.Encode(testEvent())
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	want := &bytes.Buffer{}
+	for _, s := range []string{"ks", "-80", "MariaDB/0-1-5", "DML", "t1"} {
+		want.WriteByte(byte(len(s) * 2))
+		want.WriteString(s)
+	}
+	// primary_key_fields: one block of 1 item
+	want.Write([]byte{2, 4})
+	want.WriteString("id")
+	want.WriteByte(0)
+	// primary_key_values: one block of 2 rows, each one block of 1 item
+	want.Write([]byte{4, 2, 2, 2})
+	want.WriteString("1")
+	want.Write([]byte{0, 2, 0, 0, 0})
+	sql := "update t1 set msg='a' where id in (1, 2)"
+	want.WriteByte(byte(len(sql) * 2))
+	want.WriteString(sql)
+	// 1400000000 as a zig-zag varint
+	want.Write([]byte{0x80, 0xb8, 0x92, 0xb7, 0x0a})
+
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want.Bytes())
+	}
+}
+
+func TestGetEncoderUnknown(t *testing.T) {
+	if _, err := GetEncoder("xml"); err == nil {
+		t.Errorf("GetEncoder(xml) should have failed")
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cdc implements change data capture: it reads the update
+// stream of shards, and publishes their events to external sinks.
+// Each event is delivered at least once: the position reached in each
+// shard is only checkpointed after its events were flushed to the sink.
+package cdc
+
+import (
+	"github.com/youtube/vitess/go/vt/binlog/proto"
+)
+
+// Event is a change published to a sink: a DML, DDL or ERR
+// StreamEvent of a shard.
+type Event struct {
+	Keyspace string
+	Shard    string
+
+	// Position is the replication position after the transaction
+	// of the event. Restarting the stream from there skips the event.
+	Position string
+
+	StreamEvent *proto.StreamEvent
+}
+
+// key returns the key used to send an event to the sinks. All events
+// of a shard have the same key, so they stay in order.
+func (e *Event) key() []byte {
+	return []byte(e.Keyspace + "/" + e.Shard)
+}
@@ -0,0 +1,92 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cdc
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+var kafkaBrokers = flag.String("kafka_brokers", "", "comma separated list of the Kafka brokers used by the kafka sink")
+
+// KafkaProducer is the part of a Kafka client the kafka sink needs.
+// No client is linked in by default: a plugin has to register a
+// KafkaProducerFactory wrapping the client library of its choice.
+type KafkaProducer interface {
+	// Produce sends a message to a topic. The message may be
+	// buffered until the next Flush.
+	Produce(topic string, key, value []byte) error
+
+	// Flush returns when all the messages sent so far are
+	// acknowledged by the brokers.
+	Flush() error
+
+	// Close releases the producer.
+	Close() error
+}
+
+// KafkaProducerFactory creates a KafkaProducer for a list of brokers.
+type KafkaProducerFactory func(brokers []string) (KafkaProducer, error)
+
+var kafkaProducerFactory KafkaProducerFactory
+
+// RegisterKafkaProducerFactory sets the factory the kafka sink uses.
+// Call during init().
+func RegisterKafkaProducerFactory(factory KafkaProducerFactory) {
+	kafkaProducerFactory = factory
+}
+
+// kafkaSink publishes the events to a Kafka topic. The key of the
+// events is the shard, so the events of a shard go to the same
+// partition and stay in order.
+type kafkaSink struct {
+	topic    string
+	producer KafkaProducer
+}
+
+// newKafkaSink returns a sink producing to the target topic.
+func newKafkaSink(target string, encoder Encoder) (Sink, error) {
+	if kafkaProducerFactory == nil {
+		return nil, fmt.Errorf("no Kafka producer registered, link in a Kafka plugin")
+	}
+	if target == "" {
+		return nil, fmt.Errorf("the kafka sink needs a topic as target")
+	}
+	if *kafkaBrokers == "" {
+		return nil, fmt.Errorf("the kafka sink needs -kafka_brokers")
+	}
+	producer, err := kafkaProducerFactory(strings.Split(*kafkaBrokers, ","))
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaSink{
+		topic:    target,
+		producer: producer,
+	}, nil
+}
+
+// Send is part of the Sink interface.
+func (ks *kafkaSink) Send(key, value []byte) error {
+	return ks.producer.Produce(ks.topic, key, value)
+}
+
+// Flush is part of the Sink interface.
+func (ks *kafkaSink) Flush() error {
+	return ks.producer.Flush()
+}
+
+// Close is part of the Sink interface.
+func (ks *kafkaSink) Close() error {
+	err := ks.producer.Flush()
+	if cerr := ks.producer.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func init() {
+	RegisterSinkFactory("kafka", newKafkaSink)
+}
@@ -0,0 +1,261 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cdc
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	log "github.com/golang/glog"
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/stats"
+	"github.com/youtube/vitess/go/vt/binlog/binlogplayer"
+	"github.com/youtube/vitess/go/vt/binlog/proto"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/tabletmanager/tmclient"
+	"github.com/youtube/vitess/go/vt/topo"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+var (
+	eventsPublished = stats.NewCounters("CdcEventsPublished")
+	checkpoints     = stats.NewCounters("CdcCheckpoints")
+	streamErrors    = stats.NewCounters("CdcStreamErrors")
+)
+
+// ShardStreamer reads the update stream of a shard and publishes its
+// events to a sink.
+type ShardStreamer struct {
+	ts         topo.Server
+	tmc        tmclient.TabletManagerClient
+	cell       string
+	keyspace   string
+	shard      string
+	tabletType pb.TabletType
+
+	encoder      Encoder
+	sink         Sink
+	checkpointer Checkpointer
+
+	// flushInterval is how often the sink is flushed and the
+	// position checkpointed.
+	flushInterval time.Duration
+}
+
+// NewShardStreamer returns a ShardStreamer for a shard. It streams from
+// the tablets of tabletType in cell.
+func NewShardStreamer(ts topo.Server, tmc tmclient.TabletManagerClient, cell, keyspace, shard string, tabletType pb.TabletType, encoder Encoder, sink Sink, checkpointer Checkpointer, flushInterval time.Duration) *ShardStreamer {
+	return &ShardStreamer{
+		ts:            ts,
+		tmc:           tmc,
+		cell:          cell,
+		keyspace:      keyspace,
+		shard:         shard,
+		tabletType:    tabletType,
+		encoder:       encoder,
+		sink:          sink,
+		checkpointer:  checkpointer,
+		flushInterval: flushInterval,
+	}
+}
+
+// Run streams until the context is canceled. When the stream fails, it
+// waits for retryDelay and restarts from the last checkpoint, possibly
+// from another tablet.
+func (ss *ShardStreamer) Run(ctx context.Context, retryDelay time.Duration) {
+	for {
+		err := ss.streamOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		streamErrors.Add(ss.statsKey(), 1)
+		log.Warningf("Stream of %v/%v stopped, restarting in %v: %v", ss.keyspace, ss.shard, retryDelay, err)
+
+		t := time.NewTimer(retryDelay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// streamOnce picks a tablet and streams from it, from the last
+// checkpoint, until the stream or the context ends.
+func (ss *ShardStreamer) streamOnce(ctx context.Context) error {
+	endPoint, err := ss.pickEndPoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	position, err := ss.checkpointer.Position(ss.keyspace, ss.shard)
+	if err != nil {
+		return fmt.Errorf("cannot read checkpoint: %v", err)
+	}
+	var startPos myproto.ReplicationPosition
+	if position == "" {
+		// first run for this shard, start from the current position
+		// of the tablet, and store it so we never miss events
+		// after a restart.
+		startPos, err = ss.tabletPosition(ctx, endPoint)
+		if err != nil {
+			return err
+		}
+		position = myproto.EncodeReplicationPosition(startPos)
+		log.Infof("No checkpoint for %v/%v, starting at %v", ss.keyspace, ss.shard, position)
+		if err := ss.checkpointer.SetPosition(ss.keyspace, ss.shard, position); err != nil {
+			return fmt.Errorf("cannot write checkpoint: %v", err)
+		}
+	} else {
+		startPos, err = myproto.DecodeReplicationPosition(position)
+		if err != nil {
+			return fmt.Errorf("cannot decode checkpoint %v: %v", position, err)
+		}
+	}
+
+	client, err := binlogplayer.NewClient()
+	if err != nil {
+		return err
+	}
+	if err := client.Dial(endPoint, *binlogplayer.BinlogPlayerConnTimeout); err != nil {
+		return fmt.Errorf("cannot dial %v: %v", endPoint, err)
+	}
+	defer client.Close()
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	log.Infof("Streaming %v/%v from tablet %v at %v", ss.keyspace, ss.shard, endPoint.Uid, position)
+	events, errFunc, err := client.ServeUpdateStream(streamCtx, position)
+	if err != nil {
+		return fmt.Errorf("ServeUpdateStream failed: %v", err)
+	}
+	return ss.publish(ctx, events, errFunc, startPos)
+}
+
+// publish sends the events to the sink. Events are only sent once the
+// position of their transaction is known, and the position is only
+// checkpointed once the sink is flushed. After a failure, the stream
+// restarts from the last checkpoint, so events may be sent twice, but
+// never lost.
+func (ss *ShardStreamer) publish(ctx context.Context, events <-chan *proto.StreamEvent, errFunc binlogplayer.ErrFunc, pos myproto.ReplicationPosition) error {
+	ticker := time.NewTicker(ss.flushInterval)
+	defer ticker.Stop()
+
+	var pending []*proto.StreamEvent
+	checkpointed := myproto.EncodeReplicationPosition(pos)
+	sent := checkpointed
+	checkpoint := func() error {
+		if sent == checkpointed {
+			return nil
+		}
+		if err := ss.sink.Flush(); err != nil {
+			return fmt.Errorf("cannot flush sink: %v", err)
+		}
+		if err := ss.checkpointer.SetPosition(ss.keyspace, ss.shard, sent); err != nil {
+			return fmt.Errorf("cannot write checkpoint: %v", err)
+		}
+		checkpoints.Add(ss.statsKey(), 1)
+		checkpointed = sent
+		return nil
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				if err := checkpoint(); err != nil {
+					return err
+				}
+				if err := errFunc(); err != nil {
+					return err
+				}
+				return fmt.Errorf("stream ended")
+			}
+			if event.Category != "POS" {
+				pending = append(pending, event)
+				continue
+			}
+
+			// the transaction is complete
+			gtid, err := myproto.DecodeGTID(event.TransactionID)
+			if err != nil {
+				return fmt.Errorf("cannot decode GTID %v: %v", event.TransactionID, err)
+			}
+			pos = myproto.AppendGTID(pos, gtid)
+			sent = myproto.EncodeReplicationPosition(pos)
+			for _, se := range pending {
+				e := &Event{
+					Keyspace:    ss.keyspace,
+					Shard:       ss.shard,
+					Position:    sent,
+					StreamEvent: se,
+				}
+				data, err := ss.encoder.Encode(e)
+				if err != nil {
+					return fmt.Errorf("cannot encode event: %v", err)
+				}
+				if err := ss.sink.Send(e.key(), data); err != nil {
+					return fmt.Errorf("cannot send event: %v", err)
+				}
+			}
+			eventsPublished.Add(ss.statsKey(), int64(len(pending)))
+			pending = nil
+		case <-ticker.C:
+			if err := checkpoint(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			if err := checkpoint(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// pickEndPoint returns a random healthy endpoint of the shard.
+func (ss *ShardStreamer) pickEndPoint(ctx context.Context) (*pb.EndPoint, error) {
+	endPoints, _, err := ss.ts.GetEndPoints(ctx, ss.cell, ss.keyspace, ss.shard, ss.tabletType)
+	if err != nil {
+		return nil, fmt.Errorf("GetEndPoints(%v,%v,%v,%v) failed: %v", ss.cell, ss.keyspace, ss.shard, ss.tabletType, err)
+	}
+	var healthyEndPoints []*pb.EndPoint
+	for _, entry := range endPoints.Entries {
+		if len(entry.HealthMap) == 0 {
+			healthyEndPoints = append(healthyEndPoints, entry)
+		}
+	}
+	if len(healthyEndPoints) == 0 {
+		return nil, fmt.Errorf("no healthy %v tablet in %v/%v/%v", ss.tabletType, ss.cell, ss.keyspace, ss.shard)
+	}
+	return healthyEndPoints[rand.Intn(len(healthyEndPoints))], nil
+}
+
+// tabletPosition returns the current replication position of the
+// tablet of an endpoint.
+func (ss *ShardStreamer) tabletPosition(ctx context.Context, endPoint *pb.EndPoint) (myproto.ReplicationPosition, error) {
+	ti, err := ss.ts.GetTablet(ctx, &pb.TabletAlias{
+		Cell: ss.cell,
+		Uid:  endPoint.Uid,
+	})
+	if err != nil {
+		return myproto.ReplicationPosition{}, err
+	}
+	pos, err := ss.tmc.MasterPosition(ctx, ti)
+	if err != nil {
+		return myproto.ReplicationPosition{}, fmt.Errorf("cannot get the position of tablet %v: %v", endPoint.Uid, err)
+	}
+	return pos, nil
+}
+
+func (ss *ShardStreamer) statsKey() string {
+	return ss.keyspace + "." + ss.shard
+}
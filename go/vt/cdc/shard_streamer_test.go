@@ -0,0 +1,155 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cdc
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/vt/binlog/proto"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/topo"
+)
+
+// fakeSink records the sent events, and which of them were flushed.
+type fakeSink struct {
+	mu      sync.Mutex
+	sent    []string
+	flushed int
+}
+
+func (fs *fakeSink) Send(key, value []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.sent = append(fs.sent, string(key)+" "+string(value))
+	return nil
+}
+
+func (fs *fakeSink) Flush() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.flushed = len(fs.sent)
+	return nil
+}
+
+func (fs *fakeSink) Close() error {
+	return fs.Flush()
+}
+
+// fakeCheckpointer keeps the positions in memory. It checks the sink
+// was flushed before each checkpoint.
+type fakeCheckpointer struct {
+	t         *testing.T
+	sink      *fakeSink
+	positions map[string]string
+}
+
+func (fc *fakeCheckpointer) Position(keyspace, shard string) (string, error) {
+	return fc.positions[keyspace+"/"+shard], nil
+}
+
+func (fc *fakeCheckpointer) SetPosition(keyspace, shard, position string) error {
+	fc.sink.mu.Lock()
+	defer fc.sink.mu.Unlock()
+	if fc.sink.flushed != len(fc.sink.sent) {
+		fc.t.Errorf("checkpoint of %v before the sink was flushed", position)
+	}
+	fc.positions[keyspace+"/"+shard] = position
+	return nil
+}
+
+// positionEncoder encodes events as their position and SQL.
+type positionEncoder struct{}
+
+func (positionEncoder) Encode(event *Event) ([]byte, error) {
+	return []byte(event.Position + " " + event.StreamEvent.Sql), nil
+}
+
+func (positionEncoder) LineDelimited() bool {
+	return true
+}
+
+func TestPublish(t *testing.T) {
+	sink := &fakeSink{}
+	checkpointer := &fakeCheckpointer{
+		t:         t,
+		sink:      sink,
+		positions: make(map[string]string),
+	}
+	ss := NewShardStreamer(topo.Server{}, nil, "cell1", "ks", "-80", 0, positionEncoder{}, sink, checkpointer, time.Hour)
+
+	gtid := func(sequence uint64) string {
+		return myproto.EncodeGTID(myproto.MariadbGTID{Domain: 0, Server: 1, Sequence: sequence})
+	}
+	events := make(chan *proto.StreamEvent, 10)
+	events <- &proto.StreamEvent{Category: "DML", Sql: "insert 1"}
+	events <- &proto.StreamEvent{Category: "DML", Sql: "insert 2"}
+	events <- &proto.StreamEvent{Category: "POS", TransactionID: gtid(5)}
+	events <- &proto.StreamEvent{Category: "DDL", Sql: "alter"}
+	events <- &proto.StreamEvent{Category: "POS", TransactionID: gtid(6)}
+	// a transaction without its POS event is not sent
+	events <- &proto.StreamEvent{Category: "DML", Sql: "insert 3"}
+	close(events)
+
+	streamErr := errors.New("stream broke")
+	startPos := myproto.ReplicationPosition{GTIDSet: myproto.MariadbGTID{Domain: 0, Server: 1, Sequence: 4}}
+	err := ss.publish(context.Background(), events, func() error { return streamErr }, startPos)
+	if err != streamErr {
+		t.Errorf("publish returned %v, want %v", err, streamErr)
+	}
+
+	wantSent := []string{
+		"ks/-80 MariaDB/0-1-5 insert 1",
+		"ks/-80 MariaDB/0-1-5 insert 2",
+		"ks/-80 MariaDB/0-1-6 alter",
+	}
+	if !reflect.DeepEqual(sink.sent, wantSent) {
+		t.Errorf("got sent events %v, want %v", sink.sent, wantSent)
+	}
+	if got, want := checkpointer.positions["ks/-80"], "MariaDB/0-1-6"; got != want {
+		t.Errorf("got checkpoint %v, want %v", got, want)
+	}
+}
+
+func TestPublishCanceled(t *testing.T) {
+	sink := &fakeSink{}
+	checkpointer := &fakeCheckpointer{
+		t:         t,
+		sink:      sink,
+		positions: make(map[string]string),
+	}
+	ss := NewShardStreamer(topo.Server{}, nil, "cell1", "ks", "-80", 0, positionEncoder{}, sink, checkpointer, time.Hour)
+
+	events := make(chan *proto.StreamEvent, 10)
+	events <- &proto.StreamEvent{Category: "DML", Sql: "insert 1"}
+	events <- &proto.StreamEvent{Category: "POS", TransactionID: myproto.EncodeGTID(myproto.MariadbGTID{Domain: 0, Server: 1, Sequence: 5})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- ss.publish(ctx, events, func() error { return nil }, myproto.ReplicationPosition{})
+	}()
+	for {
+		sink.mu.Lock()
+		n := len(sink.sent)
+		sink.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("publish returned %v, want %v", err, context.Canceled)
+	}
+	if got, want := checkpointer.positions["ks/-80"], "MariaDB/0-1-5"; got != want {
+		t.Errorf("got checkpoint %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cdc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	log "github.com/golang/glog"
+)
+
+// Sink is where the encoded events are published. Implementations
+// must be safe for concurrent use, as all shards share the same sink.
+type Sink interface {
+	// Send publishes an event. The key is the same for all the events
+	// of a shard. The event may be buffered until the next Flush.
+	Send(key, value []byte) error
+
+	// Flush returns when all the events sent so far are durably
+	// published. Positions are only checkpointed after a Flush.
+	Flush() error
+
+	// Close flushes and releases the sink.
+	Close() error
+}
+
+// SinkFactory creates a sink for a target. The meaning of the target
+// depends on the sink (a file name, a topic, ...).
+type SinkFactory func(target string, encoder Encoder) (Sink, error)
+
+var sinkFactories = make(map[string]SinkFactory)
+
+// RegisterSinkFactory adds a new factory. Call during init().
+func RegisterSinkFactory(name string, factory SinkFactory) {
+	if _, ok := sinkFactories[name]; ok {
+		log.Fatalf("SinkFactory %s already exists", name)
+	}
+	sinkFactories[name] = factory
+}
+
+// NewSink returns a new Sink from the factory registered under name.
+func NewSink(name, target string, encoder Encoder) (Sink, error) {
+	factory, ok := sinkFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("no sink factory named %v", name)
+	}
+	return factory(target, encoder)
+}
+
+// writerSink writes the events to a stream, one per line for line
+// delimited encoders, prefixed with their length as a uvarint
+// otherwise. The keys are not written.
+type writerSink struct {
+	lineDelimited bool
+
+	// mu protects all the following fields
+	mu     sync.Mutex
+	buf    *bufio.Writer
+	closer io.Closer
+	// file is set for file sinks, to sync it on Flush.
+	file *os.File
+}
+
+// Send is part of the Sink interface.
+func (ws *writerSink) Send(key, value []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if !ws.lineDelimited {
+		var b [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(b[:], uint64(len(value)))
+		if _, err := ws.buf.Write(b[:n]); err != nil {
+			return err
+		}
+	}
+	if _, err := ws.buf.Write(value); err != nil {
+		return err
+	}
+	if ws.lineDelimited {
+		return ws.buf.WriteByte('\n')
+	}
+	return nil
+}
+
+// Flush is part of the Sink interface.
+func (ws *writerSink) Flush() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.flush()
+}
+
+func (ws *writerSink) flush() error {
+	if err := ws.buf.Flush(); err != nil {
+		return err
+	}
+	if ws.file != nil {
+		return ws.file.Sync()
+	}
+	return nil
+}
+
+// Close is part of the Sink interface.
+func (ws *writerSink) Close() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	err := ws.flush()
+	if ws.closer != nil {
+		if cerr := ws.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// newStdoutSink returns a sink writing to stdout. The target is ignored.
+func newStdoutSink(target string, encoder Encoder) (Sink, error) {
+	return &writerSink{
+		lineDelimited: encoder.LineDelimited(),
+		buf:           bufio.NewWriter(os.Stdout),
+	}, nil
+}
+
+// newFileSink returns a sink appending to the target file.
+func newFileSink(target string, encoder Encoder) (Sink, error) {
+	if target == "" {
+		return nil, fmt.Errorf("the file sink needs a file name as target")
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &writerSink{
+		lineDelimited: encoder.LineDelimited(),
+		buf:           bufio.NewWriter(f),
+		closer:        f,
+		file:          f,
+	}, nil
+}
+
+func init() {
+	RegisterSinkFactory("stdout", newStdoutSink)
+	RegisterSinkFactory("file", newFileSink)
+}
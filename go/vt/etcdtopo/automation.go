@@ -0,0 +1,46 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package etcdtopo
+
+import (
+	"path"
+
+	"github.com/youtube/vitess/go/vt/topo"
+	"golang.org/x/net/context"
+)
+
+/*
+This file contains the automation checkpoints code for etcdtopo.Server
+*/
+
+// SaveClusterOperation is part of the topo.ClusterOperationStore interface.
+func (s *Server) SaveClusterOperation(ctx context.Context, id, contents string) error {
+	_, err := s.getGlobal().Set(path.Join(automationDirPath, id), contents, 0 /* ttl */)
+	if err != nil {
+		return convertError(err)
+	}
+	return nil
+}
+
+// GetClusterOperations is part of the topo.ClusterOperationStore interface.
+func (s *Server) GetClusterOperations(ctx context.Context) (map[string]string, error) {
+	resp, err := s.getGlobal().Get(automationDirPath, false /* sort */, false /* recursive */)
+	if err != nil {
+		err = convertError(err)
+		if err == topo.ErrNoNode {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	if resp.Node == nil {
+		return nil, ErrBadResponse
+	}
+
+	result := make(map[string]string, len(resp.Node.Nodes))
+	for _, n := range resp.Node.Nodes {
+		result[path.Base(n.Key)] = n.Value
+	}
+	return result, nil
+}
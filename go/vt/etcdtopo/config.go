@@ -24,6 +24,7 @@ const (
 	replicationDirPath = rootPath + "/replication"
 	servingDirPath     = rootPath + "/ns"
 	vschemaPath        = rootPath + "/vschema"
+	automationDirPath  = rootPath + "/automation"
 
 	// Magic file names. Directories in etcd cannot have data. Files whose names
 	// begin with '_' are hidden from directory listings.
@@ -18,6 +18,9 @@ We follow these conventions within this package:
   - Call convertError(err) on any errors returned from the etcd client library.
     Functions defined in this package can be assumed to have already converted
     errors as necessary.
+
+The etcd v2 API has no multi-key transactions, so Server doesn't implement
+topo.MultiUpdater, and records that go together are saved one at a time.
 */
 package etcdtopo
 
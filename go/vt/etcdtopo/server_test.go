@@ -47,6 +47,14 @@ func TestShard(t *testing.T) {
 	test.CheckShard(ctx, t, ts)
 }
 
+func TestNoMultiUpdate(t *testing.T) {
+	ts := newTestServer(t, []string{"test"})
+	defer ts.Close()
+	if (topo.Server{Impl: ts}).SupportsMultiUpdate() {
+		t.Errorf("etcd cannot save several records atomically, it shouldn't be a topo.MultiUpdater")
+	}
+}
+
 func TestTablet(t *testing.T) {
 	ctx := context.Background()
 	ts := newTestServer(t, []string{"test"})
@@ -163,3 +171,10 @@ func TestVSchema(t *testing.T) {
 	defer ts.Close()
 	test.CheckVSchema(ctx, t, ts)
 }
+
+func TestClusterOperations(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestServer(t, []string{"test"})
+	defer ts.Close()
+	test.CheckClusterOperations(ctx, t, ts)
+}
@@ -7,6 +7,7 @@ package hook
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path"
@@ -49,6 +50,24 @@ func NewSimpleHook(name string) *Hook {
 }
 
 func (hook *Hook) Execute() (result *HookResult) {
+	var stdout bytes.Buffer
+	result = hook.execute(nil, &stdout)
+	result.Stdout += stdout.String()
+	log.Infof("hook: result is %v", result.String())
+	return result
+}
+
+// ExecuteStreaming runs the hook with stdin as its standard input,
+// if not nil, and writes its standard output to stdout, for hooks
+// producing or consuming a lot of data. The Stdout of the result
+// is only set if the hook couldn't run.
+func (hook *Hook) ExecuteStreaming(stdin io.Reader, stdout io.Writer) (result *HookResult) {
+	result = hook.execute(stdin, stdout)
+	log.Infof("hook: result is %v", result.String())
+	return result
+}
+
+func (hook *Hook) execute(stdin io.Reader, stdout io.Writer) (result *HookResult) {
 	result = &HookResult{}
 
 	// also check for bad string here on the server side, to be sure
@@ -90,11 +109,11 @@ func (hook *Hook) Execute() (result *HookResult) {
 			cmd.Env = append(cmd.Env, key+"="+value)
 		}
 	}
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
 	cmd.Stderr = &stderr
 	err = cmd.Run()
-	result.Stdout = stdout.String()
 	result.Stderr = stderr.String()
 	if err == nil {
 		result.ExitStatus = HOOK_SUCCESS
@@ -106,9 +125,6 @@ func (hook *Hook) Execute() (result *HookResult) {
 		}
 		result.Stderr += "ERROR: " + err.Error() + "\n"
 	}
-
-	log.Infof("hook: result is %v", result.String())
-
 	return result
 }
 
@@ -0,0 +1,127 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package janitor
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/youtube/vitess/go/vt/logutil"
+	"github.com/youtube/vitess/go/vt/mysqlctl"
+	"github.com/youtube/vitess/go/vt/wrangler"
+)
+
+var (
+	backupPruneKeepLast      = flag.Int("backup_prune_keep_last", 0, "number of most recent full backups the backup_pruner janitor keeps")
+	backupPruneKeepDaily     = flag.Int("backup_prune_keep_daily", 0, "number of days for which the backup_pruner janitor keeps the most recent full backup of each day")
+	backupPruneKeepWeekly    = flag.Int("backup_prune_keep_weekly", 0, "number of weeks for which the backup_pruner janitor keeps the most recent full backup of each week")
+	backupPruneKeepOlderThan = flag.Duration("backup_prune_keep_older_than", 0, "the backup_pruner janitor keeps the most recent full backup older than this duration")
+	backupPruneInterval      = flag.Duration("backup_prune_interval", 1*time.Hour, "minimum time between two runs of the backup_pruner janitor")
+)
+
+func init() {
+	Register("backup_pruner", newBackupPrunerJanitor())
+}
+
+// backupPrunerJanitor periodically removes the backups of a shard
+// that the retention policy doesn't keep.
+type backupPrunerJanitor struct {
+	wr       *wrangler.Wrangler
+	keyspace string
+	shard    string
+	policy   mysqlctl.BackupRetentionPolicy
+
+	// mu protects all the fields below.
+	mu        sync.Mutex
+	lastRun   time.Time
+	dryRun    bool
+	decisions []*mysqlctl.BackupPruneDecision
+	lastError error
+}
+
+func newBackupPrunerJanitor() *backupPrunerJanitor {
+	return &backupPrunerJanitor{}
+}
+
+// Configure is part of the Janitor interface.
+func (bpj *backupPrunerJanitor) Configure(wr *wrangler.Wrangler, keyspace, shard string) error {
+	policy := mysqlctl.BackupRetentionPolicy{
+		KeepLast:      *backupPruneKeepLast,
+		KeepDaily:     *backupPruneKeepDaily,
+		KeepWeekly:    *backupPruneKeepWeekly,
+		KeepOlderThan: *backupPruneKeepOlderThan,
+	}
+	if policy.IsZero() {
+		return fmt.Errorf("backup_pruner needs at least one of -backup_prune_keep_last, -backup_prune_keep_daily, -backup_prune_keep_weekly or -backup_prune_keep_older_than")
+	}
+	bpj.wr = wr
+	bpj.keyspace = keyspace
+	bpj.shard = shard
+	bpj.policy = policy
+	return nil
+}
+
+// Run is part of the Janitor interface.
+func (bpj *backupPrunerJanitor) Run(active bool) error {
+	now := time.Now()
+	bpj.mu.Lock()
+	if now.Sub(bpj.lastRun) < *backupPruneInterval {
+		bpj.mu.Unlock()
+		return nil
+	}
+	bpj.lastRun = now
+	bpj.mu.Unlock()
+
+	dir := fmt.Sprintf("%v/%v", bpj.keyspace, bpj.shard)
+	decisions, err := mysqlctl.PruneBackups(logutil.NewConsoleLogger(), dir, bpj.policy, now, !active)
+	if !active {
+		for _, d := range decisions {
+			if !d.Keep() {
+				log.Infof("dry run: would remove backup %v/%v", dir, d.Name)
+			}
+		}
+	}
+
+	bpj.mu.Lock()
+	defer bpj.mu.Unlock()
+	bpj.dryRun = !active
+	bpj.decisions = decisions
+	bpj.lastError = err
+	return err
+}
+
+var backupPrunerTemplate = template.Must(template.New("backup_pruner").Parse(`
+<h2>Backup pruner {{.Keyspace}}/{{.Shard}}</h2>
+<p>Policy: {{.Policy}}</p>
+<p>Last run: {{.LastRun}}{{if .DryRun}} (dry run){{end}}{{if .LastError}}, error: {{.LastError}}{{end}}</p>
+<ul>
+  {{range .Decisions}}
+  <li>{{.}}</li>
+  {{end}}
+</ul>
+`))
+
+// ServeHTTP displays the decisions of the last run.
+func (bpj *backupPrunerJanitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bpj.mu.Lock()
+	data := map[string]interface{}{
+		"Keyspace":  bpj.keyspace,
+		"Shard":     bpj.shard,
+		"Policy":    fmt.Sprintf("%+v", bpj.policy),
+		"LastRun":   bpj.lastRun,
+		"DryRun":    bpj.dryRun,
+		"LastError": bpj.lastError,
+		"Decisions": bpj.decisions,
+	}
+	bpj.mu.Unlock()
+	if err := backupPrunerTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package janitor
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/youtube/vitess/go/vt/discovery"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
+	"github.com/youtube/vitess/go/vt/wrangler"
+	"golang.org/x/net/context"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+var (
+	failoverMaxFailedChecks      = flag.Int("failover_max_failed_checks", 3, "number of consecutive failed master health checks before the failover janitor reparents the shard")
+	failoverCooldown             = flag.Duration("failover_cooldown", 30*time.Minute, "minimum time between two failovers of the same shard")
+	failoverFlapWindow           = flag.Duration("failover_flap_window", 24*time.Hour, "window over which failovers are counted to detect flapping")
+	failoverMaxInFlapWindow      = flag.Int("failover_max_in_flap_window", 3, "maximum number of failovers of the same shard within failover_flap_window, after which the failover janitor stops acting")
+	failoverActionTimeout        = flag.Duration("failover_action_timeout", 2*time.Minute, "timeout for one run of the failover janitor, including the emergency reparent")
+	failoverWaitSlaveTimeout     = flag.Duration("failover_wait_slave_timeout", 30*time.Second, "time to wait for slaves to catch up during the emergency reparent")
+	failoverHealthCheckTimeout   = flag.Duration("failover_healthcheck_conn_timeout", 1*time.Minute, "healthcheck connection timeout used by the failover janitor")
+	failoverHealthCheckRetry     = flag.Duration("failover_healthcheck_retry_delay", 5*time.Second, "healthcheck retry delay used by the failover janitor")
+	failoverTopoRefresh          = flag.Duration("failover_topo_refresh_interval", 1*time.Minute, "how often the failover janitor reloads the tablets of the shard from the topology")
+	failoverRequireSemiSyncSlave = flag.Bool("failover_require_semi_sync_slave", false, "only fail over to tablets that are acknowledging transactions as semi-sync slaves")
+	failoverHistorySize          = flag.Int("failover_history_size", 20, "number of failover records kept for the status page")
+)
+
+func init() {
+	Register("failover", newFailoverJanitor())
+}
+
+// failoverRecord describes one failover the janitor performed, or
+// would have performed in dry run mode.
+type failoverRecord struct {
+	Time      time.Time
+	OldMaster string
+	NewMaster string
+	DryRun    bool
+	Err       error
+}
+
+// failoverJanitor watches the health of the master of a shard, and
+// runs an EmergencyReparentShard to the most advanced replica when
+// the master has been unhealthy for failoverMaxFailedChecks
+// consecutive runs.
+type failoverJanitor struct {
+	wr       *wrangler.Wrangler
+	keyspace string
+	shard    string
+
+	hc       discovery.HealthCheck
+	watchers []*discovery.TopologyWatcher
+
+	// mu protects all the fields below.
+	mu           sync.Mutex
+	failedChecks int
+	lastError    error
+	history      []*failoverRecord
+}
+
+func newFailoverJanitor() *failoverJanitor {
+	return &failoverJanitor{}
+}
+
+// Configure is part of the Janitor interface.
+func (fj *failoverJanitor) Configure(wr *wrangler.Wrangler, keyspace, shard string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), *failoverActionTimeout)
+	defer cancel()
+	si, err := wr.TopoServer().GetShard(ctx, keyspace, shard)
+	if err != nil {
+		return fmt.Errorf("cannot read shard %v/%v: %v", keyspace, shard, err)
+	}
+
+	fj.wr = wr
+	fj.keyspace = keyspace
+	fj.shard = shard
+	fj.hc = discovery.NewHealthCheck(*failoverHealthCheckTimeout, *failoverHealthCheckRetry)
+	for _, cell := range si.Cells {
+		fj.watchers = append(fj.watchers, discovery.NewShardReplicationWatcher(wr.TopoServer(), fj.hc, cell, keyspace, shard, *failoverTopoRefresh, 1))
+	}
+	return nil
+}
+
+// Run is part of the Janitor interface.
+func (fj *failoverJanitor) Run(active bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), *failoverActionTimeout)
+	defer cancel()
+
+	si, err := fj.wr.TopoServer().GetShard(ctx, fj.keyspace, fj.shard)
+	if err != nil {
+		return fmt.Errorf("cannot read shard %v/%v: %v", fj.keyspace, fj.shard, err)
+	}
+	if !si.HasMaster() {
+		log.Warningf("shard %v/%v has no master, nothing to watch", fj.keyspace, fj.shard)
+		return nil
+	}
+
+	if !fj.recordCheck(fj.checkMaster(si.MasterAlias)) {
+		return nil
+	}
+
+	if err := fj.canFailover(time.Now(), !active); err != nil {
+		return err
+	}
+
+	candidate, err := fj.pickCandidate(ctx, si)
+	if err != nil {
+		return err
+	}
+
+	record := &failoverRecord{
+		Time:      time.Now(),
+		OldMaster: topoproto.TabletAliasString(si.MasterAlias),
+		NewMaster: topoproto.TabletAliasString(candidate),
+		DryRun:    !active,
+	}
+	if !active {
+		log.Infof("dry run: would emergency reparent %v/%v from %v to %v", fj.keyspace, fj.shard, record.OldMaster, record.NewMaster)
+	} else {
+		log.Infof("emergency reparenting %v/%v from %v to %v", fj.keyspace, fj.shard, record.OldMaster, record.NewMaster)
+		record.Err = fj.wr.EmergencyReparentShard(ctx, fj.keyspace, fj.shard, candidate, &wrangler.ReparentCandidatePolicy{RequireSemiSyncSlave: *failoverRequireSemiSyncSlave}, *failoverWaitSlaveTimeout)
+	}
+	fj.recordFailover(record)
+	return record.Err
+}
+
+// checkMaster returns an error if the health check doesn't report
+// the provided master as up and serving.
+func (fj *failoverJanitor) checkMaster(masterAlias *pb.TabletAlias) error {
+	for _, eps := range fj.hc.GetEndPointStatsFromKeyspaceShard(fj.keyspace, fj.shard) {
+		if eps.Cell != masterAlias.Cell || eps.EndPoint.Uid != masterAlias.Uid {
+			continue
+		}
+		switch {
+		case !eps.Up:
+			return fmt.Errorf("master is down")
+		case eps.LastError != nil:
+			return eps.LastError
+		case eps.Target == nil || eps.Target.TabletType != pb.TabletType_MASTER:
+			return fmt.Errorf("master reports type %v", eps.Target)
+		case !eps.Serving:
+			return fmt.Errorf("master is not serving")
+		}
+		return nil
+	}
+	return fmt.Errorf("no health information for master")
+}
+
+// recordCheck records the result of a master health check, and
+// returns true if the master has failed enough consecutive checks
+// to be failed over.
+func (fj *failoverJanitor) recordCheck(err error) bool {
+	fj.mu.Lock()
+	defer fj.mu.Unlock()
+	fj.lastError = err
+	if err == nil {
+		fj.failedChecks = 0
+		return false
+	}
+	fj.failedChecks++
+	log.Warningf("master of %v/%v failed health check (%v/%v): %v", fj.keyspace, fj.shard, fj.failedChecks, *failoverMaxFailedChecks, err)
+	return fj.failedChecks >= *failoverMaxFailedChecks
+}
+
+// canFailover returns an error if a failover at time now would
+// violate the cooldown or anti-flapping settings. Only records of
+// the same mode are considered, so a janitor can switch from dry run
+// to active mode without being blocked.
+func (fj *failoverJanitor) canFailover(now time.Time, dryRun bool) error {
+	fj.mu.Lock()
+	defer fj.mu.Unlock()
+
+	inWindow := 0
+	for _, r := range fj.history {
+		if r.DryRun != dryRun {
+			continue
+		}
+		if now.Sub(r.Time) < *failoverCooldown {
+			return fmt.Errorf("not failing over %v/%v: last failover was at %v, cooldown is %v", fj.keyspace, fj.shard, r.Time, *failoverCooldown)
+		}
+		if now.Sub(r.Time) < *failoverFlapWindow {
+			inWindow++
+		}
+	}
+	if inWindow >= *failoverMaxInFlapWindow {
+		return fmt.Errorf("not failing over %v/%v: %v failovers in the last %v, shard is flapping", fj.keyspace, fj.shard, inWindow, *failoverFlapWindow)
+	}
+	return nil
+}
+
+// pickCandidate returns the replica with the most advanced
+// replication position.
+func (fj *failoverJanitor) pickCandidate(ctx context.Context, si *topo.ShardInfo) (*pb.TabletAlias, error) {
+	tabletMap, err := fj.wr.TopoServer().GetTabletMapForShard(ctx, fj.keyspace, fj.shard)
+	if err != nil && err != topo.ErrPartialResult {
+		return nil, err
+	}
+
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	statusMap := make(map[pb.TabletAlias]myproto.ReplicationStatus)
+	for alias, ti := range tabletMap {
+		if topoproto.TabletAliasEqual(&alias, si.MasterAlias) || ti.Type != pb.TabletType_REPLICA {
+			continue
+		}
+		wg.Add(1)
+		go func(alias pb.TabletAlias, ti *topo.TabletInfo) {
+			defer wg.Done()
+			status, err := fj.wr.TabletManagerClient().SlaveStatus(ctx, ti)
+			if err != nil {
+				log.Warningf("cannot get replication status from %v, ignoring it: %v", topoproto.TabletAliasString(&alias), err)
+				return
+			}
+			if *failoverRequireSemiSyncSlave && !status.SemiSyncSlaveActive {
+				log.Infof("%v is not an active semi-sync slave, ignoring it", topoproto.TabletAliasString(&alias))
+				return
+			}
+			mu.Lock()
+			statusMap[alias] = status
+			mu.Unlock()
+		}(alias, ti)
+	}
+	wg.Wait()
+
+	return mostAdvanced(statusMap)
+}
+
+// mostAdvanced returns the alias with the most advanced position in
+// statusMap.
+func mostAdvanced(statusMap map[pb.TabletAlias]myproto.ReplicationStatus) (*pb.TabletAlias, error) {
+	var best *pb.TabletAlias
+	var bestPosition myproto.ReplicationPosition
+	for alias, status := range statusMap {
+		if best != nil && bestPosition.AtLeast(status.Position) {
+			continue
+		}
+		a := alias
+		best = &a
+		bestPosition = status.Position
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no replica available to fail over to")
+	}
+	return best, nil
+}
+
+func (fj *failoverJanitor) recordFailover(record *failoverRecord) {
+	fj.mu.Lock()
+	defer fj.mu.Unlock()
+	fj.history = append(fj.history, record)
+	if len(fj.history) > *failoverHistorySize {
+		fj.history = fj.history[len(fj.history)-*failoverHistorySize:]
+	}
+}
+
+// History returns the failover records, most recent first.
+func (fj *failoverJanitor) History() []*failoverRecord {
+	fj.mu.Lock()
+	defer fj.mu.Unlock()
+	result := make([]*failoverRecord, len(fj.history))
+	for i, r := range fj.history {
+		result[len(fj.history)-1-i] = r
+	}
+	return result
+}
+
+// FailedChecks returns the number of consecutive failed master
+// health checks, and the last error.
+func (fj *failoverJanitor) FailedChecks() (int, error) {
+	fj.mu.Lock()
+	defer fj.mu.Unlock()
+	return fj.failedChecks, fj.lastError
+}
+
+var failoverTemplate = template.Must(template.New("failover").Parse(`
+<h2>Failover {{.Keyspace}}/{{.Shard}}</h2>
+<p>Consecutive failed master checks: {{.FailedChecks}}{{if .LastError}} (last error: {{.LastError}}){{end}}</p>
+<table>
+  <tr><th>Time</th><th>Old master</th><th>New master</th><th>Dry run</th><th>Error</th></tr>
+  {{range .History}}
+  <tr><td>{{.Time}}</td><td>{{.OldMaster}}</td><td>{{.NewMaster}}</td><td>{{.DryRun}}</td><td>{{if .Err}}{{.Err}}{{end}}</td></tr>
+  {{end}}
+</table>
+`))
+
+// ServeHTTP displays the failover history.
+func (fj *failoverJanitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	failedChecks, lastError := fj.FailedChecks()
+	data := map[string]interface{}{
+		"Keyspace":     fj.keyspace,
+		"Shard":        fj.shard,
+		"FailedChecks": failedChecks,
+		"LastError":    lastError,
+		"History":      fj.History(),
+	}
+	if err := failoverTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -3,6 +3,7 @@ package janitor
 import (
 	"errors"
 	"fmt"
+	"html"
 	"net/http"
 	"sync"
 	"time"
@@ -66,7 +67,7 @@ func New(keyspace, shard string, ts topo.Server, wr *wrangler.Wrangler, sleepTim
 	if keyspace == "" || shard == "" {
 		return nil, errors.New("keyspace and shard cannot be empty")
 	}
-	return &Scheduler{
+	scheduler := &Scheduler{
 		Keyspace:  keyspace,
 		Shard:     shard,
 		ts:        ts,
@@ -74,7 +75,23 @@ func New(keyspace, shard string, ts topo.Server, wr *wrangler.Wrangler, sleepTim
 		sleepTime: sleepTime,
 		janitors:  make(map[string]*JanitorInfo),
 		mux:       http.NewServeMux(),
-	}, nil
+	}
+	scheduler.mux.HandleFunc("/janitorz/", scheduler.serveIndex)
+	return scheduler, nil
+}
+
+func (scheduler *Scheduler) serveIndex(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "<h1>Janitors for %v/%v</h1>\n<ul>\n", html.EscapeString(scheduler.Keyspace), html.EscapeString(scheduler.Shard))
+	for name, ji := range scheduler.janitors {
+		fmt.Fprintf(w, "<li>")
+		if _, ok := ji.Janitor.(http.Handler); ok {
+			fmt.Fprintf(w, "<a href=\"/janitorz/%v\">%v</a>", html.EscapeString(name), html.EscapeString(name))
+		} else {
+			fmt.Fprintf(w, "%v", html.EscapeString(name))
+		}
+		fmt.Fprintf(w, " (active: %v, runs: %v, errors: %v)</li>\n", ji.Active, ji.Runs(), ji.ErrorCount())
+	}
+	fmt.Fprintf(w, "</ul>\n")
 }
 
 type JanitorInfo struct {
@@ -129,6 +146,8 @@ func (ji *JanitorInfo) RecordSuccess(start time.Time) {
 	// todo: add record to history
 }
 
+// ServeHTTP serves /janitorz: the index lists the enabled
+// janitors, and janitors that are http.Handlers get their own page.
 func (scheduler *Scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	scheduler.mux.ServeHTTP(w, r)
 }
@@ -139,6 +158,9 @@ func (scheduler *Scheduler) enable(name string, active bool) error {
 		return fmt.Errorf("janitor not registered: %q", name)
 	}
 	scheduler.janitors[name] = &JanitorInfo{Janitor: janitor, Active: active}
+	if handler, ok := janitor.(http.Handler); ok {
+		scheduler.mux.Handle("/janitorz/"+name, handler)
+	}
 	return janitor.Configure(scheduler.wrangler, scheduler.Keyspace, scheduler.Shard)
 }
 
@@ -6,8 +6,11 @@ import (
 	"testing"
 	"time"
 
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 	"github.com/youtube/vitess/go/vt/topo"
 	"github.com/youtube/vitess/go/vt/wrangler"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
 )
 
 //var testCells = []string{"oe", "wj"}
@@ -76,3 +79,90 @@ func TestRunJanitor(t *testing.T) {
 	}
 
 }
+
+func TestFailoverCanFailover(t *testing.T) {
+	fj := newFailoverJanitor()
+	now := time.Now()
+	if err := fj.canFailover(now, false); err != nil {
+		t.Errorf("canFailover with no history: %v", err)
+	}
+
+	// A recent failover triggers the cooldown, but not for dry runs.
+	fj.recordFailover(&failoverRecord{Time: now.Add(-time.Minute)})
+	if err := fj.canFailover(now, false); err == nil {
+		t.Errorf("canFailover during cooldown: got no error")
+	}
+	if err := fj.canFailover(now, true); err != nil {
+		t.Errorf("canFailover in dry run mode: %v", err)
+	}
+
+	// Too many failovers in the flap window.
+	fj = newFailoverJanitor()
+	for i := 0; i < *failoverMaxInFlapWindow; i++ {
+		fj.recordFailover(&failoverRecord{Time: now.Add(-*failoverCooldown - time.Duration(i+1)*time.Minute)})
+	}
+	if err := fj.canFailover(now, false); err == nil {
+		t.Errorf("canFailover when flapping: got no error")
+	}
+	if err := fj.canFailover(now.Add(*failoverFlapWindow), false); err != nil {
+		t.Errorf("canFailover after the flap window: %v", err)
+	}
+}
+
+func TestFailoverRecordCheck(t *testing.T) {
+	fj := newFailoverJanitor()
+	for i := 1; i < *failoverMaxFailedChecks; i++ {
+		if fj.recordCheck(errors.New("down")) {
+			t.Errorf("recordCheck after %v failures: want false", i)
+		}
+	}
+	if !fj.recordCheck(errors.New("down")) {
+		t.Errorf("recordCheck after %v failures: want true", *failoverMaxFailedChecks)
+	}
+	if fj.recordCheck(nil) {
+		t.Errorf("recordCheck(nil): want false")
+	}
+	if got, _ := fj.FailedChecks(); got != 0 {
+		t.Errorf("FailedChecks after a successful check: got %v, want 0", got)
+	}
+}
+
+func TestFailoverMostAdvanced(t *testing.T) {
+	statusMap := map[pb.TabletAlias]myproto.ReplicationStatus{
+		{Cell: "cell1", Uid: 1}: {Position: myproto.MustParseReplicationPosition("MariaDB", "0-1-10")},
+		{Cell: "cell1", Uid: 2}: {Position: myproto.MustParseReplicationPosition("MariaDB", "0-1-12")},
+		{Cell: "cell2", Uid: 3}: {Position: myproto.MustParseReplicationPosition("MariaDB", "0-1-11")},
+	}
+	got, err := mostAdvanced(statusMap)
+	if err != nil {
+		t.Fatalf("mostAdvanced: %v", err)
+	}
+	if want := (pb.TabletAlias{Cell: "cell1", Uid: 2}); *got != want {
+		t.Errorf("mostAdvanced: got %v, want %v", got, want)
+	}
+	if _, err := mostAdvanced(nil); err == nil {
+		t.Errorf("mostAdvanced(nil): got no error")
+	}
+}
+
+func TestBackupPrunerConfigure(t *testing.T) {
+	bpj := newBackupPrunerJanitor()
+	if err := bpj.Configure(nil, "ks", "0"); err == nil {
+		t.Errorf("Configure without a retention policy: got no error")
+	}
+
+	*backupPruneKeepLast = 3
+	defer func() { *backupPruneKeepLast = 0 }()
+	if err := bpj.Configure(nil, "ks", "0"); err != nil {
+		t.Errorf("Configure: %v", err)
+	}
+	if bpj.policy.KeepLast != 3 {
+		t.Errorf("policy.KeepLast: got %v, want 3", bpj.policy.KeepLast)
+	}
+
+	// a run within backup_prune_interval of the previous one does nothing
+	bpj.lastRun = time.Now()
+	if err := bpj.Run(true); err != nil {
+		t.Errorf("Run: %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package keyresolver computes the keyspace id of the rows of a
+// table, when the rows are not annotated with it. It is used by the
+// resharding workers to split and filter the rows they copy or diff,
+// and by the update stream to filter the row based replication
+// events.
+package keyresolver
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/key"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/vtgate/planbuilder"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+
+	// register the vindexes the vschema can use
+	_ "github.com/youtube/vitess/go/vt/vtgate/vindexes"
+)
+
+// Resolver computes the keyspace id of the rows of a table.
+type Resolver interface {
+	// KeyspaceID returns the keyspace id of a row.
+	KeyspaceID(row []sqltypes.Value) ([]byte, error)
+}
+
+// Factory returns the Resolver for the rows of a table, made of the
+// provided columns.
+type Factory func(table string, columns []string) (Resolver, error)
+
+// NewFactory returns the Factory for the tables of a keyspace. If
+// the keyspace has a sharding column, the keyspace ids are read from
+// it, as keyspaceIDType. Otherwise, they are computed by the primary
+// vindex of each table in the vschema.
+func NewFactory(ctx context.Context, ts topo.Server, keyspaceInfo *topo.KeyspaceInfo, keyspaceIDType pb.KeyspaceIdType) (Factory, error) {
+	if keyspaceInfo.ShardingColumnName != "" {
+		return func(table string, columns []string) (Resolver, error) {
+			return NewV2Resolver(keyspaceInfo.ShardingColumnName, keyspaceIDType, table, columns)
+		}, nil
+	}
+
+	vschema, err := ts.GetVSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read vschema: %v", err)
+	}
+	if vschema == "" {
+		return nil, fmt.Errorf("keyspace %v has no sharding column, and there is no vschema", keyspaceInfo.KeyspaceName())
+	}
+	schema, err := planbuilder.NewSchema([]byte(vschema))
+	if err != nil {
+		return nil, fmt.Errorf("cannot build vschema: %v", err)
+	}
+	return func(table string, columns []string) (Resolver, error) {
+		return NewV3Resolver(schema, keyspaceInfo.KeyspaceName(), table, columns)
+	}, nil
+}
+
+// findColumn returns the index of column in columns, or -1.
+func findColumn(columns []string, column string) int {
+	for i, name := range columns {
+		if name == column {
+			return i
+		}
+	}
+	return -1
+}
+
+// v2Resolver reads the keyspace id from the sharding column of the
+// keyspace.
+type v2Resolver struct {
+	keyspaceIDType pb.KeyspaceIdType
+	columnIndex    int
+}
+
+// NewV2Resolver returns a Resolver that reads the keyspace id from
+// shardingColumn, as keyspaceIDType.
+func NewV2Resolver(shardingColumn string, keyspaceIDType pb.KeyspaceIdType, table string, columns []string) (Resolver, error) {
+	columnIndex := findColumn(columns, shardingColumn)
+	if columnIndex == -1 {
+		return nil, fmt.Errorf("table %v doesn't have a column named '%v'", table, shardingColumn)
+	}
+	return NewV2ResolverForColumn(keyspaceIDType, columnIndex), nil
+}
+
+// NewV2ResolverForColumn returns a Resolver that reads the keyspace
+// id from the column at columnIndex, as keyspaceIDType.
+func NewV2ResolverForColumn(keyspaceIDType pb.KeyspaceIdType, columnIndex int) Resolver {
+	return &v2Resolver{
+		keyspaceIDType: keyspaceIDType,
+		columnIndex:    columnIndex,
+	}
+}
+
+// KeyspaceID is part of the Resolver interface.
+func (r *v2Resolver) KeyspaceID(row []sqltypes.Value) ([]byte, error) {
+	value := row[r.columnIndex]
+	if value.IsNull() {
+		return nil, fmt.Errorf("sharding column is NULL")
+	}
+	switch r.keyspaceIDType {
+	case pb.KeyspaceIdType_UINT64:
+		u, err := parseUint64(value)
+		if err != nil {
+			return nil, fmt.Errorf("sharding column is not a 64 bits integer: %v", err)
+		}
+		return key.Uint64Key(u).Bytes(), nil
+	case pb.KeyspaceIdType_BYTES:
+		return value.Raw(), nil
+	}
+	return nil, fmt.Errorf("unsupported keyspace id type %v", r.keyspaceIDType)
+}
+
+// v3Resolver computes the keyspace id with the primary vindex of the
+// table in the vschema. Only vindexes which don't need a lookup can be
+// used, as there is no VTGate session to run the lookup queries.
+type v3Resolver struct {
+	vindex      planbuilder.Unique
+	columnIndex int
+}
+
+// NewV3Resolver returns a Resolver that uses the primary vindex of
+// table in the vschema of keyspace.
+func NewV3Resolver(schema *planbuilder.Schema, keyspace, table string, columns []string) (Resolver, error) {
+	t, reason := schema.FindTable(table)
+	if t == nil {
+		return nil, fmt.Errorf("cannot find table %v in the vschema: %v", table, reason)
+	}
+	if t.Keyspace.Name != keyspace {
+		return nil, fmt.Errorf("table %v is in keyspace %v in the vschema, not %v", table, t.Keyspace.Name, keyspace)
+	}
+	if len(t.ColVindexes) == 0 {
+		return nil, fmt.Errorf("table %v has no vindex in the vschema", table)
+	}
+	primary := t.ColVindexes[0]
+	if primary.Vindex.Cost() > 1 {
+		return nil, fmt.Errorf("primary vindex %v of table %v needs a lookup", primary.Name, table)
+	}
+	columnIndex := findColumn(columns, primary.Col)
+	if columnIndex == -1 {
+		return nil, fmt.Errorf("table %v doesn't have a column named '%v'", table, primary.Col)
+	}
+	return &v3Resolver{
+		// BuildSchema checked primary vindexes are Unique
+		vindex:      primary.Vindex.(planbuilder.Unique),
+		columnIndex: columnIndex,
+	}, nil
+}
+
+// KeyspaceID is part of the Resolver interface.
+func (r *v3Resolver) KeyspaceID(row []sqltypes.Value) ([]byte, error) {
+	value := row[r.columnIndex]
+	if value.IsNull() {
+		return nil, fmt.Errorf("vindex column is NULL")
+	}
+	ksids, err := r.vindex.Map(nil, []interface{}{vindexID(value)})
+	if err != nil {
+		return nil, err
+	}
+	if len(ksids) != 1 {
+		return nil, fmt.Errorf("vindex mapped %v to %v keyspace ids", value, len(ksids))
+	}
+	return ksids[0], nil
+}
+
+// vindexID converts a column value to what the vindexes take as ids:
+// integers are returned as uint64, and other values as bytes. The
+// rows read by the workers are not typed, so any value that parses
+// as an integer is one.
+func vindexID(value sqltypes.Value) interface{} {
+	if u, err := parseUint64(value); err == nil {
+		return u
+	}
+	return value.Raw()
+}
+
+// parseUint64 returns an integer value as an uint64. Negative numbers
+// are returned as their two's complement, as the keyspace ids stored
+// in signed BIGINT columns.
+func parseUint64(value sqltypes.Value) (uint64, error) {
+	v := sqltypes.MakeNumeric(value.Raw())
+	if u, err := v.ParseUint64(); err == nil {
+		return u, nil
+	}
+	i, err := v.ParseInt64()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(i), nil
+}
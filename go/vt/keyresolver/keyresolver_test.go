@@ -0,0 +1,149 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keyresolver
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/key"
+	"github.com/youtube/vitess/go/vt/vtgate/planbuilder"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+const testVSchema = `{
+  "Keyspaces": {
+    "ks": {
+      "Sharded": true,
+      "Vindexes": {
+        "user_index": {"Type": "numeric"},
+        "name_index": {"Type": "lookup_hash_unique", "Params": {"Table": "name_idx", "From": "name", "To": "user_id"}}
+      },
+      "Classes": {
+        "user": {"ColVindexes": [{"Col": "user_id", "Name": "user_index"}]},
+        "name": {"ColVindexes": [{"Col": "name", "Name": "name_index"}]}
+      },
+      "Tables": {"user": "user", "user_extra": "user", "name": "name"}
+    },
+    "other": {
+      "Tables": {"unsharded": ""}
+    }
+  }
+}`
+
+func TestNewV3ResolverErrors(t *testing.T) {
+	schema, err := planbuilder.NewSchema([]byte(testVSchema))
+	if err != nil {
+		t.Fatalf("NewSchema failed: %v", err)
+	}
+	testcases := []struct {
+		keyspace, table string
+		columns         []string
+		err             string
+	}{{
+		keyspace: "ks",
+		table:    "missing",
+		columns:  []string{"user_id"},
+		err:      "cannot find table missing in the vschema",
+	}, {
+		keyspace: "ks",
+		table:    "unsharded",
+		columns:  []string{"id"},
+		err:      "table unsharded is in keyspace other in the vschema, not ks",
+	}, {
+		keyspace: "ks",
+		table:    "name",
+		columns:  []string{"name"},
+		err:      "primary vindex name_index of table name needs a lookup",
+	}, {
+		keyspace: "ks",
+		table:    "user_extra",
+		columns:  []string{"id"},
+		err:      "table user_extra doesn't have a column named 'user_id'",
+	}}
+	for _, tcase := range testcases {
+		_, err := NewV3Resolver(schema, tcase.keyspace, tcase.table, tcase.columns)
+		if err == nil || !strings.Contains(err.Error(), tcase.err) {
+			t.Errorf("NewV3Resolver(%v) returned %v, want error containing %v", tcase.table, err, tcase.err)
+		}
+	}
+}
+
+func TestV2Resolver(t *testing.T) {
+	if _, err := NewV2Resolver("keyspace_id", pb.KeyspaceIdType_UINT64, "user", []string{"id"}); err == nil || !strings.Contains(err.Error(), "table user doesn't have a column named 'keyspace_id'") {
+		t.Errorf("NewV2Resolver with a missing column returned %v", err)
+	}
+
+	testcases := []struct {
+		keyspaceIDType pb.KeyspaceIdType
+		value          sqltypes.Value
+		want           []byte
+	}{{
+		keyspaceIDType: pb.KeyspaceIdType_UINT64,
+		value:          sqltypes.MakeString([]byte(fmt.Sprintf("%v", uint64(0xe000000000000000)))),
+		want:           key.Uint64Key(0xe000000000000000).Bytes(),
+	}, {
+		// a negative value is the two's complement of the
+		// keyspace id, in a signed column
+		keyspaceIDType: pb.KeyspaceIdType_UINT64,
+		value:          sqltypes.MakeNumeric([]byte("-1")),
+		want:           key.Uint64Key(0xffffffffffffffff).Bytes(),
+	}, {
+		keyspaceIDType: pb.KeyspaceIdType_BYTES,
+		value:          sqltypes.MakeString([]byte("abc")),
+		want:           []byte("abc"),
+	}}
+	for _, tcase := range testcases {
+		r, err := NewV2Resolver("keyspace_id", tcase.keyspaceIDType, "user", []string{"id", "keyspace_id"})
+		if err != nil {
+			t.Fatalf("NewV2Resolver failed: %v", err)
+		}
+		got, err := r.KeyspaceID([]sqltypes.Value{sqltypes.MakeNumeric([]byte("1")), tcase.value})
+		if err != nil || !reflect.DeepEqual(got, tcase.want) {
+			t.Errorf("KeyspaceID(%v) = (%v, %v), want %v", tcase.value, got, err, tcase.want)
+		}
+	}
+
+	r := NewV2ResolverForColumn(pb.KeyspaceIdType_UINT64, 0)
+	for _, value := range []sqltypes.Value{{}, sqltypes.MakeString([]byte("abc"))} {
+		if _, err := r.KeyspaceID([]sqltypes.Value{value}); err == nil {
+			t.Errorf("KeyspaceID(%v) should have failed", value)
+		}
+	}
+}
+
+func TestV3Resolver(t *testing.T) {
+	schema, err := planbuilder.NewSchema([]byte(testVSchema))
+	if err != nil {
+		t.Fatalf("NewSchema failed: %v", err)
+	}
+	r, err := NewV3Resolver(schema, "ks", "user", []string{"msg", "user_id"})
+	if err != nil {
+		t.Fatalf("NewV3Resolver failed: %v", err)
+	}
+
+	// the numeric vindex maps a number to itself, whether the
+	// row is typed or not
+	for _, value := range []sqltypes.Value{
+		sqltypes.MakeNumeric([]byte(fmt.Sprintf("%v", uint64(0xe000000000000000)))),
+		sqltypes.MakeString([]byte(fmt.Sprintf("%v", uint64(0xe000000000000000)))),
+	} {
+		got, err := r.KeyspaceID([]sqltypes.Value{sqltypes.MakeString([]byte("Ignored Value")), value})
+		if want := key.Uint64Key(0xe000000000000000).Bytes(); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("KeyspaceID(%v) = (%v, %v), want %v", value, got, err, want)
+		}
+	}
+
+	// values the vindex cannot map
+	for _, value := range []sqltypes.Value{{}, sqltypes.MakeString([]byte("abc"))} {
+		if _, err := r.KeyspaceID([]sqltypes.Value{sqltypes.MakeString([]byte("Ignored Value")), value}); err == nil {
+			t.Errorf("KeyspaceID(%v) should have failed", value)
+		}
+	}
+}
@@ -15,11 +15,11 @@ import (
 	"path"
 	"strings"
 	"sync"
+	"time"
 
 	log "github.com/golang/glog"
 	"golang.org/x/net/context"
 
-	"github.com/youtube/vitess/go/cgzip"
 	"github.com/youtube/vitess/go/sync2"
 	"github.com/youtube/vitess/go/vt/concurrency"
 	"github.com/youtube/vitess/go/vt/logutil"
@@ -35,8 +35,15 @@ const (
 	backupInnodbLogGroupHomeDir = "InnoDBLog"
 	backupData                  = "Data"
 
+	// the base for the binlog files of incremental backups
+	backupBinlog = "BinLog"
+
 	// the manifest file name
 	backupManifest = "MANIFEST"
+
+	// BackupTimestampFormat is the format of the UTC time at the
+	// beginning of the backup names.
+	BackupTimestampFormat = "2006-01-02.150405"
 )
 
 const (
@@ -58,17 +65,28 @@ type FileEntry struct {
 	// - backupInnodbDataHomeDir for files that go into Mycnf.InnodbDataHomeDir
 	// - backupInnodbLogGroupHomeDir for files that go into Mycnf.InnodbLogGroupHomeDir
 	// - backupData for files that go into Mycnf.DataDir
+	// - backupBinlog for files that go into the directory of Mycnf.BinLogPath
 	Base string
 
 	// Name is the file name, relative to Base
 	Name string
 
-	// Hash is the hash of the gzip compressed data stored in the
-	// BackupStorage.
+	// Hash is the hash of the data stored in the BackupStorage,
+	// compressed and maybe encrypted.
 	Hash string
+
+	// Compression is the name of the BackupCompressor of the
+	// file. It is empty for the files of older versions, which
+	// are compressed with gzip.
+	Compression string
+
+	// Encryption is empty if the file is not encrypted, or the
+	// name of the encryption, backupEncryptionAES256GCM.
+	Encryption string
 }
 
-func (fe *FileEntry) open(cnf *Mycnf, readOnly bool) (*os.File, error) {
+// fullPath returns the path of the file on the local file system
+func (fe *FileEntry) fullPath(cnf *Mycnf) (string, error) {
 	// find the root to use
 	var root string
 	switch fe.Base {
@@ -78,14 +96,22 @@ func (fe *FileEntry) open(cnf *Mycnf, readOnly bool) (*os.File, error) {
 		root = cnf.InnodbLogGroupHomeDir
 	case backupData:
 		root = cnf.DataDir
+	case backupBinlog:
+		root = path.Dir(cnf.BinLogPath)
 	default:
-		return nil, fmt.Errorf("unknown base: %v", fe.Base)
+		return "", fmt.Errorf("unknown base: %v", fe.Base)
+	}
+	return path.Join(root, fe.Name), nil
+}
+
+func (fe *FileEntry) open(cnf *Mycnf, readOnly bool) (*os.File, error) {
+	name, err := fe.fullPath(cnf)
+	if err != nil {
+		return nil, err
 	}
 
 	// and open the file
-	name := path.Join(root, fe.Name)
 	var fd *os.File
-	var err error
 	if readOnly {
 		fd, err = os.Open(name)
 	} else {
@@ -109,6 +135,39 @@ type BackupManifest struct {
 
 	// ReplicationPosition is the position at which the backup was taken
 	ReplicationPosition proto.ReplicationPosition
+
+	// Time is when the backup was at ReplicationPosition, in
+	// seconds since the epoch. It is zero for the backups taken
+	// by older versions.
+	Time int64
+
+	// Incremental is true for the backups taken by
+	// BackupIncremental, which only contain binlog files.
+	Incremental bool
+
+	// FromPosition is the position an incremental backup starts
+	// at: the ReplicationPosition of the backup before it.
+	FromPosition proto.ReplicationPosition
+
+	// BackupEngine is the name of the BackupEngine of a full
+	// backup. It is empty for the backups taken by older versions,
+	// with the builtin engine.
+	BackupEngine string
+}
+
+// readManifest reads and decodes the MANIFEST of a backup.
+func readManifest(bh backupstorage.BackupHandle) (*BackupManifest, error) {
+	rc, err := bh.ReadFile(backupManifest)
+	if err != nil {
+		return nil, fmt.Errorf("can't read MANIFEST: %v", err)
+	}
+	defer rc.Close()
+
+	bm := &BackupManifest{}
+	if err := json.NewDecoder(rc).Decode(bm); err != nil {
+		return nil, fmt.Errorf("cannot JSON decode MANIFEST: %v", err)
+	}
+	return bm, nil
 }
 
 // isDbDir returns true if the given directory contains a DB
@@ -182,9 +241,14 @@ func findFilesTobackup(cnf *Mycnf) ([]FileEntry, error) {
 
 // Backup is the main entry point for a backup:
 // - uses the BackupStorage service to store a new backup
-// - shuts down Mysqld during the backup
+// - takes it with the BackupEngine of -backup_engine_implementation
+// - the builtin engine shuts down Mysqld during the backup
 // - remember if we were replicating, restore the exact same state
 func Backup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, dir, name string, backupConcurrency int, hookExtraEnv map[string]string) error {
+	be, err := GetBackupEngine()
+	if err != nil {
+		return err
+	}
 
 	// start the backup with the BackupStorage
 	bs, err := backupstorage.GetBackupStorage()
@@ -196,7 +260,7 @@ func Backup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, dir,
 		return fmt.Errorf("StartBackup failed: %v", err)
 	}
 
-	if err = backup(ctx, mysqld, logger, bh, backupConcurrency, hookExtraEnv); err != nil {
+	if err = be.ExecuteBackup(ctx, mysqld, logger, bh, backupConcurrency, hookExtraEnv); err != nil {
 		if abortErr := bh.AbortBackup(); abortErr != nil {
 			logger.Errorf("failed to abort backup: %v", abortErr)
 		}
@@ -205,6 +269,8 @@ func Backup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, dir,
 	return bh.EndBackup()
 }
 
+// backup is the builtin BackupEngine: it stops mysqld and copies its
+// files.
 func backup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.BackupHandle, backupConcurrency int, hookExtraEnv map[string]string) error {
 
 	// save initial state so we can restore
@@ -256,6 +322,7 @@ func backup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, bh b
 		replicationPosition = slaveStatus.Position
 	}
 	logger.Infof("using replication position: %v", replicationPosition)
+	backupTime := time.Now()
 
 	// shutdown mysqld
 	err = mysqld.Shutdown(ctx, true)
@@ -271,7 +338,13 @@ func backup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, bh b
 	logger.Infof("found %v files to backup", len(fes))
 
 	// backup everything
-	if err := backupFiles(mysqld, logger, bh, fes, replicationPosition, backupConcurrency); err != nil {
+	bm := &BackupManifest{
+		FileEntries:         fes,
+		ReplicationPosition: replicationPosition,
+		Time:                backupTime.Unix(),
+		BackupEngine:        builtinBackupEngineName,
+	}
+	if err := backupFiles(mysqld, logger, bh, bm, backupConcurrency); err != nil {
 		return fmt.Errorf("cannot backup files: %v", err)
 	}
 
@@ -303,7 +376,16 @@ func backup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, bh b
 	return nil
 }
 
-func backupFiles(mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.BackupHandle, fes []FileEntry, replicationPosition proto.ReplicationPosition, backupConcurrency int) (err error) {
+// backupFiles stores the files of the manifest, and then the
+// manifest itself with the hashes of the files.
+func backupFiles(mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.BackupHandle, bm *BackupManifest, backupConcurrency int) (err error) {
+	bw, err := newBackupFileWriter()
+	if err != nil {
+		return err
+	}
+	logger.Infof("backing up %v files with %v compression", len(bm.FileEntries), *backupCompression)
+
+	fes := bm.FileEntries
 	sema := sync2.NewSemaphore(backupConcurrency, 0)
 	rec := concurrency.AllErrorRecorder{}
 	wg := sync.WaitGroup{}
@@ -328,43 +410,10 @@ func backupFiles(mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.Bac
 			}
 			defer source.Close()
 
-			// open the destination file for writing, and a buffer
-			name := fmt.Sprintf("%v", i)
-			wc, err := bh.AddFile(name)
-			if err != nil {
-				rec.RecordError(fmt.Errorf("cannot add file: %v", err))
-				return
-			}
-			defer func() { rec.RecordError(wc.Close()) }()
-			dst := bufio.NewWriterSize(wc, 2*1024*1024)
-
-			// create the hasher and the tee on top
-			hasher := newHasher()
-			tee := io.MultiWriter(dst, hasher)
-
-			// create the gzip compression filter
-			gzip, err := cgzip.NewWriterLevel(tee, cgzip.Z_BEST_SPEED)
-			if err != nil {
-				rec.RecordError(fmt.Errorf("cannot create gziper: %v", err))
-				return
-			}
-
-			// copy from the source file to gzip to tee to output file and hasher
-			_, err = io.Copy(gzip, source)
-			if err != nil {
-				rec.RecordError(fmt.Errorf("cannot copy data: %v", err))
-				return
-			}
-
-			// close gzip to flush it, after that the hash is good
-			if err = gzip.Close(); err != nil {
-				rec.RecordError(fmt.Errorf("cannot close gzip: %v", err))
-				return
+			// and store it in the file named after its index
+			if err := bw.writeFile(bh, fmt.Sprintf("%v", i), source, &fes[i]); err != nil {
+				rec.RecordError(err)
 			}
-
-			// flush the buffer to finish writing, save the hash
-			rec.RecordError(dst.Flush())
-			fes[i].Hash = hasher.HashString()
 		}(i, fe)
 	}
 
@@ -372,7 +421,11 @@ func backupFiles(mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.Bac
 	if rec.HasErrors() {
 		return rec.Error()
 	}
+	return writeManifest(bh, bm)
+}
 
+// writeManifest JSON-encodes and stores the MANIFEST of a backup.
+func writeManifest(bh backupstorage.BackupHandle, bm *BackupManifest) (err error) {
 	// open the MANIFEST
 	wc, err := bh.AddFile(backupManifest)
 	if err != nil {
@@ -385,10 +438,6 @@ func backupFiles(mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.Bac
 	}()
 
 	// JSON-encode and write the MANIFEST
-	bm := &BackupManifest{
-		FileEntries:         fes,
-		ReplicationPosition: replicationPosition,
-	}
 	data, err := json.MarshalIndent(bm, "", "  ")
 	if err != nil {
 		return fmt.Errorf("cannot JSON encode %v: %v", backupManifest, err)
@@ -400,6 +449,97 @@ func backupFiles(mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.Bac
 	return nil
 }
 
+// backupFileWriter stores files in a backup, compressed and maybe
+// encrypted according to the flags.
+type backupFileWriter struct {
+	compression string
+	compressor  BackupCompressor
+	encryption  string
+	key         []byte
+}
+
+func newBackupFileWriter() (*backupFileWriter, error) {
+	compressor, err := getBackupCompressor(*backupCompression)
+	if err != nil {
+		return nil, err
+	}
+	key, err := backupEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	bw := &backupFileWriter{
+		compression: *backupCompression,
+		compressor:  compressor,
+		key:         key,
+	}
+	if key != nil {
+		bw.encryption = backupEncryptionAES256GCM
+	}
+	return bw, nil
+}
+
+// writeFile stores the data of source as the file name of the
+// backup, and saves its hash, compression and encryption in fe.
+func (bw *backupFileWriter) writeFile(bh backupstorage.BackupHandle, name string, source io.Reader, fe *FileEntry) (err error) {
+	// open the destination file for writing, and a buffer
+	wc, err := bh.AddFile(name)
+	if err != nil {
+		return fmt.Errorf("cannot add file: %v", err)
+	}
+	defer func() {
+		if closeErr := wc.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	dst := bufio.NewWriterSize(wc, 2*1024*1024)
+
+	// create the hasher and the tee on top
+	hasher := newHasher()
+	tee := io.MultiWriter(dst, hasher)
+
+	// create the encryption filter if needed
+	var encrypter io.WriteCloser
+	var w io.Writer = tee
+	if bw.key != nil {
+		encrypter, err = newEncryptingWriter(tee, bw.key)
+		if err != nil {
+			return fmt.Errorf("cannot create encrypter: %v", err)
+		}
+		w = encrypter
+	}
+
+	// create the compression filter
+	compresser, err := bw.compressor.NewWriter(w)
+	if err != nil {
+		return err
+	}
+
+	// copy from the source file to compresser to encrypter to tee
+	// to output file and hasher
+	if _, err := io.Copy(compresser, source); err != nil {
+		return fmt.Errorf("cannot copy data: %v", err)
+	}
+
+	// close the filters to flush them, after that the hash is good
+	if err := compresser.Close(); err != nil {
+		return fmt.Errorf("cannot close compresser: %v", err)
+	}
+	if encrypter != nil {
+		if err := encrypter.Close(); err != nil {
+			return fmt.Errorf("cannot close encrypter: %v", err)
+		}
+	}
+
+	// flush the buffer to finish writing, save the hash
+	if err := dst.Flush(); err != nil {
+		return err
+	}
+	fe.Hash = hasher.HashString()
+	fe.Compression = bw.compression
+	fe.Encryption = bw.encryption
+	return nil
+}
+
 // checkNoDB makes sure there is no vt_ db already there. Used by Restore,
 // we do not wnat to destroy an existing DB.
 // Returns true iff the condition is satisfied (there's no DB).
@@ -440,6 +580,11 @@ func checkNoDB(ctx context.Context, mysqld MysqlDaemon) (bool, error) {
 // restoreFiles will copy all the files from the BackupStorage to the
 // right place
 func restoreFiles(cnf *Mycnf, bh backupstorage.BackupHandle, fes []FileEntry, restoreConcurrency int) error {
+	key, err := restoreEncryptionKey(fes)
+	if err != nil {
+		return err
+	}
+
 	sema := sync2.NewSemaphore(restoreConcurrency, 0)
 	rec := concurrency.AllErrorRecorder{}
 	wg := sync.WaitGroup{}
@@ -456,15 +601,6 @@ func restoreFiles(cnf *Mycnf, bh backupstorage.BackupHandle, fes []FileEntry, re
 				return
 			}
 
-			// open the source file for reading
-			name := fmt.Sprintf("%v", i)
-			source, err := bh.ReadFile(name)
-			if err != nil {
-				rec.RecordError(err)
-				return
-			}
-			defer source.Close()
-
 			// open the destination file for writing
 			dstFile, err := fe.open(cnf, false)
 			if err != nil {
@@ -476,33 +612,11 @@ func restoreFiles(cnf *Mycnf, bh backupstorage.BackupHandle, fes []FileEntry, re
 			// create a buffering output
 			dst := bufio.NewWriterSize(dstFile, 2*1024*1024)
 
-			// create hash to write the compressed data to
-			hasher := newHasher()
-
-			// create a Tee: we split the input into the hasher
-			// and into the gunziper
-			tee := io.TeeReader(source, hasher)
-
-			// create the uncompresser
-			gz, err := cgzip.NewReader(tee)
-			if err != nil {
+			// copy the file named after its index
+			if err := readBackupFile(bh, fmt.Sprintf("%v", i), &fe, key, dst); err != nil {
 				rec.RecordError(err)
 				return
 			}
-			defer func() { rec.RecordError(gz.Close()) }()
-
-			// copy the data. Will also write to the hasher
-			if _, err = io.Copy(dst, gz); err != nil {
-				rec.RecordError(err)
-				return
-			}
-
-			// check the hash
-			hash := hasher.HashString()
-			if hash != fe.Hash {
-				rec.RecordError(fmt.Errorf("hash mismatch for %v, got %v expected %v", fe.Name, hash, fe.Hash))
-				return
-			}
 
 			// flush the buffer
 			rec.RecordError(dst.Flush())
@@ -512,11 +626,116 @@ func restoreFiles(cnf *Mycnf, bh backupstorage.BackupHandle, fes []FileEntry, re
 	return rec.Error()
 }
 
+// restoreEncryptionKey returns the key to restore the files, or nil
+// if none of them is encrypted.
+func restoreEncryptionKey(fes []FileEntry) ([]byte, error) {
+	var key []byte
+	for _, fe := range fes {
+		if fe.Encryption == "" {
+			continue
+		}
+		if fe.Encryption != backupEncryptionAES256GCM {
+			return nil, fmt.Errorf("unknown encryption %v for %v", fe.Encryption, fe.Name)
+		}
+		if key == nil {
+			var err error
+			key, err = backupEncryptionKey()
+			if err != nil {
+				return nil, err
+			}
+			if key == nil {
+				return nil, fmt.Errorf("the backup is encrypted, restoring it needs -backup_encryption_key_file or -backup_encryption_key_hook")
+			}
+		}
+	}
+	return key, nil
+}
+
+// readBackupFile reads the file name of the backup, described by fe,
+// into dst. It decrypts it with key if needed, uncompresses it, and
+// checks its hash.
+func readBackupFile(bh backupstorage.BackupHandle, name string, fe *FileEntry, key []byte, dst io.Writer) (err error) {
+	// open the source file for reading
+	source, err := bh.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	// create hash to write the stored data to
+	hasher := newHasher()
+
+	// create a Tee: we split the input into the hasher and into
+	// the decrypter or the uncompresser
+	var r io.Reader = io.TeeReader(source, hasher)
+
+	// create the decrypter if needed
+	if fe.Encryption != "" {
+		r, err = newDecryptingReader(r, key)
+		if err != nil {
+			return fmt.Errorf("cannot decrypt %v: %v", fe.Name, err)
+		}
+	}
+
+	// create the uncompresser, with the compression of the file
+	compressor, err := getBackupCompressor(fe.Compression)
+	if err != nil {
+		return err
+	}
+	uncompresser, err := compressor.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := uncompresser.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	// copy the data. Will also write to the hasher
+	if _, err := io.Copy(dst, uncompresser); err != nil {
+		return fmt.Errorf("cannot restore %v: %v", fe.Name, err)
+	}
+
+	// the uncompresser may not read the end of the stored data,
+	// like the last encrypted chunk: read it so it is checked and
+	// hashed
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return fmt.Errorf("cannot restore %v: %v", fe.Name, err)
+	}
+
+	// check the hash
+	hash := hasher.HashString()
+	if hash != fe.Hash {
+		return fmt.Errorf("hash mismatch for %v, got %v expected %v", fe.Name, hash, fe.Hash)
+	}
+	return nil
+}
+
+// removeExistingData removes the files a full backup restores, so
+// none of them is left behind when the databases are replaced.
+func removeExistingData(cnf *Mycnf) error {
+	fes, err := findFilesTobackup(cnf)
+	if err != nil {
+		return err
+	}
+	for _, fe := range fes {
+		name, err := fe.fullPath(cnf)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(name); err != nil {
+			return fmt.Errorf("cannot remove %v: %v", name, err)
+		}
+	}
+	return nil
+}
+
 // Restore is the main entry point for backup restore.  If there is no
 // appropriate backup on the BackupStorage, Restore logs an error
 // and returns ErrNoBackup. Any other error is returned.
 func Restore(ctx context.Context, mysqld MysqlDaemon, dir string, restoreConcurrency int, hookExtraEnv map[string]string) (proto.ReplicationPosition, error) {
-	// find the right backup handle: most recent one, with a MANIFEST
+	// find the right backup handle: most recent full one, with a MANIFEST
 	log.Infof("Restore: looking for a suitable backup to restore")
 	bs, err := backupstorage.GetBackupStorage()
 	if err != nil {
@@ -527,20 +746,18 @@ func Restore(ctx context.Context, mysqld MysqlDaemon, dir string, restoreConcurr
 		return proto.ReplicationPosition{}, fmt.Errorf("ListBackups failed: %v", err)
 	}
 	var bh backupstorage.BackupHandle
-	var bm BackupManifest
+	var bm *BackupManifest
 	var toRestore int
 	for toRestore = len(bhs) - 1; toRestore >= 0; toRestore-- {
 		bh = bhs[toRestore]
-		rc, err := bh.ReadFile(backupManifest)
+		bm, err = readManifest(bh)
 		if err != nil {
-			log.Warningf("Possibly incomplete backup %v in directory %v on BackupStorage: can't read MANIFEST: %v)", bh.Name(), dir, err)
+			log.Warningf("Possibly incomplete backup %v in directory %v on BackupStorage (%v)", bh.Name(), dir, err)
 			continue
 		}
-
-		err = json.NewDecoder(rc).Decode(&bm)
-		rc.Close()
-		if err != nil {
-			log.Warningf("Possibly incomplete backup %v in directory %v on BackupStorage (cannot JSON decode MANIFEST: %v)", bh.Name(), dir, err)
+		if bm.Incremental {
+			// incremental backups are only used by
+			// RestoreToPosition and RestoreToTime
 			continue
 		}
 
@@ -561,40 +778,56 @@ func Restore(ctx context.Context, mysqld MysqlDaemon, dir string, restoreConcurr
 		return proto.ReplicationPosition{}, ErrExistingDB
 	}
 
-	log.Infof("Restore: shutdown mysqld")
-	err = mysqld.Shutdown(ctx, true)
-	if err != nil {
+	if err := restoreFull(ctx, mysqld, logutil.NewConsoleLogger(), bh, bm, restoreConcurrency, false /* removeExisting */); err != nil {
 		return proto.ReplicationPosition{}, err
 	}
+	return bm.ReplicationPosition, nil
+}
 
-	log.Infof("Restore: copying all files")
-	if err := restoreFiles(mysqld.Cnf(), bh, bm.FileEntries, restoreConcurrency); err != nil {
-		return proto.ReplicationPosition{}, err
+// restoreFull replaces the data of mysqld with the files of a full
+// backup, and restarts it. If removeExisting is set, the files of
+// the existing databases are removed first.
+func restoreFull(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.BackupHandle, bm *BackupManifest, restoreConcurrency int, removeExisting bool) error {
+	logger.Infof("Restore: shutdown mysqld")
+	err := mysqld.Shutdown(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	if removeExisting {
+		logger.Infof("Restore: removing the existing data")
+		if err := removeExistingData(mysqld.Cnf()); err != nil {
+			return err
+		}
+	}
+
+	be, err := getRestoreEngine(bm)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Restore: copying all files")
+	if err := be.ExecuteRestore(ctx, mysqld.Cnf(), logger, bh, bm, restoreConcurrency); err != nil {
+		return err
 	}
 
 	// mysqld needs to be running in order for mysql_upgrade to work.
-	log.Infof("Restore: starting mysqld for mysql_upgrade")
+	logger.Infof("Restore: starting mysqld for mysql_upgrade")
 	err = mysqld.Start(ctx)
 	if err != nil {
-		return proto.ReplicationPosition{}, err
+		return err
 	}
 
-	log.Infof("Restore: running mysql_upgrade")
+	logger.Infof("Restore: running mysql_upgrade")
 	if err := mysqld.RunMysqlUpgrade(); err != nil {
-		return proto.ReplicationPosition{}, fmt.Errorf("mysql_upgrade failed: %v", err)
+		return fmt.Errorf("mysql_upgrade failed: %v", err)
 	}
 
 	// The MySQL manual recommends restarting mysqld after running mysql_upgrade,
 	// so that any changes made to system tables take effect.
-	log.Infof("Restore: restarting mysqld after mysql_upgrade")
+	logger.Infof("Restore: restarting mysqld after mysql_upgrade")
 	err = mysqld.Shutdown(ctx, true)
 	if err != nil {
-		return proto.ReplicationPosition{}, err
-	}
-	err = mysqld.Start(ctx)
-	if err != nil {
-		return proto.ReplicationPosition{}, err
+		return err
 	}
-
-	return bm.ReplicationPosition, nil
+	return mysqld.Start(ctx)
 }
@@ -0,0 +1,118 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+	"github.com/youtube/vitess/go/cgzip"
+)
+
+// This file contains the compressors of the backup files. The name of
+// the compressor of each file is saved in the MANIFEST, so a backup
+// is restored with the compressor it was taken with.
+
+var (
+	backupCompression      = flag.String("backup_compression", "gzip", "compression of the backup files: gzip, snappy or none")
+	backupCompressionLevel = flag.Int("backup_compression_level", cgzip.Z_BEST_SPEED, "compression level of the gzip backup compression, from 1 (fastest) to 9 (smallest)")
+)
+
+const (
+	// defaultBackupCompression is the compressor of the files
+	// with no Compression in the MANIFEST, taken by older versions.
+	defaultBackupCompression = "gzip"
+)
+
+// BackupCompressor compresses and uncompresses the backup files.
+type BackupCompressor interface {
+	// NewWriter returns a writer compressing into w. Closing it
+	// flushes the compressed data, but doesn't close w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns a reader uncompressing r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// BackupCompressorMap contains the registered compressors, by the
+// name saved in the MANIFEST.
+var BackupCompressorMap = make(map[string]BackupCompressor)
+
+func init() {
+	BackupCompressorMap["gzip"] = gzipCompressor{}
+	BackupCompressorMap["snappy"] = snappyCompressor{}
+	BackupCompressorMap["none"] = noCompressor{}
+}
+
+// getBackupCompressor returns the compressor with the given name, or
+// the default one for an empty name.
+func getBackupCompressor(name string) (BackupCompressor, error) {
+	if name == "" {
+		name = defaultBackupCompression
+	}
+	c, ok := BackupCompressorMap[name]
+	if !ok {
+		return nil, fmt.Errorf("no registered backup compressor %v", name)
+	}
+	return c, nil
+}
+
+// gzipCompressor uses cgzip, at -backup_compression_level.
+type gzipCompressor struct{}
+
+// NewWriter is part of the BackupCompressor interface.
+func (gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	gz, err := cgzip.NewWriterLevel(w, *backupCompressionLevel)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create gziper: %v", err)
+	}
+	return gz, nil
+}
+
+// NewReader is part of the BackupCompressor interface.
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return cgzip.NewReader(r)
+}
+
+// snappyCompressor uses the snappy framing format. It is much faster
+// than gzip, for a lower compression ratio, and doesn't use cgo.
+type snappyCompressor struct{}
+
+// NewWriter is part of the BackupCompressor interface.
+func (snappyCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{snappy.NewWriter(w)}, nil
+}
+
+// NewReader is part of the BackupCompressor interface.
+func (snappyCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(snappy.NewReader(r)), nil
+}
+
+// noCompressor stores the files as they are.
+type noCompressor struct{}
+
+// NewWriter is part of the BackupCompressor interface.
+func (noCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+// NewReader is part of the BackupCompressor interface.
+func (noCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(r), nil
+}
+
+// nopWriteCloser adds a Close method that does nothing to a writer
+// that doesn't buffer anything.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close is part of the io.Closer interface.
+func (nopWriteCloser) Close() error {
+	return nil
+}
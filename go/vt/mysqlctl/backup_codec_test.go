@@ -0,0 +1,152 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/logutil"
+	"github.com/youtube/vitess/go/vt/mysqlctl/filebackupstorage"
+)
+
+// testBackupData returns compressible data of the given size.
+func testBackupData(size int) []byte {
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < size; i++ {
+		fmt.Fprintf(&buf, "row %v: some data that compresses well\n", i)
+	}
+	return buf.Bytes()[:size]
+}
+
+func TestBackupCompressors(t *testing.T) {
+	data := testBackupData(300 * 1024)
+	for _, name := range []string{"gzip", "snappy", "none"} {
+		c, err := getBackupCompressor(name)
+		if err != nil {
+			t.Fatalf("getBackupCompressor(%v): %v", name, err)
+		}
+		var compressed bytes.Buffer
+		w, err := c.NewWriter(&compressed)
+		if err != nil {
+			t.Fatalf("%v NewWriter: %v", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("%v Write: %v", name, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%v Close: %v", name, err)
+		}
+		if name != "none" && compressed.Len() >= len(data) {
+			t.Errorf("%v didn't compress: %v bytes for %v", name, compressed.Len(), len(data))
+		}
+		r, err := c.NewReader(&compressed)
+		if err != nil {
+			t.Fatalf("%v NewReader: %v", name, err)
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%v ReadAll: %v", name, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%v uncompressed data is different", name)
+		}
+	}
+
+	// the files of older backups have no compression
+	if c, err := getBackupCompressor(""); err != nil || c != (gzipCompressor{}) {
+		t.Errorf("getBackupCompressor(\"\"): got %v %v, want gzip", c, err)
+	}
+	if _, err := getBackupCompressor("lzma"); err == nil {
+		t.Errorf("getBackupCompressor(lzma) should have failed")
+	}
+}
+
+func TestBackupRestoreFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "backup_codec_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(root)
+	*filebackupstorage.FileBackupStorageRoot = path.Join(root, "backups")
+	keyFile := path.Join(root, "key")
+	if err := ioutil.WriteFile(keyFile, []byte(testKeyHex+"\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	defer func(compression, keyFile string) {
+		*backupCompression = compression
+		*backupEncryptionKeyFile = keyFile
+	}(*backupCompression, *backupEncryptionKeyFile)
+
+	data := testBackupData(200 * 1024)
+	fmd := NewFakeMysqlDaemon(nil)
+	fmd.Mycnf = &Mycnf{DataDir: path.Join(root, "src")}
+	if err := os.MkdirAll(path.Join(fmd.Mycnf.DataDir, "vt_test"), os.ModePerm); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := ioutil.WriteFile(path.Join(fmd.Mycnf.DataDir, "vt_test", "t1.ibd"), data, 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	fbs := &filebackupstorage.FileBackupStorage{}
+	for _, compression := range []string{"gzip", "snappy", "none"} {
+		for _, encrypted := range []bool{false, true} {
+			name := fmt.Sprintf("%v-%v", compression, encrypted)
+			*backupCompression = compression
+			*backupEncryptionKeyFile = ""
+			if encrypted {
+				*backupEncryptionKeyFile = keyFile
+			}
+
+			bh, err := fbs.StartBackup("ks/0", name)
+			if err != nil {
+				t.Fatalf("StartBackup failed: %v", err)
+			}
+			bm := &BackupManifest{
+				FileEntries: []FileEntry{{Base: backupData, Name: "vt_test/t1.ibd"}},
+			}
+			if err := backupFiles(fmd, logutil.NewConsoleLogger(), bh, bm, 2); err != nil {
+				t.Fatalf("%v: backupFiles failed: %v", name, err)
+			}
+			if err := bh.EndBackup(); err != nil {
+				t.Fatalf("EndBackup failed: %v", err)
+			}
+			fe := bm.FileEntries[0]
+			if fe.Compression != compression || (fe.Encryption != "") != encrypted {
+				t.Errorf("%v: unexpected FileEntry %+v", name, fe)
+			}
+
+			bhs, err := fbs.ListBackups("ks/0")
+			if err != nil {
+				t.Fatalf("ListBackups failed: %v", err)
+			}
+			for _, b := range bhs {
+				if b.Name() == name {
+					bh = b
+				}
+			}
+			cnf := &Mycnf{DataDir: path.Join(root, name)}
+			if err := restoreFiles(cnf, bh, bm.FileEntries, 2); err != nil {
+				t.Fatalf("%v: restoreFiles failed: %v", name, err)
+			}
+			got, err := ioutil.ReadFile(path.Join(cnf.DataDir, "vt_test", "t1.ibd"))
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("%v: restored file is different: %v", name, err)
+			}
+
+			// an encrypted backup can't be restored without the key
+			if encrypted {
+				*backupEncryptionKeyFile = ""
+				if err := restoreFiles(cnf, bh, bm.FileEntries, 2); err == nil {
+					t.Errorf("%v: restoreFiles without the key should have failed", name)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/youtube/vitess/go/vt/hook"
+)
+
+// This file encrypts the backup files, after compression. AES-GCM
+// authenticates the data, but only encrypts small messages, so the
+// files are split in chunks, each encrypted separately:
+// - the file starts with a random nonce
+// - each chunk is a flag byte, 1 for the last chunk and 0 for the
+//   others, the length of the encrypted chunk as 4 bytes big endian,
+//   and the encrypted chunk. The nonce of a chunk is the nonce of the
+//   file xored with the chunk number, and the flag byte is
+//   authenticated with it, so the chunks can't be reordered, and the
+//   file can't be truncated.
+
+var (
+	backupEncryptionKeyFile = flag.String("backup_encryption_key_file", "", "if set, the backup files are encrypted with the key in this file: 32 bytes, hex encoded. Restoring encrypted backups needs the same key")
+	backupEncryptionKeyHook = flag.String("backup_encryption_key_hook", "", "if set, the backup files are encrypted with the key printed by this hook: 32 bytes, hex encoded. Restoring encrypted backups needs the same key")
+)
+
+const (
+	// backupEncryptionAES256GCM is the name of the encryption saved
+	// in the MANIFEST.
+	backupEncryptionAES256GCM = "aes-256-gcm"
+
+	// encryptedChunkSize is the size of the unencrypted chunks.
+	encryptedChunkSize = 64 * 1024
+
+	// encryptedChunkHeaderSize is the size of the flag and length
+	// before each chunk.
+	encryptedChunkHeaderSize = 5
+)
+
+// backupEncryptionKey returns the key of -backup_encryption_key_file
+// or -backup_encryption_key_hook, or nil if neither is set.
+func backupEncryptionKey() ([]byte, error) {
+	var data string
+	switch {
+	case *backupEncryptionKeyFile != "" && *backupEncryptionKeyHook != "":
+		return nil, fmt.Errorf("only one of -backup_encryption_key_file and -backup_encryption_key_hook can be set")
+	case *backupEncryptionKeyFile != "":
+		content, err := ioutil.ReadFile(*backupEncryptionKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read backup encryption key: %v", err)
+		}
+		data = string(content)
+	case *backupEncryptionKeyHook != "":
+		hr := hook.NewSimpleHook(*backupEncryptionKeyHook).Execute()
+		if hr.ExitStatus != hook.HOOK_SUCCESS {
+			return nil, fmt.Errorf("backup encryption key hook %v failed with status %v: %v", *backupEncryptionKeyHook, hr.ExitStatus, hr.Stderr)
+		}
+		data = hr.Stdout
+	default:
+		return nil, nil
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return nil, fmt.Errorf("backup encryption key is not hex encoded: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("backup encryption key has %v bytes, it needs 32", len(key))
+	}
+	return key, nil
+}
+
+// newAES256GCM returns the AEAD for the key.
+func newAES256GCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce of a chunk.
+func chunkNonce(nonce []byte, chunk uint64) []byte {
+	result := make([]byte, len(nonce))
+	copy(result, nonce)
+	var c [8]byte
+	binary.BigEndian.PutUint64(c[:], chunk)
+	for i := range c {
+		result[len(result)-8+i] ^= c[i]
+	}
+	return result
+}
+
+// encryptingWriter encrypts the data written to it into w.
+type encryptingWriter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	nonce []byte
+	chunk uint64
+	buf   []byte
+}
+
+// newEncryptingWriter returns a writer encrypting into w with key.
+// It must be closed to write the last chunk.
+func newEncryptingWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	aead, err := newAES256GCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("cannot generate nonce: %v", err)
+	}
+	if _, err := w.Write(nonce); err != nil {
+		return nil, err
+	}
+	return &encryptingWriter{
+		w:     w,
+		aead:  aead,
+		nonce: nonce,
+		buf:   make([]byte, 0, encryptedChunkSize),
+	}, nil
+}
+
+// Write is part of the io.Writer interface. A full chunk is only
+// written when more data comes, as the last chunk is flagged.
+func (ew *encryptingWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if len(ew.buf) == encryptedChunkSize {
+			if err := ew.writeChunk(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(ew.buf[len(ew.buf):encryptedChunkSize], p)
+		ew.buf = ew.buf[:len(ew.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close is part of the io.Closer interface. It writes the last chunk,
+// but doesn't close the underlying writer.
+func (ew *encryptingWriter) Close() error {
+	return ew.writeChunk(true)
+}
+
+func (ew *encryptingWriter) writeChunk(last bool) error {
+	header := make([]byte, encryptedChunkHeaderSize, encryptedChunkHeaderSize+len(ew.buf)+ew.aead.Overhead())
+	if last {
+		header[0] = 1
+	}
+	data := ew.aead.Seal(header, chunkNonce(ew.nonce, ew.chunk), ew.buf, header[:1])
+	binary.BigEndian.PutUint32(data[1:encryptedChunkHeaderSize], uint32(len(data)-encryptedChunkHeaderSize))
+	ew.chunk++
+	ew.buf = ew.buf[:0]
+	_, err := ew.w.Write(data)
+	return err
+}
+
+// decryptingReader decrypts the data written by encryptingWriter.
+type decryptingReader struct {
+	r     io.Reader
+	aead  cipher.AEAD
+	nonce []byte
+	chunk uint64
+	buf   []byte
+	last  bool
+}
+
+// newDecryptingReader returns a reader decrypting r with key. It
+// returns an error if the data was modified or truncated.
+func newDecryptingReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newAES256GCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		return nil, fmt.Errorf("cannot read nonce: %v", err)
+	}
+	return &decryptingReader{
+		r:     r,
+		aead:  aead,
+		nonce: nonce,
+	}, nil
+}
+
+// Read is part of the io.Reader interface.
+func (dr *decryptingReader) Read(p []byte) (int, error) {
+	for len(dr.buf) == 0 {
+		if dr.last {
+			return 0, io.EOF
+		}
+		if err := dr.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, dr.buf)
+	dr.buf = dr.buf[n:]
+	return n, nil
+}
+
+func (dr *decryptingReader) readChunk() error {
+	header := make([]byte, encryptedChunkHeaderSize)
+	if _, err := io.ReadFull(dr.r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("encrypted data is truncated")
+		}
+		return err
+	}
+	if header[0] > 1 {
+		return fmt.Errorf("invalid encrypted chunk flag %v", header[0])
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > uint32(encryptedChunkSize+dr.aead.Overhead()) {
+		return fmt.Errorf("invalid encrypted chunk length %v", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(dr.r, data); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("encrypted data is truncated")
+		}
+		return err
+	}
+	buf, err := dr.aead.Open(data[:0], chunkNonce(dr.nonce, dr.chunk), data, header[:1])
+	if err != nil {
+		return fmt.Errorf("cannot decrypt: wrong key, or corrupted data")
+	}
+	dr.chunk++
+	dr.buf = buf
+	dr.last = header[0] == 1
+	if dr.last {
+		// there can't be anything after the last chunk
+		var b [1]byte
+		if _, err := io.ReadFull(dr.r, b[:]); err == nil {
+			return fmt.Errorf("unexpected data after the last encrypted chunk")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+const testKeyHex = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func testKey(t *testing.T) []byte {
+	key, err := hex.DecodeString(testKeyHex)
+	if err != nil {
+		t.Fatalf("DecodeString failed: %v", err)
+	}
+	return key
+}
+
+func encrypt(t *testing.T, key, data []byte) []byte {
+	var buf bytes.Buffer
+	w, err := newEncryptingWriter(&buf, key)
+	if err != nil {
+		t.Fatalf("newEncryptingWriter failed: %v", err)
+	}
+	// write in small pieces, to go through the chunk boundaries
+	for len(data) > 0 {
+		n := 1000
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		data = data[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decrypt(key, data []byte) ([]byte, error) {
+	r, err := newDecryptingReader(bytes.NewReader(data), key)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func TestBackupEncryption(t *testing.T) {
+	key := testKey(t)
+	for _, size := range []int{0, 10, encryptedChunkSize, 2*encryptedChunkSize + 3} {
+		data := testBackupData(size)
+		encrypted := encrypt(t, key, data)
+		if bytes.Contains(encrypted, []byte("some data")) {
+			t.Errorf("size %v: encrypted data contains plain text", size)
+		}
+		got, err := decrypt(key, encrypted)
+		if err != nil {
+			t.Fatalf("size %v: decrypt failed: %v", size, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("size %v: decrypted data is different", size)
+		}
+	}
+}
+
+func TestBackupEncryptionErrors(t *testing.T) {
+	key := testKey(t)
+	data := testBackupData(2*encryptedChunkSize + 3)
+	encrypted := encrypt(t, key, data)
+	chunk := encryptedChunkHeaderSize + encryptedChunkSize + 16
+
+	wrongKey := testKey(t)
+	wrongKey[0] ^= 1
+	modified := append([]byte(nil), encrypted...)
+	modified[len(modified)-1] ^= 1
+	lastFlag := append([]byte(nil), encrypted[:12+2*chunk]...)
+	lastFlag[12+chunk] = 1
+
+	testcases := []struct {
+		desc string
+		key  []byte
+		data []byte
+	}{
+		{"wrong key", wrongKey, encrypted},
+		{"modified", key, modified},
+		{"truncated chunk", key, encrypted[:len(encrypted)-1]},
+		{"missing last chunk", key, encrypted[:12+2*chunk]},
+		{"flagged as last chunk", key, lastFlag},
+		{"trailing data", key, append(append([]byte(nil), encrypted...), 0)},
+	}
+	for _, tc := range testcases {
+		if _, err := decrypt(tc.key, tc.data); err == nil {
+			t.Errorf("%v: decrypt should have failed", tc.desc)
+		}
+	}
+}
+
+func TestBackupEncryptionKey(t *testing.T) {
+	defer func(keyFile string) {
+		*backupEncryptionKeyFile = keyFile
+	}(*backupEncryptionKeyFile)
+
+	*backupEncryptionKeyFile = ""
+	if key, err := backupEncryptionKey(); key != nil || err != nil {
+		t.Errorf("backupEncryptionKey with no key: got %v %v", key, err)
+	}
+
+	dir, err := ioutil.TempDir("", "backup_encryption_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	*backupEncryptionKeyFile = path.Join(dir, "key")
+	for content, ok := range map[string]bool{
+		testKeyHex + "\n":   true,
+		testKeyHex[:62]:     false,
+		"not a hex key":     false,
+		testKeyHex + "0000": false,
+	} {
+		if err := ioutil.WriteFile(*backupEncryptionKeyFile, []byte(content), 0600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		key, err := backupEncryptionKey()
+		if ok && (err != nil || !bytes.Equal(key, testKey(t))) {
+			t.Errorf("backupEncryptionKey(%q): got %v %v", content, key, err)
+		}
+		if !ok && err == nil {
+			t.Errorf("backupEncryptionKey(%q) should have failed", content)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/youtube/vitess/go/vt/logutil"
+	"github.com/youtube/vitess/go/vt/mysqlctl/backupstorage"
+	"golang.org/x/net/context"
+)
+
+var (
+	// BackupEngineImplementation is the implementation to use
+	// for BackupEngine. Backups are always restored with the
+	// engine they were taken with.
+	BackupEngineImplementation = flag.String("backup_engine_implementation", builtinBackupEngineName, "which implementation to use for the backup method, builtin or hot")
+)
+
+const (
+	// builtinBackupEngineName is the engine of the backups with
+	// no BackupEngine in the MANIFEST, taken by older versions.
+	builtinBackupEngineName = "builtin"
+)
+
+// BackupEngine is the interface to take a full backup, and restore
+// it, with a given method.
+type BackupEngine interface {
+	// ExecuteBackup takes a backup of mysqld, and stores its
+	// files and MANIFEST in bh.
+	ExecuteBackup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.BackupHandle, backupConcurrency int, hookExtraEnv map[string]string) error
+
+	// ExecuteRestore puts the files of the backup in the
+	// directories of cnf, ready for mysqld to start. mysqld is
+	// not running.
+	ExecuteRestore(ctx context.Context, cnf *Mycnf, logger logutil.Logger, bh backupstorage.BackupHandle, bm *BackupManifest, restoreConcurrency int) error
+
+	// ShouldDrainForBackup returns true if the tablet has to stop
+	// serving while the backup is taken.
+	ShouldDrainForBackup() bool
+}
+
+// BackupEngineMap contains the registered implementations for
+// BackupEngine, by the name saved in the MANIFEST.
+var BackupEngineMap = make(map[string]BackupEngine)
+
+func init() {
+	BackupEngineMap[builtinBackupEngineName] = builtinBackupEngine{}
+}
+
+// GetBackupEngine returns the BackupEngine to take backups with.
+// Should be called after flags have been initialized.
+func GetBackupEngine() (BackupEngine, error) {
+	be, ok := BackupEngineMap[*BackupEngineImplementation]
+	if !ok {
+		return nil, fmt.Errorf("no registered implementation of BackupEngine %v", *BackupEngineImplementation)
+	}
+	return be, nil
+}
+
+// getRestoreEngine returns the BackupEngine a backup was taken with.
+func getRestoreEngine(bm *BackupManifest) (BackupEngine, error) {
+	name := bm.BackupEngine
+	if name == "" {
+		name = builtinBackupEngineName
+	}
+	be, ok := BackupEngineMap[name]
+	if !ok {
+		return nil, fmt.Errorf("the backup was taken with the unknown BackupEngine %v", name)
+	}
+	return be, nil
+}
+
+// builtinBackupEngine stops mysqld, and copies its files.
+type builtinBackupEngine struct{}
+
+// ExecuteBackup is part of the BackupEngine interface.
+func (builtinBackupEngine) ExecuteBackup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.BackupHandle, backupConcurrency int, hookExtraEnv map[string]string) error {
+	return backup(ctx, mysqld, logger, bh, backupConcurrency, hookExtraEnv)
+}
+
+// ExecuteRestore is part of the BackupEngine interface.
+func (builtinBackupEngine) ExecuteRestore(ctx context.Context, cnf *Mycnf, logger logutil.Logger, bh backupstorage.BackupHandle, bm *BackupManifest, restoreConcurrency int) error {
+	return restoreFiles(cnf, bh, bm.FileEntries, restoreConcurrency)
+}
+
+// ShouldDrainForBackup is part of the BackupEngine interface.
+func (builtinBackupEngine) ShouldDrainForBackup() bool {
+	return true
+}
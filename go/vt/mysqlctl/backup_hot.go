@@ -0,0 +1,199 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/youtube/vitess/go/vt/hook"
+	"github.com/youtube/vitess/go/vt/logutil"
+	"github.com/youtube/vitess/go/vt/mysqlctl/backupstorage"
+	"github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"golang.org/x/net/context"
+)
+
+// This file contains the hot BackupEngine. It runs an external hot
+// backup tool, like xtrabackup, through a hook, while mysqld keeps
+// running and replicating, and the tablet keeps serving. The hook is
+// called with one parameter:
+// - 'backup': it writes the backup stream on its standard output,
+//   and the GTID set the backup is at in $POSITION_FILE.
+// - 'restore': it extracts the backup stream from its standard input
+//   into the mysqld directories.
+// - 'prepare': it makes the extracted files consistent, for instance
+//   by applying the InnoDB log, before mysqld starts.
+// The hook also gets the mysqld directories and configuration in its
+// environment, see hotBackupHookEnv.
+
+var (
+	hotBackupHook = flag.String("hot_backup_hook", "hot_backup", "name of the hook running the hot backup tool, for the hot backup engine")
+)
+
+const (
+	hotBackupEngineName = "hot"
+
+	// hotBackupStream is the name of the FileEntry of the backup
+	// stream in the MANIFEST.
+	hotBackupStream = "stream"
+)
+
+// errHotBackupHookExited is returned when writing to the restore
+// hook after it exited.
+var errHotBackupHookExited = errors.New("the hook exited before reading all the backup stream")
+
+func init() {
+	BackupEngineMap[hotBackupEngineName] = hotBackupEngine{}
+}
+
+// hotBackupEngine stores the output of the hot backup hook.
+type hotBackupEngine struct{}
+
+// hotBackupHookEnv returns the environment of the hot backup hook.
+func hotBackupHookEnv(cnf *Mycnf, hookExtraEnv map[string]string) map[string]string {
+	env := map[string]string{
+		"MY_CNF":                    cnf.path,
+		"DATA_DIR":                  cnf.DataDir,
+		"INNODB_DATA_HOME_DIR":      cnf.InnodbDataHomeDir,
+		"INNODB_LOG_GROUP_HOME_DIR": cnf.InnodbLogGroupHomeDir,
+		"MYSQL_SOCKET":              cnf.SocketFile,
+		"TMP_DIR":                   cnf.TmpDir,
+	}
+	for k, v := range hookExtraEnv {
+		env[k] = v
+	}
+	return env
+}
+
+// hotBackupHookError returns the error for a failed hook, or nil.
+func hotBackupHookError(hr *hook.HookResult, action string) error {
+	if hr.ExitStatus == hook.HOOK_SUCCESS {
+		return nil
+	}
+	return fmt.Errorf("%v hook %v failed: %v", *hotBackupHook, action, hr.String())
+}
+
+// ExecuteBackup is part of the BackupEngine interface.
+func (hotBackupEngine) ExecuteBackup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, bh backupstorage.BackupHandle, backupConcurrency int, hookExtraEnv map[string]string) error {
+	// the hook only reports the GTID set, it has the flavor of
+	// the current position
+	pos, err := mysqld.MasterPosition()
+	if err != nil {
+		return fmt.Errorf("cannot get replication position: %v", err)
+	}
+	if pos.GTIDSet == nil {
+		return fmt.Errorf("cannot get the flavor of the replication position")
+	}
+	flavor := pos.GTIDSet.Flavor()
+
+	bw, err := newBackupFileWriter()
+	if err != nil {
+		return err
+	}
+	positionFile, err := ioutil.TempFile("", "hot_backup_position")
+	if err != nil {
+		return err
+	}
+	positionFile.Close()
+	defer os.Remove(positionFile.Name())
+
+	// run the hook, and store its output as it comes
+	h := hook.NewHook(*hotBackupHook, []string{"backup"})
+	h.ExtraEnv = hotBackupHookEnv(mysqld.Cnf(), hookExtraEnv)
+	h.ExtraEnv["POSITION_FILE"] = positionFile.Name()
+	logger.Infof("running %v hook backup", *hotBackupHook)
+	pr, pw := io.Pipe()
+	hookErr := make(chan error, 1)
+	go func() {
+		err := hotBackupHookError(h.ExecuteStreaming(nil, pw), "backup")
+		pw.CloseWithError(err)
+		hookErr <- err
+	}()
+	fe := FileEntry{Name: hotBackupStream}
+	err = bw.writeFile(bh, "0", pr, &fe)
+	// if we stopped reading early, the hook gets an error writing
+	pr.Close()
+	herr := <-hookErr
+	if err != nil {
+		return fmt.Errorf("cannot store the backup stream: %v", err)
+	}
+	if herr != nil {
+		return herr
+	}
+	backupTime := time.Now()
+
+	// the position file may have the GTID set on several lines
+	data, err := ioutil.ReadFile(positionFile.Name())
+	if err != nil {
+		return err
+	}
+	gtidSet := strings.Join(strings.Fields(string(data)), "")
+	if gtidSet == "" {
+		return fmt.Errorf("%v hook backup didn't write the replication position in $POSITION_FILE", *hotBackupHook)
+	}
+	replicationPosition, err := proto.ParseReplicationPosition(flavor, gtidSet)
+	if err != nil {
+		return fmt.Errorf("invalid replication position from %v hook backup: %v", *hotBackupHook, err)
+	}
+	logger.Infof("hot backup is at replication position %v", replicationPosition)
+
+	return writeManifest(bh, &BackupManifest{
+		FileEntries:         []FileEntry{fe},
+		ReplicationPosition: replicationPosition,
+		Time:                backupTime.Unix(),
+		BackupEngine:        hotBackupEngineName,
+	})
+}
+
+// ExecuteRestore is part of the BackupEngine interface.
+func (hotBackupEngine) ExecuteRestore(ctx context.Context, cnf *Mycnf, logger logutil.Logger, bh backupstorage.BackupHandle, bm *BackupManifest, restoreConcurrency int) error {
+	if len(bm.FileEntries) != 1 || bm.FileEntries[0].Name != hotBackupStream {
+		return fmt.Errorf("invalid hot backup: expected only the %v file, got %v", hotBackupStream, bm.FileEntries)
+	}
+	key, err := restoreEncryptionKey(bm.FileEntries)
+	if err != nil {
+		return err
+	}
+	env := hotBackupHookEnv(cnf, nil)
+
+	// feed the backup stream to the hook
+	h := hook.NewHook(*hotBackupHook, []string{"restore"})
+	h.ExtraEnv = env
+	logger.Infof("running %v hook restore", *hotBackupHook)
+	pr, pw := io.Pipe()
+	hookErr := make(chan error, 1)
+	go func() {
+		hr := h.ExecuteStreaming(pr, nil)
+		// if the hook exited early, stop writing to it
+		pr.CloseWithError(errHotBackupHookExited)
+		hookErr <- hotBackupHookError(hr, "restore")
+	}()
+	err = readBackupFile(bh, "0", &bm.FileEntries[0], key, pw)
+	// closing with nil is the end of the stream for the hook
+	pw.CloseWithError(err)
+	if herr := <-hookErr; herr != nil {
+		return herr
+	}
+	if err != nil {
+		return fmt.Errorf("cannot restore the backup stream: %v", err)
+	}
+
+	// and make the files consistent
+	h = hook.NewHook(*hotBackupHook, []string{"prepare"})
+	h.ExtraEnv = env
+	logger.Infof("running %v hook prepare", *hotBackupHook)
+	return hotBackupHookError(h.Execute(), "prepare")
+}
+
+// ShouldDrainForBackup is part of the BackupEngine interface.
+func (hotBackupEngine) ShouldDrainForBackup() bool {
+	return false
+}
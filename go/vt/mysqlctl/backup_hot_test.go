@@ -0,0 +1,130 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/logutil"
+	"github.com/youtube/vitess/go/vt/mysqlctl/filebackupstorage"
+	"golang.org/x/net/context"
+)
+
+// testHotBackupHook is a hot backup hook using tar.
+const testHotBackupHook = `#!/bin/bash
+set -e
+case "$1" in
+backup)
+  tar -C $DATA_DIR -cf - .
+  echo '0-1-5' > $POSITION_FILE
+  ;;
+restore)
+  mkdir -p $DATA_DIR
+  tar -C $DATA_DIR -xf -
+  ;;
+prepare)
+  touch $DATA_DIR/prepared
+  ;;
+esac
+`
+
+func TestHotBackupEngine(t *testing.T) {
+	root, err := ioutil.TempDir("", "backup_hot_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(root)
+	*filebackupstorage.FileBackupStorageRoot = path.Join(root, "backups")
+	if err := os.MkdirAll(path.Join(root, "vthook"), os.ModePerm); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := ioutil.WriteFile(path.Join(root, "vthook", "hot_backup"), []byte(testHotBackupHook), 0755); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	defer os.Setenv("VTROOT", os.Getenv("VTROOT"))
+	os.Setenv("VTROOT", root)
+
+	fmd := NewFakeMysqlDaemon(nil)
+	fmd.Mycnf = &Mycnf{DataDir: path.Join(root, "src")}
+	fmd.CurrentMasterPosition = mariadbPos("0-1-1")
+	if err := os.MkdirAll(path.Join(fmd.Mycnf.DataDir, "vt_test"), os.ModePerm); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	data := testBackupData(100 * 1024)
+	if err := ioutil.WriteFile(path.Join(fmd.Mycnf.DataDir, "vt_test", "t1.ibd"), data, 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	// take the backup
+	ctx := context.Background()
+	be := BackupEngineMap[hotBackupEngineName]
+	if be.ShouldDrainForBackup() {
+		t.Errorf("hot backups shouldn't drain the tablet")
+	}
+	fbs := &filebackupstorage.FileBackupStorage{}
+	bh, err := fbs.StartBackup("ks/0", "hot")
+	if err != nil {
+		t.Fatalf("StartBackup failed: %v", err)
+	}
+	if err := be.ExecuteBackup(ctx, fmd, logutil.NewConsoleLogger(), bh, 1, nil); err != nil {
+		t.Fatalf("ExecuteBackup failed: %v", err)
+	}
+	if err := bh.EndBackup(); err != nil {
+		t.Fatalf("EndBackup failed: %v", err)
+	}
+
+	// check the MANIFEST
+	bhs, err := fbs.ListBackups("ks/0")
+	if err != nil || len(bhs) != 1 {
+		t.Fatalf("ListBackups: got %v %v", bhs, err)
+	}
+	bm, err := readManifest(bhs[0])
+	if err != nil {
+		t.Fatalf("readManifest failed: %v", err)
+	}
+	if bm.BackupEngine != hotBackupEngineName || !bm.ReplicationPosition.Equal(mariadbPos("0-1-5")) || len(bm.FileEntries) != 1 {
+		t.Errorf("unexpected MANIFEST: %+v", bm)
+	}
+	if re, err := getRestoreEngine(bm); err != nil || re != be {
+		t.Errorf("getRestoreEngine: got %v %v", re, err)
+	}
+
+	// restore it, the hook extracts and prepares the files
+	cnf := &Mycnf{DataDir: path.Join(root, "dst")}
+	if err := be.ExecuteRestore(ctx, cnf, logutil.NewConsoleLogger(), bhs[0], bm, 1); err != nil {
+		t.Fatalf("ExecuteRestore failed: %v", err)
+	}
+	if got, err := ioutil.ReadFile(path.Join(cnf.DataDir, "vt_test", "t1.ibd")); err != nil || string(got) != string(data) {
+		t.Errorf("restored file is different: %v", err)
+	}
+	if _, err := os.Stat(path.Join(cnf.DataDir, "prepared")); err != nil {
+		t.Errorf("the restored files were not prepared: %v", err)
+	}
+
+	// a failing hook fails the backup
+	if err := ioutil.WriteFile(path.Join(root, "vthook", "hot_backup"), []byte("#!/bin/bash\necho failed >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	bh, err = fbs.StartBackup("ks/0", "failed")
+	if err != nil {
+		t.Fatalf("StartBackup failed: %v", err)
+	}
+	if err := be.ExecuteBackup(ctx, fmd, logutil.NewConsoleLogger(), bh, 1, nil); err == nil {
+		t.Errorf("ExecuteBackup with a failing hook should have failed")
+	}
+}
+
+func TestGetRestoreEngine(t *testing.T) {
+	// older backups have no engine
+	if be, err := getRestoreEngine(&BackupManifest{}); err != nil || be != (builtinBackupEngine{}) {
+		t.Errorf("getRestoreEngine of an older backup: got %v %v", be, err)
+	}
+	if _, err := getRestoreEngine(&BackupManifest{BackupEngine: "unknown"}); err == nil {
+		t.Errorf("getRestoreEngine of an unknown engine should have failed")
+	}
+}
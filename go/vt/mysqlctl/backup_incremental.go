@@ -0,0 +1,387 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/vt/logutil"
+	"github.com/youtube/vitess/go/vt/mysqlctl/backupstorage"
+	"github.com/youtube/vitess/go/vt/mysqlctl/proto"
+)
+
+// This file handles the incremental backups, and the point in time
+// restores: an incremental backup stores the binlog files written
+// since the previous backup, and a restore replays them on top of a
+// full backup, up to a replication position or a point in time.
+
+// backupInfo is a backup with its MANIFEST.
+type backupInfo struct {
+	bh backupstorage.BackupHandle
+	bm *BackupManifest
+}
+
+// readBackups returns the backups of a directory which have a valid
+// MANIFEST, oldest first.
+func readBackups(logger logutil.Logger, bs backupstorage.BackupStorage, dir string) ([]backupInfo, error) {
+	bhs, err := bs.ListBackups(dir)
+	if err != nil {
+		return nil, fmt.Errorf("ListBackups failed: %v", err)
+	}
+	var result []backupInfo
+	for _, bh := range bhs {
+		bm, err := readManifest(bh)
+		if err != nil {
+			logger.Warningf("Possibly incomplete backup %v in directory %v on BackupStorage (%v)", bh.Name(), dir, err)
+			continue
+		}
+		result = append(result, backupInfo{bh, bm})
+	}
+	return result, nil
+}
+
+// BackupIncremental is the entry point for an incremental backup: it
+// stores the binlog files of mysqld which have transactions after the
+// position of the most recent backup of dir. Unlike Backup, it stops
+// neither mysqld nor replication. The binlogs of mysqld must go back
+// to the position of the most recent backup.
+func BackupIncremental(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, dir, name string, backupConcurrency int) error {
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return err
+	}
+
+	// the backup starts where the previous one stopped
+	backups, err := readBackups(logger, bs, dir)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("no backup in %v for the incremental backup to start from, take a full backup first", dir)
+	}
+	fromPosition := backups[len(backups)-1].bm.ReplicationPosition
+	logger.Infof("incremental backup starting at position %v of backup %v", fromPosition, backups[len(backups)-1].bh.Name())
+
+	// close the current binlog file, so all the files but the
+	// new current one are complete
+	logger.Infof("flushing binary logs")
+	if err := mysqld.ExecuteSuperQueryList([]string{"FLUSH BINARY LOGS"}); err != nil {
+		return fmt.Errorf("cannot flush binary logs: %v", err)
+	}
+	backupTime := time.Now()
+	qr, err := mysqld.FetchSuperQuery("SHOW BINARY LOGS")
+	if err != nil {
+		return fmt.Errorf("cannot list binary logs: %v", err)
+	}
+	if len(qr.Rows) < 2 {
+		return fmt.Errorf("no complete binary log to backup")
+	}
+
+	// find the files with transactions after fromPosition, and
+	// the position they go to
+	binlogDir := path.Dir(mysqld.Cnf().BinLogPath)
+	replicationPosition := fromPosition
+	var fes []FileEntry
+	for i, row := range qr.Rows[:len(qr.Rows)-1] {
+		file := row[0].String()
+		bfi, err := mysqld.ReadBinlogFile(path.Join(binlogDir, file))
+		if err != nil {
+			return err
+		}
+		if i == 0 && !fromPosition.IsZero() && !coversBinlogFileStart(fromPosition, bfi) {
+			return fmt.Errorf("the oldest binary log %v starts after %v, the binary logs since the previous backup may have been purged: take a full backup", file, fromPosition)
+		}
+		hasNew := false
+		for _, tx := range bfi.Transactions {
+			if containsGTID(fromPosition, tx.GTID) {
+				continue
+			}
+			hasNew = true
+			replicationPosition = proto.AppendGTID(replicationPosition, tx.GTID)
+		}
+		if hasNew {
+			fes = append(fes, FileEntry{
+				Base: backupBinlog,
+				Name: file,
+			})
+		}
+	}
+	if len(fes) == 0 {
+		logger.Infof("no transaction since position %v, skipping the incremental backup", fromPosition)
+		return nil
+	}
+	logger.Infof("found %v binary logs to backup, up to position %v", len(fes), replicationPosition)
+
+	bh, err := bs.StartBackup(dir, name)
+	if err != nil {
+		return fmt.Errorf("StartBackup failed: %v", err)
+	}
+	bm := &BackupManifest{
+		FileEntries:         fes,
+		ReplicationPosition: replicationPosition,
+		Time:                backupTime.Unix(),
+		Incremental:         true,
+		FromPosition:        fromPosition,
+	}
+	if err := backupFiles(mysqld, logger, bh, bm, backupConcurrency); err != nil {
+		if abortErr := bh.AbortBackup(); abortErr != nil {
+			logger.Errorf("failed to abort backup: %v", abortErr)
+		}
+		return fmt.Errorf("cannot backup files: %v", err)
+	}
+	return bh.EndBackup()
+}
+
+// coversBinlogFileStart returns true if no transaction is missing
+// between pos and the start of the binlog file: either its first
+// transaction is in pos, or pos has all the transactions before it.
+func coversBinlogFileStart(pos proto.ReplicationPosition, bfi *BinlogFileInfo) bool {
+	if len(bfi.Transactions) == 0 {
+		return true
+	}
+	if containsGTID(pos, bfi.Transactions[0].GTID) {
+		return true
+	}
+	return !bfi.PreviousGTIDs.IsZero() && pos.AtLeast(bfi.PreviousGTIDs)
+}
+
+// containsGTID returns true if the transaction of gtid is before pos.
+func containsGTID(pos proto.ReplicationPosition, gtid proto.GTID) bool {
+	return pos.GTIDSet != nil && pos.GTIDSet.ContainsGTID(gtid)
+}
+
+// restoreTarget is where RestoreToPosition and RestoreToTime stop.
+type restoreTarget interface {
+	fmt.Stringer
+
+	// after returns true if the target is at or after the backup,
+	// so it can be the starting point of the restore.
+	after(bm *BackupManifest) bool
+
+	// reachedBy returns true if the backup goes up to the target.
+	reachedBy(bm *BackupManifest) bool
+
+	// includes returns true if the transaction is before the
+	// target, and must be replayed.
+	includes(tx BinlogFileTransaction) bool
+}
+
+// positionTarget is a replication position.
+type positionTarget struct {
+	pos proto.ReplicationPosition
+}
+
+func (t positionTarget) String() string {
+	return fmt.Sprintf("position %v", t.pos)
+}
+
+func (t positionTarget) after(bm *BackupManifest) bool {
+	return t.pos.AtLeast(bm.ReplicationPosition)
+}
+
+func (t positionTarget) reachedBy(bm *BackupManifest) bool {
+	return bm.ReplicationPosition.AtLeast(t.pos)
+}
+
+func (t positionTarget) includes(tx BinlogFileTransaction) bool {
+	return containsGTID(t.pos, tx.GTID)
+}
+
+// timeTarget is a point in time.
+type timeTarget struct {
+	t time.Time
+}
+
+func (t timeTarget) String() string {
+	return fmt.Sprintf("time %v", t.t.UTC().Format(time.RFC3339))
+}
+
+func (t timeTarget) after(bm *BackupManifest) bool {
+	// the backups without a time can't be used
+	return bm.Time != 0 && bm.Time <= t.t.Unix()
+}
+
+func (t timeTarget) reachedBy(bm *BackupManifest) bool {
+	return bm.Time >= t.t.Unix()
+}
+
+func (t timeTarget) includes(tx BinlogFileTransaction) bool {
+	return int64(tx.Timestamp) <= t.t.Unix()
+}
+
+// findRestoreChain returns the backups to restore to reach target:
+// the most recent full backup before it, and the incremental backups
+// which roll it forward up to target.
+func findRestoreChain(backups []backupInfo, target restoreTarget) ([]backupInfo, error) {
+	full := -1
+	for i := len(backups) - 1; i >= 0; i-- {
+		if !backups[i].bm.Incremental && target.after(backups[i].bm) {
+			full = i
+			break
+		}
+	}
+	if full < 0 {
+		return nil, fmt.Errorf("no full backup before %v: %v", target, ErrNoBackup)
+	}
+
+	chain := []backupInfo{backups[full]}
+	pos := backups[full].bm.ReplicationPosition
+	for _, b := range backups[full+1:] {
+		if target.reachedBy(chain[len(chain)-1].bm) {
+			break
+		}
+		// only keep the incremental backups that continue
+		// the chain, and go further
+		if !b.bm.Incremental || !pos.AtLeast(b.bm.FromPosition) || pos.AtLeast(b.bm.ReplicationPosition) {
+			continue
+		}
+		chain = append(chain, b)
+		pos = b.bm.ReplicationPosition
+	}
+	last := chain[len(chain)-1]
+	if !target.reachedBy(last.bm) {
+		return nil, fmt.Errorf("the backups from %v only go up to position %v at %v, not %v", backups[full].bh.Name(), pos, time.Unix(last.bm.Time, 0).UTC().Format(time.RFC3339), target)
+	}
+	return chain, nil
+}
+
+// binlogFileRange returns the part of a binlog file to replay to go
+// from pos towards target: start is the offset of the first
+// transaction after pos (-1 if there is none), and stop the offset of
+// the first transaction after target (0 to replay until the end of
+// the file). It also returns the position after the replayed
+// transactions, and whether the target was reached.
+func binlogFileRange(txs []BinlogFileTransaction, pos proto.ReplicationPosition, target restoreTarget) (start, stop int64, newPos proto.ReplicationPosition, done bool) {
+	start = -1
+	for _, tx := range txs {
+		if start < 0 && containsGTID(pos, tx.GTID) {
+			// already restored
+			continue
+		}
+		if !target.includes(tx) {
+			return start, tx.Offset, pos, true
+		}
+		if start < 0 {
+			start = tx.Offset
+		}
+		pos = proto.AppendGTID(pos, tx.GTID)
+	}
+	return start, 0, pos, false
+}
+
+// RestoreToPosition replaces the data of mysqld with the most recent
+// full backup of dir before pos, and replays the binlog files of the
+// incremental backups after it up to pos. It returns the position
+// mysqld was restored to. Replication is not restarted.
+func RestoreToPosition(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, dir string, restoreConcurrency int, pos proto.ReplicationPosition) (proto.ReplicationPosition, error) {
+	restored, err := restoreToTarget(ctx, mysqld, logger, dir, restoreConcurrency, positionTarget{pos})
+	if err != nil {
+		return proto.ReplicationPosition{}, err
+	}
+	if !restored.AtLeast(pos) {
+		return proto.ReplicationPosition{}, fmt.Errorf("restore stopped at position %v, before %v", restored, pos)
+	}
+	return restored, nil
+}
+
+// RestoreToTime is like RestoreToPosition, but stops before the first
+// transaction that started on the master after t.
+func RestoreToTime(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, dir string, restoreConcurrency int, t time.Time) (proto.ReplicationPosition, error) {
+	return restoreToTarget(ctx, mysqld, logger, dir, restoreConcurrency, timeTarget{t})
+}
+
+func restoreToTarget(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, dir string, restoreConcurrency int, target restoreTarget) (proto.ReplicationPosition, error) {
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return proto.ReplicationPosition{}, err
+	}
+	backups, err := readBackups(logger, bs, dir)
+	if err != nil {
+		return proto.ReplicationPosition{}, err
+	}
+	chain, err := findRestoreChain(backups, target)
+	if err != nil {
+		return proto.ReplicationPosition{}, err
+	}
+	logger.Infof("Restore: restoring full backup %v and %v incremental backups to reach %v", chain[0].bh.Name(), len(chain)-1, target)
+
+	if err := restoreFull(ctx, mysqld, logger, chain[0].bh, chain[0].bm, restoreConcurrency, true /* removeExisting */); err != nil {
+		return proto.ReplicationPosition{}, err
+	}
+
+	// The binlogs of mysqld were not removed, and with GTIDs it
+	// computed its executed transactions from them when it
+	// started: they would then all be skipped by the replay.
+	// Reset them, and set the position of the full backup.
+	logger.Infof("Restore: setting the position to %v", chain[0].bm.ReplicationPosition)
+	cmds, err := mysqld.ResetReplicationCommands()
+	if err != nil {
+		return proto.ReplicationPosition{}, err
+	}
+	setCmds, err := mysqld.SetSlavePositionCommands(chain[0].bm.ReplicationPosition)
+	if err != nil {
+		return proto.ReplicationPosition{}, err
+	}
+	if err := mysqld.ExecuteSuperQueryList(append(cmds, setCmds...)); err != nil {
+		return proto.ReplicationPosition{}, fmt.Errorf("cannot set the position of the full backup: %v", err)
+	}
+
+	return replayBinlogs(mysqld, logger, chain[1:], chain[0].bm.ReplicationPosition, target, restoreConcurrency)
+}
+
+// replayBinlogs replays the transactions of the binlog files of the
+// incremental backups which are after pos, up to target. It returns
+// the position it reached.
+func replayBinlogs(mysqld MysqlDaemon, logger logutil.Logger, backups []backupInfo, pos proto.ReplicationPosition, target restoreTarget, restoreConcurrency int) (proto.ReplicationPosition, error) {
+	if len(backups) == 0 {
+		return pos, nil
+	}
+
+	// the binlog files are copied to a temporary directory, not
+	// with the binlogs of mysqld: pointing BinLogPath there makes
+	// restoreFiles copy them to it.
+	tmpDir, err := ioutil.TempDir(mysqld.Cnf().TmpDir, "restore_binlogs")
+	if err != nil {
+		return pos, fmt.Errorf("cannot create temporary directory for the binlog files: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	cnf := *mysqld.Cnf()
+	cnf.BinLogPath = path.Join(tmpDir, "binlog")
+
+	for _, b := range backups {
+		logger.Infof("Restore: copying the %v binlog files of incremental backup %v", len(b.bm.FileEntries), b.bh.Name())
+		if err := restoreFiles(&cnf, b.bh, b.bm.FileEntries, restoreConcurrency); err != nil {
+			return pos, err
+		}
+		for _, fe := range b.bm.FileEntries {
+			name := path.Join(tmpDir, fe.Name)
+			bfi, err := mysqld.ReadBinlogFile(name)
+			if err != nil {
+				return pos, err
+			}
+			start, stop, newPos, done := binlogFileRange(bfi.Transactions, pos, target)
+			if start >= 0 {
+				logger.Infof("Restore: replaying binlog file %v up to position %v", fe.Name, newPos)
+				if err := mysqld.ApplyBinlogFile(name, start, stop); err != nil {
+					return pos, err
+				}
+				pos = newPos
+			}
+			if done {
+				return pos, nil
+			}
+			if err := os.Remove(name); err != nil {
+				return pos, err
+			}
+		}
+	}
+	return pos, nil
+}
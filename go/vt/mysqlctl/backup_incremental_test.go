@@ -0,0 +1,502 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	mproto "github.com/youtube/vitess/go/mysql/proto"
+	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/logutil"
+	"github.com/youtube/vitess/go/vt/mysqlctl/backupstorage"
+	"github.com/youtube/vitess/go/vt/mysqlctl/filebackupstorage"
+	"github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"golang.org/x/net/context"
+)
+
+// fakeBackupHandle only implements Name.
+type fakeBackupHandle struct {
+	backupstorage.BackupHandle
+	name string
+}
+
+func (bh fakeBackupHandle) Name() string {
+	return bh.name
+}
+
+func mariadbPos(s string) proto.ReplicationPosition {
+	return proto.MustParseReplicationPosition("MariaDB", s)
+}
+
+func testBackup(name string, incremental bool, from, to string, t int64) backupInfo {
+	bm := &BackupManifest{
+		ReplicationPosition: mariadbPos(to),
+		Time:                t,
+		Incremental:         incremental,
+	}
+	if from != "" {
+		bm.FromPosition = mariadbPos(from)
+	}
+	return backupInfo{fakeBackupHandle{name: name}, bm}
+}
+
+func TestFindRestoreChain(t *testing.T) {
+	backups := []backupInfo{
+		testBackup("full1", false, "", "0-1-10", 1000),
+		testBackup("inc1", true, "0-1-10", "0-1-20", 2000),
+		testBackup("full2", false, "", "0-1-25", 2500),
+		testBackup("inc2", true, "0-1-25", "0-1-30", 3000),
+		testBackup("inc3", true, "0-1-30", "0-1-40", 4000),
+		// a failed backup was retried
+		testBackup("inc3-retry", true, "0-1-30", "0-1-40", 4001),
+		testBackup("inc4", true, "0-1-40", "0-1-50", 5000),
+	}
+
+	testcases := []struct {
+		target restoreTarget
+		want   []string
+		err    string
+	}{{
+		target: positionTarget{mariadbPos("0-1-10")},
+		want:   []string{"full1"},
+	}, {
+		target: positionTarget{mariadbPos("0-1-15")},
+		want:   []string{"full1", "inc1"},
+	}, {
+		target: positionTarget{mariadbPos("0-1-35")},
+		want:   []string{"full2", "inc2", "inc3"},
+	}, {
+		target: positionTarget{mariadbPos("0-1-45")},
+		want:   []string{"full2", "inc2", "inc3", "inc4"},
+	}, {
+		target: positionTarget{mariadbPos("0-1-5")},
+		err:    "no full backup before position 0-1-5",
+	}, {
+		target: positionTarget{mariadbPos("0-1-60")},
+		err:    "only go up to position 0-1-50",
+	}, {
+		target: positionTarget{mariadbPos("0-1-22")},
+		err:    "only go up to position 0-1-20",
+	}, {
+		target: timeTarget{time.Unix(1500, 0)},
+		want:   []string{"full1", "inc1"},
+	}, {
+		target: timeTarget{time.Unix(3500, 0)},
+		want:   []string{"full2", "inc2", "inc3"},
+	}, {
+		target: timeTarget{time.Unix(500, 0)},
+		err:    "no full backup before time",
+	}}
+	for _, tcase := range testcases {
+		chain, err := findRestoreChain(backups, tcase.target)
+		if tcase.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tcase.err) {
+				t.Errorf("findRestoreChain(%v) returned %v, want error containing %v", tcase.target, err, tcase.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("findRestoreChain(%v) failed: %v", tcase.target, err)
+			continue
+		}
+		var got []string
+		for _, b := range chain {
+			got = append(got, b.bh.Name())
+		}
+		if !reflect.DeepEqual(got, tcase.want) {
+			t.Errorf("findRestoreChain(%v) = %v, want %v", tcase.target, got, tcase.want)
+		}
+	}
+}
+
+func TestBinlogFileRange(t *testing.T) {
+	txs := []BinlogFileTransaction{
+		{Offset: 100, GTID: proto.MariadbGTID{Domain: 0, Server: 1, Sequence: 11}, Timestamp: 1000},
+		{Offset: 200, GTID: proto.MariadbGTID{Domain: 0, Server: 1, Sequence: 12}, Timestamp: 1010},
+		{Offset: 300, GTID: proto.MariadbGTID{Domain: 0, Server: 1, Sequence: 13}, Timestamp: 1020},
+	}
+
+	testcases := []struct {
+		pos         string
+		target      restoreTarget
+		start, stop int64
+		newPos      string
+		done        bool
+	}{{
+		// the whole file
+		pos:    "0-1-10",
+		target: positionTarget{mariadbPos("0-1-20")},
+		start:  100,
+		newPos: "0-1-13",
+	}, {
+		// the beginning of the file was restored already
+		pos:    "0-1-11",
+		target: positionTarget{mariadbPos("0-1-20")},
+		start:  200,
+		newPos: "0-1-13",
+	}, {
+		// the target is in the file
+		pos:    "0-1-11",
+		target: positionTarget{mariadbPos("0-1-12")},
+		start:  200,
+		stop:   300,
+		newPos: "0-1-12",
+		done:   true,
+	}, {
+		// the target is at the end of the previous file
+		pos:    "0-1-10",
+		target: positionTarget{mariadbPos("0-1-10")},
+		start:  -1,
+		stop:   100,
+		newPos: "0-1-10",
+		done:   true,
+	}, {
+		// everything was restored already
+		pos:    "0-1-13",
+		target: positionTarget{mariadbPos("0-1-20")},
+		start:  -1,
+		newPos: "0-1-13",
+	}, {
+		pos:    "0-1-10",
+		target: timeTarget{time.Unix(1015, 0)},
+		start:  100,
+		stop:   300,
+		newPos: "0-1-12",
+		done:   true,
+	}}
+	for _, tcase := range testcases {
+		start, stop, newPos, done := binlogFileRange(txs, mariadbPos(tcase.pos), tcase.target)
+		if start != tcase.start || stop != tcase.stop || !newPos.Equal(mariadbPos(tcase.newPos)) || done != tcase.done {
+			t.Errorf("binlogFileRange(%v, %v) = (%v, %v, %v, %v), want (%v, %v, %v, %v)", tcase.pos, tcase.target, start, stop, newPos, done, tcase.start, tcase.stop, tcase.newPos, tcase.done)
+		}
+	}
+}
+
+// replayMysqlDaemon checks the position was set before the binlog
+// files are replayed.
+type replayMysqlDaemon struct {
+	*FakeMysqlDaemon
+	t *testing.T
+}
+
+func (rmd replayMysqlDaemon) ApplyBinlogFile(name string, start, stop int64) error {
+	if err := rmd.CheckSuperQueryList(); err != nil {
+		rmd.t.Errorf("ApplyBinlogFile(%v) called before the position was set: %v", path.Base(name), err)
+	}
+	return rmd.FakeMysqlDaemon.ApplyBinlogFile(name, start, stop)
+}
+
+// newIncrementalTestDaemon returns a FakeMysqlDaemon with its
+// directories in root, and the file BackupStorage in root/backups.
+// The returned function restores the flags.
+func newIncrementalTestDaemon(t *testing.T, root string) (*FakeMysqlDaemon, func()) {
+	savedImplementation := *backupstorage.BackupStorageImplementation
+	savedRoot := *filebackupstorage.FileBackupStorageRoot
+	*backupstorage.BackupStorageImplementation = "file"
+	*filebackupstorage.FileBackupStorageRoot = path.Join(root, "backups")
+
+	fmd := NewFakeMysqlDaemon(nil)
+	fmd.Mycnf = &Mycnf{
+		DataDir:               path.Join(root, "data"),
+		InnodbDataHomeDir:     path.Join(root, "innodb", "data"),
+		InnodbLogGroupHomeDir: path.Join(root, "innodb", "logs"),
+		BinLogPath:            path.Join(root, "binlogs", "vt-bin"),
+		TmpDir:                path.Join(root, "tmp"),
+	}
+	for _, dir := range []string{path.Join(fmd.Mycnf.DataDir, "vt_test"), fmd.Mycnf.InnodbDataHomeDir, fmd.Mycnf.InnodbLogGroupHomeDir, path.Dir(fmd.Mycnf.BinLogPath), fmd.Mycnf.TmpDir} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	writeTestFile(t, path.Join(fmd.Mycnf.DataDir, "vt_test", "db.opt"), "db.opt")
+	writeTestFile(t, path.Join(fmd.Mycnf.DataDir, "vt_test", "t1.ibd"), "t1 at 0-1-5")
+	writeTestFile(t, path.Join(fmd.Mycnf.InnodbDataHomeDir, "ibdata1"), "ibdata1")
+	return fmd, func() {
+		*backupstorage.BackupStorageImplementation = savedImplementation
+		*filebackupstorage.FileBackupStorageRoot = savedRoot
+	}
+}
+
+func writeTestFile(t *testing.T, name, data string) {
+	if err := ioutil.WriteFile(name, []byte(data), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+// testBinlogTransactions returns the transactions of a fake binlog
+// file, with sequence numbers from first to last, one per second.
+func testBinlogTransactions(first, last uint64) []BinlogFileTransaction {
+	var txs []BinlogFileTransaction
+	for seq := first; seq <= last; seq++ {
+		txs = append(txs, BinlogFileTransaction{
+			Offset:    int64(seq * 100),
+			GTID:      proto.MariadbGTID{Domain: 0, Server: 1, Sequence: seq},
+			Timestamp: uint32(1000 + seq),
+		})
+	}
+	return txs
+}
+
+// storeTestBackup stores the files of mysqld in a backup of ks/0.
+func storeTestBackup(t *testing.T, mysqld MysqlDaemon, name string, bm *BackupManifest) {
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		t.Fatalf("GetBackupStorage failed: %v", err)
+	}
+	bh, err := bs.StartBackup("ks/0", name)
+	if err != nil {
+		t.Fatalf("StartBackup failed: %v", err)
+	}
+	if err := backupFiles(mysqld, logutil.NewConsoleLogger(), bh, bm, 2); err != nil {
+		t.Fatalf("backupFiles failed: %v", err)
+	}
+	if err := bh.EndBackup(); err != nil {
+		t.Fatalf("EndBackup failed: %v", err)
+	}
+}
+
+func TestRestoreToPositionSetsPosition(t *testing.T) {
+	root, err := ioutil.TempDir("", "backup_incremental_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(root)
+	fmd, restoreFlags := newIncrementalTestDaemon(t, root)
+	defer restoreFlags()
+
+	// a full backup at 0-1-5, and an incremental one up to 0-1-8
+	fes, err := findFilesTobackup(fmd.Mycnf)
+	if err != nil {
+		t.Fatalf("findFilesTobackup failed: %v", err)
+	}
+	storeTestBackup(t, fmd, "1-full", &BackupManifest{
+		FileEntries:         fes,
+		ReplicationPosition: mariadbPos("0-1-5"),
+		Time:                1005,
+	})
+	writeTestFile(t, path.Join(root, "binlogs", "vt-bin.000001"), "binlog 1")
+	fmd.BinlogFiles = map[string]*BinlogFileInfo{
+		"vt-bin.000001": {Transactions: testBinlogTransactions(6, 8)},
+	}
+	storeTestBackup(t, fmd, "2-incremental", &BackupManifest{
+		FileEntries:         []FileEntry{{Base: backupBinlog, Name: "vt-bin.000001"}},
+		ReplicationPosition: mariadbPos("0-1-8"),
+		Time:                1008,
+		Incremental:         true,
+		FromPosition:        mariadbPos("0-1-5"),
+	})
+
+	// the tablet has moved on since, and its binlogs have
+	// transactions up to 0-1-9
+	writeTestFile(t, path.Join(fmd.Mycnf.DataDir, "vt_test", "t1.ibd"), "t1 at 0-1-9")
+
+	// the replication is reset and the position set to the one
+	// of the full backup, before the binlogs are replayed
+	fmd.ResetReplicationResult = []string{"RESET MASTER"}
+	fmd.SetSlavePositionCommandsPos = mariadbPos("0-1-5")
+	fmd.SetSlavePositionCommandsResult = []string{"SET GLOBAL gtid_slave_pos = '0-1-5'"}
+	fmd.ExpectedExecuteSuperQueryList = []string{
+		"RESET MASTER",
+		"SET GLOBAL gtid_slave_pos = '0-1-5'",
+	}
+	restored, err := RestoreToPosition(context.Background(), replayMysqlDaemon{fmd, t}, logutil.NewConsoleLogger(), "ks/0", 2, mariadbPos("0-1-7"))
+	if err != nil {
+		t.Fatalf("RestoreToPosition failed: %v", err)
+	}
+	if !restored.Equal(mariadbPos("0-1-7")) {
+		t.Errorf("RestoreToPosition restored %v, want 0-1-7", restored)
+	}
+	if err := fmd.CheckSuperQueryList(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+	if want := []string{fmt.Sprintf("vt-bin.000001:%v:%v", 600, 800)}; !reflect.DeepEqual(fmd.AppliedBinlogFiles, want) {
+		t.Errorf("AppliedBinlogFiles = %v, want %v", fmd.AppliedBinlogFiles, want)
+	}
+	if got, err := ioutil.ReadFile(path.Join(fmd.Mycnf.DataDir, "vt_test", "t1.ibd")); err != nil || string(got) != "t1 at 0-1-5" {
+		t.Errorf("t1.ibd was not restored: %q %v", got, err)
+	}
+}
+
+func TestCoversBinlogFileStart(t *testing.T) {
+	testcases := []struct {
+		pos  string
+		bfi  *BinlogFileInfo
+		want bool
+	}{{
+		// no transaction
+		pos:  "0-1-5",
+		bfi:  &BinlogFileInfo{},
+		want: true,
+	}, {
+		// the file starts before pos
+		pos:  "0-1-5",
+		bfi:  &BinlogFileInfo{Transactions: testBinlogTransactions(4, 8)},
+		want: true,
+	}, {
+		// the file starts right after pos
+		pos: "0-1-5",
+		bfi: &BinlogFileInfo{
+			PreviousGTIDs: mariadbPos("0-1-5"),
+			Transactions:  testBinlogTransactions(6, 8),
+		},
+		want: true,
+	}, {
+		// transactions are missing between pos and the file
+		pos: "0-1-5",
+		bfi: &BinlogFileInfo{
+			PreviousGTIDs: mariadbPos("0-1-6"),
+			Transactions:  testBinlogTransactions(7, 8),
+		},
+		want: false,
+	}, {
+		// unknown previous GTIDs
+		pos:  "0-1-5",
+		bfi:  &BinlogFileInfo{Transactions: testBinlogTransactions(6, 8)},
+		want: false,
+	}}
+	for _, tcase := range testcases {
+		if got := coversBinlogFileStart(mariadbPos(tcase.pos), tcase.bfi); got != tcase.want {
+			t.Errorf("coversBinlogFileStart(%v, %+v) = %v, want %v", tcase.pos, tcase.bfi, got, tcase.want)
+		}
+	}
+}
+
+// showBinaryLogs returns the result of SHOW BINARY LOGS for files.
+func showBinaryLogs(files ...string) *mproto.QueryResult {
+	qr := &mproto.QueryResult{}
+	for _, file := range files {
+		qr.Rows = append(qr.Rows, []sqltypes.Value{
+			sqltypes.MakeString([]byte(file)),
+			sqltypes.MakeString([]byte("1000")),
+		})
+	}
+	return qr
+}
+
+func TestIncrementalBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	logger := logutil.NewConsoleLogger()
+	root, err := ioutil.TempDir("", "backup_incremental_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(root)
+	fmd, restoreFlags := newIncrementalTestDaemon(t, root)
+	defer restoreFlags()
+
+	// a full backup at 0-1-5
+	fes, err := findFilesTobackup(fmd.Mycnf)
+	if err != nil {
+		t.Fatalf("findFilesTobackup failed: %v", err)
+	}
+	storeTestBackup(t, fmd, "1-full", &BackupManifest{
+		FileEntries:         fes,
+		ReplicationPosition: mariadbPos("0-1-5"),
+		Time:                1005,
+	})
+
+	// the binlogs go from 0-1-4 to 0-1-8, the last one is the
+	// new current one after the flush
+	for _, file := range []string{"vt-bin.000001", "vt-bin.000002"} {
+		writeTestFile(t, path.Join(root, "binlogs", file), file)
+	}
+	fmd.ExpectedExecuteSuperQueryList = []string{"FLUSH BINARY LOGS"}
+	fmd.FetchSuperQueryMap = map[string]*mproto.QueryResult{
+		"SHOW BINARY LOGS": showBinaryLogs("vt-bin.000001", "vt-bin.000002", "vt-bin.000003"),
+	}
+	fmd.BinlogFiles = map[string]*BinlogFileInfo{
+		"vt-bin.000001": {
+			PreviousGTIDs: mariadbPos("0-1-3"),
+			Transactions:  testBinlogTransactions(4, 6),
+		},
+		"vt-bin.000002": {
+			PreviousGTIDs: mariadbPos("0-1-6"),
+			Transactions:  testBinlogTransactions(7, 8),
+		},
+	}
+	if err := BackupIncremental(ctx, fmd, logger, "ks/0", "2-incremental", 2); err != nil {
+		t.Fatalf("BackupIncremental failed: %v", err)
+	}
+	if err := fmd.CheckSuperQueryList(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		t.Fatalf("GetBackupStorage failed: %v", err)
+	}
+	backups, err := readBackups(logger, bs, "ks/0")
+	if err != nil || len(backups) != 2 {
+		t.Fatalf("readBackups returned %v backups, %v", len(backups), err)
+	}
+	bm := backups[1].bm
+	if !bm.Incremental || !bm.FromPosition.Equal(mariadbPos("0-1-5")) || !bm.ReplicationPosition.Equal(mariadbPos("0-1-8")) || len(bm.FileEntries) != 2 {
+		t.Errorf("unexpected incremental backup manifest: %#v", bm)
+	}
+
+	// the binlogs since 0-1-8 were purged: the next
+	// incremental backup fails
+	fmd.ExpectedExecuteSuperQueryList = []string{"FLUSH BINARY LOGS"}
+	fmd.ExpectedExecuteSuperQueryCurrent = 0
+	fmd.FetchSuperQueryMap["SHOW BINARY LOGS"] = showBinaryLogs("vt-bin.000004", "vt-bin.000005")
+	fmd.BinlogFiles["vt-bin.000004"] = &BinlogFileInfo{
+		PreviousGTIDs: mariadbPos("0-1-9"),
+		Transactions:  testBinlogTransactions(10, 12),
+	}
+	if err := BackupIncremental(ctx, fmd, logger, "ks/0", "3-incremental", 2); err == nil || !strings.Contains(err.Error(), "may have been purged") {
+		t.Errorf("BackupIncremental with purged binlogs returned %v", err)
+	}
+
+	// restore to a position: the end of vt-bin.000001 after
+	// 0-1-5, and vt-bin.000002 up to 0-1-7
+	restoreExpectations := func() {
+		fmd.ExpectedExecuteSuperQueryList = []string{
+			"RESET MASTER",
+			"SET GLOBAL gtid_slave_pos = '0-1-5'",
+		}
+		fmd.ExpectedExecuteSuperQueryCurrent = 0
+		fmd.ResetReplicationResult = []string{"RESET MASTER"}
+		fmd.SetSlavePositionCommandsPos = mariadbPos("0-1-5")
+		fmd.SetSlavePositionCommandsResult = []string{"SET GLOBAL gtid_slave_pos = '0-1-5'"}
+		fmd.AppliedBinlogFiles = nil
+	}
+	restoreExpectations()
+	restored, err := RestoreToPosition(ctx, fmd, logger, "ks/0", 2, mariadbPos("0-1-7"))
+	if err != nil {
+		t.Fatalf("RestoreToPosition failed: %v", err)
+	}
+	if !restored.Equal(mariadbPos("0-1-7")) {
+		t.Errorf("RestoreToPosition restored %v, want 0-1-7", restored)
+	}
+	if want := []string{"vt-bin.000001:600:0", "vt-bin.000002:700:800"}; !reflect.DeepEqual(fmd.AppliedBinlogFiles, want) {
+		t.Errorf("AppliedBinlogFiles = %v, want %v", fmd.AppliedBinlogFiles, want)
+	}
+	if err := fmd.CheckSuperQueryList(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+
+	// restore to a time: the transactions up to 0-1-6 started
+	// before it
+	restoreExpectations()
+	restored, err = RestoreToTime(ctx, fmd, logger, "ks/0", 2, time.Unix(1006, 0))
+	if err != nil {
+		t.Fatalf("RestoreToTime failed: %v", err)
+	}
+	if !restored.Equal(mariadbPos("0-1-6")) {
+		t.Errorf("RestoreToTime restored %v, want 0-1-6", restored)
+	}
+	if want := []string{"vt-bin.000001:600:0"}; !reflect.DeepEqual(fmd.AppliedBinlogFiles, want) {
+		t.Errorf("AppliedBinlogFiles = %v, want %v", fmd.AppliedBinlogFiles, want)
+	}
+	if err := fmd.CheckSuperQueryList(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/youtube/vitess/go/vt/logutil"
+	"github.com/youtube/vitess/go/vt/mysqlctl/backupstorage"
+)
+
+// This file decides which backups of a directory to keep, according
+// to a retention policy, and removes the others.
+
+// BackupRetentionPolicy describes which full backups of a directory
+// PruneBackups keeps. A backup is kept if any of the rules selects it,
+// and the most recent full backup is always kept. Incremental backups
+// are kept with the full backup they build on.
+type BackupRetentionPolicy struct {
+	// KeepLast is the number of most recent full backups to keep.
+	KeepLast int
+
+	// KeepDaily keeps the most recent full backup of each of the
+	// last KeepDaily days (UTC), today included.
+	KeepDaily int
+
+	// KeepWeekly keeps the most recent full backup of each of the
+	// last KeepWeekly weeks (starting on Monday, UTC), this week
+	// included.
+	KeepWeekly int
+
+	// KeepOlderThan keeps the most recent full backup older than
+	// it, so there is always a backup at least that old.
+	KeepOlderThan time.Duration
+}
+
+// IsZero returns true if the policy has no rule, and would only keep
+// the most recent full backup.
+func (p BackupRetentionPolicy) IsZero() bool {
+	return p == BackupRetentionPolicy{}
+}
+
+// BackupPruneDecision is what PruneBackups decided for a backup.
+type BackupPruneDecision struct {
+	Name        string
+	Time        time.Time
+	Incremental bool
+
+	// Reasons lists the rules that keep the backup. The backup is
+	// removed if it is empty.
+	Reasons []string
+}
+
+// Keep returns true if the backup is kept.
+func (d *BackupPruneDecision) Keep() bool {
+	return len(d.Reasons) > 0
+}
+
+// String is used to display the decision.
+func (d *BackupPruneDecision) String() string {
+	kind := "full"
+	if d.Incremental {
+		kind = "incremental"
+	}
+	if !d.Keep() {
+		return fmt.Sprintf("remove %v (%v)", d.Name, kind)
+	}
+	return fmt.Sprintf("keep %v (%v): %v", d.Name, kind, strings.Join(d.Reasons, ", "))
+}
+
+// backupTime returns when a backup was taken: the time of its MANIFEST,
+// or, for the backups without it, the time at the beginning of its
+// name. It returns false if neither is known.
+func backupTime(bi backupInfo) (time.Time, bool) {
+	if bi.bm.Time != 0 {
+		return time.Unix(bi.bm.Time, 0), true
+	}
+	name := bi.bh.Name()
+	if len(name) < len(BackupTimestampFormat) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(BackupTimestampFormat, name[:len(BackupTimestampFormat)])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// planBackupPruning returns the decision for each backup, in the same
+// order. backups are sorted oldest first, as returned by readBackups.
+func planBackupPruning(backups []backupInfo, policy BackupRetentionPolicy, now time.Time) []*BackupPruneDecision {
+	decisions := make([]*BackupPruneDecision, len(backups))
+	times := make([]time.Time, len(backups))
+	for i, bi := range backups {
+		decisions[i] = &BackupPruneDecision{
+			Name:        bi.bh.Name(),
+			Incremental: bi.bm.Incremental,
+		}
+		var ok bool
+		times[i], ok = backupTime(bi)
+		if !ok && !bi.bm.Incremental {
+			decisions[i].Reasons = append(decisions[i].Reasons, "unknown time")
+		}
+		decisions[i].Time = times[i]
+	}
+
+	// the periods of the daily and weekly rules start at midnight
+	today := now.UTC().Truncate(24 * time.Hour)
+	dailyStart := today.AddDate(0, 0, 1-policy.KeepDaily)
+	weekStart := func(t time.Time) time.Time {
+		day := t.UTC().Truncate(24 * time.Hour)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	weeklyStart := weekStart(now).AddDate(0, 0, 7*(1-policy.KeepWeekly))
+	olderThan := now.Add(-policy.KeepOlderThan)
+
+	// go through the full backups, most recent first
+	full := 0
+	days := make(map[time.Time]bool)
+	weeks := make(map[time.Time]bool)
+	keptOlderThan := false
+	for i := len(backups) - 1; i >= 0; i-- {
+		if backups[i].bm.Incremental {
+			continue
+		}
+		d := decisions[i]
+		t := times[i]
+		if full == 0 {
+			d.Reasons = append(d.Reasons, "most recent")
+		}
+		full++
+		if full <= policy.KeepLast {
+			d.Reasons = append(d.Reasons, fmt.Sprintf("last %v", policy.KeepLast))
+		}
+		if t.IsZero() {
+			continue
+		}
+		if day := t.UTC().Truncate(24 * time.Hour); policy.KeepDaily > 0 && !day.Before(dailyStart) && !days[day] {
+			days[day] = true
+			d.Reasons = append(d.Reasons, "daily "+day.Format("2006-01-02"))
+		}
+		if week := weekStart(t); policy.KeepWeekly > 0 && !week.Before(weeklyStart) && !weeks[week] {
+			weeks[week] = true
+			d.Reasons = append(d.Reasons, "weekly "+week.Format("2006-01-02"))
+		}
+		if policy.KeepOlderThan > 0 && !keptOlderThan && !t.After(olderThan) {
+			keptOlderThan = true
+			d.Reasons = append(d.Reasons, fmt.Sprintf("older than %v", policy.KeepOlderThan))
+		}
+	}
+
+	// incremental backups are only useful with the full backup
+	// before them
+	var base *BackupPruneDecision
+	for i, bi := range backups {
+		if !bi.bm.Incremental {
+			base = decisions[i]
+			continue
+		}
+		if base != nil && base.Keep() {
+			decisions[i].Reasons = append(decisions[i].Reasons, "incremental of "+base.Name)
+		}
+	}
+	return decisions
+}
+
+// PruneBackups removes the backups of dir that policy doesn't keep,
+// and returns the decisions taken for all the backups, oldest first.
+// In dry run mode, nothing is removed. The backups without a valid
+// MANIFEST are ignored: they may still be in progress.
+func PruneBackups(logger logutil.Logger, dir string, policy BackupRetentionPolicy, now time.Time, dryRun bool) ([]*BackupPruneDecision, error) {
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	backups, err := readBackups(logger, bs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := planBackupPruning(backups, policy, now)
+	if dryRun {
+		return decisions, nil
+	}
+	for _, d := range decisions {
+		if d.Keep() {
+			continue
+		}
+		logger.Infof("removing backup %v/%v", dir, d.Name)
+		if err := bs.RemoveBackup(dir, d.Name); err != nil {
+			return decisions, fmt.Errorf("cannot remove backup %v/%v: %v", dir, d.Name, err)
+		}
+	}
+	return decisions, nil
+}
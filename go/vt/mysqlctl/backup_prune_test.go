@@ -0,0 +1,85 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanBackupPruning(t *testing.T) {
+	// now is Wednesday 2015-11-11 12:00 UTC
+	now := time.Date(2015, 11, 11, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour int) int64 {
+		return time.Date(2015, 11, day, hour, 0, 0, 0, time.UTC).Unix()
+	}
+	backups := []backupInfo{
+		testBackup("old", false, "", "0-1-1", at(1, 10)),
+		testBackup("2015-11-02.100000.cell-1", false, "", "0-1-2", 0),
+		testBackup("inc-old", true, "0-1-2", "0-1-3", at(2, 12)),
+		testBackup("mon-1", false, "", "0-1-4", at(9, 1)),
+		testBackup("mon-2", false, "", "0-1-5", at(9, 23)),
+		testBackup("inc-mon", true, "0-1-5", "0-1-6", at(10, 1)),
+		testBackup("tue", false, "", "0-1-7", at(10, 10)),
+		testBackup("wed", false, "", "0-1-8", at(11, 10)),
+		testBackup("inc-wed", true, "0-1-8", "0-1-9", at(11, 11)),
+	}
+
+	testcases := []struct {
+		policy BackupRetentionPolicy
+		want   []string
+	}{{
+		// the most recent full backup is always kept
+		policy: BackupRetentionPolicy{},
+		want:   []string{"wed", "inc-wed"},
+	}, {
+		policy: BackupRetentionPolicy{KeepLast: 2},
+		want:   []string{"tue", "wed", "inc-wed"},
+	}, {
+		policy: BackupRetentionPolicy{KeepDaily: 3},
+		want:   []string{"mon-2", "inc-mon", "tue", "wed", "inc-wed"},
+	}, {
+		// the week of the 2nd, whose backup time comes from its
+		// name, and this week
+		policy: BackupRetentionPolicy{KeepWeekly: 2},
+		want:   []string{"2015-11-02.100000.cell-1", "inc-old", "wed", "inc-wed"},
+	}, {
+		policy: BackupRetentionPolicy{KeepOlderThan: 7 * 24 * time.Hour},
+		want:   []string{"2015-11-02.100000.cell-1", "inc-old", "wed", "inc-wed"},
+	}, {
+		policy: BackupRetentionPolicy{KeepOlderThan: 30 * 24 * time.Hour},
+		want:   []string{"wed", "inc-wed"},
+	}}
+	for _, tc := range testcases {
+		decisions := planBackupPruning(backups, tc.policy, now)
+		if len(decisions) != len(backups) {
+			t.Fatalf("planBackupPruning(%+v) returned %v decisions, want %v", tc.policy, len(decisions), len(backups))
+		}
+		var got []string
+		for _, d := range decisions {
+			if d.Keep() {
+				got = append(got, d.Name)
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("planBackupPruning(%+v) keeps %v, want %v", tc.policy, got, tc.want)
+		}
+	}
+}
+
+func TestPlanBackupPruningUnknownTime(t *testing.T) {
+	backups := []backupInfo{
+		testBackup("unknown", false, "", "0-1-1", 0),
+		testBackup("new", false, "", "0-1-2", 1000),
+	}
+	decisions := planBackupPruning(backups, BackupRetentionPolicy{}, time.Unix(2000, 0))
+	if !decisions[0].Keep() {
+		t.Errorf("backup with an unknown time should be kept: %v", decisions[0])
+	}
+	if got, want := decisions[1].String(), "keep new (full): most recent"; got != want {
+		t.Errorf("decision: got %q, want %q", got, want)
+	}
+}
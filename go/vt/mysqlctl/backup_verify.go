@@ -0,0 +1,339 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/youtube/vitess/go/sqldb"
+	vtenv "github.com/youtube/vitess/go/vt/env"
+	"github.com/youtube/vitess/go/vt/logutil"
+	"github.com/youtube/vitess/go/vt/mysqlctl/backupstorage"
+	"golang.org/x/net/context"
+)
+
+// This file verifies backups: it restores a backup into a scratch
+// mysqld, started next to the real one, and checks the tables.
+
+// systemDatabases are not verified, mysqld can change their tables
+// when it starts.
+var systemDatabases = map[string]bool{
+	"information_schema": true,
+	"mysql":              true,
+	"performance_schema": true,
+	"sys":                true,
+}
+
+// NewScratchMysqld is part of the MysqlDaemon interface. The scratch
+// mysqld uses the same configuration templates and credentials as
+// mysqld, with all its files under root, and a random free port. As
+// it is started and stopped with mysqld_safe and mysqladmin, it
+// can't be used with mysqlctld or the mysqld_start and
+// mysqld_shutdown hooks, which would act on the real mysqld.
+func (mysqld *Mysqld) NewScratchMysqld(root string) (MysqlDaemon, error) {
+	if *socketFile != "" {
+		return nil, fmt.Errorf("cannot run a scratch mysqld with -mysqlctl_socket")
+	}
+	vtRoot, err := vtenv.VtRoot()
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range []string{"mysqld_start", "mysqld_shutdown"} {
+		if _, err := os.Stat(path.Join(vtRoot, "vthook", h)); err == nil {
+			return nil, fmt.Errorf("cannot run a scratch mysqld with the %v hook", h)
+		}
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	cnf := newScratchMycnf(root, mysqld.config.ServerID, port)
+	for _, dir := range []string{
+		cnf.DataDir,
+		cnf.InnodbDataHomeDir,
+		cnf.InnodbLogGroupHomeDir,
+		cnf.TmpDir,
+		path.Dir(cnf.RelayLogPath),
+		path.Dir(cnf.BinLogPath),
+	} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+
+	// connect to the scratch mysqld with the same users
+	params := make([]*sqldb.ConnParams, 3)
+	for i, p := range []*sqldb.ConnParams{mysqld.dba, mysqld.dbApp, mysqld.replParams} {
+		params[i] = &sqldb.ConnParams{}
+		if p != nil {
+			*params[i] = *p
+		}
+		params[i].UnixSocket = cnf.SocketFile
+	}
+	scratch := NewMysqld("", "", cnf, params[0], params[1], params[2])
+	scratch.TabletDir = root
+	if err := scratch.initConfig(vtRoot); err != nil {
+		scratch.Close()
+		return nil, fmt.Errorf("cannot create %v: %v", cnf.path, err)
+	}
+	return scratch, nil
+}
+
+// newScratchMycnf returns the Mycnf of a scratch mysqld, which has
+// the same layout as NewMycnf, under root.
+func newScratchMycnf(root string, serverID uint32, port int32) *Mycnf {
+	cnf := new(Mycnf)
+	cnf.path = path.Join(root, "my.cnf")
+	cnf.ServerID = serverID
+	cnf.MysqlPort = port
+	cnf.DataDir = path.Join(root, dataDir)
+	cnf.InnodbDataHomeDir = path.Join(root, innodbDataSubdir)
+	cnf.InnodbLogGroupHomeDir = path.Join(root, innodbLogSubdir)
+	cnf.SocketFile = path.Join(root, "mysql.sock")
+	cnf.ErrorLogPath = path.Join(root, "error.log")
+	cnf.SlowLogPath = path.Join(root, "slow-query.log")
+	cnf.RelayLogPath = path.Join(root, relayLogDir, "scratch-relay-bin")
+	cnf.RelayLogIndexPath = cnf.RelayLogPath + ".index"
+	cnf.RelayLogInfoPath = path.Join(root, relayLogDir, "relay-log.info")
+	cnf.BinLogPath = path.Join(root, binLogDir, "scratch-bin")
+	cnf.MasterInfoFile = path.Join(root, "master.info")
+	cnf.PidFile = path.Join(root, "mysql.pid")
+	cnf.TmpDir = path.Join(root, "tmp")
+	cnf.SlaveLoadTmpDir = cnf.TmpDir
+	return cnf
+}
+
+// freePort returns a TCP port nothing listens on.
+func freePort() (int32, error) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, fmt.Errorf("cannot find a free port: %v", err)
+	}
+	defer l.Close()
+	return int32(l.Addr().(*net.TCPAddr).Port), nil
+}
+
+// decodeFilename reverses the encoding of the special characters in
+// the database and table file names, for the characters encoded as
+// '@' and four hexadecimal digits.
+func decodeFilename(name string) string {
+	if !strings.Contains(name, "@") {
+		return name
+	}
+	result := make([]rune, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		if name[i] == '@' && i+5 <= len(name) {
+			if r, err := strconv.ParseUint(name[i+1:i+5], 16, 16); err == nil {
+				result = append(result, rune(r))
+				i += 4
+				continue
+			}
+		}
+		result = append(result, rune(name[i]))
+	}
+	return string(result)
+}
+
+// manifestTables returns the tables and views of a full backup, as
+// "database.table", sorted. Each of them has a .frm file.
+func manifestTables(bm *BackupManifest) []string {
+	var result []string
+	for _, fe := range bm.FileEntries {
+		if fe.Base != backupData || path.Ext(fe.Name) != ".frm" {
+			continue
+		}
+		db, file := path.Split(fe.Name)
+		db = decodeFilename(path.Clean(db))
+		if db == "." || systemDatabases[db] {
+			continue
+		}
+		result = append(result, db+"."+decodeFilename(strings.TrimSuffix(file, ".frm")))
+	}
+	sort.Strings(result)
+	return result
+}
+
+// quoteTable returns "database.table" as a quoted identifier.
+func quoteTable(table string) string {
+	parts := strings.SplitN(table, ".", 2)
+	for i, p := range parts {
+		parts[i] = "`" + strings.Replace(p, "`", "``", -1) + "`"
+	}
+	return strings.Join(parts, ".")
+}
+
+// listTables returns the tables and views of mysqld, as
+// "database.table", sorted.
+func listTables(mysqld MysqlDaemon) ([]string, error) {
+	qr, err := mysqld.FetchSuperQuery("SHOW DATABASES")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, row := range qr.Rows {
+		db := row[0].String()
+		if systemDatabases[db] {
+			continue
+		}
+		tqr, err := mysqld.FetchSuperQuery("SHOW TABLES FROM `" + strings.Replace(db, "`", "``", -1) + "`")
+		if err != nil {
+			return nil, err
+		}
+		for _, trow := range tqr.Rows {
+			result = append(result, db+"."+trow[0].String())
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// diffTables returns the elements only in want, and only in got. Both
+// are sorted.
+func diffTables(want, got []string) (missing, extra []string) {
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case j == len(got) || (i < len(want) && want[i] < got[j]):
+			missing = append(missing, want[i])
+			i++
+		case i == len(want) || got[j] < want[i]:
+			extra = append(extra, got[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return missing, extra
+}
+
+// checkTable runs CHECK TABLE, and returns an error unless all the
+// messages are good.
+func checkTable(mysqld MysqlDaemon, table string) error {
+	qr, err := mysqld.FetchSuperQuery("CHECK TABLE " + quoteTable(table))
+	if err != nil {
+		return err
+	}
+	// the columns are Table, Op, Msg_type and Msg_text
+	for _, row := range qr.Rows {
+		if len(row) < 4 {
+			return fmt.Errorf("unexpected CHECK TABLE result: %v", row)
+		}
+		msgType, msgText := row[2].String(), row[3].String()
+		switch {
+		case msgType == "error":
+			return fmt.Errorf("%v", msgText)
+		case msgType == "status" && msgText != "OK" && msgText != "Table is already up to date":
+			return fmt.Errorf("%v", msgText)
+		}
+	}
+	return nil
+}
+
+// VerifyBackup restores the full backup name of dir into a scratch
+// mysqld under a temporary directory, which checks the hash of each
+// file. It then checks the restored databases have the tables of the
+// backup, and runs CHECK TABLE on checkTables of them, picked at
+// random. The scratch mysqld and its files are removed at the end.
+func VerifyBackup(ctx context.Context, mysqld MysqlDaemon, logger logutil.Logger, dir, name string, restoreConcurrency, checkTables int) error {
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return err
+	}
+	bhs, err := bs.ListBackups(dir)
+	if err != nil {
+		return fmt.Errorf("ListBackups failed: %v", err)
+	}
+	var bh backupstorage.BackupHandle
+	for _, b := range bhs {
+		if b.Name() == name {
+			bh = b
+			break
+		}
+	}
+	if bh == nil {
+		return fmt.Errorf("no backup %v in %v", name, dir)
+	}
+	bm, err := readManifest(bh)
+	if err != nil {
+		return fmt.Errorf("backup %v/%v is incomplete: %v", dir, name, err)
+	}
+	if bm.Incremental {
+		return fmt.Errorf("backup %v/%v is incremental, only full backups can be verified", dir, name)
+	}
+	be, err := getRestoreEngine(bm)
+	if err != nil {
+		return err
+	}
+	tables := manifestTables(bm)
+
+	root, err := ioutil.TempDir(vtenv.VtDataRoot(), "vt_verify_backup_")
+	if err != nil {
+		return fmt.Errorf("cannot create scratch directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+	scratch, err := mysqld.NewScratchMysqld(root)
+	if err != nil {
+		return err
+	}
+	defer scratch.Close()
+
+	logger.Infof("VerifyBackup: restoring %v files of %v/%v into %v", len(bm.FileEntries), dir, name, root)
+	if err := be.ExecuteRestore(ctx, scratch.Cnf(), logger, bh, bm, restoreConcurrency); err != nil {
+		return fmt.Errorf("cannot restore backup %v/%v: %v", dir, name, err)
+	}
+	logger.Infof("VerifyBackup: the hashes of all the files match, starting scratch mysqld on port %v", scratch.Cnf().MysqlPort)
+	// Start may fail after mysqld_safe was started, shut it down
+	// in any case so it doesn't outlive its files.
+	defer func() {
+		if err := scratch.Shutdown(ctx, true); err != nil {
+			logger.Warningf("VerifyBackup: cannot shutdown scratch mysqld: %v", err)
+		}
+	}()
+	if err := scratch.Start(ctx); err != nil {
+		return fmt.Errorf("cannot start mysqld on backup %v/%v: %v", dir, name, err)
+	}
+
+	var problems []string
+	restored, err := listTables(scratch)
+	if err != nil {
+		return fmt.Errorf("cannot list the tables of backup %v/%v: %v", dir, name, err)
+	}
+	// the MANIFEST only lists the tables of the builtin engine
+	if bm.BackupEngine == "" || bm.BackupEngine == builtinBackupEngineName {
+		missing, extra := diffTables(tables, restored)
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("missing tables: %v", strings.Join(missing, ", ")))
+		}
+		if len(extra) > 0 {
+			problems = append(problems, fmt.Sprintf("unexpected tables: %v", strings.Join(extra, ", ")))
+		}
+	}
+
+	if checkTables > len(restored) {
+		checkTables = len(restored)
+	}
+	for _, i := range rand.Perm(len(restored))[:checkTables] {
+		logger.Infof("VerifyBackup: CHECK TABLE %v", restored[i])
+		if err := checkTable(scratch, restored[i]); err != nil {
+			problems = append(problems, fmt.Sprintf("CHECK TABLE %v failed: %v", restored[i], err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("backup %v/%v failed verification: %v", dir, name, strings.Join(problems, "; "))
+	}
+	logger.Infof("VerifyBackup: backup %v/%v is valid: %v files, %v tables, %v checked", dir, name, len(bm.FileEntries), len(restored), checkTables)
+	return nil
+}
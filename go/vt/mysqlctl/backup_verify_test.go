@@ -0,0 +1,166 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	mproto "github.com/youtube/vitess/go/mysql/proto"
+	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/logutil"
+	"golang.org/x/net/context"
+)
+
+func TestManifestTables(t *testing.T) {
+	bm := &BackupManifest{
+		FileEntries: []FileEntry{
+			{Base: backupInnodbDataHomeDir, Name: "ibdata1"},
+			{Base: backupData, Name: "vt_test/t2.frm"},
+			{Base: backupData, Name: "vt_test/t2.ibd"},
+			{Base: backupData, Name: "vt_test/db.opt"},
+			{Base: backupData, Name: "vt_test/t1.frm"},
+			{Base: backupData, Name: "vt_test@002dother/my@002dtable.frm"},
+			{Base: backupData, Name: "mysql/user.frm"},
+			{Base: backupData, Name: "auto.cnf"},
+		},
+	}
+	want := []string{"vt_test-other.my-table", "vt_test.t1", "vt_test.t2"}
+	if got := manifestTables(bm); !reflect.DeepEqual(got, want) {
+		t.Errorf("manifestTables: got %v, want %v", got, want)
+	}
+}
+
+func TestDiffTables(t *testing.T) {
+	missing, extra := diffTables([]string{"a.1", "a.2", "b.1"}, []string{"a.2", "b.1", "b.2"})
+	if want := []string{"a.1"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing: got %v, want %v", missing, want)
+	}
+	if want := []string{"b.2"}; !reflect.DeepEqual(extra, want) {
+		t.Errorf("extra: got %v, want %v", extra, want)
+	}
+	if missing, extra := diffTables([]string{"a.1"}, []string{"a.1"}); missing != nil || extra != nil {
+		t.Errorf("diffTables of the same lists: got %v %v", missing, extra)
+	}
+}
+
+func checkTableResult(msgType, msgText string) *mproto.QueryResult {
+	return &mproto.QueryResult{
+		Rows: [][]sqltypes.Value{{
+			sqltypes.MakeString([]byte("vt_test.t1")),
+			sqltypes.MakeString([]byte("check")),
+			sqltypes.MakeString([]byte(msgType)),
+			sqltypes.MakeString([]byte(msgText)),
+		}},
+	}
+}
+
+func TestCheckTable(t *testing.T) {
+	fmd := NewFakeMysqlDaemon(nil)
+	query := "CHECK TABLE `vt_test`.`t1`"
+
+	fmd.FetchSuperQueryMap = map[string]*mproto.QueryResult{query: checkTableResult("status", "OK")}
+	if err := checkTable(fmd, "vt_test.t1"); err != nil {
+		t.Errorf("checkTable: %v", err)
+	}
+
+	fmd.FetchSuperQueryMap[query] = checkTableResult("error", "Table is marked as crashed")
+	if err := checkTable(fmd, "vt_test.t1"); err == nil || err.Error() != "Table is marked as crashed" {
+		t.Errorf("checkTable on a crashed table: got %v", err)
+	}
+
+	fmd.FetchSuperQueryMap[query] = checkTableResult("status", "Corrupt")
+	if err := checkTable(fmd, "vt_test.t1"); err == nil {
+		t.Errorf("checkTable on a corrupt table: got no error")
+	}
+}
+
+// stringRows returns a QueryResult with one column.
+func stringRows(values ...string) *mproto.QueryResult {
+	qr := &mproto.QueryResult{}
+	for _, v := range values {
+		qr.Rows = append(qr.Rows, []sqltypes.Value{sqltypes.MakeString([]byte(v))})
+	}
+	return qr
+}
+
+func TestVerifyBackup(t *testing.T) {
+	ctx := context.Background()
+	logger := logutil.NewConsoleLogger()
+	root, err := ioutil.TempDir("", "backup_verify_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(root)
+	savedDataRoot := os.Getenv("VTDATAROOT")
+	os.Setenv("VTDATAROOT", root)
+	defer os.Setenv("VTDATAROOT", savedDataRoot)
+	fmd, restoreFlags := newIncrementalTestDaemon(t, path.Join(root, "source"))
+	defer restoreFlags()
+
+	// a full backup of vt_test.t1 and vt_test.t2
+	writeTestFile(t, path.Join(fmd.Mycnf.DataDir, "vt_test", "t1.frm"), "t1 definition")
+	writeTestFile(t, path.Join(fmd.Mycnf.DataDir, "vt_test", "t2.frm"), "t2 definition")
+	writeTestFile(t, path.Join(fmd.Mycnf.DataDir, "vt_test", "t2.ibd"), "t2 at 0-1-5")
+	fes, err := findFilesTobackup(fmd.Mycnf)
+	if err != nil {
+		t.Fatalf("findFilesTobackup failed: %v", err)
+	}
+	storeTestBackup(t, fmd, "1-full", &BackupManifest{
+		FileEntries:         fes,
+		ReplicationPosition: mariadbPos("0-1-5"),
+		Time:                1005,
+	})
+
+	// the scratch mysqld is stopped, and has its files in
+	// root/scratch
+	scratch := NewFakeMysqlDaemon(nil)
+	scratch.Running = false
+	scratchRoot := path.Join(root, "scratch")
+	scratch.Mycnf = &Mycnf{
+		DataDir:               path.Join(scratchRoot, "data"),
+		InnodbDataHomeDir:     path.Join(scratchRoot, "innodb", "data"),
+		InnodbLogGroupHomeDir: path.Join(scratchRoot, "innodb", "logs"),
+		TmpDir:                path.Join(scratchRoot, "tmp"),
+	}
+	fmd.ScratchMysqlDaemon = scratch
+
+	// the scratch mysqld only has t1 and t3
+	scratch.FetchSuperQueryMap = map[string]*mproto.QueryResult{
+		"SHOW DATABASES":             stringRows("information_schema", "mysql", "vt_test"),
+		"SHOW TABLES FROM `vt_test`": stringRows("t1", "t3"),
+		"CHECK TABLE `vt_test`.`t1`": checkTableResult("status", "OK"),
+		"CHECK TABLE `vt_test`.`t3`": checkTableResult("status", "OK"),
+	}
+	err = VerifyBackup(ctx, fmd, logger, "ks/0", "1-full", 2, 10)
+	if err == nil || !strings.Contains(err.Error(), "missing tables: vt_test.t2") || !strings.Contains(err.Error(), "unexpected tables: vt_test.t3") {
+		t.Errorf("VerifyBackup with missing and extra tables returned %v", err)
+	}
+	if scratch.Running {
+		t.Errorf("VerifyBackup didn't shut down the scratch mysqld")
+	}
+	if got, err := ioutil.ReadFile(path.Join(scratch.Mycnf.DataDir, "vt_test", "t2.ibd")); err != nil || string(got) != "t2 at 0-1-5" {
+		t.Errorf("VerifyBackup didn't restore t2.ibd: %q %v", got, err)
+	}
+
+	// with the tables of the backup, and a corrupt one
+	scratch.FetchSuperQueryMap["SHOW TABLES FROM `vt_test`"] = stringRows("t1", "t2")
+	scratch.FetchSuperQueryMap["CHECK TABLE `vt_test`.`t2`"] = checkTableResult("status", "OK")
+	if err := VerifyBackup(ctx, fmd, logger, "ks/0", "1-full", 2, 10); err != nil {
+		t.Errorf("VerifyBackup failed: %v", err)
+	}
+	scratch.FetchSuperQueryMap["CHECK TABLE `vt_test`.`t2`"] = checkTableResult("error", "Table is marked as crashed")
+	if err := VerifyBackup(ctx, fmd, logger, "ks/0", "1-full", 2, 10); err == nil || !strings.Contains(err.Error(), "CHECK TABLE vt_test.t2 failed: Table is marked as crashed") {
+		t.Errorf("VerifyBackup with a crashed table returned %v", err)
+	}
+
+	if err := VerifyBackup(ctx, fmd, logger, "ks/0", "2-missing", 2, 10); err == nil || !strings.Contains(err.Error(), "no backup 2-missing in ks/0") {
+		t.Errorf("VerifyBackup of a missing backup returned %v", err)
+	}
+}
@@ -0,0 +1,637 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/youtube/vitess/go/sqltypes"
+	blproto "github.com/youtube/vitess/go/vt/binlog/proto"
+)
+
+// This file contains the parsing of the row based replication events,
+// which are common between MariaDB 10.0 and MySQL 5.6.
+// http://dev.mysql.com/doc/internals/en/binlog-event.html
+
+// MySQL column types, as found in TABLE_MAP_EVENT.
+const (
+	typeDecimal    = 0
+	typeTiny       = 1
+	typeShort      = 2
+	typeLong       = 3
+	typeFloat      = 4
+	typeDouble     = 5
+	typeNull       = 6
+	typeTimestamp  = 7
+	typeLongLong   = 8
+	typeInt24      = 9
+	typeDate       = 10
+	typeTime       = 11
+	typeDateTime   = 12
+	typeYear       = 13
+	typeNewDate    = 14
+	typeVarchar    = 15
+	typeBit        = 16
+	typeTimestamp2 = 17
+	typeDateTime2  = 18
+	typeTime2      = 19
+	typeJSON       = 245
+	typeNewDecimal = 246
+	typeEnum       = 247
+	typeSet        = 248
+	typeTinyBlob   = 249
+	typeMediumBlob = 250
+	typeLongBlob   = 251
+	typeBlob       = 252
+	typeVarString  = 253
+	typeString     = 254
+	typeGeometry   = 255
+)
+
+// IsTableMap implements BinlogEvent.IsTableMap().
+func (ev binlogEvent) IsTableMap() bool {
+	return ev.Type() == 19
+}
+
+// IsWriteRows implements BinlogEvent.IsWriteRows().
+// We do not support v0 events (MySQL 5.1.0 to 5.1.15).
+func (ev binlogEvent) IsWriteRows() bool {
+	return ev.Type() == 23 || ev.Type() == 30
+}
+
+// IsUpdateRows implements BinlogEvent.IsUpdateRows().
+func (ev binlogEvent) IsUpdateRows() bool {
+	return ev.Type() == 24 || ev.Type() == 31
+}
+
+// IsDeleteRows implements BinlogEvent.IsDeleteRows().
+func (ev binlogEvent) IsDeleteRows() bool {
+	return ev.Type() == 25 || ev.Type() == 32
+}
+
+// TableID implements BinlogEvent.TableID().
+//
+// The table ID is the first field of the post-header. It is 6 bytes
+// long in all the versions we support (it was 4 bytes before 5.1).
+func (ev binlogEvent) TableID(f blproto.BinlogFormat) uint64 {
+	data := ev.Bytes()[f.HeaderLength:]
+	if len(data) < 6 {
+		return 0
+	}
+	return uint64(data[0]) |
+		uint64(data[1])<<8 |
+		uint64(data[2])<<16 |
+		uint64(data[3])<<24 |
+		uint64(data[4])<<32 |
+		uint64(data[5])<<40
+}
+
+// TableMap implements BinlogEvent.TableMap().
+//
+// Expected format (L = total length of event data):
+//   # bytes   field
+//   6         table id
+//   2         flags
+//   1         length of database name (X)
+//   X+1       database name + NULL terminator
+//   1         length of table name (Y)
+//   Y+1       table name + NULL terminator
+//   lenenc    column count (N)
+//   N         column types
+//   lenenc    length of metadata block (M)
+//   M         metadata block
+//   (N+7)/8   can-be-null bitmap
+func (ev binlogEvent) TableMap(f blproto.BinlogFormat) (*blproto.TableMap, error) {
+	data := ev.Bytes()[f.HeaderLength:]
+
+	result := &blproto.TableMap{}
+	pos := 6
+	if len(data) < pos+2 {
+		return nil, fmt.Errorf("TABLE_MAP_EVENT too short: %v bytes", len(data))
+	}
+	result.Flags = binary.LittleEndian.Uint16(data[pos : pos+2])
+	pos += 2
+
+	var err error
+	result.Database, pos, err = readNullTerminatedName(data, pos)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read database name: %v", err)
+	}
+	result.Name, pos, err = readNullTerminatedName(data, pos)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read table name: %v", err)
+	}
+
+	columnCount, pos, err := readLenEncInt(data, pos)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read column count: %v", err)
+	}
+	if pos+columnCount > len(data) {
+		return nil, fmt.Errorf("column types overflow buffer (%v > %v)", pos+columnCount, len(data))
+	}
+	result.Types = data[pos : pos+columnCount]
+	pos += columnCount
+
+	metaLen, pos, err := readLenEncInt(data, pos)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read metadata length: %v", err)
+	}
+	if pos+metaLen > len(data) {
+		return nil, fmt.Errorf("metadata block overflows buffer (%v > %v)", pos+metaLen, len(data))
+	}
+	result.Metadata, err = readColumnsMetadata(result.Types, data[pos:pos+metaLen])
+	if err != nil {
+		return nil, err
+	}
+	pos += metaLen
+
+	nullBytes := (columnCount + 7) / 8
+	if pos+nullBytes > len(data) {
+		return nil, fmt.Errorf("can-be-null bitmap overflows buffer (%v > %v)", pos+nullBytes, len(data))
+	}
+	result.CanBeNull = blproto.NewBitmap(data[pos:pos+nullBytes], columnCount)
+	return result, nil
+}
+
+// readNullTerminatedName reads a name prefixed with its length on one
+// byte, and followed by a NULL terminator.
+func readNullTerminatedName(data []byte, pos int) (string, int, error) {
+	if pos+1 > len(data) {
+		return "", 0, fmt.Errorf("name length overflows buffer (%v > %v)", pos+1, len(data))
+	}
+	l := int(data[pos])
+	pos++
+	if pos+l+1 > len(data) {
+		return "", 0, fmt.Errorf("name overflows buffer (%v > %v)", pos+l+1, len(data))
+	}
+	return string(data[pos : pos+l]), pos + l + 1, nil
+}
+
+// readLenEncInt reads a length-encoded integer.
+// http://dev.mysql.com/doc/internals/en/integer.html#length-encoded-integer
+func readLenEncInt(data []byte, pos int) (int, int, error) {
+	if pos+1 > len(data) {
+		return 0, 0, fmt.Errorf("integer overflows buffer (%v > %v)", pos+1, len(data))
+	}
+	var size int
+	switch data[pos] {
+	case 0xfc:
+		size = 2
+	case 0xfd:
+		size = 3
+	case 0xfe:
+		size = 8
+	default:
+		return int(data[pos]), pos + 1, nil
+	}
+	if pos+1+size > len(data) {
+		return 0, 0, fmt.Errorf("integer overflows buffer (%v > %v)", pos+1+size, len(data))
+	}
+	return int(readUintLE(data[pos+1 : pos+1+size])), pos + 1 + size, nil
+}
+
+// readColumnsMetadata reads the type-specific metadata of the columns.
+// The two byte metadata of NEWDECIMAL (precision, scale) and STRING
+// (real type, length) are stored first byte high.
+func readColumnsMetadata(types []byte, data []byte) ([]uint16, error) {
+	result := make([]uint16, len(types))
+	pos := 0
+	for i, typ := range types {
+		var size int
+		switch typ {
+		case typeFloat, typeDouble, typeBlob, typeGeometry, typeJSON,
+			typeTimestamp2, typeDateTime2, typeTime2:
+			size = 1
+		case typeVarchar, typeVarString, typeBit, typeNewDecimal,
+			typeString, typeEnum, typeSet:
+			size = 2
+		default:
+			continue
+		}
+		if pos+size > len(data) {
+			return nil, fmt.Errorf("metadata for column %v overflows buffer (%v > %v)", i, pos+size, len(data))
+		}
+		switch {
+		case size == 1:
+			result[i] = uint16(data[pos])
+		case typ == typeNewDecimal || typ == typeString || typ == typeEnum || typ == typeSet:
+			result[i] = uint16(data[pos])<<8 | uint16(data[pos+1])
+		default:
+			result[i] = binary.LittleEndian.Uint16(data[pos : pos+2])
+		}
+		pos += size
+	}
+	return result, nil
+}
+
+// Rows implements BinlogEvent.Rows().
+//
+// Expected format (L = total length of event data):
+//   # bytes   field
+//   6         table id
+//   2         flags
+//   2         length of extra data, including itself (E), v2 only
+//   E-2       extra data, v2 only
+//   lenenc    column count (N)
+//   (N+7)/8   columns present bitmap 1
+//   (N+7)/8   columns present bitmap 2, UPDATE only
+//   rest      rows
+//
+// Each row is made of one image (WRITE: after, DELETE: before) or two
+// images (UPDATE: before and after). An image starts with a NULL
+// bitmap of the present columns, followed by the values of the
+// present, non-NULL columns.
+func (ev binlogEvent) Rows(f blproto.BinlogFormat, tm *blproto.TableMap) (blproto.Rows, error) {
+	typ := ev.Type()
+	data := ev.Bytes()[f.HeaderLength:]
+	hasIdentify := ev.IsUpdateRows() || ev.IsDeleteRows()
+	hasData := ev.IsWriteRows() || ev.IsUpdateRows()
+
+	result := blproto.Rows{}
+	pos := 6
+	if len(data) < pos+2 {
+		return result, fmt.Errorf("rows event too short: %v bytes", len(data))
+	}
+	result.Flags = binary.LittleEndian.Uint16(data[pos : pos+2])
+	pos += 2
+
+	// v2 events have a variable length extra data block.
+	if typ >= 30 {
+		if len(data) < pos+2 {
+			return result, fmt.Errorf("rows event too short for extra data: %v bytes", len(data))
+		}
+		pos += int(binary.LittleEndian.Uint16(data[pos : pos+2]))
+	}
+
+	columnCount, pos, err := readLenEncInt(data, pos)
+	if err != nil {
+		return result, fmt.Errorf("cannot read column count: %v", err)
+	}
+	if columnCount != len(tm.Types) {
+		return result, fmt.Errorf("rows event has %v columns, but table map for %v.%v has %v", columnCount, tm.Database, tm.Name, len(tm.Types))
+	}
+	bitmapBytes := (columnCount + 7) / 8
+	if hasIdentify {
+		if pos+bitmapBytes > len(data) {
+			return result, fmt.Errorf("identify bitmap overflows buffer (%v > %v)", pos+bitmapBytes, len(data))
+		}
+		result.IdentifyColumns = blproto.NewBitmap(data[pos:pos+bitmapBytes], columnCount)
+		pos += bitmapBytes
+	}
+	if hasData {
+		if pos+bitmapBytes > len(data) {
+			return result, fmt.Errorf("data bitmap overflows buffer (%v > %v)", pos+bitmapBytes, len(data))
+		}
+		result.DataColumns = blproto.NewBitmap(data[pos:pos+bitmapBytes], columnCount)
+		pos += bitmapBytes
+	}
+
+	for pos < len(data) {
+		row := blproto.Row{}
+		if hasIdentify {
+			row.Identify, pos, err = readImage(data, pos, tm, result.IdentifyColumns)
+			if err != nil {
+				return result, fmt.Errorf("cannot read identify image of row %v: %v", len(result.Rows), err)
+			}
+		}
+		if hasData {
+			row.Data, pos, err = readImage(data, pos, tm, result.DataColumns)
+			if err != nil {
+				return result, fmt.Errorf("cannot read data image of row %v: %v", len(result.Rows), err)
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
+
+// readImage reads one row image.
+func readImage(data []byte, pos int, tm *blproto.TableMap, columns blproto.Bitmap) ([]sqltypes.Value, int, error) {
+	present := columns.BitCount()
+	nullBytes := (present + 7) / 8
+	if pos+nullBytes > len(data) {
+		return nil, 0, fmt.Errorf("NULL bitmap overflows buffer (%v > %v)", pos+nullBytes, len(data))
+	}
+	nulls := blproto.NewBitmap(data[pos:pos+nullBytes], present)
+	pos += nullBytes
+
+	values := make([]sqltypes.Value, columns.Count())
+	index := 0
+	for c := 0; c < columns.Count(); c++ {
+		if !columns.Bit(c) {
+			continue
+		}
+		isNull := nulls.Bit(index)
+		index++
+		if isNull {
+			continue
+		}
+		unsigned := c < len(tm.Unsigned) && tm.Unsigned[c]
+		value, l, err := cellValue(data[pos:], tm.Types[c], tm.Metadata[c], unsigned)
+		if err != nil {
+			return nil, 0, fmt.Errorf("column %v: %v", c, err)
+		}
+		values[c] = value
+		pos += l
+	}
+	return values, pos, nil
+}
+
+// readUintLE reads a little endian unsigned integer of up to 8 bytes.
+func readUintLE(data []byte) uint64 {
+	var result uint64
+	for i := len(data) - 1; i >= 0; i-- {
+		result = result<<8 | uint64(data[i])
+	}
+	return result
+}
+
+// readUintBE reads a big endian unsigned integer of up to 8 bytes.
+func readUintBE(data []byte) uint64 {
+	var result uint64
+	for _, b := range data {
+		result = result<<8 | uint64(b)
+	}
+	return result
+}
+
+// cellValue decodes the value of one column, and returns it with the
+// number of bytes it used.
+func cellValue(data []byte, typ byte, metadata uint16, unsigned bool) (sqltypes.Value, int, error) {
+	need := func(n int) error {
+		if n > len(data) {
+			return fmt.Errorf("value of type %v overflows buffer (%v > %v)", typ, n, len(data))
+		}
+		return nil
+	}
+	integer := func(size int) (sqltypes.Value, int, error) {
+		if err := need(size); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		v := readUintLE(data[:size])
+		if unsigned {
+			return sqltypes.MakeNumeric(strconv.AppendUint(nil, v, 10)), size, nil
+		}
+		// sign-extend
+		shift := uint(64 - 8*size)
+		return sqltypes.MakeNumeric(strconv.AppendInt(nil, int64(v<<shift)>>shift, 10)), size, nil
+	}
+	str := func(lengthBytes int) (sqltypes.Value, int, error) {
+		if err := need(lengthBytes); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		l := int(readUintLE(data[:lengthBytes]))
+		if err := need(lengthBytes + l); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		return sqltypes.MakeString(data[lengthBytes : lengthBytes+l]), lengthBytes + l, nil
+	}
+
+	switch typ {
+	case typeTiny:
+		return integer(1)
+	case typeShort:
+		return integer(2)
+	case typeInt24:
+		return integer(3)
+	case typeLong:
+		return integer(4)
+	case typeLongLong:
+		return integer(8)
+	case typeFloat:
+		if err := need(4); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		f := math.Float32frombits(binary.LittleEndian.Uint32(data[:4]))
+		return sqltypes.MakeFractional(strconv.AppendFloat(nil, float64(f), 'g', -1, 32)), 4, nil
+	case typeDouble:
+		if err := need(8); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		f := math.Float64frombits(binary.LittleEndian.Uint64(data[:8]))
+		return sqltypes.MakeFractional(strconv.AppendFloat(nil, f, 'g', -1, 64)), 8, nil
+	case typeYear:
+		if err := need(1); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		year := int64(data[0])
+		if year != 0 {
+			year += 1900
+		}
+		return sqltypes.MakeNumeric(strconv.AppendInt(nil, year, 10)), 1, nil
+	case typeNull:
+		return sqltypes.Value{}, 0, nil
+	case typeTimestamp:
+		if err := need(4); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		t := time.Unix(int64(binary.LittleEndian.Uint32(data[:4])), 0).UTC()
+		return sqltypes.MakeString([]byte(t.Format("2006-01-02 15:04:05"))), 4, nil
+	case typeDate, typeNewDate:
+		if err := need(3); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		v := readUintLE(data[:3])
+		return sqltypes.MakeString([]byte(fmt.Sprintf("%04d-%02d-%02d", v>>9, (v>>5)&15, v&31))), 3, nil
+	case typeTime:
+		if err := need(3); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		v := readUintLE(data[:3])
+		return sqltypes.MakeString([]byte(fmt.Sprintf("%02d:%02d:%02d", v/10000, (v%10000)/100, v%100))), 3, nil
+	case typeDateTime:
+		if err := need(8); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		v := binary.LittleEndian.Uint64(data[:8])
+		d, t := v/1000000, v%1000000
+		return sqltypes.MakeString([]byte(fmt.Sprintf("%04d-%02d-%02d %02d:%02d:%02d", d/10000, (d%10000)/100, d%100, t/10000, (t%10000)/100, t%100))), 8, nil
+	case typeTimestamp2:
+		fracBytes := int(metadata+1) / 2
+		if err := need(4 + fracBytes); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		t := time.Unix(int64(binary.BigEndian.Uint32(data[:4])), 0).UTC()
+		s := t.Format("2006-01-02 15:04:05") + fractionalSeconds(data[4:4+fracBytes], int(metadata))
+		return sqltypes.MakeString([]byte(s)), 4 + fracBytes, nil
+	case typeDateTime2:
+		fracBytes := int(metadata+1) / 2
+		if err := need(5 + fracBytes); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		v := readUintBE(data[:5]) - 0x8000000000
+		ymd := v >> 17
+		ym := ymd >> 5
+		hms := v & (1<<17 - 1)
+		s := fmt.Sprintf("%04d-%02d-%02d %02d:%02d:%02d", ym/13, ym%13, ymd&31, hms>>12, (hms>>6)&63, hms&63)
+		s += fractionalSeconds(data[5:5+fracBytes], int(metadata))
+		return sqltypes.MakeString([]byte(s)), 5 + fracBytes, nil
+	case typeTime2:
+		return time2Value(data, int(metadata))
+	case typeVarchar, typeVarString:
+		if metadata < 256 {
+			return str(1)
+		}
+		return str(2)
+	case typeBit:
+		nbits := int(metadata>>8)*8 + int(metadata&0xff)
+		l := (nbits + 7) / 8
+		if err := need(l); err != nil {
+			return sqltypes.Value{}, 0, err
+		}
+		return sqltypes.MakeNumeric(strconv.AppendUint(nil, readUintBE(data[:l]), 10)), l, nil
+	case typeNewDecimal:
+		return decimalValue(data, int(metadata>>8), int(metadata&0xff))
+	case typeBlob, typeGeometry, typeTinyBlob, typeMediumBlob, typeLongBlob:
+		return str(int(metadata))
+	case typeString, typeEnum, typeSet:
+		realType := byte(metadata >> 8)
+		length := int(metadata & 0xff)
+		if realType&0x30 != 0x30 {
+			// The length is over 255, and its high bits are
+			// stored inverted in the real type.
+			length |= int((realType&0x30)^0x30) << 4
+			realType |= 0x30
+		}
+		switch realType {
+		case typeEnum, typeSet:
+			if err := need(length); err != nil {
+				return sqltypes.Value{}, 0, err
+			}
+			return sqltypes.MakeNumeric(strconv.AppendUint(nil, readUintLE(data[:length]), 10)), length, nil
+		}
+		if length < 256 {
+			return str(1)
+		}
+		return str(2)
+	}
+	return sqltypes.Value{}, 0, fmt.Errorf("unsupported column type %v", typ)
+}
+
+// fractionalSeconds formats the fractional part of TIMESTAMP2,
+// DATETIME2 and TIME2 values, stored big endian on (fsp+1)/2 bytes.
+func fractionalSeconds(data []byte, fsp int) string {
+	if fsp == 0 {
+		return ""
+	}
+	// Scale the stored value to microseconds.
+	frac := readUintBE(data)
+	switch len(data) {
+	case 1:
+		frac *= 10000
+	case 2:
+		frac *= 100
+	}
+	return "." + fmt.Sprintf("%06d", frac)[:fsp]
+}
+
+// time2Value decodes a TIME2 value, which is stored big endian with
+// an offset, and a fractional part whose sign follows the integer part.
+func time2Value(data []byte, fsp int) (sqltypes.Value, int, error) {
+	fracBytes := (fsp + 1) / 2
+	l := 3 + fracBytes
+	if l > len(data) {
+		return sqltypes.Value{}, 0, fmt.Errorf("TIME2 value overflows buffer (%v > %v)", l, len(data))
+	}
+
+	// tmp is the number of microseconds, with the hours, minutes
+	// and seconds packed in the high bits.
+	var tmp int64
+	intPart := int64(readUintBE(data[:3])) - 0x800000
+	switch fracBytes {
+	case 0:
+		tmp = intPart << 24
+	case 1, 2:
+		frac := int64(readUintBE(data[3:l]))
+		if intPart < 0 && frac > 0 {
+			intPart++
+			frac -= 1 << uint(8*fracBytes)
+		}
+		if fracBytes == 1 {
+			frac *= 10000
+		} else {
+			frac *= 100
+		}
+		tmp = intPart<<24 + frac
+	case 3:
+		tmp = int64(readUintBE(data[:6])) - 0x800000000000
+	}
+
+	sign := ""
+	if tmp < 0 {
+		tmp = -tmp
+		sign = "-"
+	}
+	hms := tmp >> 24
+	s := fmt.Sprintf("%v%02d:%02d:%02d", sign, (hms>>12)%(1<<10), (hms>>6)%(1<<6), hms%(1<<6))
+	if fsp > 0 {
+		s += "." + fmt.Sprintf("%06d", tmp%(1<<24))[:fsp]
+	}
+	return sqltypes.MakeString([]byte(s)), l, nil
+}
+
+// decimalValue decodes a NEWDECIMAL value. The digits are stored in
+// groups of 9 digits on 4 bytes, big endian, with a shorter leading
+// (integer part) and trailing (fractional part) group. Negative values
+// have all their bits inverted, and the sign is stored in the first bit.
+func decimalValue(data []byte, precision, scale int) (sqltypes.Value, int, error) {
+	digitsBytes := []int{0, 1, 1, 2, 2, 3, 3, 4, 4, 4}
+	intg := precision - scale
+	intg0, intg0x := intg/9, intg%9
+	frac0, frac0x := scale/9, scale%9
+	l := intg0*4 + digitsBytes[intg0x] + frac0*4 + digitsBytes[frac0x]
+	if l > len(data) || l == 0 {
+		return sqltypes.Value{}, 0, fmt.Errorf("DECIMAL(%v,%v) value overflows buffer (%v > %v)", precision, scale, l, len(data))
+	}
+
+	buf := make([]byte, l)
+	copy(buf, data[:l])
+	negative := buf[0]&0x80 == 0
+	buf[0] ^= 0x80
+	if negative {
+		for i := range buf {
+			buf[i] ^= 0xff
+		}
+	}
+
+	var s []string
+	pos := 0
+	group := func(size, digits int) {
+		v := readUintBE(buf[pos : pos+size])
+		pos += size
+		s = append(s, fmt.Sprintf("%0*d", digits, v))
+	}
+	if digitsBytes[intg0x] > 0 {
+		group(digitsBytes[intg0x], intg0x)
+	}
+	for i := 0; i < intg0; i++ {
+		group(4, 9)
+	}
+	intPart := strings.TrimLeft(strings.Join(s, ""), "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	s = nil
+	for i := 0; i < frac0; i++ {
+		group(4, 9)
+	}
+	if digitsBytes[frac0x] > 0 {
+		group(digitsBytes[frac0x], frac0x)
+	}
+
+	result := intPart
+	if scale > 0 {
+		result += "." + strings.Join(s, "")
+	}
+	if negative {
+		result = "-" + result
+	}
+	return sqltypes.MakeFractional([]byte(result)), l, nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/youtube/vitess/go/sqltypes"
+	blproto "github.com/youtube/vitess/go/vt/binlog/proto"
+)
+
+var rbrFormat = blproto.BinlogFormat{HeaderLength: 19}
+
+// rbrEvent prepends a v4 header to the event data.
+func rbrEvent(typ byte, data []byte) binlogEvent {
+	length := 19 + len(data)
+	header := []byte{
+		0x0, 0x0, 0x0, 0x0, // timestamp
+		typ,
+		0x1, 0x0, 0x0, 0x0, // server id
+		byte(length), byte(length >> 8), 0x0, 0x0, // event length
+		0x0, 0x0, 0x0, 0x0, // next position
+		0x0, 0x0, // flags
+	}
+	return binlogEvent(append(header, data...))
+}
+
+// rbrTableMapEvent describes vt_test.t, with the columns
+// (id bigint NOT NULL, msg varchar(64), price decimal(10,2), ts datetime).
+var rbrTableMapEvent = rbrEvent(19, []byte{
+	0x42, 0x0, 0x0, 0x0, 0x0, 0x0, // table id
+	0x1, 0x0, // flags
+	0x7, 'v', 't', '_', 't', 'e', 's', 't', 0x0,
+	0x1, 't', 0x0,
+	0x4,                  // column count
+	0x8, 0xf, 0xf6, 0x12, // types
+	0x5,                      // metadata length
+	0x40, 0x0, 0xa, 0x2, 0x0, // metadata
+	0xe, // can be null
+})
+
+func TestBinlogEventTableMap(t *testing.T) {
+	ev := rbrTableMapEvent
+	if !ev.IsTableMap() || ev.IsWriteRows() || ev.IsUpdateRows() || ev.IsDeleteRows() {
+		t.Fatalf("unexpected event type detection for %v", ev.Type())
+	}
+	if got, want := ev.TableID(rbrFormat), uint64(0x42); got != want {
+		t.Errorf("TableID() = %v, want %v", got, want)
+	}
+	tm, err := ev.TableMap(rbrFormat)
+	if err != nil {
+		t.Fatalf("TableMap() failed: %v", err)
+	}
+	if tm.Flags != 1 || tm.Database != "vt_test" || tm.Name != "t" {
+		t.Errorf("TableMap() = %v, %v, %v", tm.Flags, tm.Database, tm.Name)
+	}
+	if want := []byte{8, 15, 246, 18}; !reflect.DeepEqual(tm.Types, want) {
+		t.Errorf("TableMap().Types = %v, want %v", tm.Types, want)
+	}
+	if want := []uint16{0, 64, 10<<8 | 2, 0}; !reflect.DeepEqual(tm.Metadata, want) {
+		t.Errorf("TableMap().Metadata = %v, want %v", tm.Metadata, want)
+	}
+	if tm.CanBeNull.Count() != 4 || tm.CanBeNull.Bit(0) || !tm.CanBeNull.Bit(3) {
+		t.Errorf("TableMap().CanBeNull = %#v", tm.CanBeNull)
+	}
+
+	if _, err := binlogEvent(ev[:30]).TableMap(rbrFormat); err == nil {
+		t.Errorf("TableMap() on a truncated event should fail")
+	}
+}
+
+func TestBinlogEventWriteRows(t *testing.T) {
+	tm, err := rbrTableMapEvent.TableMap(rbrFormat)
+	if err != nil {
+		t.Fatalf("TableMap() failed: %v", err)
+	}
+
+	// WRITE_ROWS_EVENT v2 with two rows:
+	// (-1, 'abc', 1234.56, '2015-10-21 13:45:30')
+	// (7, '', NULL, '2015-10-21 13:45:30')
+	ev := rbrEvent(30, []byte{
+		0x42, 0x0, 0x0, 0x0, 0x0, 0x0, // table id
+		0x0, 0x0, // flags
+		0x2, 0x0, // extra data length
+		0x4, // column count
+		0xf, // columns present
+		0x0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x3, 'a', 'b', 'c', 0x80, 0x0, 0x4, 0xd2, 0x38, 0x99, 0x97, 0x6a, 0xdb, 0x5e,
+		0x4, 0x7, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x99, 0x97, 0x6a, 0xdb, 0x5e,
+	})
+	if !ev.IsWriteRows() {
+		t.Fatalf("IsWriteRows() = false")
+	}
+	rows, err := ev.Rows(rbrFormat, tm)
+	if err != nil {
+		t.Fatalf("Rows() failed: %v", err)
+	}
+	want := []blproto.Row{
+		{
+			Data: []sqltypes.Value{
+				sqltypes.MakeNumeric([]byte("-1")),
+				sqltypes.MakeString([]byte("abc")),
+				sqltypes.MakeFractional([]byte("1234.56")),
+				sqltypes.MakeString([]byte("2015-10-21 13:45:30")),
+			},
+		},
+		{
+			Data: []sqltypes.Value{
+				sqltypes.MakeNumeric([]byte("7")),
+				sqltypes.MakeString([]byte("")),
+				sqltypes.Value{},
+				sqltypes.MakeString([]byte("2015-10-21 13:45:30")),
+			},
+		},
+	}
+	if !reflect.DeepEqual(rows.Rows, want) {
+		t.Errorf("Rows() = %v, want %v", rows.Rows, want)
+	}
+
+	// The same data decoded as unsigned.
+	tm.Unsigned = []bool{true, false, false, false}
+	rows, err = ev.Rows(rbrFormat, tm)
+	if err != nil {
+		t.Fatalf("Rows() failed: %v", err)
+	}
+	if got, want := rows.Rows[0].Data[0].String(), "18446744073709551615"; got != want {
+		t.Errorf("unsigned value = %v, want %v", got, want)
+	}
+}
+
+func TestBinlogEventUpdateRows(t *testing.T) {
+	tm, err := rbrTableMapEvent.TableMap(rbrFormat)
+	if err != nil {
+		t.Fatalf("TableMap() failed: %v", err)
+	}
+
+	// UPDATE_ROWS_EVENT v1 with a minimal row image:
+	// UPDATE t SET price=-1234.56 WHERE id=7
+	ev := rbrEvent(24, []byte{
+		0x42, 0x0, 0x0, 0x0, 0x0, 0x0, // table id
+		0x0, 0x0, // flags
+		0x4, // column count
+		0x1, // identify columns
+		0x4, // data columns
+		0x0, 0x7, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+		0x0, 0x7f, 0xff, 0xfb, 0x2d, 0xc7,
+	})
+	if !ev.IsUpdateRows() {
+		t.Fatalf("IsUpdateRows() = false")
+	}
+	rows, err := ev.Rows(rbrFormat, tm)
+	if err != nil {
+		t.Fatalf("Rows() failed: %v", err)
+	}
+	if rows.IdentifyColumns.BitCount() != 1 || rows.DataColumns.BitCount() != 1 {
+		t.Errorf("Rows() bitmaps = %#v, %#v", rows.IdentifyColumns, rows.DataColumns)
+	}
+	want := []blproto.Row{
+		{
+			Identify: []sqltypes.Value{sqltypes.MakeNumeric([]byte("7")), {}, {}, {}},
+			Data:     []sqltypes.Value{{}, {}, sqltypes.MakeFractional([]byte("-1234.56")), {}},
+		},
+	}
+	if !reflect.DeepEqual(rows.Rows, want) {
+		t.Errorf("Rows() = %v, want %v", rows.Rows, want)
+	}
+
+	// A row event for another table layout is refused.
+	tm.Types = tm.Types[:3]
+	if _, err := ev.Rows(rbrFormat, tm); err == nil {
+		t.Errorf("Rows() with a mismatched table map should fail")
+	}
+}
+
+func TestCellValue(t *testing.T) {
+	table := []struct {
+		data     []byte
+		typ      byte
+		metadata uint16
+		want     string
+	}{
+		{[]byte{0xfe}, typeTiny, 0, "-2"},
+		{[]byte{0x73}, typeYear, 0, "2015"},
+		{[]byte{0x55, 0xbf, 0xf}, typeDate, 0, "2015-10-21"},
+		{[]byte{0x0, 0x0, 0x80, 0x3f}, typeFloat, 0, "1"},
+		{[]byte{0x80, 0xd1, 0x1e, 0x1, 0xe2, 0x40}, typeTime2, 6, "13:04:30.123456"},
+		{[]byte{0x56, 0x27, 0xa5, 0x1a, 0x28}, typeTimestamp2, 1, "2015-10-21 14:45:46.4"},
+		{[]byte{0x2}, typeString, typeEnum<<8 | 1, "2"},
+		{[]byte{0x2, 'h', 'i'}, typeString, typeString<<8 | 3, "hi"},
+		{[]byte{0x1, 0x1}, typeBit, 1<<8 | 1, "257"},
+		{[]byte{0x2, 0x0, 'h', 'i'}, typeBlob, 2, "hi"},
+	}
+	for _, tcase := range table {
+		got, l, err := cellValue(tcase.data, tcase.typ, tcase.metadata, false)
+		if err != nil {
+			t.Errorf("cellValue(%v, %v) failed: %v", tcase.data, tcase.typ, err)
+			continue
+		}
+		if got.String() != tcase.want || l != len(tcase.data) {
+			t.Errorf("cellValue(%v, %v) = %v, %v, want %v, %v", tcase.data, tcase.typ, got, l, tcase.want, len(tcase.data))
+		}
+	}
+
+	if _, _, err := cellValue([]byte{0x1}, typeJSON, 1, false); err == nil {
+		t.Errorf("cellValue() on a JSON column should fail")
+	}
+}
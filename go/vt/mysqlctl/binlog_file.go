@@ -0,0 +1,259 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+
+	log "github.com/golang/glog"
+	vtenv "github.com/youtube/vitess/go/vt/env"
+	"github.com/youtube/vitess/go/vt/mysqlctl/proto"
+
+	blproto "github.com/youtube/vitess/go/vt/binlog/proto"
+)
+
+// This file reads and replays binlog files, for incremental backups.
+
+// binlogFileMagic is the header of all binlog files.
+var binlogFileMagic = []byte{0xfe, 'b', 'i', 'n'}
+
+// BinlogFileTransaction describes a transaction of a binlog file.
+type BinlogFileTransaction struct {
+	// Offset is the position in the file of the event with the
+	// GTID of the transaction, which starts it.
+	Offset int64
+
+	// GTID is the GTID of the transaction.
+	GTID proto.GTID
+
+	// Timestamp is when the transaction started on the master,
+	// in seconds since the epoch.
+	Timestamp uint32
+}
+
+// BinlogFileInfo describes the content of a binlog file.
+type BinlogFileInfo struct {
+	// PreviousGTIDs is the position before the first transaction
+	// of the file, from the PREVIOUS_GTIDS_LOG_EVENT (MySQL 5.6)
+	// or the GTID_LIST_EVENT (MariaDB) at its start. It is zero
+	// if the file doesn't have one.
+	PreviousGTIDs proto.ReplicationPosition
+
+	// Transactions are the transactions of the file.
+	Transactions []BinlogFileTransaction
+}
+
+const (
+	// previousGTIDsEvent is the PREVIOUS_GTIDS_LOG_EVENT of MySQL 5.6
+	previousGTIDsEvent = 35
+
+	// gtidListEvent is the GTID_LIST_EVENT of MariaDB
+	gtidListEvent = 163
+)
+
+// parsePreviousGTIDs returns the position stored in the body of a
+// PREVIOUS_GTIDS_LOG_EVENT or a GTID_LIST_EVENT.
+func parsePreviousGTIDs(typ byte, body []byte) (proto.ReplicationPosition, error) {
+	switch typ {
+	case previousGTIDsEvent:
+		set, err := proto.NewMysql56GTIDSetFromSIDBlock(body)
+		if err != nil {
+			return proto.ReplicationPosition{}, err
+		}
+		return proto.ReplicationPosition{GTIDSet: set}, nil
+	case gtidListEvent:
+		// The low 28 bits are the number of GTIDs, the last
+		// one of each domain. A MariaDB position can only
+		// have one domain, so we ignore the others.
+		if len(body) < 4 {
+			return proto.ReplicationPosition{}, fmt.Errorf("GTID_LIST_EVENT too short: %v bytes", len(body))
+		}
+		count := binary.LittleEndian.Uint32(body[0:4]) & 0x0fffffff
+		if count != 1 {
+			return proto.ReplicationPosition{}, nil
+		}
+		if len(body) < 4+16 {
+			return proto.ReplicationPosition{}, fmt.Errorf("GTID_LIST_EVENT too short: %v bytes", len(body))
+		}
+		gtid := proto.MariadbGTID{
+			Domain:   binary.LittleEndian.Uint32(body[4:8]),
+			Server:   binary.LittleEndian.Uint32(body[8:12]),
+			Sequence: binary.LittleEndian.Uint64(body[12:20]),
+		}
+		return proto.ReplicationPosition{GTIDSet: gtid.GTIDSet()}, nil
+	}
+	return proto.ReplicationPosition{}, fmt.Errorf("not a previous GTIDs event: %v", typ)
+}
+
+// readBinlogFile returns the content of a binlog file. makeEvent
+// is the MakeBinlogEvent method of the flavor that wrote the file.
+func readBinlogFile(r io.Reader, makeEvent func([]byte) blproto.BinlogEvent) (*BinlogFileInfo, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	magic := make([]byte, len(binlogFileMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, fmt.Errorf("cannot read binlog file header: %v", err)
+	}
+	if !bytes.Equal(magic, binlogFileMagic) {
+		return nil, fmt.Errorf("not a binlog file, bad header %x", magic)
+	}
+
+	result := &BinlogFileInfo{}
+	var format blproto.BinlogFormat
+	offset := int64(len(binlogFileMagic))
+	header := make([]byte, 19)
+	for {
+		// read the header to know the length of the event,
+		// then the rest of the event
+		if _, err := io.ReadFull(br, header); err != nil {
+			if err == io.EOF {
+				return result, nil
+			}
+			return nil, fmt.Errorf("cannot read binlog event at offset %v: %v", offset, err)
+		}
+		length := binary.LittleEndian.Uint32(header[9:13])
+		if length < uint32(len(header)) {
+			return nil, fmt.Errorf("invalid binlog event length %v at offset %v", length, offset)
+		}
+		buf := make([]byte, length)
+		copy(buf, header)
+		if _, err := io.ReadFull(br, buf[len(header):]); err != nil {
+			return nil, fmt.Errorf("cannot read binlog event at offset %v: %v", offset, err)
+		}
+		ev := makeEvent(buf)
+		eventOffset := offset
+		offset += int64(length)
+
+		if !ev.IsValid() {
+			return nil, fmt.Errorf("can't parse binlog event at offset %v, invalid data: %#v", eventOffset, ev)
+		}
+
+		// Same as the binlog streamer, a FORMAT_DESCRIPTION_EVENT
+		// tells us how to parse the next events.
+		if ev.IsFormatDescription() {
+			var err error
+			format, err = ev.Format()
+			if err != nil {
+				return nil, fmt.Errorf("can't parse FORMAT_DESCRIPTION_EVENT at offset %v: %v", eventOffset, err)
+			}
+			continue
+		}
+		if format.IsZero() {
+			return nil, fmt.Errorf("got a real event before FORMAT_DESCRIPTION_EVENT at offset %v", eventOffset)
+		}
+		ev, _, err := ev.StripChecksum(format)
+		if err != nil {
+			return nil, fmt.Errorf("can't strip checksum from binlog event at offset %v: %v", eventOffset, err)
+		}
+		if typ := buf[4]; typ == previousGTIDsEvent || typ == gtidListEvent {
+			body := buf[format.HeaderLength:]
+			if format.ChecksumAlgorithm == BinlogChecksumAlgCRC32 {
+				body = body[:len(body)-4]
+			}
+			result.PreviousGTIDs, err = parsePreviousGTIDs(typ, body)
+			if err != nil {
+				return nil, fmt.Errorf("can't parse previous GTIDs at offset %v: %v", eventOffset, err)
+			}
+			continue
+		}
+		if !ev.HasGTID(format) {
+			continue
+		}
+		gtid, err := ev.GTID(format)
+		if err != nil {
+			return nil, fmt.Errorf("can't get GTID from binlog event at offset %v: %v", eventOffset, err)
+		}
+
+		// With Google MySQL, all the events of a transaction
+		// have its GTID, only keep the first one.
+		if n := len(result.Transactions); n > 0 && result.Transactions[n-1].GTID == gtid {
+			continue
+		}
+		result.Transactions = append(result.Transactions, BinlogFileTransaction{
+			Offset:    eventOffset,
+			GTID:      gtid,
+			Timestamp: ev.Timestamp(),
+		})
+	}
+}
+
+// ReadBinlogFile returns the content of a binlog file.
+func (mysqld *Mysqld) ReadBinlogFile(name string) (*BinlogFileInfo, error) {
+	flavor, err := mysqld.flavor()
+	if err != nil {
+		return nil, fmt.Errorf("ReadBinlogFile needs flavor: %v", err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	result, err := readBinlogFile(f, flavor.MakeBinlogEvent)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read binlog file %v: %v", name, err)
+	}
+	return result, nil
+}
+
+// ApplyBinlogFile replays the events of a binlog file from the start
+// offset until the stop offset (or the end of the file if stop is 0),
+// by piping the output of mysqlbinlog into the mysql client.
+func (mysqld *Mysqld) ApplyBinlogFile(name string, start, stop int64) error {
+	dir, err := vtenv.VtMysqlRoot()
+	if err != nil {
+		return err
+	}
+
+	binlogArgs := []string{fmt.Sprintf("--start-position=%v", start)}
+	if stop > 0 {
+		binlogArgs = append(binlogArgs, fmt.Sprintf("--stop-position=%v", stop))
+	}
+	binlogArgs = append(binlogArgs, name)
+	binlogCmd := exec.Command(path.Join(dir, "bin/mysqlbinlog"), binlogArgs...)
+
+	mysqlArgs := []string{
+		// --defaults-file=* must be the first arg.
+		"--defaults-file=" + mysqld.config.path,
+		"--socket", mysqld.config.SocketFile,
+		"--user", mysqld.dba.Uname,
+	}
+	if mysqld.dba.Pass != "" {
+		// --password must be omitted entirely if empty, or else it will prompt.
+		mysqlArgs = append(mysqlArgs, "--password", mysqld.dba.Pass)
+	}
+	mysqlCmd := exec.Command(path.Join(dir, "bin/mysql"), mysqlArgs...)
+
+	libraryPath := os.ExpandEnv("LD_LIBRARY_PATH=$VT_MYSQL_ROOT/lib/mysql")
+	binlogCmd.Env = []string{libraryPath}
+	mysqlCmd.Env = []string{libraryPath}
+	var binlogErr, mysqlOut bytes.Buffer
+	binlogCmd.Stderr = &binlogErr
+	mysqlCmd.Stdout = &mysqlOut
+	mysqlCmd.Stderr = &mysqlOut
+	mysqlCmd.Stdin, err = binlogCmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	log.Infof("applying binlog file %v from %v to %v", name, start, stop)
+	if err := mysqlCmd.Start(); err != nil {
+		return fmt.Errorf("cannot start mysql: %v", err)
+	}
+	if err := binlogCmd.Run(); err != nil {
+		mysqlCmd.Process.Kill()
+		mysqlCmd.Wait()
+		return fmt.Errorf("mysqlbinlog failed: %v, output: %s", err, binlogErr.Bytes())
+	}
+	if err := mysqlCmd.Wait(); err != nil {
+		return fmt.Errorf("mysql failed to apply %v: %v, output: %s", name, err, mysqlOut.Bytes())
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/mysqlctl/proto"
+)
+
+// mariadbGTIDListEvent is a GTID_LIST_EVENT with 0-62344-8.
+var mariadbGTIDListEvent = []byte{0x88, 0x41, 0x9, 0x54, 0xa3, 0x88, 0xf3, 0x0, 0x0, 0x27, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x88, 0xf3, 0x0, 0x0, 0x8, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}
+
+func TestReadBinlogFile(t *testing.T) {
+	var file []byte
+	file = append(file, binlogFileMagic...)
+	file = append(file, mariadbFormatEvent...)
+	file = append(file, mariadbGTIDListEvent...)
+	file = append(file, mariadbStandaloneGTIDEvent...)
+	file = append(file, mariadbInsertEvent...)
+	file = append(file, mariadbBeginGTIDEvent...)
+	file = append(file, mariadbInsertEvent...)
+
+	got, err := readBinlogFile(bytes.NewReader(file), NewMariadbBinlogEvent)
+	if err != nil {
+		t.Fatalf("readBinlogFile failed: %v", err)
+	}
+	first := int64(len(binlogFileMagic) + len(mariadbFormatEvent) + len(mariadbGTIDListEvent))
+	second := first + int64(len(mariadbStandaloneGTIDEvent)+len(mariadbInsertEvent))
+	want := &BinlogFileInfo{
+		PreviousGTIDs: proto.ReplicationPosition{
+			GTIDSet: proto.MariadbGTID{Domain: 0, Server: 62344, Sequence: 8},
+		},
+		Transactions: []BinlogFileTransaction{{
+			Offset:    first,
+			GTID:      proto.MariadbGTID{Domain: 0, Server: 62344, Sequence: 9},
+			Timestamp: 1409892744,
+		}, {
+			Offset:    second,
+			GTID:      proto.MariadbGTID{Domain: 0, Server: 62344, Sequence: 10},
+			Timestamp: 1409892744,
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readBinlogFile() = %#v, want %#v", got, want)
+	}
+}
+
+func TestReadBinlogFileErrors(t *testing.T) {
+	testcases := []struct {
+		file []byte
+		err  string
+	}{{
+		file: []byte("not a binlog file"),
+		err:  "not a binlog file",
+	}, {
+		file: append(append([]byte{}, binlogFileMagic...), mariadbBeginGTIDEvent...),
+		err:  "got a real event before FORMAT_DESCRIPTION_EVENT",
+	}, {
+		file: append(append([]byte{}, binlogFileMagic...), mariadbFormatEvent[:100]...),
+		err:  "cannot read binlog event at offset 4",
+	}}
+	for _, tcase := range testcases {
+		_, err := readBinlogFile(bytes.NewReader(tcase.file), NewMariadbBinlogEvent)
+		if err == nil || !strings.Contains(err.Error(), tcase.err) {
+			t.Errorf("readBinlogFile() returned %v, want error containing %v", err, tcase.err)
+		}
+	}
+}
+
+func TestParsePreviousGTIDs(t *testing.T) {
+	sid := proto.SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	want, err := proto.ParseReplicationPosition("MySQL56", sid.String()+":1-5:10-20")
+	if err != nil {
+		t.Fatalf("ParseReplicationPosition failed: %v", err)
+	}
+	got, err := parsePreviousGTIDs(previousGTIDsEvent, want.GTIDSet.(proto.Mysql56GTIDSet).SIDBlock())
+	if err != nil {
+		t.Fatalf("parsePreviousGTIDs failed: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("parsePreviousGTIDs() = %v, want %v", got, want)
+	}
+
+	// a GTID_LIST_EVENT with several domains is ignored
+	got, err = parsePreviousGTIDs(gtidListEvent, []byte{2, 0, 0, 0})
+	if err != nil || !got.IsZero() {
+		t.Errorf("parsePreviousGTIDs(2 domains) = (%v, %v), want zero", got, err)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// tailFilePollInterval is how often tailFile checks the file for new
+// data. It is a variable so tests can change it.
+var tailFilePollInterval = time.Second
+
+// tailFileBufferSize is the maximum size of the data sent at once by
+// tailFile.
+const tailFileBufferSize = 64 * 1024
+
+// TailErrorLog sends the MySQL error log to send as it is written,
+// until ctx is done or send fails. It starts with the last lastBytes
+// bytes of the existing error log. The data slice is reused after send
+// returns. If a mysqlctld address is provided in a flag, the error
+// log is read remotely.
+func (mysqld *Mysqld) TailErrorLog(ctx context.Context, lastBytes int64, send func([]byte) error) error {
+	// Execute as remote action on mysqlctld if requested.
+	if *socketFile != "" {
+		client, err := mysqld.mysqlctldClient()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		datastream, errFunc, err := client.TailErrorLog(ctx, lastBytes)
+		if err != nil {
+			return err
+		}
+		for data := range datastream {
+			if err := send(data); err != nil {
+				// cancel, and drain the stream
+				cancel()
+				for range datastream {
+				}
+				return err
+			}
+		}
+		return errFunc()
+	}
+
+	return tailFile(ctx, mysqld.config.ErrorLogPath, lastBytes, send)
+}
+
+// tailFile sends the data appended to a file, as 'tail -F' would.
+// When the file is truncated, or rotated, it starts again from the
+// beginning of the file. It's fine if the file doesn't exist yet.
+func tailFile(ctx context.Context, name string, lastBytes int64, send func([]byte) error) error {
+	var f *os.File
+	var fi os.FileInfo
+	var offset int64
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	buf := make([]byte, tailFileBufferSize)
+	first := true
+	for {
+		if f == nil {
+			var err error
+			f, err = os.Open(name)
+			switch {
+			case err == nil:
+				if fi, err = f.Stat(); err != nil {
+					return err
+				}
+				offset = 0
+				if first && fi.Size() > lastBytes {
+					offset = fi.Size() - lastBytes
+				}
+			case !os.IsNotExist(err):
+				return err
+			}
+			first = false
+		}
+
+		if f != nil {
+			// send everything up to the end of the file
+			for {
+				n, err := f.ReadAt(buf, offset)
+				if n > 0 {
+					if err := send(buf[:n]); err != nil {
+						return err
+					}
+					offset += int64(n)
+				}
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			cur, err := os.Stat(name)
+			switch {
+			case err != nil && !os.IsNotExist(err):
+				return err
+			case err != nil || !os.SameFile(fi, cur):
+				// the file was rotated, or removed
+				f.Close()
+				f = nil
+			case cur.Size() < offset:
+				// the file was truncated
+				offset = 0
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(tailFilePollInterval):
+		}
+	}
+}
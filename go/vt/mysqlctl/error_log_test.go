@@ -0,0 +1,122 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func appendFile(t *testing.T, name, data string) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+}
+
+// expectTail waits for tailFile to send the expected data.
+func expectTail(t *testing.T, c chan string, want string) {
+	got := ""
+	timeout := time.After(10 * time.Second)
+	for got != want {
+		select {
+		case data := <-c:
+			got += data
+		case <-timeout:
+			t.Fatalf("tailFile sent %q, want %q", got, want)
+		}
+	}
+}
+
+func TestTailFile(t *testing.T) {
+	defer func(d time.Duration) {
+		tailFilePollInterval = d
+	}(tailFilePollInterval)
+	tailFilePollInterval = 10 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "error_log_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	name := path.Join(dir, "error.log")
+	appendFile(t, name, "line 1\nline 2\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan string, 10)
+	done := make(chan error)
+	go func() {
+		done <- tailFile(ctx, name, 7, func(data []byte) error {
+			c <- string(data)
+			return nil
+		})
+	}()
+
+	// only the last bytes of the existing file, then the new data
+	expectTail(t, c, "line 2\n")
+	appendFile(t, name, "line 3\n")
+	expectTail(t, c, "line 3\n")
+
+	// truncated
+	if err := os.Truncate(name, 0); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+	appendFile(t, name, "truncated\n")
+	expectTail(t, c, "truncated\n")
+
+	// rotated
+	if err := os.Rename(name, name+".old"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	appendFile(t, name, "rotated\n")
+	expectTail(t, c, "rotated\n")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("tailFile failed: %v", err)
+	}
+}
+
+func TestTailFileMissing(t *testing.T) {
+	defer func(d time.Duration) {
+		tailFilePollInterval = d
+	}(tailFilePollInterval)
+	tailFilePollInterval = 10 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "error_log_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	name := path.Join(dir, "error.log")
+
+	// the file is sent from the start once it is created
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan string, 10)
+	done := make(chan error)
+	go func() {
+		done <- tailFile(ctx, name, 0, func(data []byte) error {
+			c <- string(data)
+			return nil
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, name, "created\n")
+	expectTail(t, c, "created\n")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("tailFile failed: %v", err)
+	}
+}
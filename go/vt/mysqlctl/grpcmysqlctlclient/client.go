@@ -7,6 +7,7 @@
 package grpcmysqlctlclient
 
 import (
+	"io"
 	"net"
 	"time"
 
@@ -14,7 +15,9 @@ import (
 
 	"golang.org/x/net/context"
 
+	mproto "github.com/youtube/vitess/go/mysql/proto"
 	"github.com/youtube/vitess/go/vt/mysqlctl/mysqlctlclient"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 
 	pb "github.com/youtube/vitess/go/vt/proto/mysqlctl"
 )
@@ -60,6 +63,95 @@ func (c *client) RunMysqlUpgrade(ctx context.Context) error {
 	return err
 }
 
+// ReinitConfig is part of the MysqlctlClient interface.
+func (c *client) ReinitConfig(ctx context.Context) error {
+	_, err := c.c.ReinitConfig(ctx, &pb.ReinitConfigRequest{})
+	return err
+}
+
+// IsReadOnly is part of the MysqlctlClient interface.
+func (c *client) IsReadOnly(ctx context.Context) (bool, error) {
+	response, err := c.c.IsReadOnly(ctx, &pb.IsReadOnlyRequest{})
+	if err != nil {
+		return true, err
+	}
+	return response.ReadOnly, nil
+}
+
+// SetReadOnly is part of the MysqlctlClient interface.
+func (c *client) SetReadOnly(ctx context.Context, on bool) error {
+	_, err := c.c.SetReadOnly(ctx, &pb.SetReadOnlyRequest{
+		ReadOnly: on,
+	})
+	return err
+}
+
+// SlaveStatus is part of the MysqlctlClient interface.
+func (c *client) SlaveStatus(ctx context.Context) (myproto.ReplicationStatus, error) {
+	response, err := c.c.SlaveStatus(ctx, &pb.SlaveStatusRequest{})
+	if err != nil {
+		return myproto.ReplicationStatus{}, err
+	}
+	return myproto.ProtoToReplicationStatus(response.Status), nil
+}
+
+// MasterPosition is part of the MysqlctlClient interface.
+func (c *client) MasterPosition(ctx context.Context) (myproto.ReplicationPosition, error) {
+	response, err := c.c.MasterPosition(ctx, &pb.MasterPositionRequest{})
+	if err != nil {
+		return myproto.ReplicationPosition{}, err
+	}
+	return myproto.DecodeReplicationPosition(response.Position)
+}
+
+// ExecuteSuperQueryList is part of the MysqlctlClient interface.
+func (c *client) ExecuteSuperQueryList(ctx context.Context, queries []string) error {
+	_, err := c.c.ExecuteSuperQueryList(ctx, &pb.ExecuteSuperQueryListRequest{
+		Queries: queries,
+	})
+	return err
+}
+
+// FetchSuperQuery is part of the MysqlctlClient interface.
+func (c *client) FetchSuperQuery(ctx context.Context, query string) (*mproto.QueryResult, error) {
+	response, err := c.c.FetchSuperQuery(ctx, &pb.FetchSuperQueryRequest{
+		Query: query,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mproto.Proto3ToQueryResult(response.Result), nil
+}
+
+// TailErrorLog is part of the MysqlctlClient interface.
+func (c *client) TailErrorLog(ctx context.Context, lastBytes int64) (<-chan []byte, mysqlctlclient.ErrFunc, error) {
+	stream, err := c.c.TailErrorLog(ctx, &pb.TailErrorLogRequest{
+		LastBytes: lastBytes,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	datastream := make(chan []byte, 10)
+	var finalErr error
+	go func() {
+		for {
+			response, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					finalErr = err
+				}
+				close(datastream)
+				return
+			}
+			datastream <- response.Data
+		}
+	}()
+	return datastream, func() error {
+		return finalErr
+	}, nil
+}
+
 // Close is part of the MysqlctlClient interface.
 func (c *client) Close() {
 	c.cc.Close()
@@ -11,7 +11,9 @@ package grpcmysqlctlserver
 import (
 	"google.golang.org/grpc"
 
+	mproto "github.com/youtube/vitess/go/mysql/proto"
 	"github.com/youtube/vitess/go/vt/mysqlctl"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 	"golang.org/x/net/context"
 
 	pb "github.com/youtube/vitess/go/vt/proto/mysqlctl"
@@ -37,6 +39,64 @@ func (s *server) RunMysqlUpgrade(ctx context.Context, request *pb.RunMysqlUpgrad
 	return &pb.RunMysqlUpgradeResponse{}, s.mysqld.RunMysqlUpgrade()
 }
 
+// ReinitConfig implements the server side of the MysqlctlClient interface.
+func (s *server) ReinitConfig(ctx context.Context, request *pb.ReinitConfigRequest) (*pb.ReinitConfigResponse, error) {
+	return &pb.ReinitConfigResponse{}, s.mysqld.ReinitConfig(ctx)
+}
+
+// IsReadOnly implements the server side of the MysqlctlClient interface.
+func (s *server) IsReadOnly(ctx context.Context, request *pb.IsReadOnlyRequest) (*pb.IsReadOnlyResponse, error) {
+	readOnly, err := s.mysqld.IsReadOnly()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.IsReadOnlyResponse{ReadOnly: readOnly}, nil
+}
+
+// SetReadOnly implements the server side of the MysqlctlClient interface.
+func (s *server) SetReadOnly(ctx context.Context, request *pb.SetReadOnlyRequest) (*pb.SetReadOnlyResponse, error) {
+	return &pb.SetReadOnlyResponse{}, s.mysqld.SetReadOnly(request.ReadOnly)
+}
+
+// SlaveStatus implements the server side of the MysqlctlClient interface.
+func (s *server) SlaveStatus(ctx context.Context, request *pb.SlaveStatusRequest) (*pb.SlaveStatusResponse, error) {
+	status, err := s.mysqld.SlaveStatus()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SlaveStatusResponse{Status: myproto.ReplicationStatusToProto(status)}, nil
+}
+
+// MasterPosition implements the server side of the MysqlctlClient interface.
+func (s *server) MasterPosition(ctx context.Context, request *pb.MasterPositionRequest) (*pb.MasterPositionResponse, error) {
+	position, err := s.mysqld.MasterPosition()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.MasterPositionResponse{Position: myproto.EncodeReplicationPosition(position)}, nil
+}
+
+// ExecuteSuperQueryList implements the server side of the MysqlctlClient interface.
+func (s *server) ExecuteSuperQueryList(ctx context.Context, request *pb.ExecuteSuperQueryListRequest) (*pb.ExecuteSuperQueryListResponse, error) {
+	return &pb.ExecuteSuperQueryListResponse{}, s.mysqld.ExecuteSuperQueryList(request.Queries)
+}
+
+// FetchSuperQuery implements the server side of the MysqlctlClient interface.
+func (s *server) FetchSuperQuery(ctx context.Context, request *pb.FetchSuperQueryRequest) (*pb.FetchSuperQueryResponse, error) {
+	qr, err := s.mysqld.FetchSuperQuery(request.Query)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.FetchSuperQueryResponse{Result: mproto.QueryResultToProto3(qr)}, nil
+}
+
+// TailErrorLog implements the server side of the MysqlctlClient interface.
+func (s *server) TailErrorLog(request *pb.TailErrorLogRequest, stream pb.MysqlCtl_TailErrorLogServer) error {
+	return s.mysqld.TailErrorLog(stream.Context(), request.LastBytes, func(data []byte) error {
+		return stream.Send(&pb.TailErrorLogResponse{Data: data})
+	})
+}
+
 // StartServer registers the Server for RPCs.
 func StartServer(s *grpc.Server, mysqld *mysqlctl.Mysqld) {
 	pb.RegisterMysqlCtlServer(s, &server{mysqld})
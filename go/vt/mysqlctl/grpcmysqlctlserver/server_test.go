@@ -0,0 +1,121 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package grpcmysqlctlserver
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	mproto "github.com/youtube/vitess/go/mysql/proto"
+	"github.com/youtube/vitess/go/sqldb"
+	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/mysqlctl"
+	"github.com/youtube/vitess/go/vt/mysqlctl/mysqlctlclient"
+	"github.com/youtube/vitess/go/vt/vttest/fakesqldb"
+)
+
+// TestMysqlctlServer runs the remote mysqlctl actions through a
+// mysqlctld gRPC server listening on a unix socket, like vttablet
+// does with -mysqlctl_socket.
+func TestMysqlctlServer(t *testing.T) {
+	root, err := ioutil.TempDir("", "mysqlctl_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// the mysqld of mysqlctld, with a fake database and an error log
+	db := fakesqldb.Register()
+	db.AddQuery("SHOW VARIABLES LIKE 'read_only'", &mproto.QueryResult{
+		Rows: [][]sqltypes.Value{
+			{
+				sqltypes.MakeString([]byte("read_only")),
+				sqltypes.MakeString([]byte("ON")),
+			},
+		},
+	})
+	db.AddQuery("SET GLOBAL read_only = OFF", &mproto.QueryResult{})
+	cnf := mysqlctl.NewMycnf(11111, 6802)
+	cnf.ErrorLogPath = path.Join(root, "error.log")
+	if err := ioutil.WriteFile(cnf.ErrorLogPath, []byte("first line\nsecond line\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	params := sqldb.ConnParams{Engine: db.Name}
+	mysqld := mysqlctl.NewMysqld("", "", cnf, &params, &params, &params)
+	defer mysqld.Close()
+
+	socketFile := path.Join(root, "mysqlctl.sock")
+	listener, err := net.Listen("unix", socketFile)
+	if err != nil {
+		t.Fatalf("Cannot listen: %v", err)
+	}
+	server := grpc.NewServer()
+	StartServer(server, mysqld)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	client, err := mysqlctlclient.New("unix", socketFile)
+	if err != nil {
+		t.Fatalf("Cannot create client: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	readOnly, err := client.IsReadOnly(ctx)
+	if err != nil || !readOnly {
+		t.Errorf("IsReadOnly() = (%v, %v), want (true, nil)", readOnly, err)
+	}
+	if err := client.SetReadOnly(ctx, false); err != nil {
+		t.Errorf("SetReadOnly(false) failed: %v", err)
+	}
+	if err := client.ExecuteSuperQueryList(ctx, []string{"SET GLOBAL read_only = OFF"}); err != nil {
+		t.Errorf("ExecuteSuperQueryList failed: %v", err)
+	}
+	if err := client.ExecuteSuperQueryList(ctx, []string{"DROP DATABASE vt_test"}); err == nil {
+		t.Errorf("ExecuteSuperQueryList of an unknown query worked")
+	}
+	qr, err := client.FetchSuperQuery(ctx, "SHOW VARIABLES LIKE 'read_only'")
+	if err != nil {
+		t.Fatalf("FetchSuperQuery failed: %v", err)
+	}
+	if len(qr.Rows) != 1 || qr.Rows[0][1].String() != "ON" {
+		t.Errorf("FetchSuperQuery returned %v", qr)
+	}
+
+	// TailErrorLog sends the end of the error log, then what is
+	// appended to it, until it is canceled
+	tailCtx, cancel := context.WithCancel(ctx)
+	datastream, errFunc, err := client.TailErrorLog(tailCtx, int64(len("second line\n")))
+	if err != nil {
+		t.Fatalf("TailErrorLog failed: %v", err)
+	}
+	if data := string(<-datastream); data != "second line\n" {
+		t.Errorf("TailErrorLog sent %q, want %q", data, "second line\n")
+	}
+	f, err := os.OpenFile(cnf.ErrorLogPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	if _, err := f.Write([]byte("third line\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	f.Close()
+	if data := string(<-datastream); data != "third line\n" {
+		t.Errorf("TailErrorLog sent %q, want %q", data, "third line\n")
+	}
+	cancel()
+	for range datastream {
+	}
+	if err := errFunc(); err != nil && grpc.Code(err) != codes.Canceled {
+		t.Errorf("TailErrorLog failed: %v", err)
+	}
+}
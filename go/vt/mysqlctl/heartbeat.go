@@ -0,0 +1,198 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/youtube/vitess/go/stats"
+	"github.com/youtube/vitess/go/timer"
+	"github.com/youtube/vitess/go/vt/health"
+)
+
+// This file contains the replication heartbeat. Seconds_Behind_Master
+// only measures how late the SQL thread is compared to what the IO
+// thread received, so it is 0 when the IO thread is stalled. Instead,
+// the master writes its current time in the _vt.heartbeat table at a
+// regular interval, and the replicas compare the replicated time with
+// their own clock.
+
+var (
+	heartbeatInterval = flag.Duration("heartbeat_interval", time.Second, "how often the master writes its heartbeat in _vt.heartbeat")
+
+	heartbeatWrites      = stats.NewInt("HeartbeatWrites")
+	heartbeatWriteErrors = stats.NewInt("HeartbeatWriteErrors")
+)
+
+const (
+	// heartbeatID is the id of the only row of the table. It is
+	// updated by the current master, whoever it is.
+	heartbeatID = 1
+)
+
+// heartbeatCreateQueries creates the _vt.heartbeat table.
+var heartbeatCreateQueries = []string{
+	"CREATE DATABASE IF NOT EXISTS _vt",
+	`CREATE TABLE IF NOT EXISTS _vt.heartbeat (
+  id INT UNSIGNED NOT NULL,
+  tablet_uid INT UNSIGNED NOT NULL,
+  ts BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB`,
+}
+
+// heartbeatWriteQuery returns the query to write a heartbeat in
+// _vt.heartbeat. ts is in nanoseconds, so the lag is precise.
+func heartbeatWriteQuery(tabletUID uint32, now time.Time) string {
+	return fmt.Sprintf("INSERT INTO _vt.heartbeat (id, tablet_uid, ts) VALUES (%v, %v, %v) ON DUPLICATE KEY UPDATE tablet_uid = VALUES(tablet_uid), ts = VALUES(ts)", heartbeatID, tabletUID, now.UnixNano())
+}
+
+// heartbeatReadQuery reads the last replicated heartbeat.
+var heartbeatReadQuery = fmt.Sprintf("SELECT ts FROM _vt.heartbeat WHERE id = %v", heartbeatID)
+
+// HeartbeatWriter writes the heartbeat on the master.
+type HeartbeatWriter struct {
+	mysqld    MysqlDaemon
+	tabletUID uint32
+
+	// mu protects the fields below.
+	mu     sync.Mutex
+	ticker *timer.Timer
+
+	// created is set once the table exists. It is only used by
+	// Open before the ticker starts, and then by the ticker.
+	created bool
+}
+
+// NewHeartbeatWriter returns a HeartbeatWriter for a tablet. It
+// doesn't write until Open is called.
+func NewHeartbeatWriter(mysqld MysqlDaemon, tabletUID uint32) *HeartbeatWriter {
+	return &HeartbeatWriter{
+		mysqld:    mysqld,
+		tabletUID: tabletUID,
+	}
+}
+
+// Open creates the heartbeat table if needed, and starts writing
+// heartbeats every -heartbeat_interval. If the table cannot be
+// created, for instance while mysqld is still starting, Open
+// returns the error, and the creation is tried again before each
+// heartbeat until it succeeds. It does nothing if the
+// HeartbeatWriter is already open.
+func (hw *HeartbeatWriter) Open() error {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+	if hw.ticker != nil {
+		return nil
+	}
+
+	log.Infof("starting to write heartbeats every %v", *heartbeatInterval)
+	err := hw.createTable()
+	if err == nil {
+		hw.write()
+	}
+	hw.ticker = timer.NewTimer(*heartbeatInterval)
+	hw.ticker.Start(hw.tick)
+	return err
+}
+
+// Close stops writing heartbeats. It does nothing if the
+// HeartbeatWriter is not open.
+func (hw *HeartbeatWriter) Close() {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+	if hw.ticker == nil {
+		return
+	}
+	hw.ticker.Stop()
+	hw.ticker = nil
+	log.Infof("stopped writing heartbeats")
+}
+
+// createTable creates the heartbeat table, if it wasn't yet.
+func (hw *HeartbeatWriter) createTable() error {
+	if hw.created {
+		return nil
+	}
+	if err := hw.mysqld.ExecuteSuperQueryList(heartbeatCreateQueries); err != nil {
+		return fmt.Errorf("cannot create the heartbeat table: %v", err)
+	}
+	hw.created = true
+	return nil
+}
+
+// tick writes one heartbeat, after creating the table if needed.
+func (hw *HeartbeatWriter) tick() {
+	if err := hw.createTable(); err != nil {
+		heartbeatWriteErrors.Add(1)
+		log.Warningf("cannot write heartbeat: %v", err)
+		return
+	}
+	hw.write()
+}
+
+// write writes one heartbeat. Errors are only counted and logged,
+// the replicas will see the lag increase.
+func (hw *HeartbeatWriter) write() {
+	if err := hw.mysqld.ExecuteSuperQueryList([]string{heartbeatWriteQuery(hw.tabletUID, time.Now())}); err != nil {
+		heartbeatWriteErrors.Add(1)
+		log.Warningf("cannot write heartbeat: %v", err)
+		return
+	}
+	heartbeatWrites.Add(1)
+}
+
+// heartbeatReplicationLag implements health.Reporter
+type heartbeatReplicationLag struct {
+	mysqld MysqlDaemon
+
+	// now is time.Now, except in tests.
+	now func() time.Time
+}
+
+// Report is part of the health.Reporter interface
+func (hrl *heartbeatReplicationLag) Report(isSlaveType, shouldQueryServiceBeRunning bool) (time.Duration, error) {
+	if !isSlaveType {
+		return 0, nil
+	}
+
+	qr, err := hrl.mysqld.FetchSuperQuery(heartbeatReadQuery)
+	if err != nil {
+		return 0, fmt.Errorf("cannot read the heartbeat: %v", err)
+	}
+	if len(qr.Rows) != 1 || len(qr.Rows[0]) != 1 {
+		return 0, fmt.Errorf("no heartbeat in _vt.heartbeat, is the master writing it?")
+	}
+	ts, err := qr.Rows[0][0].ParseInt64()
+	if err != nil {
+		return 0, fmt.Errorf("invalid heartbeat %v: %v", qr.Rows[0][0], err)
+	}
+
+	// with clock skew, the heartbeat may be in the future
+	lag := hrl.now().Sub(time.Unix(0, ts))
+	if lag < 0 {
+		lag = 0
+	}
+	return lag, nil
+}
+
+// HTMLName is part of the health.Reporter interface
+func (hrl *heartbeatReplicationLag) HTMLName() template.HTML {
+	return template.HTML("HeartbeatReplicationLag")
+}
+
+// HeartbeatReplicationLag returns a reporter that reports the
+// replication lag from the heartbeat written by the master.
+func HeartbeatReplicationLag(mysqld MysqlDaemon) health.Reporter {
+	return &heartbeatReplicationLag{
+		mysqld: mysqld,
+		now:    time.Now,
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	mproto "github.com/youtube/vitess/go/mysql/proto"
+	"github.com/youtube/vitess/go/sqltypes"
+)
+
+func heartbeatResult(ts time.Time) *mproto.QueryResult {
+	return &mproto.QueryResult{
+		Rows: [][]sqltypes.Value{{
+			sqltypes.MakeNumeric([]byte(strconv.FormatInt(ts.UnixNano(), 10))),
+		}},
+	}
+}
+
+func TestHeartbeatWriteQuery(t *testing.T) {
+	got := heartbeatWriteQuery(62344, time.Unix(0, 1441234567123456789))
+	want := "INSERT INTO _vt.heartbeat (id, tablet_uid, ts) VALUES (1, 62344, 1441234567123456789) ON DUPLICATE KEY UPDATE tablet_uid = VALUES(tablet_uid), ts = VALUES(ts)"
+	if got != want {
+		t.Errorf("heartbeatWriteQuery: got %v, want %v", got, want)
+	}
+}
+
+func TestHeartbeatWriter(t *testing.T) {
+	defer func(d time.Duration) {
+		*heartbeatInterval = d
+	}(*heartbeatInterval)
+	*heartbeatInterval = time.Hour
+
+	fmd := NewFakeMysqlDaemon(nil)
+	fmd.ExpectedExecuteSuperQueryList = append(heartbeatCreateQueries, "SUBINSERT INTO _vt.heartbeat (id, tablet_uid, ts) VALUES (1, 62344, ")
+	hw := NewHeartbeatWriter(fmd, 62344)
+
+	// Open creates the table and writes the first heartbeat,
+	// opening it again does nothing.
+	writes := heartbeatWrites.Get()
+	if err := hw.Open(); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := hw.Open(); err != nil {
+		t.Fatalf("second Open failed: %v", err)
+	}
+	if err := fmd.CheckSuperQueryList(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+	if got := heartbeatWrites.Get() - writes; got != 1 {
+		t.Errorf("HeartbeatWrites increased by %v, want 1", got)
+	}
+	hw.Close()
+	hw.Close()
+}
+
+func TestHeartbeatWriterRetry(t *testing.T) {
+	defer func(d time.Duration) {
+		*heartbeatInterval = d
+	}(*heartbeatInterval)
+	*heartbeatInterval = time.Hour
+
+	// mysqld is not up yet: Open fails, but the writer is open.
+	fmd := NewFakeMysqlDaemon(nil)
+	hw := NewHeartbeatWriter(fmd, 62344)
+	writes := heartbeatWrites.Get()
+	errors := heartbeatWriteErrors.Get()
+	if err := hw.Open(); err == nil {
+		t.Fatalf("Open without mysqld: got no error")
+	}
+	defer hw.Close()
+	hw.tick()
+	if got := heartbeatWriteErrors.Get() - errors; got != 1 {
+		t.Errorf("HeartbeatWriteErrors increased by %v, want 1", got)
+	}
+
+	// the next heartbeat creates the table, and the one after
+	// only writes.
+	writeQuery := "SUBINSERT INTO _vt.heartbeat (id, tablet_uid, ts) VALUES (1, 62344, "
+	fmd.ExpectedExecuteSuperQueryList = append(append([]string{}, heartbeatCreateQueries...), writeQuery, writeQuery)
+	fmd.ExpectedExecuteSuperQueryCurrent = 0
+	hw.tick()
+	hw.tick()
+	if err := fmd.CheckSuperQueryList(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+	if got := heartbeatWrites.Get() - writes; got != 2 {
+		t.Errorf("HeartbeatWrites increased by %v, want 2", got)
+	}
+}
+
+func TestHeartbeatReplicationLag(t *testing.T) {
+	now := time.Unix(1441234567, 0)
+	fmd := NewFakeMysqlDaemon(nil)
+	hrl := &heartbeatReplicationLag{
+		mysqld: fmd,
+		now:    func() time.Time { return now },
+	}
+
+	// masters are not lagging, and don't read the table
+	if lag, err := hrl.Report(false, true); err != nil || lag != 0 {
+		t.Errorf("Report on a master: got %v %v", lag, err)
+	}
+
+	// no table, or no heartbeat yet
+	if _, err := hrl.Report(true, true); err == nil {
+		t.Errorf("Report without a heartbeat table: got no error")
+	}
+	fmd.FetchSuperQueryMap = map[string]*mproto.QueryResult{heartbeatReadQuery: &mproto.QueryResult{}}
+	if _, err := hrl.Report(true, true); err == nil {
+		t.Errorf("Report without a heartbeat: got no error")
+	}
+
+	fmd.FetchSuperQueryMap[heartbeatReadQuery] = heartbeatResult(now.Add(-1500 * time.Millisecond))
+	if lag, err := hrl.Report(true, true); err != nil || lag != 1500*time.Millisecond {
+		t.Errorf("Report: got %v %v, want 1.5s", lag, err)
+	}
+
+	// clock skew
+	fmd.FetchSuperQueryMap[heartbeatReadQuery] = heartbeatResult(now.Add(time.Second))
+	if lag, err := hrl.Report(true, true); err != nil || lag != 0 {
+		t.Errorf("Report with a heartbeat in the future: got %v %v, want 0", lag, err)
+	}
+}
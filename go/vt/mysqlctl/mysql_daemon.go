@@ -6,6 +6,7 @@ package mysqlctl
 
 import (
 	"fmt"
+	"path"
 	"reflect"
 	"strings"
 	"time"
@@ -52,10 +53,18 @@ type MysqlDaemon interface {
 
 	WaitMasterPos(proto.ReplicationPosition, time.Duration) error
 
+	// StartSlaveUntilAfter starts replication on a stopped slave,
+	// until it has applied all the transactions of the position.
+	StartSlaveUntilAfter(proto.ReplicationPosition) error
+
 	// PromoteSlave makes the slave the new master. It will not change
 	// the read_only state of the server.
 	PromoteSlave(map[string]string) (proto.ReplicationPosition, error)
 
+	// semi-sync related methods
+	SetSemiSyncEnabled(master, slave bool) error
+	SemiSyncStatus() (proto.SemiSyncStatus, error)
+
 	// Schema related methods
 	GetSchema(dbName string, tables, excludeTables []string, includeViews bool) (*proto.SchemaDefinition, error)
 	PreflightSchemaChange(dbName string, change string) (*proto.SchemaChangeResult, error)
@@ -81,6 +90,14 @@ type MysqlDaemon interface {
 	// DisableBinlogPlayback disable playback of binlog events
 	DisableBinlogPlayback() error
 
+	// binlog files related methods, used by incremental backups
+	ReadBinlogFile(name string) (*BinlogFileInfo, error)
+	ApplyBinlogFile(name string, start, stop int64) error
+
+	// NewScratchMysqld returns a new, stopped mysqld with all its
+	// files under root, used to verify backups.
+	NewScratchMysqld(root string) (MysqlDaemon, error)
+
 	// Close will close this instance of Mysqld. It will wait for all dba
 	// queries to be finished.
 	Close()
@@ -150,6 +167,11 @@ type FakeMysqlDaemon struct {
 	// same it returns nil, if different it returns an error
 	WaitMasterPosition proto.ReplicationPosition
 
+	// StartSlaveUntilAfterPosition is checked by StartSlaveUntilAfter,
+	// if the same it becomes the CurrentMasterPosition, if different
+	// it returns an error
+	StartSlaveUntilAfterPosition proto.ReplicationPosition
+
 	// PromoteSlaveResult is returned by PromoteSlave
 	PromoteSlaveResult proto.ReplicationPosition
 
@@ -185,6 +207,23 @@ type FakeMysqlDaemon struct {
 
 	// BinlogPlayerEnabled is used by {Enable,Disable}BinlogPlayer
 	BinlogPlayerEnabled bool
+
+	// SemiSyncMasterEnabled and SemiSyncSlaveEnabled are set by
+	// SetSemiSyncEnabled, and returned by SemiSyncStatus
+	SemiSyncMasterEnabled bool
+	SemiSyncSlaveEnabled  bool
+
+	// BinlogFiles is used by ReadBinlogFile, the key is the base
+	// name of the file.
+	BinlogFiles map[string]*BinlogFileInfo
+
+	// AppliedBinlogFiles is appended to by ApplyBinlogFile, as
+	// "<base name>:<start>:<stop>".
+	AppliedBinlogFiles []string
+
+	// ScratchMysqlDaemon is returned by NewScratchMysqld. If nil
+	// we'll return an error.
+	ScratchMysqlDaemon *FakeMysqlDaemon
 }
 
 // NewFakeMysqlDaemon returns a FakeMysqlDaemon where mysqld appears
@@ -304,11 +343,37 @@ func (fmd *FakeMysqlDaemon) WaitMasterPos(pos proto.ReplicationPosition, waitTim
 	return fmt.Errorf("wrong input for WaitMasterPos: expected %v got %v", fmd.WaitMasterPosition, pos)
 }
 
+// StartSlaveUntilAfter is part of the MysqlDaemon interface
+func (fmd *FakeMysqlDaemon) StartSlaveUntilAfter(pos proto.ReplicationPosition) error {
+	if !reflect.DeepEqual(fmd.StartSlaveUntilAfterPosition, pos) {
+		return fmt.Errorf("wrong input for StartSlaveUntilAfter: expected %v got %v", fmd.StartSlaveUntilAfterPosition, pos)
+	}
+	fmd.CurrentMasterPosition = pos
+	return nil
+}
+
 // PromoteSlave is part of the MysqlDaemon interface
 func (fmd *FakeMysqlDaemon) PromoteSlave(hookExtraEnv map[string]string) (proto.ReplicationPosition, error) {
 	return fmd.PromoteSlaveResult, nil
 }
 
+// SetSemiSyncEnabled is part of the MysqlDaemon interface
+func (fmd *FakeMysqlDaemon) SetSemiSyncEnabled(master, slave bool) error {
+	fmd.SemiSyncMasterEnabled = master
+	fmd.SemiSyncSlaveEnabled = slave
+	return nil
+}
+
+// SemiSyncStatus is part of the MysqlDaemon interface
+func (fmd *FakeMysqlDaemon) SemiSyncStatus() (proto.SemiSyncStatus, error) {
+	return proto.SemiSyncStatus{
+		MasterEnabled: fmd.SemiSyncMasterEnabled,
+		MasterActive:  fmd.SemiSyncMasterEnabled,
+		SlaveEnabled:  fmd.SemiSyncSlaveEnabled,
+		SlaveActive:   fmd.SemiSyncSlaveEnabled && fmd.Replicating,
+	}, nil
+}
+
 // ExecuteSuperQueryList is part of the MysqlDaemon interface
 func (fmd *FakeMysqlDaemon) ExecuteSuperQueryList(queryList []string) error {
 	for _, query := range queryList {
@@ -379,6 +444,29 @@ func (fmd *FakeMysqlDaemon) DisableBinlogPlayback() error {
 	return nil
 }
 
+// ReadBinlogFile is part of the MysqlDaemon interface
+func (fmd *FakeMysqlDaemon) ReadBinlogFile(name string) (*BinlogFileInfo, error) {
+	bfi, ok := fmd.BinlogFiles[path.Base(name)]
+	if !ok {
+		return nil, fmt.Errorf("unexpected binlog file: %v", name)
+	}
+	return bfi, nil
+}
+
+// ApplyBinlogFile is part of the MysqlDaemon interface
+func (fmd *FakeMysqlDaemon) ApplyBinlogFile(name string, start, stop int64) error {
+	fmd.AppliedBinlogFiles = append(fmd.AppliedBinlogFiles, fmt.Sprintf("%v:%v:%v", path.Base(name), start, stop))
+	return nil
+}
+
+// NewScratchMysqld is part of the MysqlDaemon interface
+func (fmd *FakeMysqlDaemon) NewScratchMysqld(root string) (MysqlDaemon, error) {
+	if fmd.ScratchMysqlDaemon == nil {
+		return nil, fmt.Errorf("no ScratchMysqlDaemon in FakeMysqlDaemon")
+	}
+	return fmd.ScratchMysqlDaemon, nil
+}
+
 // Close is part of the MysqlDaemon interface
 func (fmd *FakeMysqlDaemon) Close() {
 }
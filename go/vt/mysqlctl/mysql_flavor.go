@@ -74,6 +74,11 @@ type MysqlFlavor interface {
 	// least targetPos.
 	WaitMasterPos(mysqld *Mysqld, targetPos proto.ReplicationPosition, waitTimeout time.Duration) error
 
+	// StartSlaveUntilAfterCommands returns the commands to start
+	// replication on a stopped slave, until it has applied all
+	// the transactions of targetPos, and no other.
+	StartSlaveUntilAfterCommands(targetPos proto.ReplicationPosition) []string
+
 	// EnableBinlogPlayback prepares the server to play back
 	// events from a binlog stream.  Whatever it does for a given
 	// flavor, it must be idempotent.
@@ -83,6 +88,15 @@ type MysqlFlavor interface {
 	// state after playback is done.  Whatever it does for a given
 	// flavor, it must be idempotent.
 	DisableBinlogPlayback(mysqld *Mysqld) error
+
+	// SemiSyncEnableCommands returns the commands to enable or
+	// disable the master and slave sides of semi-sync
+	// replication. The semi-sync plugins must be loaded.
+	SemiSyncEnableCommands(master, slave bool) []string
+
+	// SemiSyncStatus returns the semi-sync replication status of
+	// the server.
+	SemiSyncStatus(mysqld *Mysqld) (proto.SemiSyncStatus, error)
 }
 
 var mysqlFlavors = make(map[string]MysqlFlavor)
@@ -107,6 +107,13 @@ func (*mariaDB10) SetSlavePositionCommands(pos proto.ReplicationPosition) ([]str
 	}, nil
 }
 
+// StartSlaveUntilAfterCommands implements MysqlFlavor.
+func (*mariaDB10) StartSlaveUntilAfterCommands(targetPos proto.ReplicationPosition) []string {
+	return []string{
+		fmt.Sprintf("START SLAVE UNTIL master_gtid_pos = '%s'", targetPos),
+	}
+}
+
 // SetMasterCommands implements MysqlFlavor.SetMasterCommands().
 func (*mariaDB10) SetMasterCommands(params *sqldb.ConnParams, masterHost string, masterPort int, masterConnectRetry int) ([]string, error) {
 	// Make CHANGE MASTER TO command.
@@ -177,6 +184,16 @@ func (*mariaDB10) DisableBinlogPlayback(mysqld *Mysqld) error {
 	return nil
 }
 
+// SemiSyncEnableCommands implements MysqlFlavor.SemiSyncEnableCommands().
+func (*mariaDB10) SemiSyncEnableCommands(master, slave bool) []string {
+	return semiSyncEnableCommands(master, slave)
+}
+
+// SemiSyncStatus implements MysqlFlavor.SemiSyncStatus().
+func (*mariaDB10) SemiSyncStatus(mysqld *Mysqld) (proto.SemiSyncStatus, error) {
+	return semiSyncStatus(mysqld)
+}
+
 // mariadbBinlogEvent wraps a raw packet buffer and provides methods to examine
 // it by implementing blproto.BinlogEvent. Some methods are pulled in from
 // binlogEvent.
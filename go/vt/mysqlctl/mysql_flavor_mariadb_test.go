@@ -195,6 +195,16 @@ func TestMariadbSetSlavePositionCommands(t *testing.T) {
 	}
 }
 
+func TestMariadbStartSlaveUntilAfterCommands(t *testing.T) {
+	pos := proto.ReplicationPosition{GTIDSet: proto.MariadbGTID{Domain: 1, Server: 41983, Sequence: 12345}}
+	want := []string{
+		"START SLAVE UNTIL master_gtid_pos = '1-41983-12345'",
+	}
+	if got := (&mariaDB10{}).StartSlaveUntilAfterCommands(pos); !reflect.DeepEqual(got, want) {
+		t.Errorf("(&mariaDB10{}).StartSlaveUntilAfterCommands(%#v) = %#v, want %#v", pos, got, want)
+	}
+}
+
 func TestMariadbSetMasterCommands(t *testing.T) {
 	params := &sqldb.ConnParams{
 		Uname: "username",
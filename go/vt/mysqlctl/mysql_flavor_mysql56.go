@@ -103,6 +103,13 @@ func (*mysql56) SetSlavePositionCommands(pos proto.ReplicationPosition) ([]strin
 	}, nil
 }
 
+// StartSlaveUntilAfterCommands implements MysqlFlavor.
+func (*mysql56) StartSlaveUntilAfterCommands(targetPos proto.ReplicationPosition) []string {
+	return []string{
+		fmt.Sprintf("START SLAVE UNTIL SQL_AFTER_GTIDS = '%s'", targetPos),
+	}
+}
+
 // SetMasterCommands implements MysqlFlavor.SetMasterCommands().
 func (*mysql56) SetMasterCommands(params *sqldb.ConnParams, masterHost string, masterPort int, masterConnectRetry int) ([]string, error) {
 	// Make CHANGE MASTER TO command.
@@ -158,6 +165,16 @@ func (*mysql56) DisableBinlogPlayback(mysqld *Mysqld) error {
 	return nil
 }
 
+// SemiSyncEnableCommands implements MysqlFlavor.SemiSyncEnableCommands().
+func (*mysql56) SemiSyncEnableCommands(master, slave bool) []string {
+	return semiSyncEnableCommands(master, slave)
+}
+
+// SemiSyncStatus implements MysqlFlavor.SemiSyncStatus().
+func (*mysql56) SemiSyncStatus(mysqld *Mysqld) (proto.SemiSyncStatus, error) {
+	return semiSyncStatus(mysqld)
+}
+
 // mysql56BinlogEvent wraps a raw packet buffer and provides methods to examine
 // it by implementing blproto.BinlogEvent. Some methods are pulled in from
 // binlogEvent.
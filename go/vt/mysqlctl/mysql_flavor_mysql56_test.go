@@ -179,6 +179,16 @@ func TestMysql56SetSlavePositionCommands(t *testing.T) {
 	}
 }
 
+func TestMysql56StartSlaveUntilAfterCommands(t *testing.T) {
+	pos, _ := (&mysql56{}).ParseReplicationPosition("00010203-0405-0607-0809-0a0b0c0d0e0f:1-2")
+	want := []string{
+		"START SLAVE UNTIL SQL_AFTER_GTIDS = '00010203-0405-0607-0809-0a0b0c0d0e0f:1-2'",
+	}
+	if got := (&mysql56{}).StartSlaveUntilAfterCommands(pos); !reflect.DeepEqual(got, want) {
+		t.Errorf("(&mysql56{}).StartSlaveUntilAfterCommands(%#v) = %#v, want %#v", pos, got, want)
+	}
+}
+
 func TestMysql56SetMasterCommands(t *testing.T) {
 	params := &sqldb.ConnParams{
 		Uname: "username",
@@ -0,0 +1,142 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/youtube/vitess/go/sqldb"
+	blproto "github.com/youtube/vitess/go/vt/binlog/proto"
+	"github.com/youtube/vitess/go/vt/mysqlctl/proto"
+)
+
+// mysql57 is the implementation of MysqlFlavor for MySQL 5.7.
+//
+// MySQL 5.7 has the same GTIDs as MySQL 5.6, so it reuses the MySQL 5.6
+// GTID parsing, and replication positions keep the MySQL56 flavor.
+// That way, the positions saved by 5.6 servers (in backups, or in the
+// blp_checkpoint table) are still valid after an upgrade to 5.7.
+type mysql57 struct {
+	mysql56
+}
+
+const mysql57FlavorID = "MySQL57"
+
+// VersionMatch implements MysqlFlavor.VersionMatch().
+func (*mysql57) VersionMatch(version string) bool {
+	return strings.HasPrefix(version, "5.7")
+}
+
+// SlaveStatus implements MysqlFlavor.SlaveStatus().
+func (flavor *mysql57) SlaveStatus(mysqld *Mysqld) (proto.ReplicationStatus, error) {
+	// With multi-source replication, SHOW SLAVE STATUS returns one row
+	// per channel. We only use the default channel.
+	fields, err := mysqld.fetchSuperQueryMap("SHOW SLAVE STATUS FOR CHANNEL ''")
+	if err != nil {
+		return proto.ReplicationStatus{}, ErrNotSlave
+	}
+	status := parseSlaveStatus(fields)
+
+	status.Position, err = flavor.ParseReplicationPosition(fields["Executed_Gtid_Set"])
+	if err != nil {
+		return proto.ReplicationStatus{}, fmt.Errorf("SlaveStatus can't parse MySQL 5.7 GTID (Executed_Gtid_Set: %#v): %v", fields["Executed_Gtid_Set"], err)
+	}
+	return status, nil
+}
+
+// WaitMasterPos implements MysqlFlavor.WaitMasterPos().
+func (*mysql57) WaitMasterPos(mysqld *Mysqld, targetPos proto.ReplicationPosition, waitTimeout time.Duration) error {
+	// WAIT_UNTIL_SQL_THREAD_AFTER_GTIDS is deprecated in MySQL 5.7.
+	// WAIT_FOR_EXECUTED_GTID_SET doesn't depend on the slave threads,
+	// and waits indefinitely without a timeout.
+	var query string
+	if waitTimeout == 0 {
+		query = fmt.Sprintf("SELECT WAIT_FOR_EXECUTED_GTID_SET('%s')", targetPos)
+	} else {
+		query = fmt.Sprintf("SELECT WAIT_FOR_EXECUTED_GTID_SET('%s', %v)", targetPos, int(waitTimeout.Seconds()))
+	}
+
+	log.Infof("Waiting for minimum replication position with query: %v", query)
+	qr, err := mysqld.FetchSuperQuery(query)
+	if err != nil {
+		return fmt.Errorf("WAIT_FOR_EXECUTED_GTID_SET() failed: %v", err)
+	}
+	if len(qr.Rows) != 1 || len(qr.Rows[0]) != 1 {
+		return fmt.Errorf("unexpected result format from WAIT_FOR_EXECUTED_GTID_SET(): %#v", qr)
+	}
+	if qr.Rows[0][0].String() == "1" {
+		return fmt.Errorf("timed out waiting for position %v", targetPos)
+	}
+	return nil
+}
+
+// SetMasterCommands implements MysqlFlavor.SetMasterCommands().
+func (*mysql57) SetMasterCommands(params *sqldb.ConnParams, masterHost string, masterPort int, masterConnectRetry int) ([]string, error) {
+	// Make CHANGE MASTER TO command, for the default channel.
+	args := changeMasterArgs(params, masterHost, masterPort, masterConnectRetry)
+	args = append(args, "MASTER_AUTO_POSITION = 1")
+	changeMasterTo := "CHANGE MASTER TO\n  " + strings.Join(args, ",\n  ") + "\n  FOR CHANNEL ''"
+
+	return []string{changeMasterTo}, nil
+}
+
+// MakeBinlogEvent implements MysqlFlavor.MakeBinlogEvent().
+func (*mysql57) MakeBinlogEvent(buf []byte) blproto.BinlogEvent {
+	return NewMysql57BinlogEvent(buf)
+}
+
+// mysql57BinlogEvent wraps a raw packet buffer and provides methods to examine
+// it by implementing blproto.BinlogEvent. The MySQL 5.7 events are a superset
+// of the MySQL 5.6 ones, so most methods are pulled in from
+// mysql56BinlogEvent. The GTID_LOG_EVENT has the logical clock of the
+// transaction after the GNO, which we don't use. The new
+// TRANSACTION_CONTEXT_EVENT, VIEW_CHANGE_EVENT and XA_PREPARE_LOG_EVENT
+// types are skipped like other unknown events. And when gtid_mode is not ON,
+// an ANONYMOUS_GTID_LOG_EVENT starts each transaction: these transactions
+// have no GTID, so we can't stream them.
+type mysql57BinlogEvent struct {
+	mysql56BinlogEvent
+}
+
+// NewMysql57BinlogEvent creates a BinlogEvent from given byte array
+func NewMysql57BinlogEvent(buf []byte) blproto.BinlogEvent {
+	return mysql57BinlogEvent{mysql56BinlogEvent: mysql56BinlogEvent{binlogEvent: binlogEvent(buf)}}
+}
+
+// isAnonymousGTID returns true if this is an ANONYMOUS_GTID_LOG_EVENT.
+func (ev mysql57BinlogEvent) isAnonymousGTID() bool {
+	return ev.Type() == 34 // ANONYMOUS_GTID_LOG_EVENT
+}
+
+// HasGTID implements BinlogEvent.HasGTID().
+func (ev mysql57BinlogEvent) HasGTID(f blproto.BinlogFormat) bool {
+	// We report anonymous transactions too, so GTID() can fail on them
+	// instead of silently attaching them to the previous GTID.
+	return ev.IsGTID() || ev.isAnonymousGTID()
+}
+
+// GTID implements BinlogEvent.GTID().
+func (ev mysql57BinlogEvent) GTID(f blproto.BinlogFormat) (proto.GTID, error) {
+	if ev.isAnonymousGTID() {
+		return nil, fmt.Errorf("got an anonymous transaction, gtid_mode must be ON")
+	}
+	return ev.mysql56BinlogEvent.GTID(f)
+}
+
+// StripChecksum implements BinlogEvent.StripChecksum().
+func (ev mysql57BinlogEvent) StripChecksum(f blproto.BinlogFormat) (blproto.BinlogEvent, []byte, error) {
+	stripped, checksum, err := ev.mysql56BinlogEvent.StripChecksum(f)
+	if err != nil {
+		return ev, nil, err
+	}
+	return mysql57BinlogEvent{mysql56BinlogEvent: stripped.(mysql56BinlogEvent)}, checksum, nil
+}
+
+func init() {
+	registerFlavorBuiltin(mysql57FlavorID, &mysql57{})
+}
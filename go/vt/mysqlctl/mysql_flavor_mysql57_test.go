@@ -0,0 +1,167 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/youtube/vitess/go/sqldb"
+	blproto "github.com/youtube/vitess/go/vt/binlog/proto"
+)
+
+// Sample event data for MySQL 5.7.
+var (
+	mysql57Format    = blproto.BinlogFormat{FormatVersion: 4, ServerVersion: "5.7.9-log", HeaderLength: 19, ChecksumAlgorithm: BinlogChecksumAlgCRC32}
+	mysql57GTIDEvent = NewMysql57BinlogEvent([]byte{0xff, 0x4e, 0x49, 0x55, 0x21, 0x64, 0x0, 0x0, 0x0, 0x41, 0x0, 0x0, 0x0, 0x36, 0x3, 0x0, 0x0, 0x0, 0x0, 0x1, 0x43, 0x91, 0x92, 0xbd, 0xf3, 0x7c, 0x11, 0xe4, 0xbb, 0xeb, 0x2, 0x42, 0xac, 0x11, 0x3, 0x5a, 0x4, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x2, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x4, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x12, 0x34, 0x56, 0x78})
+	// ANONYMOUS_GTID_LOG_EVENT, written when gtid_mode is not ON.
+	mysql57AnonymousGTIDEvent = NewMysql57BinlogEvent([]byte{0xff, 0x4e, 0x49, 0x55, 0x22, 0x64, 0x0, 0x0, 0x0, 0x41, 0x0, 0x0, 0x0, 0x36, 0x3, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x2, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x4, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x12, 0x34, 0x56, 0x78})
+	// The QUERY_EVENT didn't change.
+	mysql57QueryEvent = NewMysql57BinlogEvent(mysql56QueryEvent.(mysql56BinlogEvent).Bytes())
+)
+
+func TestMysql57IsGTID(t *testing.T) {
+	if got, want := mysql57QueryEvent.IsGTID(), false; got != want {
+		t.Errorf("%#v.IsGTID() = %#v, want %#v", mysql57QueryEvent, got, want)
+	}
+	if got, want := mysql57AnonymousGTIDEvent.IsGTID(), false; got != want {
+		t.Errorf("%#v.IsGTID() = %#v, want %#v", mysql57AnonymousGTIDEvent, got, want)
+	}
+	if got, want := mysql57GTIDEvent.IsGTID(), true; got != want {
+		t.Errorf("%#v.IsGTID() = %#v, want %#v", mysql57GTIDEvent, got, want)
+	}
+}
+
+func TestMysql57HasGTID(t *testing.T) {
+	format := blproto.BinlogFormat{}
+	if got, want := mysql57QueryEvent.HasGTID(format), false; got != want {
+		t.Errorf("%#v.HasGTID() = %#v, want %#v", mysql57QueryEvent, got, want)
+	}
+	if got, want := mysql57AnonymousGTIDEvent.HasGTID(format), true; got != want {
+		t.Errorf("%#v.HasGTID() = %#v, want %#v", mysql57AnonymousGTIDEvent, got, want)
+	}
+	if got, want := mysql57GTIDEvent.HasGTID(format), true; got != want {
+		t.Errorf("%#v.HasGTID() = %#v, want %#v", mysql57GTIDEvent, got, want)
+	}
+}
+
+func TestMysql57StripChecksum(t *testing.T) {
+	stripped, gotChecksum, err := mysql57QueryEvent.StripChecksum(mysql57Format)
+	if err != nil {
+		t.Fatalf("StripChecksum() error: %v", err)
+	}
+
+	// The stripped event must still be a MySQL 5.7 event.
+	if _, ok := stripped.(mysql57BinlogEvent); !ok {
+		t.Errorf("StripChecksum() = %#v, want a mysql57BinlogEvent", stripped)
+	}
+	if want := []byte{0x92, 0x12, 0x79, 0xc3}; !reflect.DeepEqual(gotChecksum, want) {
+		t.Errorf("checksum = %#v, want %#v", gotChecksum, want)
+	}
+	gotQuery, err := stripped.Query(mysql57Format)
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	if want := "insert into test_table (msg) values ('hello')"; string(gotQuery.Sql) != want {
+		t.Errorf("query = %#v, want %#v", string(gotQuery.Sql), want)
+	}
+}
+
+func TestMysql57GTID(t *testing.T) {
+	input, _, err := mysql57GTIDEvent.StripChecksum(mysql57Format)
+	if err != nil {
+		t.Fatalf("StripChecksum() error: %v", err)
+	}
+	if !input.IsGTID() {
+		t.Fatalf("IsGTID() = false, want true")
+	}
+
+	want, _ := (&mysql57{}).ParseGTID("439192bd-f37c-11e4-bbeb-0242ac11035a:4")
+	got, err := input.GTID(mysql57Format)
+	if err != nil {
+		t.Fatalf("GTID() error: %v", err)
+	}
+	if got != want {
+		t.Errorf("GTID() = %#v, want %#v", got, want)
+	}
+}
+
+func TestMysql57AnonymousGTID(t *testing.T) {
+	input, _, err := mysql57AnonymousGTIDEvent.StripChecksum(mysql57Format)
+	if err != nil {
+		t.Fatalf("StripChecksum() error: %v", err)
+	}
+	if got, err := input.GTID(mysql57Format); err == nil {
+		t.Errorf("GTID() = %#v, want error", got)
+	}
+}
+
+func TestMysql57ParseReplicationPosition(t *testing.T) {
+	// MySQL 5.7 positions are the same as MySQL 5.6 positions.
+	input := "00010203-0405-0607-0809-0a0b0c0d0e0f:1-2"
+	want, err := (&mysql56{}).ParseReplicationPosition(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := (&mysql57{}).ParseReplicationPosition(input)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("(&mysql57{}).ParseReplicationPosition(%#v) = %#v, want %#v", input, got, want)
+	}
+}
+
+func TestMysql57VersionMatch(t *testing.T) {
+	table := map[string]bool{
+		"10.0.13-MariaDB-1~precise-log": false,
+		"5.1.63-google-log":             false,
+		"5.6.24-log":                    false,
+		"5.7.9-log":                     true,
+	}
+	for input, want := range table {
+		if got := (&mysql57{}).VersionMatch(input); got != want {
+			t.Errorf("(&mysql57{}).VersionMatch(%#v) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestMysql57SetMasterCommands(t *testing.T) {
+	params := &sqldb.ConnParams{
+		Uname: "username",
+		Pass:  "password",
+	}
+	masterHost := "localhost"
+	masterPort := 123
+	masterConnectRetry := 1234
+	want := []string{
+		`CHANGE MASTER TO
+  MASTER_HOST = 'localhost',
+  MASTER_PORT = 123,
+  MASTER_USER = 'username',
+  MASTER_PASSWORD = 'password',
+  MASTER_CONNECT_RETRY = 1234,
+  MASTER_AUTO_POSITION = 1
+  FOR CHANNEL ''`,
+	}
+
+	got, err := (&mysql57{}).SetMasterCommands(params, masterHost, masterPort, masterConnectRetry)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("(&mysql57{}).SetMasterCommands(%#v, %#v, %#v, %#v) = %#v, want %#v", params, masterHost, masterPort, masterConnectRetry, got, want)
+	}
+}
+
+func TestMysql57MakeBinlogEvent(t *testing.T) {
+	input := []byte{1, 2, 3}
+	want := mysql57BinlogEvent{mysql56BinlogEvent: mysql56BinlogEvent{binlogEvent: binlogEvent([]byte{1, 2, 3})}}
+	if got := (&mysql57{}).MakeBinlogEvent(input); !reflect.DeepEqual(got, want) {
+		t.Errorf("(&mysql57{}).MakeBinlogEvent(%#v) = %#v, want %#v", input, got, want)
+	}
+}
@@ -30,6 +30,9 @@ func (fakeMysqlFlavor) SendBinlogDumpCommand(conn *SlaveConnection, startPos pro
 func (fakeMysqlFlavor) WaitMasterPos(mysqld *Mysqld, targetPos proto.ReplicationPosition, waitTimeout time.Duration) error {
 	return nil
 }
+func (fakeMysqlFlavor) StartSlaveUntilAfterCommands(targetPos proto.ReplicationPosition) []string {
+	return nil
+}
 func (fakeMysqlFlavor) MasterPosition(mysqld *Mysqld) (proto.ReplicationPosition, error) {
 	return proto.ReplicationPosition{}, nil
 }
@@ -44,6 +47,12 @@ func (fakeMysqlFlavor) SetMasterCommands(params *sqldb.ConnParams, masterHost st
 }
 func (fakeMysqlFlavor) EnableBinlogPlayback(mysqld *Mysqld) error  { return nil }
 func (fakeMysqlFlavor) DisableBinlogPlayback(mysqld *Mysqld) error { return nil }
+func (fakeMysqlFlavor) SemiSyncEnableCommands(master, slave bool) []string {
+	return nil
+}
+func (fakeMysqlFlavor) SemiSyncStatus(mysqld *Mysqld) (proto.SemiSyncStatus, error) {
+	return proto.SemiSyncStatus{}, nil
+}
 
 func TestMysqlFlavorEnvironmentVariable(t *testing.T) {
 	os.Setenv("MYSQL_FLAVOR", "fake flavor")
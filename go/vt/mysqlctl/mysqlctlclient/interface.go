@@ -12,12 +12,17 @@ import (
 	"time"
 
 	log "github.com/golang/glog"
+	mproto "github.com/youtube/vitess/go/mysql/proto"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 	"golang.org/x/net/context"
 )
 
 var protocol = flag.String("mysqlctl_client_protocol", "grpc", "the protocol to use to talk to the mysqlctl server")
 var connectionTimeout = flag.Duration("mysqlctl_client_connection_timeout", 30*time.Second, "the connection timeout to use to talk to the mysqlctl server")
 
+// ErrFunc is used by streaming RPCs that don't return a specific result
+type ErrFunc func() error
+
 // MysqlctlClient defines the interface used to send remote mysqlctl commands
 type MysqlctlClient interface {
 	// Start calls Mysqld.Start remotely.
@@ -29,6 +34,31 @@ type MysqlctlClient interface {
 	// RunMysqlUpgrade calls Mysqld.RunMysqlUpgrade remotely.
 	RunMysqlUpgrade(ctx context.Context) error
 
+	// ReinitConfig calls Mysqld.ReinitConfig remotely.
+	ReinitConfig(ctx context.Context) error
+
+	// IsReadOnly calls Mysqld.IsReadOnly remotely.
+	IsReadOnly(ctx context.Context) (bool, error)
+
+	// SetReadOnly calls Mysqld.SetReadOnly remotely.
+	SetReadOnly(ctx context.Context, on bool) error
+
+	// SlaveStatus calls Mysqld.SlaveStatus remotely.
+	SlaveStatus(ctx context.Context) (myproto.ReplicationStatus, error)
+
+	// MasterPosition calls Mysqld.MasterPosition remotely.
+	MasterPosition(ctx context.Context) (myproto.ReplicationPosition, error)
+
+	// ExecuteSuperQueryList calls Mysqld.ExecuteSuperQueryList remotely.
+	ExecuteSuperQueryList(ctx context.Context, queries []string) error
+
+	// FetchSuperQuery calls Mysqld.FetchSuperQuery remotely.
+	FetchSuperQuery(ctx context.Context, query string) (*mproto.QueryResult, error)
+
+	// TailErrorLog streams the MySQL error log, starting with its
+	// last lastBytes bytes, until ctx is canceled.
+	TailErrorLog(ctx context.Context, lastBytes int64) (<-chan []byte, ErrFunc, error)
+
 	// Close will terminate the connection. This object won't be used anymore.
 	Close()
 }
@@ -65,6 +65,10 @@ type Mysqld struct {
 	mysqlFlavor   MysqlFlavor
 	onTermFuncs   []func()
 	cancelWaitCmd chan struct{}
+
+	// remoteClient is the connection to mysqlctld used by the
+	// frequent remote actions. It is created on first use.
+	remoteClient mysqlctlclient.MysqlctlClient
 }
 
 // NewMysqld creates a Mysqld object based on the provided configuration
@@ -452,6 +456,31 @@ func (mysqld *Mysqld) initConfig(root string) error {
 	return ioutil.WriteFile(mysqld.config.path, []byte(configData), 0664)
 }
 
+// ReinitConfig regenerates the my.cnf file from the Mycnf values, the
+// same way Init does. mysqld has to be restarted to use it.
+// If a mysqlctld address is provided in a flag, ReinitConfig will run remotely.
+func (mysqld *Mysqld) ReinitConfig(ctx context.Context) error {
+	// Execute as remote action on mysqlctld if requested.
+	if *socketFile != "" {
+		log.Infof("executing Mysqld.ReinitConfig() remotely via mysqlctld server: %v", *socketFile)
+		client, err := mysqlctlclient.New("unix", *socketFile)
+		if err != nil {
+			return fmt.Errorf("can't dial mysqlctld: %v", err)
+		}
+		defer client.Close()
+		return client.ReinitConfig(ctx)
+	}
+
+	root, err := vtenv.VtRoot()
+	if err != nil {
+		return err
+	}
+	if err := mysqld.initConfig(root); err != nil {
+		return fmt.Errorf("failed creating %v: %v", mysqld.config.path, err)
+	}
+	return nil
+}
+
 func (mysqld *Mysqld) createDirs() error {
 	log.Infof("creating directory %s", mysqld.TabletDir)
 	if err := os.MkdirAll(mysqld.TabletDir, os.ModePerm); err != nil {
@@ -582,6 +611,29 @@ func (mysqld *Mysqld) GetDbaConnection() (*dbconnpool.DBConnection, error) {
 func (mysqld *Mysqld) Close() {
 	mysqld.dbaPool.Close()
 	mysqld.appPool.Close()
+
+	mysqld.mutex.Lock()
+	defer mysqld.mutex.Unlock()
+	if mysqld.remoteClient != nil {
+		mysqld.remoteClient.Close()
+		mysqld.remoteClient = nil
+	}
+}
+
+// mysqlctldClient returns the connection to the mysqlctld server of
+// -mysqlctl_socket. Unlike the one-off actions like Start, the queries
+// and replication actions are frequent, so the connection is kept.
+func (mysqld *Mysqld) mysqlctldClient() (mysqlctlclient.MysqlctlClient, error) {
+	mysqld.mutex.Lock()
+	defer mysqld.mutex.Unlock()
+	if mysqld.remoteClient == nil {
+		client, err := mysqlctlclient.New("unix", *socketFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't dial mysqlctld: %v", err)
+		}
+		mysqld.remoteClient = client
+	}
+	return mysqld.remoteClient, nil
 }
 
 // OnTerm registers a function to be called if mysqld terminates for any
@@ -32,6 +32,18 @@ type GTIDSet interface {
 
 	// AddGTID returns a new GTIDSet that is expanded to contain the given GTID.
 	AddGTID(GTID) GTIDSet
+
+	// Union returns a new GTIDSet that contains the transactions of both sets.
+	// A set of another flavor is ignored.
+	Union(GTIDSet) GTIDSet
+
+	// Intersect returns a new GTIDSet that contains the transactions that are
+	// in both sets. It returns nil if there are none.
+	Intersect(GTIDSet) GTIDSet
+
+	// Subtract returns a new GTIDSet that contains the transactions of this
+	// set that are not in the other set. It returns nil if there are none.
+	Subtract(GTIDSet) GTIDSet
 }
 
 // gtidSetParsers maps flavor names to parser functions. It is used by
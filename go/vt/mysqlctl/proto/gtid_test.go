@@ -437,4 +437,7 @@ func (f fakeGTID) Equal(other GTIDSet) bool {
 	}
 	return f == otherFake
 }
-func (fakeGTID) AddGTID(GTID) GTIDSet { return nil }
+func (fakeGTID) AddGTID(GTID) GTIDSet      { return nil }
+func (fakeGTID) Union(GTIDSet) GTIDSet     { return nil }
+func (fakeGTID) Intersect(GTIDSet) GTIDSet { return nil }
+func (fakeGTID) Subtract(GTIDSet) GTIDSet  { return nil }
@@ -111,11 +111,7 @@ func (gtid MariadbGTID) Contains(other GTIDSet) bool {
 	if other == nil {
 		return true
 	}
-	mdbOther, ok := other.(MariadbGTID)
-	if !ok || gtid.Domain != mdbOther.Domain {
-		return false
-	}
-	return gtid.Sequence >= mdbOther.Sequence
+	return gtid.sequenceRange().Contains(other)
 }
 
 // Equal implements GTIDSet.Equal().
@@ -136,6 +132,175 @@ func (gtid MariadbGTID) AddGTID(other GTID) GTIDSet {
 	return mdbOther
 }
 
+// Union implements GTIDSet.Union(). A MariaDB position only has one
+// domain, so a set from another domain is ignored.
+func (gtid MariadbGTID) Union(other GTIDSet) GTIDSet {
+	return gtid.sequenceRange().Union(other)
+}
+
+// Intersect implements GTIDSet.Intersect().
+func (gtid MariadbGTID) Intersect(other GTIDSet) GTIDSet {
+	return gtid.sequenceRange().Intersect(other)
+}
+
+// Subtract implements GTIDSet.Subtract(). A MariaDB position stands
+// for all the transactions of its domain up to its sequence number, so
+// the difference is a range of sequence numbers.
+func (gtid MariadbGTID) Subtract(other GTIDSet) GTIDSet {
+	return gtid.sequenceRange().Subtract(other)
+}
+
+// sequenceRange returns the transactions of the position as a range.
+func (gtid MariadbGTID) sequenceRange() mariadbSequenceRange {
+	return mariadbSequenceRange{
+		Domain: gtid.Domain,
+		Server: gtid.Server,
+		First:  1,
+		Last:   gtid.Sequence,
+	}
+}
+
+// mariadbSequenceRange is a GTIDSet of the transactions of a domain
+// with a sequence number between First and Last. It is what remains of
+// a MariaDB position after a Subtract. It is not a position MariaDB
+// understands: a range that starts at 1 is always returned as the
+// equivalent MariadbGTID, and the other ones are only meant to be
+// displayed.
+type mariadbSequenceRange struct {
+	Domain uint32
+	// Server is the ID of the server that generated the last
+	// transaction, as far as we know.
+	Server uint32
+	First  uint64
+	Last   uint64
+}
+
+// asMariadbSequenceRange returns set as a range, if it is a MariaDB set.
+func asMariadbSequenceRange(set GTIDSet) (mariadbSequenceRange, bool) {
+	switch set := set.(type) {
+	case MariadbGTID:
+		return set.sequenceRange(), true
+	case mariadbSequenceRange:
+		return set, true
+	}
+	return mariadbSequenceRange{}, false
+}
+
+// normalize returns the range as a MariadbGTID if it starts at 1, and
+// nil if it is empty.
+func (r mariadbSequenceRange) normalize() GTIDSet {
+	if r.First > r.Last {
+		return nil
+	}
+	if r.First <= 1 {
+		return MariadbGTID{Domain: r.Domain, Server: r.Server, Sequence: r.Last}
+	}
+	return r
+}
+
+// String implements GTIDSet.String().
+func (r mariadbSequenceRange) String() string {
+	return fmt.Sprintf("%d-%d-%d..%d", r.Domain, r.Server, r.First, r.Last)
+}
+
+// Flavor implements GTIDSet.Flavor().
+func (r mariadbSequenceRange) Flavor() string {
+	return mariadbFlavorID
+}
+
+// ContainsGTID implements GTIDSet.ContainsGTID().
+func (r mariadbSequenceRange) ContainsGTID(other GTID) bool {
+	if other == nil {
+		return true
+	}
+	mdbOther, ok := other.(MariadbGTID)
+	if !ok || r.Domain != mdbOther.Domain {
+		return false
+	}
+	return r.First <= mdbOther.Sequence && mdbOther.Sequence <= r.Last
+}
+
+// Contains implements GTIDSet.Contains().
+func (r mariadbSequenceRange) Contains(other GTIDSet) bool {
+	if other == nil {
+		return true
+	}
+	o, ok := asMariadbSequenceRange(other)
+	if !ok || r.Domain != o.Domain {
+		return false
+	}
+	return r.First <= o.First && o.Last <= r.Last
+}
+
+// Equal implements GTIDSet.Equal().
+func (r mariadbSequenceRange) Equal(other GTIDSet) bool {
+	o, ok := other.(mariadbSequenceRange)
+	return ok && r == o
+}
+
+// AddGTID implements GTIDSet.AddGTID(). Like Union, it can only
+// extend the range with the next transaction.
+func (r mariadbSequenceRange) AddGTID(other GTID) GTIDSet {
+	mdbOther, ok := other.(MariadbGTID)
+	if !ok || r.Domain != mdbOther.Domain || mdbOther.Sequence != r.Last+1 {
+		return r
+	}
+	r.Server = mdbOther.Server
+	r.Last = mdbOther.Sequence
+	return r
+}
+
+// Union implements GTIDSet.Union(). A range cannot hold a hole, so a
+// set that doesn't overlap or touch the range is ignored, like a set
+// from another domain.
+func (r mariadbSequenceRange) Union(other GTIDSet) GTIDSet {
+	o, ok := asMariadbSequenceRange(other)
+	if !ok || r.Domain != o.Domain || o.First > r.Last+1 || r.First > o.Last+1 {
+		return r.normalize()
+	}
+	if o.First < r.First {
+		r.First = o.First
+	}
+	if o.Last > r.Last {
+		r.Server = o.Server
+		r.Last = o.Last
+	}
+	return r.normalize()
+}
+
+// Intersect implements GTIDSet.Intersect().
+func (r mariadbSequenceRange) Intersect(other GTIDSet) GTIDSet {
+	o, ok := asMariadbSequenceRange(other)
+	if !ok || r.Domain != o.Domain {
+		return nil
+	}
+	if o.First > r.First {
+		r.First = o.First
+	}
+	if o.Last < r.Last {
+		r.Server = o.Server
+		r.Last = o.Last
+	}
+	return r.normalize()
+}
+
+// Subtract implements GTIDSet.Subtract(). If the other set is in the
+// middle of the range, the result would have a hole, so only the
+// transactions after it are returned. The result is still empty only
+// if the other set contains the whole range.
+func (r mariadbSequenceRange) Subtract(other GTIDSet) GTIDSet {
+	o, ok := asMariadbSequenceRange(other)
+	if !ok || r.Domain != o.Domain || o.First > r.Last || o.Last < r.First {
+		return r.normalize()
+	}
+	if o.Last < r.Last {
+		r.First = o.Last + 1
+		return r.normalize()
+	}
+	r.Last = o.First - 1
+	return r.normalize()
+}
+
 func init() {
 	gtidParsers[mariadbFlavorID] = parseMariadbGTID
 	gtidSetParsers[mariadbFlavorID] = parseMariadbGTIDSet
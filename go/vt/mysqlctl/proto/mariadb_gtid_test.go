@@ -410,3 +410,101 @@ func TestMariaGTIDAddGTIDDifferentDomain(t *testing.T) {
 		t.Errorf("%#v.AddGTID(%#v) = %v, want %v", input1, input2, got, want)
 	}
 }
+
+func TestMariaGTIDUnion(t *testing.T) {
+	input1 := MariadbGTID{Domain: 3, Server: 5555, Sequence: 1234}
+	table := []struct {
+		input2 GTIDSet
+		want   GTIDSet
+	}{
+		{MariadbGTID{Domain: 3, Server: 4444, Sequence: 5234}, MariadbGTID{Domain: 3, Server: 4444, Sequence: 5234}},
+		{MariadbGTID{Domain: 3, Server: 5555, Sequence: 1000}, input1},
+		{MariadbGTID{Domain: 5, Server: 5555, Sequence: 5234}, input1},
+		{fakeGTID{}, input1},
+		{nil, input1},
+	}
+	for _, tc := range table {
+		if got := input1.Union(tc.input2); got != tc.want {
+			t.Errorf("%#v.Union(%#v) = %v, want %v", input1, tc.input2, got, tc.want)
+		}
+	}
+}
+
+func TestMariaGTIDIntersect(t *testing.T) {
+	input1 := MariadbGTID{Domain: 3, Server: 5555, Sequence: 1234}
+	table := []struct {
+		input2 GTIDSet
+		want   GTIDSet
+	}{
+		{MariadbGTID{Domain: 3, Server: 4444, Sequence: 5234}, input1},
+		{MariadbGTID{Domain: 3, Server: 5555, Sequence: 1000}, MariadbGTID{Domain: 3, Server: 5555, Sequence: 1000}},
+		{MariadbGTID{Domain: 5, Server: 5555, Sequence: 5234}, nil},
+		{fakeGTID{}, nil},
+		{nil, nil},
+	}
+	for _, tc := range table {
+		if got := input1.Intersect(tc.input2); got != tc.want {
+			t.Errorf("%#v.Intersect(%#v) = %v, want %v", input1, tc.input2, got, tc.want)
+		}
+	}
+}
+
+func TestMariaGTIDSubtract(t *testing.T) {
+	input1 := MariadbGTID{Domain: 3, Server: 5555, Sequence: 1234}
+	table := []struct {
+		input2 GTIDSet
+		want   GTIDSet
+	}{
+		{MariadbGTID{Domain: 3, Server: 4444, Sequence: 5234}, nil},
+		{MariadbGTID{Domain: 3, Server: 5555, Sequence: 1234}, nil},
+		{MariadbGTID{Domain: 3, Server: 5555, Sequence: 1000}, mariadbSequenceRange{Domain: 3, Server: 5555, First: 1001, Last: 1234}},
+		{MariadbGTID{Domain: 5, Server: 5555, Sequence: 5234}, input1},
+		{mariadbSequenceRange{Domain: 3, Server: 5555, First: 1001, Last: 1234}, MariadbGTID{Domain: 3, Server: 5555, Sequence: 1000}},
+		{mariadbSequenceRange{Domain: 3, Server: 5555, First: 1001, Last: 1100}, mariadbSequenceRange{Domain: 3, Server: 5555, First: 1101, Last: 1234}},
+		{fakeGTID{}, input1},
+		{nil, input1},
+	}
+	for _, tc := range table {
+		if got := input1.Subtract(tc.input2); got != tc.want {
+			t.Errorf("%#v.Subtract(%#v) = %v, want %v", input1, tc.input2, got, tc.want)
+		}
+	}
+}
+
+func TestMariaSequenceRange(t *testing.T) {
+	r := mariadbSequenceRange{Domain: 3, Server: 5555, First: 1001, Last: 1234}
+	if got, want := r.String(), "3-5555-1001..1234"; got != want {
+		t.Errorf("%#v.String() = %v, want %v", r, got, want)
+	}
+	if !r.ContainsGTID(MariadbGTID{Domain: 3, Server: 4444, Sequence: 1001}) || r.ContainsGTID(MariadbGTID{Domain: 3, Server: 4444, Sequence: 1000}) {
+		t.Errorf("%#v.ContainsGTID() is wrong", r)
+	}
+	if r.Contains(MariadbGTID{Domain: 3, Server: 5555, Sequence: 1234}) || !(MariadbGTID{Domain: 3, Server: 5555, Sequence: 1234}).Contains(r) {
+		t.Errorf("a range and a position from the beginning of the domain are not compared right")
+	}
+
+	table := []struct {
+		input2                   GTIDSet
+		union, intersect, remain GTIDSet
+	}{
+		// the beginning of the domain
+		{MariadbGTID{Domain: 3, Server: 4444, Sequence: 1000}, MariadbGTID{Domain: 3, Server: 5555, Sequence: 1234}, nil, r},
+		// the end of the range
+		{mariadbSequenceRange{Domain: 3, Server: 6666, First: 1200, Last: 1300}, mariadbSequenceRange{Domain: 3, Server: 6666, First: 1001, Last: 1300}, mariadbSequenceRange{Domain: 3, Server: 5555, First: 1200, Last: 1234}, mariadbSequenceRange{Domain: 3, Server: 5555, First: 1001, Last: 1199}},
+		// after a hole
+		{mariadbSequenceRange{Domain: 3, Server: 6666, First: 1300, Last: 1400}, r, nil, r},
+		// another domain
+		{MariadbGTID{Domain: 5, Server: 5555, Sequence: 5234}, r, nil, r},
+	}
+	for _, tc := range table {
+		if got := r.Union(tc.input2); got != tc.union {
+			t.Errorf("%#v.Union(%#v) = %v, want %v", r, tc.input2, got, tc.union)
+		}
+		if got := r.Intersect(tc.input2); got != tc.intersect {
+			t.Errorf("%#v.Intersect(%#v) = %v, want %v", r, tc.input2, got, tc.intersect)
+		}
+		if got := r.Subtract(tc.input2); got != tc.remain {
+			t.Errorf("%#v.Subtract(%#v) = %v, want %v", r, tc.input2, got, tc.remain)
+		}
+	}
+}
@@ -56,6 +56,86 @@ func parseInterval(s string) (interval, error) {
 	}
 }
 
+// unionIntervals returns the union of two lists of intervals, sorted
+// and with the overlapping or adjacent intervals merged. The input
+// lists don't need to be sorted.
+func unionIntervals(a, b []interval) []interval {
+	all := make([]interval, 0, len(a)+len(b))
+	all = append(all, a...)
+	all = append(all, b...)
+	sort.Sort(intervalList(all))
+
+	var result []interval
+	for _, iv := range all {
+		if count := len(result); count != 0 && iv.start <= result[count-1].end+1 {
+			// Merge with the previous interval.
+			if iv.end > result[count-1].end {
+				result[count-1].end = iv.end
+			}
+			continue
+		}
+		result = append(result, iv)
+	}
+	return result
+}
+
+// intersectIntervals returns the intersection of two lists of
+// intervals. The input lists must be sorted and merged, as returned
+// by unionIntervals.
+func intersectIntervals(a, b []interval) []interval {
+	var result []interval
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		iv := interval{start: a[i].start, end: a[i].end}
+		if b[j].start > iv.start {
+			iv.start = b[j].start
+		}
+		if b[j].end < iv.end {
+			iv.end = b[j].end
+		}
+		if iv.start <= iv.end {
+			result = append(result, iv)
+		}
+		// Move on from the interval that ends first.
+		if a[i].end < b[j].end {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// subtractIntervals returns the parts of the intervals of a that are
+// not in b. The input lists must be sorted and merged, as returned by
+// unionIntervals.
+func subtractIntervals(a, b []interval) []interval {
+	var result []interval
+	j := 0
+	for _, iv := range a {
+		// Skip the intervals of b that end before this one.
+		for j < len(b) && b[j].end < iv.start {
+			j++
+		}
+		// Cut out the intervals of b that overlap this one. The
+		// last one may also overlap the next interval of a, so we
+		// don't move j past it.
+		start := iv.start
+		for k := j; k < len(b) && b[k].start <= iv.end; k++ {
+			if b[k].start > start {
+				result = append(result, interval{start: start, end: b[k].start - 1})
+			}
+			if b[k].end >= start {
+				start = b[k].end + 1
+			}
+		}
+		if start <= iv.end {
+			result = append(result, interval{start: start, end: iv.end})
+		}
+	}
+	return result
+}
+
 // parseMysql56GTIDSet is registered as a GTIDSet parser.
 //
 // https://dev.mysql.com/doc/refman/5.6/en/replication-gtids-concepts.html
@@ -320,6 +400,65 @@ func (set Mysql56GTIDSet) AddGTID(gtid GTID) GTIDSet {
 	return newSet
 }
 
+// Union implements GTIDSet.
+func (set Mysql56GTIDSet) Union(other GTIDSet) GTIDSet {
+	other56, ok := other.(Mysql56GTIDSet)
+	if !ok {
+		return set
+	}
+
+	newSet := make(Mysql56GTIDSet)
+	for sid, intervals := range set {
+		newSet[sid] = unionIntervals(intervals, other56[sid])
+	}
+	for sid, intervals := range other56 {
+		if _, ok := set[sid]; !ok {
+			newSet[sid] = unionIntervals(intervals, nil)
+		}
+	}
+	return newSet
+}
+
+// Intersect implements GTIDSet.
+func (set Mysql56GTIDSet) Intersect(other GTIDSet) GTIDSet {
+	other56, ok := other.(Mysql56GTIDSet)
+	if !ok {
+		return nil
+	}
+
+	newSet := make(Mysql56GTIDSet)
+	for sid, intervals := range set {
+		otherIntervals, ok := other56[sid]
+		if !ok {
+			continue
+		}
+		if newIntervals := intersectIntervals(unionIntervals(intervals, nil), unionIntervals(otherIntervals, nil)); len(newIntervals) != 0 {
+			newSet[sid] = newIntervals
+		}
+	}
+	if len(newSet) == 0 {
+		return nil
+	}
+	return newSet
+}
+
+// Subtract implements GTIDSet.
+func (set Mysql56GTIDSet) Subtract(other GTIDSet) GTIDSet {
+	// A set of another flavor has nothing in common with this one.
+	other56, _ := other.(Mysql56GTIDSet)
+
+	newSet := make(Mysql56GTIDSet)
+	for sid, intervals := range set {
+		if newIntervals := subtractIntervals(unionIntervals(intervals, nil), unionIntervals(other56[sid], nil)); len(newIntervals) != 0 {
+			newSet[sid] = newIntervals
+		}
+	}
+	if len(newSet) == 0 {
+		return nil
+	}
+	return newSet
+}
+
 // SIDBlock returns the binary encoding of a MySQL 5.6 GTID set as expected
 // by internal commands that refer to an "SID block".
 //
@@ -348,6 +487,40 @@ func (set Mysql56GTIDSet) SIDBlock() []byte {
 	return buf.Bytes()
 }
 
+// NewMysql56GTIDSetFromSIDBlock builds a MySQL 5.6 GTID set by parsing
+// its binary encoding, the reverse of SIDBlock. It is used in the
+// PREVIOUS_GTIDS_LOG_EVENT of the binlog files.
+func NewMysql56GTIDSetFromSIDBlock(data []byte) (Mysql56GTIDSet, error) {
+	buf := bytes.NewReader(data)
+	var nSIDs uint64
+	if err := binary.Read(buf, binary.LittleEndian, &nSIDs); err != nil {
+		return nil, fmt.Errorf("cannot read nSIDs: %v", err)
+	}
+	set := make(Mysql56GTIDSet, nSIDs)
+	for i := uint64(0); i < nSIDs; i++ {
+		var sid SID
+		if c, err := buf.Read(sid[:]); err != nil || c != 16 {
+			return nil, fmt.Errorf("cannot read SID %v: %v", i, err)
+		}
+		var nIntervals uint64
+		if err := binary.Read(buf, binary.LittleEndian, &nIntervals); err != nil {
+			return nil, fmt.Errorf("cannot read nIntervals %v: %v", i, err)
+		}
+		for j := uint64(0); j < nIntervals; j++ {
+			var start, end int64
+			if err := binary.Read(buf, binary.LittleEndian, &start); err != nil {
+				return nil, fmt.Errorf("cannot read start %v/%v: %v", i, j, err)
+			}
+			if err := binary.Read(buf, binary.LittleEndian, &end); err != nil {
+				return nil, fmt.Errorf("cannot read end %v/%v: %v", i, j, err)
+			}
+			// MySQL's internal form for intervals adds 1 to the end value.
+			set[sid] = append(set[sid], interval{start, end - 1})
+		}
+	}
+	return set, nil
+}
+
 func init() {
 	gtidSetParsers[mysql56FlavorID] = parseMysql56GTIDSet
 }
@@ -405,6 +405,113 @@ func TestMysql56GTIDSetAddGTID(t *testing.T) {
 	}
 }
 
+func TestMysql56GTIDSetUnion(t *testing.T) {
+	sid1 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	sid2 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 16}
+	sid3 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 17}
+
+	set := Mysql56GTIDSet{
+		sid1: []interval{{20, 30}, {35, 40}},
+		sid2: []interval{{1, 5}, {50, 50}},
+	}
+
+	table := []struct {
+		other GTIDSet
+		want  GTIDSet
+	}{
+		// Wrong flavor and empty sets don't change anything.
+		{fakeGTID{}, set},
+		{Mysql56GTIDSet{}, set},
+		// Overlapping and adjacent intervals are merged.
+		{
+			Mysql56GTIDSet{sid1: []interval{{25, 36}}, sid2: []interval{{6, 10}, {51, 52}}},
+			Mysql56GTIDSet{sid1: []interval{{20, 40}}, sid2: []interval{{1, 10}, {50, 52}}},
+		},
+		// New intervals and SIDs.
+		{
+			Mysql56GTIDSet{sid1: []interval{{1, 2}, {45, 46}}, sid3: []interval{{8, 10}, {1, 3}}},
+			Mysql56GTIDSet{
+				sid1: []interval{{1, 2}, {20, 30}, {35, 40}, {45, 46}},
+				sid2: []interval{{1, 5}, {50, 50}},
+				sid3: []interval{{1, 3}, {8, 10}},
+			},
+		},
+	}
+	for _, tc := range table {
+		if got := set.Union(tc.other); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%#v.Union(%#v) = %#v, want %#v", set, tc.other, got, tc.want)
+		}
+	}
+}
+
+func TestMysql56GTIDSetIntersect(t *testing.T) {
+	sid1 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	sid2 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 16}
+	sid3 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 17}
+
+	set := Mysql56GTIDSet{
+		sid1: []interval{{20, 30}, {35, 40}},
+		sid2: []interval{{1, 5}, {50, 50}},
+	}
+
+	table := []struct {
+		other GTIDSet
+		want  GTIDSet
+	}{
+		// Nothing in common.
+		{fakeGTID{}, nil},
+		{Mysql56GTIDSet{}, nil},
+		{Mysql56GTIDSet{sid1: []interval{{31, 34}}, sid3: []interval{{1, 100}}}, nil},
+		// The set intersected with itself.
+		{set, set},
+		// Partial overlaps.
+		{
+			Mysql56GTIDSet{sid1: []interval{{25, 37}, {40, 50}}, sid2: []interval{{50, 60}}, sid3: []interval{{1, 5}}},
+			Mysql56GTIDSet{sid1: []interval{{25, 30}, {35, 37}, {40, 40}}, sid2: []interval{{50, 50}}},
+		},
+	}
+	for _, tc := range table {
+		if got := set.Intersect(tc.other); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%#v.Intersect(%#v) = %#v, want %#v", set, tc.other, got, tc.want)
+		}
+	}
+}
+
+func TestMysql56GTIDSetSubtract(t *testing.T) {
+	sid1 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	sid2 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 16}
+	sid3 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 17}
+
+	set := Mysql56GTIDSet{
+		sid1: []interval{{20, 30}, {35, 40}},
+		sid2: []interval{{1, 5}, {50, 50}},
+	}
+
+	table := []struct {
+		other GTIDSet
+		want  GTIDSet
+	}{
+		// Nothing to subtract.
+		{nil, set},
+		{fakeGTID{}, set},
+		{Mysql56GTIDSet{sid3: []interval{{1, 100}}}, set},
+		// Everything is subtracted.
+		{set, nil},
+		{Mysql56GTIDSet{sid1: []interval{{1, 100}}, sid2: []interval{{1, 60}}}, nil},
+		// Holes and overlaps, with one interval of the other
+		// set overlapping two intervals of this set.
+		{
+			Mysql56GTIDSet{sid1: []interval{{22, 23}, {26, 36}}, sid2: []interval{{1, 2}}},
+			Mysql56GTIDSet{sid1: []interval{{20, 21}, {24, 25}, {37, 40}}, sid2: []interval{{3, 5}, {50, 50}}},
+		},
+	}
+	for _, tc := range table {
+		if got := set.Subtract(tc.other); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%#v.Subtract(%#v) = %#v, want %#v", set, tc.other, got, tc.want)
+		}
+	}
+}
+
 func TestMysql56GTIDSetSIDBlock(t *testing.T) {
 	sid1 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
 	sid2 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 16}
@@ -441,3 +548,25 @@ func TestMysql56GTIDSetSIDBlock(t *testing.T) {
 		t.Errorf("%#v.SIDBlock() = %#v, want %#v", input, got, want)
 	}
 }
+
+func TestNewMysql56GTIDSetFromSIDBlock(t *testing.T) {
+	sid1 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	sid2 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 16}
+
+	want := Mysql56GTIDSet{
+		sid1: []interval{{20, 30}, {35, 40}},
+		sid2: []interval{{1, 5}},
+	}
+	got, err := NewMysql56GTIDSetFromSIDBlock(want.SIDBlock())
+	if err != nil {
+		t.Fatalf("NewMysql56GTIDSetFromSIDBlock failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewMysql56GTIDSetFromSIDBlock() = %#v, want %#v", got, want)
+	}
+
+	// truncated data
+	if _, err := NewMysql56GTIDSetFromSIDBlock(want.SIDBlock()[:30]); err == nil {
+		t.Errorf("NewMysql56GTIDSetFromSIDBlock(truncated) should have failed")
+	}
+}
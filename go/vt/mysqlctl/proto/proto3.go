@@ -22,6 +22,7 @@ func ReplicationStatusToProto(r ReplicationStatus) *pb.Status {
 		MasterHost:          r.MasterHost,
 		MasterPort:          int32(r.MasterPort),
 		MasterConnectRetry:  int32(r.MasterConnectRetry),
+		SemiSyncSlaveActive: r.SemiSyncSlaveActive,
 	}
 }
 
@@ -39,6 +40,7 @@ func ProtoToReplicationStatus(r *pb.Status) ReplicationStatus {
 		MasterHost:          r.MasterHost,
 		MasterPort:          int(r.MasterPort),
 		MasterConnectRetry:  int(r.MasterConnectRetry),
+		SemiSyncSlaveActive: r.SemiSyncSlaveActive,
 	}
 }
 
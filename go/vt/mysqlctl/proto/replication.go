@@ -50,6 +50,35 @@ func (rp ReplicationPosition) AtLeast(other ReplicationPosition) bool {
 	return rp.GTIDSet.Contains(other.GTIDSet)
 }
 
+// Union returns a position that contains the transactions of both
+// positions.
+func (rp ReplicationPosition) Union(other ReplicationPosition) ReplicationPosition {
+	if rp.GTIDSet == nil {
+		return other
+	}
+	return ReplicationPosition{GTIDSet: rp.GTIDSet.Union(other.GTIDSet)}
+}
+
+// Intersect returns a position that contains the transactions that
+// are in both positions. It is the zero value if there are none.
+func (rp ReplicationPosition) Intersect(other ReplicationPosition) ReplicationPosition {
+	if rp.GTIDSet == nil || other.GTIDSet == nil {
+		return ReplicationPosition{}
+	}
+	return ReplicationPosition{GTIDSet: rp.GTIDSet.Intersect(other.GTIDSet)}
+}
+
+// Subtract returns a position that contains the transactions of this
+// position that are not in the other. It is the zero value if there
+// are none. For instance, a slave position minus its master position
+// gives the errant transactions of the slave.
+func (rp ReplicationPosition) Subtract(other ReplicationPosition) ReplicationPosition {
+	if rp.GTIDSet == nil {
+		return ReplicationPosition{}
+	}
+	return ReplicationPosition{GTIDSet: rp.GTIDSet.Subtract(other.GTIDSet)}
+}
+
 // String returns a string representation of the underlying GTIDSet.
 // If the set is nil, it returns "<nil>" in the style of Sprintf("%v", nil).
 func (rp ReplicationPosition) String() string {
@@ -205,6 +234,12 @@ type ReplicationStatus struct {
 	MasterHost          string
 	MasterPort          int
 	MasterConnectRetry  int
+
+	// SemiSyncSlaveActive is not part of SHOW SLAVE STATUS, it
+	// is filled in by the tablet when semi-sync is in use. It is
+	// true if the slave is acknowledging the transactions it
+	// receives, see SemiSyncStatus.SlaveActive.
+	SemiSyncSlaveActive bool
 }
 
 // SlaveRunning returns true iff both the Slave IO and Slave SQL threads are
@@ -227,3 +262,24 @@ func NewReplicationStatus(masterAddr string) (*ReplicationStatus, error) {
 	return &ReplicationStatus{MasterConnectRetry: 10,
 		MasterHost: host, MasterPort: port}, nil
 }
+
+// SemiSyncStatus holds the semi-sync replication variables and status
+// of a server.
+type SemiSyncStatus struct {
+	// MasterEnabled and SlaveEnabled are the values of the
+	// rpl_semi_sync_{master,slave}_enabled variables.
+	MasterEnabled bool
+	SlaveEnabled  bool
+
+	// MasterActive is true if commits on the master wait for a
+	// slave acknowledgement, i.e. semi-sync is enabled and did not
+	// fall back to asynchronous replication.
+	MasterActive bool
+
+	// MasterClients is the number of connected semi-sync slaves.
+	MasterClients int
+
+	// SlaveActive is true if the slave is currently acknowledging
+	// the events it receives.
+	SlaveActive bool
+}
@@ -198,6 +198,53 @@ func TestReplicationPositionAppendToZero(t *testing.T) {
 	}
 }
 
+func TestReplicationPositionUnion(t *testing.T) {
+	input1 := MustParseReplicationPosition(mysql56FlavorID, "00010203-0405-0607-0809-0a0b0c0d0e0f:1-5")
+	input2 := MustParseReplicationPosition(mysql56FlavorID, "00010203-0405-0607-0809-0a0b0c0d0e0f:6-8,00010203-0405-0607-0809-0a0b0c0d0eff:1")
+	want := MustParseReplicationPosition(mysql56FlavorID, "00010203-0405-0607-0809-0a0b0c0d0e0f:1-8,00010203-0405-0607-0809-0a0b0c0d0eff:1")
+
+	if got := input1.Union(input2); !got.Equal(want) {
+		t.Errorf("%#v.Union(%#v) = %#v, want %#v", input1, input2, got, want)
+	}
+	if got := (ReplicationPosition{}).Union(input1); !got.Equal(input1) {
+		t.Errorf("zero.Union(%#v) = %#v, want %#v", input1, got, input1)
+	}
+}
+
+func TestReplicationPositionIntersect(t *testing.T) {
+	input1 := MustParseReplicationPosition(mysql56FlavorID, "00010203-0405-0607-0809-0a0b0c0d0e0f:1-5")
+	input2 := MustParseReplicationPosition(mysql56FlavorID, "00010203-0405-0607-0809-0a0b0c0d0e0f:4-8")
+	want := MustParseReplicationPosition(mysql56FlavorID, "00010203-0405-0607-0809-0a0b0c0d0e0f:4-5")
+
+	if got := input1.Intersect(input2); !got.Equal(want) {
+		t.Errorf("%#v.Intersect(%#v) = %#v, want %#v", input1, input2, got, want)
+	}
+	if got := input1.Intersect(ReplicationPosition{}); !got.IsZero() {
+		t.Errorf("%#v.Intersect(zero) = %#v, want zero", input1, got)
+	}
+}
+
+func TestReplicationPositionSubtract(t *testing.T) {
+	master := MustParseReplicationPosition(mysql56FlavorID, "00010203-0405-0607-0809-0a0b0c0d0e0f:1-100")
+	slave := MustParseReplicationPosition(mysql56FlavorID, "00010203-0405-0607-0809-0a0b0c0d0e0f:1-90,00010203-0405-0607-0809-0a0b0c0d0eff:1-3")
+	want := MustParseReplicationPosition(mysql56FlavorID, "00010203-0405-0607-0809-0a0b0c0d0eff:1-3")
+
+	if got := slave.Subtract(master); !got.Equal(want) {
+		t.Errorf("%#v.Subtract(%#v) = %#v, want %#v", slave, master, got, want)
+	}
+	// what the slave is missing
+	want = MustParseReplicationPosition(mysql56FlavorID, "00010203-0405-0607-0809-0a0b0c0d0e0f:91-100")
+	if got := master.Subtract(slave); !got.Equal(want) {
+		t.Errorf("%#v.Subtract(%#v) = %#v, want %#v", master, slave, got, want)
+	}
+	if got := slave.Subtract(slave); !got.IsZero() {
+		t.Errorf("%#v.Subtract(itself) = %#v, want zero", slave, got)
+	}
+	if got := (ReplicationPosition{}).Subtract(master); !got.IsZero() {
+		t.Errorf("zero.Subtract(%#v) = %#v, want zero", master, got)
+	}
+}
+
 func TestMustParseReplicationPosition(t *testing.T) {
 	flavor := "fake flavor"
 	gtidSetParsers[flavor] = func(s string) (GTIDSet, error) {
@@ -0,0 +1,233 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaFixes returns the DDL statements that turn the actual schema
+// into the desired one. Both schemas are expected to come from
+// GetSchema, so the table schemas are SHOW CREATE TABLE outputs.
+// Missing tables are created, and tables that differ are altered
+// column by column and index by index. Extra tables, columns and
+// indexes are only dropped if allowDrops is set.
+//
+// The differences that cannot be fixed that way (views, table options,
+// foreign keys, ...) are returned in unfixable, to be fixed manually.
+func SchemaFixes(desired, actual *SchemaDefinition, allowDrops bool) (sqls []string, unfixable []string) {
+	desiredTables := make(map[string]*TableDefinition, len(desired.TableDefinitions))
+	for _, td := range desired.TableDefinitions {
+		desiredTables[td.Name] = td
+	}
+	actualTables := make(map[string]*TableDefinition, len(actual.TableDefinitions))
+	for _, td := range actual.TableDefinitions {
+		actualTables[td.Name] = td
+	}
+
+	for _, td := range desired.TableDefinitions {
+		atd, ok := actualTables[td.Name]
+		switch {
+		case !ok && td.Type == TableView:
+			unfixable = append(unfixable, fmt.Sprintf("view %v is missing", td.Name))
+		case !ok:
+			sqls = append(sqls, td.Schema)
+		case td.Type != atd.Type:
+			unfixable = append(unfixable, fmt.Sprintf("%v has type %v instead of %v", td.Name, atd.Type, td.Type))
+		case td.Schema == atd.Schema:
+			// nothing to do
+		case td.Type == TableView:
+			unfixable = append(unfixable, fmt.Sprintf("view %v has a different definition", td.Name))
+		default:
+			alter, problems := alterTableFix(td.Name, td.Schema, atd.Schema, allowDrops)
+			if alter != "" {
+				sqls = append(sqls, alter)
+			}
+			unfixable = append(unfixable, problems...)
+		}
+	}
+
+	for _, td := range actual.TableDefinitions {
+		if _, ok := desiredTables[td.Name]; ok {
+			continue
+		}
+		kind := "TABLE"
+		if td.Type == TableView {
+			kind = "VIEW"
+		}
+		if !allowDrops {
+			unfixable = append(unfixable, fmt.Sprintf("extra %v %v is not dropped", strings.ToLower(kind), td.Name))
+			continue
+		}
+		sqls = append(sqls, fmt.Sprintf("DROP %v `%v`", kind, td.Name))
+	}
+	return sqls, unfixable
+}
+
+// tableLayout is a parsed SHOW CREATE TABLE output.
+type tableLayout struct {
+	columns    []string
+	columnDefs map[string]string
+	indexes    []string
+	indexDefs  map[string]string
+
+	// options is everything after the closing parenthesis:
+	// engine, charset, partitions, ...
+	options string
+}
+
+func parseTableLayout(schema string) (*tableLayout, error) {
+	lines := strings.Split(schema, "\n")
+	end := len(lines) - 1
+	for end > 0 && !strings.HasPrefix(lines[end], ")") {
+		end--
+	}
+	if end < 1 || !strings.HasPrefix(lines[0], "CREATE TABLE ") {
+		return nil, fmt.Errorf("unexpected table schema: %v", schema)
+	}
+
+	tl := &tableLayout{
+		columnDefs: make(map[string]string),
+		indexDefs:  make(map[string]string),
+		options:    strings.Join(lines[end:], "\n"),
+	}
+	for _, line := range lines[1:end] {
+		def := strings.TrimSuffix(strings.TrimSpace(line), ",")
+		if strings.HasPrefix(def, "`") {
+			i := strings.Index(def[1:], "`")
+			if i < 0 {
+				return nil, fmt.Errorf("cannot parse column definition: %v", def)
+			}
+			name := def[1 : i+1]
+			tl.columns = append(tl.columns, name)
+			tl.columnDefs[name] = def
+			continue
+		}
+		name, err := indexName(def)
+		if err != nil {
+			return nil, err
+		}
+		tl.indexes = append(tl.indexes, name)
+		tl.indexDefs[name] = def
+	}
+	return tl, nil
+}
+
+// indexName returns the name of the index defined by def, PRIMARY
+// for the primary key.
+func indexName(def string) (string, error) {
+	if strings.HasPrefix(def, "PRIMARY KEY ") {
+		return "PRIMARY", nil
+	}
+	for _, prefix := range []string{"KEY `", "UNIQUE KEY `", "FULLTEXT KEY `", "SPATIAL KEY `"} {
+		if !strings.HasPrefix(def, prefix) {
+			continue
+		}
+		rest := def[len(prefix):]
+		if i := strings.Index(rest, "`"); i >= 0 {
+			return rest[:i], nil
+		}
+	}
+	return "", fmt.Errorf("unsupported definition: %v", def)
+}
+
+// alterTableFix returns the ALTER TABLE statement that turns the
+// actual table into the desired one, or "" if there is nothing it
+// can fix.
+func alterTableFix(table, desiredSchema, actualSchema string, allowDrops bool) (string, []string) {
+	desired, err := parseTableLayout(desiredSchema)
+	if err != nil {
+		return "", []string{fmt.Sprintf("table %v cannot be altered automatically: %v", table, err)}
+	}
+	actual, err := parseTableLayout(actualSchema)
+	if err != nil {
+		return "", []string{fmt.Sprintf("table %v cannot be altered automatically: %v", table, err)}
+	}
+
+	var clauses, unfixable []string
+
+	// Drop the indexes that are extra or different, the different
+	// ones are added back below.
+	for _, name := range actual.indexes {
+		def, ok := desired.indexDefs[name]
+		if ok && def == actual.indexDefs[name] {
+			continue
+		}
+		if !ok && !allowDrops {
+			unfixable = append(unfixable, fmt.Sprintf("extra index %v on table %v is not dropped", name, table))
+			continue
+		}
+		if name == "PRIMARY" {
+			clauses = append(clauses, "DROP PRIMARY KEY")
+		} else {
+			clauses = append(clauses, fmt.Sprintf("DROP INDEX `%v`", name))
+		}
+	}
+
+	// order tracks the columns as they will be once the clauses
+	// are applied, so columns are only moved if they need to.
+	var order []string
+	for _, name := range actual.columns {
+		if _, ok := desired.columnDefs[name]; ok {
+			order = append(order, name)
+			continue
+		}
+		if !allowDrops {
+			unfixable = append(unfixable, fmt.Sprintf("extra column %v on table %v is not dropped", name, table))
+			order = append(order, name)
+			continue
+		}
+		clauses = append(clauses, fmt.Sprintf("DROP COLUMN `%v`", name))
+	}
+	prev := ""
+	for _, name := range desired.columns {
+		def := desired.columnDefs[name]
+		pos := indexOf(order, name)
+		position := "FIRST"
+		if prev != "" {
+			position = fmt.Sprintf("AFTER `%v`", prev)
+		}
+		switch {
+		case pos == -1:
+			clauses = append(clauses, fmt.Sprintf("ADD COLUMN %v %v", def, position))
+		case def != actual.columnDefs[name] || pos != indexOf(order, prev)+1:
+			clauses = append(clauses, fmt.Sprintf("MODIFY COLUMN %v %v", def, position))
+			order = append(order[:pos], order[pos+1:]...)
+		default:
+			prev = name
+			continue
+		}
+		at := indexOf(order, prev) + 1
+		order = append(order[:at], append([]string{name}, order[at:]...)...)
+		prev = name
+	}
+
+	for _, name := range desired.indexes {
+		def := desired.indexDefs[name]
+		if adef, ok := actual.indexDefs[name]; ok && adef == def {
+			continue
+		}
+		clauses = append(clauses, "ADD "+def)
+	}
+
+	if desired.options != actual.options {
+		unfixable = append(unfixable, fmt.Sprintf("table %v has options %q instead of %q", table, actual.options, desired.options))
+	}
+
+	if len(clauses) == 0 {
+		return "", unfixable
+	}
+	return fmt.Sprintf("ALTER TABLE `%v` %v", table, strings.Join(clauses, ", ")), unfixable
+}
+
+func indexOf(list []string, s string) int {
+	for i, e := range list {
+		if e == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,106 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaFixes(t *testing.T) {
+	table := func(name, schema string) *TableDefinition {
+		return &TableDefinition{Name: name, Schema: schema, Type: TableBaseTable}
+	}
+	desired := &SchemaDefinition{
+		TableDefinitions: []*TableDefinition{
+			table("t1", "CREATE TABLE `t1` (\n"+
+				"  `id` bigint(20) NOT NULL,\n"+
+				"  `msg` varchar(64) DEFAULT NULL,\n"+
+				"  `ts` datetime NOT NULL,\n"+
+				"  PRIMARY KEY (`id`),\n"+
+				"  KEY `ts_idx` (`ts`)\n"+
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8"),
+			table("t2", "CREATE TABLE `t2` (\n"+
+				"  `id` bigint(20) NOT NULL,\n"+
+				"  PRIMARY KEY (`id`)\n"+
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8"),
+			{Name: "v1", Schema: "CREATE VIEW `v1` AS SELECT 1", Type: TableView},
+		},
+	}
+	actual := &SchemaDefinition{
+		TableDefinitions: []*TableDefinition{
+			// missed the ALTER adding ts and its index, and
+			// has an older msg and an extra column
+			table("t1", "CREATE TABLE `t1` (\n"+
+				"  `id` bigint(20) NOT NULL,\n"+
+				"  `msg` varchar(32) DEFAULT NULL,\n"+
+				"  `old` int(11) DEFAULT NULL,\n"+
+				"  PRIMARY KEY (`id`),\n"+
+				"  KEY `old_idx` (`old`)\n"+
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8"),
+			table("t3", "CREATE TABLE `t3` (\n"+
+				"  `id` bigint(20) NOT NULL\n"+
+				") ENGINE=MyISAM"),
+		},
+	}
+
+	sqls, unfixable := SchemaFixes(desired, actual, true)
+	wantSqls := []string{
+		"ALTER TABLE `t1` DROP INDEX `old_idx`, DROP COLUMN `old`, MODIFY COLUMN `msg` varchar(64) DEFAULT NULL AFTER `id`, ADD COLUMN `ts` datetime NOT NULL AFTER `msg`, ADD KEY `ts_idx` (`ts`)",
+		desired.TableDefinitions[1].Schema,
+		"DROP TABLE `t3`",
+	}
+	if !reflect.DeepEqual(sqls, wantSqls) {
+		t.Errorf("SchemaFixes() = %#v, want %#v", sqls, wantSqls)
+	}
+	if want := []string{"view v1 is missing"}; !reflect.DeepEqual(unfixable, want) {
+		t.Errorf("SchemaFixes() unfixable = %v, want %v", unfixable, want)
+	}
+
+	// Without allowDrops, the extra objects are reported instead.
+	sqls, unfixable = SchemaFixes(desired, actual, false)
+	wantSqls = []string{
+		"ALTER TABLE `t1` MODIFY COLUMN `msg` varchar(64) DEFAULT NULL AFTER `id`, ADD COLUMN `ts` datetime NOT NULL AFTER `msg`, ADD KEY `ts_idx` (`ts`)",
+		desired.TableDefinitions[1].Schema,
+	}
+	if !reflect.DeepEqual(sqls, wantSqls) {
+		t.Errorf("SchemaFixes() without drops = %#v, want %#v", sqls, wantSqls)
+	}
+	wantUnfixable := []string{
+		"extra index old_idx on table t1 is not dropped",
+		"extra column old on table t1 is not dropped",
+		"view v1 is missing",
+		"extra table t3 is not dropped",
+	}
+	if !reflect.DeepEqual(unfixable, wantUnfixable) {
+		t.Errorf("SchemaFixes() without drops unfixable = %v, want %v", unfixable, wantUnfixable)
+	}
+
+	// Identical schemas need no fix.
+	sqls, unfixable = SchemaFixes(desired, desired, true)
+	if len(sqls) != 0 || len(unfixable) != 0 {
+		t.Errorf("SchemaFixes() on identical schemas = %v, %v", sqls, unfixable)
+	}
+}
+
+func TestAlterTableFixMovesColumns(t *testing.T) {
+	desired := "CREATE TABLE `t` (\n" +
+		"  `a` int(11) DEFAULT NULL,\n" +
+		"  `b` int(11) DEFAULT NULL,\n" +
+		"  `c` int(11) DEFAULT NULL\n" +
+		") ENGINE=InnoDB"
+	actual := "CREATE TABLE `t` (\n" +
+		"  `b` int(11) DEFAULT NULL,\n" +
+		"  `c` int(11) DEFAULT NULL,\n" +
+		"  `a` int(11) DEFAULT NULL\n" +
+		") ENGINE=MyISAM"
+	got, unfixable := alterTableFix("t", desired, actual, false)
+	if want := "ALTER TABLE `t` MODIFY COLUMN `a` int(11) DEFAULT NULL FIRST"; got != want {
+		t.Errorf("alterTableFix() = %v, want %v", got, want)
+	}
+	if want := []string{`table t has options ") ENGINE=MyISAM" instead of ") ENGINE=InnoDB"`}; !reflect.DeepEqual(unfixable, want) {
+		t.Errorf("alterTableFix() unfixable = %v, want %v", unfixable, want)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	log "github.com/golang/glog"
 	mproto "github.com/youtube/vitess/go/mysql/proto"
+	"golang.org/x/net/context"
 )
 
 // ExecuteSuperQuery allows the user to execute a query as a super user.
@@ -18,7 +19,17 @@ func (mysqld *Mysqld) ExecuteSuperQuery(query string) error {
 }
 
 // ExecuteSuperQueryList alows the user to execute queries as a super user.
+// If a mysqlctld address is provided in a flag, the queries run remotely.
 func (mysqld *Mysqld) ExecuteSuperQueryList(queryList []string) error {
+	// Execute as remote action on mysqlctld if requested.
+	if *socketFile != "" {
+		client, err := mysqld.mysqlctldClient()
+		if err != nil {
+			return err
+		}
+		return client.ExecuteSuperQueryList(context.TODO(), queryList)
+	}
+
 	conn, connErr := mysqld.dbaPool.Get(0)
 	if connErr != nil {
 		return connErr
@@ -34,7 +45,17 @@ func (mysqld *Mysqld) ExecuteSuperQueryList(queryList []string) error {
 }
 
 // FetchSuperQuery returns the results of executing a query as a super user.
+// If a mysqlctld address is provided in a flag, the query runs remotely.
 func (mysqld *Mysqld) FetchSuperQuery(query string) (*mproto.QueryResult, error) {
+	// Execute as remote action on mysqlctld if requested.
+	if *socketFile != "" {
+		client, err := mysqld.mysqlctldClient()
+		if err != nil {
+			return nil, err
+		}
+		return client.FetchSuperQuery(context.TODO(), query)
+	}
+
 	conn, connErr := mysqld.dbaPool.Get(0)
 	if connErr != nil {
 		return nil, connErr
@@ -26,6 +26,7 @@ import (
 	"github.com/youtube/vitess/go/vt/dbconfigs"
 	"github.com/youtube/vitess/go/vt/hook"
 	"github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"golang.org/x/net/context"
 )
 
 const (
@@ -34,6 +35,14 @@ const (
 
 	// SQLStopSlave is the SQl command issued to stop MySQL replication
 	SQLStopSlave = "STOP SLAVE"
+
+	// SQLStartSlaveIOThread is the SQl command issued to start
+	// the MySQL replication IO thread only
+	SQLStartSlaveIOThread = "START SLAVE IO_THREAD"
+
+	// SQLStopSlaveIOThread is the SQl command issued to stop
+	// the MySQL replication IO thread only
+	SQLStopSlaveIOThread = "STOP SLAVE IO_THREAD"
 )
 
 func fillStringTemplate(tmpl string, vars interface{}) (string, error) {
@@ -155,7 +164,17 @@ func (mysqld *Mysqld) GetMysqlPort() (int32, error) {
 }
 
 // IsReadOnly return true if the instance is read only
+// If a mysqlctld address is provided in a flag, it runs remotely.
 func (mysqld *Mysqld) IsReadOnly() (bool, error) {
+	// Execute as remote action on mysqlctld if requested.
+	if *socketFile != "" {
+		client, err := mysqld.mysqlctldClient()
+		if err != nil {
+			return true, err
+		}
+		return client.IsReadOnly(context.TODO())
+	}
+
 	qr, err := mysqld.FetchSuperQuery("SHOW VARIABLES LIKE 'read_only'")
 	if err != nil {
 		return true, err
@@ -170,7 +189,17 @@ func (mysqld *Mysqld) IsReadOnly() (bool, error) {
 }
 
 // SetReadOnly set/unset the read_only flag
+// If a mysqlctld address is provided in a flag, it runs remotely.
 func (mysqld *Mysqld) SetReadOnly(on bool) error {
+	// Execute as remote action on mysqlctld if requested.
+	if *socketFile != "" {
+		client, err := mysqld.mysqlctldClient()
+		if err != nil {
+			return err
+		}
+		return client.SetReadOnly(context.TODO(), on)
+	}
+
 	query := "SET GLOBAL read_only = "
 	if on {
 		query += "ON"
@@ -196,8 +225,28 @@ func (mysqld *Mysqld) WaitMasterPos(targetPos proto.ReplicationPosition, waitTim
 	return flavor.WaitMasterPos(mysqld, targetPos, waitTimeout)
 }
 
+// StartSlaveUntilAfter starts replication on a stopped slave, until
+// it has applied all the transactions of targetPos. It doesn't wait.
+func (mysqld *Mysqld) StartSlaveUntilAfter(targetPos proto.ReplicationPosition) error {
+	flavor, err := mysqld.flavor()
+	if err != nil {
+		return fmt.Errorf("StartSlaveUntilAfter needs flavor: %v", err)
+	}
+	return mysqld.ExecuteSuperQueryList(flavor.StartSlaveUntilAfterCommands(targetPos))
+}
+
 // SlaveStatus returns the slave replication statuses
+// If a mysqlctld address is provided in a flag, it runs remotely.
 func (mysqld *Mysqld) SlaveStatus() (proto.ReplicationStatus, error) {
+	// Execute as remote action on mysqlctld if requested.
+	if *socketFile != "" {
+		client, err := mysqld.mysqlctldClient()
+		if err != nil {
+			return proto.ReplicationStatus{}, err
+		}
+		return client.SlaveStatus(context.TODO())
+	}
+
 	flavor, err := mysqld.flavor()
 	if err != nil {
 		return proto.ReplicationStatus{}, fmt.Errorf("SlaveStatus needs flavor: %v", err)
@@ -206,7 +255,17 @@ func (mysqld *Mysqld) SlaveStatus() (proto.ReplicationStatus, error) {
 }
 
 // MasterPosition returns master replication position
+// If a mysqlctld address is provided in a flag, it runs remotely.
 func (mysqld *Mysqld) MasterPosition() (rp proto.ReplicationPosition, err error) {
+	// Execute as remote action on mysqlctld if requested.
+	if *socketFile != "" {
+		client, err := mysqld.mysqlctldClient()
+		if err != nil {
+			return rp, err
+		}
+		return client.MasterPosition(context.TODO())
+	}
+
 	flavor, err := mysqld.flavor()
 	if err != nil {
 		return rp, fmt.Errorf("MasterPosition needs flavor: %v", err)
@@ -0,0 +1,315 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package s3backupstorage implements the BackupStorage interface
+// for Amazon S3, and the storage systems compatible with its API
+// (like MinIO or Ceph RGW).
+package s3backupstorage
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	"github.com/youtube/vitess/go/vt/concurrency"
+	"github.com/youtube/vitess/go/vt/mysqlctl/backupstorage"
+)
+
+var (
+	// region is the AWS region the bucket is in.
+	region = flag.String("s3_backup_aws_region", "us-east-1", "AWS region to use for backups")
+
+	// endpoint overrides the S3 endpoint, for S3 compatible storage systems.
+	endpoint = flag.String("s3_backup_aws_endpoint", "", "endpoint of the S3 compatible storage system to use for backups, instead of AWS S3")
+
+	// forcePathStyle puts the bucket name in the path instead of the host name.
+	forcePathStyle = flag.Bool("s3_backup_force_path_style", false, "use path style bucket addressing, required by most S3 compatible storage systems")
+
+	// bucket is where the backups will go.
+	bucket = flag.String("s3_backup_storage_bucket", "", "S3 bucket to use for backups")
+
+	// root is a prefix added to all object names.
+	root = flag.String("s3_backup_storage_root", "", "root prefix for all backup-related object names")
+
+	// serverSideEncryption is the encryption algorithm S3 uses for the files.
+	serverSideEncryption = flag.String("s3_backup_server_side_encryption", "", "server-side encryption algorithm for the backup files: AES256 or aws:kms (default is no encryption)")
+
+	// kmsKeyID is the KMS key S3 uses with aws:kms encryption.
+	kmsKeyID = flag.String("s3_backup_sse_kms_key_id", "", "KMS key id to use with aws:kms server-side encryption (default is the account default key)")
+
+	// uploadPartSize and uploadConcurrency tune the multipart uploads.
+	uploadPartSize    = flag.Int64("s3_backup_upload_part_size", s3manager.DefaultUploadPartSize, "size in bytes of the parts of the multipart uploads of backup files, at least 5MB")
+	uploadConcurrency = flag.Int("s3_backup_upload_concurrency", s3manager.DefaultUploadConcurrency, "number of parts of a backup file uploaded in parallel")
+)
+
+// S3BackupHandle implements BackupHandle for Amazon S3.
+type S3BackupHandle struct {
+	client   *s3.S3
+	bs       *S3BackupStorage
+	dir      string
+	name     string
+	readOnly bool
+
+	// waitGroup and errors track the uploads started by AddFile.
+	waitGroup sync.WaitGroup
+	errors    concurrency.AllErrorRecorder
+}
+
+// Directory implements BackupHandle.
+func (bh *S3BackupHandle) Directory() string {
+	return bh.dir
+}
+
+// Name implements BackupHandle.
+func (bh *S3BackupHandle) Name() string {
+	return bh.name
+}
+
+// AddFile implements BackupHandle. The file is uploaded as it is
+// written, in parts of -s3_backup_upload_part_size bytes.
+func (bh *S3BackupHandle) AddFile(filename string) (io.WriteCloser, error) {
+	if bh.readOnly {
+		return nil, fmt.Errorf("AddFile cannot be called on read-only backup")
+	}
+
+	reader, writer := io.Pipe()
+	w := &uploadWriter{
+		PipeWriter: writer,
+		done:       make(chan struct{}),
+	}
+	bh.waitGroup.Add(1)
+	go func() {
+		defer bh.waitGroup.Done()
+		defer close(w.done)
+
+		uploader := s3manager.NewUploaderWithClient(bh.client, func(u *s3manager.Uploader) {
+			u.PartSize = *uploadPartSize
+			u.Concurrency = *uploadConcurrency
+		})
+		object := objName(bh.dir, bh.name, filename)
+		_, err := uploader.Upload(&s3manager.UploadInput{
+			Bucket:               bucket,
+			Key:                  &object,
+			Body:                 reader,
+			ServerSideEncryption: optionalString(*serverSideEncryption),
+			SSEKMSKeyId:          optionalString(*kmsKeyID),
+		})
+		if err != nil {
+			err = fmt.Errorf("cannot upload %v to bucket %v: %v", object, *bucket, err)
+			// unblock the writer, if it is still writing
+			reader.CloseWithError(err)
+			bh.errors.RecordError(err)
+		}
+		w.err = err
+	}()
+	return w, nil
+}
+
+// uploadWriter is the io.WriteCloser returned by AddFile. Close waits
+// for the end of the upload.
+type uploadWriter struct {
+	*io.PipeWriter
+	done chan struct{}
+	err  error
+}
+
+// Close is part of the io.Closer interface.
+func (w *uploadWriter) Close() error {
+	if err := w.PipeWriter.Close(); err != nil {
+		return err
+	}
+	<-w.done
+	return w.err
+}
+
+// EndBackup implements BackupHandle.
+func (bh *S3BackupHandle) EndBackup() error {
+	if bh.readOnly {
+		return fmt.Errorf("EndBackup cannot be called on read-only backup")
+	}
+	bh.waitGroup.Wait()
+	return bh.errors.Error()
+}
+
+// AbortBackup implements BackupHandle.
+func (bh *S3BackupHandle) AbortBackup() error {
+	if bh.readOnly {
+		return fmt.Errorf("AbortBackup cannot be called on read-only backup")
+	}
+	bh.waitGroup.Wait()
+	return bh.bs.RemoveBackup(bh.dir, bh.name)
+}
+
+// ReadFile implements BackupHandle.
+func (bh *S3BackupHandle) ReadFile(filename string) (io.ReadCloser, error) {
+	if !bh.readOnly {
+		return nil, fmt.Errorf("ReadFile cannot be called on read-write backup")
+	}
+	object := objName(bh.dir, bh.name, filename)
+	out, err := bh.client.GetObject(&s3.GetObjectInput{
+		Bucket: bucket,
+		Key:    &object,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+// S3BackupStorage implements BackupStorage for Amazon S3.
+type S3BackupStorage struct {
+	// mu protects client
+	mu     sync.Mutex
+	client *s3.S3
+}
+
+// ListBackups implements BackupStorage.
+func (bs *S3BackupStorage) ListBackups(dir string) ([]backupstorage.BackupHandle, error) {
+	client := bs.getClient()
+
+	// List prefixes that begin with dir (i.e. list subdirs).
+	var subdirs []string
+	searchPrefix := objName(dir, "" /* include trailing slash */)
+	query := &s3.ListObjectsInput{
+		Bucket:    bucket,
+		Delimiter: aws.String("/"),
+		Prefix:    &searchPrefix,
+	}
+
+	// ListObjectsPages loops in case results are returned in
+	// multiple batches.
+	if err := client.ListObjectsPages(query, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		// Each returned prefix is a subdir.
+		// Strip parent dir from full path.
+		for _, prefix := range page.CommonPrefixes {
+			subdir := strings.TrimPrefix(aws.StringValue(prefix.Prefix), searchPrefix)
+			subdir = strings.TrimSuffix(subdir, "/")
+			subdirs = append(subdirs, subdir)
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	// Backups must be returned in order, oldest first.
+	sort.Strings(subdirs)
+
+	result := make([]backupstorage.BackupHandle, 0, len(subdirs))
+	for _, subdir := range subdirs {
+		result = append(result, &S3BackupHandle{
+			client:   client,
+			bs:       bs,
+			dir:      dir,
+			name:     subdir,
+			readOnly: true,
+		})
+	}
+	return result, nil
+}
+
+// StartBackup implements BackupStorage.
+func (bs *S3BackupStorage) StartBackup(dir, name string) (backupstorage.BackupHandle, error) {
+	return &S3BackupHandle{
+		client:   bs.getClient(),
+		bs:       bs,
+		dir:      dir,
+		name:     name,
+		readOnly: false,
+	}, nil
+}
+
+// RemoveBackup implements BackupStorage.
+func (bs *S3BackupStorage) RemoveBackup(dir, name string) error {
+	client := bs.getClient()
+
+	// Find all objects with the right prefix.
+	var objects []*s3.ObjectIdentifier
+	query := &s3.ListObjectsInput{
+		Bucket: bucket,
+		Prefix: aws.String(objName(dir, name, "" /* include trailing slash */)),
+	}
+	if err := client.ListObjectsPages(query, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			objects = append(objects, &s3.ObjectIdentifier{Key: obj.Key})
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+
+	// Delete all the found objects, at most 1000 at a time.
+	for len(objects) > 0 {
+		batch := objects
+		if len(batch) > 1000 {
+			batch = batch[:1000]
+		}
+		objects = objects[len(batch):]
+
+		out, err := client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: bucket,
+			Delete: &s3.Delete{
+				Objects: batch,
+				Quiet:   aws.Bool(true),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to delete objects from bucket %q: %v", *bucket, err)
+		}
+		if len(out.Errors) > 0 {
+			e := out.Errors[0]
+			return fmt.Errorf("unable to delete %q from bucket %q: %v", aws.StringValue(e.Key), *bucket, aws.StringValue(e.Message))
+		}
+	}
+	return nil
+}
+
+// getClient returns the S3 client, creating it the first time.
+// The credentials are found the default AWS way: environment
+// variables, shared credentials file, or EC2 instance role.
+func (bs *S3BackupStorage) getClient() *s3.S3 {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if bs.client == nil {
+		config := &aws.Config{
+			Region:           region,
+			S3ForcePathStyle: forcePathStyle,
+		}
+		if *endpoint != "" {
+			config.Endpoint = endpoint
+		}
+		bs.client = s3.New(session.New(config))
+	}
+	return bs.client
+}
+
+// optionalString returns nil for an empty string, so the option is
+// not sent to S3.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// objName joins path parts into an object name.
+// Unlike path.Join, it doesn't collapse ".." or strip trailing slashes.
+// It also adds the value of the -s3_backup_storage_root flag if set.
+func objName(parts ...string) string {
+	if *root != "" {
+		return *root + "/" + strings.Join(parts, "/")
+	}
+	return strings.Join(parts, "/")
+}
+
+func init() {
+	backupstorage.BackupStorageMap["s3"] = &S3BackupStorage{}
+}
@@ -0,0 +1,451 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package s3backupstorage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// This file tests the S3 BackupStorage engine against fakeS3, a
+// small in-process stand-in for the S3 API, with path style bucket
+// addressing. It only supports the calls the engine makes.
+
+type fakeS3 struct {
+	t      *testing.T
+	bucket string
+
+	mu sync.Mutex
+	// objects maps keys to contents
+	objects map[string][]byte
+	// encryption maps keys to their x-amz-server-side-encryption
+	encryption map[string]string
+	// uploads maps upload ids to their parts, by part number
+	uploads          map[string]map[int][]byte
+	uploadKeys       map[string]string
+	nextUploadID     int
+	completedUploads int
+}
+
+type listBucketResult struct {
+	XMLName        xml.Name       `xml:"ListBucketResult"`
+	Name           string         `xml:"Name"`
+	Prefix         string         `xml:"Prefix"`
+	Marker         string         `xml:"Marker"`
+	IsTruncated    bool           `xml:"IsTruncated"`
+	Contents       []listContent  `xml:"Contents"`
+	CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type listContent struct {
+	Key  string `xml:"Key"`
+	Size int    `xml:"Size"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type deleteRequest struct {
+	Objects []struct {
+		Key string `xml:"Key"`
+	} `xml:"Object"`
+}
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+	Bucket  string   `xml:"Bucket"`
+	Key     string   `xml:"Key"`
+	ETag    string   `xml:"ETag"`
+}
+
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func newFakeS3(t *testing.T, bucket string) *fakeS3 {
+	return &fakeS3{
+		t:          t,
+		bucket:     bucket,
+		objects:    make(map[string][]byte),
+		encryption: make(map[string]string),
+		uploads:    make(map[string]map[int][]byte),
+		uploadKeys: make(map[string]string),
+	}
+}
+
+func (f *fakeS3) writeXML(w http.ResponseWriter, status int, v interface{}) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		f.t.Errorf("xml.Marshal failed: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if parts[0] != f.bucket {
+		f.writeXML(w, http.StatusNotFound, &s3Error{Code: "NoSuchBucket", Message: parts[0]})
+		return
+	}
+	key := ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+	query := r.URL.Query()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		f.t.Errorf("cannot read request body: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, isUploads := query["uploads"]
+	_, isDelete := query["delete"]
+	uploadID := query.Get("uploadId")
+	switch {
+	case key == "" && r.Method == "GET":
+		f.list(w, query.Get("prefix"), query.Get("delimiter"))
+	case key == "" && r.Method == "POST" && isDelete:
+		req := &deleteRequest{}
+		if err := xml.Unmarshal(body, req); err != nil {
+			f.writeXML(w, http.StatusBadRequest, &s3Error{Code: "MalformedXML", Message: err.Error()})
+			return
+		}
+		for _, obj := range req.Objects {
+			delete(f.objects, obj.Key)
+			delete(f.encryption, obj.Key)
+		}
+		f.writeXML(w, http.StatusOK, &struct {
+			XMLName xml.Name `xml:"DeleteResult"`
+		}{})
+	case r.Method == "PUT" && uploadID == "":
+		f.objects[key] = body
+		f.encryption[key] = r.Header.Get("X-Amz-Server-Side-Encryption")
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == "POST" && isUploads:
+		f.nextUploadID++
+		id := fmt.Sprintf("upload%v", f.nextUploadID)
+		f.uploads[id] = make(map[int][]byte)
+		f.uploadKeys[id] = key
+		f.encryption[key] = r.Header.Get("X-Amz-Server-Side-Encryption")
+		f.writeXML(w, http.StatusOK, &initiateMultipartUploadResult{Bucket: f.bucket, Key: key, UploadID: id})
+	case r.Method == "PUT":
+		upload, ok := f.uploads[uploadID]
+		partNumber, err := strconv.Atoi(query.Get("partNumber"))
+		if !ok || err != nil {
+			f.writeXML(w, http.StatusNotFound, &s3Error{Code: "NoSuchUpload", Message: uploadID})
+			return
+		}
+		upload[partNumber] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag%v"`, partNumber))
+		w.WriteHeader(http.StatusOK)
+	case r.Method == "POST":
+		upload, ok := f.uploads[uploadID]
+		if !ok {
+			f.writeXML(w, http.StatusNotFound, &s3Error{Code: "NoSuchUpload", Message: uploadID})
+			return
+		}
+		var partNumbers []int
+		for partNumber := range upload {
+			partNumbers = append(partNumbers, partNumber)
+		}
+		sort.Ints(partNumbers)
+		buf := bytes.Buffer{}
+		for _, partNumber := range partNumbers {
+			buf.Write(upload[partNumber])
+		}
+		f.objects[f.uploadKeys[uploadID]] = buf.Bytes()
+		delete(f.uploads, uploadID)
+		f.completedUploads++
+		f.writeXML(w, http.StatusOK, &completeMultipartUploadResult{Bucket: f.bucket, Key: key, ETag: `"etag"`})
+	case r.Method == "DELETE" && uploadID != "":
+		delete(f.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "GET":
+		data, ok := f.objects[key]
+		if !ok {
+			f.writeXML(w, http.StatusNotFound, &s3Error{Code: "NoSuchKey", Message: key})
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	default:
+		f.t.Errorf("unexpected request: %v %v", r.Method, r.URL)
+		f.writeXML(w, http.StatusNotImplemented, &s3Error{Code: "NotImplemented", Message: r.URL.String()})
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix, delimiter string) {
+	result := &listBucketResult{
+		Name:   f.bucket,
+		Prefix: prefix,
+	}
+	var keys []string
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i != -1 {
+				p := key[:len(prefix)+i+len(delimiter)]
+				if !seen[p] {
+					seen[p] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: p})
+				}
+				continue
+			}
+		}
+		result.Contents = append(result.Contents, listContent{Key: key, Size: len(f.objects[key])})
+	}
+	f.writeXML(w, http.StatusOK, result)
+}
+
+// setupS3BackupStorage starts a fakeS3 and points the flags to it.
+func setupS3BackupStorage(t *testing.T) (*S3BackupStorage, *fakeS3, func()) {
+	fake := newFakeS3(t, "test-bucket")
+	server := httptest.NewServer(fake)
+
+	os.Setenv("AWS_ACCESS_KEY_ID", "test-access-key")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret-key")
+	*endpoint = server.URL
+	*forcePathStyle = true
+	*bucket = "test-bucket"
+	*root = "backups"
+	return &S3BackupStorage{}, fake, server.Close
+}
+
+func TestListBackups(t *testing.T) {
+	bs, _, cleanup := setupS3BackupStorage(t)
+	defer cleanup()
+
+	// verify we have no entry now
+	dir := "keyspace/shard"
+	bhs, err := bs.ListBackups(dir)
+	if err != nil {
+		t.Fatalf("ListBackups on empty bucket failed: %v", err)
+	}
+	if len(bhs) != 0 {
+		t.Fatalf("ListBackups on empty bucket returned results: %#v", bhs)
+	}
+
+	// add two backups with one file each, the second one with an
+	// earlier date
+	firstBackup := "cell-0001-2015-01-14-10-00-00"
+	secondBackup := "cell-0001-2015-01-12-10-00-00"
+	for _, name := range []string{firstBackup, secondBackup} {
+		bh, err := bs.StartBackup(dir, name)
+		if err != nil {
+			t.Fatalf("StartBackup failed: %v", err)
+		}
+		wc, err := bh.AddFile("MANIFEST")
+		if err != nil {
+			t.Fatalf("AddFile failed: %v", err)
+		}
+		if _, err := wc.Write([]byte("{}")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if err := wc.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if err := bh.EndBackup(); err != nil {
+			t.Fatalf("EndBackup failed: %v", err)
+		}
+	}
+
+	// verify we have two sorted entries now
+	bhs, err = bs.ListBackups(dir)
+	if err != nil {
+		t.Fatalf("ListBackups failed: %v", err)
+	}
+	if len(bhs) != 2 ||
+		bhs[0].Directory() != dir ||
+		bhs[0].Name() != secondBackup ||
+		bhs[1].Directory() != dir ||
+		bhs[1].Name() != firstBackup {
+		t.Fatalf("ListBackups with two backups returned wrong results: %#v", bhs)
+	}
+
+	// remove a backup, back to one
+	if err := bs.RemoveBackup(dir, secondBackup); err != nil {
+		t.Fatalf("RemoveBackup failed: %v", err)
+	}
+	bhs, err = bs.ListBackups(dir)
+	if err != nil {
+		t.Fatalf("ListBackups after deletion failed: %v", err)
+	}
+	if len(bhs) != 1 || bhs[0].Name() != firstBackup {
+		t.Fatalf("ListBackups after deletion returned wrong results: %#v", bhs)
+	}
+
+	// add a backup with a file but abort it, should stay at one
+	bh, err := bs.StartBackup(dir, secondBackup)
+	if err != nil {
+		t.Fatalf("StartBackup failed: %v", err)
+	}
+	wc, err := bh.AddFile("file")
+	if err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+	if err := wc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := bh.AbortBackup(); err != nil {
+		t.Fatalf("AbortBackup failed: %v", err)
+	}
+	bhs, err = bs.ListBackups(dir)
+	if err != nil {
+		t.Fatalf("ListBackups after abort failed: %v", err)
+	}
+	if len(bhs) != 1 || bhs[0].Name() != firstBackup {
+		t.Fatalf("ListBackups after abort returned wrong results: %#v", bhs)
+	}
+
+	// check we cannot change a backup we listed
+	if _, err := bhs[0].AddFile("test"); err == nil {
+		t.Fatalf("was able to AddFile to read-only backup")
+	}
+	if err := bhs[0].EndBackup(); err == nil {
+		t.Fatalf("was able to EndBackup a read-only backup")
+	}
+	if err := bhs[0].AbortBackup(); err == nil {
+		t.Fatalf("was able to AbortBackup a read-only backup")
+	}
+}
+
+func TestFileContents(t *testing.T) {
+	bs, fake, cleanup := setupS3BackupStorage(t)
+	defer cleanup()
+	*serverSideEncryption = "AES256"
+	*uploadPartSize = s3manager.MinUploadPartSize
+	defer func() {
+		*serverSideEncryption = ""
+		*uploadPartSize = s3manager.DefaultUploadPartSize
+	}()
+
+	dir := "keyspace/shard"
+	name := "cell-0001-2015-01-14-10-00-00"
+
+	// a small file, uploaded in one request, and a large one,
+	// uploaded in 3 parts, written concurrently
+	small := []byte("contents of the first file")
+	large := bytes.Repeat([]byte("0123456789abcdef"), int(2*s3manager.MinUploadPartSize+1024)/16)
+	contents := map[string][]byte{
+		"small": small,
+		"large": large,
+	}
+
+	bh, err := bs.StartBackup(dir, name)
+	if err != nil {
+		t.Fatalf("StartBackup failed: %v", err)
+	}
+	wg := sync.WaitGroup{}
+	for filename, data := range contents {
+		wg.Add(1)
+		go func(filename string, data []byte) {
+			defer wg.Done()
+			wc, err := bh.AddFile(filename)
+			if err != nil {
+				t.Errorf("AddFile(%v) failed: %v", filename, err)
+				return
+			}
+			// write in small chunks, like the backup code does
+			for len(data) > 0 {
+				n := 32 * 1024
+				if n > len(data) {
+					n = len(data)
+				}
+				if _, err := wc.Write(data[:n]); err != nil {
+					t.Errorf("Write(%v) failed: %v", filename, err)
+					return
+				}
+				data = data[n:]
+			}
+			if err := wc.Close(); err != nil {
+				t.Errorf("Close(%v) failed: %v", filename, err)
+			}
+		}(filename, data)
+	}
+	wg.Wait()
+
+	// test we can't read back on read-write backup
+	if _, err := bh.ReadFile("small"); err == nil {
+		t.Fatalf("was able to ReadFile to read-write backup")
+	}
+	if err := bh.EndBackup(); err != nil {
+		t.Fatalf("EndBackup failed: %v", err)
+	}
+
+	fake.mu.Lock()
+	if fake.completedUploads != 1 {
+		t.Errorf("got %v multipart uploads, want 1", fake.completedUploads)
+	}
+	for filename := range contents {
+		object := "backups/" + dir + "/" + name + "/" + filename
+		if got := fake.encryption[object]; got != "AES256" {
+			t.Errorf("object %v has server side encryption %q, want AES256", object, got)
+		}
+	}
+	fake.mu.Unlock()
+
+	// re-read the files
+	bhs, err := bs.ListBackups(dir)
+	if err != nil || len(bhs) != 1 {
+		t.Fatalf("ListBackups returned wrong return: %v %v", err, bhs)
+	}
+	for filename, data := range contents {
+		rc, err := bhs[0].ReadFile(filename)
+		if err != nil {
+			t.Fatalf("ReadFile(%v) failed: %v", filename, err)
+		}
+		got, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("ReadAll(%v) failed: %v", filename, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("ReadFile(%v) returned %v bytes, want %v", filename, len(got), len(data))
+		}
+		if err := rc.Close(); err != nil {
+			t.Fatalf("Close(%v) failed: %v", filename, err)
+		}
+	}
+
+	// reading a missing file fails
+	if _, err := bhs[0].ReadFile("missing"); err == nil {
+		t.Errorf("ReadFile(missing) should have failed")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+/*
+This file contains the semi-sync replication code shared by the flavors.
+*/
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/youtube/vitess/go/vt/mysqlctl/proto"
+)
+
+// semiSyncPlugins maps the semi-sync plugin names to their libraries.
+var semiSyncPlugins = []struct {
+	name, library string
+}{
+	{"rpl_semi_sync_master", "semisync_master.so"},
+	{"rpl_semi_sync_slave", "semisync_slave.so"},
+}
+
+// semiSyncEnableCommands returns the commands to enable or disable
+// the master and slave sides of semi-sync replication. The plugins
+// need to be loaded.
+func semiSyncEnableCommands(master, slave bool) []string {
+	return []string{
+		fmt.Sprintf("SET GLOBAL rpl_semi_sync_master_enabled = %v", boolToInt(master)),
+		fmt.Sprintf("SET GLOBAL rpl_semi_sync_slave_enabled = %v", boolToInt(slave)),
+	}
+}
+
+// semiSyncStatus reads the semi-sync variables and status of the
+// server. If the plugins are not loaded, everything is disabled.
+func semiSyncStatus(mysqld *Mysqld) (proto.SemiSyncStatus, error) {
+	values := make(map[string]string)
+	for _, query := range []string{
+		"SHOW GLOBAL VARIABLES LIKE 'rpl_semi_sync_%_enabled'",
+		"SHOW GLOBAL STATUS LIKE 'Rpl_semi_sync_%'",
+	} {
+		qr, err := mysqld.FetchSuperQuery(query)
+		if err != nil {
+			return proto.SemiSyncStatus{}, err
+		}
+		for _, row := range qr.Rows {
+			if len(row) != 2 {
+				return proto.SemiSyncStatus{}, fmt.Errorf("unexpected result for %v: %v", query, row)
+			}
+			values[row[0].String()] = row[1].String()
+		}
+	}
+	return parseSemiSyncStatus(values)
+}
+
+// parseSemiSyncStatus builds a SemiSyncStatus from the semi-sync
+// variables and status values.
+func parseSemiSyncStatus(values map[string]string) (proto.SemiSyncStatus, error) {
+	status := proto.SemiSyncStatus{
+		MasterEnabled: values["rpl_semi_sync_master_enabled"] == "ON",
+		MasterActive:  values["Rpl_semi_sync_master_status"] == "ON",
+		SlaveEnabled:  values["rpl_semi_sync_slave_enabled"] == "ON",
+		SlaveActive:   values["Rpl_semi_sync_slave_status"] == "ON",
+	}
+	if clients, ok := values["Rpl_semi_sync_master_clients"]; ok {
+		n, err := strconv.Atoi(clients)
+		if err != nil {
+			return proto.SemiSyncStatus{}, fmt.Errorf("cannot parse Rpl_semi_sync_master_clients %q: %v", clients, err)
+		}
+		status.MasterClients = n
+	}
+	return status, nil
+}
+
+// installSemiSyncPlugins loads the semi-sync plugins that are not
+// loaded yet.
+func (mysqld *Mysqld) installSemiSyncPlugins() error {
+	for _, plugin := range semiSyncPlugins {
+		qr, err := mysqld.FetchSuperQuery(fmt.Sprintf("SELECT PLUGIN_STATUS FROM information_schema.PLUGINS WHERE PLUGIN_NAME = '%v'", plugin.name))
+		if err != nil {
+			return err
+		}
+		if len(qr.Rows) > 0 {
+			continue
+		}
+		if err := mysqld.ExecuteSuperQuery(fmt.Sprintf("INSTALL PLUGIN %v SONAME '%v'", plugin.name, plugin.library)); err != nil {
+			return fmt.Errorf("cannot install semi-sync plugin %v: %v", plugin.name, err)
+		}
+	}
+	return nil
+}
+
+// SetSemiSyncEnabled enables or disables the master and slave sides
+// of semi-sync replication, loading the plugins if needed. The slave
+// side only takes effect when the slave IO thread is (re)started.
+func (mysqld *Mysqld) SetSemiSyncEnabled(master, slave bool) error {
+	flavor, err := mysqld.flavor()
+	if err != nil {
+		return fmt.Errorf("SetSemiSyncEnabled needs flavor: %v", err)
+	}
+	if master || slave {
+		if err := mysqld.installSemiSyncPlugins(); err != nil {
+			return err
+		}
+	} else {
+		// Nothing to disable if the plugins were never loaded.
+		status, err := flavor.SemiSyncStatus(mysqld)
+		if err != nil {
+			return err
+		}
+		if !status.MasterEnabled && !status.SlaveEnabled {
+			return nil
+		}
+	}
+	return mysqld.ExecuteSuperQueryList(flavor.SemiSyncEnableCommands(master, slave))
+}
+
+// SemiSyncStatus returns the semi-sync replication status.
+func (mysqld *Mysqld) SemiSyncStatus() (proto.SemiSyncStatus, error) {
+	flavor, err := mysqld.flavor()
+	if err != nil {
+		return proto.SemiSyncStatus{}, fmt.Errorf("SemiSyncStatus needs flavor: %v", err)
+	}
+	return flavor.SemiSyncStatus(mysqld)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysqlctl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/mysqlctl/proto"
+)
+
+func TestSemiSyncEnableCommands(t *testing.T) {
+	want := []string{
+		"SET GLOBAL rpl_semi_sync_master_enabled = 1",
+		"SET GLOBAL rpl_semi_sync_slave_enabled = 0",
+	}
+	if got := semiSyncEnableCommands(true, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("semiSyncEnableCommands(true, false) = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseSemiSyncStatus(t *testing.T) {
+	got, err := parseSemiSyncStatus(map[string]string{
+		"rpl_semi_sync_master_enabled": "ON",
+		"rpl_semi_sync_slave_enabled":  "OFF",
+		"Rpl_semi_sync_master_status":  "ON",
+		"Rpl_semi_sync_master_clients": "2",
+		"Rpl_semi_sync_slave_status":   "OFF",
+	})
+	if err != nil {
+		t.Fatalf("parseSemiSyncStatus failed: %v", err)
+	}
+	want := proto.SemiSyncStatus{
+		MasterEnabled: true,
+		MasterActive:  true,
+		MasterClients: 2,
+	}
+	if got != want {
+		t.Errorf("parseSemiSyncStatus = %#v, want %#v", got, want)
+	}
+
+	// plugins not loaded
+	got, err = parseSemiSyncStatus(map[string]string{})
+	if err != nil {
+		t.Fatalf("parseSemiSyncStatus failed: %v", err)
+	}
+	if got != (proto.SemiSyncStatus{}) {
+		t.Errorf("parseSemiSyncStatus without plugins = %#v, want zero value", got)
+	}
+
+	if _, err := parseSemiSyncStatus(map[string]string{"Rpl_semi_sync_master_clients": "x"}); err == nil {
+		t.Errorf("parseSemiSyncStatus with bad client count: got no error")
+	}
+}
@@ -22,6 +22,10 @@ type SplitStrategy struct {
 
 	// SkipSetSourceShards will not set the source shards at the end of restore
 	SkipSetSourceShards bool
+
+	// ResumableCopy will record the copied chunks on the destinations,
+	// so a failed copy can be resumed
+	ResumableCopy bool
 }
 
 func NewSplitStrategy(logger logutil.Logger, argsStr string) (*SplitStrategy, error) {
@@ -38,6 +42,7 @@ func NewSplitStrategy(logger logutil.Logger, argsStr string) (*SplitStrategy, er
 	populateBlpCheckpoint := flagSet.Bool("populate_blp_checkpoint", false, "populates the blp checkpoint table")
 	dontStartBinlogPlayer := flagSet.Bool("dont_start_binlog_player", false, "do not start the binlog player after restore is complete")
 	skipSetSourceShards := flagSet.Bool("skip_set_source_shards", false, "do not set the SourceShar field on destination shards")
+	resumableCopy := flagSet.Bool("resumable_copy", false, "records the copied chunks in the _vt.split_clone_checkpoint table on the destinations, and resumes from them")
 	if err := flagSet.Parse(args); err != nil {
 		return nil, fmt.Errorf("cannot parse strategy: %v", err)
 	}
@@ -49,6 +54,7 @@ func NewSplitStrategy(logger logutil.Logger, argsStr string) (*SplitStrategy, er
 		PopulateBlpCheckpoint: *populateBlpCheckpoint,
 		DontStartBinlogPlayer: *dontStartBinlogPlayer,
 		SkipSetSourceShards:   *skipSetSourceShards,
+		ResumableCopy:         *resumableCopy,
 	}, nil
 }
 
@@ -63,5 +69,8 @@ func (strategy *SplitStrategy) String() string {
 	if strategy.SkipSetSourceShards {
 		result = append(result, "-skip_set_source_shards")
 	}
+	if strategy.ResumableCopy {
+		result = append(result, "-resumable_copy")
+	}
 	return strings.Join(result, " ")
 }
@@ -18,6 +18,10 @@ It has these top-level messages:
 	GetClusterOperationStateResponse
 	GetClusterOperationDetailsRequest
 	GetClusterOperationDetailsResponse
+	ResumeClusterOperationRequest
+	ResumeClusterOperationResponse
+	CancelClusterOperationRequest
+	CancelClusterOperationResponse
 */
 package automation
 
@@ -210,6 +214,36 @@ func (m *GetClusterOperationDetailsResponse) GetClusterOp() *ClusterOperation {
 	return nil
 }
 
+type ResumeClusterOperationRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *ResumeClusterOperationRequest) Reset()         { *m = ResumeClusterOperationRequest{} }
+func (m *ResumeClusterOperationRequest) String() string { return proto.CompactTextString(m) }
+func (*ResumeClusterOperationRequest) ProtoMessage()    {}
+
+type ResumeClusterOperationResponse struct {
+}
+
+func (m *ResumeClusterOperationResponse) Reset()         { *m = ResumeClusterOperationResponse{} }
+func (m *ResumeClusterOperationResponse) String() string { return proto.CompactTextString(m) }
+func (*ResumeClusterOperationResponse) ProtoMessage()    {}
+
+type CancelClusterOperationRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *CancelClusterOperationRequest) Reset()         { *m = CancelClusterOperationRequest{} }
+func (m *CancelClusterOperationRequest) String() string { return proto.CompactTextString(m) }
+func (*CancelClusterOperationRequest) ProtoMessage()    {}
+
+type CancelClusterOperationResponse struct {
+}
+
+func (m *CancelClusterOperationResponse) Reset()         { *m = CancelClusterOperationResponse{} }
+func (m *CancelClusterOperationResponse) String() string { return proto.CompactTextString(m) }
+func (*CancelClusterOperationResponse) ProtoMessage()    {}
+
 func init() {
 	proto.RegisterType((*ClusterOperation)(nil), "automation.ClusterOperation")
 	proto.RegisterType((*TaskContainer)(nil), "automation.TaskContainer")
@@ -220,6 +254,10 @@ func init() {
 	proto.RegisterType((*GetClusterOperationStateResponse)(nil), "automation.GetClusterOperationStateResponse")
 	proto.RegisterType((*GetClusterOperationDetailsRequest)(nil), "automation.GetClusterOperationDetailsRequest")
 	proto.RegisterType((*GetClusterOperationDetailsResponse)(nil), "automation.GetClusterOperationDetailsResponse")
+	proto.RegisterType((*ResumeClusterOperationRequest)(nil), "automation.ResumeClusterOperationRequest")
+	proto.RegisterType((*ResumeClusterOperationResponse)(nil), "automation.ResumeClusterOperationResponse")
+	proto.RegisterType((*CancelClusterOperationRequest)(nil), "automation.CancelClusterOperationRequest")
+	proto.RegisterType((*CancelClusterOperationResponse)(nil), "automation.CancelClusterOperationResponse")
 	proto.RegisterEnum("automation.ClusterOperationState", ClusterOperationState_name, ClusterOperationState_value)
 	proto.RegisterEnum("automation.TaskState", TaskState_name, TaskState_value)
 }
@@ -39,6 +39,12 @@ type AutomationClient interface {
 	// TODO(mberlin): Polling this is bad. Implement a subscribe mechanism to wait for changes?
 	// Get all details of an active cluster operation.
 	GetClusterOperationDetails(ctx context.Context, in *automation.GetClusterOperationDetailsRequest, opts ...grpc.CallOption) (*automation.GetClusterOperationDetailsResponse, error)
+	// Resume a cluster operation which failed or was canceled.
+	// It restarts from its first task which did not succeed.
+	ResumeClusterOperation(ctx context.Context, in *automation.ResumeClusterOperationRequest, opts ...grpc.CallOption) (*automation.ResumeClusterOperationResponse, error)
+	// Cancel a cluster operation. A running task is not interrupted,
+	// but no other task will be started.
+	CancelClusterOperation(ctx context.Context, in *automation.CancelClusterOperationRequest, opts ...grpc.CallOption) (*automation.CancelClusterOperationResponse, error)
 }
 
 type automationClient struct {
@@ -67,6 +73,24 @@ func (c *automationClient) GetClusterOperationDetails(ctx context.Context, in *a
 	return out, nil
 }
 
+func (c *automationClient) ResumeClusterOperation(ctx context.Context, in *automation.ResumeClusterOperationRequest, opts ...grpc.CallOption) (*automation.ResumeClusterOperationResponse, error) {
+	out := new(automation.ResumeClusterOperationResponse)
+	err := grpc.Invoke(ctx, "/automationservice.Automation/ResumeClusterOperation", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *automationClient) CancelClusterOperation(ctx context.Context, in *automation.CancelClusterOperationRequest, opts ...grpc.CallOption) (*automation.CancelClusterOperationResponse, error) {
+	out := new(automation.CancelClusterOperationResponse)
+	err := grpc.Invoke(ctx, "/automationservice.Automation/CancelClusterOperation", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Automation service
 
 type AutomationServer interface {
@@ -75,6 +99,12 @@ type AutomationServer interface {
 	// TODO(mberlin): Polling this is bad. Implement a subscribe mechanism to wait for changes?
 	// Get all details of an active cluster operation.
 	GetClusterOperationDetails(context.Context, *automation.GetClusterOperationDetailsRequest) (*automation.GetClusterOperationDetailsResponse, error)
+	// Resume a cluster operation which failed or was canceled.
+	// It restarts from its first task which did not succeed.
+	ResumeClusterOperation(context.Context, *automation.ResumeClusterOperationRequest) (*automation.ResumeClusterOperationResponse, error)
+	// Cancel a cluster operation. A running task is not interrupted,
+	// but no other task will be started.
+	CancelClusterOperation(context.Context, *automation.CancelClusterOperationRequest) (*automation.CancelClusterOperationResponse, error)
 }
 
 func RegisterAutomationServer(s *grpc.Server, srv AutomationServer) {
@@ -105,6 +135,30 @@ func _Automation_GetClusterOperationDetails_Handler(srv interface{}, ctx context
 	return out, nil
 }
 
+func _Automation_ResumeClusterOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(automation.ResumeClusterOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(AutomationServer).ResumeClusterOperation(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Automation_CancelClusterOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(automation.CancelClusterOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(AutomationServer).CancelClusterOperation(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 var _Automation_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "automationservice.Automation",
 	HandlerType: (*AutomationServer)(nil),
@@ -117,6 +171,14 @@ var _Automation_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetClusterOperationDetails",
 			Handler:    _Automation_GetClusterOperationDetails_Handler,
 		},
+		{
+			MethodName: "ResumeClusterOperation",
+			Handler:    _Automation_ResumeClusterOperation_Handler,
+		},
+		{
+			MethodName: "CancelClusterOperation",
+			Handler:    _Automation_CancelClusterOperation_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{},
 }
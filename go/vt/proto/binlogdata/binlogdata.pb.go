@@ -18,6 +18,7 @@ It has these top-level messages:
 	StreamKeyRangeResponse
 	StreamTablesRequest
 	StreamTablesResponse
+	ChangeEvent
 */
 package binlogdata
 
@@ -291,6 +292,28 @@ func (m *StreamTablesResponse) GetBinlogTransaction() *BinlogTransaction {
 	return nil
 }
 
+// ChangeEvent is a StreamEvent published by vtcdc to external sinks.
+type ChangeEvent struct {
+	// the shard the event comes from
+	Keyspace string `protobuf:"bytes,1,opt,name=keyspace" json:"keyspace,omitempty"`
+	Shard    string `protobuf:"bytes,2,opt,name=shard" json:"shard,omitempty"`
+	// the replication position after the transaction of this event
+	Position string `protobuf:"bytes,3,opt,name=position" json:"position,omitempty"`
+	// the event itself, never a SE_POS event
+	StreamEvent *StreamEvent `protobuf:"bytes,4,opt,name=stream_event" json:"stream_event,omitempty"`
+}
+
+func (m *ChangeEvent) Reset()         { *m = ChangeEvent{} }
+func (m *ChangeEvent) String() string { return proto.CompactTextString(m) }
+func (*ChangeEvent) ProtoMessage()    {}
+
+func (m *ChangeEvent) GetStreamEvent() *StreamEvent {
+	if m != nil {
+		return m.StreamEvent
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Charset)(nil), "binlogdata.Charset")
 	proto.RegisterType((*BinlogTransaction)(nil), "binlogdata.BinlogTransaction")
@@ -302,6 +325,7 @@ func init() {
 	proto.RegisterType((*StreamKeyRangeResponse)(nil), "binlogdata.StreamKeyRangeResponse")
 	proto.RegisterType((*StreamTablesRequest)(nil), "binlogdata.StreamTablesRequest")
 	proto.RegisterType((*StreamTablesResponse)(nil), "binlogdata.StreamTablesResponse")
+	proto.RegisterType((*ChangeEvent)(nil), "binlogdata.ChangeEvent")
 	proto.RegisterEnum("binlogdata.BinlogTransaction_Statement_Category", BinlogTransaction_Statement_Category_name, BinlogTransaction_Statement_Category_value)
 	proto.RegisterEnum("binlogdata.StreamEvent_Category", StreamEvent_Category_name, StreamEvent_Category_value)
 }
@@ -15,12 +15,30 @@ It has these top-level messages:
 	ShutdownResponse
 	RunMysqlUpgradeRequest
 	RunMysqlUpgradeResponse
+	ReinitConfigRequest
+	ReinitConfigResponse
+	IsReadOnlyRequest
+	IsReadOnlyResponse
+	SetReadOnlyRequest
+	SetReadOnlyResponse
+	SlaveStatusRequest
+	SlaveStatusResponse
+	MasterPositionRequest
+	MasterPositionResponse
+	ExecuteSuperQueryListRequest
+	ExecuteSuperQueryListResponse
+	FetchSuperQueryRequest
+	FetchSuperQueryResponse
+	TailErrorLogRequest
+	TailErrorLogResponse
 */
 package mysqlctl
 
 import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
+import query "github.com/youtube/vitess/go/vt/proto/query"
+import replicationdata "github.com/youtube/vitess/go/vt/proto/replicationdata"
 
 import (
 	context "golang.org/x/net/context"
@@ -75,6 +93,144 @@ func (m *RunMysqlUpgradeResponse) Reset()         { *m = RunMysqlUpgradeResponse
 func (m *RunMysqlUpgradeResponse) String() string { return proto.CompactTextString(m) }
 func (*RunMysqlUpgradeResponse) ProtoMessage()    {}
 
+type ReinitConfigRequest struct {
+}
+
+func (m *ReinitConfigRequest) Reset()         { *m = ReinitConfigRequest{} }
+func (m *ReinitConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*ReinitConfigRequest) ProtoMessage()    {}
+
+type ReinitConfigResponse struct {
+}
+
+func (m *ReinitConfigResponse) Reset()         { *m = ReinitConfigResponse{} }
+func (m *ReinitConfigResponse) String() string { return proto.CompactTextString(m) }
+func (*ReinitConfigResponse) ProtoMessage()    {}
+
+type IsReadOnlyRequest struct {
+}
+
+func (m *IsReadOnlyRequest) Reset()         { *m = IsReadOnlyRequest{} }
+func (m *IsReadOnlyRequest) String() string { return proto.CompactTextString(m) }
+func (*IsReadOnlyRequest) ProtoMessage()    {}
+
+type IsReadOnlyResponse struct {
+	ReadOnly bool `protobuf:"varint,1,opt,name=read_only" json:"read_only,omitempty"`
+}
+
+func (m *IsReadOnlyResponse) Reset()         { *m = IsReadOnlyResponse{} }
+func (m *IsReadOnlyResponse) String() string { return proto.CompactTextString(m) }
+func (*IsReadOnlyResponse) ProtoMessage()    {}
+
+type SetReadOnlyRequest struct {
+	ReadOnly bool `protobuf:"varint,1,opt,name=read_only" json:"read_only,omitempty"`
+}
+
+func (m *SetReadOnlyRequest) Reset()         { *m = SetReadOnlyRequest{} }
+func (m *SetReadOnlyRequest) String() string { return proto.CompactTextString(m) }
+func (*SetReadOnlyRequest) ProtoMessage()    {}
+
+type SetReadOnlyResponse struct {
+}
+
+func (m *SetReadOnlyResponse) Reset()         { *m = SetReadOnlyResponse{} }
+func (m *SetReadOnlyResponse) String() string { return proto.CompactTextString(m) }
+func (*SetReadOnlyResponse) ProtoMessage()    {}
+
+type SlaveStatusRequest struct {
+}
+
+func (m *SlaveStatusRequest) Reset()         { *m = SlaveStatusRequest{} }
+func (m *SlaveStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*SlaveStatusRequest) ProtoMessage()    {}
+
+type SlaveStatusResponse struct {
+	Status *replicationdata.Status `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *SlaveStatusResponse) Reset()         { *m = SlaveStatusResponse{} }
+func (m *SlaveStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*SlaveStatusResponse) ProtoMessage()    {}
+
+func (m *SlaveStatusResponse) GetStatus() *replicationdata.Status {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type MasterPositionRequest struct {
+}
+
+func (m *MasterPositionRequest) Reset()         { *m = MasterPositionRequest{} }
+func (m *MasterPositionRequest) String() string { return proto.CompactTextString(m) }
+func (*MasterPositionRequest) ProtoMessage()    {}
+
+type MasterPositionResponse struct {
+	Position string `protobuf:"bytes,1,opt,name=position" json:"position,omitempty"`
+}
+
+func (m *MasterPositionResponse) Reset()         { *m = MasterPositionResponse{} }
+func (m *MasterPositionResponse) String() string { return proto.CompactTextString(m) }
+func (*MasterPositionResponse) ProtoMessage()    {}
+
+type ExecuteSuperQueryListRequest struct {
+	// queries are executed in order, on the same connection.
+	Queries []string `protobuf:"bytes,1,rep,name=queries" json:"queries,omitempty"`
+}
+
+func (m *ExecuteSuperQueryListRequest) Reset()         { *m = ExecuteSuperQueryListRequest{} }
+func (m *ExecuteSuperQueryListRequest) String() string { return proto.CompactTextString(m) }
+func (*ExecuteSuperQueryListRequest) ProtoMessage()    {}
+
+type ExecuteSuperQueryListResponse struct {
+}
+
+func (m *ExecuteSuperQueryListResponse) Reset()         { *m = ExecuteSuperQueryListResponse{} }
+func (m *ExecuteSuperQueryListResponse) String() string { return proto.CompactTextString(m) }
+func (*ExecuteSuperQueryListResponse) ProtoMessage()    {}
+
+type FetchSuperQueryRequest struct {
+	Query string `protobuf:"bytes,1,opt,name=query" json:"query,omitempty"`
+}
+
+func (m *FetchSuperQueryRequest) Reset()         { *m = FetchSuperQueryRequest{} }
+func (m *FetchSuperQueryRequest) String() string { return proto.CompactTextString(m) }
+func (*FetchSuperQueryRequest) ProtoMessage()    {}
+
+type FetchSuperQueryResponse struct {
+	Result *query.QueryResult `protobuf:"bytes,1,opt,name=result" json:"result,omitempty"`
+}
+
+func (m *FetchSuperQueryResponse) Reset()         { *m = FetchSuperQueryResponse{} }
+func (m *FetchSuperQueryResponse) String() string { return proto.CompactTextString(m) }
+func (*FetchSuperQueryResponse) ProtoMessage()    {}
+
+func (m *FetchSuperQueryResponse) GetResult() *query.QueryResult {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+type TailErrorLogRequest struct {
+	// last_bytes is how much of the existing error log to send first.
+	// 0 only sends what is written after the call.
+	LastBytes int64 `protobuf:"varint,1,opt,name=last_bytes" json:"last_bytes,omitempty"`
+}
+
+func (m *TailErrorLogRequest) Reset()         { *m = TailErrorLogRequest{} }
+func (m *TailErrorLogRequest) String() string { return proto.CompactTextString(m) }
+func (*TailErrorLogRequest) ProtoMessage()    {}
+
+type TailErrorLogResponse struct {
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *TailErrorLogResponse) Reset()         { *m = TailErrorLogResponse{} }
+func (m *TailErrorLogResponse) String() string { return proto.CompactTextString(m) }
+func (*TailErrorLogResponse) ProtoMessage()    {}
+
 func init() {
 	proto.RegisterType((*StartRequest)(nil), "mysqlctl.StartRequest")
 	proto.RegisterType((*StartResponse)(nil), "mysqlctl.StartResponse")
@@ -82,6 +238,22 @@ func init() {
 	proto.RegisterType((*ShutdownResponse)(nil), "mysqlctl.ShutdownResponse")
 	proto.RegisterType((*RunMysqlUpgradeRequest)(nil), "mysqlctl.RunMysqlUpgradeRequest")
 	proto.RegisterType((*RunMysqlUpgradeResponse)(nil), "mysqlctl.RunMysqlUpgradeResponse")
+	proto.RegisterType((*ReinitConfigRequest)(nil), "mysqlctl.ReinitConfigRequest")
+	proto.RegisterType((*ReinitConfigResponse)(nil), "mysqlctl.ReinitConfigResponse")
+	proto.RegisterType((*IsReadOnlyRequest)(nil), "mysqlctl.IsReadOnlyRequest")
+	proto.RegisterType((*IsReadOnlyResponse)(nil), "mysqlctl.IsReadOnlyResponse")
+	proto.RegisterType((*SetReadOnlyRequest)(nil), "mysqlctl.SetReadOnlyRequest")
+	proto.RegisterType((*SetReadOnlyResponse)(nil), "mysqlctl.SetReadOnlyResponse")
+	proto.RegisterType((*SlaveStatusRequest)(nil), "mysqlctl.SlaveStatusRequest")
+	proto.RegisterType((*SlaveStatusResponse)(nil), "mysqlctl.SlaveStatusResponse")
+	proto.RegisterType((*MasterPositionRequest)(nil), "mysqlctl.MasterPositionRequest")
+	proto.RegisterType((*MasterPositionResponse)(nil), "mysqlctl.MasterPositionResponse")
+	proto.RegisterType((*ExecuteSuperQueryListRequest)(nil), "mysqlctl.ExecuteSuperQueryListRequest")
+	proto.RegisterType((*ExecuteSuperQueryListResponse)(nil), "mysqlctl.ExecuteSuperQueryListResponse")
+	proto.RegisterType((*FetchSuperQueryRequest)(nil), "mysqlctl.FetchSuperQueryRequest")
+	proto.RegisterType((*FetchSuperQueryResponse)(nil), "mysqlctl.FetchSuperQueryResponse")
+	proto.RegisterType((*TailErrorLogRequest)(nil), "mysqlctl.TailErrorLogRequest")
+	proto.RegisterType((*TailErrorLogResponse)(nil), "mysqlctl.TailErrorLogResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -94,6 +266,14 @@ type MysqlCtlClient interface {
 	Start(ctx context.Context, in *StartRequest, opts ...grpc.CallOption) (*StartResponse, error)
 	Shutdown(ctx context.Context, in *ShutdownRequest, opts ...grpc.CallOption) (*ShutdownResponse, error)
 	RunMysqlUpgrade(ctx context.Context, in *RunMysqlUpgradeRequest, opts ...grpc.CallOption) (*RunMysqlUpgradeResponse, error)
+	ReinitConfig(ctx context.Context, in *ReinitConfigRequest, opts ...grpc.CallOption) (*ReinitConfigResponse, error)
+	IsReadOnly(ctx context.Context, in *IsReadOnlyRequest, opts ...grpc.CallOption) (*IsReadOnlyResponse, error)
+	SetReadOnly(ctx context.Context, in *SetReadOnlyRequest, opts ...grpc.CallOption) (*SetReadOnlyResponse, error)
+	SlaveStatus(ctx context.Context, in *SlaveStatusRequest, opts ...grpc.CallOption) (*SlaveStatusResponse, error)
+	MasterPosition(ctx context.Context, in *MasterPositionRequest, opts ...grpc.CallOption) (*MasterPositionResponse, error)
+	ExecuteSuperQueryList(ctx context.Context, in *ExecuteSuperQueryListRequest, opts ...grpc.CallOption) (*ExecuteSuperQueryListResponse, error)
+	FetchSuperQuery(ctx context.Context, in *FetchSuperQueryRequest, opts ...grpc.CallOption) (*FetchSuperQueryResponse, error)
+	TailErrorLog(ctx context.Context, in *TailErrorLogRequest, opts ...grpc.CallOption) (MysqlCtl_TailErrorLogClient, error)
 }
 
 type mysqlCtlClient struct {
@@ -131,12 +311,115 @@ func (c *mysqlCtlClient) RunMysqlUpgrade(ctx context.Context, in *RunMysqlUpgrad
 	return out, nil
 }
 
+func (c *mysqlCtlClient) ReinitConfig(ctx context.Context, in *ReinitConfigRequest, opts ...grpc.CallOption) (*ReinitConfigResponse, error) {
+	out := new(ReinitConfigResponse)
+	err := grpc.Invoke(ctx, "/mysqlctl.MysqlCtl/ReinitConfig", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mysqlCtlClient) IsReadOnly(ctx context.Context, in *IsReadOnlyRequest, opts ...grpc.CallOption) (*IsReadOnlyResponse, error) {
+	out := new(IsReadOnlyResponse)
+	err := grpc.Invoke(ctx, "/mysqlctl.MysqlCtl/IsReadOnly", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mysqlCtlClient) SetReadOnly(ctx context.Context, in *SetReadOnlyRequest, opts ...grpc.CallOption) (*SetReadOnlyResponse, error) {
+	out := new(SetReadOnlyResponse)
+	err := grpc.Invoke(ctx, "/mysqlctl.MysqlCtl/SetReadOnly", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mysqlCtlClient) SlaveStatus(ctx context.Context, in *SlaveStatusRequest, opts ...grpc.CallOption) (*SlaveStatusResponse, error) {
+	out := new(SlaveStatusResponse)
+	err := grpc.Invoke(ctx, "/mysqlctl.MysqlCtl/SlaveStatus", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mysqlCtlClient) MasterPosition(ctx context.Context, in *MasterPositionRequest, opts ...grpc.CallOption) (*MasterPositionResponse, error) {
+	out := new(MasterPositionResponse)
+	err := grpc.Invoke(ctx, "/mysqlctl.MysqlCtl/MasterPosition", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mysqlCtlClient) ExecuteSuperQueryList(ctx context.Context, in *ExecuteSuperQueryListRequest, opts ...grpc.CallOption) (*ExecuteSuperQueryListResponse, error) {
+	out := new(ExecuteSuperQueryListResponse)
+	err := grpc.Invoke(ctx, "/mysqlctl.MysqlCtl/ExecuteSuperQueryList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mysqlCtlClient) FetchSuperQuery(ctx context.Context, in *FetchSuperQueryRequest, opts ...grpc.CallOption) (*FetchSuperQueryResponse, error) {
+	out := new(FetchSuperQueryResponse)
+	err := grpc.Invoke(ctx, "/mysqlctl.MysqlCtl/FetchSuperQuery", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mysqlCtlClient) TailErrorLog(ctx context.Context, in *TailErrorLogRequest, opts ...grpc.CallOption) (MysqlCtl_TailErrorLogClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_MysqlCtl_serviceDesc.Streams[0], c.cc, "/mysqlctl.MysqlCtl/TailErrorLog", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mysqlCtlTailErrorLogClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MysqlCtl_TailErrorLogClient interface {
+	Recv() (*TailErrorLogResponse, error)
+	grpc.ClientStream
+}
+
+type mysqlCtlTailErrorLogClient struct {
+	grpc.ClientStream
+}
+
+func (x *mysqlCtlTailErrorLogClient) Recv() (*TailErrorLogResponse, error) {
+	m := new(TailErrorLogResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for MysqlCtl service
 
 type MysqlCtlServer interface {
 	Start(context.Context, *StartRequest) (*StartResponse, error)
 	Shutdown(context.Context, *ShutdownRequest) (*ShutdownResponse, error)
 	RunMysqlUpgrade(context.Context, *RunMysqlUpgradeRequest) (*RunMysqlUpgradeResponse, error)
+	ReinitConfig(context.Context, *ReinitConfigRequest) (*ReinitConfigResponse, error)
+	IsReadOnly(context.Context, *IsReadOnlyRequest) (*IsReadOnlyResponse, error)
+	SetReadOnly(context.Context, *SetReadOnlyRequest) (*SetReadOnlyResponse, error)
+	SlaveStatus(context.Context, *SlaveStatusRequest) (*SlaveStatusResponse, error)
+	MasterPosition(context.Context, *MasterPositionRequest) (*MasterPositionResponse, error)
+	ExecuteSuperQueryList(context.Context, *ExecuteSuperQueryListRequest) (*ExecuteSuperQueryListResponse, error)
+	FetchSuperQuery(context.Context, *FetchSuperQueryRequest) (*FetchSuperQueryResponse, error)
+	TailErrorLog(*TailErrorLogRequest, MysqlCtl_TailErrorLogServer) error
 }
 
 func RegisterMysqlCtlServer(s *grpc.Server, srv MysqlCtlServer) {
@@ -179,6 +462,111 @@ func _MysqlCtl_RunMysqlUpgrade_Handler(srv interface{}, ctx context.Context, dec
 	return out, nil
 }
 
+func _MysqlCtl_ReinitConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(ReinitConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(MysqlCtlServer).ReinitConfig(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _MysqlCtl_IsReadOnly_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(IsReadOnlyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(MysqlCtlServer).IsReadOnly(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _MysqlCtl_SetReadOnly_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(SetReadOnlyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(MysqlCtlServer).SetReadOnly(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _MysqlCtl_SlaveStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(SlaveStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(MysqlCtlServer).SlaveStatus(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _MysqlCtl_MasterPosition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(MasterPositionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(MysqlCtlServer).MasterPosition(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _MysqlCtl_ExecuteSuperQueryList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(ExecuteSuperQueryListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(MysqlCtlServer).ExecuteSuperQueryList(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _MysqlCtl_FetchSuperQuery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(FetchSuperQueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(MysqlCtlServer).FetchSuperQuery(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _MysqlCtl_TailErrorLog_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailErrorLogRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MysqlCtlServer).TailErrorLog(m, &mysqlCtlTailErrorLogServer{stream})
+}
+
+type MysqlCtl_TailErrorLogServer interface {
+	Send(*TailErrorLogResponse) error
+	grpc.ServerStream
+}
+
+type mysqlCtlTailErrorLogServer struct {
+	grpc.ServerStream
+}
+
+func (x *mysqlCtlTailErrorLogServer) Send(m *TailErrorLogResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _MysqlCtl_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "mysqlctl.MysqlCtl",
 	HandlerType: (*MysqlCtlServer)(nil),
@@ -195,6 +583,40 @@ var _MysqlCtl_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RunMysqlUpgrade",
 			Handler:    _MysqlCtl_RunMysqlUpgrade_Handler,
 		},
+		{
+			MethodName: "ReinitConfig",
+			Handler:    _MysqlCtl_ReinitConfig_Handler,
+		},
+		{
+			MethodName: "IsReadOnly",
+			Handler:    _MysqlCtl_IsReadOnly_Handler,
+		},
+		{
+			MethodName: "SetReadOnly",
+			Handler:    _MysqlCtl_SetReadOnly_Handler,
+		},
+		{
+			MethodName: "SlaveStatus",
+			Handler:    _MysqlCtl_SlaveStatus_Handler,
+		},
+		{
+			MethodName: "MasterPosition",
+			Handler:    _MysqlCtl_MasterPosition_Handler,
+		},
+		{
+			MethodName: "ExecuteSuperQueryList",
+			Handler:    _MysqlCtl_ExecuteSuperQueryList_Handler,
+		},
+		{
+			MethodName: "FetchSuperQuery",
+			Handler:    _MysqlCtl_FetchSuperQuery_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TailErrorLog",
+			Handler:       _MysqlCtl_TailErrorLog_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams: []grpc.StreamDesc{},
 }
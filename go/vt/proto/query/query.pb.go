@@ -834,6 +834,16 @@ type RealtimeStats struct {
 	SecondsBehindMasterFilteredReplication int64 `protobuf:"varint,4,opt,name=seconds_behind_master_filtered_replication" json:"seconds_behind_master_filtered_replication,omitempty"`
 	// cpu_usage is used for load-based balancing
 	CpuUsage float64 `protobuf:"fixed64,5,opt,name=cpu_usage" json:"cpu_usage,omitempty"`
+	// semi_sync_active is only populated when semi-sync replication is
+	// enabled. For a master, it is true if commits wait for a slave
+	// acknowledgement (semi-sync did not fall back to asynchronous
+	// replication). For a slave, it is true if it acknowledges the
+	// transactions it receives.
+	SemiSyncActive bool `protobuf:"varint,6,opt,name=semi_sync_active" json:"semi_sync_active,omitempty"`
+	// semi_sync_master_clients is populated for masters only, when
+	// semi-sync replication is enabled. It is the number of connected
+	// semi-sync slaves.
+	SemiSyncMasterClients int32 `protobuf:"varint,7,opt,name=semi_sync_master_clients" json:"semi_sync_master_clients,omitempty"`
 }
 
 func (m *RealtimeStats) Reset()         { *m = RealtimeStats{} }
@@ -6,9 +6,11 @@
 Package replicationdata is a generated protocol buffer package.
 
 It is generated from these files:
+
 	replicationdata.proto
 
 It has these top-level messages:
+
 	Status
 */
 package replicationdata
@@ -32,6 +34,9 @@ type Status struct {
 	MasterHost          string `protobuf:"bytes,5,opt,name=master_host" json:"master_host,omitempty"`
 	MasterPort          int32  `protobuf:"varint,6,opt,name=master_port" json:"master_port,omitempty"`
 	MasterConnectRetry  int32  `protobuf:"varint,7,opt,name=master_connect_retry" json:"master_connect_retry,omitempty"`
+	// semi_sync_slave_active is true if the slave is acknowledging the
+	// transactions it receives as a semi-sync slave.
+	SemiSyncSlaveActive bool `protobuf:"varint,8,opt,name=semi_sync_slave_active" json:"semi_sync_slave_active,omitempty"`
 }
 
 func (m *Status) Reset()         { *m = Status{} }
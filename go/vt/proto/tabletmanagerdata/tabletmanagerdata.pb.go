@@ -56,6 +56,8 @@ It has these top-level messages:
 	StopSlaveMinimumResponse
 	StartSlaveRequest
 	StartSlaveResponse
+	StartSlaveUntilAfterRequest
+	StartSlaveUntilAfterResponse
 	TabletExternallyReparentedRequest
 	TabletExternallyReparentedResponse
 	TabletExternallyElectedRequest
@@ -94,6 +96,10 @@ It has these top-level messages:
 	PromoteSlaveResponse
 	BackupRequest
 	BackupResponse
+	RestoreToPointInTimeRequest
+	RestoreToPointInTimeResponse
+	VerifyBackupRequest
+	VerifyBackupResponse
 */
 package tabletmanagerdata
 
@@ -658,6 +664,22 @@ func (m *StartSlaveResponse) Reset()         { *m = StartSlaveResponse{} }
 func (m *StartSlaveResponse) String() string { return proto.CompactTextString(m) }
 func (*StartSlaveResponse) ProtoMessage()    {}
 
+type StartSlaveUntilAfterRequest struct {
+	Position    string `protobuf:"bytes,1,opt,name=position" json:"position,omitempty"`
+	WaitTimeout int64  `protobuf:"varint,2,opt,name=wait_timeout" json:"wait_timeout,omitempty"`
+}
+
+func (m *StartSlaveUntilAfterRequest) Reset()         { *m = StartSlaveUntilAfterRequest{} }
+func (m *StartSlaveUntilAfterRequest) String() string { return proto.CompactTextString(m) }
+func (*StartSlaveUntilAfterRequest) ProtoMessage()    {}
+
+type StartSlaveUntilAfterResponse struct {
+}
+
+func (m *StartSlaveUntilAfterResponse) Reset()         { *m = StartSlaveUntilAfterResponse{} }
+func (m *StartSlaveUntilAfterResponse) String() string { return proto.CompactTextString(m) }
+func (*StartSlaveUntilAfterResponse) ProtoMessage()    {}
+
 type TabletExternallyReparentedRequest struct {
 	// external_id is an string value that may be provided by an external
 	// agent for tracking purposes. The tablet will emit this string in
@@ -997,6 +1019,8 @@ func (*PromoteSlaveResponse) ProtoMessage()    {}
 
 type BackupRequest struct {
 	Concurrency int64 `protobuf:"varint,1,opt,name=concurrency" json:"concurrency,omitempty"`
+	// incremental backups only store the binlogs since the last backup
+	Incremental bool `protobuf:"varint,2,opt,name=incremental" json:"incremental,omitempty"`
 }
 
 func (m *BackupRequest) Reset()         { *m = BackupRequest{} }
@@ -1018,6 +1042,56 @@ func (m *BackupResponse) GetEvent() *logutil.Event {
 	return nil
 }
 
+type RestoreToPointInTimeRequest struct {
+	// only one of position or time is set
+	Position string `protobuf:"bytes,1,opt,name=position" json:"position,omitempty"`
+	// time is in seconds since the epoch
+	Time int64 `protobuf:"varint,2,opt,name=time" json:"time,omitempty"`
+}
+
+func (m *RestoreToPointInTimeRequest) Reset()         { *m = RestoreToPointInTimeRequest{} }
+func (m *RestoreToPointInTimeRequest) String() string { return proto.CompactTextString(m) }
+func (*RestoreToPointInTimeRequest) ProtoMessage()    {}
+
+type RestoreToPointInTimeResponse struct {
+	Event *logutil.Event `protobuf:"bytes,1,opt,name=event" json:"event,omitempty"`
+}
+
+func (m *RestoreToPointInTimeResponse) Reset()         { *m = RestoreToPointInTimeResponse{} }
+func (m *RestoreToPointInTimeResponse) String() string { return proto.CompactTextString(m) }
+func (*RestoreToPointInTimeResponse) ProtoMessage()    {}
+
+func (m *RestoreToPointInTimeResponse) GetEvent() *logutil.Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+type VerifyBackupRequest struct {
+	// name of the full backup to verify
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *VerifyBackupRequest) Reset()         { *m = VerifyBackupRequest{} }
+func (m *VerifyBackupRequest) String() string { return proto.CompactTextString(m) }
+func (*VerifyBackupRequest) ProtoMessage()    {}
+
+type VerifyBackupResponse struct {
+	Event *logutil.Event `protobuf:"bytes,1,opt,name=event" json:"event,omitempty"`
+}
+
+func (m *VerifyBackupResponse) Reset()         { *m = VerifyBackupResponse{} }
+func (m *VerifyBackupResponse) String() string { return proto.CompactTextString(m) }
+func (*VerifyBackupResponse) ProtoMessage()    {}
+
+func (m *VerifyBackupResponse) GetEvent() *logutil.Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*TableDefinition)(nil), "tabletmanagerdata.TableDefinition")
 	proto.RegisterType((*SchemaDefinition)(nil), "tabletmanagerdata.SchemaDefinition")
@@ -1066,6 +1140,8 @@ func init() {
 	proto.RegisterType((*StopSlaveMinimumResponse)(nil), "tabletmanagerdata.StopSlaveMinimumResponse")
 	proto.RegisterType((*StartSlaveRequest)(nil), "tabletmanagerdata.StartSlaveRequest")
 	proto.RegisterType((*StartSlaveResponse)(nil), "tabletmanagerdata.StartSlaveResponse")
+	proto.RegisterType((*StartSlaveUntilAfterRequest)(nil), "tabletmanagerdata.StartSlaveUntilAfterRequest")
+	proto.RegisterType((*StartSlaveUntilAfterResponse)(nil), "tabletmanagerdata.StartSlaveUntilAfterResponse")
 	proto.RegisterType((*TabletExternallyReparentedRequest)(nil), "tabletmanagerdata.TabletExternallyReparentedRequest")
 	proto.RegisterType((*TabletExternallyReparentedResponse)(nil), "tabletmanagerdata.TabletExternallyReparentedResponse")
 	proto.RegisterType((*TabletExternallyElectedRequest)(nil), "tabletmanagerdata.TabletExternallyElectedRequest")
@@ -1104,4 +1180,8 @@ func init() {
 	proto.RegisterType((*PromoteSlaveResponse)(nil), "tabletmanagerdata.PromoteSlaveResponse")
 	proto.RegisterType((*BackupRequest)(nil), "tabletmanagerdata.BackupRequest")
 	proto.RegisterType((*BackupResponse)(nil), "tabletmanagerdata.BackupResponse")
+	proto.RegisterType((*RestoreToPointInTimeRequest)(nil), "tabletmanagerdata.RestoreToPointInTimeRequest")
+	proto.RegisterType((*RestoreToPointInTimeResponse)(nil), "tabletmanagerdata.RestoreToPointInTimeResponse")
+	proto.RegisterType((*VerifyBackupRequest)(nil), "tabletmanagerdata.VerifyBackupRequest")
+	proto.RegisterType((*VerifyBackupResponse)(nil), "tabletmanagerdata.VerifyBackupResponse")
 }
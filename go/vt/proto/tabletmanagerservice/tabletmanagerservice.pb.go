@@ -66,6 +66,9 @@ type TabletManagerClient interface {
 	StopSlaveMinimum(ctx context.Context, in *tabletmanagerdata.StopSlaveMinimumRequest, opts ...grpc.CallOption) (*tabletmanagerdata.StopSlaveMinimumResponse, error)
 	// StartSlave starts the mysql replication
 	StartSlave(ctx context.Context, in *tabletmanagerdata.StartSlaveRequest, opts ...grpc.CallOption) (*tabletmanagerdata.StartSlaveResponse, error)
+	// StartSlaveUntilAfter starts the mysql replication until and
+	// including the provided position, and waits for it to be reached
+	StartSlaveUntilAfter(ctx context.Context, in *tabletmanagerdata.StartSlaveUntilAfterRequest, opts ...grpc.CallOption) (*tabletmanagerdata.StartSlaveUntilAfterResponse, error)
 	// TabletExternallyReparented tells a tablet that its underlying MySQL is
 	// currently the master. It is only used in environments (tabletmanagerdata.such as Vitess+MoB)
 	// in which MySQL is reparented by some agent external to Vitess, and then
@@ -130,6 +133,13 @@ type TabletManagerClient interface {
 	// PromoteSlave makes the slave the new master
 	PromoteSlave(ctx context.Context, in *tabletmanagerdata.PromoteSlaveRequest, opts ...grpc.CallOption) (*tabletmanagerdata.PromoteSlaveResponse, error)
 	Backup(ctx context.Context, in *tabletmanagerdata.BackupRequest, opts ...grpc.CallOption) (TabletManager_BackupClient, error)
+	// RestoreToPointInTime restores the most recent full backup before the
+	// target, and replays the binlogs of the incremental backups up to it.
+	RestoreToPointInTime(ctx context.Context, in *tabletmanagerdata.RestoreToPointInTimeRequest, opts ...grpc.CallOption) (TabletManager_RestoreToPointInTimeClient, error)
+
+	// VerifyBackup restores a backup into a scratch mysqld, and checks
+	// its tables.
+	VerifyBackup(ctx context.Context, in *tabletmanagerdata.VerifyBackupRequest, opts ...grpc.CallOption) (TabletManager_VerifyBackupClient, error)
 }
 
 type tabletManagerClient struct {
@@ -320,6 +330,15 @@ func (c *tabletManagerClient) StartSlave(ctx context.Context, in *tabletmanagerd
 	return out, nil
 }
 
+func (c *tabletManagerClient) StartSlaveUntilAfter(ctx context.Context, in *tabletmanagerdata.StartSlaveUntilAfterRequest, opts ...grpc.CallOption) (*tabletmanagerdata.StartSlaveUntilAfterResponse, error) {
+	out := new(tabletmanagerdata.StartSlaveUntilAfterResponse)
+	err := grpc.Invoke(ctx, "/tabletmanagerservice.TabletManager/StartSlaveUntilAfter", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *tabletManagerClient) TabletExternallyReparented(ctx context.Context, in *tabletmanagerdata.TabletExternallyReparentedRequest, opts ...grpc.CallOption) (*tabletmanagerdata.TabletExternallyReparentedResponse, error) {
 	out := new(tabletmanagerdata.TabletExternallyReparentedResponse)
 	err := grpc.Invoke(ctx, "/tabletmanagerservice.TabletManager/TabletExternallyReparented", in, out, c.cc, opts...)
@@ -514,6 +533,70 @@ func (x *tabletManagerBackupClient) Recv() (*tabletmanagerdata.BackupResponse, e
 	return m, nil
 }
 
+func (c *tabletManagerClient) RestoreToPointInTime(ctx context.Context, in *tabletmanagerdata.RestoreToPointInTimeRequest, opts ...grpc.CallOption) (TabletManager_RestoreToPointInTimeClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_TabletManager_serviceDesc.Streams[1], c.cc, "/tabletmanagerservice.TabletManager/RestoreToPointInTime", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tabletManagerRestoreToPointInTimeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type TabletManager_RestoreToPointInTimeClient interface {
+	Recv() (*tabletmanagerdata.RestoreToPointInTimeResponse, error)
+	grpc.ClientStream
+}
+
+type tabletManagerRestoreToPointInTimeClient struct {
+	grpc.ClientStream
+}
+
+func (x *tabletManagerRestoreToPointInTimeClient) Recv() (*tabletmanagerdata.RestoreToPointInTimeResponse, error) {
+	m := new(tabletmanagerdata.RestoreToPointInTimeResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *tabletManagerClient) VerifyBackup(ctx context.Context, in *tabletmanagerdata.VerifyBackupRequest, opts ...grpc.CallOption) (TabletManager_VerifyBackupClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_TabletManager_serviceDesc.Streams[2], c.cc, "/tabletmanagerservice.TabletManager/VerifyBackup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tabletManagerVerifyBackupClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type TabletManager_VerifyBackupClient interface {
+	Recv() (*tabletmanagerdata.VerifyBackupResponse, error)
+	grpc.ClientStream
+}
+
+type tabletManagerVerifyBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *tabletManagerVerifyBackupClient) Recv() (*tabletmanagerdata.VerifyBackupResponse, error) {
+	m := new(tabletmanagerdata.VerifyBackupResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for TabletManager service
 
 type TabletManagerServer interface {
@@ -549,6 +632,9 @@ type TabletManagerServer interface {
 	StopSlaveMinimum(context.Context, *tabletmanagerdata.StopSlaveMinimumRequest) (*tabletmanagerdata.StopSlaveMinimumResponse, error)
 	// StartSlave starts the mysql replication
 	StartSlave(context.Context, *tabletmanagerdata.StartSlaveRequest) (*tabletmanagerdata.StartSlaveResponse, error)
+	// StartSlaveUntilAfter starts the mysql replication until and
+	// including the provided position, and waits for it to be reached
+	StartSlaveUntilAfter(context.Context, *tabletmanagerdata.StartSlaveUntilAfterRequest) (*tabletmanagerdata.StartSlaveUntilAfterResponse, error)
 	// TabletExternallyReparented tells a tablet that its underlying MySQL is
 	// currently the master. It is only used in environments (tabletmanagerdata.such as Vitess+MoB)
 	// in which MySQL is reparented by some agent external to Vitess, and then
@@ -613,6 +699,13 @@ type TabletManagerServer interface {
 	// PromoteSlave makes the slave the new master
 	PromoteSlave(context.Context, *tabletmanagerdata.PromoteSlaveRequest) (*tabletmanagerdata.PromoteSlaveResponse, error)
 	Backup(*tabletmanagerdata.BackupRequest, TabletManager_BackupServer) error
+	// RestoreToPointInTime restores the most recent full backup before the
+	// target, and replays the binlogs of the incremental backups up to it.
+	RestoreToPointInTime(*tabletmanagerdata.RestoreToPointInTimeRequest, TabletManager_RestoreToPointInTimeServer) error
+
+	// VerifyBackup restores a backup into a scratch mysqld, and checks
+	// its tables.
+	VerifyBackup(*tabletmanagerdata.VerifyBackupRequest, TabletManager_VerifyBackupServer) error
 }
 
 func RegisterTabletManagerServer(s *grpc.Server, srv TabletManagerServer) {
@@ -859,6 +952,18 @@ func _TabletManager_StartSlave_Handler(srv interface{}, ctx context.Context, dec
 	return out, nil
 }
 
+func _TabletManager_StartSlaveUntilAfter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(tabletmanagerdata.StartSlaveUntilAfterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(TabletManagerServer).StartSlaveUntilAfter(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func _TabletManager_TabletExternallyReparented_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
 	in := new(tabletmanagerdata.TabletExternallyReparentedRequest)
 	if err := dec(in); err != nil {
@@ -1096,6 +1201,48 @@ func (x *tabletManagerBackupServer) Send(m *tabletmanagerdata.BackupResponse) er
 	return x.ServerStream.SendMsg(m)
 }
 
+func _TabletManager_RestoreToPointInTime_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(tabletmanagerdata.RestoreToPointInTimeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TabletManagerServer).RestoreToPointInTime(m, &tabletManagerRestoreToPointInTimeServer{stream})
+}
+
+type TabletManager_RestoreToPointInTimeServer interface {
+	Send(*tabletmanagerdata.RestoreToPointInTimeResponse) error
+	grpc.ServerStream
+}
+
+type tabletManagerRestoreToPointInTimeServer struct {
+	grpc.ServerStream
+}
+
+func (x *tabletManagerRestoreToPointInTimeServer) Send(m *tabletmanagerdata.RestoreToPointInTimeResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _TabletManager_VerifyBackup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(tabletmanagerdata.VerifyBackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TabletManagerServer).VerifyBackup(m, &tabletManagerVerifyBackupServer{stream})
+}
+
+type TabletManager_VerifyBackupServer interface {
+	Send(*tabletmanagerdata.VerifyBackupResponse) error
+	grpc.ServerStream
+}
+
+type tabletManagerVerifyBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *tabletManagerVerifyBackupServer) Send(m *tabletmanagerdata.VerifyBackupResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _TabletManager_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "tabletmanagerservice.TabletManager",
 	HandlerType: (*TabletManagerServer)(nil),
@@ -1180,6 +1327,10 @@ var _TabletManager_serviceDesc = grpc.ServiceDesc{
 			MethodName: "StartSlave",
 			Handler:    _TabletManager_StartSlave_Handler,
 		},
+		{
+			MethodName: "StartSlaveUntilAfter",
+			Handler:    _TabletManager_StartSlaveUntilAfter_Handler,
+		},
 		{
 			MethodName: "TabletExternallyReparented",
 			Handler:    _TabletManager_TabletExternallyReparented_Handler,
@@ -1259,5 +1410,15 @@ var _TabletManager_serviceDesc = grpc.ServiceDesc{
 			Handler:       _TabletManager_Backup_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "RestoreToPointInTime",
+			Handler:       _TabletManager_RestoreToPointInTime_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "VerifyBackup",
+			Handler:       _TabletManager_VerifyBackup_Handler,
+			ServerStreams: true,
+		},
 	},
 }
@@ -12,6 +12,7 @@ It has these top-level messages:
 	KeyRange
 	TabletAlias
 	Tablet
+	TableTransform
 	Shard
 	Keyspace
 	ShardReplication
@@ -198,6 +199,34 @@ func (m *Tablet) GetHealthMap() map[string]string {
 	return nil
 }
 
+// TableTransform describes how filtered replication changes the
+// statements of a source table before applying them on the destination.
+type TableTransform struct {
+	// the source table the transform applies to
+	Table string `protobuf:"bytes,1,opt,name=table" json:"table,omitempty"`
+	// the destination table, if it is renamed
+	TargetTable string `protobuf:"bytes,2,opt,name=target_table" json:"target_table,omitempty"`
+	// the source columns which are not replicated
+	DroppedColumns []string `protobuf:"bytes,3,rep,name=dropped_columns" json:"dropped_columns,omitempty"`
+	// the source columns which are renamed, indexed by source name
+	RenamedColumns map[string]string `protobuf:"bytes,4,rep,name=renamed_columns" json:"renamed_columns,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// if set, only the rows whose filter_column value is one of
+	// filter_values are replicated. The column is a source column.
+	FilterColumn string   `protobuf:"bytes,5,opt,name=filter_column" json:"filter_column,omitempty"`
+	FilterValues []string `protobuf:"bytes,6,rep,name=filter_values" json:"filter_values,omitempty"`
+}
+
+func (m *TableTransform) Reset()         { *m = TableTransform{} }
+func (m *TableTransform) String() string { return proto.CompactTextString(m) }
+func (*TableTransform) ProtoMessage()    {}
+
+func (m *TableTransform) GetRenamedColumns() map[string]string {
+	if m != nil {
+		return m.RenamedColumns
+	}
+	return nil
+}
+
 // A Shard contains data about a subset of the data whithin a keyspace.
 type Shard struct {
 	// master_alias is the tablet alias of the master for the shard.
@@ -283,6 +312,8 @@ type Shard_SourceShard struct {
 	KeyRange *KeyRange `protobuf:"bytes,4,opt,name=key_range" json:"key_range,omitempty"`
 	// the source table list to replicate
 	Tables []string `protobuf:"bytes,5,rep,name=tables" json:"tables,omitempty"`
+	// the transforms applied to the replicated statements
+	Transforms []*TableTransform `protobuf:"bytes,6,rep,name=transforms" json:"transforms,omitempty"`
 }
 
 func (m *Shard_SourceShard) Reset()         { *m = Shard_SourceShard{} }
@@ -296,6 +327,13 @@ func (m *Shard_SourceShard) GetKeyRange() *KeyRange {
 	return nil
 }
 
+func (m *Shard_SourceShard) GetTransforms() []*TableTransform {
+	if m != nil {
+		return m.Transforms
+	}
+	return nil
+}
+
 // TabletControl controls tablet's behavior
 type Shard_TabletControl struct {
 	// which tablet type is affected
@@ -535,6 +573,7 @@ func init() {
 	proto.RegisterType((*KeyRange)(nil), "topodata.KeyRange")
 	proto.RegisterType((*TabletAlias)(nil), "topodata.TabletAlias")
 	proto.RegisterType((*Tablet)(nil), "topodata.Tablet")
+	proto.RegisterType((*TableTransform)(nil), "topodata.TableTransform")
 	proto.RegisterType((*Shard)(nil), "topodata.Shard")
 	proto.RegisterType((*Shard_ServedType)(nil), "topodata.Shard.ServedType")
 	proto.RegisterType((*Shard_SourceShard)(nil), "topodata.Shard.SourceShard")
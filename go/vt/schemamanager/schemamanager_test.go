@@ -228,6 +228,7 @@ func newFakeTabletManagerClient() *fakeTabletManagerClient {
 type fakeTabletManagerClient struct {
 	tmclient.TabletManagerClient
 	EnableExecuteFetchAsDbaError bool
+	disableBinlogs               []bool
 	preflightSchemas             map[string]*proto.SchemaChangeResult
 	schemaDefinitions            map[string]*proto.SchemaDefinition
 }
@@ -264,6 +265,7 @@ func (client *fakeTabletManagerClient) ExecuteFetchAsDba(ctx context.Context, ta
 		var result mproto.QueryResult
 		return &result, fmt.Errorf("ExecuteFetchAsDba occur an unknown error")
 	}
+	client.disableBinlogs = append(client.disableBinlogs, disableBinlogs)
 	return client.TabletManagerClient.ExecuteFetchAsDba(ctx, tablet, query, maxRows, wantFields, disableBinlogs, reloadSchema)
 }
 
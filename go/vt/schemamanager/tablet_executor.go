@@ -24,6 +24,10 @@ type TabletExecutor struct {
 	tabletInfos []*topo.TabletInfo
 	schemaDiffs []*proto.SchemaChangeResult
 	isClosed    bool
+
+	// disableBinlogs is set when the changes are applied to
+	// individual tablets, so they don't replicate.
+	disableBinlogs bool
 }
 
 // NewTabletExecutor creates a new TabletExecutor instance
@@ -69,6 +73,26 @@ func (exec *TabletExecutor) Open(ctx context.Context, keyspace string) error {
 	if len(exec.tabletInfos) == 0 {
 		return fmt.Errorf("keyspace: %s does not contain any master tablets", keyspace)
 	}
+	exec.disableBinlogs = false
+	exec.isClosed = false
+	return nil
+}
+
+// OpenTablets makes the executor apply the schema changes to the
+// given tablets instead of the shard masters, with binlogs disabled
+// so the changes don't replicate. This is used to fix the tablets
+// whose schema drifted, for instance replicas that missed a DDL.
+// The first tablet is used for the validation and preflight checks,
+// so all the tablets are expected to have the same schema.
+func (exec *TabletExecutor) OpenTablets(tabletInfos []*topo.TabletInfo) error {
+	if !exec.isClosed {
+		return fmt.Errorf("executor is already open")
+	}
+	if len(tabletInfos) == 0 {
+		return fmt.Errorf("no tablet to apply the schema changes to")
+	}
+	exec.tabletInfos = tabletInfos
+	exec.disableBinlogs = true
 	exec.isClosed = false
 	return nil
 }
@@ -208,7 +232,7 @@ func (exec *TabletExecutor) executeOneTablet(
 	errChan chan ShardWithError,
 	successChan chan ShardResult) {
 	defer wg.Done()
-	result, err := exec.tmClient.ExecuteFetchAsDba(ctx, tabletInfo, sql, 10, false, false, true)
+	result, err := exec.tmClient.ExecuteFetchAsDba(ctx, tabletInfo, sql, 10, false, exec.disableBinlogs, true)
 	if err != nil {
 		errChan <- ShardWithError{Shard: tabletInfo.Shard, Err: err.Error()}
 	} else {
@@ -8,7 +8,10 @@ import (
 	"testing"
 
 	"github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/topo"
 	"golang.org/x/net/context"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
 )
 
 func TestTabletExecutorOpen(t *testing.T) {
@@ -134,3 +137,53 @@ func TestTabletExecutorExecute(t *testing.T) {
 		t.Fatalf("execute should fail, ddl does not introduce any table schema change")
 	}
 }
+
+func TestTabletExecutorOpenTablets(t *testing.T) {
+	fakeTmc := newFakeTabletManagerClient()
+	sql := "ALTER TABLE test_table ADD COLUMN msg varchar(64)"
+	fakeTmc.AddSchemaChange(sql, &proto.SchemaChangeResult{
+		BeforeSchema: &proto.SchemaDefinition{
+			TableDefinitions: []*proto.TableDefinition{
+				&proto.TableDefinition{
+					Name:   "test_table",
+					Schema: "CREATE TABLE test_table (pk int)",
+					Type:   proto.TableBaseTable,
+				},
+			},
+		},
+		AfterSchema: &proto.SchemaDefinition{
+			TableDefinitions: []*proto.TableDefinition{
+				&proto.TableDefinition{
+					Name:   "test_table",
+					Schema: "CREATE TABLE test_table (pk int, msg varchar(64))",
+					Type:   proto.TableBaseTable,
+				},
+			},
+		},
+	})
+	fakeTmc.AddSchemaDefinition("vt_test_keyspace", &proto.SchemaDefinition{})
+	executor := NewTabletExecutor(fakeTmc, newFakeTopo())
+	ctx := context.Background()
+
+	if err := executor.OpenTablets(nil); err == nil {
+		t.Fatalf("executor.OpenTablets(nil) = nil, want error")
+	}
+	tablets := []*topo.TabletInfo{
+		topo.NewTabletInfo(&pb.Tablet{Alias: &pb.TabletAlias{Cell: "cell1", Uid: 1}, Keyspace: "test_keyspace", Shard: "0"}, 0),
+		topo.NewTabletInfo(&pb.Tablet{Alias: &pb.TabletAlias{Cell: "cell1", Uid: 2}, Keyspace: "test_keyspace", Shard: "0"}, 0),
+	}
+	if err := executor.OpenTablets(tablets); err != nil {
+		t.Fatalf("executor.OpenTablets() failed: %v", err)
+	}
+	defer executor.Close()
+	if err := executor.Validate(ctx, []string{sql}); err != nil {
+		t.Fatalf("executor.Validate() failed: %v", err)
+	}
+	result := executor.Execute(ctx, []string{sql})
+	if result.ExecutorErr != "" || len(result.FailedShards) != 0 || len(result.SuccessShards) != 2 {
+		t.Fatalf("executor.Execute() = %+v, want success on 2 tablets", result)
+	}
+	if len(fakeTmc.disableBinlogs) != 2 || !fakeTmc.disableBinlogs[0] || !fakeTmc.disableBinlogs[1] {
+		t.Errorf("the changes should be applied with binlogs disabled: %v", fakeTmc.disableBinlogs)
+	}
+}
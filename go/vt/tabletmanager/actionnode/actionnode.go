@@ -100,6 +100,10 @@ const (
 	// TabletActionStartSlave will start MySQL replication.
 	TabletActionStartSlave = "StartSlave"
 
+	// TabletActionStartSlaveUntilAfter will start MySQL replication
+	// until it reaches a point, and wait for it.
+	TabletActionStartSlaveUntilAfter = "StartSlaveUntilAfter"
+
 	// TabletActionExternallyReparented is sent directly to the new master
 	// tablet when it becomes the master. It is functionnaly equivalent
 	// to calling "ShardExternallyReparented" on the topology.
@@ -161,6 +165,14 @@ const (
 	// TabletActionBackup takes a db backup and stores it into BackupStorage
 	TabletActionBackup = "Backup"
 
+	// TabletActionRestoreToPointInTime restores a backup up to a
+	// position or a time
+	TabletActionRestoreToPointInTime = "RestoreToPointInTime"
+
+	// TabletActionVerifyBackup restores a backup into a scratch
+	// mysqld and checks it
+	TabletActionVerifyBackup = "VerifyBackup"
+
 	//
 	// Shard actions - involve all tablets in a shard.
 	// These are just descriptive and used for locking / logging.
@@ -204,6 +216,10 @@ const (
 	// KeyspaceActionApplySchema applies a schema change on the keyspace
 	KeyspaceActionApplySchema = "ApplySchemaKeyspace"
 
+	// KeyspaceActionReconcileSchema fixes the schema drift of the
+	// tablets of the keyspace
+	KeyspaceActionReconcileSchema = "ReconcileSchemaKeyspace"
+
 	// KeyspaceActionSetShardingInfo updates the sharding info
 	KeyspaceActionSetShardingInfo = "SetKeyspaceShardingInfo"
 
@@ -168,6 +168,13 @@ func ApplySchemaKeyspace(change string) *ActionNode {
 	}).SetGuid()
 }
 
+// ReconcileSchemaKeyspace returns an ActionNode
+func ReconcileSchemaKeyspace() *ActionNode {
+	return (&ActionNode{
+		Action: KeyspaceActionReconcileSchema,
+	}).SetGuid()
+}
+
 // MigrateServedFrom returns an ActionNode
 func MigrateServedFrom(servedType pb.TabletType) *ActionNode {
 	return (&ActionNode{
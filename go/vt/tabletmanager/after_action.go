@@ -73,6 +73,8 @@ func (agent *ActionAgent) broadcastHealth() {
 	replicationDelay := agent._replicationDelay
 	healthError := agent._healthy
 	terTime := agent._tabletExternallyReparentedTime
+	semiSyncStatus := agent._semiSyncStatus
+	isMaster := agent._tablet != nil && agent._tablet.Type == pbt.TabletType_MASTER
 	agent.mutex.Unlock()
 
 	// send it to our observers
@@ -80,6 +82,12 @@ func (agent *ActionAgent) broadcastHealth() {
 	stats := &pb.RealtimeStats{
 		SecondsBehindMaster: uint32(replicationDelay.Seconds()),
 	}
+	if isMaster {
+		stats.SemiSyncActive = semiSyncStatus.MasterActive
+		stats.SemiSyncMasterClients = int32(semiSyncStatus.MasterClients)
+	} else {
+		stats.SemiSyncActive = semiSyncStatus.SlaveActive
+	}
 	if agent.BinlogPlayerMap != nil {
 		stats.SecondsBehindMasterFilteredReplication, stats.BinlogPlayersCount = agent.BinlogPlayerMap.StatusSummary()
 	}
@@ -162,6 +170,8 @@ func (agent *ActionAgent) updateState(ctx context.Context, oldTablet *pbt.Tablet
 // It owns starting and stopping the update stream service.
 //
 // It owns reading the TabletControl for the current tablet, and storing it.
+//
+// It owns starting and stopping the heartbeat writer.
 func (agent *ActionAgent) changeCallback(ctx context.Context, oldTablet, newTablet *pbt.Tablet) error {
 	span := trace.NewSpanFromContext(ctx)
 	span.StartLocal("ActionAgent.changeCallback")
@@ -229,6 +239,9 @@ func (agent *ActionAgent) changeCallback(ctx context.Context, oldTablet, newTabl
 		agent.UpdateStream.Disable()
 	}
 
+	// only the master writes the heartbeat
+	agent.fixHeartbeat(newTablet.Type)
+
 	// upate the stats to our current type
 	if agent.exportStats {
 		agent.statsTabletType.Set(strings.ToLower(newTablet.Type.String()))
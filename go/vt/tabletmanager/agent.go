@@ -43,6 +43,7 @@ import (
 	"github.com/youtube/vitess/go/vt/health"
 	"github.com/youtube/vitess/go/vt/key"
 	"github.com/youtube/vitess/go/vt/mysqlctl"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 	"github.com/youtube/vitess/go/vt/tabletserver"
 	"github.com/youtube/vitess/go/vt/tabletserver/planbuilder"
 	"github.com/youtube/vitess/go/vt/tabletserver/tabletservermock"
@@ -74,6 +75,10 @@ type ActionAgent struct {
 	SchemaOverrides     []tabletserver.SchemaOverride
 	BinlogPlayerMap     *BinlogPlayerMap
 
+	// HeartbeatWriter writes the replication heartbeat when the
+	// tablet is a master. It is nil if -enable_heartbeat is not set.
+	HeartbeatWriter *mysqlctl.HeartbeatWriter
+
 	// exportStats is set only for production tablet.
 	exportStats bool
 
@@ -120,6 +125,9 @@ type ActionAgent struct {
 	// replication delay the last time we got it
 	_replicationDelay time.Duration
 
+	// semi-sync status the last time we got it, if enabled
+	_semiSyncStatus myproto.SemiSyncStatus
+
 	// last time we ran TabletExternallyReparented
 	_tabletExternallyReparentedTime time.Time
 }
@@ -197,6 +205,11 @@ func NewActionAgent(
 	})
 	RegisterBinlogPlayerMap(agent.BinlogPlayerMap)
 
+	// The heartbeat is written when we become master.
+	if *enableHeartbeat {
+		agent.HeartbeatWriter = mysqlctl.NewHeartbeatWriter(mysqld, tabletAlias.Uid)
+	}
+
 	// try to figure out the mysql port
 	mysqlPort := mycnf.MysqlPort
 	if mysqlPort == 0 {
@@ -489,7 +502,7 @@ func (agent *ActionAgent) Start(ctx context.Context, mysqlPort, vtPort, gRPCPort
 	// (it needs the dbname, so it has to be delayed up to here,
 	// but it has to be before updateState below that may use it)
 	if initUpdateStream {
-		us := binlog.NewUpdateStream(agent.MysqlDaemon, agent.DBConfigs.App.DbName)
+		us := binlog.NewUpdateStream(agent.MysqlDaemon, agent.DBConfigs.App.DbName, agent.TopoServer, tablet.Keyspace)
 		us.RegisterService()
 		agent.UpdateStream = us
 	}
@@ -544,6 +557,9 @@ func (agent *ActionAgent) Stop() {
 	if agent.BinlogPlayerMap != nil {
 		agent.BinlogPlayerMap.StopAllPlayersAndReset()
 	}
+	if agent.HeartbeatWriter != nil {
+		agent.HeartbeatWriter.Close()
+	}
 	if agent.MysqlDaemon != nil {
 		agent.MysqlDaemon.Close()
 	}
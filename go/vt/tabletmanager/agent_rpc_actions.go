@@ -5,6 +5,7 @@
 package tabletmanager
 
 import (
+	"flag"
 	"fmt"
 	"time"
 
@@ -26,6 +27,11 @@ import (
 // This file contains the actions that exist as RPC only on the ActionAgent.
 // The various rpc server implementations just call these.
 
+var (
+	backupVerify            = flag.Bool("backup_verify", false, "verify each full backup after taking it, by restoring it into a scratch mysqld")
+	backupVerifyCheckTables = flag.Int("backup_verify_check_tables", 10, "number of tables, picked at random, on which the backup verification runs CHECK TABLE")
+)
+
 // RPCAgent defines the interface implemented by the Agent for RPCs.
 // It is useful for RPC implementations to test their full stack.
 type RPCAgent interface {
@@ -75,6 +81,8 @@ type RPCAgent interface {
 
 	StartSlave(ctx context.Context) error
 
+	StartSlaveUntilAfter(ctx context.Context, position myproto.ReplicationPosition, waitTime time.Duration) error
+
 	TabletExternallyReparented(ctx context.Context, externalID string) error
 
 	GetSlaves(ctx context.Context) ([]string, error)
@@ -113,7 +121,11 @@ type RPCAgent interface {
 
 	// Backup / restore related methods
 
-	Backup(ctx context.Context, concurrency int, logger logutil.Logger) error
+	Backup(ctx context.Context, concurrency int, incremental bool, logger logutil.Logger) error
+
+	RestoreToPointInTime(ctx context.Context, pos myproto.ReplicationPosition, t time.Time, logger logutil.Logger) error
+
+	VerifyBackup(ctx context.Context, name string, logger logutil.Logger) error
 
 	// RPC helpers
 	RPCWrap(ctx context.Context, name string, args, reply interface{}, f func() error) error
@@ -155,7 +167,15 @@ func (agent *ActionAgent) SetReadOnly(ctx context.Context, rdonly bool) error {
 // ChangeType changes the tablet type
 // Should be called under RPCWrapLockAction.
 func (agent *ActionAgent) ChangeType(ctx context.Context, tabletType pb.TabletType) error {
-	return topotools.ChangeType(ctx, agent.TopoServer, agent.TabletAlias, tabletType, nil)
+	if err := topotools.ChangeType(ctx, agent.TopoServer, agent.TabletAlias, tabletType, nil); err != nil {
+		return err
+	}
+
+	// a slave may start or stop acknowledging semi-sync transactions
+	if tabletType == pb.TabletType_MASTER {
+		return nil
+	}
+	return agent.fixSemiSyncAndReplication(tabletType)
 }
 
 // Sleep sleeps for the duration
@@ -281,7 +301,12 @@ func (agent *ActionAgent) ExecuteFetchAsApp(ctx context.Context, query string, m
 // SlaveStatus returns the replication status
 // Should be called under RPCWrap.
 func (agent *ActionAgent) SlaveStatus(ctx context.Context) (myproto.ReplicationStatus, error) {
-	return agent.MysqlDaemon.SlaveStatus()
+	rs, err := agent.MysqlDaemon.SlaveStatus()
+	if err != nil {
+		return myproto.ReplicationStatus{}, err
+	}
+	agent.addSemiSyncStatus(&rs)
+	return rs, nil
 }
 
 // MasterPosition returns the master position
@@ -317,6 +342,18 @@ func (agent *ActionAgent) StartSlave(ctx context.Context) error {
 	return mysqlctl.StartSlave(agent.MysqlDaemon, agent.hookExtraEnv())
 }
 
+// StartSlaveUntilAfter will start the replication, until it has applied
+// all the transactions of the provided position, and wait for it.
+// Replication must be stopped. If the slave is already past position,
+// it stops right away.
+// Should be called under RPCWrapLock.
+func (agent *ActionAgent) StartSlaveUntilAfter(ctx context.Context, position myproto.ReplicationPosition, waitTime time.Duration) error {
+	if err := agent.MysqlDaemon.StartSlaveUntilAfter(position); err != nil {
+		return err
+	}
+	return agent.MysqlDaemon.WaitMasterPos(position, waitTime)
+}
+
 // GetSlaves returns the address of all the slaves
 // Should be called under RPCWrap.
 func (agent *ActionAgent) GetSlaves(ctx context.Context) ([]string, error) {
@@ -395,6 +432,11 @@ func (agent *ActionAgent) InitMaster(ctx context.Context) (myproto.ReplicationPo
 		return myproto.ReplicationPosition{}, err
 	}
 
+	// Enable the master side of semi-sync before accepting writes.
+	if err := agent.fixSemiSync(pb.TabletType_MASTER); err != nil {
+		return myproto.ReplicationPosition{}, err
+	}
+
 	// Set the server read-write, from now on we can accept real
 	// client writes. Note that if semi-sync replication is enabled,
 	// we'll still need some slaves to be able to commit
@@ -443,6 +485,11 @@ func (agent *ActionAgent) InitSlave(ctx context.Context, parent *pb.TabletAlias,
 	cmds = append(cmds, cmds2...)
 	cmds = append(cmds, "START SLAVE")
 
+	// Semi-sync needs to be set before replication starts.
+	if err := agent.fixSemiSync(agent.Tablet().Type); err != nil {
+		return err
+	}
+
 	if err := agent.MysqlDaemon.ExecuteSuperQueryList(cmds); err != nil {
 		return err
 	}
@@ -468,7 +515,17 @@ func (agent *ActionAgent) DemoteMaster(ctx context.Context) (myproto.Replication
 	tablet := agent.Tablet()
 	agent.disallowQueries(tablet.Tablet.Type, "DemoteMaster marks server rdonly")
 
-	return agent.MysqlDaemon.DemoteMaster()
+	rp, err := agent.MysqlDaemon.DemoteMaster()
+	if err != nil {
+		return myproto.ReplicationPosition{}, err
+	}
+
+	// All transactions are done, we can switch to the slave side
+	// of semi-sync, as we will replicate from the new master.
+	if err := agent.fixSemiSync(pb.TabletType_REPLICA); err != nil {
+		return myproto.ReplicationPosition{}, err
+	}
+	return rp, nil
 	// There is no serving graph update - the master tablet will
 	// be replaced. Even though writes may fail, reads will
 	// succeed. It will be less noisy to simply leave the entry
@@ -502,6 +559,10 @@ func (agent *ActionAgent) PromoteSlaveWhenCaughtUp(ctx context.Context, pos mypr
 		return myproto.ReplicationPosition{}, err
 	}
 
+	if err := agent.fixSemiSync(pb.TabletType_MASTER); err != nil {
+		return myproto.ReplicationPosition{}, err
+	}
+
 	if err := agent.MysqlDaemon.SetReadOnly(false); err != nil {
 		return myproto.ReplicationPosition{}, err
 	}
@@ -553,15 +614,26 @@ func (agent *ActionAgent) SetMaster(ctx context.Context, parent *pb.TabletAlias,
 	if shouldbeReplicating {
 		cmds = append(cmds, mysqlctl.SQLStartSlave)
 	}
-	if err := agent.MysqlDaemon.ExecuteSuperQueryList(cmds); err != nil {
-		return err
-	}
 
-	// change our type to spare if we used to be the master
+	// Semi-sync needs to be set before replication starts. An old
+	// master will become a spare.
 	tablet, err := agent.TopoServer.GetTablet(ctx, agent.TabletAlias)
 	if err != nil {
 		return err
 	}
+	semiSyncType := tablet.Type
+	if semiSyncType == pb.TabletType_MASTER {
+		semiSyncType = pb.TabletType_SPARE
+	}
+	if err := agent.fixSemiSync(semiSyncType); err != nil {
+		return err
+	}
+
+	if err := agent.MysqlDaemon.ExecuteSuperQueryList(cmds); err != nil {
+		return err
+	}
+
+	// change our type to spare if we used to be the master
 	if tablet.Type == pb.TabletType_MASTER {
 		tablet.Type = pb.TabletType_SPARE
 		tablet.HealthMap = nil
@@ -613,6 +685,7 @@ func (agent *ActionAgent) StopReplicationAndGetStatus(ctx context.Context) (mypr
 	if err != nil {
 		return myproto.ReplicationStatus{}, fmt.Errorf("before status failed: %v", err)
 	}
+	agent.addSemiSyncStatus(&rs)
 	if !rs.SlaveIORunning && !rs.SlaveSQLRunning {
 		// no replication is running, just return what we got
 		return rs, nil
@@ -640,6 +713,10 @@ func (agent *ActionAgent) PromoteSlave(ctx context.Context) (myproto.Replication
 		return myproto.ReplicationPosition{}, err
 	}
 
+	if err := agent.fixSemiSync(pb.TabletType_MASTER); err != nil {
+		return myproto.ReplicationPosition{}, err
+	}
+
 	// Set the server read-write
 	if err := agent.MysqlDaemon.SetReadOnly(false); err != nil {
 		return myproto.ReplicationPosition{}, err
@@ -677,14 +754,37 @@ func (agent *ActionAgent) updateReplicationGraphForPromotedSlave(ctx context.Con
 // Backup / restore related methods
 //
 
-// Backup takes a db backup and sends it to the BackupStorage
+// Backup takes a db backup and sends it to the BackupStorage.
+// An incremental backup only stores the binlogs since the last backup,
+// it doesn't stop mysqld nor change the tablet type. Neither does a
+// full backup with a BackupEngine that doesn't need to drain the
+// tablet.
 // Should be called under RPCWrapLockAction.
-func (agent *ActionAgent) Backup(ctx context.Context, concurrency int, logger logutil.Logger) error {
-	// update our type to BACKUP
+func (agent *ActionAgent) Backup(ctx context.Context, concurrency int, incremental bool, logger logutil.Logger) error {
 	tablet, err := agent.TopoServer.GetTablet(ctx, agent.TabletAlias)
 	if err != nil {
 		return err
 	}
+
+	// create the loggers: tee to console and source
+	l := logutil.NewTeeLogger(logutil.NewConsoleLogger(), logger)
+	dir := fmt.Sprintf("%v/%v", tablet.Keyspace, tablet.Shard)
+	name := fmt.Sprintf("%v.%v", time.Now().UTC().Format(mysqlctl.BackupTimestampFormat), topoproto.TabletAliasString(tablet.Alias))
+	if incremental {
+		return mysqlctl.BackupIncremental(ctx, agent.MysqlDaemon, l, dir, name, concurrency)
+	}
+	engine, err := mysqlctl.GetBackupEngine()
+	if err != nil {
+		return err
+	}
+	if !engine.ShouldDrainForBackup() {
+		if err := mysqlctl.Backup(ctx, agent.MysqlDaemon, l, dir, name, concurrency, agent.hookExtraEnv()); err != nil {
+			return err
+		}
+		return agent.verifyNewBackup(ctx, l, dir, name)
+	}
+
+	// update our type to BACKUP
 	if tablet.Type == pb.TabletType_MASTER {
 		return fmt.Errorf("type MASTER cannot take backup, if you really need to do this, restart vttablet in replica mode")
 	}
@@ -698,12 +798,7 @@ func (agent *ActionAgent) Backup(ctx context.Context, concurrency int, logger lo
 		return fmt.Errorf("failed to update state before backup: %v", err)
 	}
 
-	// create the loggers: tee to console and source
-	l := logutil.NewTeeLogger(logutil.NewConsoleLogger(), logger)
-
 	// now we can run the backup
-	dir := fmt.Sprintf("%v/%v", tablet.Keyspace, tablet.Shard)
-	name := fmt.Sprintf("%v.%v", time.Now().UTC().Format("2006-01-02.150405"), topoproto.TabletAliasString(tablet.Alias))
 	returnErr := mysqlctl.Backup(ctx, agent.MysqlDaemon, l, dir, name, concurrency, agent.hookExtraEnv())
 
 	// and change our type back to the appropriate value:
@@ -722,5 +817,98 @@ func (agent *ActionAgent) Backup(ctx context.Context, concurrency int, logger lo
 		returnErr = err
 	}
 
+	if returnErr != nil {
+		return returnErr
+	}
+	return agent.verifyNewBackup(ctx, l, dir, name)
+}
+
+// verifyNewBackup verifies a backup the tablet just took, if
+// -backup_verify is set.
+func (agent *ActionAgent) verifyNewBackup(ctx context.Context, logger logutil.Logger, dir, name string) error {
+	if !*backupVerify {
+		return nil
+	}
+	return mysqlctl.VerifyBackup(ctx, agent.MysqlDaemon, logger, dir, name, *restoreConcurrency, *backupVerifyCheckTables)
+}
+
+// RestoreToPointInTime replaces the data of the tablet with the most
+// recent full backup before the target, and replays the binlogs of the
+// incremental backups up to it. The target is either pos, or t if it
+// is not zero. Replication is not restarted, so the tablet stays at the
+// target, and it is left as a SPARE.
+// Should be called under RPCWrapLockAction.
+func (agent *ActionAgent) RestoreToPointInTime(ctx context.Context, pos myproto.ReplicationPosition, t time.Time, logger logutil.Logger) error {
+	tablet, err := agent.TopoServer.GetTablet(ctx, agent.TabletAlias)
+	if err != nil {
+		return err
+	}
+	if tablet.Type == pb.TabletType_MASTER {
+		return fmt.Errorf("type MASTER cannot restore a backup, reparent the shard first")
+	}
+	if err := topotools.ChangeType(ctx, agent.TopoServer, tablet.Alias, pb.TabletType_RESTORE, make(map[string]string)); err != nil {
+		return err
+	}
+
+	// let's update our internal state (stop query service and other things)
+	if err := agent.refreshTablet(ctx, "restore"); err != nil {
+		return fmt.Errorf("failed to update state before restore: %v", err)
+	}
+
+	// create the loggers: tee to console and source
+	l := logutil.NewTeeLogger(logutil.NewConsoleLogger(), logger)
+
+	returnErr := agent.restoreToPointInTime(ctx, tablet, pos, t, l)
+
+	// the data doesn't match the shard anymore, so the tablet
+	// can't go back to serving
+	err = topotools.ChangeType(ctx, agent.TopoServer, tablet.Alias, pb.TabletType_SPARE, nil)
+	if err != nil {
+		if returnErr != nil {
+			l.Errorf("restore returned error: %v", returnErr)
+		}
+		returnErr = err
+	}
 	return returnErr
 }
+
+func (agent *ActionAgent) restoreToPointInTime(ctx context.Context, tablet *topo.TabletInfo, pos myproto.ReplicationPosition, t time.Time, logger logutil.Logger) error {
+	dir := fmt.Sprintf("%v/%v", tablet.Keyspace, tablet.Shard)
+	var restored myproto.ReplicationPosition
+	var err error
+	if t.IsZero() {
+		restored, err = mysqlctl.RestoreToPosition(ctx, agent.MysqlDaemon, logger, dir, *restoreConcurrency, pos)
+	} else {
+		restored, err = mysqlctl.RestoreToTime(ctx, agent.MysqlDaemon, logger, dir, *restoreConcurrency, t)
+	}
+	if err != nil {
+		return err
+	}
+	logger.Infof("restored to position %v", restored)
+
+	// Forget the replication state and the replayed binlogs, and
+	// set the position to resume from when reparented.
+	cmds, err := agent.MysqlDaemon.ResetReplicationCommands()
+	if err != nil {
+		return err
+	}
+	setCmds, err := agent.MysqlDaemon.SetSlavePositionCommands(restored)
+	if err != nil {
+		return err
+	}
+	return agent.MysqlDaemon.ExecuteSuperQueryList(append(cmds, setCmds...))
+}
+
+// VerifyBackup restores a full backup of the shard into a scratch
+// mysqld, and checks its tables. The tablet keeps its type, and
+// its mysqld keeps running.
+// Should be called under RPCWrapLockAction.
+func (agent *ActionAgent) VerifyBackup(ctx context.Context, name string, logger logutil.Logger) error {
+	tablet, err := agent.TopoServer.GetTablet(ctx, agent.TabletAlias)
+	if err != nil {
+		return err
+	}
+	l := logutil.NewTeeLogger(logutil.NewConsoleLogger(), logger)
+	dir := fmt.Sprintf("%v/%v", tablet.Keyspace, tablet.Shard)
+	return mysqlctl.VerifyBackup(ctx, agent.MysqlDaemon, l, dir, name, *restoreConcurrency, *backupVerifyCheckTables)
+}
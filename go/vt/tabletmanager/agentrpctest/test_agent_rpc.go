@@ -655,6 +655,29 @@ func agentRPCTestStartSlavePanic(ctx context.Context, t *testing.T, client tmcli
 	expectRPCWrapLockPanic(t, err)
 }
 
+var testStartSlaveUntilAfterWaitTime = time.Minute
+var testStartSlaveUntilAfterCalled = false
+
+func (fra *fakeRPCAgent) StartSlaveUntilAfter(ctx context.Context, position myproto.ReplicationPosition, waitTime time.Duration) error {
+	if fra.panics {
+		panic(fmt.Errorf("test-triggered panic"))
+	}
+	compare(fra.t, "StartSlaveUntilAfter position", position.GTIDSet, testReplicationPosition.GTIDSet)
+	compare(fra.t, "StartSlaveUntilAfter waitTime", waitTime, testStartSlaveUntilAfterWaitTime)
+	testStartSlaveUntilAfterCalled = true
+	return nil
+}
+
+func agentRPCTestStartSlaveUntilAfter(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo) {
+	err := client.StartSlaveUntilAfter(ctx, ti, testReplicationPosition, testStartSlaveUntilAfterWaitTime)
+	compareError(t, "StartSlaveUntilAfter", err, true, testStartSlaveUntilAfterCalled)
+}
+
+func agentRPCTestStartSlaveUntilAfterPanic(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo) {
+	err := client.StartSlaveUntilAfter(ctx, ti, testReplicationPosition, testStartSlaveUntilAfterWaitTime)
+	expectRPCWrapLockPanic(t, err)
+}
+
 var testTabletExternallyReparentedCalled = false
 
 func (fra *fakeRPCAgent) TabletExternallyReparented(ctx context.Context, externalID string) error {
@@ -1032,20 +1055,22 @@ func agentRPCTestPromoteSlavePanic(ctx context.Context, t *testing.T, client tmc
 //
 
 var testBackupConcurrency = 24
+var testBackupIncremental = true
 var testBackupCalled = false
 
-func (fra *fakeRPCAgent) Backup(ctx context.Context, concurrency int, logger logutil.Logger) error {
+func (fra *fakeRPCAgent) Backup(ctx context.Context, concurrency int, incremental bool, logger logutil.Logger) error {
 	if fra.panics {
 		panic(fmt.Errorf("test-triggered panic"))
 	}
 	compare(fra.t, "Backup args", concurrency, testBackupConcurrency)
+	compare(fra.t, "Backup incremental", incremental, testBackupIncremental)
 	logStuff(logger, 10)
 	testBackupCalled = true
 	return nil
 }
 
 func agentRPCTestBackup(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo) {
-	logChannel, errFunc, err := client.Backup(ctx, ti, testBackupConcurrency)
+	logChannel, errFunc, err := client.Backup(ctx, ti, testBackupConcurrency, testBackupIncremental)
 	if err != nil {
 		t.Fatalf("Backup failed: %v", err)
 	}
@@ -1055,7 +1080,7 @@ func agentRPCTestBackup(ctx context.Context, t *testing.T, client tmclient.Table
 }
 
 func agentRPCTestBackupPanic(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo) {
-	logChannel, errFunc, err := client.Backup(ctx, ti, testBackupConcurrency)
+	logChannel, errFunc, err := client.Backup(ctx, ti, testBackupConcurrency, testBackupIncremental)
 	if err != nil {
 		t.Fatalf("Backup failed: %v", err)
 	}
@@ -1066,6 +1091,77 @@ func agentRPCTestBackupPanic(ctx context.Context, t *testing.T, client tmclient.
 	expectRPCWrapLockActionPanic(t, err)
 }
 
+var testRestoreToPointInTimeTime = time.Unix(1446000000, 0)
+var testRestoreToPointInTimeCalled = false
+
+func (fra *fakeRPCAgent) RestoreToPointInTime(ctx context.Context, pos myproto.ReplicationPosition, t time.Time, logger logutil.Logger) error {
+	if fra.panics {
+		panic(fmt.Errorf("test-triggered panic"))
+	}
+	compare(fra.t, "RestoreToPointInTime position", pos.GTIDSet, testReplicationPosition.GTIDSet)
+	compare(fra.t, "RestoreToPointInTime time", t.Unix(), testRestoreToPointInTimeTime.Unix())
+	logStuff(logger, 10)
+	testRestoreToPointInTimeCalled = true
+	return nil
+}
+
+func agentRPCTestRestoreToPointInTime(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo) {
+	logChannel, errFunc, err := client.RestoreToPointInTime(ctx, ti, testReplicationPosition, testRestoreToPointInTimeTime)
+	if err != nil {
+		t.Fatalf("RestoreToPointInTime failed: %v", err)
+	}
+	compareLoggedStuff(t, "RestoreToPointInTime", logChannel, 10)
+	err = errFunc()
+	compareError(t, "RestoreToPointInTime", err, true, testRestoreToPointInTimeCalled)
+}
+
+func agentRPCTestRestoreToPointInTimePanic(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo) {
+	logChannel, errFunc, err := client.RestoreToPointInTime(ctx, ti, testReplicationPosition, testRestoreToPointInTimeTime)
+	if err != nil {
+		t.Fatalf("RestoreToPointInTime failed: %v", err)
+	}
+	if e, ok := <-logChannel; ok {
+		t.Fatalf("Unexpected RestoreToPointInTime logs: %v", e)
+	}
+	err = errFunc()
+	expectRPCWrapLockActionPanic(t, err)
+}
+
+var testVerifyBackupName = "2015-11-10.140200.cell1-0000000100"
+var testVerifyBackupCalled = false
+
+func (fra *fakeRPCAgent) VerifyBackup(ctx context.Context, name string, logger logutil.Logger) error {
+	if fra.panics {
+		panic(fmt.Errorf("test-triggered panic"))
+	}
+	compare(fra.t, "VerifyBackup name", name, testVerifyBackupName)
+	logStuff(logger, 10)
+	testVerifyBackupCalled = true
+	return nil
+}
+
+func agentRPCTestVerifyBackup(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo) {
+	logChannel, errFunc, err := client.VerifyBackup(ctx, ti, testVerifyBackupName)
+	if err != nil {
+		t.Fatalf("VerifyBackup failed: %v", err)
+	}
+	compareLoggedStuff(t, "VerifyBackup", logChannel, 10)
+	err = errFunc()
+	compareError(t, "VerifyBackup", err, true, testVerifyBackupCalled)
+}
+
+func agentRPCTestVerifyBackupPanic(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo) {
+	logChannel, errFunc, err := client.VerifyBackup(ctx, ti, testVerifyBackupName)
+	if err != nil {
+		t.Fatalf("VerifyBackup failed: %v", err)
+	}
+	if e, ok := <-logChannel; ok {
+		t.Fatalf("Unexpected VerifyBackup logs: %v", e)
+	}
+	err = errFunc()
+	expectRPCWrapLockActionPanic(t, err)
+}
+
 //
 // RPC helpers
 //
@@ -1132,6 +1228,7 @@ func Run(t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo,
 	agentRPCTestStopSlave(ctx, t, client, ti)
 	agentRPCTestStopSlaveMinimum(ctx, t, client, ti)
 	agentRPCTestStartSlave(ctx, t, client, ti)
+	agentRPCTestStartSlaveUntilAfter(ctx, t, client, ti)
 	agentRPCTestTabletExternallyReparented(ctx, t, client, ti)
 	agentRPCTestGetSlaves(ctx, t, client, ti)
 	agentRPCTestWaitBlpPosition(ctx, t, client, ti)
@@ -1154,6 +1251,8 @@ func Run(t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo,
 
 	// Backup / restore related methods
 	agentRPCTestBackup(ctx, t, client, ti)
+	agentRPCTestRestoreToPointInTime(ctx, t, client, ti)
+	agentRPCTestVerifyBackup(ctx, t, client, ti)
 
 	//
 	// Tests panic handling everywhere now
@@ -1183,6 +1282,7 @@ func Run(t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo,
 	agentRPCTestStopSlavePanic(ctx, t, client, ti)
 	agentRPCTestStopSlaveMinimumPanic(ctx, t, client, ti)
 	agentRPCTestStartSlavePanic(ctx, t, client, ti)
+	agentRPCTestStartSlaveUntilAfterPanic(ctx, t, client, ti)
 	agentRPCTestTabletExternallyReparentedPanic(ctx, t, client, ti)
 	agentRPCTestGetSlavesPanic(ctx, t, client, ti)
 	agentRPCTestWaitBlpPositionPanic(ctx, t, client, ti)
@@ -1205,4 +1305,6 @@ func Run(t *testing.T, client tmclient.TabletManagerClient, ti *topo.TabletInfo,
 
 	// Backup / restore related methods
 	agentRPCTestBackupPanic(ctx, t, client, ti)
+	agentRPCTestRestoreToPointInTimePanic(ctx, t, client, ti)
+	agentRPCTestVerifyBackupPanic(ctx, t, client, ti)
 }
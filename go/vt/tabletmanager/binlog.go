@@ -8,16 +8,19 @@ package tabletmanager
 // replication
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"html/template"
 	"math/rand" // not crypto-safe is OK here
+	"net/http"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	log "github.com/golang/glog"
+	"github.com/youtube/vitess/go/acl"
 	"github.com/youtube/vitess/go/stats"
 	"github.com/youtube/vitess/go/tb"
 	"github.com/youtube/vitess/go/vt/binlog/binlogplayer"
@@ -38,6 +41,8 @@ var (
 	healthcheckTopologyRefresh = flag.Duration("binlog_player_healthcheck_topology_refresh", 30*time.Second, "refresh interval for re-reading the topology when filtered replication is running")
 
 	retryDelay = flag.Duration("binlog_player_retry_delay", 5*time.Second, "delay before retrying a failed healthcheck or a failed binlog connection")
+
+	maxReplicaLag = flag.Duration("binlog_player_max_replica_lag", 0, "if set, filtered replication pauses while a replica or rdonly tablet of the destination shard in the same cell lags more than this")
 )
 
 func init() {
@@ -60,6 +65,10 @@ type BinlogPlayerController struct {
 	// Information about the source (set at construction, immutable).
 	sourceShard *pb.Shard_SourceShard
 
+	// throttler is given to the players, if set (set at construction,
+	// immutable).
+	throttler binlogplayer.Throttler
+
 	// BinlogPlayerStats has the stats for the players we're going to use
 	// (pointer is set at construction, immutable, values are thread-safe).
 	binlogPlayerStats *binlogplayer.BinlogPlayerStats
@@ -99,7 +108,7 @@ type BinlogPlayerController struct {
 	lastError error
 }
 
-func newBinlogPlayerController(ts topo.Server, vtClientFactory func() binlogplayer.VtClient, mysqld mysqlctl.MysqlDaemon, cell string, keyspaceIDType pb.KeyspaceIdType, keyRange *pb.KeyRange, sourceShard *pb.Shard_SourceShard, dbName string) *BinlogPlayerController {
+func newBinlogPlayerController(ts topo.Server, vtClientFactory func() binlogplayer.VtClient, mysqld mysqlctl.MysqlDaemon, cell string, keyspaceIDType pb.KeyspaceIdType, keyRange *pb.KeyRange, sourceShard *pb.Shard_SourceShard, dbName string, throttler binlogplayer.Throttler) *BinlogPlayerController {
 	blc := &BinlogPlayerController{
 		ts:                   ts,
 		vtClientFactory:      vtClientFactory,
@@ -109,6 +118,7 @@ func newBinlogPlayerController(ts topo.Server, vtClientFactory func() binlogplay
 		keyRange:             keyRange,
 		dbName:               dbName,
 		sourceShard:          sourceShard,
+		throttler:            throttler,
 		binlogPlayerStats:    binlogplayer.NewBinlogPlayerStats(),
 		healthCheck:          discovery.NewHealthCheck(*binlogplayer.BinlogPlayerConnTimeout, *retryDelay),
 		initialEndpointFound: make(chan struct{}),
@@ -309,16 +319,45 @@ func (bpc *BinlogPlayerController) Iteration() (err error) {
 	bpc.lastError = nil
 	bpc.playerMutex.Unlock()
 
+	// the transforms to apply to the statements, if any
+	var transformer *binlogplayer.Transformer
+	if len(bpc.sourceShard.Transforms) > 0 {
+		transformer, err = binlogplayer.NewTransformer(bpc.sourceShard.Transforms)
+		if err != nil {
+			return fmt.Errorf("invalid transforms: %v", err)
+		}
+	}
+
 	// check which kind of replication we're doing, tables or keyrange
 	if len(bpc.sourceShard.Tables) > 0 {
-		// tables, first resolve wildcards
-		tables, err := mysqlctl.ResolveTables(bpc.mysqld, bpc.dbName, bpc.sourceShard.Tables)
-		if err != nil {
-			return fmt.Errorf("failed to resolve table names: %v", err)
+		// tables, first resolve wildcards. Renamed tables
+		// don't exist here under their source name, so we
+		// don't resolve them.
+		var toResolve, renamed []string
+		for _, table := range bpc.sourceShard.Tables {
+			if isRenamedTable(bpc.sourceShard.Transforms, table) {
+				renamed = append(renamed, table)
+			} else {
+				toResolve = append(toResolve, table)
+			}
+		}
+		var tables []string
+		if len(toResolve) > 0 {
+			tables, err = mysqlctl.ResolveTables(bpc.mysqld, bpc.dbName, toResolve)
+			if err != nil {
+				return fmt.Errorf("failed to resolve table names: %v", err)
+			}
 		}
+		tables = append(tables, renamed...)
 
 		// tables, just get them
 		player := binlogplayer.NewBinlogPlayerTables(vtClient, endPoint, tables, startPosition, bpc.stopPosition, bpc.binlogPlayerStats)
+		if transformer != nil {
+			player.SetTransformer(transformer)
+		}
+		if bpc.throttler != nil {
+			player.SetThrottler(bpc.throttler)
+		}
 		return player.ApplyBinlogEvents(bpc.ctx)
 	}
 	// the data we have to replicate is the intersection of the
@@ -329,9 +368,25 @@ func (bpc *BinlogPlayerController) Iteration() (err error) {
 	}
 
 	player := binlogplayer.NewBinlogPlayerKeyRange(vtClient, endPoint, bpc.keyspaceIDType, overlap, startPosition, bpc.stopPosition, bpc.binlogPlayerStats)
+	if transformer != nil {
+		player.SetTransformer(transformer)
+	}
+	if bpc.throttler != nil {
+		player.SetThrottler(bpc.throttler)
+	}
 	return player.ApplyBinlogEvents(bpc.ctx)
 }
 
+// isRenamedTable returns true if a transform renames the table.
+func isRenamedTable(transforms []*pb.TableTransform, table string) bool {
+	for _, transform := range transforms {
+		if transform.Table == table && transform.TargetTable != "" && transform.TargetTable != table {
+			return true
+		}
+	}
+	return false
+}
+
 // BlpPosition returns the current position for a controller, as read from the database.
 func (bpc *BinlogPlayerController) BlpPosition(vtClient binlogplayer.VtClient) (*blproto.BlpPosition, string, error) {
 	return binlogplayer.ReadStartPosition(vtClient, bpc.sourceShard.Uid)
@@ -345,10 +400,14 @@ type BinlogPlayerMap struct {
 	vtClientFactory func() binlogplayer.VtClient
 	mysqld          mysqlctl.MysqlDaemon
 
-	// This mutex protects the map and the state.
+	// This mutex protects the map, the state and the throttler.
 	mu      sync.Mutex
 	players map[uint32]*BinlogPlayerController
 	state   int64
+
+	// throttler is shared by all the players. It is only set while
+	// there are players, and if -binlog_player_max_replica_lag is set.
+	throttler *replicaLagThrottler
 }
 
 const (
@@ -412,6 +471,31 @@ func RegisterBinlogPlayerMap(blm *BinlogPlayerMap) {
 		blm.mu.Unlock()
 		return result
 	}))
+	stats.Publish("BinlogPlayerThrottledMap", stats.StringMapFunc(func() map[string]string {
+		blm.mu.Lock()
+		result := make(map[string]string, len(blm.players))
+		for i, bpc := range blm.players {
+			result[fmt.Sprintf("%v", i)] = bpc.binlogPlayerStats.GetThrottledReason()
+		}
+		blm.mu.Unlock()
+		return result
+	}))
+	http.HandleFunc("/debug/vreplication", blm.handleVReplication)
+}
+
+// handleVReplication serves the status of the players as JSON.
+func (blm *BinlogPlayerMap) handleVReplication(w http.ResponseWriter, r *http.Request) {
+	if err := acl.CheckAccessHTTP(r, acl.DEBUGGING); err != nil {
+		acl.SendError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	b, err := json.MarshalIndent(blm.Status(), "", "  ")
+	if err != nil {
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Write(b)
 }
 
 func (blm *BinlogPlayerMap) isRunningFilteredReplication() bool {
@@ -442,7 +526,11 @@ func (blm *BinlogPlayerMap) addPlayer(ctx context.Context, cell string, keyspace
 		return
 	}
 
-	bpc = newBinlogPlayerController(blm.ts, blm.vtClientFactory, blm.mysqld, cell, keyspaceIDType, keyRange, sourceShard, dbName)
+	var throttler binlogplayer.Throttler
+	if blm.throttler != nil {
+		throttler = blm.throttler
+	}
+	bpc = newBinlogPlayerController(blm.ts, blm.vtClientFactory, blm.mysqld, cell, keyspaceIDType, keyRange, sourceShard, dbName, throttler)
 	blm.players[sourceShard.Uid] = bpc
 	if blm.state == BpmStateRunning {
 		bpc.Start(ctx)
@@ -460,6 +548,10 @@ func (blm *BinlogPlayerMap) StopAllPlayersAndReset() {
 		hadPlayers = true
 	}
 	blm.players = make(map[uint32]*BinlogPlayerController)
+	if blm.throttler != nil {
+		blm.throttler.stop()
+		blm.throttler = nil
+	}
 	blm.mu.Unlock()
 
 	if hadPlayers {
@@ -492,6 +584,11 @@ func (blm *BinlogPlayerMap) RefreshMap(ctx context.Context, tablet *pb.Tablet, k
 		hadPlayers = true
 	}
 
+	// the players share a throttler, if configured
+	if *maxReplicaLag > 0 && len(shardInfo.SourceShards) > 0 && blm.throttler == nil {
+		blm.throttler = newReplicaLagThrottler(blm.ts, tablet.Alias.Cell, tablet.Keyspace, tablet.Shard, *maxReplicaLag)
+	}
+
 	// for each source, add it if not there, and delete from toRemove
 	for _, sourceShard := range shardInfo.SourceShards {
 		blm.addPlayer(ctx, tablet.Alias.Cell, keyspaceInfo.ShardingColumnType, tablet.KeyRange, sourceShard, topoproto.TabletDbName(tablet))
@@ -504,6 +601,10 @@ func (blm *BinlogPlayerMap) RefreshMap(ctx context.Context, tablet *pb.Tablet, k
 		blm.players[source].Stop()
 		delete(blm.players, source)
 	}
+	if !hasPlayers && blm.throttler != nil {
+		blm.throttler.stop()
+		blm.throttler = nil
+	}
 
 	blm.mu.Unlock()
 
@@ -630,6 +731,7 @@ type BinlogPlayerControllerStatus struct {
 	State               string
 	SourceTablet        *pb.TabletAlias
 	LastError           string
+	ThrottledReason     string
 }
 
 // SourceShardAsHTML returns the SourceShard as HTML
@@ -696,6 +798,7 @@ func (blm *BinlogPlayerMap) Status() *BinlogPlayerMapStatus {
 			SecondsBehindMaster: bpc.binlogPlayerStats.SecondsBehindMaster.Get(),
 			Counts:              bpc.binlogPlayerStats.Timings.Counts(),
 			Rates:               bpc.binlogPlayerStats.Rates.Get(),
+			ThrottledReason:     bpc.binlogPlayerStats.GetThrottledReason(),
 		}
 		bpc.playerMutex.Lock()
 		if bpc.ctx == nil {
@@ -1,8 +1,11 @@
 package tabletmanager
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"sync"
 	"testing"
@@ -875,3 +878,44 @@ func TestBinlogPlayerMapVerticalSplit(t *testing.T) {
 		t.Errorf("unexpected state: %v", s)
 	}
 }
+
+func TestVReplicationHandler(t *testing.T) {
+	ts := zktopo.NewTestServer(t, []string{"cell1"})
+	bpm := NewBinlogPlayerMap(ts, nil, nil)
+	sourceShard := &pbt.Shard_SourceShard{Uid: 1, Keyspace: "ks", Shard: "-80"}
+	bpc := newBinlogPlayerController(ts, nil, nil, "cell1", pbt.KeyspaceIdType_UINT64, nil, sourceShard, "vt_ks", nil)
+	defer bpc.shardReplicationWatcher.Stop()
+	throttledReason := "REPLICA tablet cell1-0000000001 is 30 seconds behind master, more than 5s"
+	bpc.binlogPlayerStats.SetThrottledReason(throttledReason)
+	bpc.binlogPlayerStats.SecondsBehindMaster.Set(12)
+	bpm.players[sourceShard.Uid] = bpc
+
+	req, err := http.NewRequest("GET", "/debug/vreplication", nil)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	w := httptest.NewRecorder()
+	bpm.handleVReplication(w, req)
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("/debug/vreplication has Content-Type %v", ct)
+	}
+	var status struct {
+		State       string
+		Controllers []struct {
+			Index               uint32
+			SourceShard         *pbt.Shard_SourceShard
+			SecondsBehindMaster int64
+			ThrottledReason     string
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("cannot decode /debug/vreplication: %v\n%v", err, w.Body.String())
+	}
+	if status.State != "Running" || len(status.Controllers) != 1 {
+		t.Fatalf("unexpected /debug/vreplication: %v", w.Body.String())
+	}
+	c := status.Controllers[0]
+	if c.Index != 1 || c.SourceShard.Keyspace != "ks" || c.SourceShard.Shard != "-80" || c.SecondsBehindMaster != 12 || c.ThrottledReason != throttledReason {
+		t.Errorf("unexpected player in /debug/vreplication: %v", w.Body.String())
+	}
+}
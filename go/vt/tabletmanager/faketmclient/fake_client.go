@@ -175,6 +175,11 @@ func (client *FakeTabletManagerClient) StartSlave(ctx context.Context, tablet *t
 	return nil
 }
 
+// StartSlaveUntilAfter is part of the tmclient.TabletManagerClient interface
+func (client *FakeTabletManagerClient) StartSlaveUntilAfter(ctx context.Context, tablet *topo.TabletInfo, position myproto.ReplicationPosition, waitTime time.Duration) error {
+	return nil
+}
+
 // TabletExternallyReparented is part of the tmclient.TabletManagerClient interface
 func (client *FakeTabletManagerClient) TabletExternallyReparented(ctx context.Context, tablet *topo.TabletInfo, externalID string) error {
 	return nil
@@ -285,7 +290,23 @@ func (client *FakeTabletManagerClient) PromoteSlave(ctx context.Context, tablet
 //
 
 // Backup is part of the tmclient.TabletManagerClient interface
-func (client *FakeTabletManagerClient) Backup(ctx context.Context, tablet *topo.TabletInfo, concurrency int) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
+func (client *FakeTabletManagerClient) Backup(ctx context.Context, tablet *topo.TabletInfo, concurrency int, incremental bool) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
+	logstream := make(chan *logutil.LoggerEvent, 10)
+	return logstream, func() error {
+		return nil
+	}, nil
+}
+
+// RestoreToPointInTime is part of the tmclient.TabletManagerClient interface
+func (client *FakeTabletManagerClient) RestoreToPointInTime(ctx context.Context, tablet *topo.TabletInfo, pos myproto.ReplicationPosition, t time.Time) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
+	logstream := make(chan *logutil.LoggerEvent, 10)
+	return logstream, func() error {
+		return nil
+	}, nil
+}
+
+// VerifyBackup is part of the tmclient.TabletManagerClient interface
+func (client *FakeTabletManagerClient) VerifyBackup(ctx context.Context, tablet *topo.TabletInfo, name string) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
 	logstream := make(chan *logutil.LoggerEvent, 10)
 	return logstream, func() error {
 		return nil
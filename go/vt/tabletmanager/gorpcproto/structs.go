@@ -66,6 +66,12 @@ type StopSlaveMinimumArgs struct {
 	WaitTime time.Duration
 }
 
+// StartSlaveUntilAfterArgs has arguments for StartSlaveUntilAfter
+type StartSlaveUntilAfterArgs struct {
+	Position myproto.ReplicationPosition
+	WaitTime time.Duration
+}
+
 // GetSlavesReply has the reply for GetSlaves
 type GetSlavesReply struct {
 	Addrs []string
@@ -96,6 +102,19 @@ type ExecuteFetchArgs struct {
 // BackupArgs has arguments for Backup
 type BackupArgs struct {
 	Concurrency int
+	Incremental bool
+}
+
+// RestoreToPointInTimeArgs has arguments for RestoreToPointInTime.
+// Time is in seconds since the epoch, and only used if not zero.
+type RestoreToPointInTimeArgs struct {
+	Position myproto.ReplicationPosition
+	Time     int64
+}
+
+// VerifyBackupArgs has arguments for VerifyBackup
+type VerifyBackupArgs struct {
+	Name string
 }
 
 // TabletExternallyReparentedArgs has arguments for TabletExternallyReparented
@@ -245,6 +245,14 @@ func (client *GoRPCTabletManagerClient) StartSlave(ctx context.Context, tablet *
 	return client.rpcCallTablet(ctx, tablet, actionnode.TabletActionStartSlave, &rpc.Unused{}, &rpc.Unused{})
 }
 
+// StartSlaveUntilAfter is part of the tmclient.TabletManagerClient interface
+func (client *GoRPCTabletManagerClient) StartSlaveUntilAfter(ctx context.Context, tablet *topo.TabletInfo, position myproto.ReplicationPosition, waitTime time.Duration) error {
+	return client.rpcCallTablet(ctx, tablet, actionnode.TabletActionStartSlaveUntilAfter, &gorpcproto.StartSlaveUntilAfterArgs{
+		Position: position,
+		WaitTime: waitTime,
+	}, &rpc.Unused{})
+}
+
 // TabletExternallyReparented is part of the tmclient.TabletManagerClient interface
 func (client *GoRPCTabletManagerClient) TabletExternallyReparented(ctx context.Context, tablet *topo.TabletInfo, externalID string) error {
 	return client.rpcCallTablet(ctx, tablet, actionnode.TabletActionExternallyReparented, &gorpcproto.TabletExternallyReparentedArgs{ExternalID: externalID}, &rpc.Unused{})
@@ -409,13 +417,40 @@ func (client *GoRPCTabletManagerClient) PromoteSlave(ctx context.Context, tablet
 //
 
 // Backup is part of the tmclient.TabletManagerClient interface
-func (client *GoRPCTabletManagerClient) Backup(ctx context.Context, tablet *topo.TabletInfo, concurrency int) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
+func (client *GoRPCTabletManagerClient) Backup(ctx context.Context, tablet *topo.TabletInfo, concurrency int, incremental bool) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
+	return client.streamLogs(ctx, tablet, actionnode.TabletActionBackup, &gorpcproto.BackupArgs{
+		Concurrency: concurrency,
+		Incremental: incremental,
+	})
+}
+
+// RestoreToPointInTime is part of the tmclient.TabletManagerClient interface
+func (client *GoRPCTabletManagerClient) RestoreToPointInTime(ctx context.Context, tablet *topo.TabletInfo, pos myproto.ReplicationPosition, t time.Time) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
+	args := &gorpcproto.RestoreToPointInTimeArgs{
+		Position: pos,
+	}
+	if !t.IsZero() {
+		args.Time = t.Unix()
+	}
+	return client.streamLogs(ctx, tablet, actionnode.TabletActionRestoreToPointInTime, args)
+}
+
+// VerifyBackup is part of the tmclient.TabletManagerClient interface
+func (client *GoRPCTabletManagerClient) VerifyBackup(ctx context.Context, tablet *topo.TabletInfo, name string) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
+	return client.streamLogs(ctx, tablet, actionnode.TabletActionVerifyBackup, &gorpcproto.VerifyBackupArgs{
+		Name: name,
+	})
+}
+
+// streamLogs calls a streaming TabletManager method which sends back
+// logger events.
+func (client *GoRPCTabletManagerClient) streamLogs(ctx context.Context, tablet *topo.TabletInfo, name string, args interface{}) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
 	var connectTimeout time.Duration
 	deadline, ok := ctx.Deadline()
 	if ok {
 		connectTimeout = deadline.Sub(time.Now())
 		if connectTimeout < 0 {
-			return nil, nil, timeoutError{fmt.Errorf("timeout connecting to TabletManager.Backup on %v", tablet.Alias)}
+			return nil, nil, timeoutError{fmt.Errorf("timeout connecting to TabletManager.%v on %v", name, tablet.Alias)}
 		}
 	}
 	rpcClient, err := bsonrpc.DialHTTP("tcp", tablet.Addr(), connectTimeout)
@@ -425,9 +460,7 @@ func (client *GoRPCTabletManagerClient) Backup(ctx context.Context, tablet *topo
 
 	logstream := make(chan *logutil.LoggerEvent, 10)
 	rpcstream := make(chan *logutil.LoggerEvent, 10)
-	c := rpcClient.StreamGo("TabletManager.Backup", &gorpcproto.BackupArgs{
-		Concurrency: concurrency,
-	}, rpcstream)
+	c := rpcClient.StreamGo("TabletManager."+name, args, rpcstream)
 	interrupted := false
 	go func() {
 		for {
@@ -451,7 +484,7 @@ func (client *GoRPCTabletManagerClient) Backup(ctx context.Context, tablet *topo
 	return logstream, func() error {
 		// this is only called after streaming is done
 		if interrupted {
-			return fmt.Errorf("TabletManager.Backup interrupted by context")
+			return fmt.Errorf("TabletManager.%v interrupted by context", name)
 		}
 		return c.Error
 	}, nil
@@ -244,6 +244,14 @@ func (tm *TabletManager) StartSlave(ctx context.Context, args *rpc.Unused, reply
 	})
 }
 
+// StartSlaveUntilAfter wraps RPCAgent.StartSlaveUntilAfter
+func (tm *TabletManager) StartSlaveUntilAfter(ctx context.Context, args *gorpcproto.StartSlaveUntilAfterArgs, reply *rpc.Unused) error {
+	ctx = callinfo.RPCWrapCallInfo(ctx)
+	return tm.agent.RPCWrapLock(ctx, actionnode.TabletActionStartSlaveUntilAfter, args, reply, true, func() error {
+		return tm.agent.StartSlaveUntilAfter(ctx, args.Position, args.WaitTime)
+	})
+}
+
 // TabletExternallyReparented wraps RPCAgent.TabletExternallyReparented
 func (tm *TabletManager) TabletExternallyReparented(ctx context.Context, args *gorpcproto.TabletExternallyReparentedArgs, reply *rpc.Unused) error {
 	ctx = callinfo.RPCWrapCallInfo(ctx)
@@ -451,7 +459,66 @@ func (tm *TabletManager) Backup(ctx context.Context, args *gorpcproto.BackupArgs
 			wg.Done()
 		}()
 
-		err := tm.agent.Backup(ctx, args.Concurrency, logger)
+		err := tm.agent.Backup(ctx, args.Concurrency, args.Incremental, logger)
+		close(logger)
+		wg.Wait()
+		return err
+	})
+}
+
+// RestoreToPointInTime wraps RPCAgent.RestoreToPointInTime
+func (tm *TabletManager) RestoreToPointInTime(ctx context.Context, args *gorpcproto.RestoreToPointInTimeArgs, sendReply func(interface{}) error) error {
+	ctx = callinfo.RPCWrapCallInfo(ctx)
+	return tm.agent.RPCWrapLockAction(ctx, actionnode.TabletActionRestoreToPointInTime, args, nil, true, func() error {
+		var t time.Time
+		if args.Time != 0 {
+			t = time.Unix(args.Time, 0)
+		}
+
+		// create a logger, send the result back to the caller
+		logger := logutil.NewChannelLogger(10)
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			for e := range logger {
+				// Note we don't interrupt the loop here, as
+				// we still need to flush and finish the
+				// command, even if the channel to the client
+				// has been broken. We'll just keep trying
+				// to send.
+				sendReply(&e)
+			}
+			wg.Done()
+		}()
+
+		err := tm.agent.RestoreToPointInTime(ctx, args.Position, t, logger)
+		close(logger)
+		wg.Wait()
+		return err
+	})
+}
+
+// VerifyBackup wraps RPCAgent.VerifyBackup
+func (tm *TabletManager) VerifyBackup(ctx context.Context, args *gorpcproto.VerifyBackupArgs, sendReply func(interface{}) error) error {
+	ctx = callinfo.RPCWrapCallInfo(ctx)
+	return tm.agent.RPCWrapLockAction(ctx, actionnode.TabletActionVerifyBackup, args, nil, true, func() error {
+		// create a logger, send the result back to the caller
+		logger := logutil.NewChannelLogger(10)
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			for e := range logger {
+				// Note we don't interrupt the loop here, as
+				// we still need to flush and finish the
+				// command, even if the channel to the client
+				// has been broken. We'll just keep trying
+				// to send.
+				sendReply(&e)
+			}
+			wg.Done()
+		}()
+
+		err := tm.agent.VerifyBackup(ctx, args.Name, logger)
 		close(logger)
 		wg.Wait()
 		return err
@@ -390,6 +390,20 @@ func (client *Client) StartSlave(ctx context.Context, tablet *topo.TabletInfo) e
 	return err
 }
 
+// StartSlaveUntilAfter is part of the tmclient.TabletManagerClient interface
+func (client *Client) StartSlaveUntilAfter(ctx context.Context, tablet *topo.TabletInfo, position myproto.ReplicationPosition, waitTime time.Duration) error {
+	cc, c, err := client.dial(ctx, tablet)
+	if err != nil {
+		return err
+	}
+	defer cc.Close()
+	_, err = c.StartSlaveUntilAfter(ctx, &pb.StartSlaveUntilAfterRequest{
+		Position:    myproto.EncodeReplicationPosition(position),
+		WaitTimeout: int64(waitTime),
+	})
+	return err
+}
+
 // TabletExternallyReparented is part of the tmclient.TabletManagerClient interface
 func (client *Client) TabletExternallyReparented(ctx context.Context, tablet *topo.TabletInfo, externalID string) error {
 	cc, c, err := client.dial(ctx, tablet)
@@ -655,7 +669,7 @@ func (client *Client) PromoteSlave(ctx context.Context, tablet *topo.TabletInfo)
 //
 
 // Backup is part of the tmclient.TabletManagerClient interface
-func (client *Client) Backup(ctx context.Context, tablet *topo.TabletInfo, concurrency int) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
+func (client *Client) Backup(ctx context.Context, tablet *topo.TabletInfo, concurrency int, incremental bool) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
 	cc, c, err := client.dial(ctx, tablet)
 	if err != nil {
 		return nil, nil, err
@@ -664,6 +678,7 @@ func (client *Client) Backup(ctx context.Context, tablet *topo.TabletInfo, concu
 	logstream := make(chan *logutil.LoggerEvent, 10)
 	stream, err := c.Backup(ctx, &pb.BackupRequest{
 		Concurrency: int64(concurrency),
+		Incremental: incremental,
 	})
 	if err != nil {
 		cc.Close()
@@ -690,6 +705,83 @@ func (client *Client) Backup(ctx context.Context, tablet *topo.TabletInfo, concu
 	}, nil
 }
 
+// RestoreToPointInTime is part of the tmclient.TabletManagerClient interface
+func (client *Client) RestoreToPointInTime(ctx context.Context, tablet *topo.TabletInfo, pos myproto.ReplicationPosition, t time.Time) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
+	cc, c, err := client.dial(ctx, tablet)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	request := &pb.RestoreToPointInTimeRequest{
+		Position: myproto.EncodeReplicationPosition(pos),
+	}
+	if !t.IsZero() {
+		request.Time = t.Unix()
+	}
+	logstream := make(chan *logutil.LoggerEvent, 10)
+	stream, err := c.RestoreToPointInTime(ctx, request)
+	if err != nil {
+		cc.Close()
+		return nil, nil, err
+	}
+
+	var finalErr error
+	go func() {
+		for {
+			br, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					finalErr = err
+				}
+				close(logstream)
+				return
+			}
+			logstream <- logutil.ProtoToLoggerEvent(br.Event)
+		}
+	}()
+	return logstream, func() error {
+		cc.Close()
+		return finalErr
+	}, nil
+}
+
+// VerifyBackup is part of the tmclient.TabletManagerClient interface
+func (client *Client) VerifyBackup(ctx context.Context, tablet *topo.TabletInfo, name string) (<-chan *logutil.LoggerEvent, tmclient.ErrFunc, error) {
+	cc, c, err := client.dial(ctx, tablet)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	request := &pb.VerifyBackupRequest{
+		Name: name,
+	}
+	logstream := make(chan *logutil.LoggerEvent, 10)
+	stream, err := c.VerifyBackup(ctx, request)
+	if err != nil {
+		cc.Close()
+		return nil, nil, err
+	}
+
+	var finalErr error
+	go func() {
+		for {
+			br, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					finalErr = err
+				}
+				close(logstream)
+				return
+			}
+			logstream <- logutil.ProtoToLoggerEvent(br.Event)
+		}
+	}()
+	return logstream, func() error {
+		cc.Close()
+		return finalErr
+	}, nil
+}
+
 //
 // RPC related methods
 //
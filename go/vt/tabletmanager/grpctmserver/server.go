@@ -263,6 +263,18 @@ func (s *server) StartSlave(ctx context.Context, request *pb.StartSlaveRequest)
 	})
 }
 
+func (s *server) StartSlaveUntilAfter(ctx context.Context, request *pb.StartSlaveUntilAfterRequest) (*pb.StartSlaveUntilAfterResponse, error) {
+	ctx = callinfo.GRPCCallInfo(ctx)
+	response := &pb.StartSlaveUntilAfterResponse{}
+	return response, s.agent.RPCWrapLock(ctx, actionnode.TabletActionStartSlaveUntilAfter, request, response, true, func() error {
+		position, err := myproto.DecodeReplicationPosition(request.Position)
+		if err != nil {
+			return err
+		}
+		return s.agent.StartSlaveUntilAfter(ctx, position, time.Duration(request.WaitTimeout))
+	})
+}
+
 func (s *server) TabletExternallyReparented(ctx context.Context, request *pb.TabletExternallyReparentedRequest) (*pb.TabletExternallyReparentedResponse, error) {
 	ctx = callinfo.GRPCCallInfo(ctx)
 	response := &pb.TabletExternallyReparentedResponse{}
@@ -475,7 +487,74 @@ func (s *server) Backup(request *pb.BackupRequest, stream pbs.TabletManager_Back
 			wg.Done()
 		}()
 
-		err := s.agent.Backup(ctx, int(request.Concurrency), logger)
+		err := s.agent.Backup(ctx, int(request.Concurrency), request.Incremental, logger)
+		close(logger)
+		wg.Wait()
+		return err
+	})
+}
+
+func (s *server) RestoreToPointInTime(request *pb.RestoreToPointInTimeRequest, stream pbs.TabletManager_RestoreToPointInTimeServer) error {
+	ctx := callinfo.GRPCCallInfo(stream.Context())
+	return s.agent.RPCWrapLockAction(ctx, actionnode.TabletActionRestoreToPointInTime, request, nil, true, func() error {
+		position, err := myproto.DecodeReplicationPosition(request.Position)
+		if err != nil {
+			return err
+		}
+		var t time.Time
+		if request.Time != 0 {
+			t = time.Unix(request.Time, 0)
+		}
+
+		// create a logger, send the result back to the caller
+		logger := logutil.NewChannelLogger(10)
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			for e := range logger {
+				// Note we don't interrupt the loop here, as
+				// we still need to flush and finish the
+				// command, even if the channel to the client
+				// has been broken. We'll just keep trying
+				// to send.
+				stream.Send(&pb.RestoreToPointInTimeResponse{
+					Event: logutil.LoggerEventToProto(&e),
+				})
+
+			}
+			wg.Done()
+		}()
+
+		err = s.agent.RestoreToPointInTime(ctx, position, t, logger)
+		close(logger)
+		wg.Wait()
+		return err
+	})
+}
+
+func (s *server) VerifyBackup(request *pb.VerifyBackupRequest, stream pbs.TabletManager_VerifyBackupServer) error {
+	ctx := callinfo.GRPCCallInfo(stream.Context())
+	return s.agent.RPCWrapLockAction(ctx, actionnode.TabletActionVerifyBackup, request, nil, true, func() error {
+		// create a logger, send the result back to the caller
+		logger := logutil.NewChannelLogger(10)
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			for e := range logger {
+				// Note we don't interrupt the loop here, as
+				// we still need to flush and finish the
+				// command, even if the channel to the client
+				// has been broken. We'll just keep trying
+				// to send.
+				stream.Send(&pb.VerifyBackupResponse{
+					Event: logutil.LoggerEventToProto(&e),
+				})
+
+			}
+			wg.Done()
+		}()
+
+		err := s.agent.VerifyBackup(ctx, request.Name, logger)
 		close(logger)
 		wg.Wait()
 		return err
@@ -17,6 +17,7 @@ import (
 
 	log "github.com/golang/glog"
 	"github.com/youtube/vitess/go/timer"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 	"github.com/youtube/vitess/go/vt/servenv"
 	"github.com/youtube/vitess/go/vt/topo"
 	"github.com/youtube/vitess/go/vt/topo/topoproto"
@@ -250,11 +251,22 @@ func (agent *ActionAgent) runHealthCheck(targetTabletType pbt.TabletType) {
 		}
 	}
 
+	// get the semi-sync status, it is only informational
+	var semiSyncStatus myproto.SemiSyncStatus
+	if *enableSemiSync {
+		var ssErr error
+		semiSyncStatus, ssErr = agent.MysqlDaemon.SemiSyncStatus()
+		if ssErr != nil {
+			log.Warningf("cannot get semi-sync status: %v", ssErr)
+		}
+	}
+
 	// remember our health status
 	agent.mutex.Lock()
 	agent._healthy = err
 	agent._healthyTime = time.Now()
 	agent._replicationDelay = replicationDelay
+	agent._semiSyncStatus = semiSyncStatus
 	agent.mutex.Unlock()
 
 	// send it to our observers
@@ -0,0 +1,37 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabletmanager
+
+// This file handles the replication heartbeat. It is enabled by
+// passing the enable_heartbeat command line parameter. The master
+// then writes its heartbeat in the _vt.heartbeat table, and the
+// replicas can compute their replication lag from it, see
+// mysqlctl.HeartbeatReplicationLag.
+
+import (
+	"flag"
+
+	log "github.com/golang/glog"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+var enableHeartbeat = flag.Bool("enable_heartbeat", false, "Write the replication heartbeat in _vt.heartbeat when the tablet is a master, every -heartbeat_interval.")
+
+// fixHeartbeat starts writing the heartbeat on masters, and stops it
+// on other tablet types.
+func (agent *ActionAgent) fixHeartbeat(tabletType pb.TabletType) {
+	if agent.HeartbeatWriter == nil {
+		return
+	}
+	if tabletType != pb.TabletType_MASTER {
+		agent.HeartbeatWriter.Close()
+		return
+	}
+	if err := agent.HeartbeatWriter.Open(); err != nil {
+		// the HeartbeatWriter keeps trying at every interval
+		log.Errorf("Cannot start the heartbeat, will retry: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabletmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/youtube/vitess/go/vt/binlog/binlogplayer"
+	"github.com/youtube/vitess/go/vt/discovery"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+// replicaLagThrottler is a binlogplayer.Throttler which throttles the
+// players of a shard master while the slaves of the shard lag too much.
+// Filtered replication can write faster than the slaves replicate,
+// and it would otherwise take them out of serving.
+type replicaLagThrottler struct {
+	keyspace string
+	shard    string
+	maxLag   time.Duration
+
+	healthCheck discovery.HealthCheck
+	watcher     *discovery.TopologyWatcher
+}
+
+// newReplicaLagThrottler starts watching the slaves of the shard in a cell.
+func newReplicaLagThrottler(ts topo.Server, cell, keyspace, shard string, maxLag time.Duration) *replicaLagThrottler {
+	rlt := &replicaLagThrottler{
+		keyspace:    keyspace,
+		shard:       shard,
+		maxLag:      maxLag,
+		healthCheck: discovery.NewHealthCheck(*binlogplayer.BinlogPlayerConnTimeout, *retryDelay),
+	}
+	rlt.watcher = discovery.NewShardReplicationWatcher(ts, rlt.healthCheck, cell, keyspace, shard, *healthcheckTopologyRefresh, 5)
+	return rlt
+}
+
+// Throttled is part of the binlogplayer.Throttler interface. Slaves
+// which are not healthy are ignored, so they don't stop filtered
+// replication for ever.
+func (rlt *replicaLagThrottler) Throttled() string {
+	maxLag := uint32(rlt.maxLag.Seconds())
+	for _, tabletType := range []pb.TabletType{pb.TabletType_REPLICA, pb.TabletType_RDONLY} {
+		for _, eps := range rlt.healthCheck.GetEndPointStatsFromTarget(rlt.keyspace, rlt.shard, tabletType) {
+			if !eps.Up || eps.LastError != nil || eps.Stats == nil || eps.Stats.HealthError != "" {
+				continue
+			}
+			if eps.Stats.SecondsBehindMaster > maxLag {
+				alias := &pb.TabletAlias{
+					Cell: eps.Cell,
+					Uid:  eps.EndPoint.Uid,
+				}
+				return fmt.Sprintf("%v tablet %v is %v seconds behind master, more than %v", tabletType, topoproto.TabletAliasString(alias), eps.Stats.SecondsBehindMaster, rlt.maxLag)
+			}
+		}
+	}
+	return ""
+}
+
+// stop stops watching the slaves.
+func (rlt *replicaLagThrottler) stop() {
+	rlt.watcher.Stop()
+}
@@ -0,0 +1,88 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabletmanager
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/youtube/vitess/go/vt/discovery"
+
+	pbq "github.com/youtube/vitess/go/vt/proto/query"
+	pbt "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+// statsHealthCheck returns fixed EndPointStats for each tablet type.
+// It only implements GetEndPointStatsFromTarget.
+type statsHealthCheck struct {
+	discovery.HealthCheck
+	stats map[pbt.TabletType][]*discovery.EndPointStats
+}
+
+func (shc *statsHealthCheck) GetEndPointStatsFromTarget(keyspace, shard string, tabletType pbt.TabletType) []*discovery.EndPointStats {
+	if keyspace != "ks" || shard != "-80" {
+		return nil
+	}
+	return shc.stats[tabletType]
+}
+
+func lagStats(uid uint32, secondsBehindMaster uint32) *discovery.EndPointStats {
+	return &discovery.EndPointStats{
+		EndPoint: &pbt.EndPoint{Uid: uid},
+		Cell:     "cell1",
+		Up:       true,
+		Serving:  true,
+		Stats:    &pbq.RealtimeStats{SecondsBehindMaster: secondsBehindMaster},
+	}
+}
+
+func TestReplicaLagThrottler(t *testing.T) {
+	shc := &statsHealthCheck{
+		stats: map[pbt.TabletType][]*discovery.EndPointStats{
+			pbt.TabletType_REPLICA: {lagStats(1, 2), lagStats(2, 5)},
+			pbt.TabletType_RDONLY:  {lagStats(3, 0)},
+		},
+	}
+	rlt := &replicaLagThrottler{
+		keyspace:    "ks",
+		shard:       "-80",
+		maxLag:      5 * time.Second,
+		healthCheck: shc,
+	}
+
+	// at most -binlog_player_max_replica_lag behind
+	if reason := rlt.Throttled(); reason != "" {
+		t.Errorf("Throttled with slaves under the max lag: %v", reason)
+	}
+
+	// one rdonly tablet is behind
+	shc.stats[pbt.TabletType_RDONLY] = append(shc.stats[pbt.TabletType_RDONLY], lagStats(4, 6))
+	reason := rlt.Throttled()
+	if want := "RDONLY tablet cell1-0000000004 is 6 seconds behind master, more than 5s"; reason != want {
+		t.Errorf("Throttled with a lagging rdonly tablet: got %q, want %q", reason, want)
+	}
+
+	// unhealthy tablets are ignored
+	unhealthy := lagStats(4, 60)
+	unhealthy.Stats.HealthError = "replication is not running"
+	down := lagStats(5, 60)
+	down.Up = false
+	broken := lagStats(6, 60)
+	broken.LastError = errors.New("connection refused")
+	noStats := lagStats(7, 60)
+	noStats.Stats = nil
+	shc.stats[pbt.TabletType_RDONLY] = []*discovery.EndPointStats{unhealthy, down, broken, noStats}
+	if reason := rlt.Throttled(); reason != "" {
+		t.Errorf("Throttled with lagging unhealthy slaves: %v", reason)
+	}
+
+	// a lagging replica
+	shc.stats[pbt.TabletType_REPLICA][0].Stats.SecondsBehindMaster = 30
+	if reason := rlt.Throttled(); !strings.Contains(reason, "REPLICA tablet cell1-0000000001 is 30 seconds behind master") {
+		t.Errorf("Throttled with a lagging replica: got %q", reason)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabletmanager
+
+// This file handles semi-sync replication. It is enabled by passing
+// the enable_semi_sync command line parameter. The master side is
+// then enabled on masters, and the slave side on the tablets that
+// may become master, so they acknowledge the transactions they
+// receive. rdonly tablets never acknowledge.
+
+import (
+	"flag"
+
+	log "github.com/golang/glog"
+	"github.com/youtube/vitess/go/vt/mysqlctl"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+var enableSemiSync = flag.Bool("enable_semi_sync", false, "Enable semi-sync replication: the master waits for a replica to acknowledge each transaction before committing it. rdonly tablets never acknowledge.")
+
+// semiSyncSlaveType returns true if tablets of this type should
+// acknowledge transactions as semi-sync slaves.
+func semiSyncSlaveType(tabletType pb.TabletType) bool {
+	switch tabletType {
+	case pb.TabletType_REPLICA, pb.TabletType_SPARE:
+		return true
+	}
+	return false
+}
+
+// fixSemiSync enables the master or slave side of semi-sync
+// replication, depending on the tablet type. The slave side only
+// takes effect once the slave IO thread is (re)started.
+func (agent *ActionAgent) fixSemiSync(tabletType pb.TabletType) error {
+	if !*enableSemiSync {
+		return nil
+	}
+	master := tabletType == pb.TabletType_MASTER
+	slave := semiSyncSlaveType(tabletType)
+	log.Infof("setting semi-sync for tablet type %v: master=%v slave=%v", tabletType, master, slave)
+	return agent.MysqlDaemon.SetSemiSyncEnabled(master, slave)
+}
+
+// fixSemiSyncAndReplication is like fixSemiSync, but also restarts
+// the slave IO thread if it is running, so a change of the slave side
+// takes effect right away.
+func (agent *ActionAgent) fixSemiSyncAndReplication(tabletType pb.TabletType) error {
+	if !*enableSemiSync {
+		return nil
+	}
+	status, err := agent.MysqlDaemon.SemiSyncStatus()
+	if err != nil {
+		return err
+	}
+	if err := agent.fixSemiSync(tabletType); err != nil {
+		return err
+	}
+	if status.SlaveEnabled == semiSyncSlaveType(tabletType) {
+		return nil
+	}
+	rs, err := agent.MysqlDaemon.SlaveStatus()
+	if err != nil || !rs.SlaveIORunning {
+		// not replicating, the next START SLAVE will pick it up
+		return nil
+	}
+	return agent.MysqlDaemon.ExecuteSuperQueryList([]string{
+		mysqlctl.SQLStopSlaveIOThread,
+		mysqlctl.SQLStartSlaveIOThread,
+	})
+}
+
+// addSemiSyncStatus fills in the semi-sync part of a ReplicationStatus.
+// It has to be called before replication is stopped, as a stopped
+// slave doesn't acknowledge anything.
+func (agent *ActionAgent) addSemiSyncStatus(rs *myproto.ReplicationStatus) {
+	if !*enableSemiSync {
+		return
+	}
+	status, err := agent.MysqlDaemon.SemiSyncStatus()
+	if err != nil {
+		log.Warningf("cannot get semi-sync status: %v", err)
+		return
+	}
+	rs.SemiSyncSlaveActive = status.SlaveActive
+}
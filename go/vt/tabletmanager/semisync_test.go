@@ -0,0 +1,79 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabletmanager
+
+import (
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/mysqlctl"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+func TestFixSemiSync(t *testing.T) {
+	*enableSemiSync = true
+	defer func() { *enableSemiSync = false }()
+
+	fmd := mysqlctl.NewFakeMysqlDaemon(nil)
+	agent := &ActionAgent{MysqlDaemon: fmd}
+	cases := []struct {
+		tabletType    pb.TabletType
+		master, slave bool
+	}{
+		{pb.TabletType_MASTER, true, false},
+		{pb.TabletType_REPLICA, false, true},
+		{pb.TabletType_SPARE, false, true},
+		{pb.TabletType_RDONLY, false, false},
+		{pb.TabletType_BACKUP, false, false},
+	}
+	for _, c := range cases {
+		if err := agent.fixSemiSync(c.tabletType); err != nil {
+			t.Fatalf("fixSemiSync(%v) failed: %v", c.tabletType, err)
+		}
+		if fmd.SemiSyncMasterEnabled != c.master || fmd.SemiSyncSlaveEnabled != c.slave {
+			t.Errorf("fixSemiSync(%v): got master=%v slave=%v, want master=%v slave=%v", c.tabletType, fmd.SemiSyncMasterEnabled, fmd.SemiSyncSlaveEnabled, c.master, c.slave)
+		}
+	}
+
+	// A replicating slave that stops acknowledging restarts its IO thread.
+	fmd.SemiSyncSlaveEnabled = true
+	fmd.Replicating = true
+	fmd.ExpectedExecuteSuperQueryList = []string{
+		mysqlctl.SQLStopSlaveIOThread,
+		mysqlctl.SQLStartSlaveIOThread,
+	}
+	if err := agent.fixSemiSyncAndReplication(pb.TabletType_RDONLY); err != nil {
+		t.Fatalf("fixSemiSyncAndReplication failed: %v", err)
+	}
+	if err := fmd.CheckSuperQueryList(); err != nil {
+		t.Errorf("fixSemiSyncAndReplication didn't restart replication: %v", err)
+	}
+}
+
+func TestAddSemiSyncStatus(t *testing.T) {
+	*enableSemiSync = true
+	defer func() { *enableSemiSync = false }()
+
+	fmd := mysqlctl.NewFakeMysqlDaemon(nil)
+	agent := &ActionAgent{MysqlDaemon: fmd}
+	cases := []struct {
+		enabled, replicating, want bool
+	}{
+		{false, true, false},
+		// enabled but not connected to a master: not acknowledging
+		{true, false, false},
+		{true, true, true},
+	}
+	for _, c := range cases {
+		fmd.SemiSyncSlaveEnabled = c.enabled
+		fmd.Replicating = c.replicating
+		rs := myproto.ReplicationStatus{}
+		agent.addSemiSyncStatus(&rs)
+		if rs.SemiSyncSlaveActive != c.want {
+			t.Errorf("addSemiSyncStatus with enabled=%v replicating=%v: got SemiSyncSlaveActive=%v, want %v", c.enabled, c.replicating, rs.SemiSyncSlaveActive, c.want)
+		}
+	}
+}
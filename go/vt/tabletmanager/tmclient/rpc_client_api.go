@@ -103,6 +103,11 @@ type TabletManagerClient interface {
 	// StartSlave starts the mysql replication
 	StartSlave(ctx context.Context, tablet *topo.TabletInfo) error
 
+	// StartSlaveUntilAfter starts the mysql replication until it has
+	// applied all the transactions of the provided position, and
+	// waits for it. Replication must be stopped.
+	StartSlaveUntilAfter(ctx context.Context, tablet *topo.TabletInfo, position myproto.ReplicationPosition, waitTime time.Duration) error
+
 	// TabletExternallyReparented tells a tablet it is now the master, after an
 	// external tool has already promoted the underlying mysqld to master and
 	// reparented the other mysqld servers to it.
@@ -181,8 +186,18 @@ type TabletManagerClient interface {
 	// Backup / restore related methods
 	//
 
-	// Backup creates a database backup
-	Backup(ctx context.Context, tablet *topo.TabletInfo, concurrency int) (<-chan *logutil.LoggerEvent, ErrFunc, error)
+	// Backup creates a database backup. An incremental backup only
+	// stores the binlogs since the last backup.
+	Backup(ctx context.Context, tablet *topo.TabletInfo, concurrency int, incremental bool) (<-chan *logutil.LoggerEvent, ErrFunc, error)
+
+	// RestoreToPointInTime restores the most recent full backup
+	// before the target, and replays the binlogs of the incremental
+	// backups up to it. The target is pos, or t if it is not zero.
+	RestoreToPointInTime(ctx context.Context, tablet *topo.TabletInfo, pos myproto.ReplicationPosition, t time.Time) (<-chan *logutil.LoggerEvent, ErrFunc, error)
+
+	// VerifyBackup restores a full backup of the shard of the tablet
+	// into a scratch mysqld, and checks its tables.
+	VerifyBackup(ctx context.Context, tablet *topo.TabletInfo, name string) (<-chan *logutil.LoggerEvent, ErrFunc, error)
 
 	//
 	// RPC related methods
@@ -819,3 +819,34 @@ func (tee *Tee) SaveVSchema(ctx context.Context, contents string) error {
 func (tee *Tee) GetVSchema(ctx context.Context) (string, error) {
 	return tee.readFrom.GetVSchema(ctx)
 }
+
+// SaveClusterOperation is part of the topo.ClusterOperationStore interface
+func (tee *Tee) SaveClusterOperation(ctx context.Context, id, contents string) error {
+	primary, ok := tee.primary.(topo.ClusterOperationStore)
+	if !ok {
+		return fmt.Errorf("primary %T cannot store cluster operations", tee.primary)
+	}
+	if err := primary.SaveClusterOperation(ctx, id, contents); err != nil {
+		return err
+	}
+
+	secondary, ok := tee.secondary.(topo.ClusterOperationStore)
+	if !ok {
+		log.Warningf("secondary %T cannot store cluster operations, not saving %v", tee.secondary, id)
+		return nil
+	}
+	if err := secondary.SaveClusterOperation(ctx, id, contents); err != nil {
+		// not critical enough to fail
+		log.Warningf("secondary.SaveClusterOperation(%v) failed: %v", id, err)
+	}
+	return nil
+}
+
+// GetClusterOperations is part of the topo.ClusterOperationStore interface
+func (tee *Tee) GetClusterOperations(ctx context.Context) (map[string]string, error) {
+	readFrom, ok := tee.readFrom.(topo.ClusterOperationStore)
+	if !ok {
+		return nil, fmt.Errorf("%T cannot store cluster operations", tee.readFrom)
+	}
+	return readFrom.GetClusterOperations(ctx)
+}
@@ -0,0 +1,104 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package topo
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/event"
+	"github.com/youtube/vitess/go/vt/topo/events"
+)
+
+// This file contains the multi-record update utility functions
+
+// SupportsMultiUpdate returns true if the underlying Impl can save
+// several global records atomically.
+func (ts Server) SupportsMultiUpdate() bool {
+	_, ok := ts.Impl.(MultiUpdater)
+	return ok
+}
+
+// UpdateKeyspacesAndShards saves all the provided keyspace and shard
+// records, with the right versions, and the provided serving keyspace
+// records. If the Impl is a MultiUpdater, the keyspace and shard
+// records are saved atomically, and the serving keyspaces after
+// them. Otherwise, they are saved one at a time, in order,
+// keyspaces first and serving keyspaces last, and a failure may leave
+// some of them saved and some not.
+// This should be called while holding the locks on all the keyspace
+// and shard records.
+func (ts Server) UpdateKeyspacesAndShards(ctx context.Context, kis []*KeyspaceInfo, sis []*ShardInfo, srvKeyspaces []*SrvKeyspaceUpdate) error {
+	mu, ok := ts.Impl.(MultiUpdater)
+	if !ok {
+		for _, ki := range kis {
+			if err := ts.UpdateKeyspace(ctx, ki); err != nil {
+				return err
+			}
+		}
+		for _, si := range sis {
+			if err := ts.UpdateShard(ctx, si); err != nil {
+				return err
+			}
+		}
+		for _, sku := range srvKeyspaces {
+			if err := ts.UpdateSrvKeyspace(ctx, sku.Cell, sku.Keyspace, sku.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	keyspaces := make([]*KeyspaceUpdate, len(kis))
+	for i, ki := range kis {
+		keyspaces[i] = &KeyspaceUpdate{
+			Keyspace:        ki.keyspace,
+			Value:           ki.Keyspace,
+			ExistingVersion: existingVersion(ki.version),
+		}
+	}
+	shards := make([]*ShardUpdate, len(sis))
+	for i, si := range sis {
+		shards[i] = &ShardUpdate{
+			Keyspace:        si.keyspace,
+			Shard:           si.shardName,
+			Value:           si.Shard,
+			ExistingVersion: existingVersion(si.version),
+		}
+	}
+
+	keyspaceVersions, shardVersions, err := mu.UpdateMulti(ctx, keyspaces, shards, srvKeyspaces)
+	if len(keyspaceVersions) != len(kis) || len(shardVersions) != len(sis) {
+		// nothing was saved
+		return err
+	}
+
+	for i, ki := range kis {
+		ki.version = keyspaceVersions[i]
+		event.Dispatch(&events.KeyspaceChange{
+			KeyspaceName: ki.keyspace,
+			Keyspace:     ki.Keyspace,
+			Status:       "updated",
+		})
+	}
+	for i, si := range sis {
+		si.version = shardVersions[i]
+		event.Dispatch(&events.ShardChange{
+			KeyspaceName: si.Keyspace(),
+			ShardName:    si.ShardName(),
+			Shard:        si.Shard,
+			Status:       "updated",
+		})
+	}
+	return err
+}
+
+// existingVersion maps the version of a record we read to the version
+// the Impl update methods expect: -1 if we never read it.
+func existingVersion(version int64) int64 {
+	if version == 0 {
+		return -1
+	}
+	return version
+}
@@ -319,6 +319,72 @@ type Impl interface {
 	GetVSchema(ctx context.Context) (string, error)
 }
 
+// KeyspaceUpdate describes a keyspace record to save as part of a
+// MultiUpdater transaction. ExistingVersion has the same meaning as
+// in Impl.UpdateKeyspace.
+type KeyspaceUpdate struct {
+	Keyspace        string
+	Value           *pb.Keyspace
+	ExistingVersion int64
+}
+
+// ShardUpdate describes a shard record to save as part of a
+// MultiUpdater transaction. ExistingVersion has the same meaning as
+// in Impl.UpdateShard.
+type ShardUpdate struct {
+	Keyspace        string
+	Shard           string
+	Value           *pb.Shard
+	ExistingVersion int64
+}
+
+// SrvKeyspaceUpdate describes a serving keyspace record to save as
+// part of a MultiUpdater transaction. Like with
+// Impl.UpdateSrvKeyspace, it is created if it doesn't exist, and
+// saved whatever its current version is.
+type SrvKeyspaceUpdate struct {
+	Cell     string
+	Keyspace string
+	Value    *pb.SrvKeyspace
+}
+
+// MultiUpdater is an optional interface a topo.Impl can implement
+// if it can save several global records atomically. Do not
+// implement it if the backend has no multi-record transactions.
+type MultiUpdater interface {
+	// UpdateMulti saves all the provided keyspace and shard
+	// records in a single transaction: either they are all saved,
+	// or none of them is. It returns their new versions, in the
+	// same order as the input.
+	// The serving keyspace records may live in a different
+	// backend, so they are saved after the transaction is
+	// committed. If one of them cannot be saved, UpdateMulti
+	// returns the new versions and an error, and the serving
+	// keyspace needs to be rebuilt.
+	// This will only be called with a lock on all the keyspace
+	// and shard records.
+	// Can return ErrNoNode if one of the keyspace or shard records
+	// doesn't exist, or ErrBadVersion if one of their versions
+	// has changed.
+	//
+	// Do not use directly, but instead use
+	// topo.UpdateKeyspacesAndShards.
+	UpdateMulti(ctx context.Context, keyspaces []*KeyspaceUpdate, shards []*ShardUpdate, srvKeyspaces []*SrvKeyspaceUpdate) (keyspaceVersions, shardVersions []int64, err error)
+}
+
+// ClusterOperationStore is an optional interface a topo.Impl can
+// implement if it can store the checkpoints of the automation cluster
+// operations, in the global topology.
+type ClusterOperationStore interface {
+	// SaveClusterOperation saves the checkpoint of an automation
+	// cluster operation, replacing the previous one with the same id.
+	SaveClusterOperation(ctx context.Context, id, contents string) error
+
+	// GetClusterOperations returns the checkpoints of all the
+	// automation cluster operations, indexed by id.
+	GetClusterOperations(ctx context.Context) (map[string]string, error)
+}
+
 // Server is a wrapper type that can have extra methods.
 // Outside modules should just use the Server object.
 type Server struct {
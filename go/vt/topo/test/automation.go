@@ -0,0 +1,51 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/topo"
+	"golang.org/x/net/context"
+)
+
+// CheckClusterOperations runs the tests on the automation checkpoints
+// part of the API
+func CheckClusterOperations(ctx context.Context, t *testing.T, ts topo.Impl) {
+	cos, ok := ts.(topo.ClusterOperationStore)
+	if !ok {
+		t.Fatalf("%T doesn't implement topo.ClusterOperationStore", ts)
+	}
+	got, err := cos.GetClusterOperations(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterOperations: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("GetClusterOperations: %v, want nothing", got)
+	}
+
+	if err := cos.SaveClusterOperation(ctx, "1", "first"); err != nil {
+		t.Fatalf("SaveClusterOperation: %v", err)
+	}
+	if err := cos.SaveClusterOperation(ctx, "2", "second"); err != nil {
+		t.Fatalf("SaveClusterOperation: %v", err)
+	}
+	if err := cos.SaveClusterOperation(ctx, "1", "first updated"); err != nil {
+		t.Fatalf("SaveClusterOperation: %v", err)
+	}
+
+	got, err = cos.GetClusterOperations(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterOperations: %v", err)
+	}
+	want := map[string]string{
+		"1": "first updated",
+		"2": "second",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetClusterOperations: %v, want %v", got, want)
+	}
+}
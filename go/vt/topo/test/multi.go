@@ -0,0 +1,110 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/topo"
+	"golang.org/x/net/context"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+// CheckMultiUpdate tests the topo.MultiUpdater part of the API
+func CheckMultiUpdate(ctx context.Context, t *testing.T, ts topo.Impl) {
+	if _, ok := ts.(topo.MultiUpdater); !ok {
+		t.Fatalf("%T doesn't implement topo.MultiUpdater", ts)
+	}
+	tts := topo.Server{Impl: ts}
+	if !tts.SupportsMultiUpdate() {
+		t.Fatalf("SupportsMultiUpdate returned false")
+	}
+
+	if err := ts.CreateKeyspace(ctx, "test_keyspace", &pb.Keyspace{}); err != nil {
+		t.Fatalf("CreateKeyspace: %v", err)
+	}
+	for _, shard := range []string{"-80", "80-"} {
+		if err := tts.CreateShard(ctx, "test_keyspace", shard); err != nil {
+			t.Fatalf("CreateShard(%v): %v", shard, err)
+		}
+	}
+
+	ki, err := tts.GetKeyspace(ctx, "test_keyspace")
+	if err != nil {
+		t.Fatalf("GetKeyspace: %v", err)
+	}
+	si1, err := tts.GetShard(ctx, "test_keyspace", "-80")
+	if err != nil {
+		t.Fatalf("GetShard: %v", err)
+	}
+	si2, err := tts.GetShard(ctx, "test_keyspace", "80-")
+	if err != nil {
+		t.Fatalf("GetShard: %v", err)
+	}
+
+	// Save everything at once.
+	ki.ShardingColumnName = "user_id"
+	si1.Cells = []string{"c1"}
+	si2.Cells = []string{"c2"}
+	srvKeyspace := &pb.SrvKeyspace{
+		ShardingColumnName: "user_id",
+	}
+	if err := tts.UpdateKeyspacesAndShards(ctx, []*topo.KeyspaceInfo{ki}, []*topo.ShardInfo{si1, si2}, []*topo.SrvKeyspaceUpdate{
+		{Cell: "test", Keyspace: "test_keyspace", Value: srvKeyspace},
+	}); err != nil {
+		t.Fatalf("UpdateKeyspacesAndShards: %v", err)
+	}
+	ki, err = tts.GetKeyspace(ctx, "test_keyspace")
+	if err != nil {
+		t.Fatalf("GetKeyspace: %v", err)
+	}
+	if ki.ShardingColumnName != "user_id" {
+		t.Errorf("bad keyspace after UpdateKeyspacesAndShards: %v", ki.Keyspace)
+	}
+	for _, want := range []*topo.ShardInfo{si1, si2} {
+		got, err := tts.GetShard(ctx, want.Keyspace(), want.ShardName())
+		if err != nil {
+			t.Fatalf("GetShard: %v", err)
+		}
+		if !reflect.DeepEqual(got.Cells, want.Cells) || got.Version() != want.Version() {
+			t.Errorf("bad shard after UpdateKeyspacesAndShards: got %v (version %v), want %v (version %v)", got.Shard, got.Version(), want.Shard, want.Version())
+		}
+	}
+	if got, err := tts.GetSrvKeyspace(ctx, "test", "test_keyspace"); err != nil || !reflect.DeepEqual(got, srvKeyspace) {
+		t.Errorf("bad serving keyspace after UpdateKeyspacesAndShards: got %v, %v, want %v", got, err, srvKeyspace)
+	}
+
+	// A stale version makes the whole update fail.
+	staleSi2, err := tts.GetShard(ctx, "test_keyspace", "80-")
+	if err != nil {
+		t.Fatalf("GetShard: %v", err)
+	}
+	si2.Cells = []string{"c2", "c3"}
+	if err := tts.UpdateShard(ctx, si2); err != nil {
+		t.Fatalf("UpdateShard: %v", err)
+	}
+	ki.ShardingColumnName = "other_id"
+	staleSi2.Cells = []string{"c3"}
+	if err := tts.UpdateKeyspacesAndShards(ctx, []*topo.KeyspaceInfo{ki}, []*topo.ShardInfo{si1, staleSi2}, []*topo.SrvKeyspaceUpdate{
+		{Cell: "test", Keyspace: "test_keyspace", Value: &pb.SrvKeyspace{ShardingColumnName: "other_id"}},
+	}); err != topo.ErrBadVersion {
+		t.Fatalf("UpdateKeyspacesAndShards with a stale version: got %v, want ErrBadVersion", err)
+	}
+	ki, err = tts.GetKeyspace(ctx, "test_keyspace")
+	if err != nil {
+		t.Fatalf("GetKeyspace: %v", err)
+	}
+	if ki.ShardingColumnName != "user_id" {
+		t.Errorf("keyspace was saved by a failed UpdateKeyspacesAndShards: %v", ki.Keyspace)
+	}
+	si2, err = tts.GetShard(ctx, "test_keyspace", "80-")
+	if err != nil {
+		t.Fatalf("GetShard: %v", err)
+	}
+	if !reflect.DeepEqual(si2.Cells, []string{"c2", "c3"}) {
+		t.Errorf("shard was saved by a failed UpdateKeyspacesAndShards: %v", si2.Shard)
+	}
+	if got, err := tts.GetSrvKeyspace(ctx, "test", "test_keyspace"); err != nil || !reflect.DeepEqual(got, srvKeyspace) {
+		t.Errorf("serving keyspace was saved by a failed UpdateKeyspacesAndShards: got %v, %v, want %v", got, err, srvKeyspace)
+	}
+}
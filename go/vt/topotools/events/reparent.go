@@ -20,4 +20,9 @@ type Reparent struct {
 	ShardInfo            topo.ShardInfo
 	OldMaster, NewMaster pb.Tablet
 	ExternalID           string
+
+	// CandidateReasons is set when the new master was chosen
+	// automatically, and explains why each tablet was chosen or
+	// rejected.
+	CandidateReasons []string
 }
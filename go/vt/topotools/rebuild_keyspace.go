@@ -103,6 +103,26 @@ func rebuildKeyspace(ctx context.Context, log logutil.Logger, ts topo.Server, ke
 		}
 	}
 
+	srvKeyspaceMap, err := BuildSrvKeyspaces(ctx, ts, ki, shardCache, cells)
+	if err != nil {
+		return err
+	}
+
+	// and then finally save the keyspace objects
+	for cell, srvKeyspace := range srvKeyspaceMap {
+		log.Infof("updating keyspace serving graph in cell %v for %v", cell, keyspace)
+		if err := ts.UpdateSrvKeyspace(ctx, cell, keyspace, srvKeyspace); err != nil {
+			return fmt.Errorf("writing serving data failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// BuildSrvKeyspaces computes the SrvKeyspace objects of a keyspace,
+// for all the cells its shards are in, limited to the provided cells,
+// from the keyspace record and all its shard records (indexed by
+// shard name). It returns them indexed by cell.
+func BuildSrvKeyspaces(ctx context.Context, ts topo.Server, ki *topo.KeyspaceInfo, shardCache map[string]*topo.ShardInfo, cells []string) (map[string]*pb.SrvKeyspace, error) {
 	// Build the list of cells to work on: we get the union
 	// of all the Cells of all the Shards, limited to the provided cells.
 	//
@@ -116,7 +136,7 @@ func rebuildKeyspace(ctx context.Context, log logutil.Logger, ts topo.Server, ke
 	for _, ksf := range ki.ServedFroms {
 		servedFromShards, err := ts.FindAllShardsInKeyspace(ctx, ksf.Keyspace)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		findCellsForRebuild(ki, servedFromShards, cells, srvKeyspaceMap)
 	}
@@ -149,18 +169,10 @@ func rebuildKeyspace(ctx context.Context, log logutil.Logger, ts topo.Server, ke
 		}
 
 		if err := orderAndCheckPartitions(cell, srvKeyspace); err != nil {
-			return err
-		}
-	}
-
-	// and then finally save the keyspace objects
-	for cell, srvKeyspace := range srvKeyspaceMap {
-		log.Infof("updating keyspace serving graph in cell %v for %v", cell, keyspace)
-		if err := ts.UpdateSrvKeyspace(ctx, cell, keyspace, srvKeyspace); err != nil {
-			return fmt.Errorf("writing serving data failed: %v", err)
+			return nil, err
 		}
 	}
-	return nil
+	return srvKeyspaceMap, nil
 }
 
 // orderAndCheckPartitions will re-order the partition list, and check
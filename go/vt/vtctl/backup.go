@@ -7,7 +7,9 @@ package vtctl
 import (
 	"flag"
 	"fmt"
+	"time"
 
+	"github.com/youtube/vitess/go/vt/mysqlctl"
 	"github.com/youtube/vitess/go/vt/mysqlctl/backupstorage"
 	"github.com/youtube/vitess/go/vt/topo/topoproto"
 	"github.com/youtube/vitess/go/vt/wrangler"
@@ -25,6 +27,11 @@ func init() {
 		commandRemoveBackup,
 		"<keyspace/shard> <backup name>",
 		"Removes a backup for the BackupStorage."})
+	addCommand("Shards", command{
+		"PruneBackups",
+		commandPruneBackups,
+		"[-keep_last=N] [-keep_daily=D] [-keep_weekly=W] [-keep_older_than=<duration>] [-dry_run] <keyspace/shard>",
+		"Removes the backups of a shard that none of the retention rules keeps. The most recent full backup is always kept, and incremental backups are kept with the full backup they build on. Backups that are incomplete or in progress are not removed."})
 }
 
 func commandListBackups(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
@@ -76,3 +83,45 @@ func commandRemoveBackup(ctx context.Context, wr *wrangler.Wrangler, subFlags *f
 	}
 	return bs.RemoveBackup(bucket, name)
 }
+
+func commandPruneBackups(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
+	keepLast := subFlags.Int("keep_last", 0, "Keeps the N most recent full backups")
+	keepDaily := subFlags.Int("keep_daily", 0, "Keeps the most recent full backup of each of the last D days")
+	keepWeekly := subFlags.Int("keep_weekly", 0, "Keeps the most recent full backup of each of the last W weeks")
+	keepOlderThan := subFlags.Duration("keep_older_than", 0, "Keeps the most recent full backup older than this duration, so there is always a backup at least that old")
+	dryRun := subFlags.Bool("dry_run", false, "Only displays which backups would be kept and removed")
+	if err := subFlags.Parse(args); err != nil {
+		return err
+	}
+	if subFlags.NArg() != 1 {
+		return fmt.Errorf("action PruneBackups requires <keyspace/shard>")
+	}
+	policy := mysqlctl.BackupRetentionPolicy{
+		KeepLast:      *keepLast,
+		KeepDaily:     *keepDaily,
+		KeepWeekly:    *keepWeekly,
+		KeepOlderThan: *keepOlderThan,
+	}
+	if policy.IsZero() {
+		return fmt.Errorf("action PruneBackups requires at least one of -keep_last, -keep_daily, -keep_weekly or -keep_older_than")
+	}
+
+	keyspace, shard, err := topoproto.ParseKeyspaceShard(subFlags.Arg(0))
+	if err != nil {
+		return err
+	}
+	bucket := fmt.Sprintf("%v/%v", keyspace, shard)
+
+	decisions, err := mysqlctl.PruneBackups(wr.Logger(), bucket, policy, time.Now(), *dryRun)
+	if err != nil {
+		return err
+	}
+	for _, d := range decisions {
+		if *dryRun && !d.Keep() {
+			wr.Logger().Printf("would %v\n", d)
+			continue
+		}
+		wr.Logger().Printf("%v\n", d)
+	}
+	return nil
+}
@@ -9,9 +9,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/youtube/vitess/go/flagutil"
 	"github.com/youtube/vitess/go/vt/topo/topoproto"
 	"github.com/youtube/vitess/go/vt/wrangler"
 	"golang.org/x/net/context"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
 )
 
 var (
@@ -37,13 +40,13 @@ func init() {
 	addCommand("Shards", command{
 		"PlannedReparentShard",
 		commandPlannedReparentShard,
-		"<keyspace/shard> <tablet alias>",
-		"Reparents the shard to the new master. Both old and new master need to be up and running."})
+		"[-wait_slave_timeout=<duration>] [-prefer_cells=<cell1,cell2,...>] [-exclude_tags=<key1:value1,...>] [-max_replication_lag=<duration>] <keyspace/shard> [<tablet alias>]",
+		"Reparents the shard to the new master. Both old and new master need to be up and running. If no tablet alias is provided, the new master is chosen among the replica tablets, using the cell preference, excluded tags and replication lag limit."})
 	addCommand("Shards", command{
 		"EmergencyReparentShard",
 		commandEmergencyReparentShard,
-		"<keyspace/shard> <tablet alias>",
-		"Reparents the shard to the new master. Assumes the old master is dead and not responsding."})
+		"[-wait_slave_timeout=<duration>] [-prefer_cells=<cell1,cell2,...>] [-exclude_tags=<key1:value1,...>] [-require_semi_sync_slave] <keyspace/shard> [<tablet alias>]",
+		"Reparents the shard to the new master. Assumes the old master is dead and not responsding. If no tablet alias is provided, the new master is chosen among the most advanced replica tablets, using the cell preference and excluded tags. With -require_semi_sync_slave, only a tablet that was acknowledging transactions as a semi-sync slave can become the new master."})
 }
 
 func commandDemoteMaster(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
@@ -117,22 +120,35 @@ func commandPlannedReparentShard(ctx context.Context, wr *wrangler.Wrangler, sub
 	}
 
 	waitSlaveTimeout := subFlags.Duration("wait_slave_timeout", 30*time.Second, "time to wait for slaves to catch up in reparenting")
+	var preferCells flagutil.StringListValue
+	subFlags.Var(&preferCells, "prefer_cells", "when choosing the new master, prefer tablets in these cells, in order")
+	var excludeTags flagutil.StringMapValue
+	subFlags.Var(&excludeTags, "exclude_tags", "when choosing the new master, exclude tablets with any of these tags")
+	maxReplicationLag := subFlags.Duration("max_replication_lag", 0, "when choosing the new master, exclude tablets lagging more than this (0 for no limit)")
 	if err := subFlags.Parse(args); err != nil {
 		return err
 	}
-	if subFlags.NArg() != 2 {
-		return fmt.Errorf("action PlannedReparentShard requires <keyspace/shard> <tablet alias>")
+	if subFlags.NArg() != 1 && subFlags.NArg() != 2 {
+		return fmt.Errorf("action PlannedReparentShard requires <keyspace/shard> [<tablet alias>]")
 	}
 
 	keyspace, shard, err := topoproto.ParseKeyspaceShard(subFlags.Arg(0))
 	if err != nil {
 		return err
 	}
-	tabletAlias, err := topoproto.ParseTabletAlias(subFlags.Arg(1))
-	if err != nil {
-		return err
+	var tabletAlias *pb.TabletAlias
+	if subFlags.NArg() == 2 {
+		tabletAlias, err = topoproto.ParseTabletAlias(subFlags.Arg(1))
+		if err != nil {
+			return err
+		}
+	}
+	policy := &wrangler.ReparentCandidatePolicy{
+		PreferredCells:    preferCells,
+		ExcludedTags:      excludeTags,
+		MaxReplicationLag: *maxReplicationLag,
 	}
-	return wr.PlannedReparentShard(ctx, keyspace, shard, tabletAlias, *waitSlaveTimeout)
+	return wr.PlannedReparentShard(ctx, keyspace, shard, tabletAlias, policy, *waitSlaveTimeout)
 }
 
 func commandEmergencyReparentShard(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
@@ -141,20 +157,33 @@ func commandEmergencyReparentShard(ctx context.Context, wr *wrangler.Wrangler, s
 	}
 
 	waitSlaveTimeout := subFlags.Duration("wait_slave_timeout", 30*time.Second, "time to wait for slaves to catch up in reparenting")
+	var preferCells flagutil.StringListValue
+	subFlags.Var(&preferCells, "prefer_cells", "when choosing the new master, prefer tablets in these cells, in order")
+	var excludeTags flagutil.StringMapValue
+	subFlags.Var(&excludeTags, "exclude_tags", "when choosing the new master, exclude tablets with any of these tags")
+	requireSemiSyncSlave := subFlags.Bool("require_semi_sync_slave", false, "refuse to promote a tablet that was not acknowledging transactions as a semi-sync slave")
 	if err := subFlags.Parse(args); err != nil {
 		return err
 	}
-	if subFlags.NArg() != 2 {
-		return fmt.Errorf("action EmergencyReparentShard requires <keyspace/shard> <tablet alias>")
+	if subFlags.NArg() != 1 && subFlags.NArg() != 2 {
+		return fmt.Errorf("action EmergencyReparentShard requires <keyspace/shard> [<tablet alias>]")
 	}
 
 	keyspace, shard, err := topoproto.ParseKeyspaceShard(subFlags.Arg(0))
 	if err != nil {
 		return err
 	}
-	tabletAlias, err := topoproto.ParseTabletAlias(subFlags.Arg(1))
-	if err != nil {
-		return err
+	var tabletAlias *pb.TabletAlias
+	if subFlags.NArg() == 2 {
+		tabletAlias, err = topoproto.ParseTabletAlias(subFlags.Arg(1))
+		if err != nil {
+			return err
+		}
+	}
+	policy := &wrangler.ReparentCandidatePolicy{
+		PreferredCells:       preferCells,
+		ExcludedTags:         excludeTags,
+		RequireSemiSyncSlave: *requireSemiSyncSlave,
 	}
-	return wr.EmergencyReparentShard(ctx, keyspace, shard, tabletAlias, *waitSlaveTimeout)
+	return wr.EmergencyReparentShard(ctx, keyspace, shard, tabletAlias, policy, *waitSlaveTimeout)
 }
@@ -90,6 +90,7 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/youtube/vitess/go/flagutil"
+	"github.com/youtube/vitess/go/vt/binlog/binlogplayer"
 	hk "github.com/youtube/vitess/go/vt/hook"
 	"github.com/youtube/vitess/go/vt/key"
 	"github.com/youtube/vitess/go/vt/logutil"
@@ -168,8 +169,15 @@ var commands = []commandGroup{
 				"<tablet alias> <duration>",
 				"Blocks the action queue on the specified tablet for the specified amount of time. This is typically used for testing."},
 			command{"Backup", commandBackup,
-				"[-concurrency=4] <tablet alias>",
-				"Stops mysqld and uses the BackupStorage service to store a new backup. This function also remembers if the tablet was replicating so that it can restore the same state after the backup completes."},
+				"[-concurrency=4] [-incremental] <tablet alias>",
+				"Stops mysqld and uses the BackupStorage service to store a new backup. This function also remembers if the tablet was replicating so that it can restore the same state after the backup completes.\n" +
+					"With -incremental, only the binlogs written since the last backup of the shard are stored, and mysqld is not stopped."},
+			command{"RestoreToPointInTime", commandRestoreToPointInTime,
+				"[-position=<replication position>] [-time=<RFC3339 time>] <tablet alias>",
+				"Replaces the data of a non-master tablet with the most recent full backup before the given replication position or time, and replays the binlogs of the incremental backups after it up to that point. The tablet is left as a spare, not replicating."},
+			command{"VerifyBackup", commandVerifyBackup,
+				"<tablet alias> <backup name>",
+				"Restores a full backup of the shard of the tablet into a scratch mysqld started on the tablet, which checks the hash of each file, then checks the tables of the backup are all there, and runs CHECK TABLE on some of them. The tablet keeps serving during the verification, which needs as much disk space as the backup."},
 			command{"ExecuteHook", commandExecuteHook,
 				"<tablet alias> <hook name> [<param1=value1> <param2=value2> ...]",
 				"Runs the specified hook on the given tablet. A hook is a script that resides in the $VTROOT/vthook directory. You can put any script into that directory and use this command to run that script.\n" +
@@ -200,6 +208,9 @@ var commands = []commandGroup{
 			command{"ShardReplicationPositions", commandShardReplicationPositions,
 				"<keyspace/shard>",
 				"Shows the replication status of each slave machine in the shard graph. In this case, the status refers to the replication lag between the master vttablet and the slave vttablet. In Vitess, data is always written to the master vttablet first and then replicated to all slave vttablets."},
+			command{"ValidateGTIDs", commandValidateGTIDs,
+				"<keyspace/shard>",
+				"Validates that no slave in the shard has errant transactions, i.e. transactions that are not in the executed GTID set of the master. Lists the errant transactions of each slave. PlannedReparentShard also refuses to promote a slave that has some."},
 			command{"ListShardTablets", commandListShardTablets,
 				"<keyspace/shard>",
 				"Lists all tablets in the specified shard."},
@@ -213,8 +224,8 @@ var commands = []commandGroup{
 				"<keyspace/shard> <uid>",
 				"Deletes the SourceShard record with the provided index. This is meant as an emergency cleanup function. It does not call RefreshState for the shard master."},
 			command{"SourceShardAdd", commandSourceShardAdd,
-				"[--key_range=<keyrange>] [--tables=<table1,table2,...>] <keyspace/shard> <uid> <source keyspace/shard>",
-				"Adds the SourceShard record with the provided index. This is meant as an emergency function. It does not call RefreshState for the shard master."},
+				"[--key_range=<keyrange>] [--tables=<table1,table2,...>] [--transforms=<json>] <keyspace/shard> <uid> <source keyspace/shard>",
+				"Adds the SourceShard record with the provided index. This is meant as an emergency function. It does not call RefreshState for the shard master. The optional transforms are a JSON list of TableTransform objects, applied to the replicated statements to rename tables and columns, drop columns and filter rows."},
 			command{"ShardReplicationAdd", commandShardReplicationAdd,
 				"<keyspace/shard> <tablet alias> <parent tablet alias>",
 				"HIDDEN Adds an entry to the replication graph in the given cell."},
@@ -308,6 +319,9 @@ var commands = []commandGroup{
 			command{"ApplySchema", commandApplySchema,
 				"[-force] {-sql=<sql> || -sql-file=<filename>} <keyspace>",
 				"Applies the schema change to the specified keyspace on every master, running in parallel on all shards. The changes are then propagated to slaves via replication. If the force flag is set, then numerous checks will be ignored, so that option should be used very cautiously."},
+			command{"ReconcileSchemaKeyspace", commandReconcileSchemaKeyspace,
+				"[-exclude_tables=''] [-allow_drops] [-dry-run] {-sql_dir=<dir> || -source_tablet=<tablet alias>} <keyspace>",
+				"Brings the schema of every tablet in the keyspace to the desired schema, which is either the schema of the source tablet or the CREATE statements of the .sql files in the directory. Each tablet is fixed with its own DDLs, with binlogs disabled. Views are not reconciled, and extra tables, columns and indexes are only dropped with -allow_drops. With -dry-run, only the plan is displayed."},
 			command{"CopySchemaShard", commandCopySchemaShard,
 				"[-tables=<table1>,<table2>,...] [-exclude_tables=<table1>,<table2>,...] [-include-views] {<source keyspace/shard> || <source tablet alias>} <destination keyspace/shard>",
 				"Copies the schema from a source shard's master (or a specific tablet) to a destination shard. The schema is applied directly on the master of the destination shard, and it is propagated to the replicas through binlogs."},
@@ -904,6 +918,7 @@ func commandSleep(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.Fla
 
 func commandBackup(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
 	concurrency := subFlags.Int("concurrency", 4, "Specifies the number of compression/checksum jobs to run simultaneously")
+	incremental := subFlags.Bool("incremental", false, "Only stores the binlogs written since the last backup of the shard, without stopping mysqld")
 	if err := subFlags.Parse(args); err != nil {
 		return err
 	}
@@ -919,7 +934,76 @@ func commandBackup(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.Fl
 	if err != nil {
 		return err
 	}
-	logStream, errFunc, err := wr.TabletManagerClient().Backup(ctx, tabletInfo, *concurrency)
+	logStream, errFunc, err := wr.TabletManagerClient().Backup(ctx, tabletInfo, *concurrency, *incremental)
+	if err != nil {
+		return err
+	}
+	for e := range logStream {
+		wr.Logger().Infof("%v", e)
+	}
+	return errFunc()
+}
+
+func commandRestoreToPointInTime(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
+	position := subFlags.String("position", "", "Specifies the replication position to restore to, e.g. MariaDB/0-41983-1234")
+	timeStr := subFlags.String("time", "", "Specifies the time to restore to, in RFC3339 format, e.g. 2015-11-10T14:02:00Z. The transactions which started after it on the master are not replayed")
+	if err := subFlags.Parse(args); err != nil {
+		return err
+	}
+	if subFlags.NArg() != 1 {
+		return fmt.Errorf("The RestoreToPointInTime command requires the <tablet alias> argument.")
+	}
+	if (*position == "") == (*timeStr == "") {
+		return fmt.Errorf("The RestoreToPointInTime command requires exactly one of -position or -time.")
+	}
+	var pos myproto.ReplicationPosition
+	var t time.Time
+	var err error
+	if *position != "" {
+		if pos, err = myproto.DecodeReplicationPosition(*position); err != nil {
+			return fmt.Errorf("invalid -position: %v", err)
+		}
+	} else {
+		if t, err = time.Parse(time.RFC3339, *timeStr); err != nil {
+			return fmt.Errorf("invalid -time: %v", err)
+		}
+	}
+
+	tabletAlias, err := topoproto.ParseTabletAlias(subFlags.Arg(0))
+	if err != nil {
+		return err
+	}
+	tabletInfo, err := wr.TopoServer().GetTablet(ctx, tabletAlias)
+	if err != nil {
+		return err
+	}
+	logStream, errFunc, err := wr.TabletManagerClient().RestoreToPointInTime(ctx, tabletInfo, pos, t)
+	if err != nil {
+		return err
+	}
+	for e := range logStream {
+		wr.Logger().Infof("%v", e)
+	}
+	return errFunc()
+}
+
+func commandVerifyBackup(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
+	if err := subFlags.Parse(args); err != nil {
+		return err
+	}
+	if subFlags.NArg() != 2 {
+		return fmt.Errorf("The VerifyBackup command requires the <tablet alias> and <backup name> arguments.")
+	}
+
+	tabletAlias, err := topoproto.ParseTabletAlias(subFlags.Arg(0))
+	if err != nil {
+		return err
+	}
+	tabletInfo, err := wr.TopoServer().GetTablet(ctx, tabletAlias)
+	if err != nil {
+		return err
+	}
+	logStream, errFunc, err := wr.TabletManagerClient().VerifyBackup(ctx, tabletInfo, subFlags.Arg(1))
 	if err != nil {
 		return err
 	}
@@ -1114,6 +1198,20 @@ func commandShardReplicationPositions(ctx context.Context, wr *wrangler.Wrangler
 	return nil
 }
 
+func commandValidateGTIDs(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
+	if err := subFlags.Parse(args); err != nil {
+		return err
+	}
+	if subFlags.NArg() != 1 {
+		return fmt.Errorf("The <keyspace/shard> argument is required for the ValidateGTIDs command.")
+	}
+	keyspace, shard, err := topoproto.ParseKeyspaceShard(subFlags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return wr.ValidateGTIDs(ctx, keyspace, shard)
+}
+
 func commandListShardTablets(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
 	if err := subFlags.Parse(args); err != nil {
 		return err
@@ -1207,6 +1305,7 @@ func commandSourceShardDelete(ctx context.Context, wr *wrangler.Wrangler, subFla
 func commandSourceShardAdd(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
 	keyRange := subFlags.String("key_range", "", "Identifies the key range to use for the SourceShard")
 	tablesStr := subFlags.String("tables", "", "Specifies a comma-separated list of tables to replicate (used for vertical split)")
+	transformsStr := subFlags.String("transforms", "", "Specifies a JSON list of transforms to apply to the replicated statements")
 	if err := subFlags.Parse(args); err != nil {
 		return err
 	}
@@ -1235,7 +1334,16 @@ func commandSourceShardAdd(ctx context.Context, wr *wrangler.Wrangler, subFlags
 			return err
 		}
 	}
-	return wr.SourceShardAdd(ctx, keyspace, shard, uint32(uid), skeyspace, sshard, kr, tables)
+	var transforms []*pb.TableTransform
+	if *transformsStr != "" {
+		if err := json.Unmarshal([]byte(*transformsStr), &transforms); err != nil {
+			return fmt.Errorf("cannot parse transforms: %v", err)
+		}
+		if _, err := binlogplayer.NewTransformer(transforms); err != nil {
+			return err
+		}
+	}
+	return wr.SourceShardAdd(ctx, keyspace, shard, uint32(uid), skeyspace, sshard, kr, tables, transforms)
 }
 
 func commandShardReplicationAdd(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
@@ -1846,6 +1954,41 @@ func commandApplySchema(ctx context.Context, wr *wrangler.Wrangler, subFlags *fl
 	return printJSON(wr, scr)
 }
 
+func commandReconcileSchemaKeyspace(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
+	excludeTables := subFlags.String("exclude_tables", "", "Specifies a comma-separated list of regular expressions for tables to exclude")
+	allowDrops := subFlags.Bool("allow_drops", false, "Drops the tables, columns and indexes that are not in the desired schema")
+	dryRun := subFlags.Bool("dry-run", false, "Displays the plan without applying it")
+	sqlDir := subFlags.String("sql_dir", "", "The directory that contains the .sql files with the CREATE statements of the desired schema")
+	sourceTablet := subFlags.String("source_tablet", "", "The tablet that has the desired schema")
+	if err := subFlags.Parse(args); err != nil {
+		return err
+	}
+	if subFlags.NArg() != 1 {
+		return fmt.Errorf("The <keyspace> argument is required for the ReconcileSchemaKeyspace command.")
+	}
+
+	keyspace := subFlags.Arg(0)
+	var excludeTableArray []string
+	if *excludeTables != "" {
+		excludeTableArray = strings.Split(*excludeTables, ",")
+	}
+	var sourceTabletAlias *pb.TabletAlias
+	if *sourceTablet != "" {
+		var err error
+		sourceTabletAlias, err = topoproto.ParseTabletAlias(*sourceTablet)
+		if err != nil {
+			return err
+		}
+	}
+	fixes, err := wr.ReconcileSchemaKeyspace(ctx, keyspace, *sqlDir, sourceTabletAlias, excludeTableArray, *allowDrops, *dryRun)
+	if fixes != nil {
+		if err := printJSON(wr, fixes); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
 func commandCopySchemaShard(ctx context.Context, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) error {
 	tables := subFlags.String("tables", "", "Specifies a comma-separated list of regular expressions for which tables  gather schema information for")
 	excludeTables := subFlags.String("exclude_tables", "", "Specifies a comma-separated list of regular expressions for which tables to exclude")
@@ -0,0 +1,295 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/sync2"
+	"github.com/youtube/vitess/go/vt/concurrency"
+	"github.com/youtube/vitess/go/vt/keyresolver"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
+	"github.com/youtube/vitess/go/vt/wrangler"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+// findExtraWorkerTablets finds up to count more rdonly tablets in a
+// shard to spread the diff reads on, and marks them as worker.
+// The tablets in taken are not used again. The healthy rdonly tablets
+// are listed once, without waiting for more, and we still leave
+// -min_healthy_rdonly_endpoints - 1 of them serving.
+// Not finding them is not an error, we just use fewer tablets.
+func findExtraWorkerTablets(ctx context.Context, wr *wrangler.Wrangler, cleaner *wrangler.Cleaner, cell, keyspace, shard string, taken []*pb.TabletAlias, count int) []*pb.TabletAlias {
+	if count <= 0 {
+		return nil
+	}
+	endPoints, err := healthyRdonlyEndPoints(ctx, wr, cell, keyspace, shard)
+	if err != nil {
+		wr.Logger().Warningf("Cannot find more rdonly tablets in %v/%v/%v, using %v: %v", cell, keyspace, shard, len(taken), err)
+		return nil
+	}
+	var candidates []*pb.TabletAlias
+	for _, ep := range endPoints {
+		alias := &pb.TabletAlias{
+			Cell: cell,
+			Uid:  ep.Uid,
+		}
+		if !isTabletAliasInList(alias, taken) {
+			candidates = append(candidates, alias)
+		}
+	}
+	// the tablets already taken may still be listed as healthy rdonly
+	if available := len(candidates) - (*minHealthyEndPoints - 1); available < count {
+		count = available
+	}
+
+	var result []*pb.TabletAlias
+	for _, i := range rand.Perm(len(candidates)) {
+		if len(result) >= count {
+			break
+		}
+		alias := candidates[i]
+		if err := takeWorkerTablet(ctx, wr, cleaner, alias); err != nil {
+			wr.Logger().Warningf("Cannot use rdonly tablet %v: %v", topoproto.TabletAliasString(alias), err)
+			continue
+		}
+		result = append(result, alias)
+	}
+	if len(result) == 0 {
+		wr.Logger().Infof("No extra rdonly tablets available in %v/%v/%v", cell, keyspace, shard)
+	}
+	return result
+}
+
+// isTabletAliasInList returns true if alias is in aliases.
+func isTabletAliasInList(alias *pb.TabletAlias, aliases []*pb.TabletAlias) bool {
+	for _, a := range aliases {
+		if topoproto.TabletAliasEqual(alias, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// stopExtraTablets stops replication on the extra tablets of a shard
+// right away, before the first tablet of the shard is stopped, so they
+// are not past the position it will stop at. The cleaner will restart
+// replication, and change them to spare.
+func stopExtraTablets(ctx context.Context, wr *wrangler.Wrangler, cleaner *wrangler.Cleaner, aliases []*pb.TabletAlias) error {
+	for _, alias := range aliases {
+		shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
+		ti, err := wr.TopoServer().GetTablet(shortCtx, alias)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		shortCtx, cancel = context.WithTimeout(ctx, *remoteActionsTimeout)
+		err = wr.TabletManagerClient().StopSlave(shortCtx, ti)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("cannot stop slave %v: %v", topoproto.TabletAliasString(alias), err)
+		}
+		wrangler.RecordStartSlaveAction(cleaner, ti)
+		action, err := wrangler.FindChangeSlaveTypeActionByTarget(cleaner, alias)
+		if err != nil {
+			return fmt.Errorf("cannot find ChangeSlaveType action for %v: %v", topoproto.TabletAliasString(alias), err)
+		}
+		action.TabletType = pb.TabletType_SPARE
+	}
+	return nil
+}
+
+// syncExtraTablets restarts replication on the extra tablets stopped by
+// stopExtraTablets, until exactly pos, the position of the first tablet
+// of their shard. Only the tablets which stopped at pos can be used for
+// the diff. The others were already past it, and are returned to the
+// pool at the end.
+func syncExtraTablets(ctx context.Context, wr *wrangler.Wrangler, aliases []*pb.TabletAlias, pos myproto.ReplicationPosition) ([]*pb.TabletAlias, error) {
+	var result []*pb.TabletAlias
+	for _, alias := range aliases {
+		shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
+		ti, err := wr.TopoServer().GetTablet(shortCtx, alias)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+
+		shortCtx, cancel = context.WithTimeout(ctx, *remoteActionsTimeout)
+		err = wr.TabletManagerClient().StartSlaveUntilAfter(shortCtx, ti, pos, *remoteActionsTimeout)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("cannot replicate slave %v until binlog position %v: %v", topoproto.TabletAliasString(alias), pos, err)
+		}
+
+		// the SQL thread stopped at pos, this stops the IO thread
+		// and returns the position
+		shortCtx, cancel = context.WithTimeout(ctx, *remoteActionsTimeout)
+		stoppedAt, err := wr.TabletManagerClient().StopSlaveMinimum(shortCtx, ti, pos, *remoteActionsTimeout)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("cannot stop slave %v at binlog position %v: %v", topoproto.TabletAliasString(alias), pos, err)
+		}
+		if !stoppedAt.Equal(pos) {
+			wr.Logger().Warningf("Not using tablet %v for the diff, it stopped at %v instead of %v", topoproto.TabletAliasString(alias), stoppedAt, pos)
+			continue
+		}
+		result = append(result, alias)
+	}
+	return result, nil
+}
+
+// chunkedDiff diffs tables between a source and a destination. Each
+// table is split into chunks of its primary key, and the chunks are
+// diffed in parallel, spread over all the source and destination
+// tablets (which must all be stopped at the same position).
+type chunkedDiff struct {
+	wr                 *wrangler.Wrangler
+	sourceAliases      []*pb.TabletAlias
+	destinationAliases []*pb.TabletAlias
+
+	// sourceKeyRange restricts the rows read on the source tablets.
+	// It is nil for vertical splits.
+	sourceKeyRange *pb.KeyRange
+	keyspaceIDType pb.KeyspaceIdType
+
+	// keyResolverFactory is set when the keyspace ids are computed
+	// by vindexes. The source rows are then filtered by the differ,
+	// instead of by the scan query on the keyspace_id column.
+	keyResolverFactory keyresolver.Factory
+
+	minTableSizeForSplit uint64
+	sourceReaderCount    int
+	parallelDiffsCount   int
+
+	// mismatches is optional, all differences are recorded in it.
+	mismatches *mismatchReport
+}
+
+// diffTables runs the diff on all tables. It returns an error if a
+// table has differences.
+func (cd *chunkedDiff) diffTables(ctx context.Context, tableDefinitions []*myproto.TableDefinition) error {
+	// we find the chunks on the destination, as it has less rows
+	shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
+	destinationTablet, err := cd.wr.TopoServer().GetTablet(shortCtx, cd.destinationAliases[0])
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	wg := sync.WaitGroup{}
+	rec := concurrency.AllErrorRecorder{}
+	sem := sync2.NewSemaphore(cd.parallelDiffsCount, 0)
+	mu := sync.Mutex{} // protects reports, failed and chunkCount
+	reports := make([]DiffReport, len(tableDefinitions))
+	failed := make([]bool, len(tableDefinitions))
+	chunkCount := 0
+	for tableIndex, tableDefinition := range tableDefinitions {
+		chunks, err := FindChunks(ctx, cd.wr, destinationTablet, tableDefinition, cd.minTableSizeForSplit, cd.sourceReaderCount)
+		if err != nil {
+			newErr := fmt.Errorf("FindChunks failed for table %v: %v", tableDefinition.Name, err)
+			rec.RecordError(newErr)
+			cd.wr.Logger().Errorf(newErr.Error())
+			failed[tableIndex] = true
+			continue
+		}
+		var keyResolver keyresolver.Resolver
+		if cd.keyResolverFactory != nil {
+			keyResolver, err = cd.keyResolverFactory(tableDefinition.Name, orderedColumns(tableDefinition))
+			if err != nil {
+				newErr := fmt.Errorf("cannot compute the keyspace ids of table %v: %v", tableDefinition.Name, err)
+				rec.RecordError(newErr)
+				cd.wr.Logger().Errorf(newErr.Error())
+				failed[tableIndex] = true
+				continue
+			}
+		}
+		cd.wr.Logger().Infof("Starting the diff on table %v (%v chunks)", tableDefinition.Name, len(chunks)-1)
+		reports[tableIndex].startingTime = time.Now()
+
+		for chunkIndex := 0; chunkIndex < len(chunks)-1; chunkIndex++ {
+			wg.Add(1)
+			go func(tableIndex int, tableDefinition *myproto.TableDefinition, chunkStart, chunkEnd string, n int) {
+				defer wg.Done()
+				sem.Acquire()
+				defer sem.Release()
+
+				report, err := cd.diffChunk(ctx, tableDefinition, keyResolver, chunkStart, chunkEnd, n)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					rec.RecordError(err)
+					cd.wr.Logger().Errorf(err.Error())
+					failed[tableIndex] = true
+					return
+				}
+				reports[tableIndex].merge(report)
+			}(tableIndex, tableDefinition, chunks[chunkIndex], chunks[chunkIndex+1], chunkCount)
+			chunkCount++
+		}
+	}
+	wg.Wait()
+
+	for tableIndex, tableDefinition := range tableDefinitions {
+		if failed[tableIndex] {
+			continue
+		}
+		report := &reports[tableIndex]
+		report.ComputeQPS()
+		if report.HasDifferences() {
+			err := fmt.Errorf("Table %v has differences: %v", tableDefinition.Name, report.String())
+			rec.RecordError(err)
+			cd.wr.Logger().Warningf(err.Error())
+		} else {
+			cd.wr.Logger().Infof("Table %v checks out (%v rows processed, %v qps)", tableDefinition.Name, report.processedRows, report.processingQPS)
+		}
+	}
+	return rec.Error()
+}
+
+// diffChunk diffs one chunk of a table. n is used to pick the tablets.
+// keyResolver is set if the source rows have to be filtered by the differ.
+func (cd *chunkedDiff) diffChunk(ctx context.Context, tableDefinition *myproto.TableDefinition, keyResolver keyresolver.Resolver, chunkStart, chunkEnd string, n int) (DiffReport, error) {
+	sourceAlias := cd.sourceAliases[n%len(cd.sourceAliases)]
+	destinationAlias := cd.destinationAliases[n%len(cd.destinationAliases)]
+
+	scanKeyRange := cd.sourceKeyRange
+	if keyResolver != nil {
+		scanKeyRange = nil
+	}
+	sourceQueryResultReader, err := TableScanChunk(ctx, cd.wr.Logger(), cd.wr.TopoServer(), sourceAlias, tableDefinition, chunkStart, chunkEnd, scanKeyRange, cd.keyspaceIDType)
+	if err != nil {
+		return DiffReport{}, fmt.Errorf("TableScanChunk(source) failed: %v", err)
+	}
+	defer sourceQueryResultReader.Close()
+
+	destinationQueryResultReader, err := TableScanChunk(ctx, cd.wr.Logger(), cd.wr.TopoServer(), destinationAlias, tableDefinition, chunkStart, chunkEnd, nil, cd.keyspaceIDType)
+	if err != nil {
+		return DiffReport{}, fmt.Errorf("TableScanChunk(destination) failed: %v", err)
+	}
+	defer destinationQueryResultReader.Close()
+
+	differ, err := NewRowDiffer(sourceQueryResultReader, destinationQueryResultReader, tableDefinition)
+	if err != nil {
+		return DiffReport{}, fmt.Errorf("NewRowDiffer() failed: %v", err)
+	}
+	differ.mismatches = cd.mismatches
+	if keyResolver != nil {
+		differ.left.filterByKeyRange(keyResolver, cd.sourceKeyRange)
+	}
+
+	report, err := differ.Go(cd.wr.Logger())
+	if err != nil {
+		return DiffReport{}, fmt.Errorf("Differ.Go failed: %v", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/vt/logutil"
+	"github.com/youtube/vitess/go/vt/tabletmanager/faketmclient"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/wrangler"
+	"github.com/youtube/vitess/go/vt/zktopo"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+func TestFindExtraWorkerTablets(t *testing.T) {
+	ctx := context.Background()
+	ts := zktopo.NewTestServer(t, []string{"cell1"})
+	wr := wrangler.New(logutil.NewConsoleLogger(), ts, faketmclient.NewFakeTabletManagerClient())
+	if err := ts.CreateKeyspace(ctx, "ks", &pb.Keyspace{}); err != nil {
+		t.Fatalf("CreateKeyspace failed: %v", err)
+	}
+	if err := ts.CreateShard(ctx, "ks", "0"); err != nil {
+		t.Fatalf("CreateShard failed: %v", err)
+	}
+
+	// 4 rdonly tablets, tablet 4 is unhealthy
+	endPoints := &pb.EndPoints{}
+	for uid := uint32(1); uid <= 4; uid++ {
+		if err := ts.CreateTablet(ctx, &pb.Tablet{
+			Alias:    &pb.TabletAlias{Cell: "cell1", Uid: uid},
+			Hostname: "localhost",
+			Keyspace: "ks",
+			Shard:    "0",
+			Type:     pb.TabletType_RDONLY,
+		}); err != nil {
+			t.Fatalf("CreateTablet failed: %v", err)
+		}
+		ep := &pb.EndPoint{Uid: uid, Host: "localhost"}
+		if uid == 4 {
+			ep.HealthMap = map[string]string{"replication_lag": "high"}
+		}
+		endPoints.Entries = append(endPoints.Entries, ep)
+	}
+	if err := topo.UpdateEndPoints(ctx, ts, "cell1", "ks", "0", pb.TabletType_RDONLY, endPoints, -1); err != nil {
+		t.Fatalf("UpdateEndPoints failed: %v", err)
+	}
+
+	// tablet 1 is taken, and still listed as healthy. With the
+	// default -min_healthy_rdonly_endpoints of 2, one of tablets 2
+	// and 3 is left serving. Not finding more doesn't wait.
+	oldTimeout := *WaitForHealthyEndPointsTimeout
+	*WaitForHealthyEndPointsTimeout = time.Hour
+	defer func() { *WaitForHealthyEndPointsTimeout = oldTimeout }()
+	taken := []*pb.TabletAlias{{Cell: "cell1", Uid: 1}}
+	cleaner := &wrangler.Cleaner{}
+	got := findExtraWorkerTablets(ctx, wr, cleaner, "cell1", "ks", "0", taken, 3)
+	if len(got) != 1 || (got[0].Uid != 2 && got[0].Uid != 3) {
+		t.Fatalf("findExtraWorkerTablets returned %v, want tablet 2 or 3", got)
+	}
+	if _, err := wrangler.FindChangeSlaveTypeActionByTarget(cleaner, got[0]); err != nil {
+		t.Errorf("no ChangeSlaveType clean-up action for %v: %v", got[0], err)
+	}
+
+	// with both tablets taken, there is nothing left
+	taken = append(taken, got[0])
+	if got := findExtraWorkerTablets(ctx, wr, cleaner, "cell1", "ks", "0", taken, 3); len(got) != 0 {
+		t.Errorf("findExtraWorkerTablets returned %v, want none", got)
+	}
+}
@@ -420,29 +420,47 @@ func makeValueString(fields []mproto.Field, rows [][]sqltypes.Value) string {
 			if j > 0 {
 				buf.WriteByte(',')
 			}
-			// convert value back to its original type
-			if !value.IsNull() {
-				switch fields[j].Type {
-				case mproto.VT_TINY, mproto.VT_SHORT, mproto.VT_LONG, mproto.VT_LONGLONG, mproto.VT_INT24:
-					value = sqltypes.MakeNumeric(value.Raw())
-				case mproto.VT_FLOAT, mproto.VT_DOUBLE:
-					value = sqltypes.MakeFractional(value.Raw())
-				}
-			}
-			value.EncodeSQL(&buf)
+			encodeValue(&buf, fields[j], value)
 		}
 		buf.WriteByte(')')
 	}
 	return buf.String()
 }
 
+// encodeValue writes the SQL representation of a value,
+// converted back to the original type of its field.
+func encodeValue(buf *bytes.Buffer, field mproto.Field, value sqltypes.Value) {
+	if !value.IsNull() {
+		switch field.Type {
+		case mproto.VT_TINY, mproto.VT_SHORT, mproto.VT_LONG, mproto.VT_LONGLONG, mproto.VT_INT24:
+			value = sqltypes.MakeNumeric(value.Raw())
+		case mproto.VT_FLOAT, mproto.VT_DOUBLE:
+			value = sqltypes.MakeFractional(value.Raw())
+		}
+	}
+	value.EncodeSQL(buf)
+}
+
+// insertCommand is a command sent to executeFetchLoop.
+type insertCommand struct {
+	// sql is the statement without its "INSERT INTO `<database>`." prefix.
+	sql string
+	// done is optional. If set, Done() is called once the statement was executed.
+	done *sync.WaitGroup
+}
+
 // executeFetchLoop loops over the provided insertChannel
 // and sends the commands to the provided tablet.
-func executeFetchLoop(ctx context.Context, wr *wrangler.Wrangler, r Resolver, shard string, insertChannel chan string) error {
+// If ignoreDuplicates is set, rows which already exist are skipped.
+func executeFetchLoop(ctx context.Context, wr *wrangler.Wrangler, r Resolver, shard string, insertChannel chan insertCommand, ignoreDuplicates bool) error {
 	ti, err := r.GetDestinationMaster(shard)
 	if err != nil {
 		return fmt.Errorf("executeFetchLoop failed: %v", err)
 	}
+	insert := "INSERT INTO `"
+	if ignoreDuplicates {
+		insert = "INSERT IGNORE INTO `"
+	}
 	for {
 		select {
 		case cmd, ok := <-insertChannel:
@@ -450,11 +468,13 @@ func executeFetchLoop(ctx context.Context, wr *wrangler.Wrangler, r Resolver, sh
 				// no more to read, we're done
 				return nil
 			}
-			cmd = "INSERT INTO `" + ti.DbName() + "`." + cmd
-			ti, err = executeFetchWithRetries(ctx, wr, ti, r, shard, cmd)
+			ti, err = executeFetchWithRetries(ctx, wr, ti, r, shard, insert+ti.DbName()+"`."+cmd.sql)
 			if err != nil {
 				return fmt.Errorf("ExecuteFetch failed: %v", err)
 			}
+			if cmd.done != nil {
+				cmd.done.Done()
+			}
 		case <-ctx.Done():
 			// Doesn't really matter if this select gets starved, because the other case
 			// will also return an error due to executeFetch's context being closed. This case
@@ -9,4 +9,8 @@ const (
 	defaultDestinationPackCount   = 10
 	defaultMinTableSizeForSplit   = 1024 * 1024
 	defaultDestinationWriterCount = 20
+	defaultMaxRowsPerSecond       = 0
+	defaultParallelDiffsCount     = 8
+	defaultRdonlyCount            = 1
+	defaultMismatchSampleSize     = 100
 )
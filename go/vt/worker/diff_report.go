@@ -0,0 +1,243 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	mproto "github.com/youtube/vitess/go/mysql/proto"
+	"github.com/youtube/vitess/go/sqltypes"
+)
+
+// The types of mismatches, as they appear in the report.
+const (
+	mismatchDifferentContent     = "different_content"
+	mismatchMissingOnDestination = "missing_on_destination"
+	mismatchExtraOnDestination   = "extra_on_destination"
+)
+
+// columnMismatch is a column which is different between the source and
+// the destination. Source or Destination is nil if the value is NULL,
+// or if the row does not exist on that side.
+type columnMismatch struct {
+	Name        string  `json:"name"`
+	Source      *string `json:"source"`
+	Destination *string `json:"destination"`
+}
+
+// rowMismatch is a row which is different between the source and the
+// destination.
+type rowMismatch struct {
+	Table      string            `json:"table"`
+	Type       string            `json:"type"`
+	PrimaryKey map[string]string `json:"primary_key"`
+	// Columns has the non primary key columns which differ.
+	Columns []columnMismatch `json:"columns,omitempty"`
+}
+
+// mismatchReportContent is what we write to the report file.
+type mismatchReportContent struct {
+	Keyspace string `json:"keyspace"`
+	Shard    string `json:"shard"`
+	// Counts has the number of mismatches per table and type.
+	Counts map[string]map[string]int `json:"counts"`
+	// SampleSize is the maximum number of mismatches we keep.
+	SampleSize int `json:"sample_size"`
+	// Mismatches has the first SampleSize mismatches we found.
+	// Chunks are diffed in parallel, so the sample is not ordered.
+	Mismatches []*rowMismatch `json:"mismatches"`
+}
+
+// mismatchReport collects the rows which differ during a diff, and
+// writes them to a JSON report file. In repair mode, it also writes the
+// statements which would make the destination match the source to
+// another file. The statements are not applied.
+type mismatchReport struct {
+	reportFile string
+
+	// mu protects all the following fields
+	mu         sync.Mutex
+	content    mismatchReportContent
+	repair     *os.File
+	repairBuf  *bufio.Writer
+	repairErr  error
+	repairFile string
+}
+
+// newMismatchReport returns a mismatchReport which writes to
+// reportFile (if set) when closed, and to repairFile (if set) while
+// the diff is running.
+func newMismatchReport(keyspace, shard string, sampleSize int, reportFile, repairFile string) (*mismatchReport, error) {
+	mr := &mismatchReport{
+		reportFile: reportFile,
+		content: mismatchReportContent{
+			Keyspace:   keyspace,
+			Shard:      shard,
+			Counts:     make(map[string]map[string]int),
+			SampleSize: sampleSize,
+			Mismatches: []*rowMismatch{},
+		},
+		repairFile: repairFile,
+	}
+	if repairFile != "" {
+		f, err := os.Create(repairFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create repair file: %v", err)
+		}
+		mr.repair = f
+		mr.repairBuf = bufio.NewWriter(f)
+	}
+	return mr, nil
+}
+
+// record adds a mismatch to the report. The first pkFieldCount values
+// of the rows are the primary key. left is the source row and right is
+// the destination row. One of them is nil if the row is missing on
+// that side.
+func (mr *mismatchReport) record(tableName string, fields []mproto.Field, pkFieldCount int, left, right []sqltypes.Value) {
+	m := &rowMismatch{
+		Table:      tableName,
+		PrimaryKey: make(map[string]string),
+	}
+	pk := left
+	switch {
+	case left == nil:
+		m.Type = mismatchExtraOnDestination
+		pk = right
+	case right == nil:
+		m.Type = mismatchMissingOnDestination
+	default:
+		m.Type = mismatchDifferentContent
+	}
+	for i := 0; i < pkFieldCount; i++ {
+		m.PrimaryKey[fields[i].Name] = pk[i].String()
+	}
+	for i := pkFieldCount; i < len(fields); i++ {
+		var l, r *string
+		if left != nil {
+			l = valueOrNil(left[i])
+		}
+		if right != nil {
+			r = valueOrNil(right[i])
+		}
+		if left != nil && right != nil && bytes.Equal(left[i].Raw(), right[i].Raw()) && left[i].IsNull() == right[i].IsNull() {
+			continue
+		}
+		m.Columns = append(m.Columns, columnMismatch{
+			Name:        fields[i].Name,
+			Source:      l,
+			Destination: r,
+		})
+	}
+
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	counts, ok := mr.content.Counts[tableName]
+	if !ok {
+		counts = make(map[string]int)
+		mr.content.Counts[tableName] = counts
+	}
+	counts[m.Type]++
+	if len(mr.content.Mismatches) < mr.content.SampleSize {
+		mr.content.Mismatches = append(mr.content.Mismatches, m)
+	}
+	if mr.repairBuf != nil && mr.repairErr == nil {
+		_, mr.repairErr = mr.repairBuf.WriteString(repairStatement(tableName, fields, pkFieldCount, left, right, m) + ";\n")
+	}
+}
+
+// close writes the report file and closes the repair file.
+func (mr *mismatchReport) close() error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	var err error
+	if mr.repair != nil {
+		if mr.repairErr == nil {
+			mr.repairErr = mr.repairBuf.Flush()
+		}
+		if cerr := mr.repair.Close(); mr.repairErr == nil {
+			mr.repairErr = cerr
+		}
+		mr.repair = nil
+		if mr.repairErr != nil {
+			err = fmt.Errorf("cannot write repair file %v: %v", mr.repairFile, mr.repairErr)
+		}
+	}
+	if mr.reportFile != "" {
+		data, jerr := json.MarshalIndent(&mr.content, "", "  ")
+		if jerr == nil {
+			jerr = ioutil.WriteFile(mr.reportFile, data, 0644)
+		}
+		if jerr != nil && err == nil {
+			err = fmt.Errorf("cannot write report file %v: %v", mr.reportFile, jerr)
+		}
+	}
+	return err
+}
+
+// repairStatement returns the statement which fixes the destination
+// row of a mismatch.
+func repairStatement(tableName string, fields []mproto.Field, pkFieldCount int, left, right []sqltypes.Value, m *rowMismatch) string {
+	buf := &bytes.Buffer{}
+	switch m.Type {
+	case mismatchMissingOnDestination:
+		names := make([]string, len(fields))
+		for i, field := range fields {
+			names[i] = field.Name
+		}
+		fmt.Fprintf(buf, "INSERT INTO %v (%v) VALUES ", tableName, strings.Join(names, ", "))
+		buf.WriteString(makeValueString(fields, [][]sqltypes.Value{left}))
+		return buf.String()
+	case mismatchExtraOnDestination:
+		fmt.Fprintf(buf, "DELETE FROM %v", tableName)
+		writePrimaryKeyClause(buf, fields, pkFieldCount, right)
+		return buf.String()
+	}
+
+	fmt.Fprintf(buf, "UPDATE %v SET ", tableName)
+	first := true
+	for i := pkFieldCount; i < len(fields); i++ {
+		if bytes.Equal(left[i].Raw(), right[i].Raw()) && left[i].IsNull() == right[i].IsNull() {
+			continue
+		}
+		if !first {
+			buf.WriteString(", ")
+		}
+		first = false
+		buf.WriteString(fields[i].Name + "=")
+		encodeValue(buf, fields[i], left[i])
+	}
+	writePrimaryKeyClause(buf, fields, pkFieldCount, right)
+	return buf.String()
+}
+
+// writePrimaryKeyClause writes the WHERE clause matching the primary key of row.
+func writePrimaryKeyClause(buf *bytes.Buffer, fields []mproto.Field, pkFieldCount int, row []sqltypes.Value) {
+	for i := 0; i < pkFieldCount; i++ {
+		if i == 0 {
+			buf.WriteString(" WHERE ")
+		} else {
+			buf.WriteString(" AND ")
+		}
+		buf.WriteString(fields[i].Name + "=")
+		encodeValue(buf, fields[i], row[i])
+	}
+}
+
+func valueOrNil(v sqltypes.Value) *string {
+	if v.IsNull() {
+		return nil
+	}
+	s := v.String()
+	return &s
+}
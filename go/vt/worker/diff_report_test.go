@@ -0,0 +1,110 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	mproto "github.com/youtube/vitess/go/mysql/proto"
+	"github.com/youtube/vitess/go/sqltypes"
+)
+
+func TestMismatchReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diff_report_test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	reportFile := path.Join(dir, "report.json")
+	repairFile := path.Join(dir, "repair.sql")
+
+	mr, err := newMismatchReport("ks", "-80", 2, reportFile, repairFile)
+	if err != nil {
+		t.Fatalf("newMismatchReport failed: %v", err)
+	}
+	fields := []mproto.Field{
+		{"id", mproto.VT_LONGLONG, mproto.VT_ZEROVALUE_FLAG},
+		{"msg", mproto.VT_VAR_STRING, mproto.VT_ZEROVALUE_FLAG},
+		{"count", mproto.VT_LONG, mproto.VT_ZEROVALUE_FLAG},
+	}
+	row := func(id, msg, count string) []sqltypes.Value {
+		return []sqltypes.Value{
+			sqltypes.MakeString([]byte(id)),
+			sqltypes.MakeString([]byte(msg)),
+			sqltypes.MakeString([]byte(count)),
+		}
+	}
+	mr.record("t1", fields, 1, row("1", "a", "10"), row("1", "b", "10"))
+	mr.record("t1", fields, 1, row("2", "it's", "20"), nil)
+	mr.record("t1", fields, 1, nil, row("3", "c", "30"))
+	if err := mr.close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	// check the repair statements
+	data, err := ioutil.ReadFile(repairFile)
+	if err != nil {
+		t.Fatalf("cannot read repair file: %v", err)
+	}
+	want := "UPDATE t1 SET msg='a' WHERE id=1;\n" +
+		"INSERT INTO t1 (id, msg, count) VALUES (2,'it\\'s',20);\n" +
+		"DELETE FROM t1 WHERE id=3;\n"
+	if got := string(data); got != want {
+		t.Errorf("got repair statements:\n%v\nwant:\n%v", got, want)
+	}
+
+	// check the report only has the sample
+	data, err = ioutil.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("cannot read report file: %v", err)
+	}
+	var got mismatchReportContent
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("cannot decode report file: %v", err)
+	}
+	wantContent := mismatchReportContent{
+		Keyspace: "ks",
+		Shard:    "-80",
+		Counts: map[string]map[string]int{
+			"t1": {
+				mismatchDifferentContent:     1,
+				mismatchMissingOnDestination: 1,
+				mismatchExtraOnDestination:   1,
+			},
+		},
+		SampleSize: 2,
+		Mismatches: []*rowMismatch{
+			{
+				Table:      "t1",
+				Type:       mismatchDifferentContent,
+				PrimaryKey: map[string]string{"id": "1"},
+				Columns: []columnMismatch{
+					{Name: "msg", Source: stringPtr("a"), Destination: stringPtr("b")},
+				},
+			},
+			{
+				Table:      "t1",
+				Type:       mismatchMissingOnDestination,
+				PrimaryKey: map[string]string{"id": "2"},
+				Columns: []columnMismatch{
+					{Name: "msg", Source: stringPtr("it's")},
+					{Name: "count", Source: stringPtr("20")},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, wantContent) {
+		t.Errorf("got report %s, want %+v", data, wantContent)
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
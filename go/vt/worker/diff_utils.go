@@ -15,6 +15,8 @@ import (
 
 	mproto "github.com/youtube/vitess/go/mysql/proto"
 	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/key"
+	"github.com/youtube/vitess/go/vt/keyresolver"
 	"github.com/youtube/vitess/go/vt/logutil"
 	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 	"github.com/youtube/vitess/go/vt/tabletserver/tabletconn"
@@ -104,9 +106,7 @@ func uint64FromKeyspaceID(keyspaceID []byte) string {
 // table, ordered by Primary Key. The returned columns are ordered
 // with the Primary Key columns in front.
 func TableScan(ctx context.Context, log logutil.Logger, ts topo.Server, tabletAlias *pb.TabletAlias, tableDefinition *myproto.TableDefinition) (*QueryResultReader, error) {
-	sql := fmt.Sprintf("SELECT %v FROM %v ORDER BY %v", strings.Join(orderedColumns(tableDefinition), ", "), tableDefinition.Name, strings.Join(tableDefinition.PrimaryKeyColumns, ", "))
-	log.Infof("SQL query for %v/%v: %v", topoproto.TabletAliasString(tabletAlias), tableDefinition.Name, sql)
-	return NewQueryResultReaderForTablet(ctx, ts, tabletAlias, sql)
+	return TableScanChunk(ctx, log, ts, tabletAlias, tableDefinition, "", "", nil, pb.KeyspaceIdType_UNSET)
 }
 
 // TableScanByKeyRange returns a QueryResultReader that gets all the
@@ -114,47 +114,59 @@ func TableScan(ctx context.Context, log logutil.Logger, ts topo.Server, tabletAl
 // Primary Key. The returned columns are ordered with the Primary Key
 // columns in front.
 func TableScanByKeyRange(ctx context.Context, log logutil.Logger, ts topo.Server, tabletAlias *pb.TabletAlias, tableDefinition *myproto.TableDefinition, keyRange *pb.KeyRange, keyspaceIDType pb.KeyspaceIdType) (*QueryResultReader, error) {
-	where := ""
+	return TableScanChunk(ctx, log, ts, tabletAlias, tableDefinition, "", "", keyRange, keyspaceIDType)
+}
+
+// TableScanChunk returns a QueryResultReader that gets the rows from a
+// table whose leading Primary Key column is between chunkStart
+// (inclusive) and chunkEnd (exclusive), and that match the supplied
+// KeyRange, ordered by Primary Key. Empty chunk bounds and a nil
+// KeyRange are not restricting the scan. The returned columns are
+// ordered with the Primary Key columns in front.
+func TableScanChunk(ctx context.Context, log logutil.Logger, ts topo.Server, tabletAlias *pb.TabletAlias, tableDefinition *myproto.TableDefinition, chunkStart, chunkEnd string, keyRange *pb.KeyRange, keyspaceIDType pb.KeyspaceIdType) (*QueryResultReader, error) {
+	sql, err := buildTableScanSQL(tableDefinition, chunkStart, chunkEnd, keyRange, keyspaceIDType)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("SQL query for %v/%v: %v", topoproto.TabletAliasString(tabletAlias), tableDefinition.Name, sql)
+	return NewQueryResultReaderForTablet(ctx, ts, tabletAlias, sql)
+}
+
+// buildTableScanSQL returns the query used by TableScanChunk.
+func buildTableScanSQL(tableDefinition *myproto.TableDefinition, chunkStart, chunkEnd string, keyRange *pb.KeyRange, keyspaceIDType pb.KeyspaceIdType) (string, error) {
+	var clauses []string
 	if keyRange != nil {
 		switch keyspaceIDType {
 		case pb.KeyspaceIdType_UINT64:
 			if len(keyRange.Start) > 0 {
-				if len(keyRange.End) > 0 {
-					// have start & end
-					where = fmt.Sprintf("WHERE keyspace_id >= %v AND keyspace_id < %v ", uint64FromKeyspaceID(keyRange.Start), uint64FromKeyspaceID(keyRange.End))
-				} else {
-					// have start only
-					where = fmt.Sprintf("WHERE keyspace_id >= %v ", uint64FromKeyspaceID(keyRange.Start))
-				}
-			} else {
-				if len(keyRange.End) > 0 {
-					// have end only
-					where = fmt.Sprintf("WHERE keyspace_id < %v ", uint64FromKeyspaceID(keyRange.End))
-				}
+				clauses = append(clauses, fmt.Sprintf("keyspace_id >= %v", uint64FromKeyspaceID(keyRange.Start)))
+			}
+			if len(keyRange.End) > 0 {
+				clauses = append(clauses, fmt.Sprintf("keyspace_id < %v", uint64FromKeyspaceID(keyRange.End)))
 			}
 		case pb.KeyspaceIdType_BYTES:
 			if len(keyRange.Start) > 0 {
-				if len(keyRange.End) > 0 {
-					// have start & end
-					where = fmt.Sprintf("WHERE HEX(keyspace_id) >= '%v' AND HEX(keyspace_id) < '%v' ", hex.EncodeToString(keyRange.Start), hex.EncodeToString(keyRange.End))
-				} else {
-					// have start only
-					where = fmt.Sprintf("WHERE HEX(keyspace_id) >= '%v' ", hex.EncodeToString(keyRange.Start))
-				}
-			} else {
-				if len(keyRange.End) > 0 {
-					// have end only
-					where = fmt.Sprintf("WHERE HEX(keyspace_id) < '%v' ", hex.EncodeToString(keyRange.End))
-				}
+				clauses = append(clauses, fmt.Sprintf("HEX(keyspace_id) >= '%v'", hex.EncodeToString(keyRange.Start)))
+			}
+			if len(keyRange.End) > 0 {
+				clauses = append(clauses, fmt.Sprintf("HEX(keyspace_id) < '%v'", hex.EncodeToString(keyRange.End)))
 			}
 		default:
-			return nil, fmt.Errorf("Unsupported KeyspaceIdType: %v", keyspaceIDType)
+			return "", fmt.Errorf("Unsupported KeyspaceIdType: %v", keyspaceIDType)
 		}
 	}
+	if chunkStart != "" {
+		clauses = append(clauses, fmt.Sprintf("%v >= %v", tableDefinition.PrimaryKeyColumns[0], chunkStart))
+	}
+	if chunkEnd != "" {
+		clauses = append(clauses, fmt.Sprintf("%v < %v", tableDefinition.PrimaryKeyColumns[0], chunkEnd))
+	}
 
-	sql := fmt.Sprintf("SELECT %v FROM %v %vORDER BY %v", strings.Join(orderedColumns(tableDefinition), ", "), tableDefinition.Name, where, strings.Join(tableDefinition.PrimaryKeyColumns, ", "))
-	log.Infof("SQL query for %v/%v: %v", topoproto.TabletAliasString(tabletAlias), tableDefinition.Name, sql)
-	return NewQueryResultReaderForTablet(ctx, ts, tabletAlias, sql)
+	where := ""
+	if len(clauses) > 0 {
+		where = "WHERE " + strings.Join(clauses, " AND ") + " "
+	}
+	return fmt.Sprintf("SELECT %v FROM %v %vORDER BY %v", strings.Join(orderedColumns(tableDefinition), ", "), tableDefinition.Name, where, strings.Join(tableDefinition.PrimaryKeyColumns, ", ")), nil
 }
 
 func (qrr *QueryResultReader) Error() error {
@@ -171,6 +183,10 @@ type RowReader struct {
 	queryResultReader *QueryResultReader
 	currentResult     *mproto.QueryResult
 	currentIndex      int
+
+	// keyResolver and keyRange are set by filterByKeyRange.
+	keyResolver keyresolver.Resolver
+	keyRange    *pb.KeyRange
 }
 
 // NewRowReader returns a RowReader based on the QueryResultReader
@@ -180,11 +196,36 @@ func NewRowReader(queryResultReader *QueryResultReader) *RowReader {
 	}
 }
 
+// filterByKeyRange makes the RowReader skip the rows whose keyspace id,
+// computed by keyResolver, is not in keyRange. It is used when the
+// query cannot filter the rows itself.
+func (rr *RowReader) filterByKeyRange(keyResolver keyresolver.Resolver, keyRange *pb.KeyRange) {
+	rr.keyResolver = keyResolver
+	rr.keyRange = keyRange
+}
+
 // Next will return:
 // (row, nil) for the next row
 // (nil, nil) for EOF
 // (nil, error) if an error occurred
 func (rr *RowReader) Next() ([]sqltypes.Value, error) {
+	for {
+		row, err := rr.next()
+		if err != nil || row == nil || rr.keyResolver == nil {
+			return row, err
+		}
+		keyspaceID, err := rr.keyResolver.KeyspaceID(row)
+		if err != nil {
+			return nil, err
+		}
+		if key.KeyRangeContains(rr.keyRange, keyspaceID) {
+			return row, nil
+		}
+	}
+}
+
+// next returns the next row, without filtering it.
+func (rr *RowReader) next() ([]sqltypes.Value, error) {
 	if rr.currentResult == nil || rr.currentIndex == len(rr.currentResult.Rows) {
 		var ok bool
 		rr.currentResult, ok = <-rr.queryResultReader.Output
@@ -242,6 +283,16 @@ func (dr *DiffReport) HasDifferences() bool {
 	return dr.mismatchedRows > 0 || dr.extraRowsLeft > 0 || dr.extraRowsRight > 0
 }
 
+// merge adds the stats of a diff job which ran on another chunk of the
+// same table. startingTime is left alone.
+func (dr *DiffReport) merge(other DiffReport) {
+	dr.processedRows += other.processedRows
+	dr.matchingRows += other.matchingRows
+	dr.mismatchedRows += other.mismatchedRows
+	dr.extraRowsLeft += other.extraRowsLeft
+	dr.extraRowsRight += other.extraRowsRight
+}
+
 // ComputeQPS fills in processingQPS
 func (dr *DiffReport) ComputeQPS() {
 	if dr.processedRows > 0 {
@@ -318,6 +369,10 @@ type RowDiffer struct {
 	left         *RowReader
 	right        *RowReader
 	pkFieldCount int
+	tableName    string
+
+	// mismatches is optional. If set, all differences are recorded in it.
+	mismatches *mismatchReport
 }
 
 // NewRowDiffer returns a new RowDiffer
@@ -334,9 +389,42 @@ func NewRowDiffer(left, right *QueryResultReader, tableDefinition *myproto.Table
 		left:         NewRowReader(left),
 		right:        NewRowReader(right),
 		pkFieldCount: len(tableDefinition.PrimaryKeyColumns),
+		tableName:    tableDefinition.Name,
 	}, nil
 }
 
+// recordMismatch records a difference in rd.mismatches, if set.
+// left or right is nil if the row only exists on the other side.
+func (rd *RowDiffer) recordMismatch(left, right []sqltypes.Value) {
+	if rd.mismatches != nil {
+		rd.mismatches.record(rd.tableName, rd.left.Fields(), rd.pkFieldCount, left, right)
+	}
+}
+
+// drain is like rr.Drain, but also records the drained rows as
+// mismatches. onLeft tells which side rr is reading.
+func (rd *RowDiffer) drain(rr *RowReader, onLeft bool) (int, error) {
+	if rd.mismatches == nil {
+		return rr.Drain()
+	}
+	count := 0
+	for {
+		row, err := rr.Next()
+		if err != nil {
+			return 0, err
+		}
+		if row == nil {
+			return count, nil
+		}
+		if onLeft {
+			rd.recordMismatch(row, nil)
+		} else {
+			rd.recordMismatch(nil, row)
+		}
+		count++
+	}
+}
+
 // Go runs the diff. If there is no error, it will drain both sides.
 // If an error occurs, it will just return it and stop.
 func (rd *RowDiffer) Go(log logutil.Logger) (dr DiffReport, err error) {
@@ -372,7 +460,8 @@ func (rd *RowDiffer) Go(log logutil.Logger) (dr DiffReport, err error) {
 			}
 
 			// drain right, update count
-			if count, err := rd.right.Drain(); err != nil {
+			rd.recordMismatch(nil, right)
+			if count, err := rd.drain(rd.right, false); err != nil {
 				return dr, err
 			} else {
 				dr.extraRowsRight += 1 + count
@@ -382,7 +471,8 @@ func (rd *RowDiffer) Go(log logutil.Logger) (dr DiffReport, err error) {
 		if right == nil {
 			// no more rows from the right
 			// we know we have rows from left, drain, update count
-			if count, err := rd.left.Drain(); err != nil {
+			rd.recordMismatch(left, nil)
+			if count, err := rd.drain(rd.left, true); err != nil {
 				return dr, err
 			} else {
 				dr.extraRowsLeft += 1 + count
@@ -405,6 +495,7 @@ func (rd *RowDiffer) Go(log logutil.Logger) (dr DiffReport, err error) {
 			if dr.mismatchedRows < 10 {
 				log.Errorf("Different content %v in same PK: %v != %v", dr.mismatchedRows, left, right)
 			}
+			rd.recordMismatch(left, right)
 			dr.mismatchedRows++
 			advanceLeft = true
 			advanceRight = true
@@ -420,6 +511,7 @@ func (rd *RowDiffer) Go(log logutil.Logger) (dr DiffReport, err error) {
 			if dr.extraRowsLeft < 10 {
 				log.Errorf("Extra row %v on left: %v", dr.extraRowsLeft, left)
 			}
+			rd.recordMismatch(left, nil)
 			dr.extraRowsLeft++
 			advanceLeft = true
 			continue
@@ -427,6 +519,7 @@ func (rd *RowDiffer) Go(log logutil.Logger) (dr DiffReport, err error) {
 			if dr.extraRowsRight < 10 {
 				log.Errorf("Extra row %v on right: %v", dr.extraRowsRight, right)
 			}
+			rd.recordMismatch(nil, right)
 			dr.extraRowsRight++
 			advanceRight = true
 			continue
@@ -438,6 +531,7 @@ func (rd *RowDiffer) Go(log logutil.Logger) (dr DiffReport, err error) {
 		if dr.mismatchedRows < 10 {
 			log.Errorf("Different content %v in same PK: %v != %v", dr.mismatchedRows, left, right)
 		}
+		rd.recordMismatch(left, right)
 		dr.mismatchedRows++
 		advanceLeft = true
 		advanceRight = true
@@ -11,7 +11,10 @@ import (
 
 	mproto "github.com/youtube/vitess/go/mysql/proto"
 	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/logutil"
 	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
 )
 
 func TestOrderedColumns(t *testing.T) {
@@ -124,3 +127,115 @@ func TestCompareRows(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildTableScanSQL(t *testing.T) {
+	td := &myproto.TableDefinition{
+		Name:              "t1",
+		PrimaryKeyColumns: []string{"id"},
+		Columns:           []string{"id", "msg", "keyspace_id"},
+	}
+	table := []struct {
+		chunkStart, chunkEnd string
+		keyRange             *pb.KeyRange
+		keyspaceIDType       pb.KeyspaceIdType
+		want                 string
+	}{
+		{
+			want: "SELECT id, msg, keyspace_id FROM t1 ORDER BY id",
+		},
+		{
+			keyRange:       &pb.KeyRange{End: []byte{0x40}},
+			keyspaceIDType: pb.KeyspaceIdType_UINT64,
+			want:           "SELECT id, msg, keyspace_id FROM t1 WHERE keyspace_id < 0x4000000000000000 ORDER BY id",
+		},
+		{
+			chunkStart:     "100",
+			chunkEnd:       "200",
+			keyRange:       &pb.KeyRange{Start: []byte{0x40}, End: []byte{0x80}},
+			keyspaceIDType: pb.KeyspaceIdType_BYTES,
+			want:           "SELECT id, msg, keyspace_id FROM t1 WHERE HEX(keyspace_id) >= '40' AND HEX(keyspace_id) < '80' AND id >= 100 AND id < 200 ORDER BY id",
+		},
+		{
+			chunkEnd: "200",
+			want:     "SELECT id, msg, keyspace_id FROM t1 WHERE id < 200 ORDER BY id",
+		},
+	}
+	for _, tc := range table {
+		got, err := buildTableScanSQL(td, tc.chunkStart, tc.chunkEnd, tc.keyRange, tc.keyspaceIDType)
+		if err != nil {
+			t.Errorf("buildTableScanSQL(%v, %v, %v) failed: %v", tc.chunkStart, tc.chunkEnd, tc.keyRange, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("buildTableScanSQL(%v, %v, %v) = %v, want %v", tc.chunkStart, tc.chunkEnd, tc.keyRange, got, tc.want)
+		}
+	}
+
+	if _, err := buildTableScanSQL(td, "", "", &pb.KeyRange{End: []byte{0x40}}, pb.KeyspaceIdType_UNSET); err == nil {
+		t.Errorf("buildTableScanSQL with an unset keyspace id type should have failed")
+	}
+}
+
+// fakeQueryResultReader returns a QueryResultReader streaming the
+// provided rows.
+func fakeQueryResultReader(fields []mproto.Field, rows [][]sqltypes.Value) *QueryResultReader {
+	output := make(chan *mproto.QueryResult, 1)
+	output <- &mproto.QueryResult{Rows: rows}
+	close(output)
+	return &QueryResultReader{
+		Output:      output,
+		Fields:      fields,
+		clientErrFn: func() error { return nil },
+	}
+}
+
+func TestRowDifferMismatches(t *testing.T) {
+	td := &myproto.TableDefinition{
+		Name:              "t1",
+		PrimaryKeyColumns: []string{"id"},
+		Columns:           []string{"id", "msg"},
+	}
+	fields := []mproto.Field{
+		{"id", mproto.VT_LONGLONG, mproto.VT_ZEROVALUE_FLAG},
+		{"msg", mproto.VT_VAR_STRING, mproto.VT_ZEROVALUE_FLAG},
+	}
+	row := func(id, msg string) []sqltypes.Value {
+		return []sqltypes.Value{sqltypes.MakeString([]byte(id)), sqltypes.MakeString([]byte(msg))}
+	}
+	left := fakeQueryResultReader(fields, [][]sqltypes.Value{row("1", "a"), row("2", "b"), row("4", "d"), row("6", "f")})
+	right := fakeQueryResultReader(fields, [][]sqltypes.Value{row("1", "a"), row("2", "x"), row("3", "c"), row("4", "d"), row("7", "g"), row("8", "h")})
+
+	differ, err := NewRowDiffer(left, right, td)
+	if err != nil {
+		t.Fatalf("NewRowDiffer failed: %v", err)
+	}
+	mismatches, err := newMismatchReport("ks", "-80", 10, "", "")
+	if err != nil {
+		t.Fatalf("newMismatchReport failed: %v", err)
+	}
+	differ.mismatches = mismatches
+
+	report, err := differ.Go(logutil.NewMemoryLogger())
+	if err != nil {
+		t.Fatalf("Go failed: %v", err)
+	}
+	if report.matchingRows != 2 || report.mismatchedRows != 1 || report.extraRowsLeft != 1 || report.extraRowsRight != 3 {
+		t.Errorf("unexpected report: %v", report.String())
+	}
+
+	want := map[string]int{
+		mismatchDifferentContent:     1,
+		mismatchMissingOnDestination: 1,
+		mismatchExtraOnDestination:   3,
+	}
+	if got := mismatches.content.Counts["t1"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got counts %v, want %v", got, want)
+	}
+	var ids []string
+	for _, m := range mismatches.content.Mismatches {
+		ids = append(ids, m.PrimaryKey["id"])
+	}
+	if want := []string{"2", "3", "6", "7", "8"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got mismatched ids %v, want %v", ids, want)
+	}
+}
@@ -5,11 +5,12 @@
 package worker
 
 import (
-	"fmt"
+	"sync"
 
 	mproto "github.com/youtube/vitess/go/mysql/proto"
 	"github.com/youtube/vitess/go/sqltypes"
 	"github.com/youtube/vitess/go/vt/key"
+	"github.com/youtube/vitess/go/vt/keyresolver"
 	"github.com/youtube/vitess/go/vt/topo"
 
 	pb "github.com/youtube/vitess/go/vt/proto/topodata"
@@ -18,17 +19,23 @@ import (
 // RowSplitter is a helper class to split rows into multiple
 // subsets targeted to different shards.
 type RowSplitter struct {
-	KeyspaceIdType pb.KeyspaceIdType
-	ValueIndex     int
-	KeyRanges      []*pb.KeyRange
+	KeyResolver keyresolver.Resolver
+	KeyRanges   []*pb.KeyRange
 }
 
-// NewRowSplitter returns a new row splitter for the given shard distribution.
+// NewRowSplitter returns a new row splitter for the given shard
+// distribution, for rows which have their keyspace id in the column at
+// valueIndex.
 func NewRowSplitter(shardInfos []*topo.ShardInfo, keyspaceIdType pb.KeyspaceIdType, valueIndex int) *RowSplitter {
+	return newRowSplitter(shardInfos, keyresolver.NewV2ResolverForColumn(keyspaceIdType, valueIndex))
+}
+
+// newRowSplitter returns a new row splitter for the given shard
+// distribution, which uses keyResolver to find the keyspace id of a row.
+func newRowSplitter(shardInfos []*topo.ShardInfo, keyResolver keyresolver.Resolver) *RowSplitter {
 	result := &RowSplitter{
-		KeyspaceIdType: keyspaceIdType,
-		ValueIndex:     valueIndex,
-		KeyRanges:      make([]*pb.KeyRange, len(shardInfos)),
+		KeyResolver: keyResolver,
+		KeyRanges:   make([]*pb.KeyRange, len(shardInfos)),
 	}
 	for i, si := range shardInfos {
 		result.KeyRanges[i] = si.KeyRange
@@ -43,29 +50,15 @@ func (rs *RowSplitter) StartSplit() [][][]sqltypes.Value {
 
 // Split will split the rows into subset for each distribution
 func (rs *RowSplitter) Split(result [][][]sqltypes.Value, rows [][]sqltypes.Value) error {
-	if rs.KeyspaceIdType == pb.KeyspaceIdType_UINT64 {
-		for _, row := range rows {
-			v := sqltypes.MakeNumeric(row[rs.ValueIndex].Raw())
-			i, err := v.ParseUint64()
-			if err != nil {
-				return fmt.Errorf("Non numerical value: %v", err)
-			}
-			k := key.Uint64Key(i).Bytes()
-			for i, kr := range rs.KeyRanges {
-				if key.KeyRangeContains(kr, k) {
-					result[i] = append(result[i], row)
-					break
-				}
-			}
+	for _, row := range rows {
+		k, err := rs.KeyResolver.KeyspaceID(row)
+		if err != nil {
+			return err
 		}
-	} else {
-		for _, row := range rows {
-			k := row[rs.ValueIndex].Raw()
-			for i, kr := range rs.KeyRanges {
-				if key.KeyRangeContains(kr, k) {
-					result[i] = append(result[i], row)
-					break
-				}
+		for i, kr := range rs.KeyRanges {
+			if key.KeyRangeContains(kr, k) {
+				result[i] = append(result[i], row)
+				break
 			}
 		}
 	}
@@ -73,16 +66,26 @@ func (rs *RowSplitter) Split(result [][][]sqltypes.Value, rows [][]sqltypes.Valu
 }
 
 // Send will send the rows to the list of channels. Returns true if aborted.
-func (rs *RowSplitter) Send(fields []mproto.Field, result [][][]sqltypes.Value, baseCmd string, insertChannels []chan string, abort <-chan struct{}) bool {
+// If done is not nil, it is incremented for each sent command.
+func (rs *RowSplitter) Send(fields []mproto.Field, result [][][]sqltypes.Value, baseCmd string, insertChannels []chan insertCommand, done *sync.WaitGroup, abort <-chan struct{}) bool {
 	for i, c := range insertChannels {
 		// one of the chunks might be empty, so no need
 		// to send data in that case
 		if len(result[i]) > 0 {
-			cmd := baseCmd + makeValueString(fields, result[i])
+			cmd := insertCommand{
+				sql:  baseCmd + makeValueString(fields, result[i]),
+				done: done,
+			}
+			if done != nil {
+				done.Add(1)
+			}
 			// also check on abort, so we don't wait forever
 			select {
 			case c <- cmd:
 			case <-abort:
+				if done != nil {
+					done.Done()
+				}
 				return true
 			}
 		}
@@ -11,7 +11,9 @@ import (
 	"testing"
 
 	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/keyresolver"
 	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/vtgate/planbuilder"
 
 	pb "github.com/youtube/vitess/go/vt/proto/topodata"
 )
@@ -129,3 +131,67 @@ func TestRowSplitterString(t *testing.T) {
 		t.Fatalf("Bad result[2]: %v", result[2])
 	}
 }
+
+func TestRowSplitterV3(t *testing.T) {
+	schema, err := planbuilder.NewSchema([]byte(`{
+  "Keyspaces": {
+    "ks": {
+      "Sharded": true,
+      "Vindexes": {"user_index": {"Type": "numeric"}},
+      "Classes": {"user": {"ColVindexes": [{"Col": "user_id", "Name": "user_index"}]}},
+      "Tables": {"user": "user"}
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatalf("NewSchema failed: %v", err)
+	}
+	keyResolver, err := keyresolver.NewV3Resolver(schema, "ks", "user", []string{"msg", "user_id"})
+	if err != nil {
+		t.Fatalf("NewV3Resolver failed: %v", err)
+	}
+	shards := []*topo.ShardInfo{
+		si("", "40"),
+		si("40", "c0"),
+		si("c0", ""),
+	}
+	rs := newRowSplitter(shards, keyResolver)
+
+	// the numeric vindex maps a number to itself
+	row0 := []sqltypes.Value{
+		sqltypes.MakeString([]byte("Ignored Value")),
+		sqltypes.MakeString([]byte(fmt.Sprintf("%v", 0x1000000000000000))),
+	}
+	row1 := []sqltypes.Value{
+		sqltypes.MakeString([]byte("Ignored Value")),
+		sqltypes.MakeString([]byte(fmt.Sprintf("%v", 0x6000000000000000))),
+	}
+	row2 := []sqltypes.Value{
+		sqltypes.MakeString([]byte("Ignored Value")),
+		sqltypes.MakeString([]byte(fmt.Sprintf("%v", uint64(0xe000000000000000)))),
+	}
+
+	rows := [][]sqltypes.Value{row0, row1, row2, row2, row1, row2, row0}
+	result := rs.StartSplit()
+	if err := rs.Split(result, rows); err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if !reflect.DeepEqual(result[0], [][]sqltypes.Value{row0, row0}) {
+		t.Fatalf("Bad result[0]: %v", result[0])
+	}
+	if !reflect.DeepEqual(result[1], [][]sqltypes.Value{row1, row1}) {
+		t.Fatalf("Bad result[1]: %v", result[1])
+	}
+	if !reflect.DeepEqual(result[2], [][]sqltypes.Value{row2, row2, row2}) {
+		t.Fatalf("Bad result[2]: %v", result[2])
+	}
+
+	// values the vindex cannot map
+	bad := []sqltypes.Value{
+		sqltypes.MakeString([]byte("Ignored Value")),
+		sqltypes.MakeString([]byte("abc")),
+	}
+	if err := rs.Split(rs.StartSplit(), [][]sqltypes.Value{bad}); err == nil {
+		t.Errorf("Split should have failed for a non numerical value")
+	}
+}
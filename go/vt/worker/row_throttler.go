@@ -0,0 +1,65 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// rowThrottler limits the number of rows copied per second.
+// The rate can be changed while the copy is running.
+type rowThrottler struct {
+	// mu protects all fields
+	mu sync.Mutex
+	// maxRate is the maximum number of rows per second, 0 means unlimited.
+	maxRate int64
+	// next is the time the next rows may be copied.
+	next time.Time
+}
+
+func newRowThrottler(maxRate int64) *rowThrottler {
+	return &rowThrottler{maxRate: maxRate}
+}
+
+func (rt *rowThrottler) getMaxRate() int64 {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.maxRate
+}
+
+// setMaxRate changes the rate. It is applied to the next call to wait.
+func (rt *rowThrottler) setMaxRate(maxRate int64) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.maxRate = maxRate
+	rt.next = time.Time{}
+}
+
+// wait accounts for "rows" copied rows, and blocks until the copy is
+// back under the maximum rate. It returns false if aborted.
+func (rt *rowThrottler) wait(rows int, abort <-chan struct{}) bool {
+	rt.mu.Lock()
+	if rt.maxRate <= 0 {
+		rt.mu.Unlock()
+		return true
+	}
+	now := time.Now()
+	if rt.next.Before(now) {
+		rt.next = now
+	}
+	rt.next = rt.next.Add(time.Duration(int64(rows) * int64(time.Second) / rt.maxRate))
+	delay := rt.next.Sub(now)
+	rt.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-abort:
+		return false
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRowThrottler(t *testing.T) {
+	// unlimited
+	rt := newRowThrottler(0)
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if !rt.wait(1000, nil) {
+			t.Fatalf("wait() was aborted")
+		}
+	}
+	if d := time.Now().Sub(start); d > 100*time.Millisecond {
+		t.Errorf("unlimited throttler waited %v", d)
+	}
+
+	// 1000 rows per second: 3 batches of 100 rows take at least 300ms
+	rt.setMaxRate(1000)
+	if got := rt.getMaxRate(); got != 1000 {
+		t.Errorf("getMaxRate() = %v, want 1000", got)
+	}
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		if !rt.wait(100, nil) {
+			t.Fatalf("wait() was aborted")
+		}
+	}
+	if d := time.Now().Sub(start); d < 300*time.Millisecond {
+		t.Errorf("throttler with a rate of 1000 rows/s copied 300 rows in %v", d)
+	}
+}
+
+func TestRowThrottlerAbort(t *testing.T) {
+	rt := newRowThrottler(1)
+	abort := make(chan struct{})
+	close(abort)
+	start := time.Now()
+	if rt.wait(3600, abort) {
+		t.Errorf("wait() was not aborted")
+	}
+	if d := time.Now().Sub(start); d > time.Second {
+		t.Errorf("aborted wait() took %v", d)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/youtube/vitess/go/event"
 	"github.com/youtube/vitess/go/sync2"
 	"github.com/youtube/vitess/go/vt/binlog/binlogplayer"
+	"github.com/youtube/vitess/go/vt/keyresolver"
 	"github.com/youtube/vitess/go/vt/mysqlctl"
 	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 	"github.com/youtube/vitess/go/vt/topo"
@@ -43,6 +44,7 @@ type SplitCloneWorker struct {
 	minTableSizeForSplit   uint64
 	destinationWriterCount int
 	cleaner                *wrangler.Cleaner
+	throttler              *rowThrottler
 
 	// all subsequent fields are protected by the mutex
 
@@ -54,10 +56,19 @@ type SplitCloneWorker struct {
 	// populated during WorkerStateFindTargets, read-only after that
 	sourceAliases []*pb.TabletAlias
 	sourceTablets []*topo.TabletInfo
+	// only populated with the ResumableCopy strategy: the stopped
+	// replication positions of the sources, and what a previous
+	// run already copied.
+	sourcePositions []myproto.ReplicationPosition
+	checkpoint      *splitCloneCheckpoint
 
 	// populated during WorkerStateCopy
 	tableStatus []*tableStatus
 	startTime   time.Time
+	// copyStarted is set once we started to copy data. With the
+	// ResumableCopy strategy, the sources are then left stopped
+	// if we fail, so the copy can be resumed.
+	copyStarted bool
 	// aliases of tablets that need to have their schema reloaded.
 	// Only populated once, read-only after that.
 	reloadAliases [][]*pb.TabletAlias
@@ -74,7 +85,7 @@ type SplitCloneWorker struct {
 }
 
 // NewSplitCloneWorker returns a new SplitCloneWorker object.
-func NewSplitCloneWorker(wr *wrangler.Wrangler, cell, keyspace, shard string, excludeTables []string, strategyStr string, sourceReaderCount, destinationPackCount int, minTableSizeForSplit uint64, destinationWriterCount int) (Worker, error) {
+func NewSplitCloneWorker(wr *wrangler.Wrangler, cell, keyspace, shard string, excludeTables []string, strategyStr string, sourceReaderCount, destinationPackCount int, minTableSizeForSplit uint64, destinationWriterCount int, maxRowsPerSecond int64) (Worker, error) {
 	strategy, err := mysqlctl.NewSplitStrategy(wr.Logger(), strategyStr)
 	if err != nil {
 		return nil, err
@@ -92,6 +103,7 @@ func NewSplitCloneWorker(wr *wrangler.Wrangler, cell, keyspace, shard string, ex
 		minTableSizeForSplit:   minTableSizeForSplit,
 		destinationWriterCount: destinationWriterCount,
 		cleaner:                &wrangler.Cleaner{},
+		throttler:              newRowThrottler(maxRowsPerSecond),
 
 		ev: &events.SplitClone{
 			Cell:          cell,
@@ -121,6 +133,24 @@ func (scw *SplitCloneWorker) formatSources() string {
 	return result
 }
 
+// MaxRowsPerSecond implements the Throttler interface
+func (scw *SplitCloneWorker) MaxRowsPerSecond() int64 {
+	return scw.throttler.getMaxRate()
+}
+
+// SetMaxRowsPerSecond implements the Throttler interface
+func (scw *SplitCloneWorker) SetMaxRowsPerSecond(maxRowsPerSecond int64) {
+	scw.wr.Logger().Infof("Changing the maximum copy rate to %v rows per second", maxRowsPerSecond)
+	scw.throttler.setMaxRate(maxRowsPerSecond)
+}
+
+func (scw *SplitCloneWorker) formatMaxRowsPerSecond() string {
+	if maxRate := scw.throttler.getMaxRate(); maxRate > 0 {
+		return fmt.Sprintf("%v", maxRate)
+	}
+	return "unlimited"
+}
+
 // StatusAsHTML implements the Worker interface
 func (scw *SplitCloneWorker) StatusAsHTML() template.HTML {
 	scw.Mu.Lock()
@@ -131,6 +161,7 @@ func (scw *SplitCloneWorker) StatusAsHTML() template.HTML {
 	case WorkerStateCopy:
 		result += "<b>Running</b>:</br>\n"
 		result += "<b>Copying from</b>: " + scw.formatSources() + "</br>\n"
+		result += "<b>Max rows per second</b>: " + scw.formatMaxRowsPerSecond() + "</br>\n"
 		statuses, eta := formatTableStatuses(scw.tableStatus, scw.startTime)
 		result += "<b>ETA</b>: " + eta.String() + "</br>\n"
 		result += strings.Join(statuses, "</br>\n")
@@ -153,6 +184,7 @@ func (scw *SplitCloneWorker) StatusAsText() string {
 	case WorkerStateCopy:
 		result += "Running:\n"
 		result += "Copying from: " + scw.formatSources() + "\n"
+		result += "Max rows per second: " + scw.formatMaxRowsPerSecond() + "\n"
 		statuses, eta := formatTableStatuses(scw.tableStatus, scw.startTime)
 		result += "ETA: " + eta.String() + "\n"
 		result += strings.Join(statuses, "\n")
@@ -168,6 +200,9 @@ func (scw *SplitCloneWorker) StatusAsText() string {
 func (scw *SplitCloneWorker) Run(ctx context.Context) error {
 	resetVars()
 	err := scw.run(ctx)
+	if err != nil && scw.strategy.ResumableCopy && scw.copyStarted {
+		scw.keepSourcesForResume()
+	}
 
 	scw.setState(WorkerStateCleanUp)
 	cerr := scw.cleaner.CleanUp(scw.wr)
@@ -211,6 +246,21 @@ func (scw *SplitCloneWorker) run(ctx context.Context) error {
 	return nil
 }
 
+// keepSourcesForResume removes the clean-up actions which would restart
+// replication on the source tablets and change their type back, so
+// the next run can resume the copy from the same position.
+func (scw *SplitCloneWorker) keepSourcesForResume() {
+	for _, alias := range scw.sourceAliases {
+		target := topoproto.TabletAliasString(alias)
+		for _, name := range []string{wrangler.StartSlaveActionName, wrangler.ChangeSlaveTypeActionName} {
+			if err := scw.cleaner.RemoveActionByName(name, target); err != nil {
+				scw.wr.Logger().Warningf("cannot remove %v for tablet %v: %v", name, target, err)
+			}
+		}
+		scw.wr.Logger().Infof("Leaving tablet %v as %v with replication stopped, run SplitClone again to resume the copy", target, pb.TabletType_WORKER)
+	}
+}
+
 // init phase:
 // - read the destination keyspace, make sure it has 'servedFrom' values
 func (scw *SplitCloneWorker) init(ctx context.Context) error {
@@ -269,19 +319,40 @@ func (scw *SplitCloneWorker) init(ctx context.Context) error {
 }
 
 // findTargets phase:
-// - find one rdonly in the source shard
+// - resolve the destination masters
+// - with the ResumableCopy strategy, read what a previous run copied
+// - find one rdonly in the source shard, or reuse the previous run's one
 // - mark it as 'worker' pointing back to us
 // - get the aliases of all the targets
 func (scw *SplitCloneWorker) findTargets(ctx context.Context) error {
 	scw.setState(WorkerStateFindTargets)
 	var err error
 
+	if err := scw.ResolveDestinationMasters(ctx); err != nil {
+		return err
+	}
+	if scw.strategy.ResumableCopy {
+		if err := scw.readCheckpoint(ctx); err != nil {
+			return err
+		}
+	}
+
 	// find an appropriate endpoint in the source shards
 	scw.sourceAliases = make([]*pb.TabletAlias, len(scw.sourceShards))
 	for i, si := range scw.sourceShards {
-		scw.sourceAliases[i], err = FindWorkerTablet(ctx, scw.wr, scw.cleaner, scw.cell, si.Keyspace(), si.ShardName())
-		if err != nil {
-			return fmt.Errorf("FindWorkerTablet() failed for %v/%v/%v: %v", scw.cell, si.Keyspace(), si.ShardName(), err)
+		if source, ok := scw.recordedSource(i); ok {
+			scw.sourceAliases[i], err = topoproto.ParseTabletAlias(source.sourceTablet)
+			if err == nil {
+				err = reuseWorkerTablet(ctx, scw.wr, scw.cleaner, scw.sourceAliases[i])
+			}
+			if err != nil {
+				return fmt.Errorf("cannot resume the copy from tablet %v for %v/%v: %v. To start over, delete the copied data and the _vt.split_clone_checkpoint table on the destinations", source.sourceTablet, si.Keyspace(), si.ShardName(), err)
+			}
+		} else {
+			scw.sourceAliases[i], err = FindWorkerTablet(ctx, scw.wr, scw.cleaner, scw.cell, si.Keyspace(), si.ShardName())
+			if err != nil {
+				return fmt.Errorf("FindWorkerTablet() failed for %v/%v/%v: %v", scw.cell, si.Keyspace(), si.ShardName(), err)
+			}
 		}
 		scw.wr.Logger().Infof("Using tablet %v as source for %v/%v", topoproto.TabletAliasString(scw.sourceAliases[i]), si.Keyspace(), si.ShardName())
 	}
@@ -311,7 +382,79 @@ func (scw *SplitCloneWorker) findTargets(ctx context.Context) error {
 		action.TabletType = pb.TabletType_SPARE
 	}
 
-	return scw.ResolveDestinationMasters(ctx)
+	if scw.strategy.ResumableCopy {
+		return scw.checkSourcePositions(ctx)
+	}
+	return nil
+}
+
+// readCheckpoint creates the _vt.split_clone_checkpoint table on the
+// destination masters if necessary, and reads what a previous run copied.
+func (scw *SplitCloneWorker) readCheckpoint(ctx context.Context) error {
+	destinationMasters := make([]*topo.TabletInfo, len(scw.destinationShards))
+	for i, si := range scw.destinationShards {
+		if err := runSQLCommands(ctx, scw.wr, scw, si.ShardName(), createSplitCloneCheckpoint()); err != nil {
+			return fmt.Errorf("cannot create _vt.split_clone_checkpoint on %v/%v: %v", si.Keyspace(), si.ShardName(), err)
+		}
+		ti, err := scw.GetDestinationMaster(si.ShardName())
+		if err != nil {
+			return err
+		}
+		destinationMasters[i] = ti
+	}
+
+	checkpoint, err := readSplitCloneCheckpoint(ctx, scw.wr, destinationMasters)
+	if err != nil {
+		return err
+	}
+	if len(checkpoint.sources) > 0 {
+		scw.wr.Logger().Infof("Resuming a previous copy, %v chunks were already copied", len(checkpoint.done))
+	}
+	scw.checkpoint = checkpoint
+	return nil
+}
+
+// recordedSource returns the source a previous run used for a source shard.
+func (scw *SplitCloneWorker) recordedSource(shardIndex int) (splitCloneSource, bool) {
+	if scw.checkpoint == nil {
+		return splitCloneSource{}, false
+	}
+	source, ok := scw.checkpoint.sources[shardIndex]
+	return source, ok
+}
+
+// isResuming returns true if a previous run already started the copy.
+func (scw *SplitCloneWorker) isResuming() bool {
+	return scw.checkpoint != nil && len(scw.checkpoint.sources) > 0
+}
+
+// checkSourcePositions saves the replication positions of the stopped
+// sources, and makes sure they did not move since the previous run:
+// otherwise the copied chunks would not be consistent with the others.
+func (scw *SplitCloneWorker) checkSourcePositions(ctx context.Context) error {
+	scw.sourcePositions = make([]myproto.ReplicationPosition, len(scw.sourceTablets))
+	for i, ti := range scw.sourceTablets {
+		shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
+		status, err := scw.wr.TabletManagerClient().SlaveStatus(shortCtx, ti)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("cannot get replication position of tablet %v: %v", ti.AliasString(), err)
+		}
+		scw.sourcePositions[i] = status.Position
+
+		source, ok := scw.recordedSource(i)
+		if !ok {
+			continue
+		}
+		pos, err := myproto.DecodeReplicationPosition(source.pos)
+		if err != nil {
+			return fmt.Errorf("cannot parse the recorded position of tablet %v: %v", ti.AliasString(), err)
+		}
+		if !pos.Equal(status.Position) {
+			return fmt.Errorf("tablet %v is at position %v, but the previous copy was made at position %v. To start over, delete the copied data and the _vt.split_clone_checkpoint table on the destinations", ti.AliasString(), status.Position, pos)
+		}
+	}
+	return nil
 }
 
 // ResolveDestinationMasters implements the Resolver interface.
@@ -406,21 +549,22 @@ func (scw *SplitCloneWorker) copy(ctx context.Context) error {
 	scw.startTime = time.Now()
 	scw.Mu.Unlock()
 
-	// Find the column index for the sharding columns in all the databases, and count rows
-	columnIndexes := make([]int, len(sourceSchemaDefinition.TableDefinitions))
+	// Find how to compute the keyspace id of the rows of all the
+	// tables, and count rows
+	shortCtx, cancel = context.WithTimeout(ctx, *remoteActionsTimeout)
+	keyResolverFactory, err := keyresolver.NewFactory(shortCtx, scw.wr.TopoServer(), scw.keyspaceInfo, scw.keyspaceInfo.ShardingColumnType)
+	cancel()
+	if err != nil {
+		return err
+	}
+	rowSplitters := make([]*RowSplitter, len(sourceSchemaDefinition.TableDefinitions))
 	for tableIndex, td := range sourceSchemaDefinition.TableDefinitions {
 		if td.Type == myproto.TableBaseTable {
-			// find the column to split on
-			columnIndexes[tableIndex] = -1
-			for i, name := range td.Columns {
-				if name == scw.keyspaceInfo.ShardingColumnName {
-					columnIndexes[tableIndex] = i
-					break
-				}
-			}
-			if columnIndexes[tableIndex] == -1 {
-				return fmt.Errorf("table %v doesn't have a column named '%v'", td.Name, scw.keyspaceInfo.ShardingColumnName)
+			keyResolver, err := keyResolverFactory(td.Name, td.Columns)
+			if err != nil {
+				return err
 			}
+			rowSplitters[tableIndex] = newRowSplitter(scw.destinationShards, keyResolver)
 
 			scw.tableStatus[tableIndex].mu.Lock()
 			scw.tableStatus[tableIndex].rowCount = td.RowCount
@@ -432,6 +576,15 @@ func (scw *SplitCloneWorker) copy(ctx context.Context) error {
 		}
 	}
 
+	// With the ResumableCopy strategy, record which sources we copy
+	// from before copying anything.
+	if scw.strategy.ResumableCopy {
+		if err := scw.recordSources(ctx); err != nil {
+			return err
+		}
+		scw.copyStarted = true
+	}
+
 	// In parallel, setup the channels to send SQL data chunks to for each destination tablet:
 	//
 	// mu protects the context for cancelation, and firstError
@@ -449,7 +602,10 @@ func (scw *SplitCloneWorker) copy(ctx context.Context) error {
 		mu.Unlock()
 	}
 
-	insertChannels := make([]chan string, len(scw.destinationShards))
+	// when resuming, some rows of a partially copied chunk may
+	// already be on the destinations
+	ignoreDuplicates := scw.isResuming()
+	insertChannels := make([]chan insertCommand, len(scw.destinationShards))
 	destinationWaitGroup := sync.WaitGroup{}
 	for shardIndex, si := range scw.destinationShards {
 		// we create one channel per destination tablet.  It
@@ -457,14 +613,14 @@ func (scw *SplitCloneWorker) copy(ctx context.Context) error {
 		// destinationWriterCount * 2 items, to hopefully
 		// always have data. We then have
 		// destinationWriterCount go routines reading from it.
-		insertChannels[shardIndex] = make(chan string, scw.destinationWriterCount*2)
+		insertChannels[shardIndex] = make(chan insertCommand, scw.destinationWriterCount*2)
 
-		go func(shardName string, insertChannel chan string) {
+		go func(shardName string, insertChannel chan insertCommand) {
 			for j := 0; j < scw.destinationWriterCount; j++ {
 				destinationWaitGroup.Add(1)
 				go func() {
 					defer destinationWaitGroup.Done()
-					if err := executeFetchLoop(ctx, scw.wr, scw, shardName, insertChannel); err != nil {
+					if err := executeFetchLoop(ctx, scw.wr, scw, shardName, insertChannel, ignoreDuplicates); err != nil {
 						processError("executeFetchLoop failed: %v", err)
 					}
 				}()
@@ -482,7 +638,7 @@ func (scw *SplitCloneWorker) copy(ctx context.Context) error {
 				continue
 			}
 
-			rowSplitter := NewRowSplitter(scw.destinationShards, scw.keyspaceInfo.ShardingColumnType, columnIndexes[tableIndex])
+			rowSplitter := rowSplitters[tableIndex]
 
 			chunks, err := FindChunks(ctx, scw.wr, scw.sourceTablets[shardIndex], td, scw.minTableSizeForSplit, scw.sourceReaderCount)
 			if err != nil {
@@ -491,8 +647,21 @@ func (scw *SplitCloneWorker) copy(ctx context.Context) error {
 			scw.tableStatus[tableIndex].setThreadCount(len(chunks) - 1)
 
 			for chunkIndex := 0; chunkIndex < len(chunks)-1; chunkIndex++ {
+				chunk := splitCloneChunk{
+					uid:        shardIndex,
+					tableName:  td.Name,
+					chunkStart: chunks[chunkIndex],
+					chunkEnd:   chunks[chunkIndex+1],
+				}
+				if scw.checkpoint != nil && scw.checkpoint.done[chunk] {
+					scw.wr.Logger().Infof("Skipping table %v between '%v' and '%v' from source shard %v, it was already copied", td.Name, chunk.chunkStart, chunk.chunkEnd, shardIndex)
+					scw.tableStatus[tableIndex].threadStarted()
+					scw.tableStatus[tableIndex].threadDone()
+					continue
+				}
+
 				sourceWaitGroup.Add(1)
-				go func(td *myproto.TableDefinition, tableIndex, chunkIndex int) {
+				go func(td *myproto.TableDefinition, tableIndex, chunkIndex int, chunk splitCloneChunk) {
 					defer sourceWaitGroup.Done()
 
 					sema.Acquire()
@@ -509,12 +678,21 @@ func (scw *SplitCloneWorker) copy(ctx context.Context) error {
 					}
 					defer qrr.Close()
 
-					// process the data
-					if err := scw.processData(td, tableIndex, qrr, rowSplitter, insertChannels, scw.destinationPackCount, ctx.Done()); err != nil {
+					// process the data, and with the ResumableCopy
+					// strategy record the chunk once it is inserted
+					var done *sync.WaitGroup
+					if scw.strategy.ResumableCopy {
+						done = &sync.WaitGroup{}
+					}
+					if err := scw.processData(td, tableIndex, qrr, rowSplitter, insertChannels, scw.destinationPackCount, done, ctx.Done()); err != nil {
 						processError("processData failed: %v", err)
+					} else if done != nil {
+						if err := scw.recordChunk(ctx, chunk, done); err != nil {
+							processError("cannot record copied chunk: %v", err)
+						}
 					}
 					scw.tableStatus[tableIndex].threadDone()
-				}(td, tableIndex, chunkIndex)
+				}(td, tableIndex, chunkIndex, chunk)
 			}
 		}
 	}
@@ -546,7 +724,10 @@ func (scw *SplitCloneWorker) copy(ctx context.Context) error {
 				return err
 			}
 
-			queries = append(queries, binlogplayer.PopulateBlpCheckpoint(0, status.Position, time.Now().Unix(), flags))
+			// With the ResumableCopy strategy, a previous run
+			// may have populated it already, and filtered
+			// replication may have started from it.
+			queries = append(queries, binlogplayer.PopulateBlpCheckpoint(0, status.Position, time.Now().Unix(), flags, scw.strategy.ResumableCopy))
 		}
 
 		for _, si := range scw.destinationShards {
@@ -606,12 +787,69 @@ func (scw *SplitCloneWorker) copy(ctx context.Context) error {
 		}
 	}
 	destinationWaitGroup.Wait()
-	return firstError
+	if firstError != nil {
+		return firstError
+	}
+
+	// The copy is complete, a later run should not resume from it.
+	if scw.strategy.ResumableCopy {
+		for _, si := range scw.destinationShards {
+			if err := runSQLCommands(ctx, scw.wr, scw, si.ShardName(), []string{"DROP TABLE IF EXISTS _vt.split_clone_checkpoint"}); err != nil {
+				return fmt.Errorf("cannot drop _vt.split_clone_checkpoint on %v/%v: %v", si.Keyspace(), si.ShardName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// recordSources records the source tablets and their positions in the
+// _vt.split_clone_checkpoint table of all destination masters.
+func (scw *SplitCloneWorker) recordSources(ctx context.Context) error {
+	queries := make([]string, len(scw.sourceAliases))
+	for i, alias := range scw.sourceAliases {
+		queries[i] = recordSplitCloneSource(i, topoproto.TabletAliasString(alias), scw.sourcePositions[i], time.Now().Unix())
+	}
+	for _, si := range scw.destinationShards {
+		if err := runSQLCommands(ctx, scw.wr, scw, si.ShardName(), queries); err != nil {
+			return fmt.Errorf("cannot record the sources in _vt.split_clone_checkpoint on %v/%v: %v", si.Keyspace(), si.ShardName(), err)
+		}
+	}
+	return nil
+}
+
+// recordChunk waits until all rows of a chunk are inserted, and then
+// records the chunk in the _vt.split_clone_checkpoint table of all
+// destination masters. It does nothing if the copy was aborted.
+func (scw *SplitCloneWorker) recordChunk(ctx context.Context, chunk splitCloneChunk, done *sync.WaitGroup) error {
+	inserted := make(chan struct{})
+	go func() {
+		done.Wait()
+		close(inserted)
+	}()
+	select {
+	case <-inserted:
+	case <-ctx.Done():
+		return nil
+	}
+	// processData returns early without an error if aborted,
+	// so the chunk may be incomplete.
+	if err := checkDone(ctx); err != nil {
+		return nil
+	}
+
+	query := recordSplitCloneChunk(chunk.uid, chunk.tableName, chunk.chunkStart, chunk.chunkEnd, topoproto.TabletAliasString(scw.sourceAliases[chunk.uid]), myproto.EncodeReplicationPosition(scw.sourcePositions[chunk.uid]), time.Now().Unix())
+	for _, si := range scw.destinationShards {
+		if err := runSQLCommands(ctx, scw.wr, scw, si.ShardName(), []string{query}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // processData pumps the data out of the provided QueryResultReader.
 // It returns any error the source encounters.
-func (scw *SplitCloneWorker) processData(td *myproto.TableDefinition, tableIndex int, qrr *QueryResultReader, rowSplitter *RowSplitter, insertChannels []chan string, destinationPackCount int, abort <-chan struct{}) error {
+// If done is not nil, it tracks the inserts of the sent rows.
+func (scw *SplitCloneWorker) processData(td *myproto.TableDefinition, tableIndex int, qrr *QueryResultReader, rowSplitter *RowSplitter, insertChannels []chan insertCommand, destinationPackCount int, done *sync.WaitGroup, abort <-chan struct{}) error {
 	baseCmd := td.Name + "(" + strings.Join(td.Columns, ", ") + ") VALUES "
 	sr := rowSplitter.StartSplit()
 	packCount := 0
@@ -630,7 +868,7 @@ func (scw *SplitCloneWorker) processData(td *myproto.TableDefinition, tableIndex
 				// the return value, we don't care
 				// here if we're aborted)
 				if packCount > 0 {
-					rowSplitter.Send(qrr.Fields, sr, baseCmd, insertChannels, abort)
+					rowSplitter.Send(qrr.Fields, sr, baseCmd, insertChannels, done, abort)
 				}
 				return nil
 			}
@@ -641,6 +879,9 @@ func (scw *SplitCloneWorker) processData(td *myproto.TableDefinition, tableIndex
 				return fmt.Errorf("RowSplitter failed for table %v: %v", td.Name, err)
 			}
 			scw.tableStatus[tableIndex].addCopiedRows(len(r.Rows))
+			if !scw.throttler.wait(len(r.Rows), abort) {
+				return nil
+			}
 
 			// see if we reach the destination pack count
 			packCount++
@@ -649,7 +890,7 @@ func (scw *SplitCloneWorker) processData(td *myproto.TableDefinition, tableIndex
 			}
 
 			// send the rows to be inserted
-			if aborted := rowSplitter.Send(qrr.Fields, sr, baseCmd, insertChannels, abort); aborted {
+			if aborted := rowSplitter.Send(qrr.Fields, sr, baseCmd, insertChannels, done, abort); aborted {
 				return nil
 			}
 
@@ -0,0 +1,141 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/sqltypes"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/wrangler"
+)
+
+//
+// This file contains the functions to record and read the chunks
+// copied by a resumable SplitClone. The state is kept in the
+// _vt.split_clone_checkpoint table of each destination master:
+// - one row per source shard with empty table_name, chunk_start and
+//   chunk_end records the source tablet and its (stopped) replication
+//   position when the copy started.
+// - one row per copied chunk of a table.
+//
+
+// maxSplitCloneCheckpointRows is the maximum number of rows we read
+// from the _vt.split_clone_checkpoint table.
+const maxSplitCloneCheckpointRows = 1000000
+
+// createSplitCloneCheckpoint returns the statements required to create
+// the _vt.split_clone_checkpoint table.
+func createSplitCloneCheckpoint() []string {
+	return []string{
+		"CREATE DATABASE IF NOT EXISTS _vt",
+		`CREATE TABLE IF NOT EXISTS _vt.split_clone_checkpoint (
+  source_shard_uid INT(10) UNSIGNED NOT NULL,
+  table_name VARBINARY(128) NOT NULL,
+  chunk_start VARBINARY(128) NOT NULL,
+  chunk_end VARBINARY(128) NOT NULL,
+  source_tablet VARBINARY(64) NOT NULL,
+  pos VARCHAR(250) DEFAULT NULL,
+  time_updated BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY (source_shard_uid, table_name, chunk_start, chunk_end)) ENGINE=InnoDB`}
+}
+
+// recordSplitCloneSource returns a statement to record the source
+// tablet and position used to copy the data of a source shard.
+func recordSplitCloneSource(uid int, sourceTablet string, pos myproto.ReplicationPosition, timeUpdated int64) string {
+	return recordSplitCloneChunk(uid, "", "", "", sourceTablet, myproto.EncodeReplicationPosition(pos), timeUpdated)
+}
+
+// recordSplitCloneChunk returns a statement to record that a chunk of a
+// table was copied from a source shard.
+func recordSplitCloneChunk(uid int, tableName, chunkStart, chunkEnd, sourceTablet, pos string, timeUpdated int64) string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "REPLACE INTO _vt.split_clone_checkpoint "+
+		"(source_shard_uid, table_name, chunk_start, chunk_end, source_tablet, pos, time_updated) VALUES (%v", uid)
+	for _, s := range []string{tableName, chunkStart, chunkEnd, sourceTablet, pos} {
+		buf.WriteString(", ")
+		sqltypes.MakeString([]byte(s)).EncodeSQL(buf)
+	}
+	fmt.Fprintf(buf, ", %v)", timeUpdated)
+	return buf.String()
+}
+
+// splitCloneSource is what we recorded about a source shard.
+type splitCloneSource struct {
+	sourceTablet string
+	pos          string
+}
+
+// splitCloneChunk identifies a copied chunk.
+type splitCloneChunk struct {
+	uid        int
+	tableName  string
+	chunkStart string
+	chunkEnd   string
+}
+
+// splitCloneCheckpoint is the state of a previous SplitClone run.
+type splitCloneCheckpoint struct {
+	// sources maps the source shard index to the recorded source.
+	sources map[int]splitCloneSource
+	// done has the chunks which were copied to all destinations.
+	done map[splitCloneChunk]bool
+}
+
+// readSplitCloneCheckpoint reads the _vt.split_clone_checkpoint table of
+// all destination masters. A chunk is only considered copied if it was
+// recorded on all of them.
+func readSplitCloneCheckpoint(ctx context.Context, wr *wrangler.Wrangler, destinationMasters []*topo.TabletInfo) (*splitCloneCheckpoint, error) {
+	result := &splitCloneCheckpoint{
+		sources: make(map[int]splitCloneSource),
+		done:    make(map[splitCloneChunk]bool),
+	}
+	counts := make(map[splitCloneChunk]int)
+	for _, ti := range destinationMasters {
+		shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
+		qr, err := wr.TabletManagerClient().ExecuteFetchAsApp(shortCtx, ti, "SELECT source_shard_uid, table_name, chunk_start, chunk_end, source_tablet, pos FROM _vt.split_clone_checkpoint", maxSplitCloneCheckpointRows, false)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read _vt.split_clone_checkpoint on %v: %v", ti.AliasString(), err)
+		}
+		for _, row := range qr.Rows {
+			if len(row) != 6 {
+				return nil, fmt.Errorf("unexpected row in _vt.split_clone_checkpoint on %v: %v", ti.AliasString(), row)
+			}
+			uid, err := row[0].ParseInt64()
+			if err != nil {
+				return nil, fmt.Errorf("invalid source_shard_uid in _vt.split_clone_checkpoint on %v: %v", ti.AliasString(), err)
+			}
+			chunk := splitCloneChunk{
+				uid:        int(uid),
+				tableName:  row[1].String(),
+				chunkStart: row[2].String(),
+				chunkEnd:   row[3].String(),
+			}
+			if chunk.tableName != "" {
+				counts[chunk]++
+				continue
+			}
+			source := splitCloneSource{
+				sourceTablet: row[4].String(),
+				pos:          row[5].String(),
+			}
+			if previous, ok := result.sources[chunk.uid]; ok && previous != source {
+				return nil, fmt.Errorf("destinations disagree on the source of source shard %v: %v (%v) and %v (%v)", chunk.uid, previous.sourceTablet, previous.pos, source.sourceTablet, source.pos)
+			}
+			result.sources[chunk.uid] = source
+		}
+	}
+	for chunk, count := range counts {
+		if count == len(destinationMasters) {
+			result.done[chunk] = true
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	mproto "github.com/youtube/vitess/go/mysql/proto"
+	"github.com/youtube/vitess/go/sqltypes"
+	"github.com/youtube/vitess/go/vt/logutil"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/tabletmanager/faketmclient"
+	"github.com/youtube/vitess/go/vt/tabletmanager/tmclient"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/wrangler"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+func TestRecordSplitCloneChunk(t *testing.T) {
+	got := recordSplitCloneChunk(1, "table1", "100", "200", "cell1-0000000010", "MariaDB/0-1-123", 1234)
+	want := "REPLACE INTO _vt.split_clone_checkpoint (source_shard_uid, table_name, chunk_start, chunk_end, source_tablet, pos, time_updated) VALUES (1, 'table1', '100', '200', 'cell1-0000000010', 'MariaDB/0-1-123', 1234)"
+	if got != want {
+		t.Errorf("recordSplitCloneChunk() = %v, want %v", got, want)
+	}
+
+	// the source marker has an empty table name and chunk
+	got = recordSplitCloneChunk(0, "", "", "", "cell1-0000000010", "", 1234)
+	want = "REPLACE INTO _vt.split_clone_checkpoint (source_shard_uid, table_name, chunk_start, chunk_end, source_tablet, pos, time_updated) VALUES (0, '', '', '', 'cell1-0000000010', '', 1234)"
+	if got != want {
+		t.Errorf("recordSplitCloneChunk() = %v, want %v", got, want)
+	}
+}
+
+// checkpointTabletManagerClient returns canned
+// _vt.split_clone_checkpoint rows and replication positions.
+type checkpointTabletManagerClient struct {
+	tmclient.TabletManagerClient
+
+	// rows are the checkpoint rows of the destination masters,
+	// by tablet alias.
+	rows map[string][][]sqltypes.Value
+
+	// positions are the replication positions of the source
+	// tablets, by tablet alias.
+	positions map[string]myproto.ReplicationPosition
+}
+
+func (client *checkpointTabletManagerClient) ExecuteFetchAsApp(ctx context.Context, tablet *topo.TabletInfo, query string, maxRows int, wantFields bool) (*mproto.QueryResult, error) {
+	return &mproto.QueryResult{Rows: client.rows[tablet.AliasString()]}, nil
+}
+
+func (client *checkpointTabletManagerClient) SlaveStatus(ctx context.Context, tablet *topo.TabletInfo) (myproto.ReplicationStatus, error) {
+	return myproto.ReplicationStatus{Position: client.positions[tablet.AliasString()]}, nil
+}
+
+func checkpointRow(uid, tableName, chunkStart, chunkEnd, sourceTablet, pos string) []sqltypes.Value {
+	row := []sqltypes.Value{sqltypes.MakeNumeric([]byte(uid))}
+	for _, s := range []string{tableName, chunkStart, chunkEnd, sourceTablet, pos} {
+		row = append(row, sqltypes.MakeString([]byte(s)))
+	}
+	return row
+}
+
+func testTabletInfo(uid uint32) *topo.TabletInfo {
+	return topo.NewTabletInfo(&pb.Tablet{
+		Alias: &pb.TabletAlias{Cell: "cell1", Uid: uid},
+	}, 0)
+}
+
+func TestReadSplitCloneCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	client := &checkpointTabletManagerClient{
+		TabletManagerClient: faketmclient.NewFakeTabletManagerClient(),
+		rows: map[string][][]sqltypes.Value{
+			"cell1-0000000010": {
+				checkpointRow("0", "", "", "", "cell1-0000000001", "MariaDB/12-34-5678"),
+				checkpointRow("0", "table1", "", "110", "cell1-0000000001", "MariaDB/12-34-5678"),
+				checkpointRow("0", "table1", "110", "120", "cell1-0000000001", "MariaDB/12-34-5678"),
+			},
+			"cell1-0000000020": {
+				checkpointRow("0", "", "", "", "cell1-0000000001", "MariaDB/12-34-5678"),
+				checkpointRow("0", "table1", "", "110", "cell1-0000000001", "MariaDB/12-34-5678"),
+			},
+		},
+	}
+	wr := wrangler.New(logutil.NewConsoleLogger(), topo.Server{}, client)
+	destinationMasters := []*topo.TabletInfo{testTabletInfo(10), testTabletInfo(20)}
+
+	// only the chunk recorded on both destinations is done
+	checkpoint, err := readSplitCloneCheckpoint(ctx, wr, destinationMasters)
+	if err != nil {
+		t.Fatalf("readSplitCloneCheckpoint failed: %v", err)
+	}
+	wantSources := map[int]splitCloneSource{
+		0: {sourceTablet: "cell1-0000000001", pos: "MariaDB/12-34-5678"},
+	}
+	if !reflect.DeepEqual(checkpoint.sources, wantSources) {
+		t.Errorf("readSplitCloneCheckpoint returned sources %v, want %v", checkpoint.sources, wantSources)
+	}
+	wantDone := map[splitCloneChunk]bool{
+		{uid: 0, tableName: "table1", chunkStart: "", chunkEnd: "110"}: true,
+	}
+	if !reflect.DeepEqual(checkpoint.done, wantDone) {
+		t.Errorf("readSplitCloneCheckpoint returned done chunks %v, want %v", checkpoint.done, wantDone)
+	}
+
+	// the destinations have to agree on the sources
+	client.rows["cell1-0000000020"][0] = checkpointRow("0", "", "", "", "cell1-0000000002", "MariaDB/12-34-5678")
+	if _, err := readSplitCloneCheckpoint(ctx, wr, destinationMasters); err == nil || !strings.Contains(err.Error(), "destinations disagree") {
+		t.Errorf("readSplitCloneCheckpoint with different sources returned %v, want a disagreement error", err)
+	}
+}
+
+func TestCheckSourcePositions(t *testing.T) {
+	ctx := context.Background()
+	client := &checkpointTabletManagerClient{
+		TabletManagerClient: faketmclient.NewFakeTabletManagerClient(),
+		positions: map[string]myproto.ReplicationPosition{
+			"cell1-0000000001": {GTIDSet: myproto.MariadbGTID{Domain: 12, Server: 34, Sequence: 5678}},
+			"cell1-0000000002": {GTIDSet: myproto.MariadbGTID{Domain: 12, Server: 34, Sequence: 5678}},
+		},
+	}
+	scw := &SplitCloneWorker{
+		wr:            wrangler.New(logutil.NewConsoleLogger(), topo.Server{}, client),
+		sourceTablets: []*topo.TabletInfo{testTabletInfo(1), testTabletInfo(2)},
+		checkpoint: &splitCloneCheckpoint{
+			sources: map[int]splitCloneSource{
+				0: {sourceTablet: "cell1-0000000001", pos: "MariaDB/12-34-5678"},
+			},
+		},
+	}
+
+	// the first source is where the previous run left it, the
+	// second one was not recorded
+	if err := scw.checkSourcePositions(ctx); err != nil {
+		t.Fatalf("checkSourcePositions failed: %v", err)
+	}
+	for i, ti := range scw.sourceTablets {
+		if want := client.positions[ti.AliasString()]; !scw.sourcePositions[i].Equal(want) {
+			t.Errorf("checkSourcePositions saved position %v for %v, want %v", scw.sourcePositions[i], ti.AliasString(), want)
+		}
+	}
+
+	// the first source moved
+	client.positions["cell1-0000000001"] = myproto.ReplicationPosition{GTIDSet: myproto.MariadbGTID{Domain: 12, Server: 34, Sequence: 5679}}
+	if err := scw.checkSourcePositions(ctx); err == nil || !strings.Contains(err.Error(), "previous copy was made at position") {
+		t.Errorf("checkSourcePositions with a moved source returned %v, want a position error", err)
+	}
+}
+
+func TestKeepSourcesForResume(t *testing.T) {
+	source := testTabletInfo(1)
+	other := testTabletInfo(2)
+	cleaner := &wrangler.Cleaner{}
+	for _, ti := range []*topo.TabletInfo{source, other} {
+		wrangler.RecordChangeSlaveTypeAction(cleaner, ti.Alias, pb.TabletType_RDONLY)
+		wrangler.RecordTabletTagAction(cleaner, ti.Alias, "worker", "")
+		wrangler.RecordStartSlaveAction(cleaner, ti)
+	}
+	scw := &SplitCloneWorker{
+		wr:            wrangler.New(logutil.NewConsoleLogger(), topo.Server{}, faketmclient.NewFakeTabletManagerClient()),
+		cleaner:       cleaner,
+		sourceAliases: []*pb.TabletAlias{source.Alias},
+	}
+	scw.keepSourcesForResume()
+
+	// the source keeps its type and stopped replication, but
+	// still loses its worker tag
+	if _, err := cleaner.GetActionByName(wrangler.ChangeSlaveTypeActionName, source.AliasString()); err == nil {
+		t.Errorf("ChangeSlaveType action for %v was not removed", source.AliasString())
+	}
+	if _, err := cleaner.GetActionByName(wrangler.StartSlaveActionName, source.AliasString()); err == nil {
+		t.Errorf("StartSlave action for %v was not removed", source.AliasString())
+	}
+	if _, err := cleaner.GetActionByName(wrangler.TabletTagActionName, source.AliasString()); err != nil {
+		t.Errorf("TabletTag action for %v was removed: %v", source.AliasString(), err)
+	}
+
+	// the other tablet is left alone
+	for _, name := range []string{wrangler.ChangeSlaveTypeActionName, wrangler.StartSlaveActionName, wrangler.TabletTagActionName} {
+		if _, err := cleaner.GetActionByName(name, other.AliasString()); err != nil {
+			t.Errorf("%v action for %v was removed: %v", name, other.AliasString(), err)
+		}
+	}
+}
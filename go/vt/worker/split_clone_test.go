@@ -7,8 +7,11 @@ package worker
 import (
 	"flag"
 	"fmt"
+	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -358,3 +361,338 @@ func testSplitClone(t *testing.T, strategy string) {
 		t.Errorf("Wrong statsRetryCounters: wanted %v, got %v", "{\"ReadOnly\": 2}", statsRetryCounters.String())
 	}
 }
+
+// resumeTestQueryService records the queries streamed from a source tablet.
+type resumeTestQueryService struct {
+	testQueryService
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (sq *resumeTestQueryService) StreamExecute(ctx context.Context, target *pb.Target, query *proto.Query, sendReply func(reply *mproto.QueryResult) error) error {
+	sq.mu.Lock()
+	sq.queries = append(sq.queries, query.Sql)
+	sq.mu.Unlock()
+	return sq.testQueryService.StreamExecute(ctx, target, query, sendReply)
+}
+
+func (sq *resumeTestQueryService) reset() []string {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	result := sq.queries
+	sq.queries = nil
+	return result
+}
+
+var splitCloneCheckpointValues = regexp.MustCompile(`^REPLACE INTO _vt\.split_clone_checkpoint \(.*\) VALUES \((\d+), '([^']*)', '([^']*)', '([^']*)', '([^']*)', '([^']*)', \d+\)$`)
+
+// resumeTestDestinations fakes the destination masters of a resumable
+// SplitClone: it keeps their _vt.split_clone_checkpoint tables, and
+// can make the inserts of one chunk fail once all the other chunks
+// are recorded on all destinations.
+type resumeTestDestinations struct {
+	t *testing.T
+
+	// failChunk is a string in the rows of the chunk to fail, and
+	// failAfter the number of chunks to wait for.
+	failChunk string
+	failAfter int
+
+	mu sync.Mutex
+	// checkpoints has the checkpoint rows, by destination uid.
+	checkpoints map[uint32][][]sqltypes.Value
+	// queries has the other queries, by destination uid.
+	queries map[uint32][]string
+}
+
+func newResumeTestDestinations(t *testing.T) *resumeTestDestinations {
+	return &resumeTestDestinations{
+		t:           t,
+		checkpoints: make(map[uint32][][]sqltypes.Value),
+		queries:     make(map[uint32][]string),
+	}
+}
+
+// resumeTestConnection is a destination connection, which accepts any query.
+type resumeTestConnection struct {
+	FakePoolConnection
+	d   *resumeTestDestinations
+	uid uint32
+}
+
+func (c *resumeTestConnection) ExecuteFetch(query string, maxrows int, wantfields bool) (*mproto.QueryResult, error) {
+	return c.d.executeFetch(c.uid, query)
+}
+
+func (d *resumeTestDestinations) factory(uid uint32) func() (dbconnpool.PoolConnection, error) {
+	return func() (dbconnpool.PoolConnection, error) {
+		return &resumeTestConnection{
+			FakePoolConnection: FakePoolConnection{t: d.t},
+			d:                  d,
+			uid:                uid,
+		}, nil
+	}
+}
+
+func (d *resumeTestDestinations) executeFetch(uid uint32, query string) (*mproto.QueryResult, error) {
+	switch {
+	case strings.HasPrefix(query, "SELECT source_shard_uid, table_name, chunk_start, chunk_end, source_tablet, pos FROM _vt.split_clone_checkpoint"):
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return &mproto.QueryResult{Rows: d.checkpoints[uid]}, nil
+
+	case strings.HasPrefix(query, "REPLACE INTO _vt.split_clone_checkpoint"):
+		m := splitCloneCheckpointValues.FindStringSubmatch(query)
+		if m == nil {
+			d.t.Errorf("unexpected checkpoint query: %v", query)
+			return nil, fmt.Errorf("unexpected query")
+		}
+		row := []sqltypes.Value{sqltypes.MakeNumeric([]byte(m[1]))}
+		for _, s := range m[2:] {
+			row = append(row, sqltypes.MakeString([]byte(s)))
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		rows := d.checkpoints[uid][:0]
+		for _, r := range d.checkpoints[uid] {
+			if !reflect.DeepEqual(r[:4], row[:4]) {
+				rows = append(rows, r)
+			}
+		}
+		d.checkpoints[uid] = append(rows, row)
+		return &mproto.QueryResult{}, nil
+
+	case d.failChunk != "" && strings.Contains(query, d.failChunk):
+		for start := time.Now(); d.minChunkCount() < d.failAfter; time.Sleep(10 * time.Millisecond) {
+			if time.Since(start) > 30*time.Second {
+				d.t.Errorf("the other chunks were not recorded")
+				break
+			}
+		}
+		return nil, fmt.Errorf("Lock wait timeout exceeded; try restarting transaction (errno 1205) during query: %v", query)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries[uid] = append(d.queries[uid], query)
+	return &mproto.QueryResult{}, nil
+}
+
+// minChunkCount returns the smallest number of recorded chunks of all
+// the destinations.
+func (d *resumeTestDestinations) minChunkCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := -1
+	for _, rows := range d.checkpoints {
+		count := 0
+		for _, row := range rows {
+			if row[1].String() != "" {
+				count++
+			}
+		}
+		if result == -1 || count < result {
+			result = count
+		}
+	}
+	return result
+}
+
+// resetQueries returns and clears the other queries of a destination.
+func (d *resumeTestDestinations) resetQueries(uid uint32) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := d.queries[uid]
+	d.queries[uid] = nil
+	return result
+}
+
+func TestSplitCloneResume(t *testing.T) {
+	db := fakesqldb.Register()
+	ts := zktopo.NewTestServer(t, []string{"cell1", "cell2"})
+	ctx := context.Background()
+	wi := NewInstance(ctx, ts, "cell1", time.Second)
+
+	if err := ts.CreateKeyspace(context.Background(), "ks", &pbt.Keyspace{
+		ShardingColumnName: "keyspace_id",
+		ShardingColumnType: pbt.KeyspaceIdType_UINT64,
+	}); err != nil {
+		t.Fatalf("CreateKeyspace failed: %v", err)
+	}
+
+	sourceMaster := testlib.NewFakeTablet(t, wi.wr, "cell1", 0,
+		pbt.TabletType_MASTER, db, testlib.TabletKeyspaceShard(t, "ks", "-80"))
+	sourceRdonly1 := testlib.NewFakeTablet(t, wi.wr, "cell1", 1,
+		pbt.TabletType_RDONLY, db, testlib.TabletKeyspaceShard(t, "ks", "-80"))
+	sourceRdonly2 := testlib.NewFakeTablet(t, wi.wr, "cell1", 2,
+		pbt.TabletType_RDONLY, db, testlib.TabletKeyspaceShard(t, "ks", "-80"))
+
+	leftMaster := testlib.NewFakeTablet(t, wi.wr, "cell1", 10,
+		pbt.TabletType_MASTER, db, testlib.TabletKeyspaceShard(t, "ks", "-40"))
+	leftRdonly := testlib.NewFakeTablet(t, wi.wr, "cell1", 11,
+		pbt.TabletType_RDONLY, db, testlib.TabletKeyspaceShard(t, "ks", "-40"))
+
+	rightMaster := testlib.NewFakeTablet(t, wi.wr, "cell1", 20,
+		pbt.TabletType_MASTER, db, testlib.TabletKeyspaceShard(t, "ks", "40-80"))
+	rightRdonly := testlib.NewFakeTablet(t, wi.wr, "cell1", 21,
+		pbt.TabletType_RDONLY, db, testlib.TabletKeyspaceShard(t, "ks", "40-80"))
+
+	for _, ft := range []*testlib.FakeTablet{sourceMaster, sourceRdonly1, sourceRdonly2, leftMaster, leftRdonly, rightMaster, rightRdonly} {
+		ft.StartActionLoop(t, wi.wr)
+		defer ft.StopActionLoop(t)
+	}
+
+	// add the topo and schema data we'll need
+	if err := ts.CreateShard(ctx, "ks", "80-"); err != nil {
+		t.Fatalf("CreateShard(\"-80\") failed: %v", err)
+	}
+	if err := wi.wr.SetKeyspaceShardingInfo(ctx, "ks", "keyspace_id", pbt.KeyspaceIdType_UINT64, 4, false); err != nil {
+		t.Fatalf("SetKeyspaceShardingInfo failed: %v", err)
+	}
+	if err := wi.wr.RebuildKeyspaceGraph(ctx, "ks", nil, true); err != nil {
+		t.Fatalf("RebuildKeyspaceGraph failed: %v", err)
+	}
+
+	queryServices := make(map[uint32]*resumeTestQueryService)
+	for _, sourceRdonly := range []*testlib.FakeTablet{sourceRdonly1, sourceRdonly2} {
+		sourceRdonly.FakeMysqlDaemon.Schema = &myproto.SchemaDefinition{
+			DatabaseSchema: "",
+			TableDefinitions: []*myproto.TableDefinition{
+				&myproto.TableDefinition{
+					Name:              "table1",
+					Columns:           []string{"id", "msg", "keyspace_id"},
+					PrimaryKeyColumns: []string{"id"},
+					Type:              myproto.TableBaseTable,
+					DataLength:        2048,
+				},
+			},
+		}
+		sourceRdonly.FakeMysqlDaemon.DbAppConnectionFactory = SourceRdonlyFactory(t)
+		sourceRdonly.FakeMysqlDaemon.CurrentMasterPosition = myproto.ReplicationPosition{
+			GTIDSet: myproto.MariadbGTID{Domain: 12, Server: 34, Sequence: 5678},
+		}
+		// Replication is stopped by both runs, but only restarted
+		// by the second one.
+		sourceRdonly.FakeMysqlDaemon.ExpectedExecuteSuperQueryList = []string{
+			"STOP SLAVE",
+			"STOP SLAVE",
+			"START SLAVE",
+		}
+		qs := &resumeTestQueryService{testQueryService: testQueryService{t: t}}
+		grpcqueryservice.RegisterForTest(sourceRdonly.RPCServer, qs)
+		queryServices[sourceRdonly.Tablet.Alias.Uid] = qs
+	}
+
+	destinations := newResumeTestDestinations(t)
+	leftMaster.FakeMysqlDaemon.DbAppConnectionFactory = destinations.factory(10)
+	rightMaster.FakeMysqlDaemon.DbAppConnectionFactory = destinations.factory(20)
+
+	// Only wait 1 ms between retries, so that the test passes faster
+	*executeFetchRetryTime = (1 * time.Millisecond)
+
+	runSplitClone := func() (*SplitCloneWorker, error) {
+		subFlags := flag.NewFlagSet("SplitClone", flag.ContinueOnError)
+		gwrk, err := commandSplitClone(wi, wi.wr, subFlags, []string{
+			"-strategy", "-populate_blp_checkpoint -resumable_copy",
+			"-source_reader_count", "10",
+			"-destination_pack_count", "4",
+			"-min_table_size_for_split", "1",
+			"-destination_writer_count", "10",
+			"ks/-80",
+		})
+		if err != nil {
+			t.Fatalf("Worker creation failed: %v", err)
+		}
+		wrk := gwrk.(*SplitCloneWorker)
+		err = wrk.Run(ctx)
+		t.Logf("Got status: %v", wrk.StatusAsText())
+		return wrk, err
+	}
+
+	// The first run copies the 10 chunks of table1 (see
+	// testSplitClone), but the last one fails once the 9 others
+	// are recorded on both destinations.
+	destinations.failChunk = "'Text for 19"
+	destinations.failAfter = 9
+	wrk, err := runSplitClone()
+	if err == nil {
+		t.Fatalf("first run succeeded, it should have failed")
+	}
+	source := wrk.sourceAliases[0]
+	sourceRdonly := sourceRdonly1
+	if source.Uid == sourceRdonly2.Tablet.Alias.Uid {
+		sourceRdonly = sourceRdonly2
+	}
+
+	// the source tablet is left as a worker, with replication stopped
+	ti, err := ts.GetTablet(ctx, source)
+	if err != nil {
+		t.Fatalf("GetTablet failed: %v", err)
+	}
+	if ti.Type != pbt.TabletType_WORKER {
+		t.Errorf("source tablet is a %v after the failed run, want %v", ti.Type, pbt.TabletType_WORKER)
+	}
+	if got := sourceRdonly.FakeMysqlDaemon.ExpectedExecuteSuperQueryCurrent; got != 1 {
+		t.Errorf("source tablet ran %v replication commands after the failed run, want 1", got)
+	}
+	for _, uid := range []uint32{10, 20} {
+		// 9 chunks and the source
+		if got := len(destinations.checkpoints[uid]); got != 10 {
+			t.Errorf("destination %v recorded %v rows, want 10: %v", uid, got, destinations.checkpoints[uid])
+		}
+		destinations.resetQueries(uid)
+	}
+	queryServices[source.Uid].reset()
+
+	// The second run only copies the last chunk, with INSERT IGNORE.
+	destinations.failChunk = ""
+	wrk, err = runSplitClone()
+	if err != nil || wrk.State != WorkerStateDone {
+		t.Fatalf("second run failed: %v", err)
+	}
+	if !reflect.DeepEqual(wrk.sourceAliases[0], source) {
+		t.Errorf("second run used source tablet %v, want %v", wrk.sourceAliases[0], source)
+	}
+	wantQueries := []string{"SELECT id, msg, keyspace_id FROM table1 WHERE id>=190 ORDER BY id"}
+	if got := queryServices[source.Uid].reset(); !reflect.DeepEqual(got, wantQueries) {
+		t.Errorf("second run streamed %v, want %v", got, wantQueries)
+	}
+	for _, uid := range []uint32{10, 20} {
+		queries := destinations.resetQueries(uid)
+		inserts := 0
+		blpCheckpoint := false
+		for _, query := range queries {
+			switch {
+			case strings.HasPrefix(query, "INSERT IGNORE INTO `vt_ks`.table1"):
+				inserts++
+			case strings.HasPrefix(query, "INSERT IGNORE INTO _vt.blp_checkpoint"):
+				blpCheckpoint = true
+			case strings.HasPrefix(query, "INSERT"):
+				t.Errorf("unexpected insert on destination %v: %v", uid, query)
+			}
+		}
+		// 10 rows, 5 per destination, in packs of 2 + 2 + 1
+		if inserts != 3 {
+			t.Errorf("destination %v got %v inserts, want 3", uid, inserts)
+		}
+		if !blpCheckpoint {
+			t.Errorf("destination %v didn't get the blp_checkpoint row", uid)
+		}
+		if len(queries) == 0 || queries[len(queries)-1] != "DROP TABLE IF EXISTS _vt.split_clone_checkpoint" {
+			t.Errorf("destination %v didn't drop the checkpoint table at the end: %v", uid, queries)
+		}
+	}
+
+	// replication is restarted on the source, and it is a spare
+	if got := sourceRdonly.FakeMysqlDaemon.ExpectedExecuteSuperQueryCurrent; got != 3 {
+		t.Errorf("source tablet ran %v replication commands, want 3", got)
+	}
+	ti, err = ts.GetTablet(ctx, source)
+	if err != nil {
+		t.Fatalf("GetTablet failed: %v", err)
+	}
+	if ti.Type != pbt.TabletType_SPARE {
+		t.Errorf("source tablet is a %v after the second run, want %v", ti.Type, pbt.TabletType_SPARE)
+	}
+}
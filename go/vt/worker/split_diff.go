@@ -11,10 +11,10 @@ import (
 
 	"golang.org/x/net/context"
 
-	"github.com/youtube/vitess/go/sync2"
 	blproto "github.com/youtube/vitess/go/vt/binlog/proto"
 	"github.com/youtube/vitess/go/vt/concurrency"
 	"github.com/youtube/vitess/go/vt/key"
+	"github.com/youtube/vitess/go/vt/keyresolver"
 	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
 	"github.com/youtube/vitess/go/vt/topo"
 	"github.com/youtube/vitess/go/vt/topo/topoproto"
@@ -35,6 +35,15 @@ type SplitDiffWorker struct {
 	excludeTables []string
 	cleaner       *wrangler.Cleaner
 
+	// diff parameters
+	sourceReaderCount    int
+	minTableSizeForSplit uint64
+	parallelDiffsCount   int
+	rdonlyCount          int
+	mismatchSampleSize   int
+	reportFile           string
+	repairFile           string
+
 	// all subsequent fields are protected by the mutex
 
 	// populated during WorkerStateInit, read-only after that
@@ -45,21 +54,39 @@ type SplitDiffWorker struct {
 	sourceAliases    []*pb.TabletAlias
 	destinationAlias *pb.TabletAlias
 
+	// populated during WorkerStateFindTargets, and reduced to the
+	// tablets stopped at the right position in WorkerStateSyncReplication
+	extraSourceAliases      [][]*pb.TabletAlias
+	extraDestinationAliases []*pb.TabletAlias
+
 	// populated during WorkerStateDiff
 	sourceSchemaDefinitions     []*myproto.SchemaDefinition
 	destinationSchemaDefinition *myproto.SchemaDefinition
 }
 
 // NewSplitDiffWorker returns a new SplitDiffWorker object.
-func NewSplitDiffWorker(wr *wrangler.Wrangler, cell, keyspace, shard string, excludeTables []string) Worker {
+// The diff reads from rdonlyCount tablets in each shard. Tables bigger
+// than minTableSizeForSplit are split into sourceReaderCount chunks,
+// and parallelDiffsCount chunks are diffed at a time.
+// If reportFile is set, up to mismatchSampleSize differences are
+// written to it. If repairFile is set, the statements to fix all the
+// differences on the destination are written to it.
+func NewSplitDiffWorker(wr *wrangler.Wrangler, cell, keyspace, shard string, excludeTables []string, sourceReaderCount int, minTableSizeForSplit uint64, parallelDiffsCount, rdonlyCount, mismatchSampleSize int, reportFile, repairFile string) Worker {
 	return &SplitDiffWorker{
-		StatusWorker:  NewStatusWorker(),
-		wr:            wr,
-		cell:          cell,
-		keyspace:      keyspace,
-		shard:         shard,
-		excludeTables: excludeTables,
-		cleaner:       &wrangler.Cleaner{},
+		StatusWorker:         NewStatusWorker(),
+		wr:                   wr,
+		cell:                 cell,
+		keyspace:             keyspace,
+		shard:                shard,
+		excludeTables:        excludeTables,
+		cleaner:              &wrangler.Cleaner{},
+		sourceReaderCount:    sourceReaderCount,
+		minTableSizeForSplit: minTableSizeForSplit,
+		parallelDiffsCount:   parallelDiffsCount,
+		rdonlyCount:          rdonlyCount,
+		mismatchSampleSize:   mismatchSampleSize,
+		reportFile:           reportFile,
+		repairFile:           repairFile,
 	}
 }
 
@@ -75,6 +102,12 @@ func (sdw *SplitDiffWorker) StatusAsHTML() template.HTML {
 	case WorkerStateDone:
 		result += "<b>Success.</b></br>\n"
 	}
+	if sdw.reportFile != "" {
+		result += "<b>Mismatch report:</b> " + template.HTMLEscapeString(sdw.reportFile) + "</br>\n"
+	}
+	if sdw.repairFile != "" {
+		result += "<b>Repair statements:</b> " + template.HTMLEscapeString(sdw.repairFile) + "</br>\n"
+	}
 
 	return template.HTML(result)
 }
@@ -91,6 +124,12 @@ func (sdw *SplitDiffWorker) StatusAsText() string {
 	case WorkerStateDone:
 		result += "Success.\n"
 	}
+	if sdw.reportFile != "" {
+		result += "Mismatch report: " + sdw.reportFile + "\n"
+	}
+	if sdw.repairFile != "" {
+		result += "Repair statements: " + sdw.repairFile + "\n"
+	}
 	return result
 }
 
@@ -179,8 +218,8 @@ func (sdw *SplitDiffWorker) init(ctx context.Context) error {
 }
 
 // findTargets phase:
-// - find one rdonly per source shard
-// - find one rdonly in destination shard
+// - find rdonlyCount rdonly per source shard
+// - find rdonlyCount rdonly in destination shard
 // - mark them all as 'worker' pointing back to us
 func (sdw *SplitDiffWorker) findTargets(ctx context.Context) error {
 	sdw.SetState(WorkerStateFindTargets)
@@ -201,6 +240,13 @@ func (sdw *SplitDiffWorker) findTargets(ctx context.Context) error {
 		}
 	}
 
+	// find the extra endpoints to spread the diff on
+	sdw.extraDestinationAliases = findExtraWorkerTablets(ctx, sdw.wr, sdw.cleaner, sdw.cell, sdw.keyspace, sdw.shard, []*pb.TabletAlias{sdw.destinationAlias}, sdw.rdonlyCount-1)
+	sdw.extraSourceAliases = make([][]*pb.TabletAlias, len(sdw.shardInfo.SourceShards))
+	for i, ss := range sdw.shardInfo.SourceShards {
+		sdw.extraSourceAliases[i] = findExtraWorkerTablets(ctx, sdw.wr, sdw.cleaner, sdw.cell, sdw.keyspace, ss.Shard, []*pb.TabletAlias{sdw.sourceAliases[i]}, sdw.rdonlyCount-1)
+	}
+
 	return nil
 }
 
@@ -212,12 +258,16 @@ func (sdw *SplitDiffWorker) findTargets(ctx context.Context) error {
 //   destination master. Get that new list of positions.
 //   (add a cleanup task to restart binlog replication on them, and change
 //    the existing ChangeSlaveType cleanup action to 'spare' type)
+//   The extra source tablets are stopped before, and replicate until
+//   exactly the same position. They are only used if they stopped there.
 // 3 - ask the master of the destination shard to resume filtered replication
 //   up to the new list of positions, and return its binlog position.
 // 4 - wait until the destination tablet is equal or passed that master
 //   binlog position, and stop its replication.
 //   (add a cleanup task to restart binlog replication on it, and change
 //    the existing ChangeSlaveType cleanup action to 'spare' type)
+//   The extra destination tablets are stopped at step 1, and replicate
+//   until exactly that master binlog position.
 // 5 - restart filtered replication on destination master.
 //   (remove the cleanup task that does the same)
 // At this point, all source and destination tablets are stopped at the same point.
@@ -241,6 +291,9 @@ func (sdw *SplitDiffWorker) synchronizeReplication(ctx context.Context) error {
 		return fmt.Errorf("StopBlp for %v failed: %v", sdw.shardInfo.MasterAlias, err)
 	}
 	wrangler.RecordStartBlpAction(sdw.cleaner, masterInfo)
+	if err := stopExtraTablets(ctx, sdw.wr, sdw.cleaner, sdw.extraDestinationAliases); err != nil {
+		return err
+	}
 
 	// 2 - stop all the source tablets at a binlog position
 	//     higher than the destination master
@@ -262,7 +315,10 @@ func (sdw *SplitDiffWorker) synchronizeReplication(ctx context.Context) error {
 			return err
 		}
 
-		// stop replication
+		// stop replication, the extra tablets first
+		if err := stopExtraTablets(ctx, sdw.wr, sdw.cleaner, sdw.extraSourceAliases[i]); err != nil {
+			return err
+		}
 		sdw.wr.Logger().Infof("Stopping slave[%v] %v at a minimum of %v", i, sdw.sourceAliases[i], blpPos.Position)
 		shortCtx, cancel = context.WithTimeout(ctx, *remoteActionsTimeout)
 		stoppedAt, err := sdw.wr.TabletManagerClient().StopSlaveMinimum(shortCtx, sourceTablet, blpPos.Position, *remoteActionsTimeout)
@@ -281,6 +337,11 @@ func (sdw *SplitDiffWorker) synchronizeReplication(ctx context.Context) error {
 			return fmt.Errorf("cannot find ChangeSlaveType action for %v: %v", sdw.sourceAliases[i], err)
 		}
 		action.TabletType = pb.TabletType_SPARE
+
+		sdw.extraSourceAliases[i], err = syncExtraTablets(ctx, sdw.wr, sdw.extraSourceAliases[i], stoppedAt)
+		if err != nil {
+			return err
+		}
 	}
 
 	// 3 - ask the master of the destination shard to resume filtered
@@ -314,6 +375,10 @@ func (sdw *SplitDiffWorker) synchronizeReplication(ctx context.Context) error {
 		return fmt.Errorf("cannot find ChangeSlaveType action for %v: %v", sdw.destinationAlias, err)
 	}
 	action.TabletType = pb.TabletType_SPARE
+	sdw.extraDestinationAliases, err = syncExtraTablets(ctx, sdw.wr, sdw.extraDestinationAliases, masterPos)
+	if err != nil {
+		return err
+	}
 
 	// 5 - restart filtered replication on destination master
 	sdw.wr.Logger().Infof("Restarting filtered replication on master %v", sdw.shardInfo.MasterAlias)
@@ -385,74 +450,51 @@ func (sdw *SplitDiffWorker) diff(ctx context.Context) error {
 		sdw.wr.Logger().Infof("Schema match, good.")
 	}
 
-	// run the diffs, 8 at a time
-	sdw.wr.Logger().Infof("Running the diffs...")
-	sem := sync2.NewSemaphore(8, 0)
-	for _, tableDefinition := range sdw.destinationSchemaDefinition.TableDefinitions {
-		wg.Add(1)
-		go func(tableDefinition *myproto.TableDefinition) {
-			defer wg.Done()
-			sem.Acquire()
-			defer sem.Release()
-
-			sdw.wr.Logger().Infof("Starting the diff on table %v", tableDefinition.Name)
-			if len(sdw.sourceAliases) != 1 {
-				err := fmt.Errorf("Don't support more than one source for table yet: %v", tableDefinition.Name)
-				rec.RecordError(err)
-				sdw.wr.Logger().Errorf(err.Error())
-				return
-			}
-
-			overlap, err := key.KeyRangesOverlap(sdw.shardInfo.KeyRange, sdw.shardInfo.SourceShards[0].KeyRange)
-			if err != nil {
-				newErr := fmt.Errorf("Source shard doesn't overlap with destination????: %v", err)
-				rec.RecordError(newErr)
-				sdw.wr.Logger().Errorf(newErr.Error())
-				return
-			}
-			sourceQueryResultReader, err := TableScanByKeyRange(ctx, sdw.wr.Logger(), sdw.wr.TopoServer(), sdw.sourceAliases[0], tableDefinition, overlap, sdw.keyspaceInfo.ShardingColumnType)
-			if err != nil {
-				newErr := fmt.Errorf("TableScanByKeyRange(source) failed: %v", err)
-				rec.RecordError(newErr)
-				sdw.wr.Logger().Errorf(newErr.Error())
-				return
-			}
-			defer sourceQueryResultReader.Close()
-
-			destinationQueryResultReader, err := TableScanByKeyRange(ctx, sdw.wr.Logger(), sdw.wr.TopoServer(), sdw.destinationAlias, tableDefinition, nil, sdw.keyspaceInfo.ShardingColumnType)
-			if err != nil {
-				newErr := fmt.Errorf("TableScanByKeyRange(destination) failed: %v", err)
-				rec.RecordError(newErr)
-				sdw.wr.Logger().Errorf(newErr.Error())
-				return
-			}
-			defer destinationQueryResultReader.Close()
-
-			differ, err := NewRowDiffer(sourceQueryResultReader, destinationQueryResultReader, tableDefinition)
-			if err != nil {
-				newErr := fmt.Errorf("NewRowDiffer() failed: %v", err)
-				rec.RecordError(newErr)
-				sdw.wr.Logger().Errorf(newErr.Error())
-				return
-			}
-
-			report, err := differ.Go(sdw.wr.Logger())
-			if err != nil {
-				newErr := fmt.Errorf("Differ.Go failed: %v", err.Error())
-				rec.RecordError(newErr)
-				sdw.wr.Logger().Errorf(newErr.Error())
-			} else {
-				if report.HasDifferences() {
-					err := fmt.Errorf("Table %v has differences: %v", tableDefinition.Name, report.String())
-					rec.RecordError(err)
-					sdw.wr.Logger().Warningf(err.Error())
-				} else {
-					sdw.wr.Logger().Infof("Table %v checks out (%v rows processed, %v qps)", tableDefinition.Name, report.processedRows, report.processingQPS)
-				}
-			}
-		}(tableDefinition)
+	if len(sdw.sourceAliases) != 1 {
+		return fmt.Errorf("Don't support more than one source shard yet")
+	}
+	overlap, err := key.KeyRangesOverlap(sdw.shardInfo.KeyRange, sdw.shardInfo.SourceShards[0].KeyRange)
+	if err != nil {
+		return fmt.Errorf("Source shard doesn't overlap with destination????: %v", err)
 	}
-	wg.Wait()
 
-	return rec.Error()
+	// keyspaces without a sharding column compute their keyspace
+	// ids with vindexes, the source rows are filtered by the differ
+	var keyResolverFactory keyresolver.Factory
+	if sdw.keyspaceInfo.ShardingColumnName == "" {
+		shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
+		keyResolverFactory, err = keyresolver.NewFactory(shortCtx, sdw.wr.TopoServer(), sdw.keyspaceInfo, sdw.keyspaceInfo.ShardingColumnType)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+
+	mismatches, err := newMismatchReport(sdw.keyspace, sdw.shard, sdw.mismatchSampleSize, sdw.reportFile, sdw.repairFile)
+	if err != nil {
+		return err
+	}
+	cd := &chunkedDiff{
+		wr:                   sdw.wr,
+		sourceAliases:        append([]*pb.TabletAlias{sdw.sourceAliases[0]}, sdw.extraSourceAliases[0]...),
+		destinationAliases:   append([]*pb.TabletAlias{sdw.destinationAlias}, sdw.extraDestinationAliases...),
+		sourceKeyRange:       overlap,
+		keyspaceIDType:       sdw.keyspaceInfo.ShardingColumnType,
+		keyResolverFactory:   keyResolverFactory,
+		minTableSizeForSplit: sdw.minTableSizeForSplit,
+		sourceReaderCount:    sdw.sourceReaderCount,
+		parallelDiffsCount:   sdw.parallelDiffsCount,
+		mismatches:           mismatches,
+	}
+
+	sdw.wr.Logger().Infof("Running the diffs on %v source and %v destination tablets...", len(cd.sourceAliases), len(cd.destinationAliases))
+	err = cd.diffTables(ctx, sdw.destinationSchemaDefinition.TableDefinitions)
+	if cerr := mismatches.close(); cerr != nil {
+		if err != nil {
+			sdw.wr.Logger().Errorf("Cannot write the mismatch report in addition to diff error: %v", cerr)
+		} else {
+			err = cerr
+		}
+	}
+	return err
 }
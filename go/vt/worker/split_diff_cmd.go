@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -47,10 +48,28 @@ const splitDiffHTML2 = `
     <form action="/Diffs/SplitDiff" method="post">
       <LABEL for="excludeTables">Exclude Tables: </LABEL>
         <INPUT type="text" id="excludeTables" name="excludeTables" value=""></BR>
+      <LABEL for="sourceReaderCount">Chunks Per Table: </LABEL>
+        <INPUT type="text" id="sourceReaderCount" name="sourceReaderCount" value="{{.DefaultSourceReaderCount}}"></BR>
+      <LABEL for="minTableSizeForSplit">Minimun Table Size For Split: </LABEL>
+        <INPUT type="text" id="minTableSizeForSplit" name="minTableSizeForSplit" value="{{.DefaultMinTableSizeForSplit}}"></BR>
+      <LABEL for="parallelDiffsCount">Parallel Diffs Count: </LABEL>
+        <INPUT type="text" id="parallelDiffsCount" name="parallelDiffsCount" value="{{.DefaultParallelDiffsCount}}"></BR>
+      <LABEL for="rdonlyCount">Rdonly Tablets Per Shard: </LABEL>
+        <INPUT type="text" id="rdonlyCount" name="rdonlyCount" value="{{.DefaultRdonlyCount}}"></BR>
+      <LABEL for="reportFile">Mismatch Report File: </LABEL>
+        <INPUT type="text" id="reportFile" name="reportFile" value=""></BR>
+      <LABEL for="mismatchSampleSize">Mismatch Sample Size: </LABEL>
+        <INPUT type="text" id="mismatchSampleSize" name="mismatchSampleSize" value="{{.DefaultMismatchSampleSize}}"></BR>
+      <LABEL for="repairFile">Repair Statements File: </LABEL>
+        <INPUT type="text" id="repairFile" name="repairFile" value=""></BR>
       <INPUT type="hidden" name="keyspace" value="{{.Keyspace}}"/>
       <INPUT type="hidden" name="shard" value="{{.Shard}}"/>
       <INPUT type="submit" name="submit" value="Split Diff"/>
     </form>
+
+  <h1>Help</h1>
+    <p>The mismatch report file is a JSON file with the number of differences per table, and the primary key and column values of the first differences.</p>
+    <p>The repair statements file gets the statements which would make the destination match the source. They are not applied.</p>
   </body>
 `
 
@@ -59,6 +78,13 @@ var splitDiffTemplate2 = mustParseTemplate("splitDiff2", splitDiffHTML2)
 
 func commandSplitDiff(wi *Instance, wr *wrangler.Wrangler, subFlags *flag.FlagSet, args []string) (Worker, error) {
 	excludeTables := subFlags.String("exclude_tables", "", "comma separated list of tables to exclude")
+	sourceReaderCount := subFlags.Int("source_reader_count", defaultSourceReaderCount, "number of chunks to split each table into")
+	minTableSizeForSplit := subFlags.Int("min_table_size_for_split", defaultMinTableSizeForSplit, "tables bigger than this size on disk in bytes will be split into source_reader_count chunks if possible")
+	parallelDiffsCount := subFlags.Int("parallel_diffs_count", defaultParallelDiffsCount, "number of chunks to diff at the same time")
+	rdonlyCount := subFlags.Int("rdonly_count", defaultRdonlyCount, "number of rdonly tablets to read from in each shard")
+	reportFile := subFlags.String("report_file", "", "if set, a JSON report of the differences is written to this file")
+	mismatchSampleSize := subFlags.Int("mismatch_sample_size", defaultMismatchSampleSize, "maximum number of differences to detail in the report file")
+	repairFile := subFlags.String("repair_file", "", "if set, the statements to make the destination match the source are written to this file. They are not applied")
 	if err := subFlags.Parse(args); err != nil {
 		return nil, err
 	}
@@ -74,7 +100,7 @@ func commandSplitDiff(wi *Instance, wr *wrangler.Wrangler, subFlags *flag.FlagSe
 	if *excludeTables != "" {
 		excludeTableArray = strings.Split(*excludeTables, ",")
 	}
-	return NewSplitDiffWorker(wr, wi.cell, keyspace, shard, excludeTableArray), nil
+	return NewSplitDiffWorker(wr, wi.cell, keyspace, shard, excludeTableArray, *sourceReaderCount, uint64(*minTableSizeForSplit), *parallelDiffsCount, *rdonlyCount, *mismatchSampleSize, *reportFile, *repairFile), nil
 }
 
 // shardsWithSources returns all the shards that have SourceShards set
@@ -162,6 +188,11 @@ func interactiveSplitDiff(ctx context.Context, wi *Instance, wr *wrangler.Wrangl
 		result := make(map[string]interface{})
 		result["Keyspace"] = keyspace
 		result["Shard"] = shard
+		result["DefaultSourceReaderCount"] = fmt.Sprintf("%v", defaultSourceReaderCount)
+		result["DefaultMinTableSizeForSplit"] = fmt.Sprintf("%v", defaultMinTableSizeForSplit)
+		result["DefaultParallelDiffsCount"] = fmt.Sprintf("%v", defaultParallelDiffsCount)
+		result["DefaultRdonlyCount"] = fmt.Sprintf("%v", defaultRdonlyCount)
+		result["DefaultMismatchSampleSize"] = fmt.Sprintf("%v", defaultMismatchSampleSize)
 		return nil, splitDiffTemplate2, result, nil
 	}
 
@@ -171,15 +202,32 @@ func interactiveSplitDiff(ctx context.Context, wi *Instance, wr *wrangler.Wrangl
 	if excludeTables != "" {
 		excludeTableArray = strings.Split(excludeTables, ",")
 	}
+	sourceReaderCount, minTableSizeForSplit, parallelDiffsCount, rdonlyCount, mismatchSampleSize, err := parseDiffForm(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	// start the diff job
-	wrk := NewSplitDiffWorker(wr, wi.cell, keyspace, shard, excludeTableArray)
+	wrk := NewSplitDiffWorker(wr, wi.cell, keyspace, shard, excludeTableArray, sourceReaderCount, minTableSizeForSplit, parallelDiffsCount, rdonlyCount, mismatchSampleSize, r.FormValue("reportFile"), r.FormValue("repairFile"))
 	return wrk, nil, nil, nil
 }
 
+// parseDiffForm parses the numeric diff parameters of the
+// SplitDiff and VerticalSplitDiff forms.
+func parseDiffForm(r *http.Request) (sourceReaderCount int, minTableSizeForSplit uint64, parallelDiffsCount, rdonlyCount, mismatchSampleSize int, err error) {
+	values := make(map[string]int64)
+	for _, name := range []string{"sourceReaderCount", "minTableSizeForSplit", "parallelDiffsCount", "rdonlyCount", "mismatchSampleSize"} {
+		values[name], err = strconv.ParseInt(r.FormValue(name), 0, 64)
+		if err != nil {
+			return 0, 0, 0, 0, 0, fmt.Errorf("cannot parse %v: %s", name, err)
+		}
+	}
+	return int(values["sourceReaderCount"]), uint64(values["minTableSizeForSplit"]), int(values["parallelDiffsCount"]), int(values["rdonlyCount"]), int(values["mismatchSampleSize"]), nil
+}
+
 func init() {
 	AddCommand("Diffs", Command{"SplitDiff",
 		commandSplitDiff, interactiveSplitDiff,
-		"[--exclude_tables=''] <keyspace/shard>",
+		"[--exclude_tables=''] [--source_reader_count=10] [--parallel_diffs_count=8] [--rdonly_count=1] [--report_file=''] [--mismatch_sample_size=100] [--repair_file=''] <keyspace/shard>",
 		"Diffs a rdonly destination shard against its SourceShards"})
 }
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/youtube/vitess/go/acl"
@@ -41,6 +42,14 @@ const workerStatusHTML = `
   <blockquote>
     {{.Status}}
   </blockquote>
+  {{if .Throttler}}
+  <h2>Throttling:</h2>
+  <form action="/throttle" method="post">
+    <LABEL for="maxRowsPerSecond">Max Rows Per Second (0 means unlimited): </LABEL>
+      <INPUT type="text" id="maxRowsPerSecond" name="maxRowsPerSecond" value="{{.MaxRowsPerSecond}}">
+    <INPUT type="submit" value="Set"/>
+  </form>
+  {{end}}
   <h2>Worker logs:</h2>
   <blockquote>
     {{.Logs}}
@@ -85,6 +94,10 @@ func (wi *Instance) InitStatusHandling() {
 				}
 			}
 			data["Status"] = status
+			if t, ok := wrk.(Throttler); ok && ctx != nil {
+				data["Throttler"] = true
+				data["MaxRowsPerSecond"] = t.MaxRowsPerSecond()
+			}
 			if logger != nil {
 				data["Logs"] = template.HTML(strings.Replace(logger.String(), "\n", "</br>\n", -1))
 			} else {
@@ -137,4 +150,33 @@ func (wi *Instance) InitStatusHandling() {
 		http.Redirect(w, r, servenv.StatusURLPath(), http.StatusTemporaryRedirect)
 
 	})
+
+	// throttle handler
+	http.HandleFunc("/throttle", func(w http.ResponseWriter, r *http.Request) {
+		if err := acl.CheckAccessHTTP(r, acl.ADMIN); err != nil {
+			acl.SendError(w, err)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			httpError(w, "cannot parse form: %s", err)
+			return
+		}
+		maxRowsPerSecond, err := strconv.ParseInt(r.FormValue("maxRowsPerSecond"), 0, 64)
+		if err != nil {
+			httpError(w, "cannot parse maxRowsPerSecond: %s", err)
+			return
+		}
+
+		wi.currentWorkerMutex.Lock()
+		wrk := wi.currentWorker
+		wi.currentWorkerMutex.Unlock()
+
+		t, ok := wrk.(Throttler)
+		if !ok {
+			httpError(w, "the current worker cannot be throttled", nil)
+			return
+		}
+		t.SetMaxRowsPerSecond(maxRowsPerSecond)
+		http.Redirect(w, r, servenv.StatusURLPath(), http.StatusSeeOther)
+	})
 }
@@ -0,0 +1,66 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/vt/zktopo"
+)
+
+func TestThrottleHandler(t *testing.T) {
+	ts := zktopo.NewTestServer(t, []string{"cell1"})
+	wi := NewInstance(context.Background(), ts, "cell1", time.Second)
+	wi.InitStatusHandling()
+
+	throttle := func(value string) *httptest.ResponseRecorder {
+		form := url.Values{"maxRowsPerSecond": {value}}
+		req, err := http.NewRequest("POST", "/throttle", strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatalf("NewRequest failed: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		http.DefaultServeMux.ServeHTTP(w, req)
+		return w
+	}
+
+	// the current worker can be throttled
+	wrk, err := NewSplitCloneWorker(wi.wr, "cell1", "ks", "-80", nil, "", 1, 1, 1, 1, 0)
+	if err != nil {
+		t.Fatalf("NewSplitCloneWorker failed: %v", err)
+	}
+	wi.currentWorker = wrk
+	if w := throttle("500"); w.Code != http.StatusSeeOther {
+		t.Errorf("/throttle returned %v: %v", w.Code, w.Body.String())
+	}
+	if got := wrk.(Throttler).MaxRowsPerSecond(); got != 500 {
+		t.Errorf("MaxRowsPerSecond() = %v after /throttle, want 500", got)
+	}
+	if w := throttle("0"); w.Code != http.StatusSeeOther {
+		t.Errorf("/throttle returned %v: %v", w.Code, w.Body.String())
+	}
+	if got := wrk.(Throttler).MaxRowsPerSecond(); got != 0 {
+		t.Errorf("MaxRowsPerSecond() = %v after /throttle, want 0", got)
+	}
+
+	// a bad value is rejected
+	if w := throttle("fast"); w.Code != http.StatusInternalServerError {
+		t.Errorf("/throttle with a bad value returned %v, want %v", w.Code, http.StatusInternalServerError)
+	}
+
+	// a worker which cannot be throttled
+	wi.currentWorker = &PingWorker{}
+	if w := throttle("500"); w.Code != http.StatusInternalServerError {
+		t.Errorf("/throttle for a PingWorker returned %v, want %v", w.Code, http.StatusInternalServerError)
+	}
+}
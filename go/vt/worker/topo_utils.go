@@ -47,22 +47,10 @@ func FindHealthyRdonlyEndPoint(ctx context.Context, wr *wrangler.Wrangler, cell,
 		default:
 		}
 
-		shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
-		endPoints, _, err := wr.TopoServer().GetEndPoints(shortCtx, cell, keyspace, shard, pb.TabletType_RDONLY)
-		cancel()
+		var err error
+		healthyEndpoints, err = healthyRdonlyEndPoints(ctx, wr, cell, keyspace, shard)
 		if err != nil {
-			if err == topo.ErrNoNode {
-				// If the node doesn't exist, count that as 0 available rdonly instances.
-				endPoints = &pb.EndPoints{}
-			} else {
-				return nil, fmt.Errorf("GetEndPoints(%v,%v,%v,rdonly) failed: %v", cell, keyspace, shard, err)
-			}
-		}
-		healthyEndpoints = make([]*pb.EndPoint, 0, len(endPoints.Entries))
-		for _, entry := range endPoints.Entries {
-			if len(entry.HealthMap) == 0 {
-				healthyEndpoints = append(healthyEndpoints, entry)
-			}
+			return nil, err
 		}
 		if len(healthyEndpoints) < *minHealthyEndPoints {
 			deadlineForLog, _ := busywaitCtx.Deadline()
@@ -87,6 +75,28 @@ func FindHealthyRdonlyEndPoint(ctx context.Context, wr *wrangler.Wrangler, cell,
 	}, nil
 }
 
+// healthyRdonlyEndPoints returns the healthy rdonly endpoints of a
+// shard in a cell, without waiting.
+func healthyRdonlyEndPoints(ctx context.Context, wr *wrangler.Wrangler, cell, keyspace, shard string) ([]*pb.EndPoint, error) {
+	shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
+	endPoints, _, err := wr.TopoServer().GetEndPoints(shortCtx, cell, keyspace, shard, pb.TabletType_RDONLY)
+	cancel()
+	if err != nil {
+		if err == topo.ErrNoNode {
+			// If the node doesn't exist, count that as 0 available rdonly instances.
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetEndPoints(%v,%v,%v,rdonly) failed: %v", cell, keyspace, shard, err)
+	}
+	healthyEndpoints := make([]*pb.EndPoint, 0, len(endPoints.Entries))
+	for _, entry := range endPoints.Entries {
+		if len(entry.HealthMap) == 0 {
+			healthyEndpoints = append(healthyEndpoints, entry)
+		}
+	}
+	return healthyEndpoints, nil
+}
+
 // FindWorkerTablet will:
 // - find a rdonly instance in the keyspace / shard
 // - mark it as worker
@@ -96,22 +106,19 @@ func FindWorkerTablet(ctx context.Context, wr *wrangler.Wrangler, cleaner *wrang
 	if err != nil {
 		return nil, err
 	}
+	if err := takeWorkerTablet(ctx, wr, cleaner, tabletAlias); err != nil {
+		return nil, err
+	}
+	return tabletAlias, nil
+}
 
+// takeWorkerTablet marks a rdonly tablet as worker, and tags it with
+// our worker process.
+func takeWorkerTablet(ctx context.Context, wr *wrangler.Wrangler, cleaner *wrangler.Cleaner, tabletAlias *pb.TabletAlias) error {
 	// We add the tag before calling ChangeSlaveType, so the destination
 	// vttablet reloads the worker URL when it reloads the tablet.
-	ourURL := servenv.ListeningURL.String()
-	wr.Logger().Infof("Adding tag[worker]=%v to tablet %v", ourURL, topoproto.TabletAliasString(tabletAlias))
-	shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
-	err = wr.TopoServer().UpdateTabletFields(shortCtx, tabletAlias, func(tablet *pb.Tablet) error {
-		if tablet.Tags == nil {
-			tablet.Tags = make(map[string]string)
-		}
-		tablet.Tags["worker"] = ourURL
-		return nil
-	})
-	cancel()
-	if err != nil {
-		return nil, err
+	if err := addWorkerTag(ctx, wr, tabletAlias); err != nil {
+		return err
 	}
 	// Using "defer" here because we remove the tag *before* calling
 	// ChangeSlaveType back, so we need to record this tag change after the change
@@ -119,18 +126,57 @@ func FindWorkerTablet(ctx context.Context, wr *wrangler.Wrangler, cleaner *wrang
 	defer wrangler.RecordTabletTagAction(cleaner, tabletAlias, "worker", "")
 
 	wr.Logger().Infof("Changing tablet %v to '%v'", topoproto.TabletAliasString(tabletAlias), pb.TabletType_WORKER)
-	shortCtx, cancel = context.WithTimeout(ctx, *remoteActionsTimeout)
-	err = wr.ChangeSlaveType(shortCtx, tabletAlias, pb.TabletType_WORKER)
+	shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
+	err := wr.ChangeSlaveType(shortCtx, tabletAlias, pb.TabletType_WORKER)
 	cancel()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Record a clean-up action to take the tablet back to rdonly.
 	// We will alter this one later on and let the tablet go back to
 	// 'spare' if we have stopped replication for too long on it.
 	wrangler.RecordChangeSlaveTypeAction(cleaner, tabletAlias, pb.TabletType_RDONLY)
-	return tabletAlias, nil
+	return nil
+}
+
+// reuseWorkerTablet takes over a tablet which is still a worker,
+// e.g. because a previous run left it that way to be resumed:
+// - check it is a worker
+// - tag it with our worker process
+// It records the same clean-up actions as FindWorkerTablet.
+func reuseWorkerTablet(ctx context.Context, wr *wrangler.Wrangler, cleaner *wrangler.Cleaner, tabletAlias *pb.TabletAlias) error {
+	shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
+	ti, err := wr.TopoServer().GetTablet(shortCtx, tabletAlias)
+	cancel()
+	if err != nil {
+		return err
+	}
+	if ti.Type != pb.TabletType_WORKER {
+		return fmt.Errorf("tablet %v is a %v, not a %v", topoproto.TabletAliasString(tabletAlias), ti.Type, pb.TabletType_WORKER)
+	}
+
+	if err := addWorkerTag(ctx, wr, tabletAlias); err != nil {
+		return err
+	}
+	wrangler.RecordChangeSlaveTypeAction(cleaner, tabletAlias, pb.TabletType_RDONLY)
+	wrangler.RecordTabletTagAction(cleaner, tabletAlias, "worker", "")
+	return nil
+}
+
+// addWorkerTag sets tag[worker] to our URL on the tablet.
+func addWorkerTag(ctx context.Context, wr *wrangler.Wrangler, tabletAlias *pb.TabletAlias) error {
+	ourURL := servenv.ListeningURL.String()
+	wr.Logger().Infof("Adding tag[worker]=%v to tablet %v", ourURL, topoproto.TabletAliasString(tabletAlias))
+	shortCtx, cancel := context.WithTimeout(ctx, *remoteActionsTimeout)
+	defer cancel()
+	return wr.TopoServer().UpdateTabletFields(shortCtx, tabletAlias, func(tablet *pb.Tablet) error {
+		if tablet.Tags == nil {
+			tablet.Tags = make(map[string]string)
+		}
+		tablet.Tags["worker"] = ourURL
+		return nil
+	})
 }
 
 func init() {
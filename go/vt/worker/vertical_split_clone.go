@@ -394,15 +394,15 @@ func (vscw *VerticalSplitCloneWorker) copy(ctx context.Context) error {
 	// destinationWriterCount * 2 items, to hopefully
 	// always have data. We then have
 	// destinationWriterCount go routines reading from it.
-	insertChannel := make(chan string, vscw.destinationWriterCount*2)
+	insertChannel := make(chan insertCommand, vscw.destinationWriterCount*2)
 
-	go func(shardName string, insertChannel chan string) {
+	go func(shardName string, insertChannel chan insertCommand) {
 		for j := 0; j < vscw.destinationWriterCount; j++ {
 			destinationWaitGroup.Add(1)
 			go func() {
 				defer destinationWaitGroup.Done()
 
-				if err := executeFetchLoop(ctx, vscw.wr, vscw, shardName, insertChannel); err != nil {
+				if err := executeFetchLoop(ctx, vscw.wr, vscw, shardName, insertChannel, false); err != nil {
 					processError("executeFetchLoop failed: %v", err)
 				}
 			}()
@@ -474,7 +474,7 @@ func (vscw *VerticalSplitCloneWorker) copy(ctx context.Context) error {
 		if vscw.strategy.DontStartBinlogPlayer {
 			flags = binlogplayer.BlpFlagDontStart
 		}
-		queries = append(queries, binlogplayer.PopulateBlpCheckpoint(0, status.Position, time.Now().Unix(), flags))
+		queries = append(queries, binlogplayer.PopulateBlpCheckpoint(0, status.Position, time.Now().Unix(), flags, false))
 		destinationWaitGroup.Add(1)
 		go func(shardName string) {
 			defer destinationWaitGroup.Done()
@@ -528,7 +528,7 @@ func (vscw *VerticalSplitCloneWorker) copy(ctx context.Context) error {
 
 // processData pumps the data out of the provided QueryResultReader.
 // It returns any error the source encounters.
-func (vscw *VerticalSplitCloneWorker) processData(td *myproto.TableDefinition, tableIndex int, qrr *QueryResultReader, insertChannel chan string, destinationPackCount int, abort <-chan struct{}) error {
+func (vscw *VerticalSplitCloneWorker) processData(td *myproto.TableDefinition, tableIndex int, qrr *QueryResultReader, insertChannel chan insertCommand, destinationPackCount int, abort <-chan struct{}) error {
 	// process the data
 	baseCmd := td.Name + "(" + strings.Join(td.Columns, ", ") + ") VALUES "
 	var rows [][]sqltypes.Value
@@ -546,7 +546,7 @@ func (vscw *VerticalSplitCloneWorker) processData(td *myproto.TableDefinition, t
 
 				// send the remainder if any
 				if packCount > 0 {
-					cmd := baseCmd + makeValueString(qrr.Fields, rows)
+					cmd := insertCommand{sql: baseCmd + makeValueString(qrr.Fields, rows)}
 					select {
 					case insertChannel <- cmd:
 					case <-abort:
@@ -567,7 +567,7 @@ func (vscw *VerticalSplitCloneWorker) processData(td *myproto.TableDefinition, t
 			}
 
 			// send the rows to be inserted
-			cmd := baseCmd + makeValueString(qrr.Fields, rows)
+			cmd := insertCommand{sql: baseCmd + makeValueString(qrr.Fields, rows)}
 			select {
 			case insertChannel <- cmd:
 			case <-abort:
@@ -12,7 +12,6 @@ import (
 
 	"golang.org/x/net/context"
 
-	"github.com/youtube/vitess/go/sync2"
 	blproto "github.com/youtube/vitess/go/vt/binlog/proto"
 	"github.com/youtube/vitess/go/vt/concurrency"
 	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
@@ -35,6 +34,15 @@ type VerticalSplitDiffWorker struct {
 	excludeTables []string
 	cleaner       *wrangler.Cleaner
 
+	// diff parameters
+	sourceReaderCount    int
+	minTableSizeForSplit uint64
+	parallelDiffsCount   int
+	rdonlyCount          int
+	mismatchSampleSize   int
+	reportFile           string
+	repairFile           string
+
 	// all subsequent fields are protected by the mutex
 
 	// populated during WorkerStateInit, read-only after that
@@ -45,21 +53,34 @@ type VerticalSplitDiffWorker struct {
 	sourceAlias      *pb.TabletAlias
 	destinationAlias *pb.TabletAlias
 
+	// populated during WorkerStateFindTargets, and reduced to the
+	// tablets stopped at the right position in WorkerStateSyncReplication
+	extraSourceAliases      []*pb.TabletAlias
+	extraDestinationAliases []*pb.TabletAlias
+
 	// populated during WorkerStateDiff
 	sourceSchemaDefinition      *myproto.SchemaDefinition
 	destinationSchemaDefinition *myproto.SchemaDefinition
 }
 
 // NewVerticalSplitDiffWorker returns a new VerticalSplitDiffWorker object.
-func NewVerticalSplitDiffWorker(wr *wrangler.Wrangler, cell, keyspace, shard string, excludeTables []string) Worker {
+// The diff parameters are the same as NewSplitDiffWorker.
+func NewVerticalSplitDiffWorker(wr *wrangler.Wrangler, cell, keyspace, shard string, excludeTables []string, sourceReaderCount int, minTableSizeForSplit uint64, parallelDiffsCount, rdonlyCount, mismatchSampleSize int, reportFile, repairFile string) Worker {
 	return &VerticalSplitDiffWorker{
-		StatusWorker:  NewStatusWorker(),
-		wr:            wr,
-		cell:          cell,
-		keyspace:      keyspace,
-		shard:         shard,
-		excludeTables: excludeTables,
-		cleaner:       &wrangler.Cleaner{},
+		StatusWorker:         NewStatusWorker(),
+		wr:                   wr,
+		cell:                 cell,
+		keyspace:             keyspace,
+		shard:                shard,
+		excludeTables:        excludeTables,
+		cleaner:              &wrangler.Cleaner{},
+		sourceReaderCount:    sourceReaderCount,
+		minTableSizeForSplit: minTableSizeForSplit,
+		parallelDiffsCount:   parallelDiffsCount,
+		rdonlyCount:          rdonlyCount,
+		mismatchSampleSize:   mismatchSampleSize,
+		reportFile:           reportFile,
+		repairFile:           repairFile,
 	}
 }
 
@@ -75,6 +96,12 @@ func (vsdw *VerticalSplitDiffWorker) StatusAsHTML() template.HTML {
 	case WorkerStateDone:
 		result += "<b>Success</b>:</br>\n"
 	}
+	if vsdw.reportFile != "" {
+		result += "<b>Mismatch report:</b> " + template.HTMLEscapeString(vsdw.reportFile) + "</br>\n"
+	}
+	if vsdw.repairFile != "" {
+		result += "<b>Repair statements:</b> " + template.HTMLEscapeString(vsdw.repairFile) + "</br>\n"
+	}
 
 	return template.HTML(result)
 }
@@ -91,6 +118,12 @@ func (vsdw *VerticalSplitDiffWorker) StatusAsText() string {
 	case WorkerStateDone:
 		result += "Success.\n"
 	}
+	if vsdw.reportFile != "" {
+		result += "Mismatch report: " + vsdw.reportFile + "\n"
+	}
+	if vsdw.repairFile != "" {
+		result += "Repair statements: " + vsdw.repairFile + "\n"
+	}
 	return result
 }
 
@@ -184,8 +217,8 @@ func (vsdw *VerticalSplitDiffWorker) init(ctx context.Context) error {
 }
 
 // findTargets phase:
-// - find one rdonly per source shard
-// - find one rdonly in destination shard
+// - find rdonlyCount rdonly in the source shard
+// - find rdonlyCount rdonly in destination shard
 // - mark them all as 'worker' pointing back to us
 func (vsdw *VerticalSplitDiffWorker) findTargets(ctx context.Context) error {
 	vsdw.SetState(WorkerStateFindTargets)
@@ -203,6 +236,10 @@ func (vsdw *VerticalSplitDiffWorker) findTargets(ctx context.Context) error {
 		return fmt.Errorf("FindWorkerTablet() failed for %v/%v/%v: %v", vsdw.cell, vsdw.shardInfo.SourceShards[0].Keyspace, vsdw.shardInfo.SourceShards[0].Shard, err)
 	}
 
+	// find the extra endpoints to spread the diff on
+	vsdw.extraDestinationAliases = findExtraWorkerTablets(ctx, vsdw.wr, vsdw.cleaner, vsdw.cell, vsdw.keyspace, vsdw.shard, []*pb.TabletAlias{vsdw.destinationAlias}, vsdw.rdonlyCount-1)
+	vsdw.extraSourceAliases = findExtraWorkerTablets(ctx, vsdw.wr, vsdw.cleaner, vsdw.cell, vsdw.shardInfo.SourceShards[0].Keyspace, vsdw.shardInfo.SourceShards[0].Shard, []*pb.TabletAlias{vsdw.sourceAlias}, vsdw.rdonlyCount-1)
+
 	return nil
 }
 
@@ -214,12 +251,16 @@ func (vsdw *VerticalSplitDiffWorker) findTargets(ctx context.Context) error {
 //   destination master. Get that new position.
 //   (add a cleanup task to restart binlog replication on it, and change
 //    the existing ChangeSlaveType cleanup action to 'spare' type)
+//   The extra source tablets are stopped before, and replicate until
+//   exactly the same position. They are only used if they stopped there.
 // 3 - ask the master of the destination shard to resume filtered replication
 //   up to the new list of positions, and return its binlog position.
 // 4 - wait until the destination tablet is equal or passed that master
 //   binlog position, and stop its replication.
 //   (add a cleanup task to restart binlog replication on it, and change
 //    the existing ChangeSlaveType cleanup action to 'spare' type)
+//   The extra destination tablets are stopped at step 1, and replicate
+//   until exactly that master binlog position.
 // 5 - restart filtered replication on destination master.
 //   (remove the cleanup task that does the same)
 // At this point, all source and destination tablets are stopped at the same point.
@@ -243,6 +284,9 @@ func (vsdw *VerticalSplitDiffWorker) synchronizeReplication(ctx context.Context)
 		return fmt.Errorf("StopBlp on master %v failed: %v", topoproto.TabletAliasString(vsdw.shardInfo.MasterAlias), err)
 	}
 	wrangler.RecordStartBlpAction(vsdw.cleaner, masterInfo)
+	if err := stopExtraTablets(ctx, vsdw.wr, vsdw.cleaner, vsdw.extraDestinationAliases); err != nil {
+		return err
+	}
 
 	// 2 - stop the source tablet at a binlog position
 	//     higher than the destination master
@@ -256,7 +300,10 @@ func (vsdw *VerticalSplitDiffWorker) synchronizeReplication(ctx context.Context)
 		return fmt.Errorf("no binlog position on the master for Uid %v", ss.Uid)
 	}
 
-	// stop replication
+	// stop replication, the extra tablets first
+	if err := stopExtraTablets(ctx, vsdw.wr, vsdw.cleaner, vsdw.extraSourceAliases); err != nil {
+		return err
+	}
 	vsdw.wr.Logger().Infof("Stopping slave %v at a minimum of %v", topoproto.TabletAliasString(vsdw.sourceAlias), pos.Position)
 	shortCtx, cancel = context.WithTimeout(ctx, *remoteActionsTimeout)
 	sourceTablet, err := vsdw.wr.TopoServer().GetTablet(shortCtx, vsdw.sourceAlias)
@@ -281,6 +328,10 @@ func (vsdw *VerticalSplitDiffWorker) synchronizeReplication(ctx context.Context)
 		return fmt.Errorf("cannot find ChangeSlaveType action for %v: %v", topoproto.TabletAliasString(vsdw.sourceAlias), err)
 	}
 	action.TabletType = pb.TabletType_SPARE
+	vsdw.extraSourceAliases, err = syncExtraTablets(ctx, vsdw.wr, vsdw.extraSourceAliases, stoppedAt)
+	if err != nil {
+		return err
+	}
 
 	// 3 - ask the master of the destination shard to resume filtered
 	//     replication up to the new list of positions
@@ -313,6 +364,10 @@ func (vsdw *VerticalSplitDiffWorker) synchronizeReplication(ctx context.Context)
 		return fmt.Errorf("cannot find ChangeSlaveType action for %v: %v", topoproto.TabletAliasString(vsdw.destinationAlias), err)
 	}
 	action.TabletType = pb.TabletType_SPARE
+	vsdw.extraDestinationAliases, err = syncExtraTablets(ctx, vsdw.wr, vsdw.extraDestinationAliases, masterPos)
+	if err != nil {
+		return err
+	}
 
 	// 5 - restart filtered replication on destination master
 	vsdw.wr.Logger().Infof("Restarting filtered replication on master %v", topoproto.TabletAliasString(vsdw.shardInfo.MasterAlias))
@@ -405,58 +460,28 @@ func (vsdw *VerticalSplitDiffWorker) diff(ctx context.Context) error {
 		vsdw.wr.Logger().Infof("Schema match, good.")
 	}
 
-	// run the diffs, 8 at a time
-	vsdw.wr.Logger().Infof("Running the diffs...")
-	sem := sync2.NewSemaphore(8, 0)
-	for _, tableDefinition := range vsdw.destinationSchemaDefinition.TableDefinitions {
-		wg.Add(1)
-		go func(tableDefinition *myproto.TableDefinition) {
-			defer wg.Done()
-			sem.Acquire()
-			defer sem.Release()
-
-			vsdw.wr.Logger().Infof("Starting the diff on table %v", tableDefinition.Name)
-			sourceQueryResultReader, err := TableScan(ctx, vsdw.wr.Logger(), vsdw.wr.TopoServer(), vsdw.sourceAlias, tableDefinition)
-			if err != nil {
-				newErr := fmt.Errorf("TableScan(source) failed: %v", err)
-				rec.RecordError(newErr)
-				vsdw.wr.Logger().Errorf(newErr.Error())
-				return
-			}
-			defer sourceQueryResultReader.Close()
-
-			destinationQueryResultReader, err := TableScan(ctx, vsdw.wr.Logger(), vsdw.wr.TopoServer(), vsdw.destinationAlias, tableDefinition)
-			if err != nil {
-				newErr := fmt.Errorf("TableScan(destination) failed: %v", err)
-				rec.RecordError(newErr)
-				vsdw.wr.Logger().Errorf(newErr.Error())
-				return
-			}
-			defer destinationQueryResultReader.Close()
-
-			differ, err := NewRowDiffer(sourceQueryResultReader, destinationQueryResultReader, tableDefinition)
-			if err != nil {
-				newErr := fmt.Errorf("NewRowDiffer() failed: %v", err)
-				rec.RecordError(newErr)
-				vsdw.wr.Logger().Errorf(newErr.Error())
-				return
-			}
-
-			report, err := differ.Go(vsdw.wr.Logger())
-			if err != nil {
-				vsdw.wr.Logger().Errorf("Differ.Go failed: %v", err)
-			} else {
-				if report.HasDifferences() {
-					err := fmt.Errorf("Table %v has differences: %v", tableDefinition.Name, report.String())
-					rec.RecordError(err)
-					vsdw.wr.Logger().Errorf(err.Error())
-				} else {
-					vsdw.wr.Logger().Infof("Table %v checks out (%v rows processed, %v qps)", tableDefinition.Name, report.processedRows, report.processingQPS)
-				}
-			}
-		}(tableDefinition)
+	mismatches, err := newMismatchReport(vsdw.keyspace, vsdw.shard, vsdw.mismatchSampleSize, vsdw.reportFile, vsdw.repairFile)
+	if err != nil {
+		return err
+	}
+	cd := &chunkedDiff{
+		wr:                   vsdw.wr,
+		sourceAliases:        append([]*pb.TabletAlias{vsdw.sourceAlias}, vsdw.extraSourceAliases...),
+		destinationAliases:   append([]*pb.TabletAlias{vsdw.destinationAlias}, vsdw.extraDestinationAliases...),
+		minTableSizeForSplit: vsdw.minTableSizeForSplit,
+		sourceReaderCount:    vsdw.sourceReaderCount,
+		parallelDiffsCount:   vsdw.parallelDiffsCount,
+		mismatches:           mismatches,
 	}
-	wg.Wait()
 
-	return rec.Error()
+	vsdw.wr.Logger().Infof("Running the diffs on %v source and %v destination tablets...", len(cd.sourceAliases), len(cd.destinationAliases))
+	err = cd.diffTables(ctx, vsdw.destinationSchemaDefinition.TableDefinitions)
+	if cerr := mismatches.close(); cerr != nil {
+		if err != nil {
+			vsdw.wr.Logger().Errorf("Cannot write the mismatch report in addition to diff error: %v", cerr)
+		} else {
+			err = cerr
+		}
+	}
+	return err
 }
@@ -44,6 +44,16 @@ type Resolver interface {
 	GetDestinationMaster(shardName string) (*topo.TabletInfo, error)
 }
 
+// Throttler is an interface that should be implemented by workers whose
+// copy rate can be changed while they are running.
+type Throttler interface {
+	// MaxRowsPerSecond returns the current maximum copy rate, 0 means unlimited.
+	MaxRowsPerSecond() int64
+
+	// SetMaxRowsPerSecond changes the maximum copy rate, 0 means unlimited.
+	SetMaxRowsPerSecond(maxRowsPerSecond int64)
+}
+
 var (
 	resolveTTL            = flag.Duration("resolve_ttl", 15*time.Second, "Amount of time that a topo resolution can be cached for")
 	executeFetchRetryTime = flag.Duration("executefetch_retry_time", 30*time.Second, "Amount of time we should wait before retrying ExecuteFetch calls")
@@ -0,0 +1,127 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wrangler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/youtube/vitess/go/vt/concurrency"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
+	"golang.org/x/net/context"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+// This file finds errant transactions: the transactions a slave
+// executed that didn't come from the master, like a write done
+// directly on the slave. They break the replication when the slave
+// becomes master, as the other slaves don't have them.
+//
+// The positions of the slaves are always read before the master
+// position, so the transactions they received from the master since
+// are in the master position.
+
+// ShardErrantGTIDs returns the errant transactions of each slave
+// of the shard that has some.
+func (wr *Wrangler) ShardErrantGTIDs(ctx context.Context, keyspace, shard string) (map[pb.TabletAlias]myproto.ReplicationPosition, error) {
+	si, err := wr.ts.GetShard(ctx, keyspace, shard)
+	if err != nil {
+		return nil, err
+	}
+	if !si.HasMaster() {
+		return nil, fmt.Errorf("No master in shard %v/%v", keyspace, shard)
+	}
+	tabletMap, err := wr.ts.GetTabletMapForShard(ctx, keyspace, shard)
+	if err != nil {
+		return nil, err
+	}
+	masterTabletInfo, ok := tabletMap[*si.MasterAlias]
+	if !ok {
+		return nil, fmt.Errorf("master tablet %v is not in the shard", topoproto.TabletAliasString(si.MasterAlias))
+	}
+
+	// get the slave positions first
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	rec := concurrency.AllErrorRecorder{}
+	posMap := make(map[pb.TabletAlias]myproto.ReplicationPosition)
+	for alias, ti := range tabletMap {
+		if topoproto.TabletAliasEqual(&alias, si.MasterAlias) || !ti.IsSlaveType() {
+			continue
+		}
+		wg.Add(1)
+		go func(alias pb.TabletAlias, ti *topo.TabletInfo) {
+			defer wg.Done()
+			pos, err := wr.tmc.MasterPosition(ctx, ti)
+			if err != nil {
+				rec.RecordError(fmt.Errorf("MasterPosition(%v) failed: %v", topoproto.TabletAliasString(&alias), err))
+				return
+			}
+			mu.Lock()
+			posMap[alias] = pos
+			mu.Unlock()
+		}(alias, ti)
+	}
+	wg.Wait()
+
+	// then the master position
+	masterPos, err := wr.tmc.MasterPosition(ctx, masterTabletInfo)
+	if err != nil {
+		return nil, fmt.Errorf("MasterPosition(%v) failed: %v", topoproto.TabletAliasString(si.MasterAlias), err)
+	}
+
+	result := make(map[pb.TabletAlias]myproto.ReplicationPosition)
+	for alias, pos := range posMap {
+		if errant := pos.Subtract(masterPos); !errant.IsZero() {
+			result[alias] = errant
+		}
+	}
+	return result, rec.Error()
+}
+
+// ValidateGTIDs checks that none of the slaves of the shard have
+// errant transactions, and lists them for each slave.
+func (wr *Wrangler) ValidateGTIDs(ctx context.Context, keyspace, shard string) error {
+	errantMap, err := wr.ShardErrantGTIDs(ctx, keyspace, shard)
+	if err != nil {
+		return err
+	}
+	if len(errantMap) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(errantMap))
+	for alias, errant := range errantMap {
+		lines = append(lines, fmt.Sprintf("Tablet %v has errant transactions: %v", topoproto.TabletAliasString(&alias), errant))
+	}
+	sort.Strings(lines)
+	er := concurrency.AllErrorRecorder{}
+	for _, line := range lines {
+		wr.logger.Printf("%v\n", line)
+		er.RecordError(fmt.Errorf("%v", line))
+	}
+	return fmt.Errorf("Errant transactions:\n%v", er.Error().Error())
+}
+
+// checkErrantGTIDs returns an error if the tablet has transactions
+// that the master doesn't have.
+func (wr *Wrangler) checkErrantGTIDs(ctx context.Context, master, ti *topo.TabletInfo) error {
+	pos, err := wr.tmc.MasterPosition(ctx, ti)
+	if err != nil {
+		return fmt.Errorf("MasterPosition(%v) failed: %v", ti.AliasString(), err)
+	}
+	masterPos, err := wr.tmc.MasterPosition(ctx, master)
+	if err != nil {
+		return fmt.Errorf("MasterPosition(%v) failed: %v", master.AliasString(), err)
+	}
+	if errant := pos.Subtract(masterPos); !errant.IsZero() {
+		return fmt.Errorf("tablet %v has errant transactions that master %v doesn't have: %v", ti.AliasString(), master.AliasString(), errant)
+	}
+	return nil
+}
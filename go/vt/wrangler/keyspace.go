@@ -408,12 +408,23 @@ func (wr *Wrangler) migrateServedTypes(ctx context.Context, keyspace string, sou
 		}
 	}
 
-	// All is good, we can save the shards now
-	event.DispatchUpdate(ev, "updating source shards")
-	for _, si := range sourceShards {
-		if err := wr.ts.UpdateShard(ctx, si); err != nil {
-			return err
-		}
+	// Compute the new serving graph from the updated shards, so
+	// it is saved with them.
+	srvKeyspaces, err := wr.migratedSrvKeyspaces(ctx, keyspace, cells, sourceShards, destinationShards)
+	if err != nil {
+		return err
+	}
+
+	// All is good, we can save the shards and the serving graph
+	// now. If the topology server supports it, they are all saved
+	// atomically, so we never leave a half-migrated keyspace.
+	// Otherwise the source shards are saved first, like before.
+	event.DispatchUpdate(ev, "updating shards and serving graph")
+	allShards := make([]*topo.ShardInfo, 0, len(sourceShards)+len(destinationShards))
+	allShards = append(allShards, sourceShards...)
+	allShards = append(allShards, destinationShards...)
+	if err := wr.ts.UpdateKeyspacesAndShards(ctx, nil, allShards, srvKeyspaces); err != nil {
+		return err
 	}
 	if needToRefreshSourceTablets {
 		event.DispatchUpdate(ev, "refreshing source shard tablets so they restart their query service")
@@ -421,12 +432,6 @@ func (wr *Wrangler) migrateServedTypes(ctx context.Context, keyspace string, sou
 			wr.RefreshTablesByShard(ctx, si, servedType, cells)
 		}
 	}
-	event.DispatchUpdate(ev, "updating destination shards")
-	for _, si := range destinationShards {
-		if err := wr.ts.UpdateShard(ctx, si); err != nil {
-			return err
-		}
-	}
 	if needToRefreshDestinationTablets {
 		event.DispatchUpdate(ev, "refreshing destination shard tablets so they restart their query service")
 		for _, si := range destinationShards {
@@ -448,6 +453,39 @@ func (wr *Wrangler) migrateServedTypes(ctx context.Context, keyspace string, sou
 	return nil
 }
 
+// migratedSrvKeyspaces returns the serving keyspace records of the
+// provided cells, built from the in-memory source and destination
+// shards of a migration, and the other shards of the keyspace.
+func (wr *Wrangler) migratedSrvKeyspaces(ctx context.Context, keyspace string, cells []string, sourceShards, destinationShards []*topo.ShardInfo) ([]*topo.SrvKeyspaceUpdate, error) {
+	ki, err := wr.ts.GetKeyspace(ctx, keyspace)
+	if err != nil {
+		return nil, err
+	}
+	shardMap, err := wr.ts.FindAllShardsInKeyspace(ctx, keyspace)
+	if err != nil {
+		return nil, err
+	}
+	for _, si := range sourceShards {
+		shardMap[si.ShardName()] = si
+	}
+	for _, si := range destinationShards {
+		shardMap[si.ShardName()] = si
+	}
+	srvKeyspaceMap, err := topotools.BuildSrvKeyspaces(ctx, wr.ts, ki, shardMap, cells)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*topo.SrvKeyspaceUpdate, 0, len(srvKeyspaceMap))
+	for cell, srvKeyspace := range srvKeyspaceMap {
+		result = append(result, &topo.SrvKeyspaceUpdate{
+			Cell:     cell,
+			Keyspace: keyspace,
+			Value:    srvKeyspace,
+		})
+	}
+	return result, nil
+}
+
 // MigrateServedFrom is used during vertical splits to migrate a
 // served type from a keyspace to another.
 func (wr *Wrangler) MigrateServedFrom(ctx context.Context, keyspace, shard string, servedType pb.TabletType, cells []string, reverse bool, filteredReplicationWaitTime time.Duration) error {
@@ -572,19 +610,17 @@ func (wr *Wrangler) migrateServedFrom(ctx context.Context, ki *topo.KeyspaceInfo
 
 // replicaMigrateServedFrom handles the slave (replica, rdonly) migration.
 func (wr *Wrangler) replicaMigrateServedFrom(ctx context.Context, ki *topo.KeyspaceInfo, sourceShard *topo.ShardInfo, destinationShard *topo.ShardInfo, servedType pb.TabletType, cells []string, reverse bool, tables []string, ev *events.MigrateServedFrom) error {
-	// Save the destination keyspace (its ServedFrom has been changed)
-	event.DispatchUpdate(ev, "updating keyspace")
-	if err := wr.ts.UpdateKeyspace(ctx, ki); err != nil {
-		return err
-	}
-
-	// Save the source shard (its blacklisted tables field has changed)
-	event.DispatchUpdate(ev, "updating source shard")
+	// Update the source shard (its blacklisted tables field has changed)
 	if err := sourceShard.UpdateSourceBlacklistedTables(servedType, cells, reverse, tables); err != nil {
 		return fmt.Errorf("UpdateSourceBlacklistedTables(%v/%v) failed: %v", sourceShard.Keyspace(), sourceShard.ShardName(), err)
 	}
-	if err := wr.ts.UpdateShard(ctx, sourceShard); err != nil {
-		return fmt.Errorf("UpdateShard(%v/%v) failed: %v", sourceShard.Keyspace(), sourceShard.ShardName(), err)
+
+	// Save the destination keyspace (its ServedFrom has been changed)
+	// and the source shard, in a single transaction if the topology
+	// server supports it.
+	event.DispatchUpdate(ev, "updating keyspace and source shard")
+	if err := wr.ts.UpdateKeyspacesAndShards(ctx, []*topo.KeyspaceInfo{ki}, []*topo.ShardInfo{sourceShard}, nil); err != nil {
+		return fmt.Errorf("UpdateKeyspacesAndShards(%v, %v/%v) failed: %v", ki.KeyspaceName(), sourceShard.Keyspace(), sourceShard.ShardName(), err)
 	}
 
 	// Now refresh the source servers so they reload their
@@ -650,15 +686,11 @@ func (wr *Wrangler) masterMigrateServedFrom(ctx context.Context, ki *topo.Keyspa
 	}
 
 	// Update the destination keyspace (its ServedFrom has changed)
-	event.DispatchUpdate(ev, "updating keyspace")
-	if err = wr.ts.UpdateKeyspace(ctx, ki); err != nil {
-		return err
-	}
-
-	// Update the destination shard (no more source shard)
-	event.DispatchUpdate(ev, "updating destination shard")
+	// and the destination shard (no more source shard), in a single
+	// transaction if the topology server supports it.
+	event.DispatchUpdate(ev, "updating keyspace and destination shard")
 	destinationShard.SourceShards = nil
-	if err := wr.ts.UpdateShard(ctx, destinationShard); err != nil {
+	if err := wr.ts.UpdateKeyspacesAndShards(ctx, []*topo.KeyspaceInfo{ki}, []*topo.ShardInfo{destinationShard}, nil); err != nil {
 		return err
 	}
 
@@ -324,7 +324,8 @@ func (wr *Wrangler) initShardMasterLocked(ctx context.Context, ev *events.Repare
 
 // PlannedReparentShard will make the provided tablet the master for the shard,
 // when both the current and new master are reachable and in good shape.
-func (wr *Wrangler) PlannedReparentShard(ctx context.Context, keyspace, shard string, masterElectTabletAlias *pb.TabletAlias, waitSlaveTimeout time.Duration) error {
+// If masterElectTabletAlias is nil, the new master is chosen using policy.
+func (wr *Wrangler) PlannedReparentShard(ctx context.Context, keyspace, shard string, masterElectTabletAlias *pb.TabletAlias, policy *ReparentCandidatePolicy, waitSlaveTimeout time.Duration) error {
 	// lock the shard
 	actionNode := actionnode.ReparentShard(plannedReparentShardOperation, masterElectTabletAlias)
 	lockPath, err := wr.lockShard(ctx, keyspace, shard, actionNode)
@@ -336,7 +337,7 @@ func (wr *Wrangler) PlannedReparentShard(ctx context.Context, keyspace, shard st
 	ev := &events.Reparent{}
 
 	// do the work
-	err = wr.plannedReparentShardLocked(ctx, ev, keyspace, shard, masterElectTabletAlias, waitSlaveTimeout)
+	err = wr.plannedReparentShardLocked(ctx, ev, keyspace, shard, masterElectTabletAlias, policy, waitSlaveTimeout)
 	if err != nil {
 		event.DispatchUpdate(ev, "failed PlannedReparentShard: "+err.Error())
 	} else {
@@ -347,7 +348,7 @@ func (wr *Wrangler) PlannedReparentShard(ctx context.Context, keyspace, shard st
 	return wr.unlockShard(ctx, keyspace, shard, actionNode, lockPath, err)
 }
 
-func (wr *Wrangler) plannedReparentShardLocked(ctx context.Context, ev *events.Reparent, keyspace, shard string, masterElectTabletAlias *pb.TabletAlias, waitSlaveTimeout time.Duration) error {
+func (wr *Wrangler) plannedReparentShardLocked(ctx context.Context, ev *events.Reparent, keyspace, shard string, masterElectTabletAlias *pb.TabletAlias, policy *ReparentCandidatePolicy, waitSlaveTimeout time.Duration) error {
 	shardInfo, err := wr.ts.GetShard(ctx, keyspace, shard)
 	if err != nil {
 		return err
@@ -360,6 +361,19 @@ func (wr *Wrangler) plannedReparentShardLocked(ctx context.Context, ev *events.R
 		return err
 	}
 
+	// Choose the master-elect if we weren't given one
+	if masterElectTabletAlias == nil {
+		if policy == nil {
+			policy = &ReparentCandidatePolicy{}
+		}
+		event.DispatchUpdate(ev, "choosing master-elect")
+		masterElectTabletAlias, ev.CandidateReasons, err = wr.choosePlannedReparentCandidate(ctx, policy, shardInfo, tabletMap)
+		wr.logCandidateReasons(ev.CandidateReasons)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Check corner cases we're going to depend on
 	masterElectTabletInfo, ok := tabletMap[*masterElectTabletAlias]
 	if !ok {
@@ -375,6 +389,13 @@ func (wr *Wrangler) plannedReparentShardLocked(ctx context.Context, ev *events.R
 	}
 	ev.OldMaster = *oldMasterTabletInfo.Tablet
 
+	// The master-elect cannot have transactions the other slaves
+	// won't get, it would break their replication
+	event.DispatchUpdate(ev, "checking for errant transactions")
+	if err := wr.checkErrantGTIDs(ctx, oldMasterTabletInfo, masterElectTabletInfo); err != nil {
+		return fmt.Errorf("cannot promote master-elect tablet %v: %v", topoproto.TabletAliasString(masterElectTabletAlias), err)
+	}
+
 	// Demote the current master, get its replication position
 	wr.logger.Infof("demote current master %v", shardInfo.MasterAlias)
 	event.DispatchUpdate(ev, "demoting old master")
@@ -456,7 +477,9 @@ func (wr *Wrangler) plannedReparentShardLocked(ctx context.Context, ev *events.R
 
 // EmergencyReparentShard will make the provided tablet the master for
 // the shard, when the old master is completely unreachable.
-func (wr *Wrangler) EmergencyReparentShard(ctx context.Context, keyspace, shard string, masterElectTabletAlias *pb.TabletAlias, waitSlaveTimeout time.Duration) error {
+// If masterElectTabletAlias is nil, the new master is chosen using policy
+// among the most advanced slaves.
+func (wr *Wrangler) EmergencyReparentShard(ctx context.Context, keyspace, shard string, masterElectTabletAlias *pb.TabletAlias, policy *ReparentCandidatePolicy, waitSlaveTimeout time.Duration) error {
 	// lock the shard
 	actionNode := actionnode.ReparentShard(emergencyReparentShardOperation, masterElectTabletAlias)
 	lockPath, err := wr.lockShard(ctx, keyspace, shard, actionNode)
@@ -468,7 +491,7 @@ func (wr *Wrangler) EmergencyReparentShard(ctx context.Context, keyspace, shard
 	ev := &events.Reparent{}
 
 	// do the work
-	err = wr.emergencyReparentShardLocked(ctx, ev, keyspace, shard, masterElectTabletAlias, waitSlaveTimeout)
+	err = wr.emergencyReparentShardLocked(ctx, ev, keyspace, shard, masterElectTabletAlias, policy, waitSlaveTimeout)
 	if err != nil {
 		event.DispatchUpdate(ev, "failed EmergencyReparentShard: "+err.Error())
 	} else {
@@ -479,7 +502,7 @@ func (wr *Wrangler) EmergencyReparentShard(ctx context.Context, keyspace, shard
 	return wr.unlockShard(ctx, keyspace, shard, actionNode, lockPath, err)
 }
 
-func (wr *Wrangler) emergencyReparentShardLocked(ctx context.Context, ev *events.Reparent, keyspace, shard string, masterElectTabletAlias *pb.TabletAlias, waitSlaveTimeout time.Duration) error {
+func (wr *Wrangler) emergencyReparentShardLocked(ctx context.Context, ev *events.Reparent, keyspace, shard string, masterElectTabletAlias *pb.TabletAlias, policy *ReparentCandidatePolicy, waitSlaveTimeout time.Duration) error {
 	shardInfo, err := wr.ts.GetShard(ctx, keyspace, shard)
 	if err != nil {
 		return err
@@ -493,39 +516,31 @@ func (wr *Wrangler) emergencyReparentShardLocked(ctx context.Context, ev *events
 	}
 
 	// Check corner cases we're going to depend on
-	masterElectTabletInfo, ok := tabletMap[*masterElectTabletAlias]
-	if !ok {
-		return fmt.Errorf("master-elect tablet %v is not in the shard", topoproto.TabletAliasString(masterElectTabletAlias))
-	}
-	ev.NewMaster = *masterElectTabletInfo.Tablet
-	if topoproto.TabletAliasEqual(shardInfo.MasterAlias, masterElectTabletAlias) {
-		return fmt.Errorf("master-elect tablet %v is already the master", topoproto.TabletAliasString(masterElectTabletAlias))
+	if masterElectTabletAlias != nil {
+		masterElectTabletInfo, ok := tabletMap[*masterElectTabletAlias]
+		if !ok {
+			return fmt.Errorf("master-elect tablet %v is not in the shard", topoproto.TabletAliasString(masterElectTabletAlias))
+		}
+		ev.NewMaster = *masterElectTabletInfo.Tablet
+		if topoproto.TabletAliasEqual(shardInfo.MasterAlias, masterElectTabletAlias) {
+			return fmt.Errorf("master-elect tablet %v is already the master", topoproto.TabletAliasString(masterElectTabletAlias))
+		}
 	}
 
-	// Deal with the old master: try to remote-scrap it, if it's
-	// truely dead we force-scrap it. Remove it from our map in any case.
+	// Remove the old master from our map, it is not a candidate
+	// and we won't reparent it. It is deleted once we know which
+	// tablet we promote.
+	var oldMasterTabletInfo *topo.TabletInfo
 	if shardInfo.HasMaster() {
-		deleteOldMaster := true
-		oldMasterTabletInfo, ok := tabletMap[*shardInfo.MasterAlias]
+		var ok bool
+		oldMasterTabletInfo, ok = tabletMap[*shardInfo.MasterAlias]
 		if ok {
 			delete(tabletMap, *shardInfo.MasterAlias)
 		} else {
 			oldMasterTabletInfo, err = wr.ts.GetTablet(ctx, shardInfo.MasterAlias)
 			if err != nil {
 				wr.logger.Warningf("cannot read old master tablet %v, won't touch it: %v", topoproto.TabletAliasString(shardInfo.MasterAlias), err)
-				deleteOldMaster = false
-			}
-		}
-
-		if deleteOldMaster {
-			ev.OldMaster = *oldMasterTabletInfo.Tablet
-			wr.logger.Infof("deleting old master %v", topoproto.TabletAliasString(shardInfo.MasterAlias))
-
-			ctx, cancel := context.WithTimeout(ctx, waitSlaveTimeout)
-			defer cancel()
-
-			if err := topotools.DeleteTablet(ctx, wr.ts, oldMasterTabletInfo.Tablet); err != nil {
-				wr.logger.Warningf("failed to delete old master tablet %v: %v", topoproto.TabletAliasString(shardInfo.MasterAlias), err)
+				oldMasterTabletInfo = nil
 			}
 		}
 	}
@@ -555,11 +570,29 @@ func (wr *Wrangler) emergencyReparentShardLocked(ctx context.Context, ev *events
 	}
 	wg.Wait()
 
+	// Choose the master-elect if we weren't given one
+	if masterElectTabletAlias == nil {
+		if policy == nil {
+			policy = &ReparentCandidatePolicy{}
+		}
+		event.DispatchUpdate(ev, "choosing master-elect")
+		masterElectTabletAlias, ev.CandidateReasons, err = wr.chooseEmergencyReparentCandidate(policy, tabletMap, statusMap)
+		wr.logCandidateReasons(ev.CandidateReasons)
+		if err != nil {
+			return err
+		}
+		ev.NewMaster = *tabletMap[*masterElectTabletAlias].Tablet
+	}
+	masterElectTabletInfo := tabletMap[*masterElectTabletAlias]
+
 	// Verify masterElect is alive and has the most advanced position
 	masterElectStatus, ok := statusMap[*masterElectTabletAlias]
 	if !ok {
 		return fmt.Errorf("couldn't get master elect %v replication position", topoproto.TabletAliasString(masterElectTabletAlias))
 	}
+	if policy != nil && policy.RequireSemiSyncSlave && !masterElectStatus.SemiSyncSlaveActive {
+		return fmt.Errorf("master elect %v is not an active semi-sync slave", topoproto.TabletAliasString(masterElectTabletAlias))
+	}
 	for alias, status := range statusMap {
 		if topoproto.TabletAliasEqual(&alias, masterElectTabletAlias) {
 			continue
@@ -568,6 +601,24 @@ func (wr *Wrangler) emergencyReparentShardLocked(ctx context.Context, ev *events
 			return fmt.Errorf("tablet %v is more advanced than master elect tablet %v: %v > %v", topoproto.TabletAliasString(&alias), topoproto.TabletAliasString(masterElectTabletAlias), status.Position, masterElectStatus)
 		}
 	}
+	// Unlike in PlannedReparentShard, the master-elect is not checked
+	// for errant transactions: the master is gone, and the most
+	// advanced slave usually has transactions from it that no other
+	// slave received yet.
+
+	// Deal with the old master: try to remote-scrap it, if it's
+	// truely dead we force-scrap it.
+	if oldMasterTabletInfo != nil {
+		ev.OldMaster = *oldMasterTabletInfo.Tablet
+		wr.logger.Infof("deleting old master %v", topoproto.TabletAliasString(shardInfo.MasterAlias))
+
+		ctx, cancel := context.WithTimeout(ctx, waitSlaveTimeout)
+		defer cancel()
+
+		if err := topotools.DeleteTablet(ctx, wr.ts, oldMasterTabletInfo.Tablet); err != nil {
+			wr.logger.Warningf("failed to delete old master tablet %v: %v", topoproto.TabletAliasString(shardInfo.MasterAlias), err)
+		}
+	}
 
 	// Promote the masterElect
 	wr.logger.Infof("promote slave %v", topoproto.TabletAliasString(masterElectTabletAlias))
@@ -0,0 +1,228 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wrangler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
+	"golang.org/x/net/context"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+// ReparentCandidatePolicy describes how PlannedReparentShard and
+// EmergencyReparentShard choose the new master when none is
+// provided. Only REPLICA tablets are ever chosen, so rdonly tablets
+// are never promoted.
+type ReparentCandidatePolicy struct {
+	// PreferredCells lists cells in order of preference. Tablets
+	// in other cells are only chosen if no tablet in these cells
+	// qualifies.
+	PreferredCells []string
+
+	// ExcludedTags excludes the tablets that have any of these
+	// tags with the same value.
+	ExcludedTags map[string]string
+
+	// MaxReplicationLag excludes the tablets that are more than
+	// this behind the current master. It is only used by
+	// PlannedReparentShard, as the lag cannot be known once the
+	// master is gone. Zero means no limit.
+	MaxReplicationLag time.Duration
+
+	// RequireSemiSyncSlave makes EmergencyReparentShard refuse
+	// any master-elect, chosen or provided, that was not
+	// acknowledging transactions as a semi-sync slave when its
+	// replication was stopped. Having semi-sync slave enabled is not
+	// enough: the slave only acknowledges when it is connected to
+	// a semi-sync master.
+	RequireSemiSyncSlave bool
+}
+
+// reparentCandidate is a tablet that passed the policy filters.
+type reparentCandidate struct {
+	alias    pb.TabletAlias
+	cellRank int
+
+	// lag is only known for PlannedReparentShard.
+	lag      uint
+	lagKnown bool
+}
+
+// cellRank returns the index of cell in the preferred cells, or
+// len(PreferredCells) if it is not preferred.
+func (p *ReparentCandidatePolicy) cellRank(cell string) int {
+	for i, c := range p.PreferredCells {
+		if c == cell {
+			return i
+		}
+	}
+	return len(p.PreferredCells)
+}
+
+// excludedTag returns a description of the first excluded tag the
+// tablet has, or "" if it has none.
+func (p *ReparentCandidatePolicy) excludedTag(tablet *pb.Tablet) string {
+	for k, v := range p.ExcludedTags {
+		if tv, ok := tablet.Tags[k]; ok && tv == v {
+			return k + ":" + v
+		}
+	}
+	return ""
+}
+
+// eligibleCandidates returns the tablets that pass the type and tag
+// filters of the policy, and appends the reasons the others were
+// rejected to reasons.
+func (p *ReparentCandidatePolicy) eligibleCandidates(tabletMap map[pb.TabletAlias]*topo.TabletInfo, masterAlias *pb.TabletAlias, reasons []string) (map[pb.TabletAlias]*topo.TabletInfo, []string) {
+	result := make(map[pb.TabletAlias]*topo.TabletInfo)
+	for alias, ti := range tabletMap {
+		a := alias
+		switch {
+		case topoproto.TabletAliasEqual(&a, masterAlias):
+			continue
+		case ti.Type != pb.TabletType_REPLICA:
+			reasons = append(reasons, fmt.Sprintf("%v: rejected, tablet type is %v", topoproto.TabletAliasString(&a), ti.Type))
+		default:
+			if tag := p.excludedTag(ti.Tablet); tag != "" {
+				reasons = append(reasons, fmt.Sprintf("%v: rejected, excluded tag %v", topoproto.TabletAliasString(&a), tag))
+				continue
+			}
+			result[alias] = ti
+		}
+	}
+	return result, reasons
+}
+
+// chooseCandidate returns the best of the candidates: the one in the
+// most preferred cell, then the one with the lowest lag. Ties are
+// broken by alias so the choice is deterministic.
+func (p *ReparentCandidatePolicy) chooseCandidate(candidates []reparentCandidate, reasons []string) (*pb.TabletAlias, []string, error) {
+	sort.Strings(reasons)
+	if len(candidates) == 0 {
+		return nil, reasons, fmt.Errorf("no tablet qualifies to become the new master: %v", strings.Join(reasons, "; "))
+	}
+	sort.Sort(reparentCandidateList(candidates))
+	best := candidates[0]
+	reason := fmt.Sprintf("%v: chosen", topoproto.TabletAliasString(&best.alias))
+	if best.cellRank < len(p.PreferredCells) {
+		reason += fmt.Sprintf(", in preferred cell %v", best.alias.Cell)
+	}
+	if best.lagKnown {
+		reason += fmt.Sprintf(", replication lag %vs", best.lag)
+	}
+	reasons = append(reasons, reason)
+	for _, c := range candidates[1:] {
+		reasons = append(reasons, fmt.Sprintf("%v: qualified, but not preferred", topoproto.TabletAliasString(&c.alias)))
+	}
+	return &best.alias, reasons, nil
+}
+
+type reparentCandidateList []reparentCandidate
+
+func (l reparentCandidateList) Len() int      { return len(l) }
+func (l reparentCandidateList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l reparentCandidateList) Less(i, j int) bool {
+	if l[i].cellRank != l[j].cellRank {
+		return l[i].cellRank < l[j].cellRank
+	}
+	if l[i].lag != l[j].lag {
+		return l[i].lag < l[j].lag
+	}
+	return topoproto.TabletAliasString(&l[i].alias) < topoproto.TabletAliasString(&l[j].alias)
+}
+
+// choosePlannedReparentCandidate picks the new master for a
+// PlannedReparentShard, while the current master is still
+// replicating to the slaves. It returns the chosen tablet, and the
+// reasons each tablet was chosen or rejected.
+func (wr *Wrangler) choosePlannedReparentCandidate(ctx context.Context, policy *ReparentCandidatePolicy, shardInfo *topo.ShardInfo, tabletMap map[pb.TabletAlias]*topo.TabletInfo) (*pb.TabletAlias, []string, error) {
+	eligible, reasons := policy.eligibleCandidates(tabletMap, shardInfo.MasterAlias, nil)
+
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	var candidates []reparentCandidate
+	for alias, ti := range eligible {
+		wg.Add(1)
+		go func(alias pb.TabletAlias, ti *topo.TabletInfo) {
+			defer wg.Done()
+			status, err := wr.tmc.SlaveStatus(ctx, ti)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				reasons = append(reasons, fmt.Sprintf("%v: rejected, cannot get replication status: %v", topoproto.TabletAliasString(&alias), err))
+			case !status.SlaveRunning():
+				reasons = append(reasons, fmt.Sprintf("%v: rejected, replication is not running", topoproto.TabletAliasString(&alias)))
+			case policy.MaxReplicationLag != 0 && time.Duration(status.SecondsBehindMaster)*time.Second > policy.MaxReplicationLag:
+				reasons = append(reasons, fmt.Sprintf("%v: rejected, replication lag %vs is over %v", topoproto.TabletAliasString(&alias), status.SecondsBehindMaster, policy.MaxReplicationLag))
+			default:
+				candidates = append(candidates, reparentCandidate{
+					alias:    alias,
+					cellRank: policy.cellRank(alias.Cell),
+					lag:      status.SecondsBehindMaster,
+					lagKnown: true,
+				})
+			}
+		}(alias, ti)
+	}
+	wg.Wait()
+
+	return policy.chooseCandidate(candidates, reasons)
+}
+
+// chooseEmergencyReparentCandidate picks the new master for an
+// EmergencyReparentShard, once replication has been stopped on all
+// the slaves. Only the tablets with the most advanced position
+// qualify. It returns the chosen tablet, and the reasons each tablet
+// was chosen or rejected.
+func (wr *Wrangler) chooseEmergencyReparentCandidate(policy *ReparentCandidatePolicy, tabletMap map[pb.TabletAlias]*topo.TabletInfo, statusMap map[pb.TabletAlias]myproto.ReplicationStatus) (*pb.TabletAlias, []string, error) {
+	eligible, reasons := policy.eligibleCandidates(tabletMap, nil, nil)
+
+	var candidates []reparentCandidate
+	for alias := range eligible {
+		status, ok := statusMap[alias]
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("%v: rejected, no replication position", topoproto.TabletAliasString(&alias)))
+			continue
+		}
+		if policy.RequireSemiSyncSlave && !status.SemiSyncSlaveActive {
+			reasons = append(reasons, fmt.Sprintf("%v: rejected, not an active semi-sync slave", topoproto.TabletAliasString(&alias)))
+			continue
+		}
+		mostAdvanced := true
+		for _, other := range statusMap {
+			if !status.Position.AtLeast(other.Position) {
+				mostAdvanced = false
+				break
+			}
+		}
+		if !mostAdvanced {
+			reasons = append(reasons, fmt.Sprintf("%v: rejected, position %v is not the most advanced", topoproto.TabletAliasString(&alias), status.Position))
+			continue
+		}
+		candidates = append(candidates, reparentCandidate{
+			alias:    alias,
+			cellRank: policy.cellRank(alias.Cell),
+		})
+	}
+
+	return policy.chooseCandidate(candidates, reasons)
+}
+
+// logCandidateReasons displays the reasons the master-elect was chosen.
+func (wr *Wrangler) logCandidateReasons(reasons []string) {
+	wr.logger.Printf("Choosing the master-elect:\n")
+	for _, reason := range reasons {
+		wr.logger.Printf("  %v\n", reason)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wrangler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/youtube/vitess/go/vt/logutil"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/tabletmanager/tmclient"
+	"github.com/youtube/vitess/go/vt/topo"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+func TestChooseEmergencyReparentCandidate(t *testing.T) {
+	tablet := func(cell string, uid uint32, tabletType pb.TabletType, tags map[string]string) *topo.TabletInfo {
+		return topo.NewTabletInfo(&pb.Tablet{
+			Alias: &pb.TabletAlias{Cell: cell, Uid: uid},
+			Type:  tabletType,
+			Tags:  tags,
+		}, 0)
+	}
+	tabletMap := map[pb.TabletAlias]*topo.TabletInfo{}
+	for _, ti := range []*topo.TabletInfo{
+		tablet("cell1", 1, pb.TabletType_REPLICA, nil),
+		tablet("cell2", 2, pb.TabletType_REPLICA, nil),
+		tablet("cell2", 3, pb.TabletType_REPLICA, map[string]string{"maintenance": "true"}),
+		tablet("cell3", 4, pb.TabletType_RDONLY, nil),
+		tablet("cell3", 5, pb.TabletType_REPLICA, nil),
+	} {
+		tabletMap[*ti.Alias] = ti
+	}
+	statusMap := map[pb.TabletAlias]myproto.ReplicationStatus{}
+	for alias := range tabletMap {
+		pos := "0-1-12"
+		if alias.Uid == 1 {
+			pos = "0-1-10"
+		}
+		statusMap[alias] = myproto.ReplicationStatus{Position: myproto.MustParseReplicationPosition("MariaDB", pos)}
+	}
+
+	wr := &Wrangler{}
+	testcases := []struct {
+		policy *ReparentCandidatePolicy
+		want   pb.TabletAlias
+		err    bool
+	}{
+		{
+			// cell1-1 is behind, so the preference is ignored.
+			policy: &ReparentCandidatePolicy{PreferredCells: []string{"cell1", "cell3"}},
+			want:   pb.TabletAlias{Cell: "cell3", Uid: 5},
+		},
+		{
+			// Ties are broken by alias.
+			policy: &ReparentCandidatePolicy{},
+			want:   pb.TabletAlias{Cell: "cell2", Uid: 2},
+		},
+		{
+			policy: &ReparentCandidatePolicy{PreferredCells: []string{"cell2"}, ExcludedTags: map[string]string{"maintenance": "true"}},
+			want:   pb.TabletAlias{Cell: "cell2", Uid: 2},
+		},
+		{
+			// The rdonly tablet is never chosen.
+			policy: &ReparentCandidatePolicy{ExcludedTags: map[string]string{"maintenance": "true"}, PreferredCells: []string{"cell3"}},
+			want:   pb.TabletAlias{Cell: "cell3", Uid: 5},
+		},
+	}
+	for _, tc := range testcases {
+		got, reasons, err := wr.chooseEmergencyReparentCandidate(tc.policy, tabletMap, statusMap)
+		if err != nil {
+			t.Errorf("chooseEmergencyReparentCandidate(%v): %v", tc.policy, err)
+			continue
+		}
+		if *got != tc.want {
+			t.Errorf("chooseEmergencyReparentCandidate(%v) = %v, want %v (reasons: %v)", tc.policy, got, tc.want, reasons)
+		}
+		if len(reasons) != len(tabletMap) {
+			t.Errorf("chooseEmergencyReparentCandidate(%v) returned %v reasons for %v tablets: %v", tc.policy, len(reasons), len(tabletMap), reasons)
+		}
+	}
+
+	// Only semi-sync slaves qualify if semi-sync is required.
+	status := statusMap[pb.TabletAlias{Cell: "cell3", Uid: 5}]
+	status.SemiSyncSlaveActive = true
+	statusMap[pb.TabletAlias{Cell: "cell3", Uid: 5}] = status
+	got, _, err := wr.chooseEmergencyReparentCandidate(&ReparentCandidatePolicy{RequireSemiSyncSlave: true}, tabletMap, statusMap)
+	if err != nil {
+		t.Fatalf("chooseEmergencyReparentCandidate with RequireSemiSyncSlave: %v", err)
+	}
+	if want := (pb.TabletAlias{Cell: "cell3", Uid: 5}); *got != want {
+		t.Errorf("chooseEmergencyReparentCandidate with RequireSemiSyncSlave = %v, want %v", got, want)
+	}
+
+	// Nobody qualifies if the only most advanced tablet is rdonly.
+	delete(statusMap, pb.TabletAlias{Cell: "cell2", Uid: 2})
+	delete(statusMap, pb.TabletAlias{Cell: "cell2", Uid: 3})
+	delete(statusMap, pb.TabletAlias{Cell: "cell3", Uid: 5})
+	if _, _, err := wr.chooseEmergencyReparentCandidate(&ReparentCandidatePolicy{}, tabletMap, statusMap); err == nil {
+		t.Errorf("chooseEmergencyReparentCandidate with only a rdonly tablet up to date: got no error")
+	}
+}
+
+// slaveStatusTabletManagerClient returns the replication status of
+// each tablet from a map, and an error for the tablets not in it.
+// It only implements SlaveStatus.
+type slaveStatusTabletManagerClient struct {
+	tmclient.TabletManagerClient
+	statusMap map[pb.TabletAlias]myproto.ReplicationStatus
+}
+
+func (client *slaveStatusTabletManagerClient) SlaveStatus(ctx context.Context, tablet *topo.TabletInfo) (myproto.ReplicationStatus, error) {
+	status, ok := client.statusMap[*tablet.Alias]
+	if !ok {
+		return myproto.ReplicationStatus{}, fmt.Errorf("no status for %v", *tablet.Alias)
+	}
+	return status, nil
+}
+
+func TestChoosePlannedReparentCandidate(t *testing.T) {
+	tablet := func(cell string, uid uint32, tabletType pb.TabletType, tags map[string]string) *topo.TabletInfo {
+		return topo.NewTabletInfo(&pb.Tablet{
+			Alias: &pb.TabletAlias{Cell: cell, Uid: uid},
+			Type:  tabletType,
+			Tags:  tags,
+		}, 0)
+	}
+	tabletMap := map[pb.TabletAlias]*topo.TabletInfo{}
+	for _, ti := range []*topo.TabletInfo{
+		tablet("cell1", 1, pb.TabletType_MASTER, nil),
+		tablet("cell1", 2, pb.TabletType_REPLICA, nil),
+		tablet("cell2", 3, pb.TabletType_REPLICA, nil),
+		tablet("cell2", 4, pb.TabletType_REPLICA, map[string]string{"maintenance": "true"}),
+		tablet("cell3", 5, pb.TabletType_RDONLY, nil),
+		tablet("cell3", 6, pb.TabletType_REPLICA, nil),
+		tablet("cell3", 7, pb.TabletType_REPLICA, nil),
+		tablet("cell3", 8, pb.TabletType_REPLICA, nil),
+	} {
+		tabletMap[*ti.Alias] = ti
+	}
+	running := func(lag uint) myproto.ReplicationStatus {
+		return myproto.ReplicationStatus{SlaveIORunning: true, SlaveSQLRunning: true, SecondsBehindMaster: lag}
+	}
+	// cell1-2 is 10s behind, cell3-7 is not replicating and we
+	// cannot reach cell3-8.
+	client := &slaveStatusTabletManagerClient{
+		statusMap: map[pb.TabletAlias]myproto.ReplicationStatus{
+			{Cell: "cell1", Uid: 2}: running(10),
+			{Cell: "cell2", Uid: 3}: running(1),
+			{Cell: "cell2", Uid: 4}: running(0),
+			{Cell: "cell3", Uid: 5}: running(0),
+			{Cell: "cell3", Uid: 6}: running(2),
+			{Cell: "cell3", Uid: 7}: {SlaveIORunning: true},
+		},
+	}
+	wr := New(logutil.NewConsoleLogger(), topo.Server{}, client)
+	shardInfo := topo.NewShardInfo("ks", "0", &pb.Shard{MasterAlias: &pb.TabletAlias{Cell: "cell1", Uid: 1}}, 0)
+
+	testcases := []struct {
+		policy *ReparentCandidatePolicy
+		want   pb.TabletAlias
+	}{
+		{
+			// The least lagging tablet wins without preference.
+			policy: &ReparentCandidatePolicy{},
+			want:   pb.TabletAlias{Cell: "cell2", Uid: 4},
+		},
+		{
+			policy: &ReparentCandidatePolicy{ExcludedTags: map[string]string{"maintenance": "true"}},
+			want:   pb.TabletAlias{Cell: "cell2", Uid: 3},
+		},
+		{
+			// The preferred cell wins over the lag.
+			policy: &ReparentCandidatePolicy{PreferredCells: []string{"cell1"}},
+			want:   pb.TabletAlias{Cell: "cell1", Uid: 2},
+		},
+		{
+			// Unless the lag is over the maximum.
+			policy: &ReparentCandidatePolicy{PreferredCells: []string{"cell1", "cell3"}, MaxReplicationLag: 5 * time.Second},
+			want:   pb.TabletAlias{Cell: "cell3", Uid: 6},
+		},
+	}
+	for _, tc := range testcases {
+		got, reasons, err := wr.choosePlannedReparentCandidate(context.Background(), tc.policy, shardInfo, tabletMap)
+		if err != nil {
+			t.Errorf("choosePlannedReparentCandidate(%v): %v", tc.policy, err)
+			continue
+		}
+		if *got != tc.want {
+			t.Errorf("choosePlannedReparentCandidate(%v) = %v, want %v (reasons: %v)", tc.policy, got, tc.want, reasons)
+		}
+		// The current master is not a candidate.
+		if len(reasons) != len(tabletMap)-1 {
+			t.Errorf("choosePlannedReparentCandidate(%v) returned %v reasons for %v slaves: %v", tc.policy, len(reasons), len(tabletMap)-1, reasons)
+		}
+	}
+
+	// Nobody qualifies if all the tablets are too far behind.
+	if _, _, err := wr.choosePlannedReparentCandidate(context.Background(), &ReparentCandidatePolicy{MaxReplicationLag: 500 * time.Millisecond, ExcludedTags: map[string]string{"maintenance": "true"}}, shardInfo, tabletMap); err == nil {
+		t.Errorf("choosePlannedReparentCandidate with all tablets lagging: got no error")
+	}
+}
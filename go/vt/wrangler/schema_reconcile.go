@@ -0,0 +1,305 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wrangler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+
+	log "github.com/golang/glog"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/schemamanager"
+	"github.com/youtube/vitess/go/vt/sqlparser"
+	"github.com/youtube/vitess/go/vt/tabletmanager/actionnode"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+// SchemaFix describes what ReconcileSchemaKeyspace does to bring a
+// tablet to the desired schema.
+type SchemaFix struct {
+	TabletAlias *pb.TabletAlias
+
+	// Sqls are the DDLs that fix the tablet schema.
+	Sqls []string
+
+	// Unfixable lists the differences that need to be fixed manually.
+	Unfixable []string
+
+	// Error is set if the tablet schema could not be read, or
+	// the fix failed.
+	Error string
+}
+
+// statementSeparator splits the files of a schema directory into
+// statements.
+var statementSeparator = regexp.MustCompile(`;\s*(\n|$)`)
+
+// readSchemaDir reads the CREATE statements of the .sql files in dir,
+// in file name order. Any other statement is refused.
+func readSchemaDir(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .sql file in %v", dir)
+	}
+	var sqls []string
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, sql := range statementSeparator.Split(string(data), -1) {
+			sql = strings.TrimSpace(sql)
+			if sql == "" {
+				continue
+			}
+			stmt, err := sqlparser.Parse(sql)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse statement in %v: %v", file, err)
+			}
+			if ddl, ok := stmt.(*sqlparser.DDL); !ok || ddl.Action != sqlparser.CreateStr {
+				return nil, fmt.Errorf("only CREATE statements are allowed in %v: %v", file, sql)
+			}
+			sqls = append(sqls, sql)
+		}
+	}
+	return sqls, nil
+}
+
+// desiredSchema returns the schema the keyspace should have, read
+// from sourceTabletAlias, or from the CREATE statements in sourceDir.
+func (wr *Wrangler) desiredSchema(ctx context.Context, keyspace, referenceShard, sourceDir string, sourceTabletAlias *pb.TabletAlias, excludeTables []string) (*myproto.SchemaDefinition, error) {
+	switch {
+	case sourceDir != "" && sourceTabletAlias != nil:
+		return nil, fmt.Errorf("the desired schema cannot come from both a directory and a tablet")
+	case sourceTabletAlias != nil:
+		return wr.GetSchema(ctx, sourceTabletAlias, nil, excludeTables, false)
+	case sourceDir == "":
+		return nil, fmt.Errorf("the desired schema needs to come from a directory or a tablet")
+	}
+
+	sqls, err := readSchemaDir(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The statements are run in the preflight database of a master,
+	// once its tables and views are dropped, so the desired schema has the
+	// same SHOW CREATE TABLE format as the ones of the tablets.
+	si, err := wr.ts.GetShard(ctx, keyspace, referenceShard)
+	if err != nil {
+		return nil, err
+	}
+	if !si.HasMaster() {
+		return nil, fmt.Errorf("No master in shard %v/%v", keyspace, referenceShard)
+	}
+	current, err := wr.GetSchema(ctx, si.MasterAlias, nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	change := dropAllSQL(current)
+	for _, sql := range sqls {
+		change += sql + ";\n"
+	}
+	scr, err := wr.PreflightSchema(ctx, si.MasterAlias, change)
+	if err != nil {
+		return nil, fmt.Errorf("cannot apply the statements of %v on %v: %v", sourceDir, topoproto.TabletAliasString(si.MasterAlias), err)
+	}
+	return scr.AfterSchema.FilterTables(nil, excludeTables, false)
+}
+
+// dropAllSQL returns the statements dropping all the views, then all
+// the tables of a schema.
+func dropAllSQL(sd *myproto.SchemaDefinition) string {
+	views, tables := "", ""
+	for _, td := range sd.TableDefinitions {
+		if td.Type == myproto.TableView {
+			views += fmt.Sprintf("DROP VIEW `%v`;\n", td.Name)
+		} else {
+			tables += fmt.Sprintf("DROP TABLE `%v`;\n", td.Name)
+		}
+	}
+	return views + tables
+}
+
+// schemaFixes computes the fixes of all the tablets of the shards.
+func (wr *Wrangler) schemaFixes(ctx context.Context, keyspace string, shards []string, desired *myproto.SchemaDefinition, excludeTables []string, allowDrops bool) ([]*SchemaFix, error) {
+	var aliases []*pb.TabletAlias
+	for _, shard := range shards {
+		shardAliases, err := wr.ts.FindAllTabletAliasesInShard(ctx, keyspace, shard)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, shardAliases...)
+	}
+
+	fixes := make([]*SchemaFix, len(aliases))
+	wg := sync.WaitGroup{}
+	for i, alias := range aliases {
+		fixes[i] = &SchemaFix{TabletAlias: alias}
+		wg.Add(1)
+		go func(fix *SchemaFix) {
+			defer wg.Done()
+			log.Infof("Gathering schema for %v", topoproto.TabletAliasString(fix.TabletAlias))
+			actual, err := wr.GetSchema(ctx, fix.TabletAlias, nil, excludeTables, false)
+			if err != nil {
+				fix.Error = err.Error()
+				return
+			}
+			fix.Sqls, fix.Unfixable = myproto.SchemaFixes(desired, actual, allowDrops)
+		}(fixes[i])
+	}
+	wg.Wait()
+	return fixes, nil
+}
+
+// logSchemaFixes displays the reconciliation plan.
+func (wr *Wrangler) logSchemaFixes(fixes []*SchemaFix) {
+	for _, fix := range fixes {
+		alias := topoproto.TabletAliasString(fix.TabletAlias)
+		if fix.Error != "" {
+			wr.logger.Printf("%v: cannot be reconciled: %v\n", alias, fix.Error)
+			continue
+		}
+		if len(fix.Sqls) == 0 && len(fix.Unfixable) == 0 {
+			wr.logger.Printf("%v: schema is up to date\n", alias)
+			continue
+		}
+		for _, sql := range fix.Sqls {
+			wr.logger.Printf("%v: will run: %v\n", alias, sql)
+		}
+		for _, u := range fix.Unfixable {
+			wr.logger.Printf("%v: needs a manual fix: %v\n", alias, u)
+		}
+	}
+}
+
+// applySchemaFixes applies the fixes, grouping the tablets that need
+// the same statements in one TabletExecutor.
+func (wr *Wrangler) applySchemaFixes(ctx context.Context, fixes []*SchemaFix) error {
+	var groups []string
+	tablets := make(map[string][]*topo.TabletInfo)
+	groupFixes := make(map[string][]*SchemaFix)
+	for _, fix := range fixes {
+		if fix.Error != "" || len(fix.Sqls) == 0 {
+			continue
+		}
+		ti, err := wr.ts.GetTablet(ctx, fix.TabletAlias)
+		if err != nil {
+			fix.Error = err.Error()
+			continue
+		}
+		key := strings.Join(fix.Sqls, ";\n")
+		if _, ok := tablets[key]; !ok {
+			groups = append(groups, key)
+		}
+		tablets[key] = append(tablets[key], ti)
+		groupFixes[key] = append(groupFixes[key], fix)
+	}
+
+	for _, key := range groups {
+		sqls := groupFixes[key][0].Sqls
+		if err := wr.applySchemaFix(ctx, tablets[key], sqls); err != nil {
+			for _, fix := range groupFixes[key] {
+				fix.Error = err.Error()
+			}
+		}
+	}
+
+	var failed []string
+	for _, fix := range fixes {
+		if fix.Error != "" {
+			failed = append(failed, topoproto.TabletAliasString(fix.TabletAlias))
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("schema could not be reconciled on tablets: %v", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// applySchemaFix runs the statements on the tablets, which all have
+// the same schema.
+func (wr *Wrangler) applySchemaFix(ctx context.Context, tablets []*topo.TabletInfo, sqls []string) error {
+	executor := schemamanager.NewTabletExecutor(wr.tmc, wr.ts)
+	if err := executor.OpenTablets(tablets); err != nil {
+		return err
+	}
+	defer executor.Close()
+	if err := executor.Validate(ctx, sqls); err != nil {
+		return err
+	}
+	result := executor.Execute(ctx, sqls)
+	if result.ExecutorErr != "" {
+		return fmt.Errorf("%v", result.ExecutorErr)
+	}
+	if len(result.FailedShards) > 0 {
+		return fmt.Errorf("%v failed: %v", result.Sqls[result.CurSqlIndex], result.FailedShards[0].Err)
+	}
+	return nil
+}
+
+// ReconcileSchemaKeyspace compares the schema of all the tablets of
+// the keyspace with the desired schema, and fixes the differences.
+// The desired schema is either the schema of sourceTabletAlias, or
+// the CREATE statements of the .sql files in sourceDir. Views are
+// not reconciled, and extra tables, columns and indexes are only
+// dropped if allowDrops is set.
+//
+// Each tablet is fixed independently, with binlogs disabled, through
+// a schemamanager.TabletExecutor: its validation refuses big schema
+// changes, and every statement is preflighted first. With dryRun,
+// the plan is only displayed.
+func (wr *Wrangler) ReconcileSchemaKeyspace(ctx context.Context, keyspace, sourceDir string, sourceTabletAlias *pb.TabletAlias, excludeTables []string, allowDrops, dryRun bool) ([]*SchemaFix, error) {
+	if dryRun {
+		return wr.reconcileSchemaKeyspace(ctx, keyspace, sourceDir, sourceTabletAlias, excludeTables, allowDrops, dryRun)
+	}
+
+	actionNode := actionnode.ReconcileSchemaKeyspace()
+	lockPath, err := wr.lockKeyspace(ctx, keyspace, actionNode)
+	if err != nil {
+		return nil, err
+	}
+	fixes, err := wr.reconcileSchemaKeyspace(ctx, keyspace, sourceDir, sourceTabletAlias, excludeTables, allowDrops, dryRun)
+	return fixes, wr.unlockKeyspace(ctx, keyspace, actionNode, lockPath, err)
+}
+
+func (wr *Wrangler) reconcileSchemaKeyspace(ctx context.Context, keyspace, sourceDir string, sourceTabletAlias *pb.TabletAlias, excludeTables []string, allowDrops, dryRun bool) ([]*SchemaFix, error) {
+	shards, err := wr.ts.GetShardNames(ctx, keyspace)
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("No shards in keyspace %v", keyspace)
+	}
+	sort.Strings(shards)
+
+	desired, err := wr.desiredSchema(ctx, keyspace, shards[0], sourceDir, sourceTabletAlias, excludeTables)
+	if err != nil {
+		return nil, err
+	}
+	fixes, err := wr.schemaFixes(ctx, keyspace, shards, desired, excludeTables, allowDrops)
+	if err != nil {
+		return nil, err
+	}
+	wr.logSchemaFixes(fixes)
+	if dryRun {
+		return fixes, nil
+	}
+	return fixes, wr.applySchemaFixes(ctx, fixes)
+}
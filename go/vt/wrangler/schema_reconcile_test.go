@@ -0,0 +1,71 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wrangler
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+)
+
+func TestReadSchemaDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema_dir")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := readSchemaDir(dir); err == nil {
+		t.Errorf("readSchemaDir on an empty directory should fail")
+	}
+
+	files := map[string]string{
+		"2_t2.sql":   "create table t2 (\n  id bigint\n);\n",
+		"1_t1.sql":   "create table t1 (id bigint);\ncreate view v1 as select id from t1;",
+		"README.txt": "not read",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	got, err := readSchemaDir(dir)
+	if err != nil {
+		t.Fatalf("readSchemaDir failed: %v", err)
+	}
+	want := []string{
+		"create table t1 (id bigint)",
+		"create view v1 as select id from t1",
+		"create table t2 (\n  id bigint\n)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readSchemaDir() = %#v, want %#v", got, want)
+	}
+
+	if err := ioutil.WriteFile(path.Join(dir, "3_drop.sql"), []byte("drop table t1;"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := readSchemaDir(dir); err == nil {
+		t.Errorf("readSchemaDir should refuse a DROP statement")
+	}
+}
+
+func TestDropAllSQL(t *testing.T) {
+	sd := &myproto.SchemaDefinition{
+		TableDefinitions: []*myproto.TableDefinition{
+			{Name: "t1", Type: myproto.TableBaseTable},
+			{Name: "v1", Type: myproto.TableView},
+			{Name: "t2", Type: myproto.TableBaseTable},
+		},
+	}
+	want := "DROP VIEW `v1`;\nDROP TABLE `t1`;\nDROP TABLE `t2`;\n"
+	if got := dropAllSQL(sd); got != want {
+		t.Errorf("dropAllSQL() = %q, want %q", got, want)
+	}
+}
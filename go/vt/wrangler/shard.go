@@ -330,19 +330,20 @@ func (wr *Wrangler) sourceShardDelete(ctx context.Context, keyspace, shard strin
 	return wr.ts.UpdateShard(ctx, si)
 }
 
-// SourceShardAdd will add a new SourceShard inside a shard
-func (wr *Wrangler) SourceShardAdd(ctx context.Context, keyspace, shard string, uid uint32, skeyspace, sshard string, keyRange *pb.KeyRange, tables []string) error {
+// SourceShardAdd will add a new SourceShard inside a shard.
+// transforms is optional, and applied to the replicated statements.
+func (wr *Wrangler) SourceShardAdd(ctx context.Context, keyspace, shard string, uid uint32, skeyspace, sshard string, keyRange *pb.KeyRange, tables []string, transforms []*pb.TableTransform) error {
 	actionNode := actionnode.UpdateShard()
 	lockPath, err := wr.lockShard(ctx, keyspace, shard, actionNode)
 	if err != nil {
 		return err
 	}
 
-	err = wr.sourceShardAdd(ctx, keyspace, shard, uid, skeyspace, sshard, keyRange, tables)
+	err = wr.sourceShardAdd(ctx, keyspace, shard, uid, skeyspace, sshard, keyRange, tables, transforms)
 	return wr.unlockShard(ctx, keyspace, shard, actionNode, lockPath, err)
 }
 
-func (wr *Wrangler) sourceShardAdd(ctx context.Context, keyspace, shard string, uid uint32, skeyspace, sshard string, keyRange *pb.KeyRange, tables []string) error {
+func (wr *Wrangler) sourceShardAdd(ctx context.Context, keyspace, shard string, uid uint32, skeyspace, sshard string, keyRange *pb.KeyRange, tables []string, transforms []*pb.TableTransform) error {
 	si, err := wr.ts.GetShard(ctx, keyspace, shard)
 	if err != nil {
 		return err
@@ -356,11 +357,12 @@ func (wr *Wrangler) sourceShardAdd(ctx context.Context, keyspace, shard string,
 	}
 
 	si.SourceShards = append(si.SourceShards, &pb.Shard_SourceShard{
-		Uid:      uid,
-		Keyspace: skeyspace,
-		Shard:    sshard,
-		KeyRange: keyRange,
-		Tables:   tables,
+		Uid:        uid,
+		Keyspace:   skeyspace,
+		Shard:      sshard,
+		KeyRange:   keyRange,
+		Tables:     tables,
+		Transforms: transforms,
 	})
 	return wr.ts.UpdateShard(ctx, si)
 }
@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	mproto "github.com/youtube/vitess/go/mysql/proto"
+	"github.com/youtube/vitess/go/sqltypes"
 	"github.com/youtube/vitess/go/vt/logutil"
 	"github.com/youtube/vitess/go/vt/mysqlctl"
 	"github.com/youtube/vitess/go/vt/mysqlctl/backupstorage"
@@ -158,3 +159,125 @@ func TestBackupRestore(t *testing.T) {
 	}
 
 }
+
+func TestIncrementalBackupRestoreToPointInTime(t *testing.T) {
+	// Initialize our environment
+	ctx := context.Background()
+	db := fakesqldb.Register()
+	ts := zktopo.NewTestServer(t, []string{"cell1"})
+	wr := wrangler.New(logutil.NewConsoleLogger(), ts, tmclient.NewTabletManagerClient())
+	vp := NewVtctlPipe(t, ts)
+	defer vp.Close()
+
+	// Initialize our temp dirs
+	root, err := ioutil.TempDir("", "backuptest")
+	if err != nil {
+		t.Fatalf("os.TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	// Initialize BackupStorage
+	*filebackupstorage.FileBackupStorageRoot = path.Join(root, "fbs")
+	*backupstorage.BackupStorageImplementation = "file"
+
+	// Initialize the fake mysql root directories, with two
+	// binlog files
+	innodbDataDir := path.Join(root, "innodb_data")
+	innodbLogDir := path.Join(root, "innodb_log")
+	dataDir := path.Join(root, "data")
+	binlogDir := path.Join(root, "binlogs")
+	for _, s := range []string{innodbDataDir, innodbLogDir, path.Join(dataDir, "vt_db"), binlogDir} {
+		if err := os.MkdirAll(s, os.ModePerm); err != nil {
+			t.Fatalf("failed to create directory %v: %v", s, err)
+		}
+	}
+	for _, name := range []string{path.Join(dataDir, "vt_db", "db.opt"), path.Join(binlogDir, "vt-bin.000001"), path.Join(binlogDir, "vt-bin.000002")} {
+		if err := ioutil.WriteFile(name, []byte(path.Base(name)+" contents"), os.ModePerm); err != nil {
+			t.Fatalf("failed to write file %v: %v", name, err)
+		}
+	}
+
+	// create a master tablet, not started, just for shard health
+	NewFakeTablet(t, wr, "cell1", 0, pb.TabletType_MASTER, db)
+
+	// create a tablet at 0-1-5, set it up so we can do backups
+	tablet := NewFakeTablet(t, wr, "cell1", 1, pb.TabletType_REPLICA, db)
+	fullPos := myproto.ReplicationPosition{
+		GTIDSet: myproto.MariadbGTID{Domain: 0, Server: 1, Sequence: 5},
+	}
+	tablet.FakeMysqlDaemon.ReadOnly = true
+	tablet.FakeMysqlDaemon.Replicating = true
+	tablet.FakeMysqlDaemon.CurrentMasterPosition = fullPos
+	tablet.FakeMysqlDaemon.Mycnf = &mysqlctl.Mycnf{
+		DataDir:               dataDir,
+		InnodbDataHomeDir:     innodbDataDir,
+		InnodbLogGroupHomeDir: innodbLogDir,
+		BinLogPath:            path.Join(binlogDir, "vt-bin"),
+		TmpDir:                root,
+	}
+	tablet.FakeMysqlDaemon.ExpectedExecuteSuperQueryList = []string{
+		// full backup
+		"STOP SLAVE",
+		"START SLAVE",
+		// incremental backup
+		"FLUSH BINARY LOGS",
+		// restore, before the replay, and at the end
+		"RESET MASTER",
+		"SET GLOBAL gtid_slave_pos",
+		"RESET MASTER",
+		"SET GLOBAL gtid_slave_pos",
+	}
+	tablet.FakeMysqlDaemon.FetchSuperQueryMap = map[string]*mproto.QueryResult{
+		"SHOW BINARY LOGS": &mproto.QueryResult{
+			Rows: [][]sqltypes.Value{
+				{sqltypes.MakeString([]byte("vt-bin.000001"))},
+				{sqltypes.MakeString([]byte("vt-bin.000002"))},
+			},
+		},
+	}
+	tablet.FakeMysqlDaemon.BinlogFiles = map[string]*mysqlctl.BinlogFileInfo{
+		"vt-bin.000001": {
+			Transactions: []mysqlctl.BinlogFileTransaction{{
+				Offset:    100,
+				GTID:      myproto.MariadbGTID{Domain: 0, Server: 1, Sequence: 5},
+				Timestamp: 1005,
+			}, {
+				Offset:    200,
+				GTID:      myproto.MariadbGTID{Domain: 0, Server: 1, Sequence: 6},
+				Timestamp: 1006,
+			}},
+		},
+	}
+	tablet.FakeMysqlDaemon.ResetReplicationResult = []string{"RESET MASTER"}
+	tablet.FakeMysqlDaemon.SetSlavePositionCommandsPos = fullPos
+	tablet.FakeMysqlDaemon.SetSlavePositionCommandsResult = []string{"SET GLOBAL gtid_slave_pos"}
+	tablet.StartActionLoop(t, wr)
+	defer tablet.StopActionLoop(t)
+
+	// run a full and an incremental backup
+	alias := topoproto.TabletAliasString(tablet.Tablet.Alias)
+	if err := vp.Run([]string{"Backup", alias}); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if err := vp.Run([]string{"Backup", "-incremental", alias}); err != nil {
+		t.Fatalf("Backup -incremental failed: %v", err)
+	}
+
+	// restore to the position of the full backup
+	if err := vp.Run([]string{"RestoreToPointInTime", "-position", myproto.EncodeReplicationPosition(fullPos), alias}); err != nil {
+		t.Fatalf("RestoreToPointInTime failed: %v", err)
+	}
+	if err := tablet.FakeMysqlDaemon.CheckSuperQueryList(); err != nil {
+		t.Errorf("tablet.FakeMysqlDaemon.CheckSuperQueryList failed: %v", err)
+	}
+	if len(tablet.FakeMysqlDaemon.AppliedBinlogFiles) != 0 {
+		t.Errorf("unexpected AppliedBinlogFiles: %v", tablet.FakeMysqlDaemon.AppliedBinlogFiles)
+	}
+	ti, err := ts.GetTablet(ctx, tablet.Tablet.Alias)
+	if err != nil {
+		t.Fatalf("GetTablet failed: %v", err)
+	}
+	if ti.Type != pb.TabletType_SPARE {
+		t.Errorf("tablet has type %v after RestoreToPointInTime, want SPARE", ti.Type)
+	}
+}
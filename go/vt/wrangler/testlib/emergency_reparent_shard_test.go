@@ -188,7 +188,7 @@ func TestEmergencyReparentShardMasterElectNotBest(t *testing.T) {
 	defer moreAdvancedSlave.StopActionLoop(t)
 
 	// run EmergencyReparentShard
-	if err := wr.EmergencyReparentShard(ctx, newMaster.Tablet.Keyspace, newMaster.Tablet.Shard, newMaster.Tablet.Alias, 10*time.Second); err == nil || !strings.Contains(err.Error(), "is more advanced than master elect tablet") {
+	if err := wr.EmergencyReparentShard(ctx, newMaster.Tablet.Keyspace, newMaster.Tablet.Shard, newMaster.Tablet.Alias, nil, 10*time.Second); err == nil || !strings.Contains(err.Error(), "is more advanced than master elect tablet") {
 		t.Fatalf("EmergencyReparentShard returned the wrong error: %v", err)
 	}
 
@@ -0,0 +1,106 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testlib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/youtube/vitess/go/vt/logutil"
+	myproto "github.com/youtube/vitess/go/vt/mysqlctl/proto"
+	"github.com/youtube/vitess/go/vt/tabletmanager/tmclient"
+	"github.com/youtube/vitess/go/vt/topo/topoproto"
+	"github.com/youtube/vitess/go/vt/vttest/fakesqldb"
+	"github.com/youtube/vitess/go/vt/wrangler"
+	"github.com/youtube/vitess/go/vt/zktopo"
+	"golang.org/x/net/context"
+
+	pb "github.com/youtube/vitess/go/vt/proto/topodata"
+)
+
+const (
+	masterSID = "00010203-0405-0607-0809-0a0b0c0d0e0f"
+	slaveSID  = "00010203-0405-0607-0809-0a0b0c0d0eff"
+)
+
+func TestValidateGTIDs(t *testing.T) {
+	ctx := context.Background()
+	db := fakesqldb.Register()
+	ts := zktopo.NewTestServer(t, []string{"cell1", "cell2"})
+	wr := wrangler.New(logutil.NewConsoleLogger(), ts, tmclient.NewTabletManagerClient())
+	vp := NewVtctlPipe(t, ts)
+	defer vp.Close()
+
+	master := NewFakeTablet(t, wr, "cell1", 0, pb.TabletType_MASTER, db)
+	goodSlave := NewFakeTablet(t, wr, "cell1", 1, pb.TabletType_REPLICA, db)
+	badSlave := NewFakeTablet(t, wr, "cell2", 2, pb.TabletType_REPLICA, db)
+
+	master.FakeMysqlDaemon.CurrentMasterPosition = myproto.MustParseReplicationPosition("MySQL56", masterSID+":1-100")
+	master.StartActionLoop(t, wr)
+	defer master.StopActionLoop(t)
+
+	// lagging slaves are fine
+	goodSlave.FakeMysqlDaemon.CurrentMasterPosition = myproto.MustParseReplicationPosition("MySQL56", masterSID+":1-90")
+	goodSlave.StartActionLoop(t, wr)
+	defer goodSlave.StopActionLoop(t)
+
+	// but not local writes
+	badSlave.FakeMysqlDaemon.CurrentMasterPosition = myproto.MustParseReplicationPosition("MySQL56", masterSID+":1-95,"+slaveSID+":1-3")
+	badSlave.StartActionLoop(t, wr)
+	defer badSlave.StopActionLoop(t)
+
+	errantMap, err := wr.ShardErrantGTIDs(ctx, master.Tablet.Keyspace, master.Tablet.Shard)
+	if err != nil {
+		t.Fatalf("ShardErrantGTIDs failed: %v", err)
+	}
+	want := myproto.MustParseReplicationPosition("MySQL56", slaveSID+":1-3")
+	if len(errantMap) != 1 || !errantMap[*badSlave.Tablet.Alias].Equal(want) {
+		t.Errorf("ShardErrantGTIDs returned %v, want only %v for %v", errantMap, want, topoproto.TabletAliasString(badSlave.Tablet.Alias))
+	}
+
+	err = vp.Run([]string{"ValidateGTIDs", master.Tablet.Keyspace + "/" + master.Tablet.Shard})
+	if err == nil || !strings.Contains(err.Error(), topoproto.TabletAliasString(badSlave.Tablet.Alias)) || strings.Contains(err.Error(), topoproto.TabletAliasString(goodSlave.Tablet.Alias)) {
+		t.Errorf("ValidateGTIDs returned %v, want an error for %v only", err, topoproto.TabletAliasString(badSlave.Tablet.Alias))
+	}
+
+	// once the master has them too, it's fine
+	master.FakeMysqlDaemon.CurrentMasterPosition = myproto.MustParseReplicationPosition("MySQL56", masterSID+":1-100,"+slaveSID+":1-3")
+	if err := vp.Run([]string{"ValidateGTIDs", master.Tablet.Keyspace + "/" + master.Tablet.Shard}); err != nil {
+		t.Errorf("ValidateGTIDs failed: %v", err)
+	}
+}
+
+func TestPlannedReparentShardErrantGTIDs(t *testing.T) {
+	db := fakesqldb.Register()
+	ts := zktopo.NewTestServer(t, []string{"cell1", "cell2"})
+	wr := wrangler.New(logutil.NewConsoleLogger(), ts, tmclient.NewTabletManagerClient())
+	vp := NewVtctlPipe(t, ts)
+	defer vp.Close()
+
+	oldMaster := NewFakeTablet(t, wr, "cell1", 0, pb.TabletType_MASTER, db)
+	newMaster := NewFakeTablet(t, wr, "cell1", 1, pb.TabletType_REPLICA, db)
+
+	oldMaster.FakeMysqlDaemon.ReadOnly = false
+	oldMaster.FakeMysqlDaemon.CurrentMasterPosition = myproto.MustParseReplicationPosition("MySQL56", masterSID+":1-100")
+	oldMaster.StartActionLoop(t, wr)
+	defer oldMaster.StopActionLoop(t)
+
+	newMaster.FakeMysqlDaemon.ReadOnly = true
+	newMaster.FakeMysqlDaemon.CurrentMasterPosition = myproto.MustParseReplicationPosition("MySQL56", masterSID+":1-100,"+slaveSID+":1")
+	newMaster.StartActionLoop(t, wr)
+	defer newMaster.StopActionLoop(t)
+
+	// the reparent is refused before anything is changed
+	err := vp.Run([]string{"PlannedReparentShard", "-wait_slave_timeout", "10s", newMaster.Tablet.Keyspace + "/" + newMaster.Tablet.Shard, topoproto.TabletAliasString(newMaster.Tablet.Alias)})
+	if err == nil || !strings.Contains(err.Error(), "errant transactions") {
+		t.Fatalf("PlannedReparentShard returned %v, want an errant transactions error", err)
+	}
+	if oldMaster.FakeMysqlDaemon.ReadOnly {
+		t.Errorf("oldMaster.FakeMysqlDaemon.ReadOnly set")
+	}
+	if !newMaster.FakeMysqlDaemon.ReadOnly {
+		t.Errorf("newMaster.FakeMysqlDaemon.ReadOnly not set")
+	}
+}
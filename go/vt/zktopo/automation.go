@@ -0,0 +1,51 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zktopo
+
+import (
+	"path"
+
+	"github.com/youtube/vitess/go/zk"
+	"golang.org/x/net/context"
+	"launchpad.net/gozk/zookeeper"
+)
+
+/*
+This file contains the automation checkpoints code for zktopo.Server
+*/
+
+const (
+	globalAutomationPath = "/zk/global/vt/automation"
+)
+
+// SaveClusterOperation is part of the topo.ClusterOperationStore interface.
+func (zkts *Server) SaveClusterOperation(ctx context.Context, id, contents string) error {
+	_, err := zk.CreateOrUpdate(zkts.zconn, path.Join(globalAutomationPath, id), contents, 0, zookeeper.WorldACL(zookeeper.PERM_ALL), true)
+	return err
+}
+
+// GetClusterOperations is part of the topo.ClusterOperationStore interface.
+func (zkts *Server) GetClusterOperations(ctx context.Context) (map[string]string, error) {
+	children, _, err := zkts.zconn.Children(globalAutomationPath)
+	if err != nil {
+		if zookeeper.IsError(err, zookeeper.ZNONODE) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	result := make(map[string]string, len(children))
+	for _, child := range children {
+		data, _, err := zkts.zconn.Get(path.Join(globalAutomationPath, child))
+		if err != nil {
+			if zookeeper.IsError(err, zookeeper.ZNONODE) {
+				continue
+			}
+			return nil, err
+		}
+		result[child] = data
+	}
+	return result, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2015, Google Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zktopo
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/youtube/vitess/go/vt/concurrency"
+	"github.com/youtube/vitess/go/vt/topo"
+	"github.com/youtube/vitess/go/zk"
+	"golang.org/x/net/context"
+	"launchpad.net/gozk/zookeeper"
+)
+
+/*
+This file contains the multi-record update code for zktopo.Server
+*/
+
+// UpdateMulti is part of the topo.MultiUpdater interface. The
+// keyspace and shard records are saved in a single zookeeper
+// transaction. The serving keyspace records live in the cells, so
+// they are saved once the transaction is committed.
+func (zkts *Server) UpdateMulti(ctx context.Context, keyspaces []*topo.KeyspaceUpdate, shards []*topo.ShardUpdate, srvKeyspaces []*topo.SrvKeyspaceUpdate) ([]int64, []int64, error) {
+	mconn, ok := zkts.zconn.(zk.MultiConn)
+	if !ok {
+		return nil, nil, fmt.Errorf("zk connection %T doesn't support multi operations", zkts.zconn)
+	}
+
+	ops := make([]zookeeper.MultiOp, 0, len(keyspaces)+len(shards))
+	for _, ku := range keyspaces {
+		data, err := json.MarshalIndent(ku.Value, "", "  ")
+		if err != nil {
+			return nil, nil, err
+		}
+		keyspacePath := path.Join(globalKeyspacesPath, ku.Keyspace)
+		ops = append(ops, zookeeper.SetOp(keyspacePath, string(data), int(ku.ExistingVersion)))
+	}
+	for _, su := range shards {
+		data, err := json.MarshalIndent(su.Value, "", "  ")
+		if err != nil {
+			return nil, nil, err
+		}
+		shardPath := path.Join(globalKeyspacesPath, su.Keyspace, "shards", su.Shard)
+		ops = append(ops, zookeeper.SetOp(shardPath, string(data), int(su.ExistingVersion)))
+	}
+
+	results, err := mconn.Multi(ops...)
+	if err != nil {
+		switch {
+		case zookeeper.IsError(err, zookeeper.ZNONODE):
+			err = topo.ErrNoNode
+		case zookeeper.IsError(err, zookeeper.ZBADVERSION):
+			err = topo.ErrBadVersion
+		}
+		return nil, nil, err
+	}
+
+	versions := make([]int64, len(results))
+	for i, result := range results {
+		versions[i] = int64(result.Stat.Version())
+	}
+
+	rec := concurrency.AllErrorRecorder{}
+	for _, sku := range srvKeyspaces {
+		if err := zkts.UpdateSrvKeyspace(ctx, sku.Cell, sku.Keyspace, sku.Value); err != nil {
+			rec.RecordError(fmt.Errorf("cannot save serving keyspace %v in cell %v, it needs to be rebuilt: %v", sku.Keyspace, sku.Cell, err))
+		}
+	}
+	return versions[:len(keyspaces)], versions[len(keyspaces):], rec.Error()
+}
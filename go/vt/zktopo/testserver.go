@@ -46,6 +46,26 @@ func (s *TestServer) GetKnownCells(ctx context.Context) ([]string, error) {
 	return s.localCells, nil
 }
 
+// UpdateMulti is part of the topo.MultiUpdater interface, we forward
+// it to the underlying topo.Server so the tests can use it.
+func (s *TestServer) UpdateMulti(ctx context.Context, keyspaces []*topo.KeyspaceUpdate, shards []*topo.ShardUpdate, srvKeyspaces []*topo.SrvKeyspaceUpdate) ([]int64, []int64, error) {
+	return s.Impl.(topo.MultiUpdater).UpdateMulti(ctx, keyspaces, shards, srvKeyspaces)
+}
+
+// SaveClusterOperation is part of the topo.ClusterOperationStore
+// interface, we forward it to the underlying topo.Server so the tests
+// can use it.
+func (s *TestServer) SaveClusterOperation(ctx context.Context, id, contents string) error {
+	return s.Impl.(topo.ClusterOperationStore).SaveClusterOperation(ctx, id, contents)
+}
+
+// GetClusterOperations is part of the topo.ClusterOperationStore
+// interface, we forward it to the underlying topo.Server so the tests
+// can use it.
+func (s *TestServer) GetClusterOperations(ctx context.Context) (map[string]string, error) {
+	return s.Impl.(topo.ClusterOperationStore).GetClusterOperations(ctx)
+}
+
 // LockSrvShardForAction should override the function defined by the underlying
 // topo.Server.
 func (s *TestServer) LockSrvShardForAction(ctx context.Context, cell, keyspace, shard, contents string) (string, error) {
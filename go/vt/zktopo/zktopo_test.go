@@ -28,6 +28,13 @@ func TestShard(t *testing.T) {
 	test.CheckShard(ctx, t, ts)
 }
 
+func TestMultiUpdate(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestServer(t, []string{"test"})
+	defer ts.Close()
+	test.CheckMultiUpdate(ctx, t, ts)
+}
+
 func TestTablet(t *testing.T) {
 	ctx := context.Background()
 	ts := newTestServer(t, []string{"test"})
@@ -91,6 +98,13 @@ func TestVSchema(t *testing.T) {
 	test.CheckVSchema(ctx, t, ts)
 }
 
+func TestClusterOperations(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestServer(t, []string{"test"})
+	defer ts.Close()
+	test.CheckClusterOperations(ctx, t, ts)
+}
+
 // TestPurgeActions is a ZK specific unit test
 func TestPurgeActions(t *testing.T) {
 	ctx := context.Background()
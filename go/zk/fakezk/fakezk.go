@@ -187,7 +187,11 @@ func (conn *zconn) ExistsW(zkPath string) (stat zk.Stat, watch <-chan zookeeper.
 func (conn *zconn) Create(zkPath, value string, flags int, aclv []zookeeper.ACL) (zkPathCreated string, err error) {
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
+	return conn.create(zkPath, value, flags, aclv)
+}
 
+// create is the implementation of Create, conn.mu needs to be held.
+func (conn *zconn) create(zkPath, value string, flags int, aclv []zookeeper.ACL) (zkPathCreated string, err error) {
 	parent, _, rest, err := conn.getNode(zkPath, "create")
 	if err != nil {
 		return "", err
@@ -251,7 +255,11 @@ func (conn *zconn) Create(zkPath, value string, flags int, aclv []zookeeper.ACL)
 func (conn *zconn) Set(zkPath, value string, version int) (stat zk.Stat, err error) {
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
+	return conn.set(zkPath, value, version)
+}
 
+// set is the implementation of Set, conn.mu needs to be held.
+func (conn *zconn) set(zkPath, value string, version int) (stat zk.Stat, err error) {
 	node, _, rest, err := conn.getNode(zkPath, "set")
 	if err != nil {
 		return nil, err
@@ -280,7 +288,11 @@ func (conn *zconn) Set(zkPath, value string, version int) (stat zk.Stat, err err
 func (conn *zconn) Delete(zkPath string, version int) (err error) {
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
+	return conn.delete(zkPath, version)
+}
 
+// delete is the implementation of Delete, conn.mu needs to be held.
+func (conn *zconn) delete(zkPath string, version int) (err error) {
 	node, parent, rest, err := conn.getNode(zkPath, "delete")
 	if err != nil {
 		return err
@@ -320,6 +332,79 @@ func (conn *zconn) Delete(zkPath string, version int) (err error) {
 	return nil
 }
 
+// Multi is part of the zk.MultiConn interface. All the operations
+// are checked against the current state of the tree before any of
+// them is applied, so an operation cannot depend on the result of a
+// previous one in the same call.
+func (conn *zconn) Multi(ops ...zookeeper.MultiOp) ([]zk.MultiResult, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	results := make([]zk.MultiResult, len(ops))
+	var firstErr error
+	for i, op := range ops {
+		if firstErr != nil {
+			results[i].Err = zkError(zookeeper.ZRUNTIMEINCONSISTENCY, "multi", op.Path)
+			continue
+		}
+		if err := conn.checkMultiOp(op); err != nil {
+			results[i].Err = err
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return results, firstErr
+	}
+
+	for i, op := range ops {
+		var err error
+		switch op.Type {
+		case zookeeper.OP_CREATE:
+			results[i].Path, err = conn.create(op.Path, op.Value, op.Flags, op.ACL)
+		case zookeeper.OP_SET:
+			results[i].Stat, err = conn.set(op.Path, op.Value, op.Version)
+		case zookeeper.OP_DELETE:
+			err = conn.delete(op.Path, op.Version)
+		}
+		if err != nil {
+			// checkMultiOp should have caught this.
+			panic(fmt.Errorf("fakezk: multi operation on %v failed after being checked: %v", op.Path, err))
+		}
+	}
+	return results, nil
+}
+
+// checkMultiOp returns the error a Multi operation would return,
+// without applying it. conn.mu needs to be held.
+func (conn *zconn) checkMultiOp(op zookeeper.MultiOp) error {
+	node, _, rest, err := conn.getNode(op.Path, "multi")
+	if err != nil {
+		return err
+	}
+	switch op.Type {
+	case zookeeper.OP_CREATE:
+		if len(rest) == 0 {
+			return zkError(zookeeper.ZNODEEXISTS, "multi", op.Path)
+		}
+		if len(rest) > 1 {
+			return zkError(zookeeper.ZNONODE, "multi", op.Path)
+		}
+		return nil
+	case zookeeper.OP_SET, zookeeper.OP_DELETE, zookeeper.OP_CHECK:
+		if len(rest) != 0 {
+			return zkError(zookeeper.ZNONODE, "multi", op.Path)
+		}
+		if op.Type == zookeeper.OP_DELETE && len(node.children) > 0 {
+			return zkError(zookeeper.ZNOTEMPTY, "multi", op.Path)
+		}
+		if op.Version != -1 && node.version != op.Version {
+			return zkError(zookeeper.ZBADVERSION, "multi", op.Path)
+		}
+		return nil
+	}
+	return zkError(zookeeper.ZBADARGUMENTS, "multi", op.Path)
+}
+
 func (conn *zconn) Close() error {
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
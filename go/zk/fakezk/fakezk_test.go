@@ -240,6 +240,68 @@ func TestSequence(t *testing.T) {
 
 }
 
+func TestMulti(t *testing.T) {
+	conn := NewConn()
+	defer conn.Close()
+
+	// Make sure Conn implements the interface.
+	mconn, ok := conn.(zk.MultiConn)
+	if !ok {
+		t.Fatalf("conn doesn't implement zk.MultiConn")
+	}
+	for _, p := range []string{"/zk", "/zk/a", "/zk/b"} {
+		if _, err := conn.Create(p, "init", 0, zookeeper.WorldACL(zookeeper.PERM_ALL)); err != nil {
+			t.Fatalf("conn.Create(%v): %v", p, err)
+		}
+	}
+
+	// All operations succeed.
+	results, err := mconn.Multi(
+		zookeeper.SetOp("/zk/a", "a1", 0),
+		zookeeper.SetOp("/zk/b", "b1", -1),
+		zookeeper.CreateOp("/zk/c", "c1", 0, zookeeper.WorldACL(zookeeper.PERM_ALL)),
+	)
+	if err != nil {
+		t.Fatalf("mconn.Multi: %v", err)
+	}
+	if len(results) != 3 || results[0].Stat.Version() != 1 || results[1].Stat.Version() != 1 || results[2].Path != "/zk/c" {
+		t.Errorf("mconn.Multi returned bad results: %v", results)
+	}
+
+	// One bad version makes the whole transaction fail.
+	results, err = mconn.Multi(
+		zookeeper.SetOp("/zk/a", "a2", 1),
+		zookeeper.SetOp("/zk/b", "b2", 0),
+		zookeeper.DeleteOp("/zk/c", -1),
+	)
+	if !zookeeper.IsError(err, zookeeper.ZBADVERSION) {
+		t.Fatalf("mconn.Multi with a bad version: got %v, expected ZBADVERSION", err)
+	}
+	if results[0].Err != nil || !zookeeper.IsError(results[1].Err, zookeeper.ZBADVERSION) || !zookeeper.IsError(results[2].Err, zookeeper.ZRUNTIMEINCONSISTENCY) {
+		t.Errorf("mconn.Multi with a bad version returned bad results: %v", results)
+	}
+	for p, want := range map[string]string{"/zk/a": "a1", "/zk/b": "b1", "/zk/c": "c1"} {
+		data, _, err := conn.Get(p)
+		if err != nil {
+			t.Fatalf("conn.Get(%v): %v", p, err)
+		}
+		if data != want {
+			t.Errorf("conn.Get(%v) after a failed transaction: got %q, wanted %q", p, data, want)
+		}
+	}
+
+	// A check operation guards the others.
+	if _, err := mconn.Multi(
+		zookeeper.CheckOp("/zk/a", 1),
+		zookeeper.DeleteOp("/zk/c", 0),
+	); err != nil {
+		t.Fatalf("mconn.Multi: %v", err)
+	}
+	if stat, err := conn.Exists("/zk/c"); err != nil || stat != nil {
+		t.Errorf("conn.Exists(/zk/c) after delete: got %v %v", stat, err)
+	}
+}
+
 func TestFromFile(t *testing.T) {
 	conn := NewConnFromFile(testfiles.Locate("fakezk_test_config.json"))
 
@@ -5,6 +5,7 @@
 package zk
 
 import (
+	"fmt"
 	"math/rand"
 	"strings"
 	"time"
@@ -58,6 +59,22 @@ type Conn interface {
 
 type ChangeFunc func(oldValue string, oldStat Stat) (newValue string, err error)
 
+// MultiResult is the outcome of one operation of a MultiConn.Multi
+// call. It is the same as zookeeper.MultiResult, but uses our Stat
+// interface.
+type MultiResult struct {
+	Err  error
+	Path string
+	Stat Stat
+}
+
+// MultiConn is implemented by the Conn implementations that can run
+// a list of operations as a single atomic transaction. All the
+// operations must be on paths served by the same zookeeper cell.
+type MultiConn interface {
+	Multi(ops ...zookeeper.MultiOp) ([]MultiResult, error)
+}
+
 // Smooth API to talk to any zk path in the global system.  Emulates
 // "/zk/local" paths by guessing and substituting the correct cell for
 // your current environment.
@@ -227,6 +244,47 @@ func (conn *MetaConn) Delete(path string, version int) (err error) {
 	return
 }
 
+// Multi is part of the MultiConn interface. All the operations
+// need to be in the same cell.
+func (conn *MetaConn) Multi(ops ...zookeeper.MultiOp) (results []MultiResult, err error) {
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	cell, err := ZkCellFromZkPath(ops[0].Path)
+	if err != nil {
+		return nil, err
+	}
+	resolvedOps := make([]zookeeper.MultiOp, len(ops))
+	for i, op := range ops {
+		opCell, err := ZkCellFromZkPath(op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if opCell != cell {
+			return nil, fmt.Errorf("zk: multi operations span cells %v and %v", cell, opCell)
+		}
+		resolvedOps[i] = op
+		resolvedOps[i].Path = resolveZkPath(op.Path)
+	}
+
+	var zconn Conn
+	for i := 0; i < maxAttempts; i++ {
+		zconn, err = conn.connCache.ConnForPath(ops[0].Path)
+		if err != nil {
+			return
+		}
+		mconn, ok := zconn.(MultiConn)
+		if !ok {
+			return nil, fmt.Errorf("zk: connection for cell %v doesn't support multi operations", cell)
+		}
+		results, err = mconn.Multi(resolvedOps...)
+		if !shouldRetry(err) {
+			return
+		}
+	}
+	return
+}
+
 func (conn *MetaConn) Close() error {
 	return conn.connCache.Close()
 }
@@ -305,6 +305,31 @@ func (conn *ZkConn) Delete(path string, version int) (err error) {
 	return c.Delete(path, version)
 }
 
+// Multi is part of the MultiConn interface.
+func (conn *ZkConn) Multi(ops ...zookeeper.MultiOp) ([]MultiResult, error) {
+	c := conn.getConn()
+	if c == nil {
+		return nil, ErrConnectionClosed
+	}
+
+	sem.Acquire()
+	defer sem.Release()
+	zresults, err := c.Multi(ops...)
+	if zresults == nil {
+		return nil, err
+	}
+	results := make([]MultiResult, len(zresults))
+	for i, zr := range zresults {
+		results[i].Err = zr.Err
+		results[i].Path = zr.Path
+		if zr.Stat != nil {
+			// Handle nil-nil interface conversion.
+			results[i].Stat = zr.Stat
+		}
+	}
+	return results, err
+}
+
 // Close will close the connection asynchronously.  It will never
 // fail, even though closing the connection might fail in the
 // background.  Accessing this ZkConn after Close has been called will
@@ -63,6 +63,17 @@ class MySQL56(MysqlFlavor):
     return "mysql-db-dir_5.6.24.tbz"
 
 
+class MySQL57(MySQL56):
+  """Overrides specific to MySQL 5.7"""
+
+  def my_cnf(self):
+    files = [
+        os.path.join(vttop, "config/mycnf/default-fast.cnf"),
+        os.path.join(vttop, "config/mycnf/master_mysql57.cnf"),
+        ]
+    return ":".join(files)
+
+
 __mysql_flavor = None
 
 
@@ -92,6 +103,8 @@ def set_mysql_flavor(flavor):
     __mysql_flavor = MariaDB()
   elif flavor == "MySQL56":
     __mysql_flavor = MySQL56()
+  elif flavor == "MySQL57":
+    __mysql_flavor = MySQL57()
   else:
     logging.error("Unknown MYSQL_FLAVOR '%s'", flavor)
     exit(1)
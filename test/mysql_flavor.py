@@ -157,6 +157,13 @@ class MySQL56(MysqlFlavor):
         (host, port)]
 
 
+class MySQL57(MySQL56):
+  """Overrides specific to MySQL 5.7, which keeps the MySQL56 positions."""
+
+  def extra_my_cnf(self):
+    return environment.vttop + "/config/mycnf/master_mysql57.cnf"
+
+
 __mysql_flavor = None
 
 
@@ -186,6 +193,8 @@ def set_mysql_flavor(flavor):
     __mysql_flavor = MariaDB()
   elif flavor == "MySQL56":
     __mysql_flavor = MySQL56()
+  elif flavor == "MySQL57":
+    __mysql_flavor = MySQL57()
   else:
     logging.error("Unknown MYSQL_FLAVOR '%s'", flavor)
     exit(1)
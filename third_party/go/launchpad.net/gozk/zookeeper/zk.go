@@ -201,6 +201,14 @@ const (
 	SEQUENCE
 )
 
+// Constants for MultiOp Type.
+const (
+	OP_CREATE = 1
+	OP_DELETE = 2
+	OP_SET    = 5
+	OP_CHECK  = 13
+)
+
 // Constants for ACL Perms.
 const (
 	PERM_READ = 1 << iota
@@ -257,7 +265,11 @@ func init() {
 		STATE_AUTH_FAILED != C.ZOO_AUTH_FAILED_STATE ||
 		STATE_CONNECTING != C.ZOO_CONNECTING_STATE ||
 		STATE_ASSOCIATING != C.ZOO_ASSOCIATING_STATE ||
-		STATE_CONNECTED != C.ZOO_CONNECTED_STATE {
+		STATE_CONNECTED != C.ZOO_CONNECTED_STATE ||
+		OP_CREATE != C.ZOO_CREATE_OP ||
+		OP_DELETE != C.ZOO_DELETE_OP ||
+		OP_SET != C.ZOO_SETDATA_OP ||
+		OP_CHECK != C.ZOO_CHECK_OP {
 
 		panic("OOPS: Constants don't match C counterparts")
 	}
@@ -843,6 +855,132 @@ func (conn *Conn) SetACL(path string, aclv []ACL, version int) error {
 	return zkError(rc, cerr, "setacl", path)
 }
 
+// MultiOp describes one operation of a Multi call. Use CreateOp,
+// SetOp, DeleteOp and CheckOp to build them.
+type MultiOp struct {
+	Type    int    // One of the OP_* constants.
+	Path    string // Path of the node the operation applies to.
+	Value   string // Data for OP_CREATE and OP_SET.
+	Flags   int    // Flags for OP_CREATE.
+	ACL     []ACL  // ACLs for OP_CREATE.
+	Version int    // Expected version for OP_SET, OP_DELETE and OP_CHECK.
+}
+
+// MultiResult holds the outcome of one operation of a Multi call.
+type MultiResult struct {
+	// Err is the error for this specific operation, if any.
+	Err error
+	// Path is the created path, for OP_CREATE.
+	Path string
+	// Stat is the resulting node Stat, for OP_SET.
+	Stat *Stat
+}
+
+// CreateOp returns a MultiOp that creates a node, like Create does.
+func CreateOp(path, value string, flags int, aclv []ACL) MultiOp {
+	return MultiOp{Type: OP_CREATE, Path: path, Value: value, Flags: flags, ACL: aclv}
+}
+
+// SetOp returns a MultiOp that modifies the data of a node, like Set does.
+func SetOp(path, value string, version int) MultiOp {
+	return MultiOp{Type: OP_SET, Path: path, Value: value, Version: version}
+}
+
+// DeleteOp returns a MultiOp that removes a node, like Delete does.
+func DeleteOp(path string, version int) MultiOp {
+	return MultiOp{Type: OP_DELETE, Path: path, Version: version}
+}
+
+// CheckOp returns a MultiOp that only checks the node at path is at
+// the given version, without modifying it.
+func CheckOp(path string, version int) MultiOp {
+	return MultiOp{Type: OP_CHECK, Path: path, Version: version}
+}
+
+// Multi runs all the provided operations as a single atomic
+// transaction: either all of them succeed, or none of them is applied.
+// The returned slice has one MultiResult per operation, so the caller
+// can find out which operation caused the transaction to fail.
+func (conn *Conn) Multi(ops ...MultiOp) (results []MultiResult, err error) {
+	conn.mutex.RLock()
+	defer conn.mutex.RUnlock()
+	if conn.handle == nil {
+		return nil, closingError("multi", "")
+	}
+	count := len(ops)
+	if count == 0 {
+		return nil, nil
+	}
+
+	// Everything referenced by the zoo_op_t structures is kept
+	// by the C library until zoo_multi returns, so it all has to
+	// live in C memory.
+	cops := (*C.zoo_op_t)(C.calloc(C.size_t(count), C.size_t(unsafe.Sizeof(C.zoo_op_t{}))))
+	cresults := (*C.zoo_op_result_t)(C.calloc(C.size_t(count), C.size_t(unsafe.Sizeof(C.zoo_op_result_t{}))))
+	cstats := (*C.struct_Stat)(C.calloc(C.size_t(count), C.size_t(unsafe.Sizeof(C.struct_Stat{}))))
+	if cops == nil || cresults == nil || cstats == nil {
+		panic("Multi data allocation failed")
+	}
+	defer C.free(unsafe.Pointer(cops))
+	defer C.free(unsafe.Pointer(cresults))
+	defer C.free(unsafe.Pointer(cstats))
+	opv := (*[1 << 20]C.zoo_op_t)(unsafe.Pointer(cops))[:count:count]
+	resultv := (*[1 << 20]C.zoo_op_result_t)(unsafe.Pointer(cresults))[:count:count]
+	statv := (*[1 << 20]C.struct_Stat)(unsafe.Pointer(cstats))[:count:count]
+	createdv := make([]*C.char, count)
+
+	for i, op := range ops {
+		cpath := C.CString(op.Path)
+		defer C.free(unsafe.Pointer(cpath))
+
+		switch op.Type {
+		case OP_CREATE:
+			cvalue := C.CString(op.Value)
+			defer C.free(unsafe.Pointer(cvalue))
+
+			caclv := buildACLVector(op.ACL)
+			defer C.deallocate_ACL_vector(caclv)
+			ccaclv := (*C.struct_ACL_vector)(C.malloc(C.size_t(unsafe.Sizeof(C.struct_ACL_vector{}))))
+			defer C.free(unsafe.Pointer(ccaclv))
+			*ccaclv = *caclv
+
+			// Allocate additional space for the sequence, as in Create.
+			cpathLen := C.size_t(len(op.Path) + 32)
+			createdv[i] = (*C.char)(C.malloc(cpathLen))
+			defer C.free(unsafe.Pointer(createdv[i]))
+
+			C.zoo_create_op_init(&opv[i], cpath, cvalue, C.int(len(op.Value)), ccaclv, C.int(op.Flags), createdv[i], C.int(cpathLen))
+		case OP_SET:
+			cvalue := C.CString(op.Value)
+			defer C.free(unsafe.Pointer(cvalue))
+			C.zoo_set_op_init(&opv[i], cpath, cvalue, C.int(len(op.Value)), C.int(op.Version), &statv[i])
+		case OP_DELETE:
+			C.zoo_delete_op_init(&opv[i], cpath, C.int(op.Version))
+		case OP_CHECK:
+			C.zoo_check_op_init(&opv[i], cpath, C.int(op.Version))
+		default:
+			return nil, fmt.Errorf("zookeeper: multi %q: unknown operation type %d", op.Path, op.Type)
+		}
+	}
+
+	rc, cerr := C.zoo_multi(conn.handle, C.int(count), cops, cresults)
+	results = make([]MultiResult, count)
+	for i, op := range ops {
+		result := &results[i]
+		result.Err = zkError(resultv[i].err, nil, "multi", op.Path)
+		if result.Err != nil {
+			continue
+		}
+		switch op.Type {
+		case OP_CREATE:
+			result.Path = C.GoString(createdv[i])
+		case OP_SET:
+			result.Stat = &Stat{c: statv[i]}
+		}
+	}
+	return results, zkError(rc, cerr, "multi", "")
+}
+
 func parseACLVector(caclv *C.struct_ACL_vector) []ACL {
 	structACLSize := unsafe.Sizeof(C.struct_ACL{})
 	aclv := make([]ACL, caclv.count)